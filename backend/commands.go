@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/jobs"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+)
+
+// runCommand は API サーバーを起動せずに、一度だけ実行する保守用のコマンドを実行します。
+// 例: `main encrypt-secrets`
+func runCommand(ctx context.Context, q *query.Queries, client crypto.EncryptionServiceClient, args []string) error {
+	switch args[0] {
+	case "encrypt-secrets":
+		// 暗号化される前に保存された devices と subscriptions のパスワードを暗号化する。
+		// 暗号化した行は enc_version で区別するので、再実行しても安全
+		results, err := jobs.NewSecretEncryptor(q, client).Run(ctx)
+		for _, result := range results {
+			log.Printf("%s: encrypted %d, skipped %d, failed %d", result.Table, result.Encrypted, result.Skipped, result.Failed)
+		}
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			if result.Failed > 0 {
+				return fmt.Errorf("%d %s could not be encrypted; run the command again", result.Failed, result.Table)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
@@ -9,8 +9,10 @@ import (
 )
 
 type Config struct {
-	Server ServerConfig
-	DB     DBConfig
+	Server    ServerConfig
+	DB        DBConfig
+	Crypto    CryptoConfig
+	Scheduler SchedulerConfig
 }
 
 var (
@@ -31,12 +33,29 @@ func LoadConfig() *Config {
 				Port: getEnv("SERVER_PORT", "8080"),
 			},
 			DB: DBConfig{
-				Host:     getEnv("DB_HOST", "localhost"),
-				Port:     getEnv("DB_PORT", "5432"),
-				User:     getEnv("DB_USER", "user"),
-				Password: getEnv("DB_PASSWORD", "password"),
-				Name:     getEnv("DB_NAME", "digi_baton"),
-				UseSSL:   getEnv("SSL", "false"),
+				Host:           getEnv("DB_HOST", "localhost"),
+				Port:           getEnv("DB_PORT", "5432"),
+				User:           getEnv("DB_USER", "digi_baton_app"),
+				Password:       getEnv("DB_PASSWORD", "password"),
+				SystemUser:     getEnv("DB_SYSTEM_USER", "user"),
+				SystemPassword: getEnv("DB_SYSTEM_PASSWORD", "password"),
+				Name:           getEnv("DB_NAME", "digi_baton"),
+				UseSSL:         getEnv("SSL", "false"),
+			},
+			Crypto: CryptoConfig{
+				Addr:         getEnv("CRYPTO_ADDR", "localhost:50051"),
+				CAFile:       getEnv("CRYPTO_CA_FILE", ""),
+				CertFile:     getEnv("CRYPTO_CERT_FILE", ""),
+				KeyFile:      getEnv("CRYPTO_KEY_FILE", ""),
+				ServerName:   getEnv("CRYPTO_SERVER_NAME", ""),
+				Caller:       getEnv("CRYPTO_CALLER", "backend"),
+				CallerSecret: getEnv("CRYPTO_CALLER_SECRET", ""),
+				GrantKey:     getEnv("CRYPTO_GRANT_KEY", ""),
+				Insecure:     getEnv("CRYPTO_INSECURE", "false"),
+				HealthWait:   getEnv("CRYPTO_HEALTH_TIMEOUT", "30s"),
+			},
+			Scheduler: SchedulerConfig{
+				Interval: getEnv("SCHEDULER_INTERVAL", "1m"),
 			},
 		}
 	})
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// CryptoConfig は crypto サービスへの接続設定
+type CryptoConfig struct {
+	Addr string
+	// crypto サービスのサーバー証明書を検証する CA
+	CAFile string
+	// crypto サービスに提示するクライアント証明書と鍵
+	CertFile string
+	KeyFile  string
+	// サーバー証明書の名前が Addr のホスト名と異なるときに指定する
+	ServerName string
+	// クライアント証明書の CN と一致する呼び出し元の名前
+	Caller string
+	// 呼び出しごとの署名に使う秘密 (base64)
+	CallerSecret string
+	// 復号の許可 (grant) に署名する Ed25519 鍵のシード (base64)
+	GrantKey string
+	// "true" なら TLS を使わない。ローカル開発専用
+	Insecure string
+	// 起動時に crypto サービスが使えるようになるまで待つ時間 (例: "30s")
+	HealthWait string
+}
+
+// defaultHealthTimeout は HealthWait が読めないときの待ち時間
+const defaultHealthTimeout = 30 * time.Second
+
+// HealthTimeout は起動時に crypto サービスを待つ時間を返す
+func (c *CryptoConfig) HealthTimeout() time.Duration {
+	d, err := time.ParseDuration(c.HealthWait)
+	if err != nil || d <= 0 {
+		return defaultHealthTimeout
+	}
+	return d
+}
+
+// TransportCredentials は相互 TLS のクレデンシャルを返す
+func (c *CryptoConfig) TransportCredentials() (credentials.TransportCredentials, error) {
+	if c.Insecure == "true" {
+		return insecure.NewCredentials(), nil
+	}
+	if c.CAFile == "" || c.CertFile == "" || c.KeyFile == "" {
+		return nil, fmt.Errorf("CRYPTO_CA_FILE, CRYPTO_CERT_FILE and CRYPTO_KEY_FILE must be set unless CRYPTO_INSECURE is true")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   c.ServerName,
+		MinVersion:   tls.VersionTLS13,
+	}), nil
+}
+
+// Secret は呼び出しごとの署名に使う秘密を返す
+func (c *CryptoConfig) Secret() ([]byte, error) {
+	if c.CallerSecret == "" {
+		if c.Insecure == "true" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("CRYPTO_CALLER_SECRET must be set unless CRYPTO_INSECURE is true")
+	}
+	secret, err := base64.StdEncoding.DecodeString(c.CallerSecret)
+	if err != nil {
+		return nil, fmt.Errorf("CRYPTO_CALLER_SECRET is not valid base64: %w", err)
+	}
+	return secret, nil
+}
+
+// GrantSigningKey は復号の許可に署名する鍵を返す
+func (c *CryptoConfig) GrantSigningKey() (ed25519.PrivateKey, error) {
+	if c.GrantKey == "" {
+		if c.Insecure == "true" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("CRYPTO_GRANT_KEY must be set unless CRYPTO_INSECURE is true")
+	}
+	seed, err := base64.StdEncoding.DecodeString(c.GrantKey)
+	if err != nil {
+		return nil, fmt.Errorf("CRYPTO_GRANT_KEY is not valid base64: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("CRYPTO_GRANT_KEY must be a %d byte Ed25519 seed", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
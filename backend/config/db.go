@@ -5,16 +5,30 @@ import (
 )
 
 type DBConfig struct {
-	Host     string
-	Port     string
+	Host string
+	Port string
+	// User はリクエストを処理するロール。行単位のセキュリティが効くよう、テーブルの所有者でないロールにする
 	User     string
 	Password string
-	Name     string
-	UseSSL   string
+	// SystemUser はスケジューラーなど、ユーザーをまたいで処理するときのロール。テーブルの所有者にする
+	SystemUser     string
+	SystemPassword string
+	Name           string
+	UseSSL         string
 }
 
+// GetConnStr はリクエストを処理する接続の接続文字列を返します
 func (c *DBConfig) GetConnStr() string {
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", c.Host, c.Port, c.User, c.Password, c.Name)
+	return c.connStr(c.User, c.Password)
+}
+
+// GetSystemConnStr はスケジューラーなどが使う接続の接続文字列を返します
+func (c *DBConfig) GetSystemConnStr() string {
+	return c.connStr(c.SystemUser, c.SystemPassword)
+}
+
+func (c *DBConfig) connStr(user, password string) string {
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", c.Host, c.Port, user, password, c.Name)
 	if c.UseSSL == "false" {
 		connStr += " sslmode=disable"
 	}
@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// SchedulerConfig はバックグラウンドで動く定期処理の設定
+type SchedulerConfig struct {
+	// 期限を過ぎた開示請求を確認する間隔 (例: "1m")
+	Interval string
+}
+
+// defaultSchedulerInterval は Interval が読めないときの間隔
+const defaultSchedulerInterval = time.Minute
+
+// Every は定期処理の間隔を返す
+func (c *SchedulerConfig) Every() time.Duration {
+	d, err := time.ParseDuration(c.Interval)
+	if err != nil || d <= 0 {
+		return defaultSchedulerInterval
+	}
+	return d
+}
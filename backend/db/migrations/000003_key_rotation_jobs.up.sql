@@ -0,0 +1,28 @@
+-- ===============================
+-- KeyRotationJobs
+-- 暗号鍵ローテーション後の再暗号化ジョブ。進捗を保存して再開できるようにする
+-- ===============================
+CREATE TABLE key_rotation_jobs
+(
+    id              SERIAL PRIMARY KEY,
+    user_id         UUID                        NOT NULL REFERENCES users (id),
+    key_version     INTEGER                     NOT NULL,
+    status          TEXT                        NOT NULL DEFAULT 'running'
+        CONSTRAINT key_rotation_jobs_status_check CHECK (status IN ('running', 'completed', 'failed')),
+    -- accounts -> devices -> subscriptions -> done の順に処理する
+    phase           TEXT                        NOT NULL DEFAULT 'accounts',
+    -- 現在のフェーズで最後に処理した行のID
+    last_item_id    INTEGER                     NOT NULL DEFAULT 0,
+    total_items     INTEGER                     NOT NULL DEFAULT 0,
+    processed_items INTEGER                     NOT NULL DEFAULT 0,
+    failed_items    INTEGER                     NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    created_at      TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMP WITHOUT TIME ZONE
+);
+
+-- 同じユーザーで同時に走るジョブは1つまで
+CREATE UNIQUE INDEX key_rotation_jobs_running_user_idx
+    ON key_rotation_jobs (user_id)
+    WHERE status = 'running';
@@ -0,0 +1,4 @@
+-- 開示済みアカウントのパスワードを受取人の鍵で暗号化し直したもの。
+-- 受取人はこちらを自分の鍵で復号するため、パッサーの鍵には依存しない
+ALTER TABLE accounts
+    ADD COLUMN receiver_enc_password BYTEA;
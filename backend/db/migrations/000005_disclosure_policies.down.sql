@@ -0,0 +1,3 @@
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS vault_enc_password;
+DROP TABLE IF EXISTS disclosure_policies;
@@ -0,0 +1,21 @@
+-- ===============================
+-- DisclosurePolicies
+-- しきい値開示の設定。受取人 share_count 人のうち threshold 人の開示請求が揃うまで、
+-- crypto サービスの金庫 (vault) は開かない
+-- ===============================
+CREATE TABLE disclosure_policies
+(
+    passer_id     UUID PRIMARY KEY REFERENCES users (id),
+    threshold     INTEGER                     NOT NULL,
+    share_count   INTEGER                     NOT NULL,
+    -- crypto サービス側の金庫のバージョン。設定し直すたびに上がる
+    vault_version INTEGER                     NOT NULL,
+    unlocked_at   TIMESTAMP WITHOUT TIME ZONE,
+    created_at    TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT disclosure_policies_threshold_check CHECK (threshold >= 1 AND threshold <= share_count)
+);
+
+-- 金庫の鍵で暗号化したパスワード。しきい値開示のときはこちらから受取人に引き渡す
+ALTER TABLE accounts
+    ADD COLUMN vault_enc_password BYTEA;
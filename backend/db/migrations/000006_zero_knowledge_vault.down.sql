@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS zk_key_envelopes;
+DROP TABLE IF EXISTS zk_account_secrets;
+DROP TABLE IF EXISTS client_public_keys;
+
+-- サーバー側の暗号文がないアカウントは元のスキーマに戻せないので削除する
+DELETE FROM accounts
+WHERE encryption_mode = 'zero_knowledge';
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS accounts_enc_password_check;
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS accounts_encryption_mode_check;
+ALTER TABLE accounts
+    ALTER COLUMN enc_password SET NOT NULL;
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS encryption_mode;
@@ -0,0 +1,48 @@
+-- ===============================
+-- ゼロ知識モード
+-- パスワードをクライアント側で暗号化し、サーバーは平文を一切扱わない。
+-- server: 従来どおり crypto サービスが暗号化する (enc_password を使う)
+-- zero_knowledge: クライアントが暗号化した不透明なデータを zk_account_secrets に置く
+-- ===============================
+ALTER TABLE accounts
+    ADD COLUMN encryption_mode TEXT NOT NULL DEFAULT 'server';
+ALTER TABLE accounts
+    ALTER COLUMN enc_password DROP NOT NULL;
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_encryption_mode_check CHECK (encryption_mode IN ('server', 'zero_knowledge'));
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_enc_password_check CHECK ((encryption_mode = 'server') = (enc_password IS NOT NULL));
+
+-- クライアントが生成した鍵ペアの公開鍵。パッサーはこれを使って受取人ごとに鍵を包む
+CREATE TABLE client_public_keys
+(
+    user_id    UUID PRIMARY KEY REFERENCES users (id),
+    -- クライアントが決める方式 (例: x25519)
+    algorithm  TEXT                        NOT NULL,
+    public_key BYTEA                       NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- クライアントが暗号化したパスワード。owner_key_envelope はパスフレーズから導出した鍵で
+-- アイテム鍵を包んだもので、パッサー本人しか開けない
+CREATE TABLE zk_account_secrets
+(
+    account_id         INTEGER PRIMARY KEY REFERENCES accounts (id) ON DELETE CASCADE,
+    -- クライアントが決める暗号方式。サーバーは解釈しない
+    format             TEXT                        NOT NULL,
+    ciphertext         BYTEA                       NOT NULL,
+    owner_key_envelope BYTEA                       NOT NULL,
+    created_at         TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- アイテム鍵を受取人の公開鍵 (client_public_keys) で包んだもの。開示されるまで受取人には返さない
+CREATE TABLE zk_key_envelopes
+(
+    account_id       INTEGER                     NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    receiver_user_id UUID                        NOT NULL REFERENCES users (id),
+    envelope         BYTEA                       NOT NULL,
+    created_at       TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, receiver_user_id)
+);
@@ -0,0 +1,5 @@
+-- 暗号化済みの行は元に戻らない。戻す前に暗号化済みのデータがないことを確かめること
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS enc_version;
+ALTER TABLE devices
+    DROP COLUMN IF EXISTS enc_version;
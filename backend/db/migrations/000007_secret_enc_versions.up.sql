@@ -0,0 +1,8 @@
+-- デバイスとサブスクリプションの enc_password は平文のまま保存されていた。
+-- enc_version で暗号化の方式を区別し、既存の行は encrypt-secrets コマンドで暗号化する
+-- 0: 平文 (移行前)
+-- 1: crypto サービスのエンベロープ暗号
+ALTER TABLE devices
+    ADD COLUMN enc_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions
+    ADD COLUMN enc_version INTEGER NOT NULL DEFAULT 0;
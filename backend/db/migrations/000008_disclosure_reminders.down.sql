@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS disclosure_reminders;
+DROP TABLE IF EXISTS reminder_plans;
+DROP TABLE IF EXISTS notification_channels;
@@ -0,0 +1,34 @@
+-- ===============================
+-- 開示請求の猶予期間中の生存確認の催促
+-- 請求を受けてからの経過時間ごとに、パッサーが設定した経路へ段階的に広げて送る
+-- ===============================
+
+-- パッサーが生存確認を受け取る経路。email を設定しなければ Clerk のメールアドレスに送る
+CREATE TABLE notification_channels
+(
+    user_id    UUID                        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    channel    TEXT                        NOT NULL CHECK (channel IN ('email', 'line', 'webhook')),
+    -- メールアドレス、LINE のユーザーID、Webhook の URL
+    address    TEXT                        NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, channel)
+);
+
+-- 開示請求を受けてから何時間後に催促するか。昇順に並べる
+CREATE TABLE reminder_plans
+(
+    user_id       UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    offsets_hours INTEGER[]                   NOT NULL,
+    updated_at    TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 送った催促。先に行を入れてから送るので、再起動や複数のレプリカでも同じ段階を二重に送らない
+CREATE TABLE disclosure_reminders
+(
+    disclosure_id INTEGER                     NOT NULL REFERENCES disclosures (id) ON DELETE CASCADE,
+    step          INTEGER                     NOT NULL,
+    channel       TEXT                        NOT NULL,
+    sent_at       TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (disclosure_id, step, channel)
+);
@@ -0,0 +1,26 @@
+-- ===============================
+-- 開示請求とは別に、パッサーが自分で設定する定期的な生存確認
+-- interval_days ごとに確認のリンクを送り、grace_days 以内に確認がなければ
+-- 既定の受取人 (users.default_receiver_id) の名前で開示請求を出す
+-- ===============================
+
+CREATE TABLE alive_check_schedules
+(
+    user_id            UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    interval_days      INTEGER                     NOT NULL CHECK (interval_days > 0),
+    grace_days         INTEGER                     NOT NULL CHECK (grace_days > 0),
+    enabled            BOOLEAN                     NOT NULL DEFAULT TRUE,
+    -- 次に確認のリンクを送る時刻
+    next_check_at      TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    -- 送った確認のリンクがまだ確認されていなければ、送った時刻
+    sent_at            TIMESTAMP WITHOUT TIME ZONE,
+    last_confirmed_at  TIMESTAMP WITHOUT TIME ZONE,
+    last_missed_at     TIMESTAMP WITHOUT TIME ZONE,
+    -- 確認がなかったために出した開示請求
+    last_disclosure_id INTEGER REFERENCES disclosures (id) ON DELETE SET NULL,
+    created_at         TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX alive_check_schedules_next_check_at_idx ON alive_check_schedules (next_check_at) WHERE enabled AND sent_at IS NULL;
+CREATE INDEX alive_check_schedules_sent_at_idx ON alive_check_schedules (sent_at) WHERE enabled AND sent_at IS NOT NULL;
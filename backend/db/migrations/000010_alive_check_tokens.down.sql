@@ -0,0 +1 @@
+DROP TABLE IF EXISTS alive_check_tokens;
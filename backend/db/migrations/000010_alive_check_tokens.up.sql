@@ -0,0 +1,24 @@
+-- ===============================
+-- 生存確認のリンクのトークン
+-- トークンそのものは保存せず、SHA-256 のハッシュだけを持つ。
+-- 1回使うと使えなくなり、同じ用途で新しいリンクを送ると古いリンクは使えなくなる
+-- ===============================
+
+CREATE TABLE alive_check_tokens
+(
+    token_hash    BYTEA PRIMARY KEY,
+    user_id       UUID                        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    -- disclosure: 開示請求への生存確認、scheduled: 定期的な生存確認、manual: 自分で送った確認メール
+    purpose       TEXT                        NOT NULL CHECK (purpose IN ('disclosure', 'scheduled', 'manual')),
+    disclosure_id INTEGER REFERENCES disclosures (id) ON DELETE CASCADE,
+    expires_at    TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    used_at       TIMESTAMP WITHOUT TIME ZONE,
+    -- 同じ用途で新しいリンクを送った時刻
+    superseded_at TIMESTAMP WITHOUT TIME ZONE,
+    revoked_at    TIMESTAMP WITHOUT TIME ZONE,
+    created_at    TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((purpose = 'disclosure') = (disclosure_id IS NOT NULL))
+);
+
+CREATE INDEX alive_check_tokens_user_id_idx ON alive_check_tokens (user_id, purpose) WHERE used_at IS NULL AND superseded_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX alive_check_tokens_disclosure_id_idx ON alive_check_tokens (disclosure_id);
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS disclosure_transitions;
+
+ALTER TABLE disclosures
+    ADD COLUMN in_progress BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN disclosed   BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE disclosures
+SET in_progress = status IN ('requested', 'notifying', 'grace'),
+    disclosed   = status = 'disclosed';
+
+ALTER TABLE disclosures
+    ALTER COLUMN in_progress DROP DEFAULT,
+    ALTER COLUMN disclosed DROP DEFAULT,
+    DROP COLUMN status,
+    DROP COLUMN status_changed_at;
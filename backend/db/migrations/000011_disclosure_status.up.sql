@@ -0,0 +1,48 @@
+-- ===============================
+-- 開示請求の状態を in_progress / disclosed / prevented_by の組み合わせから、明示的な status に置き換える
+-- 状態の移り変わりは disclosure_transitions に、いつ誰が変えたかとともに残す
+-- ===============================
+
+-- requested: 請求を受けた。まだ生存確認を送っていない
+-- notifying: パッサーに最初の生存確認を送っている
+-- grace:     生存確認を送り、期限まで待っている
+-- vetoed:    パッサーが生存を確認して止めた
+-- disclosed: 期限までに止められず開示した
+-- cancelled: 請求者が取り下げた
+-- expired:   期限を過ぎたが、請求者がもう受取人でないため開示しなかった
+ALTER TABLE disclosures
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'requested'
+        CHECK (status IN ('requested', 'notifying', 'grace', 'vetoed', 'disclosed', 'cancelled', 'expired')),
+    ADD COLUMN status_changed_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW();
+
+UPDATE disclosures
+SET status = CASE
+                 WHEN disclosed THEN 'disclosed'
+                 WHEN prevented_by IS NOT NULL THEN 'vetoed'
+                 WHEN in_progress THEN 'grace'
+                 ELSE 'cancelled' END,
+    status_changed_at = COALESCE(disclosed_at, issued_time);
+
+ALTER TABLE disclosures
+    DROP COLUMN in_progress,
+    DROP COLUMN disclosed;
+
+CREATE TABLE disclosure_transitions
+(
+    id            SERIAL PRIMARY KEY,
+    disclosure_id INTEGER                     NOT NULL REFERENCES disclosures (id) ON DELETE CASCADE,
+    -- 作成時は NULL
+    from_status   TEXT,
+    to_status     TEXT                        NOT NULL,
+    -- requester: 請求者、passer: パッサー、system: スケジューラーなど
+    actor         TEXT                        NOT NULL CHECK (actor IN ('requester', 'passer', 'system')),
+    actor_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at    TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX disclosure_transitions_disclosure_id_idx ON disclosure_transitions (disclosure_id, id);
+
+-- 既存の請求は、今の状態になった記録だけを残す
+INSERT INTO disclosure_transitions(disclosure_id, from_status, to_status, actor, created_at)
+SELECT id, NULL, status, 'system', status_changed_at
+FROM disclosures;
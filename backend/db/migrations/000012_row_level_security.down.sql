@@ -0,0 +1,72 @@
+DROP POLICY IF EXISTS subscriptions_modification ON subscriptions;
+DROP POLICY IF EXISTS subscriptions_select ON subscriptions;
+ALTER TABLE subscriptions
+    DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY accounts_select ON accounts;
+DROP POLICY accounts_modification ON accounts;
+DROP POLICY devices_select ON devices;
+DROP POLICY devices_modification ON devices;
+
+CREATE POLICY accounts_select
+    ON accounts
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = current_setting('digi_baton.current_user_id')::uuid)
+        OR
+    (
+        is_disclosed
+            AND EXISTS (SELECT 1
+                        FROM trusts t
+                        WHERE t.id = accounts.trust_id
+                          AND t.receiver_user_id = current_setting('digi_baton.current_user_id')::uuid
+                          AND t.passer_user_id = accounts.passer_id)
+        )
+    );
+
+CREATE POLICY accounts_modification
+    ON accounts
+    FOR ALL
+    TO PUBLIC
+    USING (
+    passer_id = current_setting('digi_baton.current_user_id')::uuid
+    )
+    WITH CHECK (
+    passer_id = current_setting('digi_baton.current_user_id')::uuid
+    );
+
+CREATE POLICY devices_select
+    ON devices
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = current_setting('digi_baton.current_user_id')::uuid)
+        OR
+    (
+        is_disclosed
+            AND EXISTS (SELECT 1
+                        FROM trusts t
+                        WHERE t.id = devices.trust_id
+                          AND t.receiver_user_id = current_setting('digi_baton.current_user_id')::uuid
+                          AND t.passer_user_id = devices.passer_id)
+        )
+    );
+
+CREATE POLICY devices_modification
+    ON devices
+    FOR ALL
+    TO PUBLIC
+    USING (
+    passer_id = current_setting('digi_baton.current_user_id')::uuid
+    )
+    WITH CHECK (
+    passer_id = current_setting('digi_baton.current_user_id')::uuid
+    );
+
+-- ロールは他のデータベースやログインするロールから使われていることがあるので消さない
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE SELECT, USAGE ON SEQUENCES FROM digi_baton_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM digi_baton_app;
+REVOKE SELECT, USAGE ON ALL SEQUENCES IN SCHEMA public FROM digi_baton_app;
+REVOKE SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public FROM digi_baton_app;
+REVOKE USAGE ON SCHEMA public FROM digi_baton_app;
@@ -0,0 +1,117 @@
+-- ===============================
+-- 行単位のセキュリティ (RLS) をアプリから効かせる
+-- テーブルの所有者には RLS が効かないため、アプリはリクエストを所有者でないロール digi_baton_app で処理する。
+-- リクエストごとのトランザクションで digi_baton.current_user_id にログインユーザーを設定する
+-- ===============================
+
+-- ログインするロールは環境ごとに作り、digi_baton_app に所属させる
+--   CREATE ROLE digi_baton_backend LOGIN PASSWORD '...' IN ROLE digi_baton_app;
+DO
+$$
+    BEGIN
+        IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'digi_baton_app') THEN
+            CREATE ROLE digi_baton_app NOLOGIN;
+        END IF;
+    END
+$$;
+
+GRANT USAGE ON SCHEMA public TO digi_baton_app;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO digi_baton_app;
+GRANT SELECT, USAGE ON ALL SEQUENCES IN SCHEMA public TO digi_baton_app;
+-- 以降のマイグレーションで作るテーブルにも同じ権限を付ける
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO digi_baton_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, USAGE ON SEQUENCES TO digi_baton_app;
+
+-- digi_baton.current_user_id を設定していない接続ではエラーにせず、どの行も見えないようにする
+DROP POLICY accounts_select ON accounts;
+DROP POLICY accounts_modification ON accounts;
+DROP POLICY devices_select ON devices;
+DROP POLICY devices_modification ON devices;
+
+CREATE POLICY accounts_select
+    ON accounts
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    (
+        is_disclosed
+            AND EXISTS (SELECT 1
+                        FROM trusts t
+                        WHERE t.id = accounts.trust_id
+                          AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                          AND t.passer_user_id = accounts.passer_id)
+        )
+    );
+
+CREATE POLICY accounts_modification
+    ON accounts
+    FOR ALL
+    TO PUBLIC
+    USING (
+    passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+    )
+    WITH CHECK (
+    passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+    );
+
+CREATE POLICY devices_select
+    ON devices
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    (
+        is_disclosed
+            AND EXISTS (SELECT 1
+                        FROM trusts t
+                        WHERE t.id = devices.trust_id
+                          AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                          AND t.passer_user_id = devices.passer_id)
+        )
+    );
+
+CREATE POLICY devices_modification
+    ON devices
+    FOR ALL
+    TO PUBLIC
+    USING (
+    passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+    )
+    WITH CHECK (
+    passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+    );
+
+-- subscriptions にも accounts、devices と同じポリシーを付ける
+ALTER TABLE subscriptions
+    ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY subscriptions_select
+    ON subscriptions
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    (
+        is_disclosed
+            AND EXISTS (SELECT 1
+                        FROM trusts t
+                        WHERE t.id = subscriptions.trust_id
+                          AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                          AND t.passer_user_id = subscriptions.passer_id)
+        )
+    );
+
+CREATE POLICY subscriptions_modification
+    ON subscriptions
+    FOR ALL
+    TO PUBLIC
+    USING (
+    passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+    )
+    WITH CHECK (
+    passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+    );
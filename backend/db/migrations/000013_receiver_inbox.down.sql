@@ -0,0 +1,8 @@
+ALTER TABLE devices
+    DROP COLUMN IF EXISTS pls_delete;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS receiver_enc_password;
+
+ALTER TABLE devices
+    DROP COLUMN IF EXISTS receiver_enc_password;
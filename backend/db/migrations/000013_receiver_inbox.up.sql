@@ -0,0 +1,14 @@
+-- ===============================
+-- 受取人の受信箱
+-- デバイスとサブスクリプションも、開示したときにアカウントと同じく受取人の鍵で暗号化し直す。
+-- 受取人はこちらを自分の鍵で復号するため、パッサーの鍵には依存しない
+-- ===============================
+ALTER TABLE devices
+    ADD COLUMN receiver_enc_password BYTEA;
+
+ALTER TABLE subscriptions
+    ADD COLUMN receiver_enc_password BYTEA;
+
+-- アカウント、サブスクリプションと同じく、デバイスにも受取人に消してほしいかを残せるようにする
+ALTER TABLE devices
+    ADD COLUMN pls_delete BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,32 @@
+-- ===============================
+-- 相続関係への招待
+-- まだアカウントのない受取人を、パッサーがメールアドレスで招待する。
+-- トークンそのものは保存せず、SHA-256 のハッシュだけを持つ。
+-- 招待されたメールアドレスで登録するか、リンクのトークンを使うと trusts の行ができ、受取人が承諾するか辞退するまで pending のまま
+-- ===============================
+
+CREATE TABLE trust_invitations
+(
+    id               SERIAL PRIMARY KEY,
+    passer_user_id   UUID                        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    -- 小文字にそろえたメールアドレス
+    email            TEXT                        NOT NULL,
+    token_hash       BYTEA                       NOT NULL UNIQUE,
+    -- pending: 返事を待っている、accepted: 承諾した、declined: 辞退した、revoked: パッサーが取り消した
+    status           TEXT                        NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'declined', 'revoked')),
+    -- 招待された人が登録するかリンクを開くまでは NULL
+    receiver_user_id UUID REFERENCES users (id) ON DELETE CASCADE,
+    -- 招待からできた相続関係。パッサーが消すと NULL になる
+    trust_id         INTEGER REFERENCES trusts (id) ON DELETE SET NULL,
+    -- リンクの期限。受取人と結びついた後は期限を過ぎても返事できる
+    expires_at       TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    responded_at     TIMESTAMP WITHOUT TIME ZONE,
+    created_at       TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 同じ相手への返事待ちの招待は1つだけ
+CREATE UNIQUE INDEX trust_invitations_pending_email_idx ON trust_invitations (passer_user_id, email) WHERE status = 'pending';
+CREATE INDEX trust_invitations_email_idx ON trust_invitations (email) WHERE status = 'pending' AND receiver_user_id IS NULL;
+CREATE INDEX trust_invitations_receiver_user_id_idx ON trust_invitations (receiver_user_id);
+CREATE INDEX trust_invitations_trust_id_idx ON trust_invitations (trust_id);
@@ -0,0 +1,107 @@
+ALTER TABLE accounts
+    ADD COLUMN trust_id              INTEGER REFERENCES trusts (id),
+    ADD COLUMN receiver_enc_password BYTEA;
+
+ALTER TABLE devices
+    ADD COLUMN trust_id              INTEGER REFERENCES trusts (id),
+    ADD COLUMN receiver_enc_password BYTEA;
+
+ALTER TABLE subscriptions
+    ADD COLUMN trust_id              INTEGER REFERENCES trusts (id),
+    ADD COLUMN receiver_enc_password BYTEA;
+
+-- 1件に1人しか持てないため、複数の受取人に割り当てたものは一番古い相続関係だけを戻す
+UPDATE accounts a
+SET trust_id              = aa.trust_id,
+    receiver_enc_password = aa.receiver_enc_password
+FROM (SELECT DISTINCT ON (account_id) account_id, trust_id, receiver_enc_password
+      FROM account_assignments
+      ORDER BY account_id, trust_id) aa
+WHERE aa.account_id = a.id;
+
+UPDATE devices d
+SET trust_id              = da.trust_id,
+    receiver_enc_password = da.receiver_enc_password
+FROM (SELECT DISTINCT ON (device_id) device_id, trust_id, receiver_enc_password
+      FROM device_assignments
+      ORDER BY device_id, trust_id) da
+WHERE da.device_id = d.id;
+
+UPDATE subscriptions s
+SET trust_id              = sa.trust_id,
+    receiver_enc_password = sa.receiver_enc_password
+FROM (SELECT DISTINCT ON (subscription_id) subscription_id, trust_id, receiver_enc_password
+      FROM subscription_assignments
+      ORDER BY subscription_id, trust_id) sa
+WHERE sa.subscription_id = s.id;
+
+-- 割り当てのないものは元に戻せない
+DELETE FROM accounts WHERE trust_id IS NULL;
+DELETE FROM devices WHERE trust_id IS NULL;
+DELETE FROM subscriptions WHERE trust_id IS NULL;
+
+ALTER TABLE accounts
+    ALTER COLUMN trust_id SET NOT NULL;
+ALTER TABLE devices
+    ALTER COLUMN trust_id SET NOT NULL;
+ALTER TABLE subscriptions
+    ALTER COLUMN trust_id SET NOT NULL;
+
+DROP POLICY accounts_select ON accounts;
+DROP POLICY devices_select ON devices;
+DROP POLICY subscriptions_select ON subscriptions;
+
+CREATE POLICY accounts_select
+    ON accounts
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    (
+        is_disclosed
+            AND EXISTS (SELECT 1
+                        FROM trusts t
+                        WHERE t.id = accounts.trust_id
+                          AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                          AND t.passer_user_id = accounts.passer_id)
+        )
+    );
+
+CREATE POLICY devices_select
+    ON devices
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    (
+        is_disclosed
+            AND EXISTS (SELECT 1
+                        FROM trusts t
+                        WHERE t.id = devices.trust_id
+                          AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                          AND t.passer_user_id = devices.passer_id)
+        )
+    );
+
+CREATE POLICY subscriptions_select
+    ON subscriptions
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    (
+        is_disclosed
+            AND EXISTS (SELECT 1
+                        FROM trusts t
+                        WHERE t.id = subscriptions.trust_id
+                          AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                          AND t.passer_user_id = subscriptions.passer_id)
+        )
+    );
+
+DROP TABLE IF EXISTS subscription_assignments;
+DROP TABLE IF EXISTS device_assignments;
+DROP TABLE IF EXISTS account_assignments;
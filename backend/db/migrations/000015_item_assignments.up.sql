@@ -0,0 +1,228 @@
+-- ===============================
+-- アカウント、デバイス、サブスクリプションの受取人への割り当て
+-- これまでは1件につき trust_id を1つしか持てなかった。割り当てを別テーブルにして、
+-- 1件を複数の受取人に託し、受取人ごとに見せる範囲を決められるようにする。
+-- 受取人向けに暗号化し直したパスワードも受取人ごとに持つ
+-- ===============================
+
+CREATE TABLE account_assignments
+(
+    account_id            INTEGER                     NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    trust_id              INTEGER                     NOT NULL REFERENCES trusts (id) ON DELETE CASCADE,
+    -- credentials: ログイン情報まで見せる、message: パッサーからの伝言だけを見せる
+    permission            TEXT                        NOT NULL DEFAULT 'credentials'
+        CHECK (permission IN ('credentials', 'message')),
+    is_disclosed          BOOLEAN                     NOT NULL DEFAULT false,
+    receiver_enc_password BYTEA,
+    created_at            TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, trust_id)
+);
+
+CREATE TABLE device_assignments
+(
+    device_id             INTEGER                     NOT NULL REFERENCES devices (id) ON DELETE CASCADE,
+    trust_id              INTEGER                     NOT NULL REFERENCES trusts (id) ON DELETE CASCADE,
+    permission            TEXT                        NOT NULL DEFAULT 'credentials'
+        CHECK (permission IN ('credentials', 'message')),
+    is_disclosed          BOOLEAN                     NOT NULL DEFAULT false,
+    receiver_enc_password BYTEA,
+    created_at            TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (device_id, trust_id)
+);
+
+CREATE TABLE subscription_assignments
+(
+    subscription_id       INTEGER                     NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    trust_id              INTEGER                     NOT NULL REFERENCES trusts (id) ON DELETE CASCADE,
+    permission            TEXT                        NOT NULL DEFAULT 'credentials'
+        CHECK (permission IN ('credentials', 'message')),
+    is_disclosed          BOOLEAN                     NOT NULL DEFAULT false,
+    receiver_enc_password BYTEA,
+    created_at            TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subscription_id, trust_id)
+);
+
+CREATE INDEX account_assignments_trust_id_idx ON account_assignments (trust_id);
+CREATE INDEX device_assignments_trust_id_idx ON device_assignments (trust_id);
+CREATE INDEX subscription_assignments_trust_id_idx ON subscription_assignments (trust_id);
+
+-- 今の割り当てをそのまま移す。開示済みのものは受取人向けの暗号文も移す
+INSERT INTO account_assignments (account_id, trust_id, is_disclosed, receiver_enc_password)
+SELECT a.id, a.trust_id, a.is_disclosed, a.receiver_enc_password
+FROM accounts a
+JOIN trusts t ON t.id = a.trust_id AND t.passer_user_id = a.passer_id;
+
+INSERT INTO device_assignments (device_id, trust_id, is_disclosed, receiver_enc_password)
+SELECT d.id, d.trust_id, d.is_disclosed, d.receiver_enc_password
+FROM devices d
+JOIN trusts t ON t.id = d.trust_id AND t.passer_user_id = d.passer_id;
+
+INSERT INTO subscription_assignments (subscription_id, trust_id, is_disclosed, receiver_enc_password)
+SELECT s.id, s.trust_id, s.is_disclosed, s.receiver_enc_password
+FROM subscriptions s
+JOIN trusts t ON t.id = s.trust_id AND t.passer_user_id = s.passer_id;
+
+-- 受取人が見られるのは、自分に割り当てられて開示されたものだけにする
+DROP POLICY accounts_select ON accounts;
+DROP POLICY devices_select ON devices;
+DROP POLICY subscriptions_select ON subscriptions;
+
+CREATE POLICY accounts_select
+    ON accounts
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    EXISTS (SELECT 1
+            FROM account_assignments aa
+                     JOIN trusts t ON t.id = aa.trust_id
+            WHERE aa.account_id = accounts.id
+              AND aa.is_disclosed
+              AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+              AND t.passer_user_id = accounts.passer_id)
+    );
+
+CREATE POLICY devices_select
+    ON devices
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    EXISTS (SELECT 1
+            FROM device_assignments da
+                     JOIN trusts t ON t.id = da.trust_id
+            WHERE da.device_id = devices.id
+              AND da.is_disclosed
+              AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+              AND t.passer_user_id = devices.passer_id)
+    );
+
+CREATE POLICY subscriptions_select
+    ON subscriptions
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    (passer_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+        OR
+    EXISTS (SELECT 1
+            FROM subscription_assignments sa
+                     JOIN trusts t ON t.id = sa.trust_id
+            WHERE sa.subscription_id = subscriptions.id
+              AND sa.is_disclosed
+              AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+              AND t.passer_user_id = subscriptions.passer_id)
+    );
+
+-- 割り当ては相続関係のパッサーが管理し、受取人は自分宛てで開示されたものだけを見られる。
+-- accounts などのポリシーから参照されるため、こちらからは trusts だけを見る
+ALTER TABLE account_assignments
+    ENABLE ROW LEVEL SECURITY;
+ALTER TABLE device_assignments
+    ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_assignments
+    ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY account_assignments_select
+    ON account_assignments
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = account_assignments.trust_id
+              AND (t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                OR (account_assignments.is_disclosed
+                    AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)))
+    );
+
+CREATE POLICY account_assignments_modification
+    ON account_assignments
+    FOR ALL
+    TO PUBLIC
+    USING (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = account_assignments.trust_id
+              AND t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+    )
+    WITH CHECK (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = account_assignments.trust_id
+              AND t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+    );
+
+CREATE POLICY device_assignments_select
+    ON device_assignments
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = device_assignments.trust_id
+              AND (t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                OR (device_assignments.is_disclosed
+                    AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)))
+    );
+
+CREATE POLICY device_assignments_modification
+    ON device_assignments
+    FOR ALL
+    TO PUBLIC
+    USING (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = device_assignments.trust_id
+              AND t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+    )
+    WITH CHECK (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = device_assignments.trust_id
+              AND t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+    );
+
+CREATE POLICY subscription_assignments_select
+    ON subscription_assignments
+    FOR SELECT
+    TO PUBLIC
+    USING (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = subscription_assignments.trust_id
+              AND (t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid
+                OR (subscription_assignments.is_disclosed
+                    AND t.receiver_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)))
+    );
+
+CREATE POLICY subscription_assignments_modification
+    ON subscription_assignments
+    FOR ALL
+    TO PUBLIC
+    USING (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = subscription_assignments.trust_id
+              AND t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+    )
+    WITH CHECK (
+    EXISTS (SELECT 1
+            FROM trusts t
+            WHERE t.id = subscription_assignments.trust_id
+              AND t.passer_user_id = NULLIF(current_setting('digi_baton.current_user_id', true), '')::uuid)
+    );
+
+-- is_disclosed は「いずれかの受取人に開示済み」として残す
+ALTER TABLE accounts
+    DROP COLUMN trust_id,
+    DROP COLUMN receiver_enc_password;
+
+ALTER TABLE devices
+    DROP COLUMN trust_id,
+    DROP COLUMN receiver_enc_password;
+
+ALTER TABLE subscriptions
+    DROP COLUMN trust_id,
+    DROP COLUMN receiver_enc_password;
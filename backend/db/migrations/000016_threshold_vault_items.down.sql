@@ -0,0 +1,6 @@
+ALTER TABLE disclosure_policies
+    DROP COLUMN receiver_ids;
+ALTER TABLE subscriptions
+    DROP COLUMN vault_enc_password;
+ALTER TABLE devices
+    DROP COLUMN vault_enc_password;
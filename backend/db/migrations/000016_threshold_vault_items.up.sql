@@ -0,0 +1,14 @@
+-- ===============================
+-- しきい値開示の対象をデバイスとサブスクリプションにも広げる
+-- ===============================
+
+-- 金庫の鍵で暗号化したパスワード。しきい値開示のときはこちらから受取人に引き渡す
+ALTER TABLE devices
+    ADD COLUMN vault_enc_password BYTEA;
+ALTER TABLE subscriptions
+    ADD COLUMN vault_enc_password BYTEA;
+
+-- 金庫の鍵のシェアを配った受取人。trusts の受取人と違っていればシェアを配り直す。
+-- 既存の設定は空なので、次のスケジューラーの実行で配り直される
+ALTER TABLE disclosure_policies
+    ADD COLUMN receiver_ids UUID[] NOT NULL DEFAULT '{}';
@@ -11,41 +11,6 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const assignReceiverToAccount = `-- name: AssignReceiverToAccount :one
-UPDATE accounts
-SET trust_id = $2
-WHERE id = $1
-RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
-`
-
-type AssignReceiverToAccountParams struct {
-	ID      int32
-	TrustID int32
-}
-
-func (q *Queries) AssignReceiverToAccount(ctx context.Context, arg AssignReceiverToAccountParams) (Account, error) {
-	row := q.db.QueryRow(ctx, assignReceiverToAccount, arg.ID, arg.TrustID)
-	var i Account
-	err := row.Scan(
-		&i.ID,
-		&i.AppTemplateID,
-		&i.AppName,
-		&i.AppDescription,
-		&i.AppIconUrl,
-		&i.Username,
-		&i.Email,
-		&i.EncPassword,
-		&i.Memo,
-		&i.PlsDelete,
-		&i.Message,
-		&i.PasserID,
-		&i.TrustID,
-		&i.IsDisclosed,
-		&i.CustomData,
-	)
-	return i, err
-}
-
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts(app_template_id,
                     app_name,
@@ -58,11 +23,11 @@ INSERT INTO accounts(app_template_id,
                     pls_delete,
                     message,
                     passer_id,
-                    trust_id,
                     is_disclosed,
-                    custom_data)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false, $9, $10, $11, false, $12)
-RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+                    custom_data,
+                    encryption_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false, $9, $10, false, $11, $12)
+RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, is_disclosed, custom_data, vault_enc_password, encryption_mode
 `
 
 type CreateAccountParams struct {
@@ -76,8 +41,8 @@ type CreateAccountParams struct {
 	Memo           string
 	Message        string
 	PasserID       pgtype.UUID
-	TrustID        int32
 	CustomData     []byte
+	EncryptionMode string
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
@@ -92,8 +57,8 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		arg.Memo,
 		arg.Message,
 		arg.PasserID,
-		arg.TrustID,
 		arg.CustomData,
+		arg.EncryptionMode,
 	)
 	var i Account
 	err := row.Scan(
@@ -109,9 +74,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.VaultEncPassword,
+		&i.EncryptionMode,
 	)
 	return i, err
 }
@@ -119,7 +85,7 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 const deleteAccount = `-- name: DeleteAccount :one
 DELETE FROM accounts
 WHERE id = $1 AND passer_id = $2
-RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, is_disclosed, custom_data, vault_enc_password, encryption_mode
 `
 
 type DeleteAccountParams struct {
@@ -143,29 +109,28 @@ func (q *Queries) DeleteAccount(ctx context.Context, arg DeleteAccountParams) (A
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.VaultEncPassword,
+		&i.EncryptionMode,
 	)
 	return i, err
 }
 
 const setAccountDisclosureStatus = `-- name: SetAccountDisclosureStatus :one
 UPDATE accounts
-SET is_disclosed = $2,
-    trust_id = $3
+SET is_disclosed = $2
 WHERE id = $1
-RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, is_disclosed, custom_data, vault_enc_password, encryption_mode
 `
 
 type SetAccountDisclosureStatusParams struct {
 	ID          int32
 	IsDisclosed bool
-	TrustID     int32
 }
 
 func (q *Queries) SetAccountDisclosureStatus(ctx context.Context, arg SetAccountDisclosureStatusParams) (Account, error) {
-	row := q.db.QueryRow(ctx, setAccountDisclosureStatus, arg.ID, arg.IsDisclosed, arg.TrustID)
+	row := q.db.QueryRow(ctx, setAccountDisclosureStatus, arg.ID, arg.IsDisclosed)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -180,9 +145,10 @@ func (q *Queries) SetAccountDisclosureStatus(ctx context.Context, arg SetAccount
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.VaultEncPassword,
+		&i.EncryptionMode,
 	)
 	return i, err
 }
@@ -198,9 +164,10 @@ SET app_template_id = $2,
     enc_password = $8,
     memo = $9,
     message = $10,
-    custom_data = $11
+    custom_data = $11,
+    encryption_mode = $13
 WHERE id = $1 AND passer_id = $12
-RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, is_disclosed, custom_data, vault_enc_password, encryption_mode
 `
 
 type UpdateAccountParams struct {
@@ -216,6 +183,7 @@ type UpdateAccountParams struct {
 	Message        string
 	CustomData     []byte
 	PasserID       pgtype.UUID
+	EncryptionMode string
 }
 
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
@@ -232,6 +200,7 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		arg.Message,
 		arg.CustomData,
 		arg.PasserID,
+		arg.EncryptionMode,
 	)
 	var i Account
 	err := row.Scan(
@@ -247,18 +216,55 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.VaultEncPassword,
+		&i.EncryptionMode,
 	)
 	return i, err
 }
 
+const updateAccountEncPassword = `-- name: UpdateAccountEncPassword :execrows
+UPDATE accounts
+SET enc_password = $2
+WHERE id = $1 AND enc_password = $3
+`
+
+type UpdateAccountEncPasswordParams struct {
+	ID            int32
+	EncPassword   []byte
+	EncPassword_2 []byte
+}
+
+func (q *Queries) UpdateAccountEncPassword(ctx context.Context, arg UpdateAccountEncPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateAccountEncPassword, arg.ID, arg.EncPassword, arg.EncPassword_2)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateAccountVaultEncPassword = `-- name: UpdateAccountVaultEncPassword :exec
+UPDATE accounts
+SET vault_enc_password = $2
+WHERE id = $1
+`
+
+type UpdateAccountVaultEncPasswordParams struct {
+	ID               int32
+	VaultEncPassword []byte
+}
+
+func (q *Queries) UpdateAccountVaultEncPassword(ctx context.Context, arg UpdateAccountVaultEncPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateAccountVaultEncPassword, arg.ID, arg.VaultEncPassword)
+	return err
+}
+
 const updateDeleteRequest = `-- name: UpdateDeleteRequest :one
 UPDATE accounts
 SET pls_delete = $2
 WHERE id = $1
-RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, app_template_id, app_name, app_description, app_icon_url, username, email, enc_password, memo, pls_delete, message, passer_id, is_disclosed, custom_data, vault_enc_password, encryption_mode
 `
 
 type UpdateDeleteRequestParams struct {
@@ -282,9 +288,10 @@ func (q *Queries) UpdateDeleteRequest(ctx context.Context, arg UpdateDeleteReque
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.VaultEncPassword,
+		&i.EncryptionMode,
 	)
 	return i, err
 }
@@ -11,8 +11,21 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countAccountsByPasserId = `-- name: CountAccountsByPasserId :one
+SELECT COUNT(*)
+FROM accounts
+WHERE accounts.passer_id = $1
+`
+
+func (q *Queries) CountAccountsByPasserId(ctx context.Context, passerID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countAccountsByPasserId, passerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getAccount = `-- name: GetAccount :one
-SELECT accounts.id, accounts.app_template_id, accounts.app_name, accounts.app_description, accounts.app_icon_url, accounts.username, accounts.email, accounts.enc_password, accounts.memo, accounts.pls_delete, accounts.message, accounts.passer_id, accounts.trust_id, accounts.is_disclosed, accounts.custom_data
+SELECT accounts.id, accounts.app_template_id, accounts.app_name, accounts.app_description, accounts.app_icon_url, accounts.username, accounts.email, accounts.enc_password, accounts.memo, accounts.pls_delete, accounts.message, accounts.passer_id, accounts.is_disclosed, accounts.custom_data, accounts.vault_enc_password, accounts.encryption_mode
 FROM accounts
 WHERE accounts.id = $1
 `
@@ -33,15 +46,16 @@ func (q *Queries) GetAccount(ctx context.Context, id int32) (Account, error) {
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.VaultEncPassword,
+		&i.EncryptionMode,
 	)
 	return i, err
 }
 
 const listAccountsByPasserId = `-- name: ListAccountsByPasserId :many
-SELECT accounts.id, accounts.app_template_id, accounts.app_name, accounts.app_description, accounts.app_icon_url, accounts.username, accounts.email, accounts.enc_password, accounts.memo, accounts.pls_delete, accounts.message, accounts.passer_id, accounts.trust_id, accounts.is_disclosed, accounts.custom_data
+SELECT accounts.id, accounts.app_template_id, accounts.app_name, accounts.app_description, accounts.app_icon_url, accounts.username, accounts.email, accounts.enc_password, accounts.memo, accounts.pls_delete, accounts.message, accounts.passer_id, accounts.is_disclosed, accounts.custom_data, accounts.vault_enc_password, accounts.encryption_mode
 FROM accounts
 WHERE accounts.passer_id = $1
 ORDER BY accounts.id DESC
@@ -69,9 +83,10 @@ func (q *Queries) ListAccountsByPasserId(ctx context.Context, passerID pgtype.UU
 			&i.PlsDelete,
 			&i.Message,
 			&i.PasserID,
-			&i.TrustID,
 			&i.IsDisclosed,
 			&i.CustomData,
+			&i.VaultEncPassword,
+			&i.EncryptionMode,
 		); err != nil {
 			return nil, err
 		}
@@ -83,38 +98,153 @@ func (q *Queries) ListAccountsByPasserId(ctx context.Context, passerID pgtype.UU
 	return items, nil
 }
 
-const listDisclosedAccountsByReceiverId = `-- name: ListDisclosedAccountsByReceiverId :many
-SELECT accounts.id, accounts.app_template_id, accounts.app_name, accounts.app_description, accounts.app_icon_url, accounts.username, accounts.email, accounts.enc_password, accounts.memo, accounts.pls_delete, accounts.message, accounts.passer_id, accounts.trust_id, accounts.is_disclosed, accounts.custom_data
+const listAccountsByPasserIdAndReceiverId = `-- name: ListAccountsByPasserIdAndReceiverId :many
+SELECT accounts.id, accounts.app_template_id, accounts.app_name, accounts.app_description, accounts.app_icon_url, accounts.username, accounts.email, accounts.enc_password, accounts.memo, accounts.pls_delete, accounts.message, accounts.passer_id, accounts.is_disclosed, accounts.custom_data, accounts.vault_enc_password, accounts.encryption_mode, aa.trust_id, aa.permission
 FROM accounts
-JOIN trusts t ON accounts.trust_id = t.id
-WHERE t.receiver_user_id = $1 AND accounts.is_disclosed = true
+JOIN account_assignments aa ON aa.account_id = accounts.id
+JOIN trusts t ON aa.trust_id = t.id
+WHERE accounts.passer_id = $1 AND t.receiver_user_id = $2 AND t.passer_user_id = accounts.passer_id
+  AND NOT aa.is_disclosed
+ORDER BY accounts.id, aa.trust_id
 `
 
-func (q *Queries) ListDisclosedAccountsByReceiverId(ctx context.Context, receiverUserID pgtype.UUID) ([]Account, error) {
-	rows, err := q.db.Query(ctx, listDisclosedAccountsByReceiverId, receiverUserID)
+type ListAccountsByPasserIdAndReceiverIdParams struct {
+	PasserID       pgtype.UUID
+	ReceiverUserID pgtype.UUID
+}
+
+type ListAccountsByPasserIdAndReceiverIdRow struct {
+	Account    Account
+	TrustID    int32
+	Permission string
+}
+
+func (q *Queries) ListAccountsByPasserIdAndReceiverId(ctx context.Context, arg ListAccountsByPasserIdAndReceiverIdParams) ([]ListAccountsByPasserIdAndReceiverIdRow, error) {
+	rows, err := q.db.Query(ctx, listAccountsByPasserIdAndReceiverId, arg.PasserID, arg.ReceiverUserID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Account
+	var items []ListAccountsByPasserIdAndReceiverIdRow
 	for rows.Next() {
-		var i Account
+		var i ListAccountsByPasserIdAndReceiverIdRow
+		if err := rows.Scan(
+			&i.Account.ID,
+			&i.Account.AppTemplateID,
+			&i.Account.AppName,
+			&i.Account.AppDescription,
+			&i.Account.AppIconUrl,
+			&i.Account.Username,
+			&i.Account.Email,
+			&i.Account.EncPassword,
+			&i.Account.Memo,
+			&i.Account.PlsDelete,
+			&i.Account.Message,
+			&i.Account.PasserID,
+			&i.Account.IsDisclosed,
+			&i.Account.CustomData,
+			&i.Account.VaultEncPassword,
+			&i.Account.EncryptionMode,
+			&i.TrustID,
+			&i.Permission,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsForKeyRotation = `-- name: ListAccountsForKeyRotation :many
+SELECT accounts.id, accounts.enc_password
+FROM accounts
+WHERE accounts.passer_id = $1 AND accounts.id > $2
+ORDER BY accounts.id
+LIMIT $3
+`
+
+type ListAccountsForKeyRotationParams struct {
+	PasserID pgtype.UUID
+	ID       int32
+	Limit    int32
+}
+
+type ListAccountsForKeyRotationRow struct {
+	ID          int32
+	EncPassword []byte
+}
+
+func (q *Queries) ListAccountsForKeyRotation(ctx context.Context, arg ListAccountsForKeyRotationParams) ([]ListAccountsForKeyRotationRow, error) {
+	rows, err := q.db.Query(ctx, listAccountsForKeyRotation, arg.PasserID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAccountsForKeyRotationRow
+	for rows.Next() {
+		var i ListAccountsForKeyRotationRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.AppTemplateID,
-			&i.AppName,
-			&i.AppDescription,
-			&i.AppIconUrl,
-			&i.Username,
-			&i.Email,
 			&i.EncPassword,
-			&i.Memo,
-			&i.PlsDelete,
-			&i.Message,
-			&i.PasserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDisclosedAccountsByReceiverId = `-- name: ListDisclosedAccountsByReceiverId :many
+SELECT DISTINCT ON (accounts.passer_id, accounts.id) accounts.id, accounts.app_template_id, accounts.app_name, accounts.app_description, accounts.app_icon_url, accounts.username, accounts.email, accounts.enc_password, accounts.memo, accounts.pls_delete, accounts.message, accounts.passer_id, accounts.is_disclosed, accounts.custom_data, accounts.vault_enc_password, accounts.encryption_mode, aa.trust_id, aa.permission, aa.receiver_enc_password
+FROM accounts
+JOIN account_assignments aa ON aa.account_id = accounts.id
+JOIN trusts t ON aa.trust_id = t.id
+WHERE t.receiver_user_id = $1 AND t.passer_user_id = accounts.passer_id AND aa.is_disclosed = true
+ORDER BY accounts.passer_id, accounts.id, aa.permission = 'credentials' DESC, aa.trust_id
+`
+
+type ListDisclosedAccountsByReceiverIdRow struct {
+	Account             Account
+	TrustID             int32
+	Permission          string
+	ReceiverEncPassword []byte
+}
+
+func (q *Queries) ListDisclosedAccountsByReceiverId(ctx context.Context, receiverUserID pgtype.UUID) ([]ListDisclosedAccountsByReceiverIdRow, error) {
+	rows, err := q.db.Query(ctx, listDisclosedAccountsByReceiverId, receiverUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDisclosedAccountsByReceiverIdRow
+	for rows.Next() {
+		var i ListDisclosedAccountsByReceiverIdRow
+		if err := rows.Scan(
+			&i.Account.ID,
+			&i.Account.AppTemplateID,
+			&i.Account.AppName,
+			&i.Account.AppDescription,
+			&i.Account.AppIconUrl,
+			&i.Account.Username,
+			&i.Account.Email,
+			&i.Account.EncPassword,
+			&i.Account.Memo,
+			&i.Account.PlsDelete,
+			&i.Account.Message,
+			&i.Account.PasserID,
+			&i.Account.IsDisclosed,
+			&i.Account.CustomData,
+			&i.Account.VaultEncPassword,
+			&i.Account.EncryptionMode,
 			&i.TrustID,
-			&i.IsDisclosed,
-			&i.CustomData,
+			&i.Permission,
+			&i.ReceiverEncPassword,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,57 @@
+package query
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// testDB は TEST_DATABASE_URL のデータベースに db/migrations をすべて適用して接続します。
+// public スキーマは作り直すので、捨ててよいデータベースを指定する。指定がなければテストを飛ばす
+func testDB(t *testing.T) *pgx.Conn {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close(ctx) })
+
+	resetSchema := func() error {
+		_, err := conn.Exec(ctx, "DROP SCHEMA public CASCADE; CREATE SCHEMA public")
+		return err
+	}
+	if err := resetSchema(); err != nil {
+		t.Fatalf("failed to reset schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := resetSchema(); err != nil {
+			t.Errorf("failed to reset schema: %v", err)
+		}
+	})
+
+	migrations, err := filepath.Glob("../migrations/*.up.sql")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, migration := range migrations {
+		sql, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", migration, err)
+		}
+		if _, err := conn.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("failed to apply %s: %v", migration, err)
+		}
+	}
+	return conn
+}
@@ -21,11 +21,12 @@ INSERT INTO devices(
                     memo,
                     message,
                     passer_id,
-                    trust_id,
                     is_disclosed,
-                    custom_data)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, false, $10)
-RETURNING id, device_type, device_description, device_username, device_icon_url, enc_password, memo, message, passer_id, trust_id, is_disclosed, custom_data
+                    custom_data,
+                    enc_version,
+                    pls_delete)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false, $9, $10, $11)
+RETURNING id, device_type, device_description, device_username, device_icon_url, enc_password, memo, message, passer_id, is_disclosed, custom_data, enc_version, pls_delete, vault_enc_password
 `
 
 type CreateDeviceParams struct {
@@ -37,8 +38,9 @@ type CreateDeviceParams struct {
 	Memo              string
 	Message           string
 	PasserID          pgtype.UUID
-	TrustID           int32
 	CustomData        []byte
+	EncVersion        int32
+	PlsDelete         bool
 }
 
 func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Device, error) {
@@ -51,8 +53,9 @@ func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Dev
 		arg.Memo,
 		arg.Message,
 		arg.PasserID,
-		arg.TrustID,
 		arg.CustomData,
+		arg.EncVersion,
+		arg.PlsDelete,
 	)
 	var i Device
 	err := row.Scan(
@@ -65,9 +68,11 @@ func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Dev
 		&i.Memo,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.PlsDelete,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
@@ -75,7 +80,7 @@ func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Dev
 const deleteDevice = `-- name: DeleteDevice :one
 DELETE FROM devices
 WHERE id = $1 AND passer_id = $2
-RETURNING id, device_type, device_description, device_username, device_icon_url, enc_password, memo, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, device_type, device_description, device_username, device_icon_url, enc_password, memo, message, passer_id, is_disclosed, custom_data, enc_version, pls_delete, vault_enc_password
 `
 
 type DeleteDeviceParams struct {
@@ -96,29 +101,56 @@ func (q *Queries) DeleteDevice(ctx context.Context, arg DeleteDeviceParams) (Dev
 		&i.Memo,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.PlsDelete,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
 
+const encryptPlaintextDevice = `-- name: EncryptPlaintextDevice :execrows
+UPDATE devices
+SET enc_password = $2,
+    enc_version = $3
+WHERE id = $1 AND enc_version = 0 AND enc_password = $4
+`
+
+type EncryptPlaintextDeviceParams struct {
+	ID            int32
+	EncPassword   []byte
+	EncVersion    int32
+	EncPassword_2 []byte
+}
+
+func (q *Queries) EncryptPlaintextDevice(ctx context.Context, arg EncryptPlaintextDeviceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, encryptPlaintextDevice,
+		arg.ID,
+		arg.EncPassword,
+		arg.EncVersion,
+		arg.EncPassword_2,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const setDeviceDisclosureStatus = `-- name: SetDeviceDisclosureStatus :one
 UPDATE devices
-SET is_disclosed = $2,
-    trust_id = $3
+SET is_disclosed = $2
 WHERE id = $1
-RETURNING id, device_type, device_description, device_username, device_icon_url, enc_password, memo, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, device_type, device_description, device_username, device_icon_url, enc_password, memo, message, passer_id, is_disclosed, custom_data, enc_version, pls_delete, vault_enc_password
 `
 
 type SetDeviceDisclosureStatusParams struct {
 	ID          int32
 	IsDisclosed bool
-	TrustID     int32
 }
 
 func (q *Queries) SetDeviceDisclosureStatus(ctx context.Context, arg SetDeviceDisclosureStatusParams) (Device, error) {
-	row := q.db.QueryRow(ctx, setDeviceDisclosureStatus, arg.ID, arg.IsDisclosed, arg.TrustID)
+	row := q.db.QueryRow(ctx, setDeviceDisclosureStatus, arg.ID, arg.IsDisclosed)
 	var i Device
 	err := row.Scan(
 		&i.ID,
@@ -130,9 +162,11 @@ func (q *Queries) SetDeviceDisclosureStatus(ctx context.Context, arg SetDeviceDi
 		&i.Memo,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.PlsDelete,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
@@ -146,9 +180,11 @@ SET device_type = $2,
     enc_password = $6,
     memo = $7,
     message = $8,
-    custom_data = $9
+    custom_data = $9,
+    enc_version = $10,
+    pls_delete = $11
 WHERE id = $1
-RETURNING id, device_type, device_description, device_username, device_icon_url, enc_password, memo, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, device_type, device_description, device_username, device_icon_url, enc_password, memo, message, passer_id, is_disclosed, custom_data, enc_version, pls_delete, vault_enc_password
 `
 
 type UpdateDeviceParams struct {
@@ -161,6 +197,8 @@ type UpdateDeviceParams struct {
 	Memo              string
 	Message           string
 	CustomData        []byte
+	EncVersion        int32
+	PlsDelete         bool
 }
 
 func (q *Queries) UpdateDevice(ctx context.Context, arg UpdateDeviceParams) (Device, error) {
@@ -174,6 +212,8 @@ func (q *Queries) UpdateDevice(ctx context.Context, arg UpdateDeviceParams) (Dev
 		arg.Memo,
 		arg.Message,
 		arg.CustomData,
+		arg.EncVersion,
+		arg.PlsDelete,
 	)
 	var i Device
 	err := row.Scan(
@@ -186,9 +226,47 @@ func (q *Queries) UpdateDevice(ctx context.Context, arg UpdateDeviceParams) (Dev
 		&i.Memo,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.PlsDelete,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
+
+const updateDeviceEncPassword = `-- name: UpdateDeviceEncPassword :execrows
+UPDATE devices
+SET enc_password = $2
+WHERE id = $1 AND enc_password = $3
+`
+
+type UpdateDeviceEncPasswordParams struct {
+	ID            int32
+	EncPassword   []byte
+	EncPassword_2 []byte
+}
+
+func (q *Queries) UpdateDeviceEncPassword(ctx context.Context, arg UpdateDeviceEncPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateDeviceEncPassword, arg.ID, arg.EncPassword, arg.EncPassword_2)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateDeviceVaultEncPassword = `-- name: UpdateDeviceVaultEncPassword :exec
+UPDATE devices
+SET vault_enc_password = $2
+WHERE id = $1
+`
+
+type UpdateDeviceVaultEncPasswordParams struct {
+	ID               int32
+	VaultEncPassword []byte
+}
+
+func (q *Queries) UpdateDeviceVaultEncPassword(ctx context.Context, arg UpdateDeviceVaultEncPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateDeviceVaultEncPassword, arg.ID, arg.VaultEncPassword)
+	return err
+}
@@ -11,8 +11,21 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countDevicesByPasserId = `-- name: CountDevicesByPasserId :one
+SELECT COUNT(*)
+FROM devices
+WHERE devices.passer_id = $1 AND devices.enc_version > 0
+`
+
+func (q *Queries) CountDevicesByPasserId(ctx context.Context, passerID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countDevicesByPasserId, passerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getDevice = `-- name: GetDevice :one
-SELECT devices.id, devices.device_type, devices.device_description, devices.device_username, devices.device_icon_url, devices.enc_password, devices.memo, devices.message, devices.passer_id, devices.trust_id, devices.is_disclosed, devices.custom_data
+SELECT devices.id, devices.device_type, devices.device_description, devices.device_username, devices.device_icon_url, devices.enc_password, devices.memo, devices.message, devices.passer_id, devices.is_disclosed, devices.custom_data, devices.enc_version, devices.pls_delete, devices.vault_enc_password
 FROM devices
 WHERE devices.id = $1
 `
@@ -30,15 +43,17 @@ func (q *Queries) GetDevice(ctx context.Context, id int32) (Device, error) {
 		&i.Memo,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.PlsDelete,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
 
 const listDevicesByPasserId = `-- name: ListDevicesByPasserId :many
-SELECT devices.id, devices.device_type, devices.device_description, devices.device_username, devices.device_icon_url, devices.enc_password, devices.memo, devices.message, devices.passer_id, devices.trust_id, devices.is_disclosed, devices.custom_data
+SELECT devices.id, devices.device_type, devices.device_description, devices.device_username, devices.device_icon_url, devices.enc_password, devices.memo, devices.message, devices.passer_id, devices.is_disclosed, devices.custom_data, devices.enc_version, devices.pls_delete, devices.vault_enc_password
 FROM devices
 WHERE devices.passer_id = $1
 ORDER BY devices.id DESC
@@ -63,9 +78,111 @@ func (q *Queries) ListDevicesByPasserId(ctx context.Context, passerID pgtype.UUI
 			&i.Memo,
 			&i.Message,
 			&i.PasserID,
-			&i.TrustID,
 			&i.IsDisclosed,
 			&i.CustomData,
+			&i.EncVersion,
+			&i.PlsDelete,
+			&i.VaultEncPassword,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDevicesByPasserIdAndReceiverId = `-- name: ListDevicesByPasserIdAndReceiverId :many
+SELECT devices.id, devices.device_type, devices.device_description, devices.device_username, devices.device_icon_url, devices.enc_password, devices.memo, devices.message, devices.passer_id, devices.is_disclosed, devices.custom_data, devices.enc_version, devices.pls_delete, devices.vault_enc_password, da.trust_id, da.permission
+FROM devices
+JOIN device_assignments da ON da.device_id = devices.id
+JOIN trusts t ON da.trust_id = t.id
+WHERE devices.passer_id = $1 AND t.receiver_user_id = $2 AND t.passer_user_id = devices.passer_id
+  AND NOT da.is_disclosed
+ORDER BY devices.id, da.trust_id
+`
+
+type ListDevicesByPasserIdAndReceiverIdParams struct {
+	PasserID       pgtype.UUID
+	ReceiverUserID pgtype.UUID
+}
+
+type ListDevicesByPasserIdAndReceiverIdRow struct {
+	Device     Device
+	TrustID    int32
+	Permission string
+}
+
+func (q *Queries) ListDevicesByPasserIdAndReceiverId(ctx context.Context, arg ListDevicesByPasserIdAndReceiverIdParams) ([]ListDevicesByPasserIdAndReceiverIdRow, error) {
+	rows, err := q.db.Query(ctx, listDevicesByPasserIdAndReceiverId, arg.PasserID, arg.ReceiverUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDevicesByPasserIdAndReceiverIdRow
+	for rows.Next() {
+		var i ListDevicesByPasserIdAndReceiverIdRow
+		if err := rows.Scan(
+			&i.Device.ID,
+			&i.Device.DeviceType,
+			&i.Device.DeviceDescription,
+			&i.Device.DeviceUsername,
+			&i.Device.DeviceIconUrl,
+			&i.Device.EncPassword,
+			&i.Device.Memo,
+			&i.Device.Message,
+			&i.Device.PasserID,
+			&i.Device.IsDisclosed,
+			&i.Device.CustomData,
+			&i.Device.EncVersion,
+			&i.Device.PlsDelete,
+			&i.Device.VaultEncPassword,
+			&i.TrustID,
+			&i.Permission,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDevicesForKeyRotation = `-- name: ListDevicesForKeyRotation :many
+SELECT devices.id, devices.enc_password
+FROM devices
+WHERE devices.passer_id = $1 AND devices.id > $2 AND devices.enc_version > 0
+ORDER BY devices.id
+LIMIT $3
+`
+
+type ListDevicesForKeyRotationParams struct {
+	PasserID pgtype.UUID
+	ID       int32
+	Limit    int32
+}
+
+type ListDevicesForKeyRotationRow struct {
+	ID          int32
+	EncPassword []byte
+}
+
+func (q *Queries) ListDevicesForKeyRotation(ctx context.Context, arg ListDevicesForKeyRotationParams) ([]ListDevicesForKeyRotationRow, error) {
+	rows, err := q.db.Query(ctx, listDevicesForKeyRotation, arg.PasserID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDevicesForKeyRotationRow
+	for rows.Next() {
+		var i ListDevicesForKeyRotationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EncPassword,
 		); err != nil {
 			return nil, err
 		}
@@ -78,34 +195,91 @@ func (q *Queries) ListDevicesByPasserId(ctx context.Context, passerID pgtype.UUI
 }
 
 const listDisclosedDevicesByReceiverId = `-- name: ListDisclosedDevicesByReceiverId :many
-SELECT devices.id, devices.device_type, devices.device_description, devices.device_username, devices.device_icon_url, devices.enc_password, devices.memo, devices.message, devices.passer_id, devices.trust_id, devices.is_disclosed, devices.custom_data
+SELECT DISTINCT ON (devices.passer_id, devices.id) devices.id, devices.device_type, devices.device_description, devices.device_username, devices.device_icon_url, devices.enc_password, devices.memo, devices.message, devices.passer_id, devices.is_disclosed, devices.custom_data, devices.enc_version, devices.pls_delete, devices.vault_enc_password, da.trust_id, da.permission, da.receiver_enc_password
 FROM devices
-JOIN trusts t ON devices.trust_id = t.id
-WHERE t.receiver_user_id = $1 AND devices.is_disclosed = true
+JOIN device_assignments da ON da.device_id = devices.id
+JOIN trusts t ON da.trust_id = t.id
+WHERE t.receiver_user_id = $1 AND t.passer_user_id = devices.passer_id AND da.is_disclosed = true
+ORDER BY devices.passer_id, devices.id, da.permission = 'credentials' DESC, da.trust_id
 `
 
-func (q *Queries) ListDisclosedDevicesByReceiverId(ctx context.Context, receiverUserID pgtype.UUID) ([]Device, error) {
+type ListDisclosedDevicesByReceiverIdRow struct {
+	Device              Device
+	TrustID             int32
+	Permission          string
+	ReceiverEncPassword []byte
+}
+
+func (q *Queries) ListDisclosedDevicesByReceiverId(ctx context.Context, receiverUserID pgtype.UUID) ([]ListDisclosedDevicesByReceiverIdRow, error) {
 	rows, err := q.db.Query(ctx, listDisclosedDevicesByReceiverId, receiverUserID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Device
+	var items []ListDisclosedDevicesByReceiverIdRow
 	for rows.Next() {
-		var i Device
+		var i ListDisclosedDevicesByReceiverIdRow
+		if err := rows.Scan(
+			&i.Device.ID,
+			&i.Device.DeviceType,
+			&i.Device.DeviceDescription,
+			&i.Device.DeviceUsername,
+			&i.Device.DeviceIconUrl,
+			&i.Device.EncPassword,
+			&i.Device.Memo,
+			&i.Device.Message,
+			&i.Device.PasserID,
+			&i.Device.IsDisclosed,
+			&i.Device.CustomData,
+			&i.Device.EncVersion,
+			&i.Device.PlsDelete,
+			&i.Device.VaultEncPassword,
+			&i.TrustID,
+			&i.Permission,
+			&i.ReceiverEncPassword,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaintextDevices = `-- name: ListPlaintextDevices :many
+SELECT devices.id, devices.passer_id, devices.enc_password
+FROM devices
+WHERE devices.enc_version = 0 AND devices.id > $1
+ORDER BY devices.id
+LIMIT $2
+`
+
+type ListPlaintextDevicesParams struct {
+	ID    int32
+	Limit int32
+}
+
+type ListPlaintextDevicesRow struct {
+	ID          int32
+	PasserID    pgtype.UUID
+	EncPassword []byte
+}
+
+func (q *Queries) ListPlaintextDevices(ctx context.Context, arg ListPlaintextDevicesParams) ([]ListPlaintextDevicesRow, error) {
+	rows, err := q.db.Query(ctx, listPlaintextDevices, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPlaintextDevicesRow
+	for rows.Next() {
+		var i ListPlaintextDevicesRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.DeviceType,
-			&i.DeviceDescription,
-			&i.DeviceUsername,
-			&i.DeviceIconUrl,
-			&i.EncPassword,
-			&i.Memo,
-			&i.Message,
 			&i.PasserID,
-			&i.TrustID,
-			&i.IsDisclosed,
-			&i.CustomData,
+			&i.EncPassword,
 		); err != nil {
 			return nil, err
 		}
@@ -12,15 +12,16 @@ import (
 )
 
 const createDisclosure = `-- name: CreateDisclosure :one
-INSERT INTO disclosures(requester_id,
-                        passer_id,
-                        issued_time,
-                        deadline,
-                        custom_data,
-                        disclosed,
-                        in_progress)
-VALUES ($1, $2, $3, $4, $5, false, true)
-RETURNING id, requester_id, passer_id, issued_time, in_progress, disclosed, disclosed_at, prevented_by, deadline, custom_data
+WITH created AS (
+    INSERT INTO disclosures (requester_id, passer_id, issued_time, deadline, custom_data, status)
+        VALUES ($1, $2, $3, $4, $5, 'requested')
+        RETURNING id, requester_id, passer_id, issued_time, disclosed_at, prevented_by, deadline, custom_data, status, status_changed_at),
+     logged AS (
+         INSERT INTO disclosure_transitions (disclosure_id, from_status, to_status, actor, actor_user_id)
+             SELECT created.id, NULL, created.status, $6::text, $7::uuid
+             FROM created)
+SELECT created.id, created.requester_id, created.passer_id, created.issued_time, created.disclosed_at, created.prevented_by, created.deadline, created.custom_data, created.status, created.status_changed_at
+FROM created
 `
 
 type CreateDisclosureParams struct {
@@ -29,6 +30,8 @@ type CreateDisclosureParams struct {
 	IssuedTime  pgtype.Timestamp
 	Deadline    pgtype.Timestamp
 	CustomData  []byte
+	Actor       string
+	ActorUserID pgtype.UUID
 }
 
 func (q *Queries) CreateDisclosure(ctx context.Context, arg CreateDisclosureParams) (Disclosure, error) {
@@ -38,6 +41,8 @@ func (q *Queries) CreateDisclosure(ctx context.Context, arg CreateDisclosurePara
 		arg.IssuedTime,
 		arg.Deadline,
 		arg.CustomData,
+		arg.Actor,
+		arg.ActorUserID,
 	)
 	var i Disclosure
 	err := row.Scan(
@@ -45,79 +50,91 @@ func (q *Queries) CreateDisclosure(ctx context.Context, arg CreateDisclosurePara
 		&i.RequesterID,
 		&i.PasserID,
 		&i.IssuedTime,
-		&i.InProgress,
-		&i.Disclosed,
 		&i.DisclosedAt,
 		&i.PreventedBy,
 		&i.Deadline,
 		&i.CustomData,
+		&i.Status,
+		&i.StatusChangedAt,
 	)
 	return i, err
 }
 
-const deleteDisclosure = `-- name: DeleteDisclosure :one
-DELETE FROM disclosures
-WHERE id = $1 AND requester_id = $2
-RETURNING id, requester_id, passer_id, issued_time, in_progress, disclosed, disclosed_at, prevented_by, deadline, custom_data
+const transitionDisclosure = `-- name: TransitionDisclosure :one
+WITH updated AS (
+    UPDATE disclosures
+        SET status = $1::text,
+            status_changed_at = NOW(),
+            disclosed_at = CASE WHEN $1::text = 'disclosed' THEN NOW() ELSE disclosures.disclosed_at END,
+            prevented_by = COALESCE($2::uuid, disclosures.prevented_by)
+        WHERE disclosures.id = $3
+            AND disclosures.status = $4::text
+        RETURNING id, requester_id, passer_id, issued_time, disclosed_at, prevented_by, deadline, custom_data, status, status_changed_at),
+     logged AS (
+         INSERT INTO disclosure_transitions (disclosure_id, from_status, to_status, actor, actor_user_id)
+             SELECT updated.id, $4::text, updated.status, $5::text, $6::uuid
+             FROM updated)
+SELECT updated.id, updated.requester_id, updated.passer_id, updated.issued_time, updated.disclosed_at, updated.prevented_by, updated.deadline, updated.custom_data, updated.status, updated.status_changed_at
+FROM updated
 `
 
-type DeleteDisclosureParams struct {
+type TransitionDisclosureParams struct {
+	ToStatus    string
+	PreventedBy pgtype.UUID
 	ID          int32
-	RequesterID pgtype.UUID
+	FromStatus  string
+	Actor       string
+	ActorUserID pgtype.UUID
 }
 
-func (q *Queries) DeleteDisclosure(ctx context.Context, arg DeleteDisclosureParams) (Disclosure, error) {
-	row := q.db.QueryRow(ctx, deleteDisclosure, arg.ID, arg.RequesterID)
+func (q *Queries) TransitionDisclosure(ctx context.Context, arg TransitionDisclosureParams) (Disclosure, error) {
+	row := q.db.QueryRow(ctx, transitionDisclosure,
+		arg.ToStatus,
+		arg.PreventedBy,
+		arg.ID,
+		arg.FromStatus,
+		arg.Actor,
+		arg.ActorUserID,
+	)
 	var i Disclosure
 	err := row.Scan(
 		&i.ID,
 		&i.RequesterID,
 		&i.PasserID,
 		&i.IssuedTime,
-		&i.InProgress,
-		&i.Disclosed,
 		&i.DisclosedAt,
 		&i.PreventedBy,
 		&i.Deadline,
 		&i.CustomData,
+		&i.Status,
+		&i.StatusChangedAt,
 	)
 	return i, err
 }
 
-const updateDisclosure = `-- name: UpdateDisclosure :one
+const updateDisclosureDetails = `-- name: UpdateDisclosureDetails :one
 UPDATE disclosures
-SET requester_id = $2,
-    passer_id = $3,
-    disclosed = $4,
-    deadline = $5,
-    prevented_by = $6,
-    custom_data = $7,
-    in_progress = $8
-WHERE id = $1 AND requester_id = $2
-RETURNING id, requester_id, passer_id, issued_time, in_progress, disclosed, disclosed_at, prevented_by, deadline, custom_data
+SET deadline = $3,
+    custom_data = $4
+WHERE disclosures.id = $1
+  AND disclosures.requester_id = $2
+  AND disclosures.status IN ('requested', 'notifying', 'grace')
+RETURNING id, requester_id, passer_id, issued_time, disclosed_at, prevented_by, deadline, custom_data, status, status_changed_at
 `
 
-type UpdateDisclosureParams struct {
+type UpdateDisclosureDetailsParams struct {
 	ID          int32
 	RequesterID pgtype.UUID
-	PasserID    pgtype.UUID
-	Disclosed   bool
 	Deadline    pgtype.Timestamp
-	PreventedBy pgtype.UUID
 	CustomData  []byte
-	InProgress  bool
 }
 
-func (q *Queries) UpdateDisclosure(ctx context.Context, arg UpdateDisclosureParams) (Disclosure, error) {
-	row := q.db.QueryRow(ctx, updateDisclosure,
+func (q *Queries) UpdateDisclosureDetails(ctx context.Context, arg UpdateDisclosureDetailsParams) (Disclosure, error) {
+	row := q.db.QueryRow(ctx, updateDisclosureDetails,
 		arg.ID,
 		arg.RequesterID,
-		arg.PasserID,
-		arg.Disclosed,
 		arg.Deadline,
-		arg.PreventedBy,
 		arg.CustomData,
-		arg.InProgress,
 	)
 	var i Disclosure
 	err := row.Scan(
@@ -125,12 +142,12 @@ func (q *Queries) UpdateDisclosure(ctx context.Context, arg UpdateDisclosurePara
 		&i.RequesterID,
 		&i.PasserID,
 		&i.IssuedTime,
-		&i.InProgress,
-		&i.Disclosed,
 		&i.DisclosedAt,
 		&i.PreventedBy,
 		&i.Deadline,
 		&i.CustomData,
+		&i.Status,
+		&i.StatusChangedAt,
 	)
 	return i, err
 }
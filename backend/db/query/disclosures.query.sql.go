@@ -12,7 +12,7 @@ import (
 )
 
 const getDisclosure = `-- name: GetDisclosure :one
-SELECT id, requester_id, passer_id, issued_time, in_progress, disclosed, disclosed_at, prevented_by, deadline, custom_data FROM disclosures WHERE id = $1
+SELECT id, requester_id, passer_id, issued_time, disclosed_at, prevented_by, deadline, custom_data, status, status_changed_at FROM disclosures WHERE id = $1
 `
 
 func (q *Queries) GetDisclosure(ctx context.Context, id int32) (Disclosure, error) {
@@ -23,18 +23,144 @@ func (q *Queries) GetDisclosure(ctx context.Context, id int32) (Disclosure, erro
 		&i.RequesterID,
 		&i.PasserID,
 		&i.IssuedTime,
-		&i.InProgress,
-		&i.Disclosed,
 		&i.DisclosedAt,
 		&i.PreventedBy,
 		&i.Deadline,
 		&i.CustomData,
+		&i.Status,
+		&i.StatusChangedAt,
 	)
 	return i, err
 }
 
+const getOpenDisclosure = `-- name: GetOpenDisclosure :one
+SELECT disclosures.id, disclosures.requester_id, disclosures.passer_id, disclosures.issued_time, disclosures.disclosed_at, disclosures.prevented_by, disclosures.deadline, disclosures.custom_data, disclosures.status, disclosures.status_changed_at
+FROM disclosures
+WHERE disclosures.requester_id = $1
+  AND disclosures.passer_id = $2
+  AND disclosures.status IN ('requested', 'notifying', 'grace')
+ORDER BY disclosures.id DESC
+LIMIT 1
+`
+
+type GetOpenDisclosureParams struct {
+	RequesterID pgtype.UUID
+	PasserID    pgtype.UUID
+}
+
+func (q *Queries) GetOpenDisclosure(ctx context.Context, arg GetOpenDisclosureParams) (Disclosure, error) {
+	row := q.db.QueryRow(ctx, getOpenDisclosure, arg.RequesterID, arg.PasserID)
+	var i Disclosure
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterID,
+		&i.PasserID,
+		&i.IssuedTime,
+		&i.DisclosedAt,
+		&i.PreventedBy,
+		&i.Deadline,
+		&i.CustomData,
+		&i.Status,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const hasCompletedDisclosure = `-- name: HasCompletedDisclosure :one
+SELECT EXISTS (SELECT 1
+               FROM disclosures
+               WHERE disclosures.passer_id = $1
+                 AND disclosures.requester_id = $2
+                 AND disclosures.status = 'disclosed')
+`
+
+type HasCompletedDisclosureParams struct {
+	PasserID    pgtype.UUID
+	RequesterID pgtype.UUID
+}
+
+func (q *Queries) HasCompletedDisclosure(ctx context.Context, arg HasCompletedDisclosureParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasCompletedDisclosure, arg.PasserID, arg.RequesterID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listDisclosureTransitionsByDisclosureId = `-- name: ListDisclosureTransitionsByDisclosureId :many
+SELECT id, disclosure_id, from_status, to_status, actor, actor_user_id, created_at
+FROM disclosure_transitions
+WHERE disclosure_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListDisclosureTransitionsByDisclosureId(ctx context.Context, disclosureID int32) ([]DisclosureTransition, error) {
+	rows, err := q.db.Query(ctx, listDisclosureTransitionsByDisclosureId, disclosureID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DisclosureTransition
+	for rows.Next() {
+		var i DisclosureTransition
+		if err := rows.Scan(
+			&i.ID,
+			&i.DisclosureID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Actor,
+			&i.ActorUserID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDisclosuresByPasserId = `-- name: ListDisclosuresByPasserId :many
+SELECT disclosures.id, disclosures.requester_id, disclosures.passer_id, disclosures.issued_time, disclosures.disclosed_at, disclosures.prevented_by, disclosures.deadline, disclosures.custom_data, disclosures.status, disclosures.status_changed_at
+FROM disclosures
+WHERE disclosures.passer_id = $1
+ORDER BY disclosures.id
+`
+
+func (q *Queries) ListDisclosuresByPasserId(ctx context.Context, passerID pgtype.UUID) ([]Disclosure, error) {
+	rows, err := q.db.Query(ctx, listDisclosuresByPasserId, passerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Disclosure
+	for rows.Next() {
+		var i Disclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequesterID,
+			&i.PasserID,
+			&i.IssuedTime,
+			&i.DisclosedAt,
+			&i.PreventedBy,
+			&i.Deadline,
+			&i.CustomData,
+			&i.Status,
+			&i.StatusChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDisclosuresByRequesterId = `-- name: ListDisclosuresByRequesterId :many
-SELECT id, requester_id, passer_id, issued_time, in_progress, disclosed, disclosed_at, prevented_by, deadline, custom_data FROM disclosures WHERE requester_id = $1
+SELECT id, requester_id, passer_id, issued_time, disclosed_at, prevented_by, deadline, custom_data, status, status_changed_at FROM disclosures WHERE requester_id = $1
 `
 
 func (q *Queries) ListDisclosuresByRequesterId(ctx context.Context, requesterID pgtype.UUID) ([]Disclosure, error) {
@@ -51,12 +177,12 @@ func (q *Queries) ListDisclosuresByRequesterId(ctx context.Context, requesterID
 			&i.RequesterID,
 			&i.PasserID,
 			&i.IssuedTime,
-			&i.InProgress,
-			&i.Disclosed,
 			&i.DisclosedAt,
 			&i.PreventedBy,
 			&i.Deadline,
 			&i.CustomData,
+			&i.Status,
+			&i.StatusChangedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -67,3 +193,192 @@ func (q *Queries) ListDisclosuresByRequesterId(ctx context.Context, requesterID
 	}
 	return items, nil
 }
+
+const listMaturedDisclosuresByPasserId = `-- name: ListMaturedDisclosuresByPasserId :many
+SELECT disclosures.id, disclosures.requester_id, disclosures.passer_id, disclosures.issued_time, disclosures.disclosed_at, disclosures.prevented_by, disclosures.deadline, disclosures.custom_data, disclosures.status, disclosures.status_changed_at
+FROM disclosures
+WHERE disclosures.passer_id = $1
+  AND disclosures.deadline <= NOW()
+  AND disclosures.status IN ('requested', 'notifying', 'grace', 'disclosed')
+ORDER BY disclosures.id
+`
+
+func (q *Queries) ListMaturedDisclosuresByPasserId(ctx context.Context, passerID pgtype.UUID) ([]Disclosure, error) {
+	rows, err := q.db.Query(ctx, listMaturedDisclosuresByPasserId, passerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Disclosure
+	for rows.Next() {
+		var i Disclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequesterID,
+			&i.PasserID,
+			&i.IssuedTime,
+			&i.DisclosedAt,
+			&i.PreventedBy,
+			&i.Deadline,
+			&i.CustomData,
+			&i.Status,
+			&i.StatusChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOverdueDisclosures = `-- name: ListOverdueDisclosures :many
+SELECT disclosures.id, disclosures.requester_id, disclosures.passer_id, disclosures.issued_time, disclosures.disclosed_at, disclosures.prevented_by, disclosures.deadline, disclosures.custom_data, disclosures.status, disclosures.status_changed_at
+FROM disclosures
+WHERE disclosures.status IN ('requested', 'notifying', 'grace')
+  AND disclosures.deadline <= NOW()
+  AND (disclosures.passer_id, disclosures.id) > ($1, $2)
+ORDER BY disclosures.passer_id, disclosures.id
+LIMIT $3
+`
+
+type ListOverdueDisclosuresParams struct {
+	PasserID pgtype.UUID
+	ID       int32
+	Limit    int32
+}
+
+func (q *Queries) ListOverdueDisclosures(ctx context.Context, arg ListOverdueDisclosuresParams) ([]Disclosure, error) {
+	rows, err := q.db.Query(ctx, listOverdueDisclosures, arg.PasserID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Disclosure
+	for rows.Next() {
+		var i Disclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequesterID,
+			&i.PasserID,
+			&i.IssuedTime,
+			&i.DisclosedAt,
+			&i.PreventedBy,
+			&i.Deadline,
+			&i.CustomData,
+			&i.Status,
+			&i.StatusChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingDisclosures = `-- name: ListPendingDisclosures :many
+SELECT disclosures.id, disclosures.requester_id, disclosures.passer_id, disclosures.issued_time, disclosures.disclosed_at, disclosures.prevented_by, disclosures.deadline, disclosures.custom_data, disclosures.status, disclosures.status_changed_at
+FROM disclosures
+WHERE disclosures.status IN ('requested', 'notifying', 'grace')
+  AND disclosures.deadline > NOW()
+  AND disclosures.id > $1
+ORDER BY disclosures.id
+LIMIT $2
+`
+
+type ListPendingDisclosuresParams struct {
+	ID    int32
+	Limit int32
+}
+
+func (q *Queries) ListPendingDisclosures(ctx context.Context, arg ListPendingDisclosuresParams) ([]Disclosure, error) {
+	rows, err := q.db.Query(ctx, listPendingDisclosures, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Disclosure
+	for rows.Next() {
+		var i Disclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequesterID,
+			&i.PasserID,
+			&i.IssuedTime,
+			&i.DisclosedAt,
+			&i.PreventedBy,
+			&i.Deadline,
+			&i.CustomData,
+			&i.Status,
+			&i.StatusChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnreleasedDisclosures = `-- name: ListUnreleasedDisclosures :many
+SELECT DISTINCT ON (disclosures.passer_id, disclosures.requester_id) disclosures.id, disclosures.requester_id, disclosures.passer_id, disclosures.issued_time, disclosures.disclosed_at, disclosures.prevented_by, disclosures.deadline, disclosures.custom_data, disclosures.status, disclosures.status_changed_at
+FROM disclosures
+JOIN trusts t ON t.passer_user_id = disclosures.passer_id AND t.receiver_user_id = disclosures.requester_id
+WHERE disclosures.status = 'disclosed'
+  AND (EXISTS (SELECT 1 FROM account_assignments aa WHERE aa.trust_id = t.id AND NOT aa.is_disclosed)
+    OR EXISTS (SELECT 1 FROM device_assignments da WHERE da.trust_id = t.id AND NOT da.is_disclosed)
+    OR EXISTS (SELECT 1 FROM subscription_assignments sa WHERE sa.trust_id = t.id AND NOT sa.is_disclosed))
+ORDER BY disclosures.passer_id, disclosures.requester_id, disclosures.id
+LIMIT $1
+`
+
+func (q *Queries) ListUnreleasedDisclosures(ctx context.Context, limit int32) ([]Disclosure, error) {
+	rows, err := q.db.Query(ctx, listUnreleasedDisclosures, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Disclosure
+	for rows.Next() {
+		var i Disclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequesterID,
+			&i.PasserID,
+			&i.IssuedTime,
+			&i.DisclosedAt,
+			&i.PreventedBy,
+			&i.Deadline,
+			&i.CustomData,
+			&i.Status,
+			&i.StatusChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tryLockPasserDisclosures = `-- name: TryLockPasserDisclosures :one
+SELECT pg_try_advisory_xact_lock(hashtextextended('disclosures:' || users.id::text, 0))
+FROM users
+WHERE users.id = $1
+`
+
+func (q *Queries) TryLockPasserDisclosures(ctx context.Context, id pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, tryLockPasserDisclosures, id)
+	var pg_try_advisory_xact_lock bool
+	err := row.Scan(&pg_try_advisory_xact_lock)
+	return pg_try_advisory_xact_lock, err
+}
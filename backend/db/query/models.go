@@ -9,21 +9,31 @@ import (
 )
 
 type Account struct {
-	ID             int32
-	AppTemplateID  pgtype.Int4
-	AppName        pgtype.Text
-	AppDescription pgtype.Text
-	AppIconUrl     pgtype.Text
-	Username       string
-	Email          string
-	EncPassword    []byte
-	Memo           string
-	PlsDelete      bool
-	Message        string
-	PasserID       pgtype.UUID
-	TrustID        int32
-	IsDisclosed    bool
-	CustomData     []byte
+	ID               int32
+	AppTemplateID    pgtype.Int4
+	AppName          pgtype.Text
+	AppDescription   pgtype.Text
+	AppIconUrl       pgtype.Text
+	Username         string
+	Email            string
+	EncPassword      []byte
+	Memo             string
+	PlsDelete        bool
+	Message          string
+	PasserID         pgtype.UUID
+	IsDisclosed      bool
+	CustomData       []byte
+	VaultEncPassword []byte
+	EncryptionMode   string
+}
+
+type AccountAssignment struct {
+	AccountID           int32
+	TrustID             int32
+	Permission          string
+	IsDisclosed         bool
+	ReceiverEncPassword []byte
+	CreatedAt           pgtype.Timestamp
 }
 
 type AliveCheckHistory struct {
@@ -36,6 +46,40 @@ type AliveCheckHistory struct {
 	CustomData       []byte
 }
 
+type AliveCheckSchedule struct {
+	UserID           pgtype.UUID
+	IntervalDays     int32
+	GraceDays        int32
+	Enabled          bool
+	NextCheckAt      pgtype.Timestamp
+	SentAt           pgtype.Timestamp
+	LastConfirmedAt  pgtype.Timestamp
+	LastMissedAt     pgtype.Timestamp
+	LastDisclosureID pgtype.Int4
+	CreatedAt        pgtype.Timestamp
+	UpdatedAt        pgtype.Timestamp
+}
+
+type AliveCheckToken struct {
+	TokenHash    []byte
+	UserID       pgtype.UUID
+	Purpose      string
+	DisclosureID pgtype.Int4
+	ExpiresAt    pgtype.Timestamp
+	UsedAt       pgtype.Timestamp
+	SupersededAt pgtype.Timestamp
+	RevokedAt    pgtype.Timestamp
+	CreatedAt    pgtype.Timestamp
+}
+
+type ClientPublicKey struct {
+	UserID    pgtype.UUID
+	Algorithm string
+	PublicKey []byte
+	CreatedAt pgtype.Timestamp
+	UpdatedAt pgtype.Timestamp
+}
+
 type Device struct {
 	ID                int32
 	DeviceType        int32
@@ -46,22 +90,85 @@ type Device struct {
 	Memo              string
 	Message           string
 	PasserID          pgtype.UUID
-	TrustID           int32
 	IsDisclosed       bool
 	CustomData        []byte
+	EncVersion        int32
+	PlsDelete         bool
+	VaultEncPassword  []byte
+}
+
+type DeviceAssignment struct {
+	DeviceID            int32
+	TrustID             int32
+	Permission          string
+	IsDisclosed         bool
+	ReceiverEncPassword []byte
+	CreatedAt           pgtype.Timestamp
 }
 
 type Disclosure struct {
-	ID          int32
-	RequesterID pgtype.UUID
-	PasserID    pgtype.UUID
-	IssuedTime  pgtype.Timestamp
-	InProgress  bool
-	Disclosed   bool
-	DisclosedAt pgtype.Timestamp
-	PreventedBy pgtype.UUID
-	Deadline    pgtype.Timestamp
-	CustomData  []byte
+	ID              int32
+	RequesterID     pgtype.UUID
+	PasserID        pgtype.UUID
+	IssuedTime      pgtype.Timestamp
+	DisclosedAt     pgtype.Timestamp
+	PreventedBy     pgtype.UUID
+	Deadline        pgtype.Timestamp
+	CustomData      []byte
+	Status          string
+	StatusChangedAt pgtype.Timestamp
+}
+
+type DisclosurePolicy struct {
+	PasserID     pgtype.UUID
+	Threshold    int32
+	ShareCount   int32
+	VaultVersion int32
+	UnlockedAt   pgtype.Timestamp
+	CreatedAt    pgtype.Timestamp
+	UpdatedAt    pgtype.Timestamp
+	ReceiverIds  []pgtype.UUID
+}
+
+type DisclosureReminder struct {
+	DisclosureID int32
+	Step         int32
+	Channel      string
+	SentAt       pgtype.Timestamp
+}
+
+type DisclosureTransition struct {
+	ID           int32
+	DisclosureID int32
+	FromStatus   pgtype.Text
+	ToStatus     string
+	Actor        string
+	ActorUserID  pgtype.UUID
+	CreatedAt    pgtype.Timestamp
+}
+
+type KeyRotationJob struct {
+	ID             int32
+	UserID         pgtype.UUID
+	KeyVersion     int32
+	Status         string
+	Phase          string
+	LastItemID     int32
+	TotalItems     int32
+	ProcessedItems int32
+	FailedItems    int32
+	LastError      pgtype.Text
+	CreatedAt      pgtype.Timestamp
+	UpdatedAt      pgtype.Timestamp
+	CompletedAt    pgtype.Timestamp
+}
+
+type NotificationChannel struct {
+	UserID    pgtype.UUID
+	Channel   string
+	Address   string
+	CreatedAt pgtype.Timestamp
+	UpdatedAt pgtype.Timestamp
 }
 
 type Passkey struct {
@@ -75,23 +182,39 @@ type Passkey struct {
 	SignCount    int64
 }
 
+type ReminderPlan struct {
+	UserID       pgtype.UUID
+	OffsetsHours []int32
+	UpdatedAt    pgtype.Timestamp
+}
+
 type Subscription struct {
-	ID           int32
-	ServiceName  pgtype.Text
-	IconUrl      pgtype.Text
-	Username     string
-	Email        string
-	EncPassword  []byte
-	Amount       int32
-	Currency     string
-	BillingCycle string
-	Memo         string
-	PlsDelete    bool
-	Message      string
-	PasserID     pgtype.UUID
-	TrustID      int32
-	IsDisclosed  bool
-	CustomData   []byte
+	ID               int32
+	ServiceName      pgtype.Text
+	IconUrl          pgtype.Text
+	Username         string
+	Email            string
+	EncPassword      []byte
+	Amount           int32
+	Currency         string
+	BillingCycle     string
+	Memo             string
+	PlsDelete        bool
+	Message          string
+	PasserID         pgtype.UUID
+	IsDisclosed      bool
+	CustomData       []byte
+	EncVersion       int32
+	VaultEncPassword []byte
+}
+
+type SubscriptionAssignment struct {
+	SubscriptionID      int32
+	TrustID             int32
+	Permission          string
+	IsDisclosed         bool
+	ReceiverEncPassword []byte
+	CreatedAt           pgtype.Timestamp
 }
 
 type Trust struct {
@@ -100,8 +223,37 @@ type Trust struct {
 	PasserUserID   pgtype.UUID
 }
 
+type TrustInvitation struct {
+	ID             int32
+	PasserUserID   pgtype.UUID
+	Email          string
+	TokenHash      []byte
+	Status         string
+	ReceiverUserID pgtype.UUID
+	TrustID        pgtype.Int4
+	ExpiresAt      pgtype.Timestamp
+	RespondedAt    pgtype.Timestamp
+	CreatedAt      pgtype.Timestamp
+}
+
 type User struct {
 	ID                pgtype.UUID
 	DefaultReceiverID pgtype.UUID
 	ClerkUserID       string
 }
+
+type ZkAccountSecret struct {
+	AccountID        int32
+	Format           string
+	Ciphertext       []byte
+	OwnerKeyEnvelope []byte
+	CreatedAt        pgtype.Timestamp
+	UpdatedAt        pgtype.Timestamp
+}
+
+type ZkKeyEnvelope struct {
+	AccountID      int32
+	ReceiverUserID pgtype.UUID
+	Envelope       []byte
+	CreatedAt      pgtype.Timestamp
+}
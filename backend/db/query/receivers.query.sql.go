@@ -11,6 +11,34 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const isReceiverOfPasser = `-- name: IsReceiverOfPasser :one
+SELECT EXISTS (SELECT 1
+               FROM trusts
+               WHERE trusts.passer_user_id = $1
+                 AND trusts.receiver_user_id = $2
+                 -- 招待から作られ、まだ承諾されていない相続関係は数えない
+                 AND NOT EXISTS (SELECT 1
+                                 FROM trust_invitations
+                                 WHERE trust_invitations.trust_id = trusts.id
+                                   AND trust_invitations.status = 'pending'))
+           OR EXISTS (SELECT 1
+                      FROM users
+                      WHERE users.id = $1
+                        AND users.default_receiver_id = $2) AS is_receiver
+`
+
+type IsReceiverOfPasserParams struct {
+	PasserUserID   pgtype.UUID
+	ReceiverUserID pgtype.UUID
+}
+
+func (q *Queries) IsReceiverOfPasser(ctx context.Context, arg IsReceiverOfPasserParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isReceiverOfPasser, arg.PasserUserID, arg.ReceiverUserID)
+	var isReceiver bool
+	err := row.Scan(&isReceiver)
+	return isReceiver, err
+}
+
 const listReceiversByUserId = `-- name: ListReceiversByUserId :many
 SELECT trusts.id as trust_id, users.clerk_user_id, users.id AS user_id 
 FROM trusts
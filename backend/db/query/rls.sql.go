@@ -10,10 +10,27 @@ import (
 )
 
 const assumeUserID = `-- name: AssumeUserID :exec
-SET LOCAL digi_baton.current_user_id = $1
+SELECT set_config('digi_baton.current_user_id', $1::text, true)
 `
 
-func (q *Queries) AssumeUserID(ctx context.Context, dollar_1 interface{}) error {
-	_, err := q.db.Exec(ctx, assumeUserID, dollar_1)
+func (q *Queries) AssumeUserID(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, assumeUserID, userID)
 	return err
 }
+
+const bypassesRowLevelSecurity = `-- name: BypassesRowLevelSecurity :one
+SELECT roles.rolsuper
+           OR roles.rolbypassrls
+           OR pg_has_role(roles.oid, tables.relowner, 'MEMBER') AS bypasses
+FROM pg_catalog.pg_roles roles,
+     pg_catalog.pg_class tables
+WHERE roles.rolname = current_user
+  AND tables.oid = 'public.accounts'::regclass
+`
+
+func (q *Queries) BypassesRowLevelSecurity(ctx context.Context) (bool, error) {
+	row := q.db.QueryRow(ctx, bypassesRowLevelSecurity)
+	var bypasses bool
+	err := row.Scan(&bypasses)
+	return bypasses, err
+}
@@ -0,0 +1,21 @@
+-- name: UpsertAccountAssignment :one
+INSERT INTO account_assignments(account_id,
+                                trust_id,
+                                permission)
+VALUES ($1, $2, $3)
+ON CONFLICT (account_id, trust_id) DO UPDATE
+    SET permission = EXCLUDED.permission,
+        receiver_enc_password = CASE WHEN EXCLUDED.permission = 'credentials' THEN account_assignments.receiver_enc_password END
+RETURNING *;
+
+-- name: DeleteAccountAssignmentsExcept :exec
+DELETE FROM account_assignments
+WHERE account_id = sqlc.arg(account_id)
+  AND NOT (trust_id = ANY (sqlc.arg(trust_ids)::int[]));
+
+-- name: ReleaseAccountAssignment :one
+UPDATE account_assignments
+SET is_disclosed = true,
+    receiver_enc_password = $3
+WHERE account_id = $1 AND trust_id = $2
+RETURNING *;
@@ -0,0 +1,12 @@
+-- name: ListAccountAssignmentsByAccountId :many
+SELECT account_assignments.*
+FROM account_assignments
+WHERE account_assignments.account_id = $1
+ORDER BY account_assignments.trust_id;
+
+-- name: ListAccountAssignmentsByPasserId :many
+SELECT account_assignments.*
+FROM account_assignments
+JOIN accounts i ON account_assignments.account_id = i.id
+WHERE i.passer_id = $1
+ORDER BY account_assignments.account_id, account_assignments.trust_id;
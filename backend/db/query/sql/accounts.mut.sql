@@ -10,10 +10,10 @@ INSERT INTO accounts(app_template_id,
                     pls_delete,
                     message,
                     passer_id,
-                    trust_id,
                     is_disclosed,
-                    custom_data)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false, $9, $10, $11, false, $12)
+                    custom_data,
+                    encryption_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false, $9, $10, false, $11, $12)
 RETURNING *;
 
 -- name: UpdateAccount :one
@@ -27,7 +27,8 @@ SET app_template_id = $2,
     enc_password = $8,
     memo = $9,
     message = $10,
-    custom_data = $11
+    custom_data = $11,
+    encryption_mode = $13
 WHERE id = $1 AND passer_id = $12
 RETURNING *;
 
@@ -42,16 +43,18 @@ SET pls_delete = $2
 WHERE id = $1
 RETURNING *;
 
-
--- name: AssignReceiverToAccount :one
+-- name: SetAccountDisclosureStatus :one
 UPDATE accounts
-SET trust_id = $2
+SET is_disclosed = $2
 WHERE id = $1
 RETURNING *;
 
--- name: SetAccountDisclosureStatus :one
+-- name: UpdateAccountEncPassword :execrows
 UPDATE accounts
-SET is_disclosed = $2,
-    trust_id = $3
-WHERE id = $1
-RETURNING *;
+SET enc_password = $2
+WHERE id = $1 AND enc_password = $3;
+
+-- name: UpdateAccountVaultEncPassword :exec
+UPDATE accounts
+SET vault_enc_password = $2
+WHERE id = $1;
@@ -10,7 +10,30 @@ WHERE accounts.passer_id = $1
 ORDER BY accounts.id DESC;
 
 -- name: ListDisclosedAccountsByReceiverId :many
-SELECT accounts.*
+SELECT DISTINCT ON (accounts.passer_id, accounts.id) sqlc.embed(accounts), aa.trust_id, aa.permission, aa.receiver_enc_password
+FROM accounts
+JOIN account_assignments aa ON aa.account_id = accounts.id
+JOIN trusts t ON aa.trust_id = t.id
+WHERE t.receiver_user_id = $1 AND t.passer_user_id = accounts.passer_id AND aa.is_disclosed = true
+ORDER BY accounts.passer_id, accounts.id, aa.permission = 'credentials' DESC, aa.trust_id;
+
+-- name: CountAccountsByPasserId :one
+SELECT COUNT(*)
+FROM accounts
+WHERE accounts.passer_id = $1;
+
+-- name: ListAccountsForKeyRotation :many
+SELECT accounts.id, accounts.enc_password
+FROM accounts
+WHERE accounts.passer_id = $1 AND accounts.id > $2
+ORDER BY accounts.id
+LIMIT $3;
+
+-- name: ListAccountsByPasserIdAndReceiverId :many
+SELECT sqlc.embed(accounts), aa.trust_id, aa.permission
 FROM accounts
-JOIN trusts t ON accounts.trust_id = t.id
-WHERE t.receiver_user_id = $1 AND accounts.is_disclosed = true;
+JOIN account_assignments aa ON aa.account_id = accounts.id
+JOIN trusts t ON aa.trust_id = t.id
+WHERE accounts.passer_id = $1 AND t.receiver_user_id = $2 AND t.passer_user_id = accounts.passer_id
+  AND NOT aa.is_disclosed
+ORDER BY accounts.id, aa.trust_id;
@@ -0,0 +1,55 @@
+-- name: UpsertAliveCheckSchedule :one
+INSERT INTO alive_check_schedules(user_id, interval_days, grace_days, enabled, next_check_at)
+VALUES ($1, $2, $3, $4, NOW() + make_interval(days => $2))
+ON CONFLICT (user_id) DO UPDATE
+SET interval_days = EXCLUDED.interval_days,
+    grace_days = EXCLUDED.grace_days,
+    enabled = EXCLUDED.enabled,
+    -- 確認待ちの間は次の確認を動かさない。止めたときは確認待ちも取り消す
+    next_check_at = CASE
+                        WHEN alive_check_schedules.sent_at IS NULL OR NOT EXCLUDED.enabled THEN EXCLUDED.next_check_at
+                        ELSE alive_check_schedules.next_check_at END,
+    sent_at = CASE WHEN EXCLUDED.enabled THEN alive_check_schedules.sent_at END,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteAliveCheckSchedule :execrows
+DELETE FROM alive_check_schedules
+WHERE user_id = $1;
+
+-- name: MarkAliveCheckSent :one
+UPDATE alive_check_schedules
+SET sent_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+  AND enabled
+  AND sent_at IS NULL
+  AND next_check_at <= NOW()
+RETURNING sent_at;
+
+-- name: MarkAliveCheckMissed :execrows
+UPDATE alive_check_schedules
+SET sent_at = NULL,
+    last_missed_at = NOW(),
+    last_disclosure_id = $3,
+    next_check_at = NOW() + make_interval(days => interval_days),
+    updated_at = NOW()
+WHERE user_id = $1
+  AND sent_at = $2;
+
+-- name: ConfirmAliveCheckSchedule :one
+UPDATE alive_check_schedules
+SET sent_at = NULL,
+    last_confirmed_at = NOW(),
+    next_check_at = NOW() + make_interval(days => interval_days),
+    updated_at = NOW()
+WHERE user_id = $1
+  AND enabled
+RETURNING *;
+
+-- name: ReleaseAliveCheckSent :exec
+UPDATE alive_check_schedules
+SET sent_at = NULL,
+    updated_at = NOW()
+WHERE user_id = $1
+  AND sent_at = $2;
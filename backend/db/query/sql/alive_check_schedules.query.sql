@@ -0,0 +1,21 @@
+-- name: GetAliveCheckSchedule :one
+SELECT * FROM alive_check_schedules
+WHERE user_id = $1;
+
+-- name: ListDueAliveCheckSchedules :many
+SELECT alive_check_schedules.*
+FROM alive_check_schedules
+WHERE alive_check_schedules.enabled
+  AND alive_check_schedules.sent_at IS NULL
+  AND alive_check_schedules.next_check_at <= NOW()
+ORDER BY alive_check_schedules.next_check_at
+LIMIT $1;
+
+-- name: ListMissedAliveCheckSchedules :many
+SELECT alive_check_schedules.*
+FROM alive_check_schedules
+WHERE alive_check_schedules.enabled
+  AND alive_check_schedules.sent_at IS NOT NULL
+  AND alive_check_schedules.sent_at + make_interval(days => alive_check_schedules.grace_days) <= NOW()
+ORDER BY alive_check_schedules.sent_at
+LIMIT $1;
@@ -0,0 +1,40 @@
+-- name: CreateAliveCheckToken :one
+WITH superseded AS (
+    UPDATE alive_check_tokens
+    SET superseded_at = NOW()
+    WHERE alive_check_tokens.user_id = $2
+      AND alive_check_tokens.purpose = $3
+      AND alive_check_tokens.disclosure_id IS NOT DISTINCT FROM $4
+      AND alive_check_tokens.used_at IS NULL
+      AND alive_check_tokens.superseded_at IS NULL
+      AND alive_check_tokens.revoked_at IS NULL
+)
+INSERT INTO alive_check_tokens(token_hash, user_id, purpose, disclosure_id, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ConsumeAliveCheckToken :one
+UPDATE alive_check_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND superseded_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: RevokeAliveCheckTokensByDisclosureId :execrows
+UPDATE alive_check_tokens
+SET revoked_at = NOW()
+WHERE disclosure_id = $1
+  AND used_at IS NULL
+  AND superseded_at IS NULL
+  AND revoked_at IS NULL;
+
+-- name: RevokeAliveCheckTokensByUserId :execrows
+UPDATE alive_check_tokens
+SET revoked_at = NOW()
+WHERE user_id = $1
+  AND used_at IS NULL
+  AND superseded_at IS NULL
+  AND revoked_at IS NULL;
@@ -0,0 +1,3 @@
+-- name: GetAliveCheckToken :one
+SELECT * FROM alive_check_tokens
+WHERE token_hash = $1;
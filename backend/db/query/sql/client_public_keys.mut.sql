@@ -0,0 +1,10 @@
+-- name: UpsertClientPublicKey :one
+INSERT INTO client_public_keys(user_id,
+                               algorithm,
+                               public_key)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+    SET algorithm = EXCLUDED.algorithm,
+        public_key = EXCLUDED.public_key,
+        updated_at = NOW()
+RETURNING *;
@@ -0,0 +1,12 @@
+-- name: GetClientPublicKey :one
+SELECT client_public_keys.*
+FROM client_public_keys
+WHERE client_public_keys.user_id = $1;
+
+-- name: ListReceiverClientPublicKeysByPasserId :many
+SELECT client_public_keys.*
+FROM client_public_keys
+WHERE client_public_keys.user_id IN (SELECT receiver_user_id
+                                     FROM trusts
+                                     WHERE passer_user_id = $1)
+ORDER BY client_public_keys.user_id;
@@ -0,0 +1,21 @@
+-- name: UpsertDeviceAssignment :one
+INSERT INTO device_assignments(device_id,
+                               trust_id,
+                               permission)
+VALUES ($1, $2, $3)
+ON CONFLICT (device_id, trust_id) DO UPDATE
+    SET permission = EXCLUDED.permission,
+        receiver_enc_password = CASE WHEN EXCLUDED.permission = 'credentials' THEN device_assignments.receiver_enc_password END
+RETURNING *;
+
+-- name: DeleteDeviceAssignmentsExcept :exec
+DELETE FROM device_assignments
+WHERE device_id = sqlc.arg(device_id)
+  AND NOT (trust_id = ANY (sqlc.arg(trust_ids)::int[]));
+
+-- name: ReleaseDeviceAssignment :one
+UPDATE device_assignments
+SET is_disclosed = true,
+    receiver_enc_password = $3
+WHERE device_id = $1 AND trust_id = $2
+RETURNING *;
@@ -0,0 +1,12 @@
+-- name: ListDeviceAssignmentsByDeviceId :many
+SELECT device_assignments.*
+FROM device_assignments
+WHERE device_assignments.device_id = $1
+ORDER BY device_assignments.trust_id;
+
+-- name: ListDeviceAssignmentsByPasserId :many
+SELECT device_assignments.*
+FROM device_assignments
+JOIN devices i ON device_assignments.device_id = i.id
+WHERE i.passer_id = $1
+ORDER BY device_assignments.device_id, device_assignments.trust_id;
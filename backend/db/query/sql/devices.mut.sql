@@ -8,10 +8,11 @@ INSERT INTO devices(
                     memo,
                     message,
                     passer_id,
-                    trust_id,
                     is_disclosed,
-                    custom_data)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, false, $10)
+                    custom_data,
+                    enc_version,
+                    pls_delete)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false, $9, $10, $11)
 RETURNING *;
 
 -- name: UpdateDevice :one
@@ -23,7 +24,9 @@ SET device_type = $2,
     enc_password = $6,
     memo = $7,
     message = $8,
-    custom_data = $9
+    custom_data = $9,
+    enc_version = $10,
+    pls_delete = $11
 WHERE id = $1
 RETURNING *;
 
@@ -34,7 +37,22 @@ RETURNING *;
 
 -- name: SetDeviceDisclosureStatus :one
 UPDATE devices
-SET is_disclosed = $2,
-    trust_id = $3
+SET is_disclosed = $2
 WHERE id = $1
 RETURNING *;
+
+-- name: UpdateDeviceEncPassword :execrows
+UPDATE devices
+SET enc_password = $2
+WHERE id = $1 AND enc_password = $3;
+
+-- name: EncryptPlaintextDevice :execrows
+UPDATE devices
+SET enc_password = $2,
+    enc_version = $3
+WHERE id = $1 AND enc_version = 0 AND enc_password = $4;
+
+-- name: UpdateDeviceVaultEncPassword :exec
+UPDATE devices
+SET vault_enc_password = $2
+WHERE id = $1;
@@ -10,7 +10,37 @@ WHERE devices.passer_id = $1
 ORDER BY devices.id DESC;
 
 -- name: ListDisclosedDevicesByReceiverId :many
-SELECT devices.*
+SELECT DISTINCT ON (devices.passer_id, devices.id) sqlc.embed(devices), da.trust_id, da.permission, da.receiver_enc_password
+FROM devices
+JOIN device_assignments da ON da.device_id = devices.id
+JOIN trusts t ON da.trust_id = t.id
+WHERE t.receiver_user_id = $1 AND t.passer_user_id = devices.passer_id AND da.is_disclosed = true
+ORDER BY devices.passer_id, devices.id, da.permission = 'credentials' DESC, da.trust_id;
+
+-- name: CountDevicesByPasserId :one
+SELECT COUNT(*)
+FROM devices
+WHERE devices.passer_id = $1 AND devices.enc_version > 0;
+
+-- name: ListDevicesForKeyRotation :many
+SELECT devices.id, devices.enc_password
+FROM devices
+WHERE devices.passer_id = $1 AND devices.id > $2 AND devices.enc_version > 0
+ORDER BY devices.id
+LIMIT $3;
+
+-- name: ListPlaintextDevices :many
+SELECT devices.id, devices.passer_id, devices.enc_password
+FROM devices
+WHERE devices.enc_version = 0 AND devices.id > $1
+ORDER BY devices.id
+LIMIT $2;
+
+-- name: ListDevicesByPasserIdAndReceiverId :many
+SELECT sqlc.embed(devices), da.trust_id, da.permission
 FROM devices
-JOIN trusts t ON devices.trust_id = t.id
-WHERE t.receiver_user_id = $1 AND devices.is_disclosed = true;
+JOIN device_assignments da ON da.device_id = devices.id
+JOIN trusts t ON da.trust_id = t.id
+WHERE devices.passer_id = $1 AND t.receiver_user_id = $2 AND t.passer_user_id = devices.passer_id
+  AND NOT da.is_disclosed
+ORDER BY devices.id, da.trust_id;
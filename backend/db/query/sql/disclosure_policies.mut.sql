@@ -0,0 +1,27 @@
+-- name: UpsertDisclosurePolicy :one
+INSERT INTO disclosure_policies(passer_id,
+                                threshold,
+                                share_count,
+                                vault_version,
+                                receiver_ids)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (passer_id) DO UPDATE
+    SET threshold = EXCLUDED.threshold,
+        share_count = EXCLUDED.share_count,
+        vault_version = EXCLUDED.vault_version,
+        receiver_ids = EXCLUDED.receiver_ids,
+        unlocked_at = NULL,
+        updated_at = NOW()
+RETURNING *;
+
+-- name: MarkDisclosurePolicyUnlocked :one
+UPDATE disclosure_policies
+SET unlocked_at = NOW(),
+    updated_at = NOW()
+WHERE passer_id = $1 AND vault_version = $2
+RETURNING *;
+
+-- name: DeleteDisclosurePolicy :one
+DELETE FROM disclosure_policies
+WHERE passer_id = $1
+RETURNING *;
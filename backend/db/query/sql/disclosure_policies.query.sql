@@ -0,0 +1,15 @@
+-- name: GetDisclosurePolicyByPasserId :one
+SELECT disclosure_policies.*
+FROM disclosure_policies
+WHERE disclosure_policies.passer_id = $1;
+
+-- name: ListStaleDisclosurePolicies :many
+SELECT disclosure_policies.*
+FROM disclosure_policies
+WHERE disclosure_policies.unlocked_at IS NULL
+  AND disclosure_policies.receiver_ids <> ARRAY(SELECT DISTINCT trusts.receiver_user_id
+                                                FROM trusts
+                                                WHERE trusts.passer_user_id = disclosure_policies.passer_id
+                                                ORDER BY trusts.receiver_user_id)
+ORDER BY disclosure_policies.passer_id
+LIMIT $1;
@@ -0,0 +1,4 @@
+-- name: ClaimDisclosureReminder :execrows
+INSERT INTO disclosure_reminders(disclosure_id, step, channel)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
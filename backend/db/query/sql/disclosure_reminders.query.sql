@@ -0,0 +1,5 @@
+-- name: ListDisclosureRemindersByDisclosureId :many
+SELECT disclosure_reminders.*
+FROM disclosure_reminders
+WHERE disclosure_reminders.disclosure_id = $1
+ORDER BY disclosure_reminders.step, disclosure_reminders.channel;
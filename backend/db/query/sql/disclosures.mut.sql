@@ -1,27 +1,37 @@
 -- name: CreateDisclosure :one
-INSERT INTO disclosures(requester_id,
-                        passer_id,
-                        issued_time,
-                        deadline,
-                        custom_data,
-                        disclosed,
-                        in_progress)
-VALUES ($1, $2, $3, $4, $5, false, true)
-RETURNING *;
+WITH created AS (
+    INSERT INTO disclosures (requester_id, passer_id, issued_time, deadline, custom_data, status)
+        VALUES (sqlc.arg(requester_id), sqlc.arg(passer_id), sqlc.arg(issued_time), sqlc.arg(deadline), sqlc.arg(custom_data), 'requested')
+        RETURNING *),
+     logged AS (
+         INSERT INTO disclosure_transitions (disclosure_id, from_status, to_status, actor, actor_user_id)
+             SELECT created.id, NULL, created.status, sqlc.arg(actor)::text, sqlc.narg(actor_user_id)::uuid
+             FROM created)
+SELECT created.*
+FROM created;
 
--- name: UpdateDisclosure :one
+-- name: UpdateDisclosureDetails :one
 UPDATE disclosures
-SET requester_id = $2,
-    passer_id = $3,
-    disclosed = $4,
-    deadline = $5,
-    prevented_by = $6,
-    custom_data = $7,
-    in_progress = $8
-WHERE id = $1 AND requester_id = $2
+SET deadline = $3,
+    custom_data = $4
+WHERE disclosures.id = $1
+  AND disclosures.requester_id = $2
+  AND disclosures.status IN ('requested', 'notifying', 'grace')
 RETURNING *;
 
--- name: DeleteDisclosure :one
-DELETE FROM disclosures
-WHERE id = $1 AND requester_id = $2
-RETURNING *;
+-- name: TransitionDisclosure :one
+WITH updated AS (
+    UPDATE disclosures
+        SET status = sqlc.arg(to_status)::text,
+            status_changed_at = NOW(),
+            disclosed_at = CASE WHEN sqlc.arg(to_status)::text = 'disclosed' THEN NOW() ELSE disclosures.disclosed_at END,
+            prevented_by = COALESCE(sqlc.narg(prevented_by)::uuid, disclosures.prevented_by)
+        WHERE disclosures.id = sqlc.arg(id)
+            AND disclosures.status = sqlc.arg(from_status)::text
+        RETURNING *),
+     logged AS (
+         INSERT INTO disclosure_transitions (disclosure_id, from_status, to_status, actor, actor_user_id)
+             SELECT updated.id, sqlc.arg(from_status)::text, updated.status, sqlc.arg(actor)::text, sqlc.narg(actor_user_id)::uuid
+             FROM updated)
+SELECT updated.*
+FROM updated;
@@ -3,3 +3,73 @@ SELECT * FROM disclosures WHERE id = $1;
 
 -- name: ListDisclosuresByRequesterId :many
 SELECT * FROM disclosures WHERE requester_id = $1;
+
+-- name: ListMaturedDisclosuresByPasserId :many
+SELECT disclosures.*
+FROM disclosures
+WHERE disclosures.passer_id = $1
+  AND disclosures.deadline <= NOW()
+  AND disclosures.status IN ('requested', 'notifying', 'grace', 'disclosed')
+ORDER BY disclosures.id;
+
+-- name: HasCompletedDisclosure :one
+SELECT EXISTS (SELECT 1
+               FROM disclosures
+               WHERE disclosures.passer_id = $1
+                 AND disclosures.requester_id = $2
+                 AND disclosures.status = 'disclosed');
+
+-- name: ListOverdueDisclosures :many
+SELECT disclosures.*
+FROM disclosures
+WHERE disclosures.status IN ('requested', 'notifying', 'grace')
+  AND disclosures.deadline <= NOW()
+  AND (disclosures.passer_id, disclosures.id) > ($1, $2)
+ORDER BY disclosures.passer_id, disclosures.id
+LIMIT $3;
+
+-- name: TryLockPasserDisclosures :one
+SELECT pg_try_advisory_xact_lock(hashtextextended('disclosures:' || users.id::text, 0))
+FROM users
+WHERE users.id = $1;
+
+-- name: ListPendingDisclosures :many
+SELECT disclosures.*
+FROM disclosures
+WHERE disclosures.status IN ('requested', 'notifying', 'grace')
+  AND disclosures.deadline > NOW()
+  AND disclosures.id > $1
+ORDER BY disclosures.id
+LIMIT $2;
+
+-- name: GetOpenDisclosure :one
+SELECT disclosures.*
+FROM disclosures
+WHERE disclosures.requester_id = $1
+  AND disclosures.passer_id = $2
+  AND disclosures.status IN ('requested', 'notifying', 'grace')
+ORDER BY disclosures.id DESC
+LIMIT 1;
+
+-- name: ListDisclosuresByPasserId :many
+SELECT disclosures.*
+FROM disclosures
+WHERE disclosures.passer_id = $1
+ORDER BY disclosures.id;
+
+-- name: ListDisclosureTransitionsByDisclosureId :many
+SELECT *
+FROM disclosure_transitions
+WHERE disclosure_id = $1
+ORDER BY id;
+
+-- name: ListUnreleasedDisclosures :many
+SELECT DISTINCT ON (disclosures.passer_id, disclosures.requester_id) disclosures.*
+FROM disclosures
+JOIN trusts t ON t.passer_user_id = disclosures.passer_id AND t.receiver_user_id = disclosures.requester_id
+WHERE disclosures.status = 'disclosed'
+  AND (EXISTS (SELECT 1 FROM account_assignments aa WHERE aa.trust_id = t.id AND NOT aa.is_disclosed)
+    OR EXISTS (SELECT 1 FROM device_assignments da WHERE da.trust_id = t.id AND NOT da.is_disclosed)
+    OR EXISTS (SELECT 1 FROM subscription_assignments sa WHERE sa.trust_id = t.id AND NOT sa.is_disclosed))
+ORDER BY disclosures.passer_id, disclosures.requester_id, disclosures.id
+LIMIT $1;
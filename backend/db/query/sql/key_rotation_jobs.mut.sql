@@ -0,0 +1,33 @@
+-- name: CreateKeyRotationJob :one
+INSERT INTO key_rotation_jobs(user_id,
+                              key_version,
+                              total_items)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UpdateKeyRotationJobProgress :one
+UPDATE key_rotation_jobs
+SET phase = $2,
+    last_item_id = $3,
+    processed_items = $4,
+    failed_items = $5,
+    last_error = $6,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'running'
+RETURNING *;
+
+-- name: FinishKeyRotationJob :one
+UPDATE key_rotation_jobs
+SET status = $2,
+    last_error = $3,
+    updated_at = NOW(),
+    completed_at = NOW()
+WHERE id = $1 AND status = 'running'
+RETURNING *;
+
+-- name: SetKeyRotationJobKeyVersion :one
+UPDATE key_rotation_jobs
+SET key_version = $2,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'running'
+RETURNING *;
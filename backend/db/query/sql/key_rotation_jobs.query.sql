@@ -0,0 +1,12 @@
+-- name: GetLatestKeyRotationJobByUserId :one
+SELECT key_rotation_jobs.*
+FROM key_rotation_jobs
+WHERE key_rotation_jobs.user_id = $1
+ORDER BY key_rotation_jobs.id DESC
+LIMIT 1;
+
+-- name: ListRunningKeyRotationJobs :many
+SELECT key_rotation_jobs.*
+FROM key_rotation_jobs
+WHERE key_rotation_jobs.status = 'running'
+ORDER BY key_rotation_jobs.id;
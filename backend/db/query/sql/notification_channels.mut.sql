@@ -0,0 +1,11 @@
+-- name: UpsertNotificationChannel :one
+INSERT INTO notification_channels(user_id, channel, address)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, channel) DO UPDATE
+SET address = EXCLUDED.address,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteNotificationChannel :execrows
+DELETE FROM notification_channels
+WHERE user_id = $1 AND channel = $2;
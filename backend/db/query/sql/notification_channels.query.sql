@@ -0,0 +1,5 @@
+-- name: ListNotificationChannelsByUserId :many
+SELECT notification_channels.*
+FROM notification_channels
+WHERE notification_channels.user_id = $1
+ORDER BY notification_channels.channel;
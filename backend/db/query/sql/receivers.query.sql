@@ -3,3 +3,18 @@ SELECT trusts.id as trust_id, users.clerk_user_id, users.id AS user_id
 FROM trusts
 JOIN users ON trusts.receiver_user_id = users.id
 WHERE trusts.passer_user_id = $1;
+
+-- name: IsReceiverOfPasser :one
+SELECT EXISTS (SELECT 1
+               FROM trusts
+               WHERE trusts.passer_user_id = $1
+                 AND trusts.receiver_user_id = $2
+                 -- 招待から作られ、まだ承諾されていない相続関係は数えない
+                 AND NOT EXISTS (SELECT 1
+                                 FROM trust_invitations
+                                 WHERE trust_invitations.trust_id = trusts.id
+                                   AND trust_invitations.status = 'pending'))
+           OR EXISTS (SELECT 1
+                      FROM users
+                      WHERE users.id = $1
+                        AND users.default_receiver_id = $2) AS is_receiver;
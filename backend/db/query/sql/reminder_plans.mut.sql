@@ -0,0 +1,11 @@
+-- name: UpsertReminderPlan :one
+INSERT INTO reminder_plans(user_id, offsets_hours)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET offsets_hours = EXCLUDED.offsets_hours,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteReminderPlan :execrows
+DELETE FROM reminder_plans
+WHERE user_id = $1;
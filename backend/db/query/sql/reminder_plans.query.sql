@@ -0,0 +1,3 @@
+-- name: GetReminderPlan :one
+SELECT * FROM reminder_plans
+WHERE user_id = $1;
@@ -1,2 +1,11 @@
 -- name: AssumeUserID :exec
-SET LOCAL digi_baton.current_user_id = $1;
+SELECT set_config('digi_baton.current_user_id', sqlc.arg(user_id)::text, true);
+
+-- name: BypassesRowLevelSecurity :one
+SELECT roles.rolsuper
+           OR roles.rolbypassrls
+           OR pg_has_role(roles.oid, tables.relowner, 'MEMBER') AS bypasses
+FROM pg_catalog.pg_roles roles,
+     pg_catalog.pg_class tables
+WHERE roles.rolname = current_user
+  AND tables.oid = 'public.accounts'::regclass;
@@ -0,0 +1,21 @@
+-- name: UpsertSubscriptionAssignment :one
+INSERT INTO subscription_assignments(subscription_id,
+                                     trust_id,
+                                     permission)
+VALUES ($1, $2, $3)
+ON CONFLICT (subscription_id, trust_id) DO UPDATE
+    SET permission = EXCLUDED.permission,
+        receiver_enc_password = CASE WHEN EXCLUDED.permission = 'credentials' THEN subscription_assignments.receiver_enc_password END
+RETURNING *;
+
+-- name: DeleteSubscriptionAssignmentsExcept :exec
+DELETE FROM subscription_assignments
+WHERE subscription_id = sqlc.arg(subscription_id)
+  AND NOT (trust_id = ANY (sqlc.arg(trust_ids)::int[]));
+
+-- name: ReleaseSubscriptionAssignment :one
+UPDATE subscription_assignments
+SET is_disclosed = true,
+    receiver_enc_password = $3
+WHERE subscription_id = $1 AND trust_id = $2
+RETURNING *;
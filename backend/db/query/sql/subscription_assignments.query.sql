@@ -0,0 +1,12 @@
+-- name: ListSubscriptionAssignmentsBySubscriptionId :many
+SELECT subscription_assignments.*
+FROM subscription_assignments
+WHERE subscription_assignments.subscription_id = $1
+ORDER BY subscription_assignments.trust_id;
+
+-- name: ListSubscriptionAssignmentsByPasserId :many
+SELECT subscription_assignments.*
+FROM subscription_assignments
+JOIN subscriptions i ON subscription_assignments.subscription_id = i.id
+WHERE i.passer_id = $1
+ORDER BY subscription_assignments.subscription_id, subscription_assignments.trust_id;
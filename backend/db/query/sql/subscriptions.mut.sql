@@ -12,11 +12,11 @@ INSERT INTO subscriptions(
     pls_delete,
     message,
     passer_id,
-    trust_id,
     is_disclosed,
-    custom_data
+    custom_data,
+    enc_version
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, false, $14)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, false, $13, $14)
 RETURNING *;
 
 -- name: UpdateSubscription :one
@@ -31,7 +31,8 @@ SET service_name = $2,
     billing_cycle = $9,
     memo = $10,
     message = $11,
-    custom_data = $12
+    custom_data = $12,
+    enc_version = $13
 WHERE id = $1
 RETURNING *;
 
@@ -48,7 +49,22 @@ RETURNING *;
 
 -- name: SetSubscriptionDisclosureStatus :one
 UPDATE subscriptions
-SET is_disclosed = $2,
-    trust_id = $3
+SET is_disclosed = $2
 WHERE id = $1
-RETURNING *; 
\ No newline at end of file
+RETURNING *;
+
+-- name: UpdateSubscriptionEncPassword :execrows
+UPDATE subscriptions
+SET enc_password = $2
+WHERE id = $1 AND enc_password = $3;
+
+-- name: EncryptPlaintextSubscription :execrows
+UPDATE subscriptions
+SET enc_password = $2,
+    enc_version = $3
+WHERE id = $1 AND enc_version = 0 AND enc_password = $4;
+
+-- name: UpdateSubscriptionVaultEncPassword :exec
+UPDATE subscriptions
+SET vault_enc_password = $2
+WHERE id = $1;
@@ -12,4 +12,40 @@ ORDER BY id;
 SELECT *
 FROM subscriptions
 WHERE passer_id = $1
-ORDER BY id; 
\ No newline at end of file
+ORDER BY id; 
+
+-- name: CountSubscriptionsByPasserId :one
+SELECT COUNT(*)
+FROM subscriptions
+WHERE subscriptions.passer_id = $1 AND subscriptions.enc_version > 0;
+
+-- name: ListSubscriptionsForKeyRotation :many
+SELECT subscriptions.id, subscriptions.enc_password
+FROM subscriptions
+WHERE subscriptions.passer_id = $1 AND subscriptions.id > $2 AND subscriptions.enc_version > 0
+ORDER BY subscriptions.id
+LIMIT $3;
+
+-- name: ListPlaintextSubscriptions :many
+SELECT subscriptions.id, subscriptions.passer_id, subscriptions.enc_password
+FROM subscriptions
+WHERE subscriptions.enc_version = 0 AND subscriptions.id > $1
+ORDER BY subscriptions.id
+LIMIT $2;
+
+-- name: ListSubscriptionsByPasserIdAndReceiverId :many
+SELECT sqlc.embed(subscriptions), sa.trust_id, sa.permission
+FROM subscriptions
+JOIN subscription_assignments sa ON sa.subscription_id = subscriptions.id
+JOIN trusts t ON sa.trust_id = t.id
+WHERE subscriptions.passer_id = $1 AND t.receiver_user_id = $2 AND t.passer_user_id = subscriptions.passer_id
+  AND NOT sa.is_disclosed
+ORDER BY subscriptions.id, sa.trust_id;
+
+-- name: ListDisclosedSubscriptionsByReceiverId :many
+SELECT DISTINCT ON (subscriptions.passer_id, subscriptions.id) sqlc.embed(subscriptions), sa.trust_id, sa.permission, sa.receiver_enc_password
+FROM subscriptions
+JOIN subscription_assignments sa ON sa.subscription_id = subscriptions.id
+JOIN trusts t ON sa.trust_id = t.id
+WHERE t.receiver_user_id = $1 AND t.passer_user_id = subscriptions.passer_id AND sa.is_disclosed = true
+ORDER BY subscriptions.passer_id, subscriptions.id, sa.permission = 'credentials' DESC, sa.trust_id;
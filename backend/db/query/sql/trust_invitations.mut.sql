@@ -0,0 +1,47 @@
+-- name: CreateTrustInvitation :one
+WITH superseded AS (
+    UPDATE trust_invitations
+    SET status       = 'revoked',
+        responded_at = NOW()
+    WHERE trust_invitations.passer_user_id = $1
+      AND trust_invitations.email = $2
+      AND trust_invitations.status = 'pending'
+      AND (trust_invitations.receiver_user_id IS NULL OR trust_invitations.trust_id IS NULL)
+    RETURNING trust_invitations.id
+)
+-- 取り消しを先に終えてから追加するよう superseded を参照する。参照しないと追加が先に走り、部分一意インデックスに反する
+INSERT INTO trust_invitations(passer_user_id, email, token_hash, expires_at)
+SELECT $1, $2, $3, $4
+FROM (SELECT count(*) FROM superseded) AS revoked
+RETURNING *;
+
+-- name: LinkTrustInvitation :one
+WITH invitation AS (
+    SELECT trust_invitations.id, trust_invitations.passer_user_id
+    FROM trust_invitations
+    WHERE trust_invitations.id = $1
+      AND trust_invitations.status = 'pending'
+      AND trust_invitations.receiver_user_id IS NULL
+      AND trust_invitations.expires_at > NOW()
+      AND trust_invitations.passer_user_id <> $2
+    FOR UPDATE
+), created AS (
+    INSERT INTO trusts(receiver_user_id, passer_user_id)
+    SELECT $2, invitation.passer_user_id
+    FROM invitation
+    RETURNING trusts.id
+)
+UPDATE trust_invitations
+SET receiver_user_id = $2,
+    trust_id         = created.id
+FROM created
+WHERE trust_invitations.id = $1
+RETURNING trust_invitations.*;
+
+-- name: RespondTrustInvitation :one
+UPDATE trust_invitations
+SET status       = $2,
+    responded_at = NOW()
+WHERE id = $1
+  AND status = 'pending'
+RETURNING *;
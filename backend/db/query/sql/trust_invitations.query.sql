@@ -0,0 +1,43 @@
+-- name: GetTrustInvitation :one
+SELECT *
+FROM trust_invitations
+WHERE id = $1;
+
+-- name: GetTrustInvitationByTokenHash :one
+SELECT *
+FROM trust_invitations
+WHERE token_hash = $1;
+
+-- name: GetPendingTrustInvitationByEmail :one
+SELECT *
+FROM trust_invitations
+WHERE passer_user_id = $1
+  AND email = $2
+  AND status = 'pending';
+
+-- name: ListTrustInvitationsByPasserID :many
+SELECT *
+FROM trust_invitations
+WHERE passer_user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: ListTrustInvitationsByReceiverID :many
+SELECT *
+FROM trust_invitations
+WHERE receiver_user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: ListLinkableTrustInvitationsByEmails :many
+SELECT *
+FROM trust_invitations
+WHERE email = ANY (sqlc.arg(emails)::text[])
+  AND status = 'pending'
+  AND receiver_user_id IS NULL
+  AND expires_at > NOW()
+ORDER BY id;
+
+-- name: IsTrustAwaitingAcceptance :one
+SELECT EXISTS (SELECT 1
+               FROM trust_invitations
+               WHERE trust_id = $1
+                 AND status = 'pending') AS is_awaiting;
@@ -13,3 +13,9 @@ SELECT u.id, u.clerk_user_id
 FROM trusts
          LEFT JOIN public.users u on trusts.passer_user_id = u.id
 WHERE receiver_user_id = $1;
+
+-- name: ListReceiverIDsByPasserID :many
+SELECT DISTINCT receiver_user_id
+FROM trusts
+WHERE passer_user_id = $1
+ORDER BY receiver_user_id;
@@ -4,4 +4,8 @@ WHERE clerk_user_id = $1
 LIMIT 1; 
 
 -- name: ListUsers :many
-SELECT * FROM users; 
\ No newline at end of file
+SELECT * FROM users; 
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1;
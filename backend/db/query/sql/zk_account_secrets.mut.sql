@@ -0,0 +1,16 @@
+-- name: UpsertZkAccountSecret :one
+INSERT INTO zk_account_secrets(account_id,
+                               format,
+                               ciphertext,
+                               owner_key_envelope)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id) DO UPDATE
+    SET format = EXCLUDED.format,
+        ciphertext = EXCLUDED.ciphertext,
+        owner_key_envelope = EXCLUDED.owner_key_envelope,
+        updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteZkAccountSecret :exec
+DELETE FROM zk_account_secrets
+WHERE account_id = $1;
@@ -0,0 +1,17 @@
+-- name: ListZkAccountSecretsByPasserId :many
+SELECT zk_account_secrets.*
+FROM zk_account_secrets
+JOIN accounts a ON zk_account_secrets.account_id = a.id
+WHERE a.passer_id = $1
+ORDER BY zk_account_secrets.account_id DESC;
+
+-- name: ListDisclosedZkAccountSecretsByReceiverId :many
+SELECT DISTINCT s.account_id, s.format, s.ciphertext, e.envelope
+FROM zk_account_secrets s
+JOIN zk_key_envelopes e ON e.account_id = s.account_id
+JOIN account_assignments aa ON aa.account_id = s.account_id
+JOIN trusts t ON aa.trust_id = t.id
+WHERE e.receiver_user_id = $1
+  AND t.receiver_user_id = e.receiver_user_id
+  AND aa.is_disclosed = true
+  AND aa.permission = 'credentials';
@@ -0,0 +1,12 @@
+-- name: UpsertZkKeyEnvelope :exec
+INSERT INTO zk_key_envelopes(account_id,
+                             receiver_user_id,
+                             envelope)
+VALUES ($1, $2, $3)
+ON CONFLICT (account_id, receiver_user_id) DO UPDATE
+    SET envelope = EXCLUDED.envelope,
+        created_at = NOW();
+
+-- name: DeleteZkKeyEnvelopesByAccountId :exec
+DELETE FROM zk_key_envelopes
+WHERE account_id = $1;
@@ -0,0 +1,12 @@
+-- name: ListZkKeyEnvelopesByPasserId :many
+SELECT zk_key_envelopes.*
+FROM zk_key_envelopes
+JOIN accounts a ON zk_key_envelopes.account_id = a.id
+WHERE a.passer_id = $1
+ORDER BY zk_key_envelopes.account_id, zk_key_envelopes.receiver_user_id;
+
+-- name: ListZkKeyEnvelopesByAccountId :many
+SELECT zk_key_envelopes.*
+FROM zk_key_envelopes
+WHERE zk_key_envelopes.account_id = $1
+ORDER BY zk_key_envelopes.receiver_user_id;
@@ -25,12 +25,12 @@ INSERT INTO subscriptions(
     pls_delete,
     message,
     passer_id,
-    trust_id,
     is_disclosed,
-    custom_data
+    custom_data,
+    enc_version
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, false, $14)
-RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, false, $13, $14)
+RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, is_disclosed, custom_data, enc_version, vault_enc_password
 `
 
 type CreateSubscriptionParams struct {
@@ -46,8 +46,8 @@ type CreateSubscriptionParams struct {
 	PlsDelete    bool
 	Message      string
 	PasserID     pgtype.UUID
-	TrustID      int32
 	CustomData   []byte
+	EncVersion   int32
 }
 
 func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) (Subscription, error) {
@@ -64,8 +64,8 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		arg.PlsDelete,
 		arg.Message,
 		arg.PasserID,
-		arg.TrustID,
 		arg.CustomData,
+		arg.EncVersion,
 	)
 	var i Subscription
 	err := row.Scan(
@@ -82,9 +82,10 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
@@ -92,7 +93,7 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 const deleteSubscription = `-- name: DeleteSubscription :one
 DELETE FROM subscriptions
 WHERE id = $1 AND passer_id = $2
-RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, is_disclosed, custom_data, enc_version, vault_enc_password
 `
 
 type DeleteSubscriptionParams struct {
@@ -117,18 +118,46 @@ func (q *Queries) DeleteSubscription(ctx context.Context, arg DeleteSubscription
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
 
+const encryptPlaintextSubscription = `-- name: EncryptPlaintextSubscription :execrows
+UPDATE subscriptions
+SET enc_password = $2,
+    enc_version = $3
+WHERE id = $1 AND enc_version = 0 AND enc_password = $4
+`
+
+type EncryptPlaintextSubscriptionParams struct {
+	ID            int32
+	EncPassword   []byte
+	EncVersion    int32
+	EncPassword_2 []byte
+}
+
+func (q *Queries) EncryptPlaintextSubscription(ctx context.Context, arg EncryptPlaintextSubscriptionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, encryptPlaintextSubscription,
+		arg.ID,
+		arg.EncPassword,
+		arg.EncVersion,
+		arg.EncPassword_2,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const setSubscriptionDeleteFlag = `-- name: SetSubscriptionDeleteFlag :one
 UPDATE subscriptions
 SET pls_delete = $2
 WHERE id = $1
-RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, is_disclosed, custom_data, enc_version, vault_enc_password
 `
 
 type SetSubscriptionDeleteFlagParams struct {
@@ -153,29 +182,28 @@ func (q *Queries) SetSubscriptionDeleteFlag(ctx context.Context, arg SetSubscrip
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
 
 const setSubscriptionDisclosureStatus = `-- name: SetSubscriptionDisclosureStatus :one
 UPDATE subscriptions
-SET is_disclosed = $2,
-    trust_id = $3
+SET is_disclosed = $2
 WHERE id = $1
-RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, is_disclosed, custom_data, enc_version, vault_enc_password
 `
 
 type SetSubscriptionDisclosureStatusParams struct {
 	ID          int32
 	IsDisclosed bool
-	TrustID     int32
 }
 
 func (q *Queries) SetSubscriptionDisclosureStatus(ctx context.Context, arg SetSubscriptionDisclosureStatusParams) (Subscription, error) {
-	row := q.db.QueryRow(ctx, setSubscriptionDisclosureStatus, arg.ID, arg.IsDisclosed, arg.TrustID)
+	row := q.db.QueryRow(ctx, setSubscriptionDisclosureStatus, arg.ID, arg.IsDisclosed)
 	var i Subscription
 	err := row.Scan(
 		&i.ID,
@@ -191,9 +219,10 @@ func (q *Queries) SetSubscriptionDisclosureStatus(ctx context.Context, arg SetSu
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
@@ -210,9 +239,10 @@ SET service_name = $2,
     billing_cycle = $9,
     memo = $10,
     message = $11,
-    custom_data = $12
+    custom_data = $12,
+    enc_version = $13
 WHERE id = $1
-RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+RETURNING id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, is_disclosed, custom_data, enc_version, vault_enc_password
 `
 
 type UpdateSubscriptionParams struct {
@@ -228,6 +258,7 @@ type UpdateSubscriptionParams struct {
 	Memo         string
 	Message      string
 	CustomData   []byte
+	EncVersion   int32
 }
 
 func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscriptionParams) (Subscription, error) {
@@ -244,6 +275,7 @@ func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscription
 		arg.Memo,
 		arg.Message,
 		arg.CustomData,
+		arg.EncVersion,
 	)
 	var i Subscription
 	err := row.Scan(
@@ -260,9 +292,46 @@ func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscription
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
+
+const updateSubscriptionEncPassword = `-- name: UpdateSubscriptionEncPassword :execrows
+UPDATE subscriptions
+SET enc_password = $2
+WHERE id = $1 AND enc_password = $3
+`
+
+type UpdateSubscriptionEncPasswordParams struct {
+	ID            int32
+	EncPassword   []byte
+	EncPassword_2 []byte
+}
+
+func (q *Queries) UpdateSubscriptionEncPassword(ctx context.Context, arg UpdateSubscriptionEncPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateSubscriptionEncPassword, arg.ID, arg.EncPassword, arg.EncPassword_2)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateSubscriptionVaultEncPassword = `-- name: UpdateSubscriptionVaultEncPassword :exec
+UPDATE subscriptions
+SET vault_enc_password = $2
+WHERE id = $1
+`
+
+type UpdateSubscriptionVaultEncPasswordParams struct {
+	ID               int32
+	VaultEncPassword []byte
+}
+
+func (q *Queries) UpdateSubscriptionVaultEncPassword(ctx context.Context, arg UpdateSubscriptionVaultEncPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateSubscriptionVaultEncPassword, arg.ID, arg.VaultEncPassword)
+	return err
+}
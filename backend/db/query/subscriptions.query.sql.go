@@ -11,8 +11,21 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countSubscriptionsByPasserId = `-- name: CountSubscriptionsByPasserId :one
+SELECT COUNT(*)
+FROM subscriptions
+WHERE subscriptions.passer_id = $1 AND subscriptions.enc_version > 0
+`
+
+func (q *Queries) CountSubscriptionsByPasserId(ctx context.Context, passerID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countSubscriptionsByPasserId, passerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getSubscription = `-- name: GetSubscription :one
-SELECT id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+SELECT id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, is_disclosed, custom_data, enc_version, vault_enc_password
 FROM subscriptions
 WHERE id = $1
 `
@@ -34,15 +47,116 @@ func (q *Queries) GetSubscription(ctx context.Context, id int32) (Subscription,
 		&i.PlsDelete,
 		&i.Message,
 		&i.PasserID,
-		&i.TrustID,
 		&i.IsDisclosed,
 		&i.CustomData,
+		&i.EncVersion,
+		&i.VaultEncPassword,
 	)
 	return i, err
 }
 
+const listDisclosedSubscriptionsByReceiverId = `-- name: ListDisclosedSubscriptionsByReceiverId :many
+SELECT DISTINCT ON (subscriptions.passer_id, subscriptions.id) subscriptions.id, subscriptions.service_name, subscriptions.icon_url, subscriptions.username, subscriptions.email, subscriptions.enc_password, subscriptions.amount, subscriptions.currency, subscriptions.billing_cycle, subscriptions.memo, subscriptions.pls_delete, subscriptions.message, subscriptions.passer_id, subscriptions.is_disclosed, subscriptions.custom_data, subscriptions.enc_version, subscriptions.vault_enc_password, sa.trust_id, sa.permission, sa.receiver_enc_password
+FROM subscriptions
+JOIN subscription_assignments sa ON sa.subscription_id = subscriptions.id
+JOIN trusts t ON sa.trust_id = t.id
+WHERE t.receiver_user_id = $1 AND t.passer_user_id = subscriptions.passer_id AND sa.is_disclosed = true
+ORDER BY subscriptions.passer_id, subscriptions.id, sa.permission = 'credentials' DESC, sa.trust_id
+`
+
+type ListDisclosedSubscriptionsByReceiverIdRow struct {
+	Subscription        Subscription
+	TrustID             int32
+	Permission          string
+	ReceiverEncPassword []byte
+}
+
+func (q *Queries) ListDisclosedSubscriptionsByReceiverId(ctx context.Context, receiverUserID pgtype.UUID) ([]ListDisclosedSubscriptionsByReceiverIdRow, error) {
+	rows, err := q.db.Query(ctx, listDisclosedSubscriptionsByReceiverId, receiverUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDisclosedSubscriptionsByReceiverIdRow
+	for rows.Next() {
+		var i ListDisclosedSubscriptionsByReceiverIdRow
+		if err := rows.Scan(
+			&i.Subscription.ID,
+			&i.Subscription.ServiceName,
+			&i.Subscription.IconUrl,
+			&i.Subscription.Username,
+			&i.Subscription.Email,
+			&i.Subscription.EncPassword,
+			&i.Subscription.Amount,
+			&i.Subscription.Currency,
+			&i.Subscription.BillingCycle,
+			&i.Subscription.Memo,
+			&i.Subscription.PlsDelete,
+			&i.Subscription.Message,
+			&i.Subscription.PasserID,
+			&i.Subscription.IsDisclosed,
+			&i.Subscription.CustomData,
+			&i.Subscription.EncVersion,
+			&i.Subscription.VaultEncPassword,
+			&i.TrustID,
+			&i.Permission,
+			&i.ReceiverEncPassword,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaintextSubscriptions = `-- name: ListPlaintextSubscriptions :many
+SELECT subscriptions.id, subscriptions.passer_id, subscriptions.enc_password
+FROM subscriptions
+WHERE subscriptions.enc_version = 0 AND subscriptions.id > $1
+ORDER BY subscriptions.id
+LIMIT $2
+`
+
+type ListPlaintextSubscriptionsParams struct {
+	ID    int32
+	Limit int32
+}
+
+type ListPlaintextSubscriptionsRow struct {
+	ID          int32
+	PasserID    pgtype.UUID
+	EncPassword []byte
+}
+
+func (q *Queries) ListPlaintextSubscriptions(ctx context.Context, arg ListPlaintextSubscriptionsParams) ([]ListPlaintextSubscriptionsRow, error) {
+	rows, err := q.db.Query(ctx, listPlaintextSubscriptions, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPlaintextSubscriptionsRow
+	for rows.Next() {
+		var i ListPlaintextSubscriptionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PasserID,
+			&i.EncPassword,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSubscriptions = `-- name: ListSubscriptions :many
-SELECT id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+SELECT id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, is_disclosed, custom_data, enc_version, vault_enc_password
 FROM subscriptions
 ORDER BY id
 `
@@ -70,9 +184,10 @@ func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error)
 			&i.PlsDelete,
 			&i.Message,
 			&i.PasserID,
-			&i.TrustID,
 			&i.IsDisclosed,
 			&i.CustomData,
+			&i.EncVersion,
+			&i.VaultEncPassword,
 		); err != nil {
 			return nil, err
 		}
@@ -85,7 +200,7 @@ func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error)
 }
 
 const listSubscriptionsByPasserId = `-- name: ListSubscriptionsByPasserId :many
-SELECT id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, trust_id, is_disclosed, custom_data
+SELECT id, service_name, icon_url, username, email, enc_password, amount, currency, billing_cycle, memo, pls_delete, message, passer_id, is_disclosed, custom_data, enc_version, vault_enc_password
 FROM subscriptions
 WHERE passer_id = $1
 ORDER BY id
@@ -114,9 +229,113 @@ func (q *Queries) ListSubscriptionsByPasserId(ctx context.Context, passerID pgty
 			&i.PlsDelete,
 			&i.Message,
 			&i.PasserID,
-			&i.TrustID,
 			&i.IsDisclosed,
 			&i.CustomData,
+			&i.EncVersion,
+			&i.VaultEncPassword,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionsByPasserIdAndReceiverId = `-- name: ListSubscriptionsByPasserIdAndReceiverId :many
+SELECT subscriptions.id, subscriptions.service_name, subscriptions.icon_url, subscriptions.username, subscriptions.email, subscriptions.enc_password, subscriptions.amount, subscriptions.currency, subscriptions.billing_cycle, subscriptions.memo, subscriptions.pls_delete, subscriptions.message, subscriptions.passer_id, subscriptions.is_disclosed, subscriptions.custom_data, subscriptions.enc_version, subscriptions.vault_enc_password, sa.trust_id, sa.permission
+FROM subscriptions
+JOIN subscription_assignments sa ON sa.subscription_id = subscriptions.id
+JOIN trusts t ON sa.trust_id = t.id
+WHERE subscriptions.passer_id = $1 AND t.receiver_user_id = $2 AND t.passer_user_id = subscriptions.passer_id
+  AND NOT sa.is_disclosed
+ORDER BY subscriptions.id, sa.trust_id
+`
+
+type ListSubscriptionsByPasserIdAndReceiverIdParams struct {
+	PasserID       pgtype.UUID
+	ReceiverUserID pgtype.UUID
+}
+
+type ListSubscriptionsByPasserIdAndReceiverIdRow struct {
+	Subscription Subscription
+	TrustID      int32
+	Permission   string
+}
+
+func (q *Queries) ListSubscriptionsByPasserIdAndReceiverId(ctx context.Context, arg ListSubscriptionsByPasserIdAndReceiverIdParams) ([]ListSubscriptionsByPasserIdAndReceiverIdRow, error) {
+	rows, err := q.db.Query(ctx, listSubscriptionsByPasserIdAndReceiverId, arg.PasserID, arg.ReceiverUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubscriptionsByPasserIdAndReceiverIdRow
+	for rows.Next() {
+		var i ListSubscriptionsByPasserIdAndReceiverIdRow
+		if err := rows.Scan(
+			&i.Subscription.ID,
+			&i.Subscription.ServiceName,
+			&i.Subscription.IconUrl,
+			&i.Subscription.Username,
+			&i.Subscription.Email,
+			&i.Subscription.EncPassword,
+			&i.Subscription.Amount,
+			&i.Subscription.Currency,
+			&i.Subscription.BillingCycle,
+			&i.Subscription.Memo,
+			&i.Subscription.PlsDelete,
+			&i.Subscription.Message,
+			&i.Subscription.PasserID,
+			&i.Subscription.IsDisclosed,
+			&i.Subscription.CustomData,
+			&i.Subscription.EncVersion,
+			&i.Subscription.VaultEncPassword,
+			&i.TrustID,
+			&i.Permission,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionsForKeyRotation = `-- name: ListSubscriptionsForKeyRotation :many
+SELECT subscriptions.id, subscriptions.enc_password
+FROM subscriptions
+WHERE subscriptions.passer_id = $1 AND subscriptions.id > $2 AND subscriptions.enc_version > 0
+ORDER BY subscriptions.id
+LIMIT $3
+`
+
+type ListSubscriptionsForKeyRotationParams struct {
+	PasserID pgtype.UUID
+	ID       int32
+	Limit    int32
+}
+
+type ListSubscriptionsForKeyRotationRow struct {
+	ID          int32
+	EncPassword []byte
+}
+
+func (q *Queries) ListSubscriptionsForKeyRotation(ctx context.Context, arg ListSubscriptionsForKeyRotationParams) ([]ListSubscriptionsForKeyRotationRow, error) {
+	rows, err := q.db.Query(ctx, listSubscriptionsForKeyRotation, arg.PasserID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubscriptionsForKeyRotationRow
+	for rows.Next() {
+		var i ListSubscriptionsForKeyRotationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EncPassword,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,56 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TestCreateTrustInvitationReplacesPending は同じメールアドレスをもう一度招待すると、
+// 返事待ちの招待を取り消して新しい招待に置き換えることを確かめます
+func TestCreateTrustInvitationReplacesPending(t *testing.T) {
+	ctx := context.Background()
+	q := New(testDB(t))
+
+	passerID := pgtype.UUID{Bytes: [16]byte{0x01}, Valid: true}
+	if _, err := q.CreateUser(ctx, CreateUserParams{ID: passerID, ClerkUserID: "user_passer"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	expiresAt := pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true}
+	first, err := q.CreateTrustInvitation(ctx, CreateTrustInvitationParams{
+		PasserUserID: passerID,
+		Email:        "heir@example.com",
+		TokenHash:    []byte("first"),
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("first CreateTrustInvitation() error = %v", err)
+	}
+	second, err := q.CreateTrustInvitation(ctx, CreateTrustInvitationParams{
+		PasserUserID: passerID,
+		Email:        "heir@example.com",
+		TokenHash:    []byte("second"),
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("second CreateTrustInvitation() error = %v", err)
+	}
+
+	invitations, err := q.ListTrustInvitationsByPasserID(ctx, passerID)
+	if err != nil {
+		t.Fatalf("ListTrustInvitationsByPasserID() error = %v", err)
+	}
+	statuses := map[int32]string{}
+	for _, invitation := range invitations {
+		statuses[invitation.ID] = invitation.Status
+	}
+	if got := statuses[first.ID]; got != "revoked" {
+		t.Errorf("first invitation status = %q, want %q", got, "revoked")
+	}
+	if got := statuses[second.ID]; got != "pending" {
+		t.Errorf("second invitation status = %q, want %q", got, "pending")
+	}
+}
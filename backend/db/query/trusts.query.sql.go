@@ -24,6 +24,33 @@ func (q *Queries) GetTrust(ctx context.Context, id int32) (Trust, error) {
 	return i, err
 }
 
+const listReceiverIDsByPasserID = `-- name: ListReceiverIDsByPasserID :many
+SELECT DISTINCT receiver_user_id
+FROM trusts
+WHERE passer_user_id = $1
+ORDER BY receiver_user_id
+`
+
+func (q *Queries) ListReceiverIDsByPasserID(ctx context.Context, passerUserID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listReceiverIDsByPasserID, passerUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var receiver_user_id pgtype.UUID
+		if err := rows.Scan(&receiver_user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, receiver_user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTrustersByReceiverID = `-- name: ListTrustersByReceiverID :many
 SELECT u.id, u.clerk_user_id
 FROM trusts
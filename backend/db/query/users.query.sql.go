@@ -7,8 +7,26 @@ package query
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const getUser = `-- name: GetUser :one
+SELECT id, default_receiver_id, clerk_user_id FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.DefaultReceiverID,
+		&i.ClerkUserID,
+	)
+	return i, err
+}
+
 const getUserByClerkID = `-- name: GetUserByClerkID :one
 SELECT id, default_receiver_id, clerk_user_id FROM users
 WHERE clerk_user_id = $1
@@ -20,6 +20,23 @@ SET default_tablespace = '';
 
 SET default_table_access_method = heap;
 
+--
+-- Name: account_assignments; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.account_assignments (
+    account_id integer NOT NULL,
+    trust_id integer NOT NULL,
+    permission text DEFAULT 'credentials'::text NOT NULL,
+    is_disclosed boolean DEFAULT false NOT NULL,
+    receiver_enc_password bytea,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT account_assignments_permission_check CHECK ((permission = ANY (ARRAY['credentials'::text, 'message'::text])))
+);
+
+
+ALTER TABLE public.account_assignments OWNER TO "user";
+
 --
 -- Name: accounts; Type: TABLE; Schema: public; Owner: user
 --
@@ -32,14 +49,17 @@ CREATE TABLE public.accounts (
     app_icon_url text,
     username text DEFAULT ''::text NOT NULL,
     email text DEFAULT ''::text NOT NULL,
-    enc_password bytea NOT NULL,
+    enc_password bytea,
     memo text NOT NULL,
     pls_delete boolean NOT NULL,
     message text NOT NULL,
     passer_id uuid NOT NULL,
-    trust_id integer NOT NULL,
     is_disclosed boolean NOT NULL,
-    custom_data jsonb
+    custom_data jsonb,
+    vault_enc_password bytea,
+    encryption_mode text DEFAULT 'server'::text NOT NULL,
+    CONSTRAINT accounts_enc_password_check CHECK (((encryption_mode = 'server'::text) = (enc_password IS NOT NULL))),
+    CONSTRAINT accounts_encryption_mode_check CHECK ((encryption_mode = ANY (ARRAY['server'::text, 'zero_knowledge'::text])))
 );
 
 
@@ -84,6 +104,50 @@ CREATE TABLE public.alive_check_histories (
 
 ALTER TABLE public.alive_check_histories OWNER TO "user";
 
+--
+-- Name: alive_check_schedules; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.alive_check_schedules (
+    user_id uuid NOT NULL,
+    interval_days integer NOT NULL,
+    grace_days integer NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    next_check_at timestamp without time zone NOT NULL,
+    sent_at timestamp without time zone,
+    last_confirmed_at timestamp without time zone,
+    last_missed_at timestamp without time zone,
+    last_disclosure_id integer,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT alive_check_schedules_grace_days_check CHECK ((grace_days > 0)),
+    CONSTRAINT alive_check_schedules_interval_days_check CHECK ((interval_days > 0))
+);
+
+
+ALTER TABLE public.alive_check_schedules OWNER TO "user";
+
+--
+-- Name: alive_check_tokens; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.alive_check_tokens (
+    token_hash bytea NOT NULL,
+    user_id uuid NOT NULL,
+    purpose text NOT NULL,
+    disclosure_id integer,
+    expires_at timestamp without time zone NOT NULL,
+    used_at timestamp without time zone,
+    superseded_at timestamp without time zone,
+    revoked_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT alive_check_tokens_check CHECK (((purpose = 'disclosure'::text) = (disclosure_id IS NOT NULL))),
+    CONSTRAINT alive_check_tokens_purpose_check CHECK ((purpose = ANY (ARRAY['disclosure'::text, 'scheduled'::text, 'manual'::text])))
+);
+
+
+ALTER TABLE public.alive_check_tokens OWNER TO "user";
+
 --
 -- Name: app_template; Type: TABLE; Schema: public; Owner: user
 --
@@ -120,6 +184,38 @@ ALTER SEQUENCE public.app_template_id_seq OWNER TO "user";
 ALTER SEQUENCE public.app_template_id_seq OWNED BY public.app_template.id;
 
 
+--
+-- Name: client_public_keys; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.client_public_keys (
+    user_id uuid NOT NULL,
+    algorithm text NOT NULL,
+    public_key bytea NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.client_public_keys OWNER TO "user";
+
+--
+-- Name: device_assignments; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.device_assignments (
+    device_id integer NOT NULL,
+    trust_id integer NOT NULL,
+    permission text DEFAULT 'credentials'::text NOT NULL,
+    is_disclosed boolean DEFAULT false NOT NULL,
+    receiver_enc_password bytea,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT device_assignments_permission_check CHECK ((permission = ANY (ARRAY['credentials'::text, 'message'::text])))
+);
+
+
+ALTER TABLE public.device_assignments OWNER TO "user";
+
 --
 -- Name: devices; Type: TABLE; Schema: public; Owner: user
 --
@@ -134,9 +230,11 @@ CREATE TABLE public.devices (
     memo text NOT NULL,
     message text NOT NULL,
     passer_id uuid NOT NULL,
-    trust_id integer NOT NULL,
     is_disclosed boolean NOT NULL,
-    custom_data jsonb
+    custom_data jsonb,
+    enc_version integer DEFAULT 0 NOT NULL,
+    pls_delete boolean DEFAULT false NOT NULL,
+    vault_enc_password bytea
 );
 
 
@@ -164,6 +262,79 @@ ALTER SEQUENCE public.devices_id_seq OWNER TO "user";
 ALTER SEQUENCE public.devices_id_seq OWNED BY public.devices.id;
 
 
+--
+-- Name: disclosure_policies; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.disclosure_policies (
+    passer_id uuid NOT NULL,
+    threshold integer NOT NULL,
+    share_count integer NOT NULL,
+    vault_version integer NOT NULL,
+    unlocked_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    receiver_ids uuid[] DEFAULT '{}'::uuid[] NOT NULL,
+    CONSTRAINT disclosure_policies_threshold_check CHECK (((threshold >= 1) AND (threshold <= share_count)))
+);
+
+
+ALTER TABLE public.disclosure_policies OWNER TO "user";
+
+--
+-- Name: disclosure_reminders; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.disclosure_reminders (
+    disclosure_id integer NOT NULL,
+    step integer NOT NULL,
+    channel text NOT NULL,
+    sent_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.disclosure_reminders OWNER TO "user";
+
+--
+-- Name: disclosure_transitions; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.disclosure_transitions (
+    id integer NOT NULL,
+    disclosure_id integer NOT NULL,
+    from_status text,
+    to_status text NOT NULL,
+    actor text NOT NULL,
+    actor_user_id uuid,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT disclosure_transitions_actor_check CHECK ((actor = ANY (ARRAY['requester'::text, 'passer'::text, 'system'::text])))
+);
+
+
+ALTER TABLE public.disclosure_transitions OWNER TO "user";
+
+--
+-- Name: disclosure_transitions_id_seq; Type: SEQUENCE; Schema: public; Owner: user
+--
+
+CREATE SEQUENCE public.disclosure_transitions_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE public.disclosure_transitions_id_seq OWNER TO "user";
+
+--
+-- Name: disclosure_transitions_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: user
+--
+
+ALTER SEQUENCE public.disclosure_transitions_id_seq OWNED BY public.disclosure_transitions.id;
+
+
 --
 -- Name: disclosures; Type: TABLE; Schema: public; Owner: user
 --
@@ -173,12 +344,13 @@ CREATE TABLE public.disclosures (
     requester_id uuid NOT NULL,
     passer_id uuid NOT NULL,
     issued_time timestamp without time zone NOT NULL,
-    in_progress boolean NOT NULL,
-    disclosed boolean NOT NULL,
     disclosed_at timestamp without time zone,
     prevented_by uuid,
     deadline timestamp without time zone NOT NULL,
-    custom_data jsonb
+    custom_data jsonb,
+    status text DEFAULT 'requested'::text NOT NULL,
+    status_changed_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT disclosures_status_check CHECK ((status = ANY (ARRAY['requested'::text, 'notifying'::text, 'grace'::text, 'vetoed'::text, 'disclosed'::text, 'cancelled'::text, 'expired'::text])))
 );
 
 
@@ -206,6 +378,68 @@ ALTER SEQUENCE public.disclosures_id_seq OWNER TO "user";
 ALTER SEQUENCE public.disclosures_id_seq OWNED BY public.disclosures.id;
 
 
+--
+-- Name: key_rotation_jobs; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.key_rotation_jobs (
+    id integer NOT NULL,
+    user_id uuid NOT NULL,
+    key_version integer NOT NULL,
+    status text DEFAULT 'running'::text NOT NULL,
+    phase text DEFAULT 'accounts'::text NOT NULL,
+    last_item_id integer DEFAULT 0 NOT NULL,
+    total_items integer DEFAULT 0 NOT NULL,
+    processed_items integer DEFAULT 0 NOT NULL,
+    failed_items integer DEFAULT 0 NOT NULL,
+    last_error text,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    completed_at timestamp without time zone,
+    CONSTRAINT key_rotation_jobs_status_check CHECK ((status = ANY (ARRAY['running'::text, 'completed'::text, 'failed'::text])))
+);
+
+
+ALTER TABLE public.key_rotation_jobs OWNER TO "user";
+
+--
+-- Name: key_rotation_jobs_id_seq; Type: SEQUENCE; Schema: public; Owner: user
+--
+
+CREATE SEQUENCE public.key_rotation_jobs_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE public.key_rotation_jobs_id_seq OWNER TO "user";
+
+--
+-- Name: key_rotation_jobs_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: user
+--
+
+ALTER SEQUENCE public.key_rotation_jobs_id_seq OWNED BY public.key_rotation_jobs.id;
+
+
+--
+-- Name: notification_channels; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.notification_channels (
+    user_id uuid NOT NULL,
+    channel text NOT NULL,
+    address text NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT notification_channels_channel_check CHECK ((channel = ANY (ARRAY['email'::text, 'line'::text, 'webhook'::text])))
+);
+
+
+ALTER TABLE public.notification_channels OWNER TO "user";
+
 --
 -- Name: passkeys; Type: TABLE; Schema: public; Owner: user
 --
@@ -246,6 +480,19 @@ ALTER SEQUENCE public.passkeys_id_seq OWNER TO "user";
 ALTER SEQUENCE public.passkeys_id_seq OWNED BY public.passkeys.id;
 
 
+--
+-- Name: reminder_plans; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.reminder_plans (
+    user_id uuid NOT NULL,
+    offsets_hours integer[] NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.reminder_plans OWNER TO "user";
+
 --
 -- Name: schema_migrations; Type: TABLE; Schema: public; Owner: user
 --
@@ -270,6 +517,23 @@ CREATE TABLE public.schema_migrations_seed (
 
 ALTER TABLE public.schema_migrations_seed OWNER TO "user";
 
+--
+-- Name: subscription_assignments; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.subscription_assignments (
+    subscription_id integer NOT NULL,
+    trust_id integer NOT NULL,
+    permission text DEFAULT 'credentials'::text NOT NULL,
+    is_disclosed boolean DEFAULT false NOT NULL,
+    receiver_enc_password bytea,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT subscription_assignments_permission_check CHECK ((permission = ANY (ARRAY['credentials'::text, 'message'::text])))
+);
+
+
+ALTER TABLE public.subscription_assignments OWNER TO "user";
+
 --
 -- Name: subscriptions; Type: TABLE; Schema: public; Owner: user
 --
@@ -288,9 +552,10 @@ CREATE TABLE public.subscriptions (
     pls_delete boolean NOT NULL,
     message text NOT NULL,
     passer_id uuid NOT NULL,
-    trust_id integer NOT NULL,
     is_disclosed boolean NOT NULL,
-    custom_data jsonb
+    custom_data jsonb,
+    enc_version integer DEFAULT 0 NOT NULL,
+    vault_enc_password bytea
 );
 
 
@@ -318,6 +583,49 @@ ALTER SEQUENCE public.subscriptions_id_seq OWNER TO "user";
 ALTER SEQUENCE public.subscriptions_id_seq OWNED BY public.subscriptions.id;
 
 
+--
+-- Name: trust_invitations; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.trust_invitations (
+    id integer NOT NULL,
+    passer_user_id uuid NOT NULL,
+    email text NOT NULL,
+    token_hash bytea NOT NULL,
+    status text DEFAULT 'pending'::text NOT NULL,
+    receiver_user_id uuid,
+    trust_id integer,
+    expires_at timestamp without time zone NOT NULL,
+    responded_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT trust_invitations_status_check CHECK ((status = ANY (ARRAY['pending'::text, 'accepted'::text, 'declined'::text, 'revoked'::text])))
+);
+
+
+ALTER TABLE public.trust_invitations OWNER TO "user";
+
+--
+-- Name: trust_invitations_id_seq; Type: SEQUENCE; Schema: public; Owner: user
+--
+
+CREATE SEQUENCE public.trust_invitations_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER SEQUENCE public.trust_invitations_id_seq OWNER TO "user";
+
+--
+-- Name: trust_invitations_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: user
+--
+
+ALTER SEQUENCE public.trust_invitations_id_seq OWNED BY public.trust_invitations.id;
+
+
 --
 -- Name: trusts; Type: TABLE; Schema: public; Owner: user
 --
@@ -366,6 +674,36 @@ CREATE TABLE public.users (
 
 ALTER TABLE public.users OWNER TO "user";
 
+--
+-- Name: zk_account_secrets; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.zk_account_secrets (
+    account_id integer NOT NULL,
+    format text NOT NULL,
+    ciphertext bytea NOT NULL,
+    owner_key_envelope bytea NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.zk_account_secrets OWNER TO "user";
+
+--
+-- Name: zk_key_envelopes; Type: TABLE; Schema: public; Owner: user
+--
+
+CREATE TABLE public.zk_key_envelopes (
+    account_id integer NOT NULL,
+    receiver_user_id uuid NOT NULL,
+    envelope bytea NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.zk_key_envelopes OWNER TO "user";
+
 --
 -- Name: accounts id; Type: DEFAULT; Schema: public; Owner: user
 --
@@ -387,6 +725,13 @@ ALTER TABLE ONLY public.app_template ALTER COLUMN id SET DEFAULT nextval('public
 ALTER TABLE ONLY public.devices ALTER COLUMN id SET DEFAULT nextval('public.devices_id_seq'::regclass);
 
 
+--
+-- Name: disclosure_transitions id; Type: DEFAULT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.disclosure_transitions ALTER COLUMN id SET DEFAULT nextval('public.disclosure_transitions_id_seq'::regclass);
+
+
 --
 -- Name: disclosures id; Type: DEFAULT; Schema: public; Owner: user
 --
@@ -394,6 +739,13 @@ ALTER TABLE ONLY public.devices ALTER COLUMN id SET DEFAULT nextval('public.devi
 ALTER TABLE ONLY public.disclosures ALTER COLUMN id SET DEFAULT nextval('public.disclosures_id_seq'::regclass);
 
 
+--
+-- Name: key_rotation_jobs id; Type: DEFAULT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.key_rotation_jobs ALTER COLUMN id SET DEFAULT nextval('public.key_rotation_jobs_id_seq'::regclass);
+
+
 --
 -- Name: passkeys id; Type: DEFAULT; Schema: public; Owner: user
 --
@@ -408,6 +760,13 @@ ALTER TABLE ONLY public.passkeys ALTER COLUMN id SET DEFAULT nextval('public.pas
 ALTER TABLE ONLY public.subscriptions ALTER COLUMN id SET DEFAULT nextval('public.subscriptions_id_seq'::regclass);
 
 
+--
+-- Name: trust_invitations id; Type: DEFAULT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.trust_invitations ALTER COLUMN id SET DEFAULT nextval('public.trust_invitations_id_seq'::regclass);
+
+
 --
 -- Name: trusts id; Type: DEFAULT; Schema: public; Owner: user
 --
@@ -415,6 +774,14 @@ ALTER TABLE ONLY public.subscriptions ALTER COLUMN id SET DEFAULT nextval('publi
 ALTER TABLE ONLY public.trusts ALTER COLUMN id SET DEFAULT nextval('public.trusts_id_seq'::regclass);
 
 
+--
+-- Name: account_assignments account_assignments_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.account_assignments
+    ADD CONSTRAINT account_assignments_pkey PRIMARY KEY (account_id, trust_id);
+
+
 --
 -- Name: accounts accounts_pkey; Type: CONSTRAINT; Schema: public; Owner: user
 --
@@ -431,6 +798,22 @@ ALTER TABLE ONLY public.alive_check_histories
     ADD CONSTRAINT alive_check_histories_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: alive_check_schedules alive_check_schedules_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.alive_check_schedules
+    ADD CONSTRAINT alive_check_schedules_pkey PRIMARY KEY (user_id);
+
+
+--
+-- Name: alive_check_tokens alive_check_tokens_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.alive_check_tokens
+    ADD CONSTRAINT alive_check_tokens_pkey PRIMARY KEY (token_hash);
+
+
 --
 -- Name: app_template app_template_pkey; Type: CONSTRAINT; Schema: public; Owner: user
 --
@@ -439,6 +822,22 @@ ALTER TABLE ONLY public.app_template
     ADD CONSTRAINT app_template_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: client_public_keys client_public_keys_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.client_public_keys
+    ADD CONSTRAINT client_public_keys_pkey PRIMARY KEY (user_id);
+
+
+--
+-- Name: device_assignments device_assignments_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.device_assignments
+    ADD CONSTRAINT device_assignments_pkey PRIMARY KEY (device_id, trust_id);
+
+
 --
 -- Name: devices devices_pkey; Type: CONSTRAINT; Schema: public; Owner: user
 --
@@ -447,6 +846,30 @@ ALTER TABLE ONLY public.devices
     ADD CONSTRAINT devices_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: disclosure_policies disclosure_policies_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.disclosure_policies
+    ADD CONSTRAINT disclosure_policies_pkey PRIMARY KEY (passer_id);
+
+
+--
+-- Name: disclosure_reminders disclosure_reminders_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.disclosure_reminders
+    ADD CONSTRAINT disclosure_reminders_pkey PRIMARY KEY (disclosure_id, step, channel);
+
+
+--
+-- Name: disclosure_transitions disclosure_transitions_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.disclosure_transitions
+    ADD CONSTRAINT disclosure_transitions_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: disclosures disclosures_pkey; Type: CONSTRAINT; Schema: public; Owner: user
 --
@@ -455,6 +878,22 @@ ALTER TABLE ONLY public.disclosures
     ADD CONSTRAINT disclosures_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: key_rotation_jobs key_rotation_jobs_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.key_rotation_jobs
+    ADD CONSTRAINT key_rotation_jobs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: notification_channels notification_channels_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.notification_channels
+    ADD CONSTRAINT notification_channels_pkey PRIMARY KEY (user_id, channel);
+
+
 --
 -- Name: passkeys passkeys_credential_id_unique; Type: CONSTRAINT; Schema: public; Owner: user
 --
@@ -479,6 +918,14 @@ ALTER TABLE ONLY public.passkeys
     ADD CONSTRAINT passkeys_user_id_rp_id_unique UNIQUE (user_id, rp_id);
 
 
+--
+-- Name: reminder_plans reminder_plans_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.reminder_plans
+    ADD CONSTRAINT reminder_plans_pkey PRIMARY KEY (user_id);
+
+
 --
 -- Name: schema_migrations schema_migrations_pkey; Type: CONSTRAINT; Schema: public; Owner: user
 --
@@ -495,6 +942,14 @@ ALTER TABLE ONLY public.schema_migrations_seed
     ADD CONSTRAINT schema_migrations_seed_pkey PRIMARY KEY (version);
 
 
+--
+-- Name: subscription_assignments subscription_assignments_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.subscription_assignments
+    ADD CONSTRAINT subscription_assignments_pkey PRIMARY KEY (subscription_id, trust_id);
+
+
 --
 -- Name: subscriptions subscriptions_pkey; Type: CONSTRAINT; Schema: public; Owner: user
 --
@@ -504,10 +959,26 @@ ALTER TABLE ONLY public.subscriptions
 
 
 --
--- Name: trusts trusts_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+-- Name: trust_invitations trust_invitations_pkey; Type: CONSTRAINT; Schema: public; Owner: user
 --
 
-ALTER TABLE ONLY public.trusts
+ALTER TABLE ONLY public.trust_invitations
+    ADD CONSTRAINT trust_invitations_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: trust_invitations trust_invitations_token_hash_key; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.trust_invitations
+    ADD CONSTRAINT trust_invitations_token_hash_key UNIQUE (token_hash);
+
+
+--
+-- Name: trusts trusts_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.trusts
     ADD CONSTRAINT trusts_pkey PRIMARY KEY (id);
 
 
@@ -519,6 +990,129 @@ ALTER TABLE ONLY public.users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: zk_account_secrets zk_account_secrets_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.zk_account_secrets
+    ADD CONSTRAINT zk_account_secrets_pkey PRIMARY KEY (account_id);
+
+
+--
+-- Name: zk_key_envelopes zk_key_envelopes_pkey; Type: CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.zk_key_envelopes
+    ADD CONSTRAINT zk_key_envelopes_pkey PRIMARY KEY (account_id, receiver_user_id);
+
+
+--
+-- Name: account_assignments_trust_id_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX account_assignments_trust_id_idx ON public.account_assignments USING btree (trust_id);
+
+
+--
+-- Name: alive_check_schedules_next_check_at_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX alive_check_schedules_next_check_at_idx ON public.alive_check_schedules USING btree (next_check_at) WHERE (enabled AND (sent_at IS NULL));
+
+
+--
+-- Name: alive_check_schedules_sent_at_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX alive_check_schedules_sent_at_idx ON public.alive_check_schedules USING btree (sent_at) WHERE (enabled AND (sent_at IS NOT NULL));
+
+
+--
+-- Name: alive_check_tokens_disclosure_id_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX alive_check_tokens_disclosure_id_idx ON public.alive_check_tokens USING btree (disclosure_id);
+
+
+--
+-- Name: alive_check_tokens_user_id_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX alive_check_tokens_user_id_idx ON public.alive_check_tokens USING btree (user_id, purpose) WHERE ((used_at IS NULL) AND (superseded_at IS NULL) AND (revoked_at IS NULL));
+
+
+--
+-- Name: device_assignments_trust_id_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX device_assignments_trust_id_idx ON public.device_assignments USING btree (trust_id);
+
+
+--
+-- Name: disclosure_transitions_disclosure_id_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX disclosure_transitions_disclosure_id_idx ON public.disclosure_transitions USING btree (disclosure_id, id);
+
+
+--
+-- Name: key_rotation_jobs_running_user_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE UNIQUE INDEX key_rotation_jobs_running_user_idx ON public.key_rotation_jobs USING btree (user_id) WHERE (status = 'running'::text);
+
+
+--
+-- Name: subscription_assignments_trust_id_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX subscription_assignments_trust_id_idx ON public.subscription_assignments USING btree (trust_id);
+
+
+--
+-- Name: trust_invitations_email_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX trust_invitations_email_idx ON public.trust_invitations USING btree (email) WHERE ((status = 'pending'::text) AND (receiver_user_id IS NULL));
+
+
+--
+-- Name: trust_invitations_pending_email_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE UNIQUE INDEX trust_invitations_pending_email_idx ON public.trust_invitations USING btree (passer_user_id, email) WHERE (status = 'pending'::text);
+
+
+--
+-- Name: trust_invitations_receiver_user_id_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX trust_invitations_receiver_user_id_idx ON public.trust_invitations USING btree (receiver_user_id);
+
+
+--
+-- Name: trust_invitations_trust_id_idx; Type: INDEX; Schema: public; Owner: user
+--
+
+CREATE INDEX trust_invitations_trust_id_idx ON public.trust_invitations USING btree (trust_id);
+
+
+--
+-- Name: account_assignments account_assignments_account_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.account_assignments
+    ADD CONSTRAINT account_assignments_account_id_fkey FOREIGN KEY (account_id) REFERENCES public.accounts(id) ON DELETE CASCADE;
+
+
+--
+-- Name: account_assignments account_assignments_trust_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.account_assignments
+    ADD CONSTRAINT account_assignments_trust_id_fkey FOREIGN KEY (trust_id) REFERENCES public.trusts(id) ON DELETE CASCADE;
+
+
 --
 -- Name: accounts accounts_app_template_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
 --
@@ -536,19 +1130,67 @@ ALTER TABLE ONLY public.accounts
 
 
 --
--- Name: accounts accounts_trust_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+-- Name: alive_check_histories alive_check_histories_target_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
 --
 
-ALTER TABLE ONLY public.accounts
-    ADD CONSTRAINT accounts_trust_id_fkey FOREIGN KEY (trust_id) REFERENCES public.trusts(id);
+ALTER TABLE ONLY public.alive_check_histories
+    ADD CONSTRAINT alive_check_histories_target_user_id_fkey FOREIGN KEY (target_user_id) REFERENCES public.users(id);
 
 
 --
--- Name: alive_check_histories alive_check_histories_target_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+-- Name: alive_check_schedules alive_check_schedules_last_disclosure_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
 --
 
-ALTER TABLE ONLY public.alive_check_histories
-    ADD CONSTRAINT alive_check_histories_target_user_id_fkey FOREIGN KEY (target_user_id) REFERENCES public.users(id);
+ALTER TABLE ONLY public.alive_check_schedules
+    ADD CONSTRAINT alive_check_schedules_last_disclosure_id_fkey FOREIGN KEY (last_disclosure_id) REFERENCES public.disclosures(id) ON DELETE SET NULL;
+
+
+--
+-- Name: alive_check_schedules alive_check_schedules_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.alive_check_schedules
+    ADD CONSTRAINT alive_check_schedules_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: alive_check_tokens alive_check_tokens_disclosure_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.alive_check_tokens
+    ADD CONSTRAINT alive_check_tokens_disclosure_id_fkey FOREIGN KEY (disclosure_id) REFERENCES public.disclosures(id) ON DELETE CASCADE;
+
+
+--
+-- Name: alive_check_tokens alive_check_tokens_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.alive_check_tokens
+    ADD CONSTRAINT alive_check_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: client_public_keys client_public_keys_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.client_public_keys
+    ADD CONSTRAINT client_public_keys_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);
+
+
+--
+-- Name: device_assignments device_assignments_device_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.device_assignments
+    ADD CONSTRAINT device_assignments_device_id_fkey FOREIGN KEY (device_id) REFERENCES public.devices(id) ON DELETE CASCADE;
+
+
+--
+-- Name: device_assignments device_assignments_trust_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.device_assignments
+    ADD CONSTRAINT device_assignments_trust_id_fkey FOREIGN KEY (trust_id) REFERENCES public.trusts(id) ON DELETE CASCADE;
 
 
 --
@@ -560,11 +1202,35 @@ ALTER TABLE ONLY public.devices
 
 
 --
--- Name: devices devices_trust_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+-- Name: disclosure_policies disclosure_policies_passer_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
 --
 
-ALTER TABLE ONLY public.devices
-    ADD CONSTRAINT devices_trust_id_fkey FOREIGN KEY (trust_id) REFERENCES public.trusts(id);
+ALTER TABLE ONLY public.disclosure_policies
+    ADD CONSTRAINT disclosure_policies_passer_id_fkey FOREIGN KEY (passer_id) REFERENCES public.users(id);
+
+
+--
+-- Name: disclosure_reminders disclosure_reminders_disclosure_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.disclosure_reminders
+    ADD CONSTRAINT disclosure_reminders_disclosure_id_fkey FOREIGN KEY (disclosure_id) REFERENCES public.disclosures(id) ON DELETE CASCADE;
+
+
+--
+-- Name: disclosure_transitions disclosure_transitions_actor_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.disclosure_transitions
+    ADD CONSTRAINT disclosure_transitions_actor_user_id_fkey FOREIGN KEY (actor_user_id) REFERENCES public.users(id) ON DELETE SET NULL;
+
+
+--
+-- Name: disclosure_transitions disclosure_transitions_disclosure_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.disclosure_transitions
+    ADD CONSTRAINT disclosure_transitions_disclosure_id_fkey FOREIGN KEY (disclosure_id) REFERENCES public.disclosures(id) ON DELETE CASCADE;
 
 
 --
@@ -591,6 +1257,22 @@ ALTER TABLE ONLY public.disclosures
     ADD CONSTRAINT disclosures_requester_id_fkey FOREIGN KEY (requester_id) REFERENCES public.users(id);
 
 
+--
+-- Name: key_rotation_jobs key_rotation_jobs_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.key_rotation_jobs
+    ADD CONSTRAINT key_rotation_jobs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);
+
+
+--
+-- Name: notification_channels notification_channels_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.notification_channels
+    ADD CONSTRAINT notification_channels_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
 --
 -- Name: passkeys passkeys_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
 --
@@ -599,6 +1281,30 @@ ALTER TABLE ONLY public.passkeys
     ADD CONSTRAINT passkeys_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);
 
 
+--
+-- Name: reminder_plans reminder_plans_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.reminder_plans
+    ADD CONSTRAINT reminder_plans_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: subscription_assignments subscription_assignments_subscription_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.subscription_assignments
+    ADD CONSTRAINT subscription_assignments_subscription_id_fkey FOREIGN KEY (subscription_id) REFERENCES public.subscriptions(id) ON DELETE CASCADE;
+
+
+--
+-- Name: subscription_assignments subscription_assignments_trust_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.subscription_assignments
+    ADD CONSTRAINT subscription_assignments_trust_id_fkey FOREIGN KEY (trust_id) REFERENCES public.trusts(id) ON DELETE CASCADE;
+
+
 --
 -- Name: subscriptions subscriptions_passer_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
 --
@@ -608,11 +1314,27 @@ ALTER TABLE ONLY public.subscriptions
 
 
 --
--- Name: subscriptions subscriptions_trust_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+-- Name: trust_invitations trust_invitations_passer_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
 --
 
-ALTER TABLE ONLY public.subscriptions
-    ADD CONSTRAINT subscriptions_trust_id_fkey FOREIGN KEY (trust_id) REFERENCES public.trusts(id);
+ALTER TABLE ONLY public.trust_invitations
+    ADD CONSTRAINT trust_invitations_passer_user_id_fkey FOREIGN KEY (passer_user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: trust_invitations trust_invitations_receiver_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.trust_invitations
+    ADD CONSTRAINT trust_invitations_receiver_user_id_fkey FOREIGN KEY (receiver_user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: trust_invitations trust_invitations_trust_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.trust_invitations
+    ADD CONSTRAINT trust_invitations_trust_id_fkey FOREIGN KEY (trust_id) REFERENCES public.trusts(id) ON DELETE SET NULL;
 
 
 --
@@ -639,6 +1361,56 @@ ALTER TABLE ONLY public.users
     ADD CONSTRAINT users_default_receiver_id_fkey FOREIGN KEY (default_receiver_id) REFERENCES public.users(id);
 
 
+--
+-- Name: zk_account_secrets zk_account_secrets_account_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.zk_account_secrets
+    ADD CONSTRAINT zk_account_secrets_account_id_fkey FOREIGN KEY (account_id) REFERENCES public.accounts(id) ON DELETE CASCADE;
+
+
+--
+-- Name: zk_key_envelopes zk_key_envelopes_account_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.zk_key_envelopes
+    ADD CONSTRAINT zk_key_envelopes_account_id_fkey FOREIGN KEY (account_id) REFERENCES public.accounts(id) ON DELETE CASCADE;
+
+
+--
+-- Name: zk_key_envelopes zk_key_envelopes_receiver_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: user
+--
+
+ALTER TABLE ONLY public.zk_key_envelopes
+    ADD CONSTRAINT zk_key_envelopes_receiver_user_id_fkey FOREIGN KEY (receiver_user_id) REFERENCES public.users(id);
+
+
+--
+-- Name: account_assignments; Type: ROW SECURITY; Schema: public; Owner: user
+--
+
+ALTER TABLE public.account_assignments ENABLE ROW LEVEL SECURITY;
+
+--
+-- Name: account_assignments account_assignments_modification; Type: POLICY; Schema: public; Owner: user
+--
+
+CREATE POLICY account_assignments_modification ON public.account_assignments USING ((EXISTS ( SELECT 1
+   FROM public.trusts t
+  WHERE ((t.id = account_assignments.trust_id) AND (t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid))))) WITH CHECK ((EXISTS ( SELECT 1
+   FROM public.trusts t
+  WHERE ((t.id = account_assignments.trust_id) AND (t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)))));
+
+
+--
+-- Name: account_assignments account_assignments_select; Type: POLICY; Schema: public; Owner: user
+--
+
+CREATE POLICY account_assignments_select ON public.account_assignments FOR SELECT USING ((EXISTS ( SELECT 1
+   FROM public.trusts t
+  WHERE ((t.id = account_assignments.trust_id) AND ((t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) OR (account_assignments.is_disclosed AND (t.receiver_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)))))));
+
+
 --
 -- Name: accounts; Type: ROW SECURITY; Schema: public; Owner: user
 --
@@ -649,16 +1421,43 @@ ALTER TABLE public.accounts ENABLE ROW LEVEL SECURITY;
 -- Name: accounts accounts_modification; Type: POLICY; Schema: public; Owner: user
 --
 
-CREATE POLICY accounts_modification ON public.accounts USING ((passer_id = (current_setting('digi_baton.current_user_id'::text))::uuid)) WITH CHECK ((passer_id = (current_setting('digi_baton.current_user_id'::text))::uuid));
+CREATE POLICY accounts_modification ON public.accounts USING ((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)) WITH CHECK ((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid));
 
 
 --
 -- Name: accounts accounts_select; Type: POLICY; Schema: public; Owner: user
 --
 
-CREATE POLICY accounts_select ON public.accounts FOR SELECT USING (((passer_id = (current_setting('digi_baton.current_user_id'::text))::uuid) OR (is_disclosed AND (EXISTS ( SELECT 1
+CREATE POLICY accounts_select ON public.accounts FOR SELECT USING (((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) OR (EXISTS ( SELECT 1
+   FROM (public.account_assignments aa
+     JOIN public.trusts t ON ((t.id = aa.trust_id)))
+  WHERE ((aa.account_id = accounts.id) AND aa.is_disclosed AND (t.receiver_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) AND (t.passer_user_id = accounts.passer_id))))));
+
+
+--
+-- Name: device_assignments; Type: ROW SECURITY; Schema: public; Owner: user
+--
+
+ALTER TABLE public.device_assignments ENABLE ROW LEVEL SECURITY;
+
+--
+-- Name: device_assignments device_assignments_modification; Type: POLICY; Schema: public; Owner: user
+--
+
+CREATE POLICY device_assignments_modification ON public.device_assignments USING ((EXISTS ( SELECT 1
+   FROM public.trusts t
+  WHERE ((t.id = device_assignments.trust_id) AND (t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid))))) WITH CHECK ((EXISTS ( SELECT 1
+   FROM public.trusts t
+  WHERE ((t.id = device_assignments.trust_id) AND (t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)))));
+
+
+--
+-- Name: device_assignments device_assignments_select; Type: POLICY; Schema: public; Owner: user
+--
+
+CREATE POLICY device_assignments_select ON public.device_assignments FOR SELECT USING ((EXISTS ( SELECT 1
    FROM public.trusts t
-  WHERE ((t.id = accounts.trust_id) AND (t.receiver_user_id = (current_setting('digi_baton.current_user_id'::text))::uuid) AND (t.passer_user_id = accounts.passer_id)))))));
+  WHERE ((t.id = device_assignments.trust_id) AND ((t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) OR (device_assignments.is_disclosed AND (t.receiver_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)))))));
 
 
 --
@@ -671,16 +1470,325 @@ ALTER TABLE public.devices ENABLE ROW LEVEL SECURITY;
 -- Name: devices devices_modification; Type: POLICY; Schema: public; Owner: user
 --
 
-CREATE POLICY devices_modification ON public.devices USING ((passer_id = (current_setting('digi_baton.current_user_id'::text))::uuid)) WITH CHECK ((passer_id = (current_setting('digi_baton.current_user_id'::text))::uuid));
+CREATE POLICY devices_modification ON public.devices USING ((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)) WITH CHECK ((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid));
 
 
 --
 -- Name: devices devices_select; Type: POLICY; Schema: public; Owner: user
 --
 
-CREATE POLICY devices_select ON public.devices FOR SELECT USING (((passer_id = (current_setting('digi_baton.current_user_id'::text))::uuid) OR (is_disclosed AND (EXISTS ( SELECT 1
+CREATE POLICY devices_select ON public.devices FOR SELECT USING (((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) OR (EXISTS ( SELECT 1
+   FROM (public.device_assignments da
+     JOIN public.trusts t ON ((t.id = da.trust_id)))
+  WHERE ((da.device_id = devices.id) AND da.is_disclosed AND (t.receiver_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) AND (t.passer_user_id = devices.passer_id))))));
+
+
+--
+-- Name: subscription_assignments; Type: ROW SECURITY; Schema: public; Owner: user
+--
+
+ALTER TABLE public.subscription_assignments ENABLE ROW LEVEL SECURITY;
+
+--
+-- Name: subscription_assignments subscription_assignments_modification; Type: POLICY; Schema: public; Owner: user
+--
+
+CREATE POLICY subscription_assignments_modification ON public.subscription_assignments USING ((EXISTS ( SELECT 1
+   FROM public.trusts t
+  WHERE ((t.id = subscription_assignments.trust_id) AND (t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid))))) WITH CHECK ((EXISTS ( SELECT 1
+   FROM public.trusts t
+  WHERE ((t.id = subscription_assignments.trust_id) AND (t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)))));
+
+
+--
+-- Name: subscription_assignments subscription_assignments_select; Type: POLICY; Schema: public; Owner: user
+--
+
+CREATE POLICY subscription_assignments_select ON public.subscription_assignments FOR SELECT USING ((EXISTS ( SELECT 1
    FROM public.trusts t
-  WHERE ((t.id = devices.trust_id) AND (t.receiver_user_id = (current_setting('digi_baton.current_user_id'::text))::uuid) AND (t.passer_user_id = devices.passer_id)))))));
+  WHERE ((t.id = subscription_assignments.trust_id) AND ((t.passer_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) OR (subscription_assignments.is_disclosed AND (t.receiver_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)))))));
+
+
+--
+-- Name: subscriptions; Type: ROW SECURITY; Schema: public; Owner: user
+--
+
+ALTER TABLE public.subscriptions ENABLE ROW LEVEL SECURITY;
+
+--
+-- Name: subscriptions subscriptions_modification; Type: POLICY; Schema: public; Owner: user
+--
+
+CREATE POLICY subscriptions_modification ON public.subscriptions USING ((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid)) WITH CHECK ((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid));
+
+
+--
+-- Name: subscriptions subscriptions_select; Type: POLICY; Schema: public; Owner: user
+--
+
+CREATE POLICY subscriptions_select ON public.subscriptions FOR SELECT USING (((passer_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) OR (EXISTS ( SELECT 1
+   FROM (public.subscription_assignments sa
+     JOIN public.trusts t ON ((t.id = sa.trust_id)))
+  WHERE ((sa.subscription_id = subscriptions.id) AND sa.is_disclosed AND (t.receiver_user_id = (NULLIF(current_setting('digi_baton.current_user_id'::text, true), ''::text))::uuid) AND (t.passer_user_id = subscriptions.passer_id))))));
+
+
+--
+-- Name: SCHEMA public; Type: ACL; Schema: -; Owner: pg_database_owner
+--
+
+GRANT USAGE ON SCHEMA public TO digi_baton_app;
+
+
+--
+-- Name: TABLE account_assignments; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.account_assignments TO digi_baton_app;
+
+
+--
+-- Name: TABLE accounts; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.accounts TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE accounts_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.accounts_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE alive_check_histories; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.alive_check_histories TO digi_baton_app;
+
+
+--
+-- Name: TABLE alive_check_schedules; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.alive_check_schedules TO digi_baton_app;
+
+
+--
+-- Name: TABLE alive_check_tokens; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.alive_check_tokens TO digi_baton_app;
+
+
+--
+-- Name: TABLE app_template; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.app_template TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE app_template_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.app_template_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE client_public_keys; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.client_public_keys TO digi_baton_app;
+
+
+--
+-- Name: TABLE device_assignments; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.device_assignments TO digi_baton_app;
+
+
+--
+-- Name: TABLE devices; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.devices TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE devices_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.devices_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE disclosure_policies; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.disclosure_policies TO digi_baton_app;
+
+
+--
+-- Name: TABLE disclosure_reminders; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.disclosure_reminders TO digi_baton_app;
+
+
+--
+-- Name: TABLE disclosure_transitions; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.disclosure_transitions TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE disclosure_transitions_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.disclosure_transitions_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE disclosures; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.disclosures TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE disclosures_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.disclosures_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE key_rotation_jobs; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.key_rotation_jobs TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE key_rotation_jobs_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.key_rotation_jobs_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE notification_channels; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.notification_channels TO digi_baton_app;
+
+
+--
+-- Name: TABLE passkeys; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.passkeys TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE passkeys_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.passkeys_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE reminder_plans; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.reminder_plans TO digi_baton_app;
+
+
+--
+-- Name: TABLE schema_migrations; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.schema_migrations TO digi_baton_app;
+
+
+--
+-- Name: TABLE schema_migrations_seed; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.schema_migrations_seed TO digi_baton_app;
+
+
+--
+-- Name: TABLE subscription_assignments; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.subscription_assignments TO digi_baton_app;
+
+
+--
+-- Name: TABLE subscriptions; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.subscriptions TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE subscriptions_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.subscriptions_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE trusts; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.trusts TO digi_baton_app;
+
+
+--
+-- Name: SEQUENCE trusts_id_seq; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,USAGE ON SEQUENCE public.trusts_id_seq TO digi_baton_app;
+
+
+--
+-- Name: TABLE users; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.users TO digi_baton_app;
+
+
+--
+-- Name: TABLE zk_account_secrets; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.zk_account_secrets TO digi_baton_app;
+
+
+--
+-- Name: TABLE zk_key_envelopes; Type: ACL; Schema: public; Owner: user
+--
+
+GRANT SELECT,INSERT,DELETE,UPDATE ON TABLE public.zk_key_envelopes TO digi_baton_app;
+
+
+--
+-- Name: DEFAULT PRIVILEGES FOR SEQUENCES; Type: DEFAULT ACL; Schema: public; Owner: user
+--
+
+ALTER DEFAULT PRIVILEGES FOR ROLE "user" IN SCHEMA public GRANT SELECT,USAGE ON SEQUENCES TO digi_baton_app;
+
+
+--
+-- Name: DEFAULT PRIVILEGES FOR TABLES; Type: DEFAULT ACL; Schema: public; Owner: user
+--
+
+ALTER DEFAULT PRIVILEGES FOR ROLE "user" IN SCHEMA public GRANT SELECT,INSERT,DELETE,UPDATE ON TABLES TO digi_baton_app;
 
 
 --
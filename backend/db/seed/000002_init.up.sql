@@ -84,7 +84,6 @@ INSERT INTO accounts (
     pls_delete,
     message,
     passer_id,
-    trust_id,
     is_disclosed,
     custom_data
 ) VALUES 
@@ -101,7 +100,6 @@ INSERT INTO accounts (
     false, 
     'This is my main google account',
     '00000000-0000-0000-0000-000000000001', -- Sample UUID for passer_id
-    0, 
     false, 
     '{"recovery_email": "backup@example.com", "last_login": "2023-01-01"}'::jsonb
 ),
@@ -118,7 +116,6 @@ INSERT INTO accounts (
     false, 
     'Used for work-related communications', 
     '00000000-0000-0000-0000-000000000001', -- Same passer_id as above
-    0, 
     false, 
     '{"phone_number": "+1234567890", "two_factor_enabled": true}'::jsonb
 ),
@@ -135,7 +132,6 @@ INSERT INTO accounts (
     false, 
     'Used for work-related communications', 
     '00000000-0000-0000-0000-000000000001', -- Same passer_id as above
-    0,
     false,
     null
 ),
@@ -152,7 +148,6 @@ INSERT INTO accounts (
     false, 
     '', 
     '00000000-0000-0000-0000-000000000001', -- Same passer_id as above
-    0, 
     false, 
     '{"recovery_email": "backup@example.com", "last_login": "2023-01-01"}'::jsonb
 );
@@ -170,7 +165,6 @@ INSERT INTO subscriptions (
     pls_delete,
     message,
     passer_id,
-    trust_id,
     is_disclosed,
     custom_data
 ) VALUES
@@ -187,7 +181,6 @@ INSERT INTO subscriptions (
     false,
     '家族で共有しているNetflixアカウント',
     '00000000-0000-0000-0000-000000000001', -- Sample UUID for passer_id
-    0,
     false,
     '{"payment_method": "credit_card", "next_billing_date": "2023-06-15"}'::jsonb
 ),
@@ -204,7 +197,6 @@ INSERT INTO subscriptions (
     false,
     '音楽ストリーミングサービス',
     '00000000-0000-0000-0000-000000000001',
-    0,
     false,
     '{"payment_method": "paypal", "auto_renew": true}'::jsonb
 ),
@@ -221,7 +213,6 @@ INSERT INTO subscriptions (
     false,
     '配送無料とPrimeビデオを利用中',
     '00000000-0000-0000-0000-000000000001',
-    0,
     false,
     '{"member_since": "2020-03-10", "benefits": ["free_shipping", "prime_video", "prime_reading"]}'::jsonb
 ),
@@ -238,7 +229,6 @@ INSERT INTO subscriptions (
     false,
     '写真編集に使用',
     '00000000-0000-0000-0000-000000000001',
-    0,
     false,
     '{"apps": ["photoshop", "lightroom"], "storage": "20GB"}'::jsonb
 ),
@@ -255,7 +245,6 @@ INSERT INTO subscriptions (
     false,
     'YouTube Musicも含まれる',
     '00000000-0000-0000-0000-000000000001',
-    0,
     false,
     '{"family_plan": false, "youtube_music": true}'::jsonb
 );
@@ -269,7 +258,6 @@ INSERT INTO devices (
     memo,
     message,
     passer_id,
-    trust_id,
     is_disclosed,
     custom_data
 ) VALUES
@@ -282,7 +270,6 @@ INSERT INTO devices (
     '仕事用のMacBook',
     'パスワードは大文字小文字と数字を含む',
     '00000000-0000-0000-0000-000000000001', -- Same passer_id as above
-    0,
     false,
     '{"purchase_date": "2022-10-15", "os": "macOS Ventura", "serial_number": "C02ZN3YBLVCG"}'::jsonb
 ),
@@ -295,7 +282,6 @@ INSERT INTO devices (
     '自宅のデスクトップPC',
     'ゲームとプライベート作業用',
     '00000000-0000-0000-0000-000000000001',
-    0,
     false,
     '{"specs": {"cpu": "Intel Core i7", "ram": "32GB", "storage": "1TB SSD"}, "location": "自宅書斎"}'::jsonb
 ),
@@ -308,7 +294,6 @@ INSERT INTO devices (
     'メインスマートフォン',
     'Face IDも設定済み',
     '00000000-0000-0000-0000-000000000001',
-    0,
     false,
     '{"phone_number": "+81-90-1234-5678", "icloud_enabled": true, "color": "スペースブラック"}'::jsonb
 ),
@@ -321,7 +306,6 @@ INSERT INTO devices (
     'テスト用Android端末',
     'アプリ開発のテスト用',
     '00000000-0000-0000-0000-000000000001',
-    0,
     false,
     '{"android_version": "Android 13", "screen_lock": "pattern"}'::jsonb
 ),
@@ -334,11 +318,31 @@ INSERT INTO devices (
     '出張時のサブデバイス',
     'Apple Pencilでメモを取るのに使用',
     '00000000-0000-0000-0000-000000000001',
-    0,
     false,
     '{"accessories": ["Apple Pencil", "Magic Keyboard"], "cellular": true, "storage": "256GB"}'::jsonb
 );
 
+-- 受取人ごとの割り当て。SNS は2人目の受取人にも伝言だけを見せる
+INSERT INTO account_assignments (account_id, trust_id, permission)
+SELECT id, 0, 'credentials'
+FROM accounts
+WHERE passer_id = '00000000-0000-0000-0000-000000000001';
+
+INSERT INTO account_assignments (account_id, trust_id, permission)
+SELECT id, 1, 'message'
+FROM accounts
+WHERE passer_id = '00000000-0000-0000-0000-000000000001' AND app_template_id IN (2, 3);
+
+INSERT INTO subscription_assignments (subscription_id, trust_id, permission)
+SELECT id, 0, 'credentials'
+FROM subscriptions
+WHERE passer_id = '00000000-0000-0000-0000-000000000001';
+
+INSERT INTO device_assignments (device_id, trust_id, permission)
+SELECT id, 0, 'credentials'
+FROM devices
+WHERE passer_id = '00000000-0000-0000-0000-000000000001';
+
 -- disclosuresテーブルのシードデータ
 INSERT INTO disclosures (
     id,
@@ -346,8 +350,8 @@ INSERT INTO disclosures (
     requester_id,
     issued_time,
     deadline,
-    disclosed,
-    in_progress,
+    status,
+    status_changed_at,
     prevented_by,
     custom_data
 ) VALUES
@@ -357,8 +361,21 @@ INSERT INTO disclosures (
     '00000000-0000-0000-0000-000000000001', -- 申請者ID (山田花子)
     NOW() - INTERVAL '5 days',
     NOW() + INTERVAL '25 days',
-    false,
-    true,
+    'grace',
+    NOW() - INTERVAL '5 days',
     NULL,
     '{"reason": "家族のサポート", "relationship": "daughter"}'::jsonb
-);
\ No newline at end of file
+);
+
+-- disclosure_transitionsテーブルのシードデータ
+INSERT INTO disclosure_transitions (
+    disclosure_id,
+    from_status,
+    to_status,
+    actor,
+    actor_user_id,
+    created_at
+) VALUES
+(0, NULL, 'requested', 'requester', '00000000-0000-0000-0000-000000000001', NOW() - INTERVAL '5 days'),
+(0, 'requested', 'notifying', 'system', NULL, NOW() - INTERVAL '5 days'),
+(0, 'notifying', 'grace', 'system', NULL, NOW() - INTERVAL '5 days');
\ No newline at end of file
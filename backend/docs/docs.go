@@ -53,7 +53,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "アカウントを更新する",
+                "description": "自分のアカウントを更新する",
                 "consumes": [
                     "application/json"
                 ],
@@ -88,6 +88,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "アカウントか相続関係が見つかりません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "データベース接続に失敗しました",
                         "schema": {
@@ -97,7 +115,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "アカウントを作成する",
+                "description": "自分のアカウントを作成し、assignments (または trustID) で指定した受取人に託す",
                 "consumes": [
                     "application/json"
                 ],
@@ -132,6 +150,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "データベース接続に失敗しました",
                         "schema": {
@@ -141,7 +177,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "アカウントを削除する",
+                "description": "自分のアカウントを削除する",
                 "consumes": [
                     "application/json"
                 ],
@@ -176,6 +212,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "アカウントが見つかりません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "データベース接続に失敗しました",
                         "schema": {
@@ -185,9 +233,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/accounts/templates": {
-            "get": {
-                "description": "アカウントテンプレートの一覧取得",
+        "/accounts/assignments": {
+            "put": {
+                "description": "自分のアカウントを託す受取人と、受取人ごとに見せる範囲をまとめて置き換える。すでに開示された受取人にはその場で引き渡す",
                 "consumes": [
                     "application/json"
                 ],
@@ -197,40 +245,16 @@ const docTemplate = `{
                 "tags": [
                     "accounts"
                 ],
-                "summary": "アカウントテンプレート一覧",
-                "responses": {
-                    "200": {
-                        "description": "成功",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/handlers.AccountTemplateResponse"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/alive-checks": {
-            "get": {
-                "description": "アライブチェック履歴一覧を取得する",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "aliveChecks"
-                ],
-                "summary": "アライブチェック履歴一覧取得",
+                "summary": "アカウントの割り当て更新",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "アライブチェック履歴を取得するユーザのID",
-                        "name": "passerID",
-                        "in": "query",
-                        "required": true
+                        "description": "割り当て",
+                        "name": "assignments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AssignmentsUpdateRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -239,44 +263,30 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/handlers.AliveCheckHistoryResponse"
+                                "$ref": "#/definitions/handlers.AssignmentResponse"
                             }
                         }
-                    }
-                }
-            },
-            "put": {
-                "description": "アライブチェック履歴を更新する",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "aliveChecks"
-                ],
-                "summary": "アライブチェック履歴更新",
-                "parameters": [
-                    {
-                        "description": "アライブチェック履歴更新リクエスト",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
                         "schema": {
-                            "$ref": "#/definitions/handlers.AliveCheckHistoryUpdateRequest"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "成功",
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
                         "schema": {
-                            "$ref": "#/definitions/handlers.AliveCheckHistoryResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "400": {
-                        "description": "リクエストデータが不正です",
+                    "404": {
+                        "description": "アカウントか相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -288,9 +298,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "アライブチェック履歴を作成する",
+            }
+        },
+        "/accounts/disclosed": {
+            "get": {
+                "description": "受取人として開示されたアカウント一覧を取得する。パスワードは受取人自身の鍵で復号する",
                 "consumes": [
                     "application/json"
                 ],
@@ -298,25 +310,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "aliveChecks"
-                ],
-                "summary": "アライブチェック履歴作成",
-                "parameters": [
-                    {
-                        "description": "アライブチェック履歴作成リクエスト",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.AliveCheckHistoryCreateRequest"
-                        }
-                    }
+                    "accounts"
                 ],
+                "summary": "開示されたアカウント一覧取得",
                 "responses": {
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.AliveCheckHistoryResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.AccountResponse"
+                            }
                         }
                     },
                     "400": {
@@ -334,9 +338,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/devices": {
+        "/accounts/templates": {
             "get": {
-                "description": "ユーザが開示しているデバイス一覧を取得する",
+                "description": "アカウントテンプレートの一覧取得",
                 "consumes": [
                     "application/json"
                 ],
@@ -344,27 +348,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "accounts"
                 ],
-                "summary": "デバイス一覧取得",
+                "summary": "アカウントテンプレート一覧",
                 "responses": {
                     "200": {
                         "description": "成功",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/handlers.DeviceResponse"
+                                "$ref": "#/definitions/handlers.AccountTemplateResponse"
                             }
                         }
+                    }
+                }
+            }
+        },
+        "/alive-check-schedule": {
+            "get": {
+                "description": "定期的な生存確認の設定と、次に確認のリンクを送る日時を取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alive-check-schedule"
+                ],
+                "summary": "定期的な生存確認の取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AliveCheckScheduleResponse"
+                        }
                     },
-                    "401": {
-                        "description": "認証に失敗しました",
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "定期的な生存確認が設定されていません",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "データベース接続に失敗しました",
+                        "description": "定期的な生存確認の取得に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -372,7 +405,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "デバイスを更新する",
+                "description": "確認のリンクを送る間隔と猶予を設定する。猶予までに確認がなければ既定の受取人の名前で開示請求を出すため、有効にするには既定の受取人の設定が必要",
                 "consumes": [
                     "application/json"
                 ],
@@ -380,17 +413,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "alive-check-schedule"
                 ],
-                "summary": "デバイス更新",
+                "summary": "定期的な生存確認の設定",
                 "parameters": [
                     {
-                        "description": "デバイス情報",
-                        "name": "device",
+                        "description": "定期的な生存確認",
+                        "name": "schedule",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.DeviceCreateRequest"
+                            "$ref": "#/definitions/handlers.AliveCheckScheduleRequest"
                         }
                     }
                 ],
@@ -398,7 +431,7 @@ const docTemplate = `{
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.DeviceResponse"
+                            "$ref": "#/definitions/handlers.AliveCheckScheduleResponse"
                         }
                     },
                     "400": {
@@ -408,15 +441,15 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "データベース接続に失敗しました",
+                        "description": "定期的な生存確認の設定に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "デバイスを追加する",
+            "delete": {
+                "description": "定期的な生存確認をやめる。確認待ちのリンクがあっても開示請求は出さない",
                 "consumes": [
                     "application/json"
                 ],
@@ -424,43 +457,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
-                ],
-                "summary": "デバイス追加",
-                "parameters": [
-                    {
-                        "description": "デバイス情報",
-                        "name": "device",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.DeviceCreateRequest"
-                        }
-                    }
+                    "alive-check-schedule"
                 ],
+                "summary": "定期的な生存確認の削除",
                 "responses": {
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.DeviceResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "リクエストデータが不正です",
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "定期的な生存確認が設定されていません",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "データベース接続に失敗しました",
+                        "description": "定期的な生存確認の削除に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "デバイスを削除する",
+            }
+        },
+        "/alive-check-schedule/check-in": {
+            "post": {
+                "description": "リンクを待たずに生存を確認し、次に確認のリンクを送る日時を今から間隔の日数後にする",
                 "consumes": [
                     "application/json"
                 ],
@@ -468,35 +501,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
-                ],
-                "summary": "デバイス削除",
-                "parameters": [
-                    {
-                        "description": "デバイス情報",
-                        "name": "device",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.DeleteDeviceCreateRequest"
-                        }
-                    }
+                    "alive-check-schedule"
                 ],
+                "summary": "アプリからの生存確認",
                 "responses": {
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.DeviceResponse"
+                            "$ref": "#/definitions/handlers.AliveCheckScheduleResponse"
                         }
                     },
                     "400": {
-                        "description": "リクエストデータが不正です",
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "定期的な生存確認が設定されていません",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "データベース接続に失敗しました",
+                        "description": "生存確認に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -504,9 +532,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/disclosures": {
+        "/alive-checks": {
             "get": {
-                "description": "ユーザが受けた開示請求一覧を取得する",
+                "description": "自分のアライブチェック履歴一覧を取得する",
                 "consumes": [
                     "application/json"
                 ],
@@ -514,27 +542,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "disclosures"
+                    "aliveChecks"
                 ],
-                "summary": "開示申請一覧取得",
+                "summary": "アライブチェック履歴一覧取得",
                 "responses": {
                     "200": {
                         "description": "成功",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/handlers.DisclosureResponse"
+                                "$ref": "#/definitions/handlers.AliveCheckHistoryResponse"
                             }
                         }
                     },
-                    "400": {
-                        "description": "リクエストが不正",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "開示請求が見つかりませんでした",
+                    "401": {
+                        "description": "認証に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -542,7 +564,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "開示申請のステータスを更新する",
+                "description": "自分のアライブチェック履歴を更新する",
                 "consumes": [
                     "application/json"
                 ],
@@ -550,17 +572,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "disclosures"
+                    "aliveChecks"
                 ],
-                "summary": "開示申請更新",
+                "summary": "アライブチェック履歴更新",
                 "parameters": [
                     {
-                        "description": "開示申請情報",
-                        "name": "disclosure",
+                        "description": "アライブチェック履歴更新リクエスト",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.DisclosureUpdateRequest"
+                            "$ref": "#/definitions/handlers.AliveCheckHistoryUpdateRequest"
                         }
                     }
                 ],
@@ -568,69 +590,37 @@ const docTemplate = `{
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.DisclosureResponse"
+                            "$ref": "#/definitions/handlers.AliveCheckHistoryResponse"
                         }
                     },
                     "400": {
-                        "description": "リクエストが不正",
+                        "description": "リクエストデータが不正です",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "開示申請の更新に失敗",
+                    "401": {
+                        "description": "認証に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
-                    }
-                }
-            },
-            "post": {
-                "description": "ユーザが他のユーザに開示請求を出す",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "disclosures"
-                ],
-                "summary": "開示申請作成",
-                "parameters": [
-                    {
-                        "description": "開示請求情報",
-                        "name": "disclosure",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.DisclosureCreateRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "成功",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.DisclosureResponse"
-                        }
                     },
-                    "400": {
-                        "description": "リクエストが不正",
+                    "404": {
+                        "description": "確認履歴が見つかりませんでした",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "開示請求の作成に失敗",
+                        "description": "データベース接続に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "開示申請を削除する",
+            "post": {
+                "description": "自分のアライブチェック履歴を作成する",
                 "consumes": [
                     "application/json"
                 ],
@@ -638,17 +628,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "disclosures"
+                    "aliveChecks"
                 ],
-                "summary": "開示申請削除",
+                "summary": "アライブチェック履歴作成",
                 "parameters": [
                     {
-                        "description": "開示申請情報",
-                        "name": "disclosure",
+                        "description": "アライブチェック履歴作成リクエスト",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.DisclosureDeleteRequest"
+                            "$ref": "#/definitions/handlers.AliveCheckHistoryCreateRequest"
                         }
                     }
                 ],
@@ -656,17 +646,23 @@ const docTemplate = `{
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.DisclosureResponse"
+                            "$ref": "#/definitions/handlers.AliveCheckHistoryResponse"
                         }
                     },
                     "400": {
-                        "description": "リクエストが不正",
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "開示申請の削除に失敗",
+                        "description": "データベース接続に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -674,9 +670,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/receivers": {
+        "/audit-events": {
             "get": {
-                "description": "相続人の一覧を取得します",
+                "description": "自分の秘密情報がいつ誰によって暗号化・復号されたかを新しい順に取得する",
                 "consumes": [
                     "application/json"
                 ],
@@ -684,27 +680,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "receivers"
+                    "audit-events"
+                ],
+                "summary": "監査ログ一覧取得",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "取得件数（最大500、既定50）",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "このIDより古いイベントを取得する",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "操作の種類で絞り込む（例: DECRYPT）",
+                        "name": "operation",
+                        "in": "query"
+                    }
                 ],
-                "summary": "相続人の一覧取得",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "成功",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/handlers.ReceiverResponse"
-                            }
+                            "$ref": "#/definitions/handlers.AuditEventListResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "リクエストが不正です",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "監査ログの取得に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -712,9 +729,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions": {
+        "/devices": {
             "get": {
-                "description": "ユーザが開示しているサブスクリプション一覧を取得する",
+                "description": "ユーザが開示しているデバイス一覧を取得する",
                 "consumes": [
                     "application/json"
                 ],
@@ -722,21 +739,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "devices"
                 ],
-                "summary": "サブスクリプション一覧取得",
+                "summary": "デバイス一覧取得",
                 "responses": {
                     "200": {
                         "description": "成功",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/handlers.SubscriptionResponse"
+                                "$ref": "#/definitions/handlers.DeviceResponse"
                             }
                         }
                     },
-                    "400": {
-                        "description": "リクエストデータが不正です",
+                    "401": {
+                        "description": "認証に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -750,7 +767,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "既存のサブスクリプション情報を更新する",
+                "description": "自分のデバイスを更新する",
                 "consumes": [
                     "application/json"
                 ],
@@ -758,17 +775,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "devices"
                 ],
-                "summary": "サブスクリプション更新",
+                "summary": "デバイス更新",
                 "parameters": [
                     {
-                        "description": "サブスクリプション情報",
-                        "name": "subscription",
+                        "description": "デバイス情報",
+                        "name": "device",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SubscriptionUpdateRequest"
+                            "$ref": "#/definitions/handlers.DeviceCreateRequest"
                         }
                     }
                 ],
@@ -776,7 +793,7 @@ const docTemplate = `{
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                            "$ref": "#/definitions/handlers.DeviceResponse"
                         }
                     },
                     "400": {
@@ -785,6 +802,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "デバイスか相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "データベース接続に失敗しました",
                         "schema": {
@@ -794,7 +829,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "新しいサブスクリプションを作成する",
+                "description": "自分のデバイスを追加し、assignments で指定した受取人に託す",
                 "consumes": [
                     "application/json"
                 ],
@@ -802,17 +837,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "devices"
                 ],
-                "summary": "サブスクリプション作成",
+                "summary": "デバイス追加",
                 "parameters": [
                     {
-                        "description": "サブスクリプション情報",
-                        "name": "subscription",
+                        "description": "デバイス情報",
+                        "name": "device",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.SubscriptionCreateRequest"
+                            "$ref": "#/definitions/handlers.DeviceCreateRequest"
                         }
                     }
                 ],
@@ -820,7 +855,7 @@ const docTemplate = `{
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                            "$ref": "#/definitions/handlers.DeviceResponse"
                         }
                     },
                     "400": {
@@ -829,6 +864,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "データベース接続に失敗しました",
                         "schema": {
@@ -838,7 +891,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "指定されたサブスクリプションを削除する",
+                "description": "自分のデバイスを削除する",
                 "consumes": [
                     "application/json"
                 ],
@@ -846,17 +899,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "devices"
                 ],
-                "summary": "サブスクリプション削除",
+                "summary": "デバイス削除",
                 "parameters": [
                     {
-                        "description": "削除するサブスクリプション情報",
-                        "name": "subscription",
+                        "description": "デバイス情報",
+                        "name": "device",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.DeleteSubscriptionRequest"
+                            "$ref": "#/definitions/handlers.DeleteDeviceCreateRequest"
                         }
                     }
                 ],
@@ -864,7 +917,7 @@ const docTemplate = `{
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                            "$ref": "#/definitions/handlers.DeviceResponse"
                         }
                     },
                     "400": {
@@ -873,6 +926,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "デバイスが見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "データベース接続に失敗しました",
                         "schema": {
@@ -882,9 +947,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/trusts": {
-            "get": {
-                "description": "ユーザが開示している相続関係一覧を取得する",
+        "/devices/assignments": {
+            "put": {
+                "description": "自分のデバイスを託す受取人と、受取人ごとに見せる範囲をまとめて置き換える。すでに開示された受取人にはその場で引き渡す",
                 "consumes": [
                     "application/json"
                 ],
@@ -892,16 +957,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trusts"
+                    "devices"
                 ],
-                "summary": "相続関係の一覧取得",
+                "summary": "デバイスの割り当て更新",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "相続関係を取得するユーザのID",
-                        "name": "passerID",
-                        "in": "query",
-                        "required": true
+                        "description": "割り当て",
+                        "name": "assignments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AssignmentsUpdateRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -910,7 +977,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/handlers.TrustResponse"
+                                "$ref": "#/definitions/handlers.AssignmentResponse"
                             }
                         }
                     },
@@ -920,6 +987,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "デバイスか相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "データベース接続に失敗しました",
                         "schema": {
@@ -927,9 +1012,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "ユーザ間の相続関係を更新する",
+            }
+        },
+        "/disclosure-policy": {
+            "get": {
+                "description": "何人の受取人の開示請求が揃えば開示するかの設定を取得する",
                 "consumes": [
                     "application/json"
                 ],
@@ -937,43 +1024,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trusts"
-                ],
-                "summary": "相続関係の更新",
-                "parameters": [
-                    {
-                        "description": "相続関係",
-                        "name": "trust",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.UpdateTrustRequest"
-                        }
-                    }
+                    "disclosure-policy"
                 ],
+                "summary": "しきい値開示の設定取得",
                 "responses": {
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.TrustResponse"
+                            "$ref": "#/definitions/handlers.DisclosurePolicyResponse"
                         }
                     },
                     "400": {
-                        "description": "リクエストデータが不正です",
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "しきい値開示は設定されていません",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "データベース接続に失敗しました",
+                        "description": "設定の取得に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "ユーザ間の相続関係を作成する",
+            "put": {
+                "description": "現在の受取人全員に金庫の鍵のシェアを配り、threshold 人の開示請求が揃うまで開示しないようにする。受取人を変えた場合は設定し直す",
                 "consumes": [
                     "application/json"
                 ],
@@ -981,17 +1063,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trusts"
+                    "disclosure-policy"
                 ],
-                "summary": "相続関係の作成",
+                "summary": "しきい値開示の設定",
                 "parameters": [
                     {
-                        "description": "相続関係",
-                        "name": "trust",
+                        "description": "しきい値",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.TrustRequest"
+                            "$ref": "#/definitions/handlers.DisclosurePolicyUpdateRequest"
                         }
                     }
                 ],
@@ -999,17 +1081,17 @@ const docTemplate = `{
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.TrustResponse"
+                            "$ref": "#/definitions/handlers.DisclosurePolicyResponse"
                         }
                     },
                     "400": {
-                        "description": "リクエストデータが不正です",
+                        "description": "リクエストが不正です",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "データベース接続に失敗しました",
+                        "description": "しきい値開示の設定に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -1017,7 +1099,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "ユーザ間の相続関係を削除する",
+                "description": "しきい値開示をやめ、受取人1人の開示請求で開示されるようにする",
                 "consumes": [
                     "application/json"
                 ],
@@ -1025,35 +1107,1790 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "trusts"
-                ],
-                "summary": "相続関係の削除",
-                "parameters": [
-                    {
-                        "description": "相続関係",
-                        "name": "trust",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.DeleteTrustRequest"
-                        }
-                    }
+                    "disclosure-policy"
                 ],
+                "summary": "しきい値開示の解除",
                 "responses": {
                     "200": {
                         "description": "成功",
                         "schema": {
-                            "$ref": "#/definitions/handlers.TrustResponse"
+                            "$ref": "#/definitions/handlers.DisclosurePolicyResponse"
                         }
                     },
                     "400": {
-                        "description": "リクエストデータが不正です",
+                        "description": "ユーザー認証に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "データベース接続に失敗しました",
+                    "404": {
+                        "description": "しきい値開示は設定されていません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "しきい値開示の解除に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disclosures": {
+            "get": {
+                "description": "ユーザが受けた開示請求一覧を取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disclosures"
+                ],
+                "summary": "開示申請一覧取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.DisclosureResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストが不正",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "開示請求が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "請求者が自分の開示申請を取り下げるか、まだ処理されていない開示申請の期限と付加情報を変える。状態はサービス層が許す変更だけができる",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disclosures"
+                ],
+                "summary": "開示申請更新",
+                "parameters": [
+                    {
+                        "description": "開示申請情報",
+                        "name": "disclosure",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisclosureUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisclosureResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストが不正、または期限を早めようとした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "開示申請が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "開示申請の状態は変えられません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "開示申請の更新に失敗",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "ユーザが他のユーザに開示請求を出す",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disclosures"
+                ],
+                "summary": "開示申請作成",
+                "parameters": [
+                    {
+                        "description": "開示請求情報",
+                        "name": "disclosure",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisclosureCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisclosureResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストが不正",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "パッサーの受取人ではありません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "開示請求の作成に失敗",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disclosures/incoming": {
+            "get": {
+                "description": "パッサーとして自分が受けている開示請求の一覧を取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disclosures"
+                ],
+                "summary": "自分への開示請求一覧取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.DisclosureResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "開示請求の取得に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disclosures/reject": {
+            "post": {
+                "description": "パッサーが自分への開示請求を却下する。マジックリンクでの生存確認と同じく、生存確認の履歴を残して開示請求を止める",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disclosures"
+                ],
+                "summary": "開示請求の却下",
+                "parameters": [
+                    {
+                        "description": "却下する開示請求",
+                        "name": "disclosure",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisclosureRejectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisclosureResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストが不正",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "開示請求が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "開示請求はすでに処理されています",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "開示請求の却下に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disclosures/reject-all": {
+            "post": {
+                "description": "生きていることを伝え、自分へのまだ処理されていない開示請求をすべて却下する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disclosures"
+                ],
+                "summary": "開示請求の一括却下",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DisclosureRejectAllResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "開示請求の却下に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disclosures/transitions": {
+            "get": {
+                "description": "開示申請の状態がいつ、誰によって変わったかを古い順に取得する。請求者とパッサーだけが取得できる",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disclosures"
+                ],
+                "summary": "開示申請の状態の履歴取得",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "開示申請ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.DisclosureTransitionResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストが不正",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "開示申請が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "履歴の取得に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/inbox": {
+            "get": {
+                "description": "受取人として開示されたアカウント、デバイス、サブスクリプションをパッサーごとにまとめて取得する。パスワードは受取人自身の鍵で復号する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inbox"
+                ],
+                "summary": "受信箱の取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.InboxPasserResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys/client": {
+            "get": {
+                "description": "登録済みのクライアント公開鍵を取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "自分のクライアント公開鍵の取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ClientPublicKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "公開鍵が登録されていません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "公開鍵の取得に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "ゼロ知識モードでパッサーが受取人向けに鍵を包むための公開鍵を登録する。登録済みなら置き換える",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "クライアント公開鍵の登録",
+                "parameters": [
+                    {
+                        "description": "公開鍵",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ClientPublicKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ClientPublicKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "公開鍵の登録に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys/client/receivers": {
+            "get": {
+                "description": "ゼロ知識モードでアイテム鍵を包むために、自分の受取人の公開鍵を取得する。未登録の受取人は含まれない",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "受取人のクライアント公開鍵一覧",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.ClientPublicKeyResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "公開鍵の取得に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys/recovery": {
+            "get": {
+                "description": "最後に預けた復旧用の鍵を取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "復旧用に包んだ鍵の取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecoveryWrapResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "鍵が預けられていません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "鍵の取得に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "パスフレーズを忘れたときのために、クライアントが包んだ鍵を crypto サービスに預ける。以前のものも残る",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "復旧用に包んだ鍵の預け入れ",
+                "parameters": [
+                    {
+                        "description": "包んだ鍵",
+                        "name": "wrap",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecoveryWrapRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RecoveryWrapResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "鍵の預け入れに失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys/rotate": {
+            "post": {
+                "description": "ユーザの暗号鍵を新しいバージョンに切り替え、保存済みのパスワードをバックグラウンドで再暗号化する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "暗号鍵のローテーション",
+                "responses": {
+                    "202": {
+                        "description": "ジョブを開始しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.KeyRotationJobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "ローテーションが実行中です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.KeyRotationJobResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "鍵のローテーションに失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/keys/rotation": {
+            "get": {
+                "description": "最新の再暗号化ジョブの進捗を取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "暗号鍵ローテーションの進捗取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.KeyRotationJobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "ローテーションの履歴がありません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "進捗の取得に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notification-channels": {
+            "get": {
+                "description": "生存確認の催促を受け取る経路の一覧を取得する。メールを設定していなければ登録済みのメールアドレスに送る",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "生存確認の経路一覧",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.NotificationChannelResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "経路の取得に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "生存確認の催促を受け取る経路を追加する。同じ経路が設定済みなら宛先を置き換える",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "生存確認の経路の設定",
+                "parameters": [
+                    {
+                        "description": "経路",
+                        "name": "channel",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotificationChannelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotificationChannelResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "経路の設定に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "生存確認の催促を受け取る経路を削除する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "生存確認の経路の削除",
+                "parameters": [
+                    {
+                        "description": "経路",
+                        "name": "channel",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotificationChannelDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "経路が設定されていません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "経路の削除に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/receivers": {
+            "get": {
+                "description": "相続人の一覧を取得します",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "receivers"
+                ],
+                "summary": "相続人の一覧取得",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.ReceiverResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reminders/plan": {
+            "get": {
+                "description": "開示請求を受けてから生存確認を催促する時間を取得する。設定していなければ既定の計画を返す",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "催促の計画の取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReminderPlanResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "催促の計画の取得に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "開示請求を受けてから生存確認を催促する時間を設定する。段階が進むごとに、メール、LINE、Webhook の順に送る経路を広げる",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "催促の計画の設定",
+                "parameters": [
+                    {
+                        "description": "催促の計画",
+                        "name": "plan",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReminderPlanUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReminderPlanResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "催促の計画の設定に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "設定した催促の計画を削除し、既定の計画を使うようにする",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "催促の計画を既定に戻す",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReminderPlanResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ユーザー認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "催促の計画の削除に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "ユーザが開示しているサブスクリプション一覧を取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "サブスクリプション一覧取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.SubscriptionResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "自分の既存のサブスクリプション情報を更新する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "サブスクリプション更新",
+                "parameters": [
+                    {
+                        "description": "サブスクリプション情報",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "サブスクリプションか相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "自分の新しいサブスクリプションを作成し、assignments で指定した受取人に託す",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "サブスクリプション作成",
+                "parameters": [
+                    {
+                        "description": "サブスクリプション情報",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "自分のサブスクリプションを削除する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "サブスクリプション削除",
+                "parameters": [
+                    {
+                        "description": "削除するサブスクリプション情報",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeleteSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "サブスクリプションが見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/assignments": {
+            "put": {
+                "description": "自分のサブスクリプションを託す受取人と、受取人ごとに見せる範囲をまとめて置き換える。すでに開示された受取人にはその場で引き渡す",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "サブスクリプションの割り当て更新",
+                "parameters": [
+                    {
+                        "description": "割り当て",
+                        "name": "assignments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AssignmentsUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.AssignmentResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "サブスクリプションか相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "受取人が招待をまだ承諾していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trust-invitations": {
+            "get": {
+                "description": "自分がパッサーとして送った受取人への招待を新しい順に取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trust-invitations"
+                ],
+                "summary": "送った招待の一覧取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.TrustInvitationResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "まだアカウントのない人を、メールアドレスで受取人に招待する。招待のリンクをメールで送る。\n招待された人がそのメールアドレスで登録すると相続関係ができ、承諾するとその相続関係で開示請求できるようになる",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trust-invitations"
+                ],
+                "summary": "受取人の招待",
+                "parameters": [
+                    {
+                        "description": "招待するメールアドレス",
+                        "name": "invitation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustInvitationCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustInvitationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "同じメールアドレスの人が招待に返事していません",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "招待の送信に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "自分が送った、返事を待っている招待を取り消す。招待からできた相続関係も消す",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trust-invitations"
+                ],
+                "summary": "招待の取り消し",
+                "parameters": [
+                    {
+                        "description": "招待",
+                        "name": "invitation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustInvitationDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustInvitationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "招待が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "招待はすでに返事されています",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trust-invitations/accept": {
+            "post": {
+                "description": "受けた招待を承諾し、パッサーの受取人になる。メールのリンクの token で指定すると、その招待を自分と結びつけてから承諾する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trust-invitations"
+                ],
+                "summary": "招待の承諾",
+                "parameters": [
+                    {
+                        "description": "招待",
+                        "name": "invitation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustInvitationRespondRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustInvitationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "招待が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "招待はすでに返事されたか、期限が切れています",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trust-invitations/decline": {
+            "post": {
+                "description": "受けた招待を辞退する。招待からできた相続関係は消える。メールのリンクの token で指定すると、登録したメールアドレスが違っても辞退できる",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trust-invitations"
+                ],
+                "summary": "招待の辞退",
+                "parameters": [
+                    {
+                        "description": "招待",
+                        "name": "invitation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustInvitationRespondRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustInvitationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "招待が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "招待はすでに返事されたか、期限が切れています",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trust-invitations/incoming": {
+            "get": {
+                "description": "自分を受取人とする招待を新しい順に取得する。登録したメールアドレスへの招待と、リンクを開いた招待が含まれる",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trust-invitations"
+                ],
+                "summary": "受けた招待の一覧取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.TrustInvitationResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trusts": {
+            "get": {
+                "description": "自分がパッサーとして結んでいる相続関係一覧を取得する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trusts"
+                ],
+                "summary": "相続関係の一覧取得",
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.TrustResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "自分がパッサーの相続関係の受取人を変更する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trusts"
+                ],
+                "summary": "相続関係の更新",
+                "parameters": [
+                    {
+                        "description": "相続関係",
+                        "name": "trust",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateTrustRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "しきい値開示に必要な受取人が足りなくなります",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "自分をパッサーとして、指定したユーザを受取人にする相続関係を作成する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trusts"
+                ],
+                "summary": "相続関係の作成",
+                "parameters": [
+                    {
+                        "description": "相続関係",
+                        "name": "trust",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "自分がパッサーの相続関係を削除する",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trusts"
+                ],
+                "summary": "相続関係の削除",
+                "parameters": [
+                    {
+                        "description": "相続関係",
+                        "name": "trust",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeleteTrustRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TrustResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "リクエストデータが不正です",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "相続関係が見つかりませんでした",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "しきい値開示に必要な受取人が足りなくなります",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "データベース接続に失敗しました",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -1084,7 +2921,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "clerkでユーザ認証した後にバックエンドのDBにユーザを更新するためのエンドポイント",
+                "description": "clerkでユーザ認証した後に、自分の既定の受取人を更新するためのエンドポイント",
                 "consumes": [
                     "application/json"
                 ],
@@ -1102,7 +2939,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UserCreateRequest"
+                            "$ref": "#/definitions/handlers.UserUpdateRequest"
                         }
                     }
                 ],
@@ -1119,6 +2956,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "認証に失敗しました",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "データベース接続に失敗しました",
                         "schema": {
@@ -1128,7 +2971,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "clerkでユーザ認証した後にバックエンドのDBにユーザを登録するためのエンドポイント",
+                "description": "clerkでユーザ認証した後にバックエンドのDBにユーザを登録するためのエンドポイント。\n確認済みのメールアドレスに受取人への招待が届いていれば、招待したパッサーとの相続関係を作る",
                 "consumes": [
                     "application/json"
                 ],
@@ -1178,10 +3021,7 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "appName",
-                "passerID",
-                "password",
-                "plsDelete",
-                "trustID"
+                "plsDelete"
             ],
             "properties": {
                 "appDescription": {
@@ -1196,6 +3036,13 @@ const docTemplate = `{
                 "appTemplateID": {
                     "type": "integer"
                 },
+                "assignments": {
+                    "description": "受取人ごとの割り当て。更新で省略したときは今の割り当てのまま",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AssignmentRequest"
+                    }
+                },
                 "customData": {
                     "type": "object",
                     "additionalProperties": true
@@ -1203,15 +3050,20 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "encryptionMode": {
+                    "description": "省略時は server。zero_knowledge のときは password を空にし、zeroKnowledge を指定する",
+                    "type": "string",
+                    "enum": [
+                        "server",
+                        "zero_knowledge"
+                    ]
+                },
                 "memo": {
                     "type": "string"
                 },
                 "message": {
                     "type": "string"
                 },
-                "passerID": {
-                    "type": "string"
-                },
                 "password": {
                     "type": "string"
                 },
@@ -1219,10 +3071,14 @@ const docTemplate = `{
                     "type": "boolean"
                 },
                 "trustID": {
+                    "description": "1人の受取人にログイン情報まで託すときの省略形。assignments と一緒には指定できない",
                     "type": "integer"
                 },
                 "username": {
                     "type": "string"
+                },
+                "zeroKnowledge": {
+                    "$ref": "#/definitions/handlers.ZeroKnowledgeSecret"
                 }
             }
         },
@@ -1231,6 +3087,7 @@ const docTemplate = `{
             "required": [
                 "appDescription",
                 "appName",
+                "encryptionMode",
                 "id",
                 "isDisclosed",
                 "passerID",
@@ -1251,13 +3108,31 @@ const docTemplate = `{
                 "appTemplateID": {
                     "type": "integer"
                 },
+                "assignments": {
+                    "description": "パッサーにのみ返す、受取人ごとの割り当て",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AssignmentResponse"
+                    }
+                },
                 "customData": {
                     "type": "object",
                     "additionalProperties": true
                 },
+                "decryptError": {
+                    "description": "パスワードを復号できなかった場合のみ設定される",
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
+                "encryptionMode": {
+                    "type": "string",
+                    "enum": [
+                        "server",
+                        "zero_knowledge"
+                    ]
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -1276,102 +3151,337 @@ const docTemplate = `{
                 "password": {
                     "type": "string"
                 },
+                "permission": {
+                    "description": "受取人にのみ返す、自分に見せられる範囲。message ならログイン情報は空になる",
+                    "type": "string",
+                    "enum": [
+                        "credentials",
+                        "message"
+                    ]
+                },
                 "plsDelete": {
                     "type": "boolean"
                 },
+                "trustID": {
+                    "description": "パッサーには最初の割り当ての、受取人には自分への割り当ての相続関係ID。割り当てがなければ 0",
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "zeroKnowledge": {
+                    "description": "ゼロ知識モードのときのみ設定される。Password は空になる",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.ZeroKnowledgeSecret"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.AccountTemplateResponse": {
+            "type": "object",
+            "required": [
+                "appDescription",
+                "appIconUrl",
+                "appName",
+                "id"
+            ],
+            "properties": {
+                "appDescription": {
+                    "type": "string"
+                },
+                "appIconUrl": {
+                    "type": "string"
+                },
+                "appName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.AliveCheckHistoryCreateRequest": {
+            "type": "object",
+            "properties": {
+                "checkMethod": {
+                    "type": "integer"
+                },
+                "customedData": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "handlers.AliveCheckHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "checkMethod": {
+                    "type": "integer"
+                },
+                "checkSuccess": {
+                    "type": "boolean"
+                },
+                "checkSuccessTime": {
+                    "type": "string"
+                },
+                "checkTime": {
+                    "type": "string"
+                },
+                "customedData": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "targetUserID": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AliveCheckHistoryUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "checkMethod": {
+                    "type": "integer"
+                },
+                "checkSuccess": {
+                    "type": "boolean"
+                },
+                "customedData": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AliveCheckScheduleRequest": {
+            "type": "object",
+            "required": [
+                "enabled",
+                "graceDays",
+                "intervalDays"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "graceDays": {
+                    "description": "確認のリンクを送ってから、既定の受取人の名前で開示請求を出すまでの猶予 (日)",
+                    "type": "integer"
+                },
+                "intervalDays": {
+                    "description": "確認のリンクを送る間隔 (日)",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.AliveCheckScheduleResponse": {
+            "type": "object",
+            "required": [
+                "enabled",
+                "graceDays",
+                "intervalDays",
+                "nextCheckAt"
+            ],
+            "properties": {
+                "confirmBy": {
+                    "description": "確認待ちなら、送った確認のリンクの期限",
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "graceDays": {
+                    "type": "integer"
+                },
+                "intervalDays": {
+                    "type": "integer"
+                },
+                "lastConfirmedAt": {
+                    "type": "string"
+                },
+                "lastDisclosureID": {
+                    "type": "integer"
+                },
+                "lastMissedAt": {
+                    "type": "string"
+                },
+                "nextCheckAt": {
+                    "description": "次に確認のリンクを送る日時",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AssignmentRequest": {
+            "type": "object",
+            "required": [
+                "trustID"
+            ],
+            "properties": {
+                "permission": {
+                    "description": "省略時は credentials。message なら受取人にはパッサーからの伝言だけを見せる",
+                    "type": "string",
+                    "enum": [
+                        "credentials",
+                        "message"
+                    ]
+                },
+                "trustID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.AssignmentResponse": {
+            "type": "object",
+            "required": [
+                "isDisclosed",
+                "permission",
+                "trustID"
+            ],
+            "properties": {
+                "isDisclosed": {
+                    "description": "受取人に引き渡し済みか",
+                    "type": "boolean"
+                },
+                "permission": {
+                    "type": "string",
+                    "enum": [
+                        "credentials",
+                        "message"
+                    ]
+                },
                 "trustID": {
                     "type": "integer"
-                },
-                "username": {
-                    "type": "string"
                 }
             }
         },
-        "handlers.AccountTemplateResponse": {
+        "handlers.AssignmentsUpdateRequest": {
             "type": "object",
             "required": [
-                "appDescription",
-                "appIconUrl",
-                "appName",
+                "assignments",
                 "id"
             ],
             "properties": {
-                "appDescription": {
-                    "type": "string"
-                },
-                "appIconUrl": {
-                    "type": "string"
-                },
-                "appName": {
-                    "type": "string"
+                "assignments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AssignmentRequest"
+                    }
                 },
                 "id": {
                     "type": "integer"
                 }
             }
         },
-        "handlers.AliveCheckHistoryCreateRequest": {
+        "handlers.AuditEventListResponse": {
             "type": "object",
+            "required": [
+                "events"
+            ],
             "properties": {
-                "checkMethod": {
-                    "type": "integer"
-                },
-                "customedData": {
+                "events": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/handlers.AuditEventResponse"
                     }
                 },
-                "targetUserID": {
-                    "type": "string"
+                "nextBefore": {
+                    "description": "次のページを取得するときの before。続きがなければ 0",
+                    "type": "integer"
                 }
             }
         },
-        "handlers.AliveCheckHistoryResponse": {
+        "handlers.AuditEventResponse": {
             "type": "object",
+            "required": [
+                "caller",
+                "createdAt",
+                "hash",
+                "id",
+                "operation",
+                "prevHash"
+            ],
             "properties": {
-                "checkMethod": {
-                    "type": "integer"
-                },
-                "checkSuccess": {
-                    "type": "boolean"
+                "actorID": {
+                    "description": "操作したユーザーのID。バックグラウンドジョブによる操作では空",
+                    "type": "string"
                 },
-                "checkSuccessTime": {
+                "caller": {
+                    "description": "crypto サービスを呼び出したサービス",
                     "type": "string"
                 },
-                "checkTime": {
+                "createdAt": {
                     "type": "string"
                 },
-                "customedData": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "hash": {
+                    "type": "string"
                 },
                 "id": {
+                    "type": "integer"
+                },
+                "keyVersion": {
+                    "type": "integer"
+                },
+                "operation": {
                     "type": "string"
                 },
-                "targetUserID": {
+                "prevHash": {
+                    "description": "1つ前のイベントのハッシュと合わせて、改ざんされていないことを確かめられる",
+                    "type": "string"
+                },
+                "requestID": {
                     "type": "string"
                 }
             }
         },
-        "handlers.AliveCheckHistoryUpdateRequest": {
+        "handlers.ClientPublicKeyRequest": {
+            "type": "object",
+            "required": [
+                "algorithm",
+                "publicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "クライアントが決める方式 (例: x25519)",
+                    "type": "string"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "format": "byte"
+                }
+            }
+        },
+        "handlers.ClientPublicKeyResponse": {
             "type": "object",
+            "required": [
+                "algorithm",
+                "publicKey",
+                "updatedAt",
+                "userID"
+            ],
             "properties": {
-                "checkMethod": {
-                    "type": "integer"
+                "algorithm": {
+                    "type": "string"
                 },
-                "checkSuccess": {
-                    "type": "boolean"
+                "publicKey": {
+                    "type": "string",
+                    "format": "byte"
                 },
-                "customedData": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "updatedAt": {
+                    "type": "string"
                 },
-                "id": {
+                "userID": {
                     "type": "string"
                 }
             }
@@ -1381,9 +3491,6 @@ const docTemplate = `{
             "properties": {
                 "deviceID": {
                     "type": "integer"
-                },
-                "passerID": {
-                    "type": "string"
                 }
             }
         },
@@ -1392,9 +3499,6 @@ const docTemplate = `{
             "properties": {
                 "deviceID": {
                     "type": "integer"
-                },
-                "passerID": {
-                    "type": "string"
                 }
             }
         },
@@ -1409,9 +3513,6 @@ const docTemplate = `{
         "handlers.DeleteTrustRequest": {
             "type": "object",
             "properties": {
-                "passerID": {
-                    "type": "string"
-                },
                 "trustID": {
                     "type": "integer"
                 }
@@ -1420,6 +3521,13 @@ const docTemplate = `{
         "handlers.DeviceCreateRequest": {
             "type": "object",
             "properties": {
+                "assignments": {
+                    "description": "受取人ごとの割り当て。更新で省略したときは今の割り当てのまま",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AssignmentRequest"
+                    }
+                },
                 "credentialType": {
                     "type": "integer"
                 },
@@ -1442,17 +3550,24 @@ const docTemplate = `{
                 "message": {
                     "type": "string"
                 },
-                "passerID": {
-                    "type": "string"
-                },
                 "password": {
                     "type": "string"
+                },
+                "plsDelete": {
+                    "type": "boolean"
                 }
             }
         },
         "handlers.DeviceResponse": {
             "type": "object",
             "properties": {
+                "assignments": {
+                    "description": "パッサーにのみ返す、受取人ごとの割り当て",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AssignmentResponse"
+                    }
+                },
                 "credentialType": {
                     "type": "integer"
                 },
@@ -1460,6 +3575,10 @@ const docTemplate = `{
                     "type": "object",
                     "additionalProperties": true
                 },
+                "decryptError": {
+                    "description": "復号に失敗したときのみ設定される。Password は空になる",
+                    "type": "string"
+                },
                 "deviceDescription": {
                     "type": "string"
                 },
@@ -1469,9 +3588,6 @@ const docTemplate = `{
                 "deviceUsername": {
                     "type": "string"
                 },
-                "encPassword": {
-                    "type": "string"
-                },
                 "id": {
                     "type": "integer"
                 },
@@ -1484,7 +3600,22 @@ const docTemplate = `{
                 "passerID": {
                     "type": "string"
                 },
+                "password": {
+                    "type": "string"
+                },
+                "permission": {
+                    "description": "受取人にのみ返す、自分に見せられる範囲。message ならログイン情報は空になる",
+                    "type": "string",
+                    "enum": [
+                        "credentials",
+                        "message"
+                    ]
+                },
+                "plsDelete": {
+                    "type": "boolean"
+                },
                 "trustID": {
+                    "description": "パッサーには最初の割り当ての、受取人には自分への割り当ての相続関係ID。割り当てがなければ 0",
                     "type": "integer"
                 }
             }
@@ -1499,103 +3630,337 @@ const docTemplate = `{
                 "deadlineDuration": {
                     "type": "integer"
                 },
-                "passerID": {
+                "passerID": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DisclosurePolicyResponse": {
+            "type": "object",
+            "required": [
+                "createdAt",
+                "passerID",
+                "shareCount",
+                "threshold",
+                "updatedAt",
+                "vaultVersion"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "passerID": {
+                    "type": "string"
+                },
+                "shareCount": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "integer"
+                },
+                "unlockedAt": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "vaultVersion": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.DisclosurePolicyUpdateRequest": {
+            "type": "object",
+            "required": [
+                "threshold"
+            ],
+            "properties": {
+                "threshold": {
+                    "description": "開示に必要な受取人の人数",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.DisclosureRejectAllResponse": {
+            "type": "object",
+            "required": [
+                "rejected"
+            ],
+            "properties": {
+                "error": {
+                    "description": "却下できなかった開示請求があれば、その理由",
+                    "type": "string"
+                },
+                "rejected": {
+                    "description": "却下した開示請求",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.DisclosureResponse"
+                    }
+                }
+            }
+        },
+        "handlers.DisclosureRejectRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.DisclosureResponse": {
+            "type": "object",
+            "required": [
+                "deadline",
+                "disclosed",
+                "id",
+                "inProgress",
+                "issuedTime",
+                "passerID",
+                "preventedBy",
+                "requesterID",
+                "status",
+                "statusChangedAt"
+            ],
+            "properties": {
+                "customData": {
+                    "type": "string"
+                },
+                "deadline": {
+                    "type": "string"
+                },
+                "disclosed": {
+                    "description": "status から求めた値。開示済みなら true",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inProgress": {
+                    "description": "status から求めた値。まだ処理されていなければ true",
+                    "type": "boolean"
+                },
+                "issuedTime": {
+                    "type": "string"
+                },
+                "passerID": {
+                    "type": "string"
+                },
+                "preventedBy": {
+                    "type": "string"
+                },
+                "requesterID": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "requested, notifying, grace, vetoed, disclosed, cancelled, expired のいずれか",
+                    "type": "string"
+                },
+                "statusChangedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DisclosureTransitionResponse": {
+            "type": "object",
+            "required": [
+                "actor",
+                "createdAt",
+                "toStatus"
+            ],
+            "properties": {
+                "actor": {
+                    "description": "requester, passer, system のいずれか",
+                    "type": "string"
+                },
+                "actorUserID": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "fromStatus": {
+                    "type": "string"
+                },
+                "toStatus": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DisclosureUpdateRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "customData": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "deadLine": {
+                    "description": "省略すると期限は変えない",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "変える先の状態。請求者は cancelled にだけ変えられる。省略すると状態は変えない",
                     "type": "string"
                 }
             }
         },
-        "handlers.DisclosureDeleteRequest": {
+        "handlers.ErrorResponse": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "integer"
+                "details": {
+                    "type": "string"
                 },
-                "requesterID": {
+                "error": {
                     "type": "string"
                 }
             }
         },
-        "handlers.DisclosureResponse": {
+        "handlers.InboxPasserResponse": {
             "type": "object",
             "required": [
-                "deadline",
-                "disclosed",
-                "id",
-                "inProgress",
-                "issuedTime",
+                "accounts",
+                "devices",
                 "passerID",
-                "preventedBy",
-                "requesterID"
+                "subscriptions"
             ],
             "properties": {
-                "customData": {
-                    "type": "string"
-                },
-                "deadline": {
-                    "type": "string"
-                },
-                "disclosed": {
-                    "type": "boolean"
-                },
-                "id": {
-                    "type": "integer"
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AccountResponse"
+                    }
                 },
-                "inProgress": {
-                    "type": "boolean"
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.DeviceResponse"
+                    }
                 },
-                "issuedTime": {
+                "disclosedAt": {
+                    "description": "受取人の開示請求が開示された日時。開示請求が見つからなければ空",
                     "type": "string"
                 },
                 "passerID": {
                     "type": "string"
                 },
-                "preventedBy": {
-                    "type": "string"
-                },
-                "requesterID": {
-                    "type": "string"
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SubscriptionResponse"
+                    }
                 }
             }
         },
-        "handlers.DisclosureUpdateRequest": {
+        "handlers.KeyRotationJobResponse": {
             "type": "object",
+            "required": [
+                "createdAt",
+                "failedItems",
+                "id",
+                "keyVersion",
+                "phase",
+                "processedItems",
+                "status",
+                "totalItems",
+                "updatedAt"
+            ],
             "properties": {
-                "customData": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "completedAt": {
+                    "type": "string"
                 },
-                "deadLine": {
+                "createdAt": {
                     "type": "string"
                 },
-                "disclosed": {
-                    "type": "boolean"
+                "failedItems": {
+                    "type": "integer"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "inProgress": {
-                    "type": "boolean"
+                "keyVersion": {
+                    "type": "integer"
                 },
-                "passerID": {
+                "lastError": {
                     "type": "string"
                 },
-                "preventedBy": {
+                "phase": {
                     "type": "string"
                 },
-                "requesterID": {
+                "processedItems": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "totalItems": {
+                    "type": "integer"
+                },
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "handlers.ErrorResponse": {
+        "handlers.NotificationChannelDeleteRequest": {
             "type": "object",
+            "required": [
+                "channel"
+            ],
             "properties": {
-                "details": {
+                "channel": {
+                    "type": "string",
+                    "enum": [
+                        "email",
+                        "line",
+                        "webhook"
+                    ]
+                }
+            }
+        },
+        "handlers.NotificationChannelRequest": {
+            "type": "object",
+            "required": [
+                "address",
+                "channel"
+            ],
+            "properties": {
+                "address": {
+                    "description": "メールアドレス、LINE のユーザーID、Webhook の URL",
                     "type": "string"
                 },
-                "error": {
+                "channel": {
+                    "type": "string",
+                    "enum": [
+                        "email",
+                        "line",
+                        "webhook"
+                    ]
+                }
+            }
+        },
+        "handlers.NotificationChannelResponse": {
+            "type": "object",
+            "required": [
+                "address",
+                "channel",
+                "updatedAt"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "channel": {
+                    "type": "string"
+                },
+                "updatedAt": {
                     "type": "string"
                 }
             }
@@ -1623,12 +3988,92 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.RecoveryWrapRequest": {
+            "type": "object",
+            "required": [
+                "format",
+                "wrap"
+            ],
+            "properties": {
+                "format": {
+                    "description": "クライアントが決める方式 (例: 復旧コードから鍵を導出する KDF と暗号)",
+                    "type": "string"
+                },
+                "wrap": {
+                    "description": "クライアントが包んだ鍵。crypto サービスは中身を開けない",
+                    "type": "string",
+                    "format": "byte"
+                }
+            }
+        },
+        "handlers.RecoveryWrapResponse": {
+            "type": "object",
+            "required": [
+                "format",
+                "version",
+                "wrap"
+            ],
+            "properties": {
+                "format": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                },
+                "wrap": {
+                    "type": "string",
+                    "format": "byte"
+                }
+            }
+        },
+        "handlers.ReminderPlanResponse": {
+            "type": "object",
+            "required": [
+                "isDefault",
+                "offsetsHours"
+            ],
+            "properties": {
+                "isDefault": {
+                    "description": "設定していないため既定の計画を使っている",
+                    "type": "boolean"
+                },
+                "offsetsHours": {
+                    "description": "開示請求を受けてから何時間後に催促するか",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "handlers.ReminderPlanUpdateRequest": {
+            "type": "object",
+            "required": [
+                "offsetsHours"
+            ],
+            "properties": {
+                "offsetsHours": {
+                    "description": "開示請求を受けてから何時間後に催促するか。昇順に並べる",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
         "handlers.SubscriptionCreateRequest": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "integer"
                 },
+                "assignments": {
+                    "description": "受取人ごとの割り当て。更新で省略したときは今の割り当てのまま",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AssignmentRequest"
+                    }
+                },
                 "billingCycle": {
                     "type": "string"
                 },
@@ -1653,9 +4098,6 @@ const docTemplate = `{
                 "message": {
                     "type": "string"
                 },
-                "passerID": {
-                    "type": "string"
-                },
                 "password": {
                     "type": "string"
                 },
@@ -1676,6 +4118,13 @@ const docTemplate = `{
                 "amount": {
                     "type": "integer"
                 },
+                "assignments": {
+                    "description": "パッサーにのみ返す、受取人ごとの割り当て",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AssignmentResponse"
+                    }
+                },
                 "billingCycle": {
                     "type": "string"
                 },
@@ -1688,14 +4137,12 @@ const docTemplate = `{
                         "type": "integer"
                     }
                 },
-                "email": {
+                "decryptError": {
+                    "description": "復号に失敗したときのみ設定される。Password は空になる",
                     "type": "string"
                 },
-                "encPassword": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "email": {
+                    "type": "string"
                 },
                 "iconUrl": {
                     "type": "string"
@@ -1715,6 +4162,17 @@ const docTemplate = `{
                 "passerID": {
                     "type": "string"
                 },
+                "password": {
+                    "type": "string"
+                },
+                "permission": {
+                    "description": "受取人にのみ返す、自分に見せられる範囲。message ならログイン情報は空になる",
+                    "type": "string",
+                    "enum": [
+                        "credentials",
+                        "message"
+                    ]
+                },
                 "plsDelete": {
                     "type": "boolean"
                 },
@@ -1722,6 +4180,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "trustID": {
+                    "description": "パッサーには最初の割り当ての、受取人には自分への割り当ての相続関係ID。割り当てがなければ空",
                     "type": "integer"
                 },
                 "username": {
@@ -1735,6 +4194,13 @@ const docTemplate = `{
                 "amount": {
                     "type": "integer"
                 },
+                "assignments": {
+                    "description": "受取人ごとの割り当て。更新で省略したときは今の割り当てのまま",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AssignmentRequest"
+                    }
+                },
                 "billingCycle": {
                     "type": "string"
                 },
@@ -1762,9 +4228,6 @@ const docTemplate = `{
                 "message": {
                     "type": "string"
                 },
-                "passerID": {
-                    "type": "string"
-                },
                 "password": {
                     "type": "string"
                 },
@@ -1779,12 +4242,85 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.TrustRequest": {
+        "handlers.TrustInvitationCreateRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TrustInvitationDeleteRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.TrustInvitationRespondRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TrustInvitationResponse": {
             "type": "object",
+            "required": [
+                "createdAt",
+                "email",
+                "expiresAt",
+                "id",
+                "passerID",
+                "status"
+            ],
             "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
                 "passerID": {
                     "type": "string"
                 },
+                "receiverID": {
+                    "description": "招待された人が登録するかリンクを開くまでは空",
+                    "type": "string"
+                },
+                "respondedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, accepted, declined, revoked のいずれか",
+                    "type": "string"
+                },
+                "trustID": {
+                    "description": "招待からできた相続関係",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.TrustRequest": {
+            "type": "object",
+            "properties": {
                 "reviverID": {
                     "type": "string"
                 }
@@ -1807,9 +4343,6 @@ const docTemplate = `{
         "handlers.UpdateTrustRequest": {
             "type": "object",
             "properties": {
-                "passerID": {
-                    "type": "string"
-                },
                 "reviverID": {
                     "type": "string"
                 },
@@ -1850,6 +4383,61 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "handlers.UserUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "defaultReceiverID": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ZeroKnowledgeEnvelope": {
+            "type": "object",
+            "required": [
+                "envelope",
+                "receiverUserID"
+            ],
+            "properties": {
+                "envelope": {
+                    "type": "string",
+                    "format": "byte"
+                },
+                "receiverUserID": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ZeroKnowledgeSecret": {
+            "type": "object",
+            "required": [
+                "ciphertext",
+                "format",
+                "ownerKeyEnvelope"
+            ],
+            "properties": {
+                "ciphertext": {
+                    "description": "アイテム鍵で暗号化したパスワード",
+                    "type": "string",
+                    "format": "byte"
+                },
+                "format": {
+                    "description": "クライアントが決める暗号方式 (例: argon2id+aes-256-gcm/v1)",
+                    "type": "string"
+                },
+                "ownerKeyEnvelope": {
+                    "description": "パスフレーズから導出した鍵で包んだアイテム鍵",
+                    "type": "string",
+                    "format": "byte"
+                },
+                "receiverEnvelopes": {
+                    "description": "受取人の公開鍵で包んだアイテム鍵。受取人には開示後に自分の分だけを返す",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ZeroKnowledgeEnvelope"
+                    }
+                }
+            }
         }
     }
 }`
@@ -10,6 +10,7 @@ import (
 
 	"github.com/a-company-jp/digi-baton/backend/db/query"
 	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/service"
 	"github.com/a-company-jp/digi-baton/proto/crypto"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -48,45 +49,62 @@ var accountTemplateMap map[int32]AccountTemplate = map[int32]AccountTemplate{
 type AccountsHandler struct {
 	queries      *query.Queries
 	cryptoClient crypto.EncryptionServiceClient
+	disclosures  *service.DisclosureService
+	grants       *service.DecryptGrantService
 }
 
-func NewAccountsHandler(q *query.Queries, cryptoClient crypto.EncryptionServiceClient) *AccountsHandler {
-	return &AccountsHandler{queries: q, cryptoClient: cryptoClient}
+func NewAccountsHandler(q *query.Queries, cryptoClient crypto.EncryptionServiceClient, disclosures *service.DisclosureService, grants *service.DecryptGrantService) *AccountsHandler {
+	return &AccountsHandler{queries: q, cryptoClient: cryptoClient, disclosures: disclosures, grants: grants}
 }
 
 // 冗長に見えるが、後でrequestとresponseのフィールドが変わる可能性があるため
 type AccountResponse struct {
-	ID             int32                  `json:"id" validate:"required"`
-	AppTemplateID  *int32                 `json:"appTemplateID"`
-	AppName        string                 `json:"appName" validate:"required"`
-	AppDescription string                 `json:"appDescription" validate:"required"`
-	AppIconUrl     string                 `json:"appIconUrl"`
-	Username       string                 `json:"username"`
-	Email          string                 `json:"email"`
-	Password       string                 `json:"password" validate:"required"`
-	Memo           string                 `json:"memo"`
-	PlsDelete      bool                   `json:"plsDelete"  validate:"required"`
-	Message        string                 `json:"message"`
-	PasserID       string                 `json:"passerID"  validate:"required"`
+	ID             int32  `json:"id" validate:"required"`
+	AppTemplateID  *int32 `json:"appTemplateID"`
+	AppName        string `json:"appName" validate:"required"`
+	AppDescription string `json:"appDescription" validate:"required"`
+	AppIconUrl     string `json:"appIconUrl"`
+	Username       string `json:"username"`
+	Email          string `json:"email"`
+	Password       string `json:"password" validate:"required"`
+	Memo           string `json:"memo"`
+	PlsDelete      bool   `json:"plsDelete"  validate:"required"`
+	Message        string `json:"message"`
+	PasserID       string `json:"passerID"  validate:"required"`
+	// パッサーには最初の割り当ての、受取人には自分への割り当ての相続関係ID。割り当てがなければ 0
 	TrustID        int32                  `json:"trustID" validate:"required"`
 	IsDisclosed    bool                   `json:"isDisclosed" validate:"required"`
 	CustomData     map[string]interface{} `json:"customData"`
+	EncryptionMode string                 `json:"encryptionMode" validate:"required" enums:"server,zero_knowledge"`
+	// パッサーにのみ返す、受取人ごとの割り当て
+	Assignments []AssignmentResponse `json:"assignments,omitempty"`
+	// 受取人にのみ返す、自分に見せられる範囲。message ならログイン情報は空になる
+	Permission string `json:"permission,omitempty" enums:"credentials,message"`
+	// ゼロ知識モードのときのみ設定される。Password は空になる
+	ZeroKnowledge *ZeroKnowledgeSecret `json:"zeroKnowledge,omitempty"`
+	// パスワードを復号できなかった場合のみ設定される
+	DecryptError string `json:"decryptError,omitempty"`
 }
 
 type AccountCreateRequest struct {
-	AppTemplateID  *int32                  `json:"appTemplateID"`
-	AppName        string                  `json:"appName" validate:"required"`
-	AppDescription string                  `json:"appDescription"`
-	AppIconUrl     string                  `json:"appIconUrl"`
-	Username       string                  `json:"username"`
-	Email          string                  `json:"email"`
-	Password       string                  `json:"password" validate:"required"`
-	Memo           string                  `json:"memo"`
-	PlsDelete      bool                    `json:"plsDelete" validate:"required"`
-	Message        string                  `json:"message"`
-	PasserID       string                  `json:"passerID" validate:"required"`
-	TrustID        int32                   `json:"trustID" validate:"required"`
-	CustomData     *map[string]interface{} `json:"customData"`
+	AppTemplateID  *int32 `json:"appTemplateID"`
+	AppName        string `json:"appName" validate:"required"`
+	AppDescription string `json:"appDescription"`
+	AppIconUrl     string `json:"appIconUrl"`
+	Username       string `json:"username"`
+	Email          string `json:"email"`
+	Password       string `json:"password"`
+	Memo           string `json:"memo"`
+	PlsDelete      bool   `json:"plsDelete" validate:"required"`
+	Message        string `json:"message"`
+	// 1人の受取人にログイン情報まで託すときの省略形。assignments と一緒には指定できない
+	TrustID *int32 `json:"trustID"`
+	// 受取人ごとの割り当て。更新で省略したときは今の割り当てのまま
+	Assignments []AssignmentRequest     `json:"assignments"`
+	CustomData  *map[string]interface{} `json:"customData"`
+	// 省略時は server。zero_knowledge のときは password を空にし、zeroKnowledge を指定する
+	EncryptionMode string               `json:"encryptionMode" enums:"server,zero_knowledge"`
+	ZeroKnowledge  *ZeroKnowledgeSecret `json:"zeroKnowledge"`
 }
 
 // List アカウント一覧取得
@@ -113,14 +131,41 @@ func (h *AccountsHandler) List(c *gin.Context) {
 		return
 	}
 
+	// パスワードはまとめて1回で復号する
+	grant, err := h.grants.OwnAccounts(userUUID, accounts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードの復号の許可に失敗しました", "details": err.Error()})
+		return
+	}
+	ciphertexts := make([][]byte, len(accounts))
+	for i, account := range accounts {
+		ciphertexts[i] = account.EncPassword
+	}
+	secrets := decryptSecrets(c.Request.Context(), h.cryptoClient, userUUID.String(), crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, grant, ciphertexts)
+
+	// ゼロ知識モードのアカウントはクライアントが暗号化したまま返す
+	zkSecrets, err := h.ownZeroKnowledgeSecrets(c.Request.Context(), userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "暗号化されたパスワードの取得に失敗しました", "details": err.Error()})
+		return
+	}
+
+	assignments, err := h.queries.ListAccountAssignmentsByPasserId(c, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "受取人の割り当ての取得に失敗しました", "details": err.Error()})
+		return
+	}
+	assignmentsByAccount := map[int32][]query.AccountAssignment{}
+	for _, assignment := range assignments {
+		assignmentsByAccount[assignment.AccountID] = append(assignmentsByAccount[assignment.AccountID], assignment)
+	}
+
 	response := make([]AccountResponse, len(accounts))
 	for i, account := range accounts {
-		resp, err := accountToResponse(account, h.cryptoClient, c.Request.Context())
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "アカウント情報の変換に失敗しました", "details": err.Error()})
-			return
-		}
-		response[i] = resp
+		response[i] = accountToResponse(account, secrets[i].Plaintext)
+		response[i].DecryptError = secrets[i].Error
+		response[i].ZeroKnowledge = zkSecrets[account.ID]
+		response[i].setAssignments(accountAssignmentsToResponse(assignmentsByAccount[account.ID]))
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -128,16 +173,23 @@ func (h *AccountsHandler) List(c *gin.Context) {
 
 // Create アカウント作成
 // @Summary アカウント作成
-// @Description アカウントを作成する
+// @Description 自分のアカウントを作成し、assignments (または trustID) で指定した受取人に託す
 // @Tags accounts
 // @Accept json
 // @Produce json
 // @Param account body AccountCreateRequest true "アカウント情報"
 // @Success 200 {object} AccountResponse "成功"
 // @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 404 {object} ErrorResponse "相続関係が見つかりませんでした"
+// @Failure 409 {object} ErrorResponse "受取人が招待をまだ承諾していません"
 // @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
 // @Router /accounts [post]
 func (h *AccountsHandler) Create(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req AccountCreateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -145,16 +197,36 @@ func (h *AccountsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	params, err := reqToCreateAccountParams(req)
+	params, err := reqToCreateAccountParams(passerID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "パラメータ変換中にエラーが発生しました", "details": err.Error()})
 		return
 	}
 
+	assignments, _, err := req.assignments()
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+	// 他のユーザーの相続関係には託させない
+	if err := h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments); err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	var zkReceivers []pgtype.UUID
+	if params.EncryptionMode == EncryptionModeZeroKnowledge {
+		zkReceivers, err = h.zeroKnowledgeReceivers(c.Request.Context(), params.PasserID, credentialTrustIDs(assignments), req.ZeroKnowledge)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "受取人ごとの鍵が不正です", "details": err.Error()})
+			return
+		}
+	}
+
 	// パスワードを暗号化する
-	if req.Password != "" {
+	if params.EncryptionMode == EncryptionModeServer {
 		encResp, err := h.cryptoClient.Encrypt(c, &crypto.EncryptRequest{
-			UserId:    req.PasserID,
+			UserId:    passerID.String(),
 			Plaintext: []byte(req.Password),
 		})
 		if err != nil {
@@ -171,12 +243,30 @@ func (h *AccountsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	response, err := accountToResponse(account, h.cryptoClient, c.Request.Context())
+	if account.EncryptionMode == EncryptionModeZeroKnowledge {
+		if err := h.saveZeroKnowledgeSecret(c.Request.Context(), account.ID, req.ZeroKnowledge, zkReceivers); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "暗号化されたパスワードの保存に失敗しました", "details": err.Error()})
+			return
+		}
+	}
+
+	// しきい値開示が設定されていれば金庫の鍵でも暗号化しておく
+	account, err = h.disclosures.SealAccount(c.Request.Context(), account)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "アカウント情報の変換に失敗しました", "details": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードの金庫への登録に失敗しました", "details": err.Error()})
 		return
 	}
 
+	assigned, err := h.disclosures.AssignAccount(c.Request.Context(), account, assignments)
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	// 暗号化したばかりなので復号せずにリクエストのパスワードを返す
+	response := accountToResponse(account, req.Password)
+	response.ZeroKnowledge = req.ZeroKnowledge
+	response.setAssignments(accountAssignmentsToResponse(assigned))
 	c.JSON(http.StatusOK, response)
 }
 
@@ -187,16 +277,23 @@ type AccountUpdateRequest struct {
 
 // Update アカウント更新
 // @Summary アカウント更新
-// @Description アカウントを更新する
+// @Description 自分のアカウントを更新する
 // @Tags accounts
 // @Accept json
 // @Produce json
 // @Param account body AccountCreateRequest true "アカウント情報"
 // @Success 200 {object} AccountResponse "成功"
 // @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 404 {object} ErrorResponse "アカウントか相続関係が見つかりません"
+// @Failure 409 {object} ErrorResponse "受取人が招待をまだ承諾していません"
 // @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
 // @Router /accounts [put]
 func (h *AccountsHandler) Update(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req AccountUpdateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -204,16 +301,42 @@ func (h *AccountsHandler) Update(c *gin.Context) {
 		return
 	}
 
-	params, err := reqToUpdateAccountParams(req)
+	params, err := reqToUpdateAccountParams(passerID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "パラメータ変換中にエラーが発生しました", "details": err.Error()})
 		return
 	}
 
+	account, err := h.queries.GetAccount(c, params.ID)
+	if !authorizeOwner(c, passerID, account.PasserID, err) {
+		return
+	}
+
+	assignments, reassign, err := req.assignments()
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+	if reassign {
+		if err := h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments); err != nil {
+			respondAssignmentError(c, err)
+			return
+		}
+	} else {
+		current, err := h.queries.ListAccountAssignmentsByAccountId(c, account.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "受取人の割り当ての取得に失敗しました", "details": err.Error()})
+			return
+		}
+		for _, assignment := range current {
+			assignments = append(assignments, service.Assignment{TrustID: assignment.TrustID, Permission: assignment.Permission})
+		}
+	}
+
 	// パスワードを暗号化する
-	if req.Password != "" {
+	if params.EncryptionMode == EncryptionModeServer {
 		encResp, err := h.cryptoClient.Encrypt(c, &crypto.EncryptRequest{
-			UserId:    req.PasserID,
+			UserId:    passerID.String(),
 			Plaintext: []byte(req.Password),
 		})
 		if err != nil {
@@ -223,10 +346,13 @@ func (h *AccountsHandler) Update(c *gin.Context) {
 		params.EncPassword = encResp.GetCiphertext()
 	}
 
-	account, err := h.queries.GetAccount(c, params.ID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "アカウントが見つかりません", "details": err.Error()})
-		return
+	var zkReceivers []pgtype.UUID
+	if params.EncryptionMode == EncryptionModeZeroKnowledge {
+		zkReceivers, err = h.zeroKnowledgeReceivers(c.Request.Context(), params.PasserID, credentialTrustIDs(assignments), req.ZeroKnowledge)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "受取人ごとの鍵が不正です", "details": err.Error()})
+			return
+		}
 	}
 
 	account, err = h.queries.UpdateAccount(c, params)
@@ -235,32 +361,211 @@ func (h *AccountsHandler) Update(c *gin.Context) {
 		return
 	}
 
-	response, err := accountToResponse(account, h.cryptoClient, c.Request.Context())
+	// 暗号化方式に合わせてクライアント暗号文を保存し直すか、不要になったものを消す
+	if account.EncryptionMode == EncryptionModeZeroKnowledge {
+		err = h.saveZeroKnowledgeSecret(c.Request.Context(), account.ID, req.ZeroKnowledge, zkReceivers)
+	} else {
+		err = h.dropZeroKnowledgeSecret(c.Request.Context(), account.ID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "暗号化されたパスワードの保存に失敗しました", "details": err.Error()})
+		return
+	}
+
+	account, err = h.disclosures.SealAccount(c.Request.Context(), account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードの金庫への登録に失敗しました", "details": err.Error()})
+		return
+	}
+
+	// 割り当てを置き換えるか、開示済みなら受取人側の暗号文も新しいパスワードで作り直す
+	var assigned []query.AccountAssignment
+	if reassign {
+		assigned, err = h.disclosures.AssignAccount(c.Request.Context(), account, assignments)
+		if err != nil {
+			respondAssignmentError(c, err)
+			return
+		}
+	} else {
+		if account.IsDisclosed {
+			account, err = h.disclosures.ReleaseAccount(c.Request.Context(), account)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "受取人へのパスワードの引き渡しに失敗しました", "details": err.Error()})
+				return
+			}
+		}
+		assigned, err = h.queries.ListAccountAssignmentsByAccountId(c, account.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "受取人の割り当ての取得に失敗しました", "details": err.Error()})
+			return
+		}
+	}
+
+	response := accountToResponse(account, req.Password)
+	response.ZeroKnowledge = req.ZeroKnowledge
+	response.setAssignments(accountAssignmentsToResponse(assigned))
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateAssignments アカウントの割り当て更新
+// @Summary アカウントの割り当て更新
+// @Description 自分のアカウントを託す受取人と、受取人ごとに見せる範囲をまとめて置き換える。すでに開示された受取人にはその場で引き渡す
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param assignments body AssignmentsUpdateRequest true "割り当て"
+// @Success 200 {array} AssignmentResponse "成功"
+// @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 404 {object} ErrorResponse "アカウントか相続関係が見つかりませんでした"
+// @Failure 409 {object} ErrorResponse "受取人が招待をまだ承諾していません"
+// @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
+// @Router /accounts/assignments [put]
+func (h *AccountsHandler) UpdateAssignments(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
+	var req AssignmentsUpdateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{"リクエストデータが不正です", err.Error()})
+		return
+	}
+
+	account, err := h.queries.GetAccount(c, req.ID)
+	if !authorizeOwner(c, passerID, account.PasserID, err) {
+		return
+	}
+
+	assignments, err := toAssignments(req.Assignments)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "アカウント情報の変換に失敗しました", "details": err.Error()})
+		respondAssignmentError(c, err)
+		return
+	}
+	if err := h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments); err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+	// ゼロ知識モードではサーバーが受取人の鍵を作れないので、ログイン情報を見せる受取人の鍵が揃っている必要がある
+	if account.EncryptionMode == EncryptionModeZeroKnowledge {
+		if err := h.checkZeroKnowledgeReceivers(c.Request.Context(), account, credentialTrustIDs(assignments)); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{"受取人ごとの鍵が不正です", err.Error()})
+			return
+		}
+	}
+
+	assigned, err := h.disclosures.AssignAccount(c.Request.Context(), account, assignments)
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, accountAssignmentsToResponse(assigned))
+}
+
+// ListDisclosed 開示されたアカウント一覧取得
+// @Summary 開示されたアカウント一覧取得
+// @Description 受取人として開示されたアカウント一覧を取得する。パスワードは受取人自身の鍵で復号する
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Success 200 {array} AccountResponse "成功"
+// @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
+// @Router /accounts/disclosed [get]
+func (h *AccountsHandler) ListDisclosed(c *gin.Context) {
+	receiverUUID, exists := middleware.GetUserIdUUID(c)
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ユーザー認証に失敗しました"})
+		return
+	}
+
+	accounts, err := h.queries.ListDisclosedAccountsByReceiverId(c, receiverUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "開示されたアカウント一覧取得に失敗しました", "details": err.Error()})
+		return
+	}
+
+	response, err := disclosedAccountsToResponse(c.Request.Context(), h.queries, h.cryptoClient, h.grants, receiverUUID, accounts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "開示されたパスワードの取得に失敗しました", "details": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// disclosedAccountsToResponse は受取人に開示されたアカウントをレスポンスに変換する。
+// 受取人向けに暗号化し直したものだけを受取人の鍵で復号し、ゼロ知識モードのものは受取人の鍵で包んだアイテム鍵と一緒に暗号文のまま返す
+// 伝言だけを見せる割り当てでは、ログイン情報を空にして返す
+func disclosedAccountsToResponse(ctx context.Context, q *query.Queries, cryptoClient crypto.EncryptionServiceClient, grants *service.DecryptGrantService, receiverID pgtype.UUID, rows []query.ListDisclosedAccountsByReceiverIdRow) ([]AccountResponse, error) {
+	// 開示が済んでいることを確かめてから許可を出す
+	grant, err := grants.DisclosedAccounts(ctx, receiverID, rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant decryption: %w", err)
+	}
+	ciphertexts := make([][]byte, len(rows))
+	for i, row := range rows {
+		if row.Permission == service.PermissionCredentials {
+			ciphertexts[i] = row.ReceiverEncPassword
+		}
+	}
+	secrets := decryptSecrets(ctx, cryptoClient, receiverID.String(), crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED, grant, ciphertexts)
+
+	zkSecrets, err := disclosedZeroKnowledgeSecrets(ctx, q, grants, receiverID, rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zero knowledge secrets: %w", err)
+	}
+
+	response := make([]AccountResponse, len(rows))
+	for i, row := range rows {
+		account := row.Account
+		response[i] = accountToResponse(account, secrets[i].Plaintext)
+		response[i].TrustID = row.TrustID
+		response[i].Permission = row.Permission
+		if row.Permission != service.PermissionCredentials {
+			response[i].Username = ""
+			response[i].Email = ""
+			response[i].Memo = ""
+			response[i].CustomData = map[string]interface{}{}
+			continue
+		}
+		response[i].DecryptError = secrets[i].Error
+		if len(account.EncPassword) > 0 && len(row.ReceiverEncPassword) == 0 {
+			response[i].DecryptError = "受取人への引き渡しが完了していません"
+		}
+		if account.EncryptionMode == EncryptionModeZeroKnowledge {
+			response[i].ZeroKnowledge = zkSecrets[account.ID]
+			if response[i].ZeroKnowledge == nil {
+				response[i].DecryptError = "受取人向けの鍵がありません"
+			}
+		}
+	}
+	return response, nil
+}
+
 type DeleteAccountCreateRequest struct {
-	PasserID string `json:"passerID"`
-	DeviceID int    `json:"deviceID"`
+	DeviceID int `json:"deviceID"`
 }
 
 // Delete アカウント削除
 // @Summary アカウント削除
-// @Description アカウントを削除する
+// @Description 自分のアカウントを削除する
 // @Tags accounts
 // @Accept json
 // @Produce json
 // @Param account body DeleteAccountCreateRequest true "アカウント情報"
 // @Success 200 {object} AccountResponse "成功"
 // @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 404 {object} ErrorResponse "アカウントが見つかりません"
 // @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
 // @Router /accounts [delete]
 func (h *AccountsHandler) Delete(c *gin.Context) {
+	pID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req DeleteAccountCreateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -268,13 +573,12 @@ func (h *AccountsHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	pID, err := toPGUUID(req.PasserID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{"UUID変換に失敗しました", err.Error()})
+	existing, err := h.queries.GetAccount(c, int32(req.DeviceID))
+	if !authorizeOwner(c, pID, existing.PasserID, err) {
 		return
 	}
 
-	account, err := h.queries.DeleteAccount(c, query.DeleteAccountParams{ID: int32(req.DeviceID), PasserID: pID})
+	account, err := h.queries.DeleteAccount(c, query.DeleteAccountParams{ID: existing.ID, PasserID: pID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{"アカウント削除に失敗しました", err.Error()})
 		return
@@ -299,7 +603,7 @@ func (h *AccountsHandler) ListTemplate(c *gin.Context) {
 	c.JSON(http.StatusOK, templates)
 }
 
-func reqToCreateAccountParams(req AccountCreateRequest) (query.CreateAccountParams, error) {
+func reqToCreateAccountParams(passerID pgtype.UUID, req AccountCreateRequest) (query.CreateAccountParams, error) {
 	var params query.CreateAccountParams
 
 	params.AppName = pgtype.Text{String: req.AppName, Valid: true}
@@ -309,15 +613,7 @@ func reqToCreateAccountParams(req AccountCreateRequest) (query.CreateAccountPara
 	params.Email = req.Email
 	params.Memo = req.Memo
 	params.Message = req.Message
-	params.TrustID = req.TrustID
-
-	if req.PasserID != "" {
-		uuid, err := toPGUUID(req.PasserID)
-		if err != nil {
-			return params, err
-		}
-		params.PasserID = uuid
-	}
+	params.PasserID = passerID
 
 	if req.AppTemplateID == nil {
 		params.AppTemplateID = pgtype.Int4{Valid: false}
@@ -325,7 +621,13 @@ func reqToCreateAccountParams(req AccountCreateRequest) (query.CreateAccountPara
 		params.AppTemplateID = pgtype.Int4{Int32: *req.AppTemplateID, Valid: true}
 	}
 
-	if req.Password == "" {
+	mode, err := accountEncryptionMode(req)
+	if err != nil {
+		return params, err
+	}
+	params.EncryptionMode = mode
+
+	if mode == EncryptionModeServer && req.Password == "" {
 		return params, errors.New("パスワードは必須です。")
 	}
 	strings.ReplaceAll(req.Password, " ", "")
@@ -344,7 +646,7 @@ func reqToCreateAccountParams(req AccountCreateRequest) (query.CreateAccountPara
 	return params, nil
 }
 
-func reqToUpdateAccountParams(req AccountUpdateRequest) (query.UpdateAccountParams, error) {
+func reqToUpdateAccountParams(passerID pgtype.UUID, req AccountUpdateRequest) (query.UpdateAccountParams, error) {
 	var params query.UpdateAccountParams
 
 	params.ID = req.ID
@@ -355,14 +657,7 @@ func reqToUpdateAccountParams(req AccountUpdateRequest) (query.UpdateAccountPara
 	params.Email = req.Email
 	params.Memo = req.Memo
 	params.Message = req.Message
-
-	if req.PasserID != "" {
-		uuid, err := toPGUUID(req.PasserID)
-		if err != nil {
-			return params, err
-		}
-		params.PasserID = uuid
-	}
+	params.PasserID = passerID
 
 	if req.AppTemplateID == nil {
 		params.AppTemplateID = pgtype.Int4{Valid: false}
@@ -370,7 +665,13 @@ func reqToUpdateAccountParams(req AccountUpdateRequest) (query.UpdateAccountPara
 		params.AppTemplateID = pgtype.Int4{Int32: *req.AppTemplateID, Valid: true}
 	}
 
-	if req.Password == "" {
+	mode, err := accountEncryptionMode(req.AccountCreateRequest)
+	if err != nil {
+		return params, err
+	}
+	params.EncryptionMode = mode
+
+	if mode == EncryptionModeServer && req.Password == "" {
 		return params, errors.New("パスワードは必須です。")
 	}
 	strings.ReplaceAll(req.Password, " ", "")
@@ -389,7 +690,7 @@ func reqToUpdateAccountParams(req AccountUpdateRequest) (query.UpdateAccountPara
 	return params, nil
 }
 
-func accountToResponse(account query.Account, cryptoClient crypto.EncryptionServiceClient, ctx context.Context) (AccountResponse, error) {
+func accountToResponse(account query.Account, password string) AccountResponse {
 	var appTemplateID *int32
 	var appName, appDescription, appIconUrl string
 
@@ -407,25 +708,6 @@ func accountToResponse(account query.Account, cryptoClient crypto.EncryptionServ
 		appIconUrl = account.AppIconUrl.String
 	}
 
-	var trustID int32
-	trustID = account.TrustID
-
-	// 暗号化されたパスワードを復号化
-	password := ""
-	// 暗号化されたパスワードがある場合のみ復号化を試みる
-	if len(account.EncPassword) > 0 && cryptoClient != nil && ctx != nil {
-		// パスワードの復号化を行う
-		decResp, err := cryptoClient.Decrypt(ctx, &crypto.DecryptRequest{
-			UserId:     account.PasserID.String(),
-			Ciphertext: account.EncPassword,
-		})
-		if err != nil {
-			fmt.Println(err)
-			return AccountResponse{}, fmt.Errorf("パスワードの復号化に失敗しました: %w", err)
-		}
-		password = string(decResp.GetPlaintext()) // byte[]をstringに変換
-	}
-
 	// CustomDataをJSONからmap[string]interface{}に変換
 	var customData map[string]interface{}
 	if account.CustomData != nil {
@@ -450,8 +732,29 @@ func accountToResponse(account query.Account, cryptoClient crypto.EncryptionServ
 		PlsDelete:      account.PlsDelete,
 		Message:        account.Message,
 		PasserID:       account.PasserID.String(),
-		TrustID:        trustID,
 		IsDisclosed:    account.IsDisclosed,
 		CustomData:     customData,
-	}, nil
+		EncryptionMode: account.EncryptionMode,
+	}
+}
+
+// assignments はリクエストの割り当てを返します。trustID も assignments も省略したときは指定なしとして false を返す
+func (req AccountCreateRequest) assignments() ([]service.Assignment, bool, error) {
+	switch {
+	case req.TrustID != nil && req.Assignments != nil:
+		return nil, false, fmt.Errorf("%w: trustID と assignments はどちらか一方を指定してください", service.ErrInvalidAssignment)
+	case req.TrustID != nil:
+		return []service.Assignment{{TrustID: *req.TrustID, Permission: service.PermissionCredentials}}, true, nil
+	case req.Assignments != nil:
+		assignments, err := toAssignments(req.Assignments)
+		return assignments, true, err
+	default:
+		return nil, false, nil
+	}
+}
+
+// setAssignments はパッサーに返す割り当てを設定します
+func (r *AccountResponse) setAssignments(assignments []AssignmentResponse) {
+	r.Assignments = assignments
+	r.TrustID, _ = primaryTrustID(assignments)
 }
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// 開示請求とは別に、パッサーが自分で設定する定期的な生存確認
+type AliveCheckSchedulesHandler struct {
+	queries *query.Queries
+	checks  *service.AliveCheckService
+}
+
+func NewAliveCheckSchedulesHandler(q *query.Queries, checks *service.AliveCheckService) *AliveCheckSchedulesHandler {
+	return &AliveCheckSchedulesHandler{queries: q, checks: checks}
+}
+
+type AliveCheckScheduleRequest struct {
+	// 確認のリンクを送る間隔 (日)
+	IntervalDays int32 `json:"intervalDays" validate:"required"`
+	// 確認のリンクを送ってから、既定の受取人の名前で開示請求を出すまでの猶予 (日)
+	GraceDays int32 `json:"graceDays" validate:"required"`
+	Enabled   bool  `json:"enabled" validate:"required"`
+}
+
+type AliveCheckScheduleResponse struct {
+	IntervalDays int32 `json:"intervalDays" validate:"required"`
+	GraceDays    int32 `json:"graceDays" validate:"required"`
+	Enabled      bool  `json:"enabled" validate:"required"`
+	// 次に確認のリンクを送る日時
+	NextCheckAt string `json:"nextCheckAt" validate:"required"`
+	// 確認待ちなら、送った確認のリンクの期限
+	ConfirmBy        *string `json:"confirmBy"`
+	LastConfirmedAt  *string `json:"lastConfirmedAt"`
+	LastMissedAt     *string `json:"lastMissedAt"`
+	LastDisclosureID *int32  `json:"lastDisclosureID"`
+}
+
+// Get 定期的な生存確認の取得
+// @Summary		定期的な生存確認の取得
+// @Description	定期的な生存確認の設定と、次に確認のリンクを送る日時を取得する
+// @Tags			alive-check-schedule
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	AliveCheckScheduleResponse	"成功"
+// @Failure		400	{object}	ErrorResponse				"ユーザー認証に失敗しました"
+// @Failure		404	{object}	ErrorResponse				"定期的な生存確認が設定されていません"
+// @Failure		500	{object}	ErrorResponse				"定期的な生存確認の取得に失敗しました"
+// @Router			/alive-check-schedule [get]
+func (h *AliveCheckSchedulesHandler) Get(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	schedule, err := h.queries.GetAliveCheckSchedule(c, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "定期的な生存確認が設定されていません", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "定期的な生存確認の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aliveCheckScheduleToResponse(schedule))
+}
+
+// Update 定期的な生存確認の設定
+// @Summary		定期的な生存確認の設定
+// @Description	確認のリンクを送る間隔と猶予を設定する。猶予までに確認がなければ既定の受取人の名前で開示請求を出すため、有効にするには既定の受取人の設定が必要
+// @Tags			alive-check-schedule
+// @Accept			json
+// @Produce		json
+// @Param			schedule	body		AliveCheckScheduleRequest	true	"定期的な生存確認"
+// @Success		200			{object}	AliveCheckScheduleResponse	"成功"
+// @Failure		400			{object}	ErrorResponse				"リクエストデータが不正です"
+// @Failure		500			{object}	ErrorResponse				"定期的な生存確認の設定に失敗しました"
+// @Router			/alive-check-schedule [put]
+func (h *AliveCheckSchedulesHandler) Update(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	var req AliveCheckScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+	if err := service.ValidateAliveCheckSchedule(req.IntervalDays, req.GraceDays); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+
+	if req.Enabled {
+		user, err := h.queries.GetUser(c, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "ユーザーの取得に失敗しました", Details: err.Error()})
+			return
+		}
+		if !user.DefaultReceiverID.Valid {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "既定の受取人を設定してください", Details: "確認がなかったときに開示請求を出す受取人がいません"})
+			return
+		}
+	}
+
+	schedule, err := h.queries.UpsertAliveCheckSchedule(c, query.UpsertAliveCheckScheduleParams{
+		UserID:       userID,
+		IntervalDays: req.IntervalDays,
+		GraceDays:    req.GraceDays,
+		Enabled:      req.Enabled,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "定期的な生存確認の設定に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aliveCheckScheduleToResponse(schedule))
+}
+
+// Delete 定期的な生存確認の削除
+// @Summary		定期的な生存確認の削除
+// @Description	定期的な生存確認をやめる。確認待ちのリンクがあっても開示請求は出さない
+// @Tags			alive-check-schedule
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	map[string]string	"成功"
+// @Failure		400	{object}	ErrorResponse		"ユーザー認証に失敗しました"
+// @Failure		404	{object}	ErrorResponse		"定期的な生存確認が設定されていません"
+// @Failure		500	{object}	ErrorResponse		"定期的な生存確認の削除に失敗しました"
+// @Router			/alive-check-schedule [delete]
+func (h *AliveCheckSchedulesHandler) Delete(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	deleted, err := h.queries.DeleteAliveCheckSchedule(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "定期的な生存確認の削除に失敗しました", Details: err.Error()})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "定期的な生存確認が設定されていません", Details: ""})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "定期的な生存確認を削除しました"})
+}
+
+// CheckIn アプリからの生存確認
+// @Summary		アプリからの生存確認
+// @Description	リンクを待たずに生存を確認し、次に確認のリンクを送る日時を今から間隔の日数後にする
+// @Tags			alive-check-schedule
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	AliveCheckScheduleResponse	"成功"
+// @Failure		400	{object}	ErrorResponse				"ユーザー認証に失敗しました"
+// @Failure		404	{object}	ErrorResponse				"定期的な生存確認が設定されていません"
+// @Failure		500	{object}	ErrorResponse				"生存確認に失敗しました"
+// @Router			/alive-check-schedule/check-in [post]
+func (h *AliveCheckSchedulesHandler) CheckIn(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	schedule, err := h.checks.CheckIn(c.Request.Context(), userID, service.AliveCheckMethodApp)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "定期的な生存確認が設定されていません", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生存確認に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aliveCheckScheduleToResponse(schedule))
+}
+
+func aliveCheckScheduleToResponse(schedule query.AliveCheckSchedule) AliveCheckScheduleResponse {
+	res := AliveCheckScheduleResponse{
+		IntervalDays: schedule.IntervalDays,
+		GraceDays:    schedule.GraceDays,
+		Enabled:      schedule.Enabled,
+		NextCheckAt:  schedule.NextCheckAt.Time.Format(time.RFC3339),
+	}
+	if confirmBy, ok := service.ConfirmBy(schedule); ok {
+		s := confirmBy.Format(time.RFC3339)
+		res.ConfirmBy = &s
+	}
+	if schedule.LastConfirmedAt.Valid {
+		s := schedule.LastConfirmedAt.Time.Format(time.RFC3339)
+		res.LastConfirmedAt = &s
+	}
+	if schedule.LastMissedAt.Valid {
+		s := schedule.LastMissedAt.Time.Format(time.RFC3339)
+		res.LastMissedAt = &s
+	}
+	if schedule.LastDisclosureID.Valid {
+		res.LastDisclosureID = &schedule.LastDisclosureID.Int32
+	}
+	return res
+}
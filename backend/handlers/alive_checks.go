@@ -30,23 +30,16 @@ type AliveCheckHistoryResponse struct {
 }
 
 // @Summary		アライブチェック履歴一覧取得
-// @Description	アライブチェック履歴一覧を取得する
+// @Description	自分のアライブチェック履歴一覧を取得する
 // @Tags			aliveChecks
 // @Accept			json
 // @Produce		json
-// @Param			passerID	query	string						true	"アライブチェック履歴を取得するユーザのID"
-// @Success		200			{array}	AliveCheckHistoryResponse	"成功"
+// @Success		200	{array}		AliveCheckHistoryResponse	"成功"
+// @Failure		401	{object}	ErrorResponse				"認証に失敗しました"
 // @Router			/alive-checks [get]
 func (h *aliveChecksHandler) List(c *gin.Context) {
-	passerID := c.Query("passerID")
-	if passerID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "passerID is required"})
-		return
-	}
-
-	targetUserID, err := toPGUUID(passerID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid passerID"})
+	targetUserID, ok := actorID(c)
+	if !ok {
 		return
 	}
 
@@ -65,29 +58,33 @@ func (h *aliveChecksHandler) List(c *gin.Context) {
 }
 
 type AliveCheckHistoryCreateRequest struct {
-	TargetUserID *uuid.UUID `json:"targetUserID"`
-	CheckMethod  int32      `json:"checkMethod"`
-	CustomData   *[]byte    `json:"customedData"`
+	CheckMethod int32   `json:"checkMethod"`
+	CustomData  *[]byte `json:"customedData"`
 }
 
 // @Summary		アライブチェック履歴作成
-// @Description	アライブチェック履歴を作成する
+// @Description	自分のアライブチェック履歴を作成する
 // @Tags			aliveChecks
 // @Accept			json
 // @Produce		json
 // @Param			body	body		AliveCheckHistoryCreateRequest	true	"アライブチェック履歴作成リクエスト"
 // @Success		200		{object}	AliveCheckHistoryResponse		"成功"
 // @Failure		400		{object}	ErrorResponse					"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse					"認証に失敗しました"
 // @Failure		500		{object}	ErrorResponse					"データベース接続に失敗しました"
 // @Router			/alive-checks [post]
 func (h *aliveChecksHandler) Create(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req AliveCheckHistoryCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
 		return
 	}
 
-	params, err := reqToCreateAliveCheckHistoryParams(req)
+	params, err := reqToCreateAliveCheckHistoryParams(userID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
 		return
@@ -112,16 +109,22 @@ type AliveCheckHistoryUpdateRequest struct {
 }
 
 // @Summary		アライブチェック履歴更新
-// @Description	アライブチェック履歴を更新する
+// @Description	自分のアライブチェック履歴を更新する
 // @Tags			aliveChecks
 // @Accept			json
 // @Produce		json
 // @Param			body	body		AliveCheckHistoryUpdateRequest	true	"アライブチェック履歴更新リクエスト"
 // @Success		200		{object}	AliveCheckHistoryResponse		"成功"
 // @Failure		400		{object}	ErrorResponse					"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse					"認証に失敗しました"
+// @Failure		404		{object}	ErrorResponse					"確認履歴が見つかりませんでした"
 // @Failure		500		{object}	ErrorResponse					"データベース接続に失敗しました"
 // @Router			/alive-checks [put]
 func (h *aliveChecksHandler) Update(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req AliveCheckHistoryUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
@@ -135,8 +138,7 @@ func (h *aliveChecksHandler) Update(c *gin.Context) {
 	}
 
 	aliveCheckHistory, err := h.queries.GetAliveCheckHistory(c, params.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "確認履歴の取得に失敗しました", Details: err.Error()})
+	if !authorizeOwner(c, userID, aliveCheckHistory.TargetUserID, err) {
 		return
 	}
 
@@ -151,7 +153,7 @@ func (h *aliveChecksHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-func reqToCreateAliveCheckHistoryParams(req AliveCheckHistoryCreateRequest) (query.CreateAliveCheckHistoryParams, error) {
+func reqToCreateAliveCheckHistoryParams(userID pgtype.UUID, req AliveCheckHistoryCreateRequest) (query.CreateAliveCheckHistoryParams, error) {
 	var params query.CreateAliveCheckHistoryParams
 
 	newUUID := uuid.New()
@@ -161,10 +163,6 @@ func reqToCreateAliveCheckHistoryParams(req AliveCheckHistoryCreateRequest) (que
 	}
 	params.ID = pgUUID
 
-	userID, err := toPGUUID(req.TargetUserID.String())
-	if err != nil {
-		return query.CreateAliveCheckHistoryParams{}, err
-	}
 	params.TargetUserID = userID
 	params.CheckMethod = req.CheckMethod
 	params.CheckTime = toPGTimestamp(time.Now())
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AssignmentRequest はアカウント、デバイス、サブスクリプションを託す相続関係と、その受取人に見せる範囲
+type AssignmentRequest struct {
+	TrustID int32 `json:"trustID" validate:"required"`
+	// 省略時は credentials。message なら受取人にはパッサーからの伝言だけを見せる
+	Permission string `json:"permission" enums:"credentials,message"`
+}
+
+type AssignmentResponse struct {
+	TrustID    int32  `json:"trustID" validate:"required"`
+	Permission string `json:"permission" validate:"required" enums:"credentials,message"`
+	// 受取人に引き渡し済みか
+	IsDisclosed bool `json:"isDisclosed" validate:"required"`
+}
+
+// AssignmentsUpdateRequest は1件のアカウント、デバイス、サブスクリプションの割り当てをまとめて置き換える。
+// 空にするとどの受取人にも託さない
+type AssignmentsUpdateRequest struct {
+	ID          int32               `json:"id" validate:"required"`
+	Assignments []AssignmentRequest `json:"assignments" validate:"required"`
+}
+
+// toAssignments はリクエストの割り当てを確かめ、サービスの割り当てに変換します
+func toAssignments(reqs []AssignmentRequest) ([]service.Assignment, error) {
+	assignments := make([]service.Assignment, len(reqs))
+	for i, req := range reqs {
+		assignments[i] = service.Assignment{TrustID: req.TrustID, Permission: req.Permission}
+	}
+	return service.NormalizeAssignments(assignments)
+}
+
+// credentialTrustIDs はログイン情報まで見せる割り当ての相続関係IDを返します
+func credentialTrustIDs(assignments []service.Assignment) []int32 {
+	var ids []int32
+	for _, assignment := range assignments {
+		if assignment.Permission == service.PermissionCredentials {
+			ids = append(ids, assignment.TrustID)
+		}
+	}
+	return ids
+}
+
+// respondAssignmentError は割り当てに失敗した理由に合わせてレスポンスを返します
+func respondAssignmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidAssignment):
+		c.JSON(http.StatusBadRequest, ErrorResponse{"割り当てが不正です", err.Error()})
+	case errors.Is(err, service.ErrTrustNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{"相続関係が見つかりませんでした", "指定したものがないか、操作する権限がありません"})
+	case errors.Is(err, service.ErrTrustAwaitingAcceptance):
+		c.JSON(http.StatusConflict, ErrorResponse{"受取人が招待をまだ承諾していません", "承諾されてから割り当ててください"})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"受取人の割り当てに失敗しました", err.Error()})
+	}
+}
+
+func accountAssignmentsToResponse(assignments []query.AccountAssignment) []AssignmentResponse {
+	response := make([]AssignmentResponse, len(assignments))
+	for i, assignment := range assignments {
+		response[i] = AssignmentResponse{
+			TrustID:     assignment.TrustID,
+			Permission:  assignment.Permission,
+			IsDisclosed: assignment.IsDisclosed,
+		}
+	}
+	return response
+}
+
+func deviceAssignmentsToResponse(assignments []query.DeviceAssignment) []AssignmentResponse {
+	response := make([]AssignmentResponse, len(assignments))
+	for i, assignment := range assignments {
+		response[i] = AssignmentResponse{
+			TrustID:     assignment.TrustID,
+			Permission:  assignment.Permission,
+			IsDisclosed: assignment.IsDisclosed,
+		}
+	}
+	return response
+}
+
+func subscriptionAssignmentsToResponse(assignments []query.SubscriptionAssignment) []AssignmentResponse {
+	response := make([]AssignmentResponse, len(assignments))
+	for i, assignment := range assignments {
+		response[i] = AssignmentResponse{
+			TrustID:     assignment.TrustID,
+			Permission:  assignment.Permission,
+			IsDisclosed: assignment.IsDisclosed,
+		}
+	}
+	return response
+}
+
+// primaryTrustID は trustID を1つだけ返していた頃のクライアント向けに、最初の割り当ての相続関係IDを返します
+func primaryTrustID(assignments []AssignmentResponse) (int32, bool) {
+	if len(assignments) == 0 {
+		return 0, false
+	}
+	return assignments[0].TrustID, true
+}
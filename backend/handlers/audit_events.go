@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditEventsHandler struct {
+	cryptoClient crypto.EncryptionServiceClient
+}
+
+func NewAuditEventsHandler(cryptoClient crypto.EncryptionServiceClient) *AuditEventsHandler {
+	return &AuditEventsHandler{cryptoClient: cryptoClient}
+}
+
+type AuditEventResponse struct {
+	ID        int64  `json:"id" validate:"required"`
+	Operation string `json:"operation" validate:"required"`
+	// crypto サービスを呼び出したサービス
+	Caller string `json:"caller" validate:"required"`
+	// 操作したユーザーのID。バックグラウンドジョブによる操作では空
+	ActorID    string `json:"actorID"`
+	RequestID  string `json:"requestID"`
+	KeyVersion int32  `json:"keyVersion"`
+	CreatedAt  string `json:"createdAt" validate:"required"`
+	// 1つ前のイベントのハッシュと合わせて、改ざんされていないことを確かめられる
+	PrevHash string `json:"prevHash" validate:"required"`
+	Hash     string `json:"hash" validate:"required"`
+}
+
+type AuditEventListResponse struct {
+	Events []AuditEventResponse `json:"events" validate:"required"`
+	// 次のページを取得するときの before。続きがなければ 0
+	NextBefore int64 `json:"nextBefore"`
+}
+
+// List 監査ログ一覧取得
+// @Summary		監査ログ一覧取得
+// @Description	自分の秘密情報がいつ誰によって暗号化・復号されたかを新しい順に取得する
+// @Tags			audit-events
+// @Accept			json
+// @Produce		json
+// @Param			limit		query		int						false	"取得件数（最大500、既定50）"
+// @Param			before		query		int						false	"このIDより古いイベントを取得する"
+// @Param			operation	query		[]string				false	"操作の種類で絞り込む（例: DECRYPT）"	collectionFormat(multi)
+// @Success		200			{object}	AuditEventListResponse	"成功"
+// @Failure		400			{object}	ErrorResponse			"リクエストが不正です"
+// @Failure		500			{object}	ErrorResponse			"監査ログの取得に失敗しました"
+// @Router			/audit-events [get]
+func (h *AuditEventsHandler) List(c *gin.Context) {
+	userID, ok := middleware.GetUserId(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	var limit, before int64
+	var err error
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.ParseInt(v, 10, 32); err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストが不正です", Details: "limit must be a positive integer"})
+			return
+		}
+	}
+	if v := c.Query("before"); v != "" {
+		if before, err = strconv.ParseInt(v, 10, 64); err != nil || before < 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストが不正です", Details: "before must be a positive integer"})
+			return
+		}
+	}
+
+	resp, err := h.cryptoClient.ListAuditEvents(c, &crypto.ListAuditEventsRequest{
+		UserId:     userID,
+		PageSize:   int32(limit),
+		BeforeId:   before,
+		Operations: c.QueryArray("operation"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "監査ログの取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	response := AuditEventListResponse{
+		Events:     make([]AuditEventResponse, len(resp.GetEvents())),
+		NextBefore: resp.GetNextBeforeId(),
+	}
+	for i, e := range resp.GetEvents() {
+		response.Events[i] = AuditEventResponse{
+			ID:         e.GetId(),
+			Operation:  e.GetOperation(),
+			Caller:     e.GetCaller(),
+			ActorID:    e.GetActorId(),
+			RequestID:  e.GetRequestId(),
+			KeyVersion: e.GetKeyVersion(),
+			CreatedAt:  time.UnixMicro(e.GetCreatedAt()).UTC().Format(time.RFC3339),
+			PrevHash:   hex.EncodeToString(e.GetPrevHash()),
+			Hash:       hex.EncodeToString(e.GetHash()),
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Policy はルートごとに、誰がどのリソースを扱えるかを表す。
+// 操作するユーザーは必ず認証から取り、リクエストのユーザーIDは信用しない
+type Policy int
+
+const (
+	// PolicyPublic は認証せずに呼べる。生存確認のトークンのように、リクエスト自体で確かめる
+	PolicyPublic Policy = iota
+	// PolicySelf は認証したユーザー自身のデータだけを扱う。他のユーザーのIDは受け取らない
+	PolicySelf
+	// PolicyOwner はリクエストで指定したリソースを、その持ち主だけが扱える
+	PolicyOwner
+	// PolicyReceiver はリクエストで指定したパッサーのものを、trusts でその受取人になっている人も扱える
+	PolicyReceiver
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyPublic:
+		return "public"
+	case PolicySelf:
+		return "self"
+	case PolicyOwner:
+		return "owner"
+	case PolicyReceiver:
+		return "receiver"
+	default:
+		return "unknown"
+	}
+}
+
+// actorID は認証したユーザーのIDを返します。取れなければ 401 を返す
+func actorID(c *gin.Context) (pgtype.UUID, bool) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{"認証に失敗しました", "ユーザーIDが見つかりません"})
+	}
+	return userID, ok
+}
+
+// authorizeOwner は取得したリソースの持ち主が actor かを確かめます。err はリソースを取得したときのエラー。
+// 見つからないときと持ち主でないときは区別せず 404 を返し、他のユーザーのリソースがあるかを漏らさない
+func authorizeOwner(c *gin.Context, actor, owner pgtype.UUID, err error) bool {
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && owner != actor) {
+		c.JSON(http.StatusNotFound, ErrorResponse{"見つかりませんでした", "指定したものがないか、操作する権限がありません"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"データベース接続に失敗しました", err.Error()})
+		return false
+	}
+	return true
+}
+
+// authorizeReceiver は actor が passerID 本人か、trusts や既定の受取人として passerID の受取人になっているかを確かめます。
+// 受取人でなければ 403 を返す
+func authorizeReceiver(c *gin.Context, q *query.Queries, actor, passerID pgtype.UUID) bool {
+	if actor == passerID {
+		return true
+	}
+	ok, err := q.IsReceiverOfPasser(c, query.IsReceiverOfPasserParams{
+		PasserUserID:   passerID,
+		ReceiverUserID: actor,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"データベース接続に失敗しました", err.Error()})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, ErrorResponse{"権限がありません", "指定したユーザーの受取人ではありません"})
+		return false
+	}
+	return true
+}
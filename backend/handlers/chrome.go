@@ -27,13 +27,13 @@ func NewChromeHandler(q *query.Queries) *ChromeHandler {
 	return &ChromeHandler{queries: q}
 }
 
+// HandleGetAccessibleUsers は自分を受取人にしているパッサーの一覧を返す
 func (h *ChromeHandler) HandleGetAccessibleUsers(c *gin.Context) {
-	userID, err := uuid.Parse(c.Query("user_id"))
-	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid user_id"})
+	userID, ok := actorID(c)
+	if !ok {
 		return
 	}
-	users, err := h.GetAccessibleUsers(c, userID)
+	users, err := h.GetAccessibleUsers(c, uuid.UUID(userID.Bytes))
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
@@ -79,11 +79,19 @@ func (h *ChromeHandler) HandleGetAssertion(c *gin.Context) {
 		ClerkID string    `json:"clerk_id"`
 		ReqJson string    `json:"req_json"`
 	}
+	actor, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req AssertionRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
+	// パスキーを使えるのは本人と受取人だけ
+	if !authorizeReceiver(c, h.queries, actor, utils.ToPgxUUID(req.UserID)) {
+		return
+	}
 	s := webauthn.NewPasskeyStore(h.queries)
 	p := webauthn.NewPasskeyProcessor(*s)
 	resp, err := p.ProcessGetAssertion(c, req.UserID, req.ReqJson)
@@ -96,9 +104,12 @@ func (h *ChromeHandler) HandleGetAssertion(c *gin.Context) {
 
 func (h *ChromeHandler) HandleCreate(c *gin.Context) {
 	type CreateRequest struct {
-		UserID  uuid.UUID `json:"user_id"`
-		ClerkID string    `json:"clerk_id"`
-		ReqJson string    `json:"req_json"`
+		ClerkID string `json:"clerk_id"`
+		ReqJson string `json:"req_json"`
+	}
+	userID, ok := actorID(c)
+	if !ok {
+		return
 	}
 	var req CreateRequest
 	if err := c.BindJSON(&req); err != nil {
@@ -107,7 +118,7 @@ func (h *ChromeHandler) HandleCreate(c *gin.Context) {
 	}
 	s := webauthn.NewPasskeyStore(h.queries)
 	p := webauthn.NewPasskeyProcessor(*s)
-	resp, err := p.ProcessCreate(c, req.UserID, req.ReqJson)
+	resp, err := p.ProcessCreate(c, uuid.UUID(userID.Bytes), req.ReqJson)
 	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
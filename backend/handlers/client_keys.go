@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ゼロ知識モードで使うクライアント側の鍵を扱う。
+// 秘密鍵とパスフレーズはクライアントから出ず、サーバーは公開鍵と包まれた鍵しか持たない
+type ClientKeysHandler struct {
+	queries      *query.Queries
+	cryptoClient crypto.EncryptionServiceClient
+}
+
+func NewClientKeysHandler(q *query.Queries, cryptoClient crypto.EncryptionServiceClient) *ClientKeysHandler {
+	return &ClientKeysHandler{queries: q, cryptoClient: cryptoClient}
+}
+
+type ClientPublicKeyRequest struct {
+	// クライアントが決める方式 (例: x25519)
+	Algorithm string `json:"algorithm" validate:"required"`
+	PublicKey []byte `json:"publicKey" validate:"required" swaggertype:"string" format:"byte"`
+}
+
+type ClientPublicKeyResponse struct {
+	UserID    string `json:"userID" validate:"required"`
+	Algorithm string `json:"algorithm" validate:"required"`
+	PublicKey []byte `json:"publicKey" validate:"required" swaggertype:"string" format:"byte"`
+	UpdatedAt string `json:"updatedAt" validate:"required"`
+}
+
+type RecoveryWrapRequest struct {
+	// クライアントが決める方式 (例: 復旧コードから鍵を導出する KDF と暗号)
+	Format string `json:"format" validate:"required"`
+	// クライアントが包んだ鍵。crypto サービスは中身を開けない
+	Wrap []byte `json:"wrap" validate:"required" swaggertype:"string" format:"byte"`
+}
+
+type RecoveryWrapResponse struct {
+	Version int32  `json:"version" validate:"required"`
+	Format  string `json:"format" validate:"required"`
+	Wrap    []byte `json:"wrap" validate:"required" swaggertype:"string" format:"byte"`
+}
+
+// RegisterPublicKey クライアント公開鍵の登録
+// @Summary		クライアント公開鍵の登録
+// @Description	ゼロ知識モードでパッサーが受取人向けに鍵を包むための公開鍵を登録する。登録済みなら置き換える
+// @Tags			keys
+// @Accept			json
+// @Produce		json
+// @Param			key	body		ClientPublicKeyRequest	true	"公開鍵"
+// @Success		200	{object}	ClientPublicKeyResponse	"成功"
+// @Failure		400	{object}	ErrorResponse			"リクエストデータが不正です"
+// @Failure		500	{object}	ErrorResponse			"公開鍵の登録に失敗しました"
+// @Router			/keys/client [put]
+func (h *ClientKeysHandler) RegisterPublicKey(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	var req ClientPublicKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+	if req.Algorithm == "" || len(req.PublicKey) == 0 || len(req.PublicKey) > maxZeroKnowledgeBlobSize {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: "algorithm and publicKey are required"})
+		return
+	}
+
+	key, err := h.queries.UpsertClientPublicKey(c, query.UpsertClientPublicKeyParams{
+		UserID:    userID,
+		Algorithm: req.Algorithm,
+		PublicKey: req.PublicKey,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "公開鍵の登録に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clientPublicKeyToResponse(key))
+}
+
+// GetPublicKey 自分のクライアント公開鍵の取得
+// @Summary		自分のクライアント公開鍵の取得
+// @Description	登録済みのクライアント公開鍵を取得する
+// @Tags			keys
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	ClientPublicKeyResponse	"成功"
+// @Failure		400	{object}	ErrorResponse			"ユーザー認証に失敗しました"
+// @Failure		404	{object}	ErrorResponse			"公開鍵が登録されていません"
+// @Failure		500	{object}	ErrorResponse			"公開鍵の取得に失敗しました"
+// @Router			/keys/client [get]
+func (h *ClientKeysHandler) GetPublicKey(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	key, err := h.queries.GetClientPublicKey(c, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "公開鍵が登録されていません", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "公開鍵の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clientPublicKeyToResponse(key))
+}
+
+// ListReceiverPublicKeys 受取人のクライアント公開鍵一覧
+// @Summary		受取人のクライアント公開鍵一覧
+// @Description	ゼロ知識モードでアイテム鍵を包むために、自分の受取人の公開鍵を取得する。未登録の受取人は含まれない
+// @Tags			keys
+// @Accept			json
+// @Produce		json
+// @Success		200	{array}		ClientPublicKeyResponse	"成功"
+// @Failure		400	{object}	ErrorResponse			"ユーザー認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse			"公開鍵の取得に失敗しました"
+// @Router			/keys/client/receivers [get]
+func (h *ClientKeysHandler) ListReceiverPublicKeys(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	keys, err := h.queries.ListReceiverClientPublicKeysByPasserId(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "公開鍵の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	response := make([]ClientPublicKeyResponse, len(keys))
+	for i, key := range keys {
+		response[i] = clientPublicKeyToResponse(key)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// EscrowRecoveryWrap 復旧用に包んだ鍵の預け入れ
+// @Summary		復旧用に包んだ鍵の預け入れ
+// @Description	パスフレーズを忘れたときのために、クライアントが包んだ鍵を crypto サービスに預ける。以前のものも残る
+// @Tags			keys
+// @Accept			json
+// @Produce		json
+// @Param			wrap	body		RecoveryWrapRequest		true	"包んだ鍵"
+// @Success		200		{object}	RecoveryWrapResponse	"成功"
+// @Failure		400		{object}	ErrorResponse			"リクエストデータが不正です"
+// @Failure		500		{object}	ErrorResponse			"鍵の預け入れに失敗しました"
+// @Router			/keys/recovery [put]
+func (h *ClientKeysHandler) EscrowRecoveryWrap(c *gin.Context) {
+	userID, ok := middleware.GetUserId(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	var req RecoveryWrapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+	if req.Format == "" || len(req.Wrap) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: "format and wrap are required"})
+		return
+	}
+
+	resp, err := h.cryptoClient.EscrowRecoveryWrap(c.Request.Context(), &crypto.EscrowRecoveryWrapRequest{
+		UserId: userID,
+		Wrap:   req.Wrap,
+		Format: req.Format,
+	})
+	if status.Code(err) == codes.InvalidArgument {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: status.Convert(err).Message()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "鍵の預け入れに失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryWrapResponse{Version: resp.GetVersion(), Format: req.Format, Wrap: req.Wrap})
+}
+
+// GetRecoveryWrap 復旧用に包んだ鍵の取得
+// @Summary		復旧用に包んだ鍵の取得
+// @Description	最後に預けた復旧用の鍵を取得する
+// @Tags			keys
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	RecoveryWrapResponse	"成功"
+// @Failure		400	{object}	ErrorResponse			"ユーザー認証に失敗しました"
+// @Failure		404	{object}	ErrorResponse			"鍵が預けられていません"
+// @Failure		500	{object}	ErrorResponse			"鍵の取得に失敗しました"
+// @Router			/keys/recovery [get]
+func (h *ClientKeysHandler) GetRecoveryWrap(c *gin.Context) {
+	userID, ok := middleware.GetUserId(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	resp, err := h.cryptoClient.GetRecoveryWrap(c.Request.Context(), &crypto.GetRecoveryWrapRequest{UserId: userID})
+	if status.Code(err) == codes.NotFound {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "鍵が預けられていません", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "鍵の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryWrapResponse{Version: resp.GetVersion(), Format: resp.GetFormat(), Wrap: resp.GetWrap()})
+}
+
+func clientPublicKeyToResponse(key query.ClientPublicKey) ClientPublicKeyResponse {
+	return ClientPublicKeyResponse{
+		UserID:    key.UserID.String(),
+		Algorithm: key.Algorithm,
+		PublicKey: key.PublicKey,
+		UpdatedAt: key.UpdatedAt.Time.Format(time.RFC3339),
+	}
+}
@@ -1,36 +1,50 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/a-company-jp/digi-baton/backend/db/query"
 	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type DevicesHandler struct {
-	queries *query.Queries
+	queries      *query.Queries
+	cryptoClient crypto.EncryptionServiceClient
+	disclosures  *service.DisclosureService
+	grants       *service.DecryptGrantService
 }
 
-func NewDevicesHandler(q *query.Queries) *DevicesHandler {
-	return &DevicesHandler{queries: q}
+func NewDevicesHandler(q *query.Queries, cryptoClient crypto.EncryptionServiceClient, disclosures *service.DisclosureService, grants *service.DecryptGrantService) *DevicesHandler {
+	return &DevicesHandler{queries: q, cryptoClient: cryptoClient, disclosures: disclosures, grants: grants}
 }
 
 type DeviceResponse struct {
-	ID                int32                  `json:"id"`
-	DeviceType        int32                  `json:"deviceType"`
-	CredentialType    int32                  `json:"credentialType"`
-	DeviceDescription string                 `json:"deviceDescription"`
-	DeviceUsername    string                 `json:"deviceUsername"`
-	EncPassword       string                 `json:"encPassword"`
-	Memo              string                 `json:"memo"`
-	Message           string                 `json:"message"`
-	PasserID          string                 `json:"passerID"`
-	TrustID           int32                  `json:"trustID"`
-	CustomData        map[string]interface{} `json:"customData"`
+	ID                int32  `json:"id"`
+	DeviceType        int32  `json:"deviceType"`
+	CredentialType    int32  `json:"credentialType"`
+	DeviceDescription string `json:"deviceDescription"`
+	DeviceUsername    string `json:"deviceUsername"`
+	Password          string `json:"password"`
+	Memo              string `json:"memo"`
+	PlsDelete         bool   `json:"plsDelete"`
+	Message           string `json:"message"`
+	PasserID          string `json:"passerID"`
+	// パッサーには最初の割り当ての、受取人には自分への割り当ての相続関係ID。割り当てがなければ 0
+	TrustID    int32                  `json:"trustID"`
+	CustomData map[string]interface{} `json:"customData"`
+	// パッサーにのみ返す、受取人ごとの割り当て
+	Assignments []AssignmentResponse `json:"assignments,omitempty"`
+	// 受取人にのみ返す、自分に見せられる範囲。message ならログイン情報は空になる
+	Permission string `json:"permission,omitempty" enums:"credentials,message"`
+	// 復号に失敗したときのみ設定される。Password は空になる
+	DecryptError string `json:"decryptError,omitempty"`
 }
 
 // @Summary		デバイス一覧取得
@@ -56,9 +70,35 @@ func (h *DevicesHandler) List(c *gin.Context) {
 		return
 	}
 
+	// パスワードはまとめて1回で復号する
+	grant, err := h.grants.OwnDevices(userID, devices)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの復号の許可に失敗しました", err.Error()})
+		return
+	}
+	encPasswords := make([][]byte, len(devices))
+	encVersions := make([]int32, len(devices))
+	for i, device := range devices {
+		encPasswords[i] = device.EncPassword
+		encVersions[i] = device.EncVersion
+	}
+	secrets := decryptVersionedSecrets(c.Request.Context(), h.cryptoClient, userID.String(), grant, encPasswords, encVersions)
+
+	assignments, err := h.queries.ListDeviceAssignmentsByPasserId(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"受取人の割り当ての取得に失敗しました", err.Error()})
+		return
+	}
+	assignmentsByDevice := map[int32][]query.DeviceAssignment{}
+	for _, assignment := range assignments {
+		assignmentsByDevice[assignment.DeviceID] = append(assignmentsByDevice[assignment.DeviceID], assignment)
+	}
+
 	response := make([]DeviceResponse, len(devices))
 	for i, device := range devices {
-		response[i] = deviceToResponse(device)
+		response[i] = deviceToResponse(device, secrets[i].Plaintext)
+		response[i].DecryptError = secrets[i].Error
+		response[i].setAssignments(deviceAssignmentsToResponse(assignmentsByDevice[device.ID]))
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -71,22 +111,31 @@ type DeviceCreateRequest struct {
 	DeviceUsername    string                  `json:"deviceUsername,omitempty"`
 	Password          string                  `json:"password,omitempty"`
 	Memo              string                  `json:"memo,omitempty"`
+	PlsDelete         bool                    `json:"plsDelete"`
 	Message           string                  `json:"message,omitempty"`
-	PasserID          string                  `json:"passerID,omitempty"`
 	CustomData        *map[string]interface{} `json:"customData"`
+	// 受取人ごとの割り当て。更新で省略したときは今の割り当てのまま
+	Assignments []AssignmentRequest `json:"assignments"`
 }
 
 // @Summary		デバイス追加
-// @Description	デバイスを追加する
+// @Description	自分のデバイスを追加し、assignments で指定した受取人に託す
 // @Tags			devices
 // @Accept			json
 // @Produce		json
 // @Param			device	body		DeviceCreateRequest	true	"デバイス情報"
 // @Success		200		{object}	DeviceResponse		"成功"
 // @Failure		400		{object}	ErrorResponse		"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse		"認証に失敗しました"
+// @Failure		404		{object}	ErrorResponse		"相続関係が見つかりませんでした"
+// @Failure		409		{object}	ErrorResponse		"受取人が招待をまだ承諾していません"
 // @Failure		500		{object}	ErrorResponse		"データベース接続に失敗しました"
 // @Router			/devices [post]
 func (h *DevicesHandler) Create(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req DeviceCreateRequest
 
 	// リクエストボディをDeviceCreateRequest構造体にバインド
@@ -96,19 +145,52 @@ func (h *DevicesHandler) Create(c *gin.Context) {
 	}
 
 	// パラメータをSQLクエリ用に変換
-	params, err := reqToCreateDeviceParams(req)
+	params, err := reqToCreateDeviceParams(passerID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{"パラメータ変換中にエラーが発生しました", err.Error()})
 		return
 	}
 
+	// 他のユーザーの相続関係には託させない
+	assignments, err := toAssignments(req.Assignments)
+	if err == nil {
+		err = h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments)
+	}
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	// パスワードを暗号化する
+	params.EncPassword, params.EncVersion, err = encryptSecret(c.Request.Context(), h.cryptoClient, passerID.String(), req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの暗号化に失敗しました", err.Error()})
+		return
+	}
+
 	device, err := h.queries.CreateDevice(c, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{"デバイス作成に失敗しました", err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, device)
+	// しきい値開示が設定されていれば金庫の鍵でも暗号化しておく
+	device, err = h.disclosures.SealDevice(c.Request.Context(), device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの金庫への登録に失敗しました", err.Error()})
+		return
+	}
+
+	assigned, err := h.disclosures.AssignDevice(c.Request.Context(), device, assignments)
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	// 暗号化したばかりなので復号せずにリクエストのパスワードを返す
+	response := deviceToResponse(device, req.Password)
+	response.setAssignments(deviceAssignmentsToResponse(assigned))
+	c.JSON(http.StatusOK, response)
 }
 
 type DeviceUpdateRequest struct {
@@ -117,16 +199,23 @@ type DeviceUpdateRequest struct {
 }
 
 // @Summary		デバイス更新
-// @Description	デバイスを更新する
+// @Description	自分のデバイスを更新する
 // @Tags			devices
 // @Accept			json
 // @Produce		json
 // @Param			device	body		DeviceCreateRequest	true	"デバイス情報"
 // @Success		200		{object}	DeviceResponse		"成功"
 // @Failure		400		{object}	ErrorResponse		"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse		"認証に失敗しました"
+// @Failure		404		{object}	ErrorResponse		"デバイスか相続関係が見つかりませんでした"
+// @Failure		409		{object}	ErrorResponse		"受取人が招待をまだ承諾していません"
 // @Failure		500		{object}	ErrorResponse		"データベース接続に失敗しました"
 // @Router			/devices [put]
 func (h *DevicesHandler) Update(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req DeviceUpdateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -141,8 +230,24 @@ func (h *DevicesHandler) Update(c *gin.Context) {
 	}
 
 	device, err := h.queries.GetDevice(c, params.ID)
+	if !authorizeOwner(c, passerID, device.PasserID, err) {
+		return
+	}
+
+	reassign := req.Assignments != nil
+	assignments, err := toAssignments(req.Assignments)
+	if err == nil && reassign {
+		err = h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments)
+	}
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	// パスワードを暗号化する
+	params.EncPassword, params.EncVersion, err = encryptSecret(c.Request.Context(), h.cryptoClient, device.PasserID.String(), req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{"デバイスが見つかりませんでした", err.Error()})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの暗号化に失敗しました", err.Error()})
 		return
 	}
 
@@ -152,25 +257,109 @@ func (h *DevicesHandler) Update(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, device)
+	// しきい値開示が設定されていれば金庫の鍵でも暗号化しておく
+	device, err = h.disclosures.SealDevice(c.Request.Context(), device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの金庫への登録に失敗しました", err.Error()})
+		return
+	}
+
+	// 割り当てを置き換えるか、開示済みなら受取人側の暗号文も新しいパスワードで作り直す
+	var assigned []query.DeviceAssignment
+	if reassign {
+		assigned, err = h.disclosures.AssignDevice(c.Request.Context(), device, assignments)
+		if err != nil {
+			respondAssignmentError(c, err)
+			return
+		}
+	} else {
+		if device.IsDisclosed {
+			device, err = h.disclosures.ReleaseDevice(c.Request.Context(), device)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{"受取人へのパスワードの引き渡しに失敗しました", err.Error()})
+				return
+			}
+		}
+		assigned, err = h.queries.ListDeviceAssignmentsByDeviceId(c, device.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{"受取人の割り当ての取得に失敗しました", err.Error()})
+			return
+		}
+	}
+
+	response := deviceToResponse(device, req.Password)
+	response.setAssignments(deviceAssignmentsToResponse(assigned))
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary		デバイスの割り当て更新
+// @Description	自分のデバイスを託す受取人と、受取人ごとに見せる範囲をまとめて置き換える。すでに開示された受取人にはその場で引き渡す
+// @Tags			devices
+// @Accept			json
+// @Produce		json
+// @Param			assignments	body		AssignmentsUpdateRequest	true	"割り当て"
+// @Success		200			{array}		AssignmentResponse			"成功"
+// @Failure		400			{object}	ErrorResponse				"リクエストデータが不正です"
+// @Failure		401			{object}	ErrorResponse				"認証に失敗しました"
+// @Failure		404			{object}	ErrorResponse				"デバイスか相続関係が見つかりませんでした"
+// @Failure		409			{object}	ErrorResponse				"受取人が招待をまだ承諾していません"
+// @Failure		500			{object}	ErrorResponse				"データベース接続に失敗しました"
+// @Router			/devices/assignments [put]
+func (h *DevicesHandler) UpdateAssignments(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
+	var req AssignmentsUpdateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{"リクエストデータが不正です", err.Error()})
+		return
+	}
+
+	device, err := h.queries.GetDevice(c, req.ID)
+	if !authorizeOwner(c, passerID, device.PasserID, err) {
+		return
+	}
+
+	assignments, err := toAssignments(req.Assignments)
+	if err == nil {
+		err = h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments)
+	}
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	assigned, err := h.disclosures.AssignDevice(c.Request.Context(), device, assignments)
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deviceAssignmentsToResponse(assigned))
 }
 
 type DeleteDeviceCreateRequest struct {
-	PasserID string `json:"passerID"`
-	DeviceID int    `json:"deviceID"`
+	DeviceID int `json:"deviceID"`
 }
 
 // @Summary		デバイス削除
-// @Description	デバイスを削除する
+// @Description	自分のデバイスを削除する
 // @Tags			devices
 // @Accept			json
 // @Produce		json
 // @Param			device	body		DeleteDeviceCreateRequest	true	"デバイス情報"
 // @Success		200		{object}	DeviceResponse				"成功"
 // @Failure		400		{object}	ErrorResponse				"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse				"認証に失敗しました"
+// @Failure		404		{object}	ErrorResponse				"デバイスが見つかりませんでした"
 // @Failure		500		{object}	ErrorResponse				"データベース接続に失敗しました"
 // @Router			/devices [delete]
 func (h *DevicesHandler) Delete(c *gin.Context) {
+	pID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req DeleteDeviceCreateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -178,14 +367,13 @@ func (h *DevicesHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	pID, err := toPGUUID(req.PasserID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{"UUID変換に失敗しました", err.Error()})
+	existing, err := h.queries.GetDevice(c, int32(req.DeviceID))
+	if !authorizeOwner(c, pID, existing.PasserID, err) {
 		return
 	}
 
 	params := query.DeleteDeviceParams{
-		ID:       int32(req.DeviceID),
+		ID:       existing.ID,
 		PasserID: pID,
 	}
 
@@ -195,27 +383,20 @@ func (h *DevicesHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, device)
+	c.JSON(http.StatusOK, deviceToResponse(device, ""))
 }
 
 // reqToCreateDeviceParams はリクエスト構造体をクエリパラメータに変換
-func reqToCreateDeviceParams(req DeviceCreateRequest) (query.CreateDeviceParams, error) {
+func reqToCreateDeviceParams(passerID pgtype.UUID, req DeviceCreateRequest) (query.CreateDeviceParams, error) {
 	params := query.CreateDeviceParams{}
 
 	params.DeviceType = req.DeviceType
 	params.DeviceDescription = pgtype.Text{String: req.DeviceDescription, Valid: req.DeviceDescription != ""}
 	params.DeviceUsername = pgtype.Text{String: req.DeviceUsername, Valid: req.DeviceUsername != ""}
-	params.EncPassword = []byte(req.Password)
 	params.Memo = req.Memo
+	params.PlsDelete = req.PlsDelete
 	params.Message = req.Message
-
-	if req.PasserID != "" {
-		uuid, err := toPGUUID(req.PasserID)
-		if err != nil {
-			return params, err
-		}
-		params.PasserID = uuid
-	}
+	params.PasserID = passerID
 
 	if req.CustomData == nil {
 		params.CustomData = nil
@@ -238,8 +419,8 @@ func reqToUpdateDeviceParams(req DeviceUpdateRequest) (query.UpdateDeviceParams,
 	params.DeviceType = req.DeviceType
 	params.DeviceDescription = pgtype.Text{String: req.DeviceDescription, Valid: req.DeviceDescription != ""}
 	params.DeviceUsername = pgtype.Text{String: req.DeviceUsername, Valid: req.DeviceUsername != ""}
-	params.EncPassword = []byte(req.Password)
 	params.Memo = req.Memo
+	params.PlsDelete = req.PlsDelete
 	params.Message = req.Message
 
 	if req.CustomData == nil {
@@ -256,8 +437,55 @@ func reqToUpdateDeviceParams(req DeviceUpdateRequest) (query.UpdateDeviceParams,
 	return params, nil
 }
 
-// deviceToResponse converts a database device object to a response object
-func deviceToResponse(device query.Device) DeviceResponse {
+// disclosedDevicesToResponse は受取人に開示されたデバイスをレスポンスに変換する。
+// 受取人向けに暗号化し直したものを受取人の鍵で復号する。暗号化前に保存された行は平文のまま返す。
+// 伝言だけを見せる割り当てでは、ログイン情報を空にして返す
+func disclosedDevicesToResponse(ctx context.Context, cryptoClient crypto.EncryptionServiceClient, grants *service.DecryptGrantService, receiverID pgtype.UUID, rows []query.ListDisclosedDevicesByReceiverIdRow) ([]DeviceResponse, error) {
+	grant, err := grants.DisclosedDevices(ctx, receiverID, rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant decryption: %w", err)
+	}
+	ciphertexts := make([][]byte, len(rows))
+	for i, row := range rows {
+		if row.Permission == service.PermissionCredentials && row.Device.EncVersion != service.EncVersionPlaintext {
+			ciphertexts[i] = row.ReceiverEncPassword
+		}
+	}
+	secrets := decryptSecrets(ctx, cryptoClient, receiverID.String(), crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED, grant, ciphertexts)
+
+	response := make([]DeviceResponse, len(rows))
+	for i, row := range rows {
+		device := row.Device
+		switch {
+		case row.Permission != service.PermissionCredentials:
+			response[i] = deviceToResponse(device, "")
+			response[i].DeviceUsername = ""
+			response[i].Memo = ""
+			response[i].CustomData = map[string]interface{}{}
+		case device.EncVersion == service.EncVersionPlaintext:
+			response[i] = deviceToResponse(device, string(device.EncPassword))
+		default:
+			response[i] = deviceToResponse(device, secrets[i].Plaintext)
+			response[i].DecryptError = secrets[i].Error
+			if len(device.EncPassword) > 0 && len(row.ReceiverEncPassword) == 0 {
+				response[i].DecryptError = "受取人への引き渡しが完了していません"
+			}
+		}
+		response[i].TrustID = row.TrustID
+		response[i].Permission = row.Permission
+	}
+	return response, nil
+}
+
+// setAssignments はパッサーに返す割り当てを設定します
+func (r *DeviceResponse) setAssignments(assignments []AssignmentResponse) {
+	r.Assignments = assignments
+	r.TrustID, _ = primaryTrustID(assignments)
+}
+
+// deviceToResponse converts a database device object to a response object.
+// password is the decrypted password; the stored ciphertext is never returned
+func deviceToResponse(device query.Device, password string) DeviceResponse {
 	var response DeviceResponse
 	var customData map[string]interface{}
 
@@ -265,11 +493,11 @@ func deviceToResponse(device query.Device) DeviceResponse {
 	response.DeviceType = device.DeviceType
 	response.DeviceDescription = device.DeviceDescription.String
 	response.DeviceUsername = device.DeviceUsername.String
-	response.EncPassword = string(device.EncPassword)
+	response.Password = password
 	response.Memo = device.Memo
+	response.PlsDelete = device.PlsDelete
 	response.Message = device.Message
 	response.PasserID = device.PasserID.String()
-	response.TrustID = device.TrustID
 
 	// Parse CustomData JSON if it exists
 	if device.CustomData != nil {
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type DisclosurePoliciesHandler struct {
+	queries     *query.Queries
+	disclosures *service.DisclosureService
+}
+
+func NewDisclosurePoliciesHandler(q *query.Queries, disclosures *service.DisclosureService) *DisclosurePoliciesHandler {
+	return &DisclosurePoliciesHandler{queries: q, disclosures: disclosures}
+}
+
+type DisclosurePolicyResponse struct {
+	PasserID     string  `json:"passerID" validate:"required"`
+	Threshold    int32   `json:"threshold" validate:"required"`
+	ShareCount   int32   `json:"shareCount" validate:"required"`
+	VaultVersion int32   `json:"vaultVersion" validate:"required"`
+	UnlockedAt   *string `json:"unlockedAt"`
+	CreatedAt    string  `json:"createdAt" validate:"required"`
+	UpdatedAt    string  `json:"updatedAt" validate:"required"`
+}
+
+type DisclosurePolicyUpdateRequest struct {
+	// 開示に必要な受取人の人数
+	Threshold int32 `json:"threshold" validate:"required"`
+}
+
+// Get しきい値開示の設定取得
+// @Summary		しきい値開示の設定取得
+// @Description	何人の受取人の開示請求が揃えば開示するかの設定を取得する
+// @Tags			disclosure-policy
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	DisclosurePolicyResponse	"成功"
+// @Failure		400	{object}	ErrorResponse				"ユーザー認証に失敗しました"
+// @Failure		404	{object}	ErrorResponse				"しきい値開示は設定されていません"
+// @Failure		500	{object}	ErrorResponse				"設定の取得に失敗しました"
+// @Router			/disclosure-policy [get]
+func (h *DisclosurePoliciesHandler) Get(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	policy, err := h.queries.GetDisclosurePolicyByPasserId(c, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "しきい値開示は設定されていません", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "設定の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, disclosurePolicyToResponse(policy))
+}
+
+// Update しきい値開示の設定
+// @Summary		しきい値開示の設定
+// @Description	現在の受取人全員に金庫の鍵のシェアを配り、threshold 人の開示請求が揃うまで開示しないようにする。受取人を変えた場合は設定し直す
+// @Tags			disclosure-policy
+// @Accept			json
+// @Produce		json
+// @Param			request	body		DisclosurePolicyUpdateRequest	true	"しきい値"
+// @Success		200		{object}	DisclosurePolicyResponse		"成功"
+// @Failure		400		{object}	ErrorResponse					"リクエストが不正です"
+// @Failure		500		{object}	ErrorResponse					"しきい値開示の設定に失敗しました"
+// @Router			/disclosure-policy [put]
+func (h *DisclosurePoliciesHandler) Update(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	var req DisclosurePolicyUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストが不正です", Details: err.Error()})
+		return
+	}
+
+	policy, err := h.disclosures.ConfigurePolicy(c.Request.Context(), userID, req.Threshold)
+	if errors.Is(err, service.ErrNoReceivers) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "受取人が登録されていません", Details: err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidThreshold) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "しきい値は1以上、受取人の人数以下にしてください", Details: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "しきい値開示の設定に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, disclosurePolicyToResponse(policy))
+}
+
+// Delete しきい値開示の解除
+// @Summary		しきい値開示の解除
+// @Description	しきい値開示をやめ、受取人1人の開示請求で開示されるようにする
+// @Tags			disclosure-policy
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	DisclosurePolicyResponse	"成功"
+// @Failure		400	{object}	ErrorResponse				"ユーザー認証に失敗しました"
+// @Failure		404	{object}	ErrorResponse				"しきい値開示は設定されていません"
+// @Failure		500	{object}	ErrorResponse				"しきい値開示の解除に失敗しました"
+// @Router			/disclosure-policy [delete]
+func (h *DisclosurePoliciesHandler) Delete(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	policy, err := h.disclosures.RemovePolicy(c.Request.Context(), userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "しきい値開示は設定されていません", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "しきい値開示の解除に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, disclosurePolicyToResponse(policy))
+}
+
+func disclosurePolicyToResponse(policy query.DisclosurePolicy) DisclosurePolicyResponse {
+	response := DisclosurePolicyResponse{
+		PasserID:     policy.PasserID.String(),
+		Threshold:    policy.Threshold,
+		ShareCount:   policy.ShareCount,
+		VaultVersion: policy.VaultVersion,
+		CreatedAt:    policy.CreatedAt.Time.Format(time.RFC3339),
+		UpdatedAt:    policy.UpdatedAt.Time.Format(time.RFC3339),
+	}
+	if policy.UnlockedAt.Valid {
+		unlockedAt := policy.UnlockedAt.Time.Format(time.RFC3339)
+		response.UnlockedAt = &unlockedAt
+	}
+	return response
+}
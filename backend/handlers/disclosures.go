@@ -1,73 +1,44 @@
 package handlers
 
 import (
-	"bytes"
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 
 	"github.com/a-company-jp/digi-baton/backend/db/query"
 	"github.com/a-company-jp/digi-baton/backend/middleware"
-	"github.com/a-company-jp/digi-baton/backend/pkg/mail"
-	"github.com/a-company-jp/digi-baton/backend/pkg/verification"
-	"github.com/clerk/clerk-sdk-go/v2/user"
+	"github.com/a-company-jp/digi-baton/backend/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type DisclosuresHandler struct {
-	queries            *query.Queries
-	mailSender         *mail.Sender
-	tokenManager       *verification.VerificationTokenManager
-	verificationURLFmt string
+	queries     *query.Queries
+	reminders   *service.ReminderService
+	disclosures *service.DisclosureService
 }
 
-func NewDisclosuresHandler(q *query.Queries) *DisclosuresHandler {
-	// メール送信機能の初期化
-	mailjetPublicKey := os.Getenv("MAILJET_API_KEY_PUBLIC")
-	mailjetPrivateKey := os.Getenv("MAILJET_API_KEY_PRIVATE")
-	mailjetFromEmail := os.Getenv("MAILJET_FROM_EMAIL")
-	mailjetFromName := os.Getenv("MAILJET_FROM_NAME")
-	jwtSecret := os.Getenv("JWT_SECRET")
-	frontendURL := os.Getenv("FRONTEND_URL")
-
-	// 有効期限は1週間に設定
-	expiresIn := 7 * 24 * time.Hour
-
-	// トークンマネージャーを初期化
-	tokenManager := verification.NewVerificationTokenManager(jwtSecret, expiresIn)
-
-	// Mailjetのメール送信機能を初期化
-	var mailSender *mail.Sender
-	if mailjetPublicKey != "" && mailjetPrivateKey != "" && mailjetFromEmail != "" {
-		if mailjetFromName == "" {
-			mailjetFromName = "Digi Baton" // デフォルト送信者名
-		}
-		mailSender = mail.NewSender(mailjetPublicKey, mailjetPrivateKey, mailjetFromEmail, mailjetFromName)
-	} else {
-		// 環境変数が設定されていない場合はダミー送信者を使用（ログのみ出力）
-		fmt.Println("WARNING: Mailjet credentials not found, using dummy email sender")
-		mailSender = mail.NewDummySender()
-	}
-
-	return &DisclosuresHandler{
-		queries:            q,
-		mailSender:         mailSender,
-		tokenManager:       tokenManager,
-		verificationURLFmt: frontendURL + "/verify?token=%s&disclosure_id=%d",
-	}
+func NewDisclosuresHandler(q *query.Queries, reminders *service.ReminderService, disclosures *service.DisclosureService) *DisclosuresHandler {
+	return &DisclosuresHandler{queries: q, reminders: reminders, disclosures: disclosures}
 }
 
 type DisclosureResponse struct {
-	ID          int32      `json:"id" validate:"required"`
-	RequesterID string     `json:"requesterID" validate:"required"`
-	PasserID    string     `json:"passerID" validate:"required"`
-	IssuedTime  string     `json:"issuedTime" validate:"required"`
-	InProgress  bool       `json:"inProgress" validate:"required"`
+	ID          int32  `json:"id" validate:"required"`
+	RequesterID string `json:"requesterID" validate:"required"`
+	PasserID    string `json:"passerID" validate:"required"`
+	IssuedTime  string `json:"issuedTime" validate:"required"`
+	// requested, notifying, grace, vetoed, disclosed, cancelled, expired のいずれか
+	Status          string `json:"status" validate:"required"`
+	StatusChangedAt string `json:"statusChangedAt" validate:"required"`
+	// status から求めた値。まだ処理されていなければ true
+	InProgress bool `json:"inProgress" validate:"required"`
+	// status から求めた値。開示済みなら true
 	Disclosed   bool       `json:"disclosed" validate:"required"`
 	PreventedBy *uuid.UUID `json:"preventedBy" validate:"required"`
 	Deadline    string     `json:"deadline" validate:"required"`
@@ -111,168 +82,262 @@ func (h *DisclosuresHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-type DisclosureCreateRequest struct {
-	PasserID         string                 `json:"passerID"`
-	DeadlineDuration int32                  `json:"deadlineDuration"`
-	CustomData       map[string]interface{} `json:"customData"`
+// @Summary		自分への開示請求一覧取得
+// @Description	パッサーとして自分が受けている開示請求の一覧を取得する
+// @Tags			disclosures
+// @Accept			json
+// @Produce		json
+// @Success		200	{array}		DisclosureResponse	"成功"
+// @Failure		400	{object}	ErrorResponse		"ユーザー認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse		"開示請求の取得に失敗しました"
+// @Router			/disclosures/incoming [get]
+func (h *DisclosuresHandler) ListIncoming(c *gin.Context) {
+	passerID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	disclosures, err := h.queries.ListDisclosuresByPasserId(c, passerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	response, err := disclosuresToResponse(disclosures)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求のレスポンス変換に失敗しました", Details: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-// @Summary		開示申請作成
-// @Description	ユーザが他のユーザに開示請求を出す
+type DisclosureRejectRequest struct {
+	ID int32 `json:"id" validate:"required"`
+}
+
+// @Summary		開示請求の却下
+// @Description	パッサーが自分への開示請求を却下する。マジックリンクでの生存確認と同じく、生存確認の履歴を残して開示請求を止める
 // @Tags			disclosures
 // @Accept			json
 // @Produce		json
-// @Param			disclosure	body		DisclosureCreateRequest	true	"開示請求情報"
+// @Param			disclosure	body		DisclosureRejectRequest	true	"却下する開示請求"
 // @Success		200			{object}	DisclosureResponse		"成功"
 // @Failure		400			{object}	ErrorResponse			"リクエストが不正"
-// @Failure		500			{object}	ErrorResponse			"開示請求の作成に失敗"
-// @Router			/disclosures [post]
-func (h *DisclosuresHandler) Create(c *gin.Context) {
-	requesterID, ok := middleware.GetUserIdUUID(c)
+// @Failure		404			{object}	ErrorResponse			"開示請求が見つかりませんでした"
+// @Failure		409			{object}	ErrorResponse			"開示請求はすでに処理されています"
+// @Failure		500			{object}	ErrorResponse			"開示請求の却下に失敗しました"
+// @Router			/disclosures/reject [post]
+func (h *DisclosuresHandler) Reject(c *gin.Context) {
+	passerID, ok := middleware.GetUserIdUUID(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: ""})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
 		return
 	}
-	var req DisclosureCreateRequest
+	var req DisclosureRejectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
 		return
 	}
 
-	params, err := reqToCreateDisclosureParams(requesterID, req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
+	disclosure, err := h.queries.GetDisclosure(c, req.ID)
+	if !authorizeOwner(c, passerID, disclosure.PasserID, err) {
 		return
 	}
 
-	disclosure, err := h.queries.CreateDisclosure(c, params)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求の作成に失敗しました", Details: err.Error()})
+	disclosure, err = h.disclosures.Prevent(c.Request.Context(), disclosure, service.AliveCheckMethodApp)
+	if errors.Is(err, service.ErrDisclosureNotPending) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "開示請求はすでに処理されています", Details: ""})
+		return
+	}
+	if err != nil && disclosure.Status != service.DisclosureStatusVetoed {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求の却下に失敗しました", Details: err.Error()})
 		return
 	}
+	if err != nil {
+		log.Printf("disclosure %d was rejected but: %v", disclosure.ID, err)
+	}
 
 	res, err := disclosureToResponse(disclosure)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求のレスポンス変換に失敗しました", Details: err.Error()})
 		return
 	}
-
-	// 生存確認メールを送信するロジック
-	h.sendAliveCheckEmail(c.Request.Context(), disclosure)
-
 	c.JSON(http.StatusOK, res)
 }
 
-// sendAliveCheckEmail は生存確認のためのメールを送信します
-func (h *DisclosuresHandler) sendAliveCheckEmail(ctx context.Context, disclosure query.Disclosure) {
-	fmt.Println("h.sendAliveCheckEmail()")
-	// このコンテキストではクエリを実行しないので新しいコンテキストを作成
-	passerID := disclosure.PasserID.String()
+type DisclosureRejectAllResponse struct {
+	// 却下した開示請求
+	Rejected []DisclosureResponse `json:"rejected" validate:"required"`
+	// 却下できなかった開示請求があれば、その理由
+	Error string `json:"error,omitempty"`
+}
 
-	// DBからパッサーのClerkIDを取得する
-	users, err := h.queries.ListUsers(ctx)
-	if err != nil {
+// @Summary		開示請求の一括却下
+// @Description	生きていることを伝え、自分へのまだ処理されていない開示請求をすべて却下する
+// @Tags			disclosures
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	DisclosureRejectAllResponse	"成功"
+// @Failure		400	{object}	ErrorResponse				"ユーザー認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse				"開示請求の却下に失敗しました"
+// @Router			/disclosures/reject-all [post]
+func (h *DisclosuresHandler) RejectAll(c *gin.Context) {
+	passerID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
 		return
 	}
 
-	var clerkUserID string
-	for _, u := range users {
-		if u.ID.String() == passerID {
-			clerkUserID = u.ClerkUserID
-			break
-		}
+	prevented, err := h.disclosures.PreventAll(c.Request.Context(), passerID, service.AliveCheckMethodApp)
+	if err != nil && len(prevented) == 0 {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求の却下に失敗しました", Details: err.Error()})
+		return
 	}
 
-	if clerkUserID == "" {
-		return // Clerk IDが見つからない
+	rejected, convErr := disclosuresToResponse(prevented)
+	if convErr != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求のレスポンス変換に失敗しました", Details: convErr.Error()})
+		return
 	}
-
-	// Clerkからユーザー情報を取得
-	clerkUser, err := user.Get(ctx, clerkUserID)
+	res := DisclosureRejectAllResponse{Rejected: rejected}
 	if err != nil {
-		return
+		res.Error = err.Error()
 	}
+	c.JSON(http.StatusOK, res)
+}
 
-	// メールアドレスがない場合は処理終了
-	if len(clerkUser.EmailAddresses) == 0 {
+type DisclosureCreateRequest struct {
+	PasserID         string                 `json:"passerID"`
+	DeadlineDuration int32                  `json:"deadlineDuration"`
+	CustomData       map[string]interface{} `json:"customData"`
+}
+
+// @Summary		開示申請作成
+// @Description	ユーザが他のユーザに開示請求を出す
+// @Tags			disclosures
+// @Accept			json
+// @Produce		json
+// @Param			disclosure	body		DisclosureCreateRequest	true	"開示請求情報"
+// @Success		200			{object}	DisclosureResponse		"成功"
+// @Failure		400			{object}	ErrorResponse			"リクエストが不正"
+// @Failure		403			{object}	ErrorResponse			"パッサーの受取人ではありません"
+// @Failure		500			{object}	ErrorResponse			"開示請求の作成に失敗"
+// @Router			/disclosures [post]
+func (h *DisclosuresHandler) Create(c *gin.Context) {
+	requesterID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: ""})
+		return
+	}
+	var req DisclosureCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
 		return
 	}
 
-	// メールアドレスを取得
-	passerEmail := clerkUser.EmailAddresses[0].EmailAddress
-	fmt.Println(passerEmail)
-
-	// ユーザー名を取得
-	passerName := ""
-	if clerkUser.FirstName != nil {
-		passerName = *clerkUser.FirstName
+	params, err := reqToCreateDisclosureParams(requesterID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
+		return
 	}
-	if passerName == "" {
-		passerName = passerEmail
+	// 開示請求を出せるのはパッサーの受取人だけ
+	if !authorizeReceiver(c, h.queries, requesterID, params.PasserID) {
+		return
 	}
 
-	// 検証トークンを生成（トークンにdisclosure_idを含める）
-	token, err := h.tokenManager.GenerateToken(passerID, clerkUserID, passerEmail)
+	disclosure, err := h.disclosures.Request(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求の作成に失敗しました", Details: err.Error()})
+		return
+	}
+
+	res, err := disclosureToResponse(disclosure)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示請求のレスポンス変換に失敗しました", Details: err.Error()})
 		return
 	}
 
-	// 生存確認リンクを作成
-	verificationURL := fmt.Sprintf(h.verificationURLFmt, token, disclosure.ID)
+	// 催促の計画の最初の段階として、パッサーに生存確認を送る。以降の段階はスケジューラーが送る
+	if err := h.reminders.Remind(c.Request.Context(), disclosure); err != nil {
+		log.Printf("failed to send alive check for disclosure %d: %v", disclosure.ID, err)
+	}
 
-	// 生存確認メールを送信
-	_ = h.mailSender.SendVerificationEmail(
-		passerEmail,
-		passerName,
-		verificationURL,
-		int(h.tokenManager.ExpiresIn().Hours()),
-	)
+	c.JSON(http.StatusOK, res)
 }
 
 type DisclosureUpdateRequest struct {
-	ID          int32      `json:"id"`
-	PasserID    string     `json:"passerID"`
-	RequesterID string     `json:"requesterID"`
-	InProgress  bool       `json:"inProgress"`
-	Disclosed   bool       `json:"disclosed"`
-	PreventedBy *uuid.UUID `json:"preventedBy"`
-	DeadLine    string     `json:"deadLine"`
-	CustomData  []byte     `json:"customData"`
+	ID int32 `json:"id" validate:"required"`
+	// 変える先の状態。請求者は cancelled にだけ変えられる。省略すると状態は変えない
+	Status string `json:"status"`
+	// 省略すると期限は変えない
+	DeadLine   string                 `json:"deadLine"`
+	CustomData map[string]interface{} `json:"customData"`
 }
 
 // @Summary		開示申請更新
-// @Description	開示申請のステータスを更新する
+// @Description	請求者が自分の開示申請を取り下げるか、まだ処理されていない開示申請の期限と付加情報を変える。状態はサービス層が許す変更だけができる
 // @Tags			disclosures
 // @Accept			json
 // @Produce		json
 // @Param			disclosure	body		DisclosureUpdateRequest	true	"開示申請情報"
 // @Success		200			{object}	DisclosureResponse		"成功"
-// @Failure		400			{object}	ErrorResponse			"リクエストが不正"
+// @Failure		400			{object}	ErrorResponse			"リクエストが不正、または期限を早めようとした"
+// @Failure		404			{object}	ErrorResponse			"開示申請が見つかりませんでした"
+// @Failure		409			{object}	ErrorResponse			"開示申請の状態は変えられません"
 // @Failure		500			{object}	ErrorResponse			"開示申請の更新に失敗"
 // @Router			/disclosures [put]
 func (h *DisclosuresHandler) Update(c *gin.Context) {
+	requesterID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
 	var req DisclosureUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
 		return
 	}
 
-	params, err := reqToUpdateDisclosureParams(req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
+	disclosure, err := h.queries.GetDisclosure(c, req.ID)
+	if !authorizeOwner(c, requesterID, disclosure.RequesterID, err) {
 		return
 	}
 
-	disclosure, err := h.queries.GetDisclosure(c, params.ID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "開示申請が見つかりませんでした", Details: err.Error()})
-		return
+	if req.DeadLine != "" || req.CustomData != nil {
+		deadline, customData, err := reqToDisclosureDetails(disclosure, req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
+			return
+		}
+		disclosure, err = h.disclosures.UpdateDetails(c.Request.Context(), disclosure, deadline, customData)
+		if errors.Is(err, service.ErrDeadlineShortened) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "期限を早めることはできません", Details: ""})
+			return
+		}
+		if errors.Is(err, service.ErrDisclosureNotPending) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "開示申請はすでに処理されています", Details: ""})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示申請の更新に失敗しました", Details: err.Error()})
+			return
+		}
 	}
 
-	disclosure, err = h.queries.UpdateDisclosure(c, params)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示申請の更新に失敗しました", Details: err.Error()})
-		return
+	if req.Status != "" && req.Status != disclosure.Status {
+		disclosure, err = h.disclosures.Transition(c.Request.Context(), disclosure, req.Status, service.DisclosureActorRequester, requesterID)
+		if errors.Is(err, service.ErrIllegalTransition) || errors.Is(err, service.ErrDisclosureStatusChanged) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "開示申請の状態は変えられません", Details: err.Error()})
+			return
+		}
+		if err != nil && disclosure.Status != req.Status {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示申請の更新に失敗しました", Details: err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("disclosure %d was changed to %s but: %v", disclosure.ID, disclosure.Status, err)
+		}
 	}
 
 	res, err := disclosureToResponse(disclosure)
@@ -284,50 +349,70 @@ func (h *DisclosuresHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
-type DisclosureDeleteRequest struct {
-	ID          int32  `json:"id"`
-	RequesterID string `json:"requesterID"`
+type DisclosureTransitionResponse struct {
+	FromStatus *string `json:"fromStatus"`
+	ToStatus   string  `json:"toStatus" validate:"required"`
+	// requester, passer, system のいずれか
+	Actor       string  `json:"actor" validate:"required"`
+	ActorUserID *string `json:"actorUserID"`
+	CreatedAt   string  `json:"createdAt" validate:"required"`
 }
 
-// @Summary		開示申請削除
-// @Description	開示申請を削除する
+// @Summary		開示申請の状態の履歴取得
+// @Description	開示申請の状態がいつ、誰によって変わったかを古い順に取得する。請求者とパッサーだけが取得できる
 // @Tags			disclosures
 // @Accept			json
 // @Produce		json
-// @Param			disclosure	body		DisclosureDeleteRequest	true	"開示申請情報"
-// @Success		200			{object}	DisclosureResponse		"成功"
-// @Failure		400			{object}	ErrorResponse			"リクエストが不正"
-// @Failure		500			{object}	ErrorResponse			"開示申請の削除に失敗"
-// @Router			/disclosures [delete]
-func (h *DisclosuresHandler) Delete(c *gin.Context) {
-	var req DisclosureDeleteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
+// @Param			id	query		int								true	"開示申請ID"
+// @Success		200	{array}		DisclosureTransitionResponse	"成功"
+// @Failure		400	{object}	ErrorResponse					"リクエストが不正"
+// @Failure		404	{object}	ErrorResponse					"開示申請が見つかりませんでした"
+// @Failure		500	{object}	ErrorResponse					"履歴の取得に失敗しました"
+// @Router			/disclosures/transitions [get]
+func (h *DisclosuresHandler) ListTransitions(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
 		return
 	}
-
-	params, err := reqToDeleteDisclosureParams(req)
+	id, err := strconv.ParseInt(c.Query("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "パラメータが不正です", Details: err.Error()})
 		return
 	}
 
-	disclosure, err := h.queries.DeleteDisclosure(c, query.DeleteDisclosureParams{
-		ID:          params.ID,
-		RequesterID: params.RequesterID,
-	})
+	disclosure, err := h.queries.GetDisclosure(c, int32(id))
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && disclosure.RequesterID != userID && disclosure.PasserID != userID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "開示申請が見つかりませんでした", Details: ""})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete disclosure"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "開示申請の取得に失敗しました", Details: err.Error()})
 		return
 	}
 
-	res, err := disclosureToResponse(disclosure)
+	transitions, err := h.queries.ListDisclosureTransitionsByDisclosureId(c, disclosure.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to convert disclosure to response"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "履歴の取得に失敗しました", Details: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, res)
+	response := make([]DisclosureTransitionResponse, len(transitions))
+	for i, t := range transitions {
+		response[i] = DisclosureTransitionResponse{
+			ToStatus:  t.ToStatus,
+			Actor:     t.Actor,
+			CreatedAt: t.CreatedAt.Time.Format(time.RFC3339),
+		}
+		if t.FromStatus.Valid {
+			response[i].FromStatus = &t.FromStatus.String
+		}
+		if t.ActorUserID.Valid {
+			actorUserID := t.ActorUserID.String()
+			response[i].ActorUserID = &actorUserID
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func reqToCreateDisclosureParams(requesterID pgtype.UUID, req DisclosureCreateRequest) (query.CreateDisclosureParams, error) {
@@ -358,61 +443,38 @@ func reqToCreateDisclosureParams(requesterID pgtype.UUID, req DisclosureCreateRe
 	}, nil
 }
 
-func reqToUpdateDisclosureParams(req DisclosureUpdateRequest) (query.UpdateDisclosureParams, error) {
-
-	var params query.UpdateDisclosureParams
-
-	params.ID = req.ID
-
-	requesterID, err := toPGUUID(req.RequesterID)
-	if err != nil {
-		return query.UpdateDisclosureParams{}, err
-	}
-	params.RequesterID = requesterID
-
-	if req.PreventedBy == nil {
-		params.PreventedBy = pgtype.UUID{Valid: false}
-	} else {
-		preventedBy, err := toPGUUID(req.PreventedBy.String())
+// reqToDisclosureDetails は更新リクエストから期限と付加情報を作ります。省略されたものは今の値のままにする
+func reqToDisclosureDetails(disclosure query.Disclosure, req DisclosureUpdateRequest) (pgtype.Timestamp, []byte, error) {
+	deadline := disclosure.Deadline
+	if req.DeadLine != "" {
+		t, err := time.Parse(time.RFC3339, req.DeadLine)
 		if err != nil {
-			return query.UpdateDisclosureParams{}, err
+			return pgtype.Timestamp{}, nil, err
 		}
-		params.PreventedBy = preventedBy
+		deadline = pgtype.Timestamp{Time: t, Valid: true}
 	}
 
-	if req.DeadLine == "" {
-		params.Deadline = pgtype.Timestamp{Valid: false}
-	} else {
-		t, err := time.Parse(time.RFC3339, req.DeadLine)
+	customData := disclosure.CustomData
+	if req.CustomData != nil {
+		var err error
+		customData, err = json.Marshal(req.CustomData)
 		if err != nil {
-			return query.UpdateDisclosureParams{}, err
+			return pgtype.Timestamp{}, nil, fmt.Errorf("failed to marshal custom data: %w", err)
 		}
-		params.Deadline = pgtype.Timestamp{Time: t, Valid: true}
 	}
-
-	passerID, err := toPGUUID(req.PasserID)
-	if err != nil {
-		return query.UpdateDisclosureParams{}, err
-	}
-	params.PasserID = passerID
-
-	if len(req.CustomData) == 0 || string(req.CustomData) == "null" || bytes.Equal(req.CustomData, []byte("\x00")) {
-		req.CustomData = []byte("{}")
-	}
-
-	return params, nil
+	return deadline, customData, nil
 }
 
-func reqToDeleteDisclosureParams(req DisclosureDeleteRequest) (query.DeleteDisclosureParams, error) {
-	requesterID, err := toPGUUID(req.RequesterID)
-	if err != nil {
-		return query.DeleteDisclosureParams{}, err
+func disclosuresToResponse(disclosures []query.Disclosure) ([]DisclosureResponse, error) {
+	response := make([]DisclosureResponse, len(disclosures))
+	for i, d := range disclosures {
+		res, err := disclosureToResponse(d)
+		if err != nil {
+			return nil, err
+		}
+		response[i] = res
 	}
-
-	return query.DeleteDisclosureParams{
-		ID:          req.ID,
-		RequesterID: requesterID,
-	}, nil
+	return response, nil
 }
 
 func disclosureToResponse(d query.Disclosure) (DisclosureResponse, error) {
@@ -427,8 +489,10 @@ func disclosureToResponse(d query.Disclosure) (DisclosureResponse, error) {
 	response.RequesterID = d.RequesterID.String()
 	response.PasserID = d.PasserID.String()
 	response.IssuedTime = d.IssuedTime.Time.Format(time.RFC3339)
-	response.InProgress = d.InProgress
-	response.Disclosed = d.Disclosed
+	response.Status = d.Status
+	response.StatusChangedAt = d.StatusChangedAt.Time.Format(time.RFC3339)
+	response.InProgress = service.IsPending(d)
+	response.Disclosed = d.Status == service.DisclosureStatusDisclosed
 
 	if d.PreventedBy.Valid {
 		u, err := uuid.FromBytes(d.PreventedBy.Bytes[:])
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// InboxHandler は受取人として開示されたものをパッサーごとにまとめて返す
+type InboxHandler struct {
+	queries      *query.Queries
+	cryptoClient crypto.EncryptionServiceClient
+	grants       *service.DecryptGrantService
+}
+
+func NewInboxHandler(q *query.Queries, cryptoClient crypto.EncryptionServiceClient, grants *service.DecryptGrantService) *InboxHandler {
+	return &InboxHandler{queries: q, cryptoClient: cryptoClient, grants: grants}
+}
+
+// InboxPasserResponse は1人のパッサーから受け取ったもの。
+// 各項目の message はパッサーからの伝言、plsDelete はパッサーが受取人に削除を頼んでいるかを表す
+type InboxPasserResponse struct {
+	PasserID string `json:"passerID" validate:"required"`
+	// 受取人の開示請求が開示された日時。開示請求が見つからなければ空
+	DisclosedAt   string                 `json:"disclosedAt"`
+	Accounts      []AccountResponse      `json:"accounts" validate:"required"`
+	Devices       []DeviceResponse       `json:"devices" validate:"required"`
+	Subscriptions []SubscriptionResponse `json:"subscriptions" validate:"required"`
+}
+
+// List 受信箱の取得
+// @Summary 受信箱の取得
+// @Description 受取人として開示されたアカウント、デバイス、サブスクリプションをパッサーごとにまとめて取得する。パスワードは受取人自身の鍵で復号する
+// @Tags inbox
+// @Accept json
+// @Produce json
+// @Success 200 {array} InboxPasserResponse "成功"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
+// @Router /inbox [get]
+func (h *InboxHandler) List(c *gin.Context) {
+	receiverUUID, exists := middleware.GetUserIdUUID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{"認証に失敗しました", "ユーザーIDが見つかりません"})
+		return
+	}
+
+	accounts, err := h.queries.ListDisclosedAccountsByReceiverId(c, receiverUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"開示されたアカウント一覧取得に失敗しました", err.Error()})
+		return
+	}
+	devices, err := h.queries.ListDisclosedDevicesByReceiverId(c, receiverUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"開示されたデバイス一覧取得に失敗しました", err.Error()})
+		return
+	}
+	subscriptions, err := h.queries.ListDisclosedSubscriptionsByReceiverId(c, receiverUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"開示されたサブスクリプション一覧取得に失敗しました", err.Error()})
+		return
+	}
+	disclosures, err := h.queries.ListDisclosuresByRequesterId(c, receiverUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"開示請求一覧取得に失敗しました", err.Error()})
+		return
+	}
+
+	accountResponses, err := disclosedAccountsToResponse(c.Request.Context(), h.queries, h.cryptoClient, h.grants, receiverUUID, accounts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"開示されたパスワードの取得に失敗しました", err.Error()})
+		return
+	}
+	deviceResponses, err := disclosedDevicesToResponse(c.Request.Context(), h.cryptoClient, h.grants, receiverUUID, devices)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"開示されたパスワードの取得に失敗しました", err.Error()})
+		return
+	}
+	subscriptionResponses, err := disclosedSubscriptionsToResponse(c.Request.Context(), h.cryptoClient, h.grants, receiverUUID, subscriptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"開示されたパスワードの取得に失敗しました", err.Error()})
+		return
+	}
+
+	// パッサーごとにまとめる
+	inbox := newInbox(disclosures)
+	for _, account := range accountResponses {
+		group := inbox.passer(account.PasserID)
+		group.Accounts = append(group.Accounts, account)
+	}
+	for _, device := range deviceResponses {
+		group := inbox.passer(device.PasserID)
+		group.Devices = append(group.Devices, device)
+	}
+	for _, subscription := range subscriptionResponses {
+		group := inbox.passer(subscription.PasserID)
+		group.Subscriptions = append(group.Subscriptions, subscription)
+	}
+
+	c.JSON(http.StatusOK, inbox.response())
+}
+
+// inbox はパッサーごとの受け取ったものを集める
+type inbox struct {
+	groups map[string]*InboxPasserResponse
+	// パッサーごとの最後に開示された日時
+	disclosedAt map[string]time.Time
+}
+
+func newInbox(disclosures []query.Disclosure) *inbox {
+	disclosedAt := map[string]time.Time{}
+	for _, d := range disclosures {
+		if d.Status != service.DisclosureStatusDisclosed || !d.DisclosedAt.Valid {
+			continue
+		}
+		passerID := d.PasserID.String()
+		if d.DisclosedAt.Time.After(disclosedAt[passerID]) {
+			disclosedAt[passerID] = d.DisclosedAt.Time
+		}
+	}
+	return &inbox{groups: map[string]*InboxPasserResponse{}, disclosedAt: disclosedAt}
+}
+
+// passer は passerID のまとまりを返す。なければ作る
+func (b *inbox) passer(passerID string) *InboxPasserResponse {
+	group, ok := b.groups[passerID]
+	if !ok {
+		group = &InboxPasserResponse{
+			PasserID:      passerID,
+			Accounts:      []AccountResponse{},
+			Devices:       []DeviceResponse{},
+			Subscriptions: []SubscriptionResponse{},
+		}
+		if t, ok := b.disclosedAt[passerID]; ok {
+			group.DisclosedAt = t.Format(time.RFC3339)
+		}
+		b.groups[passerID] = group
+	}
+	return group
+}
+
+// response はパッサーIDの順に並べたレスポンスを返す
+func (b *inbox) response() []InboxPasserResponse {
+	response := make([]InboxPasserResponse, 0, len(b.groups))
+	for _, group := range b.groups {
+		response = append(response, *group)
+	}
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].PasserID < response[j].PasserID
+	})
+	return response
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/jobs"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type KeysHandler struct {
+	queries  *query.Queries
+	rotation *jobs.KeyRotationRunner
+}
+
+func NewKeysHandler(q *query.Queries, rotation *jobs.KeyRotationRunner) *KeysHandler {
+	return &KeysHandler{queries: q, rotation: rotation}
+}
+
+type KeyRotationJobResponse struct {
+	ID             int32   `json:"id" validate:"required"`
+	KeyVersion     int32   `json:"keyVersion" validate:"required"`
+	Status         string  `json:"status" validate:"required"`
+	Phase          string  `json:"phase" validate:"required"`
+	TotalItems     int32   `json:"totalItems" validate:"required"`
+	ProcessedItems int32   `json:"processedItems" validate:"required"`
+	FailedItems    int32   `json:"failedItems" validate:"required"`
+	LastError      string  `json:"lastError"`
+	CreatedAt      string  `json:"createdAt" validate:"required"`
+	UpdatedAt      string  `json:"updatedAt" validate:"required"`
+	CompletedAt    *string `json:"completedAt"`
+}
+
+// Rotate 暗号鍵のローテーション
+// @Summary		暗号鍵のローテーション
+// @Description	ユーザの暗号鍵を新しいバージョンに切り替え、保存済みのパスワードをバックグラウンドで再暗号化する
+// @Tags			keys
+// @Accept			json
+// @Produce		json
+// @Success		202	{object}	KeyRotationJobResponse	"ジョブを開始しました"
+// @Failure		400	{object}	ErrorResponse			"ユーザー認証に失敗しました"
+// @Failure		409	{object}	KeyRotationJobResponse	"ローテーションが実行中です"
+// @Failure		500	{object}	ErrorResponse			"鍵のローテーションに失敗しました"
+// @Router			/keys/rotate [post]
+func (h *KeysHandler) Rotate(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	job, err := h.rotation.Start(c.Request.Context(), userID)
+	if errors.Is(err, jobs.ErrKeyRotationRunning) {
+		c.JSON(http.StatusConflict, keyRotationJobToResponse(job))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "鍵のローテーションに失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, keyRotationJobToResponse(job))
+}
+
+// RotationStatus 暗号鍵ローテーションの進捗取得
+// @Summary		暗号鍵ローテーションの進捗取得
+// @Description	最新の再暗号化ジョブの進捗を取得する
+// @Tags			keys
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	KeyRotationJobResponse	"成功"
+// @Failure		400	{object}	ErrorResponse			"ユーザー認証に失敗しました"
+// @Failure		404	{object}	ErrorResponse			"ローテーションの履歴がありません"
+// @Failure		500	{object}	ErrorResponse			"進捗の取得に失敗しました"
+// @Router			/keys/rotation [get]
+func (h *KeysHandler) RotationStatus(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	job, err := h.queries.GetLatestKeyRotationJobByUserId(c, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "ローテーションの履歴がありません", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "進捗の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keyRotationJobToResponse(job))
+}
+
+func keyRotationJobToResponse(job query.KeyRotationJob) KeyRotationJobResponse {
+	response := KeyRotationJobResponse{
+		ID:             job.ID,
+		KeyVersion:     job.KeyVersion,
+		Status:         job.Status,
+		Phase:          job.Phase,
+		TotalItems:     job.TotalItems,
+		ProcessedItems: job.ProcessedItems,
+		FailedItems:    job.FailedItems,
+		LastError:      job.LastError.String,
+		CreatedAt:      job.CreatedAt.Time.Format(time.RFC3339),
+		UpdatedAt:      job.UpdatedAt.Time.Format(time.RFC3339),
+	}
+	if job.CompletedAt.Valid {
+		completedAt := job.CompletedAt.Time.Format(time.RFC3339)
+		response.CompletedAt = &completedAt
+	}
+	return response
+}
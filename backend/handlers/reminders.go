@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 開示請求の猶予期間中に送る生存確認の催促の設定
+type RemindersHandler struct {
+	queries   *query.Queries
+	reminders *service.ReminderService
+}
+
+func NewRemindersHandler(q *query.Queries, reminders *service.ReminderService) *RemindersHandler {
+	return &RemindersHandler{queries: q, reminders: reminders}
+}
+
+type ReminderPlanResponse struct {
+	// 開示請求を受けてから何時間後に催促するか
+	OffsetsHours []int32 `json:"offsetsHours" validate:"required"`
+	// 設定していないため既定の計画を使っている
+	IsDefault bool `json:"isDefault" validate:"required"`
+}
+
+type ReminderPlanUpdateRequest struct {
+	// 開示請求を受けてから何時間後に催促するか。昇順に並べる
+	OffsetsHours []int32 `json:"offsetsHours" validate:"required"`
+}
+
+type NotificationChannelRequest struct {
+	Channel string `json:"channel" validate:"required" enums:"email,line,webhook"`
+	// メールアドレス、LINE のユーザーID、Webhook の URL
+	Address string `json:"address" validate:"required"`
+}
+
+type NotificationChannelDeleteRequest struct {
+	Channel string `json:"channel" validate:"required" enums:"email,line,webhook"`
+}
+
+type NotificationChannelResponse struct {
+	Channel   string `json:"channel" validate:"required"`
+	Address   string `json:"address" validate:"required"`
+	UpdatedAt string `json:"updatedAt" validate:"required"`
+}
+
+// GetPlan 催促の計画の取得
+// @Summary		催促の計画の取得
+// @Description	開示請求を受けてから生存確認を催促する時間を取得する。設定していなければ既定の計画を返す
+// @Tags			reminders
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	ReminderPlanResponse	"成功"
+// @Failure		400	{object}	ErrorResponse			"ユーザー認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse			"催促の計画の取得に失敗しました"
+// @Router			/reminders/plan [get]
+func (h *RemindersHandler) GetPlan(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	plan, isDefault, err := h.reminders.Plan(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "催促の計画の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReminderPlanResponse{OffsetsHours: plan, IsDefault: isDefault})
+}
+
+// UpdatePlan 催促の計画の設定
+// @Summary		催促の計画の設定
+// @Description	開示請求を受けてから生存確認を催促する時間を設定する。段階が進むごとに、メール、LINE、Webhook の順に送る経路を広げる
+// @Tags			reminders
+// @Accept			json
+// @Produce		json
+// @Param			plan	body		ReminderPlanUpdateRequest	true	"催促の計画"
+// @Success		200		{object}	ReminderPlanResponse		"成功"
+// @Failure		400		{object}	ErrorResponse				"リクエストデータが不正です"
+// @Failure		500		{object}	ErrorResponse				"催促の計画の設定に失敗しました"
+// @Router			/reminders/plan [put]
+func (h *RemindersHandler) UpdatePlan(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	var req ReminderPlanUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+	if err := service.ValidateReminderPlan(req.OffsetsHours); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+
+	plan, err := h.queries.UpsertReminderPlan(c, query.UpsertReminderPlanParams{
+		UserID:       userID,
+		OffsetsHours: req.OffsetsHours,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "催促の計画の設定に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReminderPlanResponse{OffsetsHours: plan.OffsetsHours, IsDefault: false})
+}
+
+// DeletePlan 催促の計画を既定に戻す
+// @Summary		催促の計画を既定に戻す
+// @Description	設定した催促の計画を削除し、既定の計画を使うようにする
+// @Tags			reminders
+// @Accept			json
+// @Produce		json
+// @Success		200	{object}	ReminderPlanResponse	"成功"
+// @Failure		400	{object}	ErrorResponse			"ユーザー認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse			"催促の計画の削除に失敗しました"
+// @Router			/reminders/plan [delete]
+func (h *RemindersHandler) DeletePlan(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	if _, err := h.queries.DeleteReminderPlan(c, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "催促の計画の削除に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReminderPlanResponse{OffsetsHours: service.DefaultReminderPlan, IsDefault: true})
+}
+
+// ListChannels 生存確認の経路一覧
+// @Summary		生存確認の経路一覧
+// @Description	生存確認の催促を受け取る経路の一覧を取得する。メールを設定していなければ登録済みのメールアドレスに送る
+// @Tags			reminders
+// @Accept			json
+// @Produce		json
+// @Success		200	{array}		NotificationChannelResponse	"成功"
+// @Failure		400	{object}	ErrorResponse				"ユーザー認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse				"経路の取得に失敗しました"
+// @Router			/notification-channels [get]
+func (h *RemindersHandler) ListChannels(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	channels, err := h.queries.ListNotificationChannelsByUserId(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "経路の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	response := make([]NotificationChannelResponse, len(channels))
+	for i, channel := range channels {
+		response[i] = notificationChannelToResponse(channel)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PutChannel 生存確認の経路の設定
+// @Summary		生存確認の経路の設定
+// @Description	生存確認の催促を受け取る経路を追加する。同じ経路が設定済みなら宛先を置き換える
+// @Tags			reminders
+// @Accept			json
+// @Produce		json
+// @Param			channel	body		NotificationChannelRequest	true	"経路"
+// @Success		200		{object}	NotificationChannelResponse	"成功"
+// @Failure		400		{object}	ErrorResponse				"リクエストデータが不正です"
+// @Failure		500		{object}	ErrorResponse				"経路の設定に失敗しました"
+// @Router			/notification-channels [put]
+func (h *RemindersHandler) PutChannel(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	var req NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+	if err := service.ValidateNotificationChannel(req.Channel, req.Address); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+
+	channel, err := h.queries.UpsertNotificationChannel(c, query.UpsertNotificationChannelParams{
+		UserID:  userID,
+		Channel: req.Channel,
+		Address: req.Address,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "経路の設定に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notificationChannelToResponse(channel))
+}
+
+// DeleteChannel 生存確認の経路の削除
+// @Summary		生存確認の経路の削除
+// @Description	生存確認の催促を受け取る経路を削除する
+// @Tags			reminders
+// @Accept			json
+// @Produce		json
+// @Param			channel	body		NotificationChannelDeleteRequest	true	"経路"
+// @Success		200		{object}	map[string]string					"成功"
+// @Failure		400		{object}	ErrorResponse						"リクエストデータが不正です"
+// @Failure		404		{object}	ErrorResponse						"経路が設定されていません"
+// @Failure		500		{object}	ErrorResponse						"経路の削除に失敗しました"
+// @Router			/notification-channels [delete]
+func (h *RemindersHandler) DeleteChannel(c *gin.Context) {
+	userID, ok := middleware.GetUserIdUUID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ユーザー認証に失敗しました", Details: ""})
+		return
+	}
+
+	var req NotificationChannelDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+
+	deleted, err := h.queries.DeleteNotificationChannel(c, query.DeleteNotificationChannelParams{
+		UserID:  userID,
+		Channel: req.Channel,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "経路の削除に失敗しました", Details: err.Error()})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "経路が設定されていません", Details: ""})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"channel": req.Channel})
+}
+
+func notificationChannelToResponse(channel query.NotificationChannel) NotificationChannelResponse {
+	return NotificationChannelResponse{
+		Channel:   channel.Channel,
+		Address:   channel.Address,
+		UpdatedAt: channel.UpdatedAt.Time.Format(time.RFC3339),
+	}
+}
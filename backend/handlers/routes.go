@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/jobs"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+// Route は1つのエンドポイントと、そこで誰が何を扱えるか
+type Route struct {
+	Method  string
+	Path    string
+	Policy  Policy
+	Handler gin.HandlerFunc
+}
+
+// Dependencies はハンドラーが使うもの
+type Dependencies struct {
+	Queries       *query.Queries
+	CryptoClient  crypto.EncryptionServiceClient
+	Disclosures   *service.DisclosureService
+	DecryptGrants *service.DecryptGrantService
+	Reminders     *service.ReminderService
+	AliveChecks   *service.AliveCheckService
+	Invitations   *service.TrustInvitationService
+	KeyRotation   *jobs.KeyRotationRunner
+}
+
+// Routes はすべてのエンドポイントを返します。
+// ルートを増やすときはここに Policy と一緒に加え、他のユーザーのものを扱えないことを routes_test.go で確かめる
+func Routes(d Dependencies) []Route {
+	q := d.Queries
+
+	usersHandler := NewUsersHandler(q, d.Invitations)
+	receiversHandler := NewReceiversHandler(q)
+	accountsHandler := NewAccountsHandler(q, d.CryptoClient, d.Disclosures, d.DecryptGrants)
+	devicesHandler := NewDevicesHandler(q, d.CryptoClient, d.Disclosures, d.DecryptGrants)
+	inboxHandler := NewInboxHandler(q, d.CryptoClient, d.DecryptGrants)
+	trustsHandler := NewTrustsHandler(q, d.Disclosures)
+	trustInvitationsHandler := NewTrustInvitationsHandler(q, d.Invitations)
+	disclosuresHandler := NewDisclosuresHandler(q, d.Reminders, d.Disclosures)
+	remindersHandler := NewRemindersHandler(q, d.Reminders)
+	disclosurePoliciesHandler := NewDisclosurePoliciesHandler(q, d.Disclosures)
+	verificationHandler := NewVerificationHandler(q, d.AliveChecks, d.Disclosures)
+	aliveCheckSchedulesHandler := NewAliveCheckSchedulesHandler(q, d.AliveChecks)
+	subscriptionsHandler := NewSubscriptionsHandler(q, d.CryptoClient, d.Disclosures, d.DecryptGrants)
+	keysHandler := NewKeysHandler(q, d.KeyRotation)
+	clientKeysHandler := NewClientKeysHandler(q, d.CryptoClient)
+	auditEventsHandler := NewAuditEventsHandler(d.CryptoClient)
+	aliveChecksHandler := NewAliveChecksHandler(q)
+	chromeHandler := NewChromeHandler(q)
+
+	return []Route{
+		// users
+		// clerkに認証の責務を負わせるため、本当に登録するためだけのエンドポイント。
+		{http.MethodPost, "/api/users", PolicyPublic, usersHandler.Create},
+		{http.MethodGet, "/api/users", PolicySelf, usersHandler.GetByClerkID},
+		{http.MethodPut, "/api/users", PolicySelf, usersHandler.Update},
+
+		// receivers
+		{http.MethodGet, "/api/receivers", PolicySelf, receiversHandler.List},
+
+		// accounts
+		{http.MethodGet, "/api/accounts", PolicySelf, accountsHandler.List},
+		{http.MethodGet, "/api/accounts/disclosed", PolicySelf, accountsHandler.ListDisclosed},
+		// 割り当てる相続関係の持ち主だけが作れる
+		{http.MethodPost, "/api/accounts", PolicyOwner, accountsHandler.Create},
+		{http.MethodPut, "/api/accounts", PolicyOwner, accountsHandler.Update},
+		{http.MethodDelete, "/api/accounts", PolicyOwner, accountsHandler.Delete},
+		// 受取人ごとの割り当て
+		{http.MethodPut, "/api/accounts/assignments", PolicyOwner, accountsHandler.UpdateAssignments},
+		{http.MethodGet, "/api/accounts/templates", PolicySelf, accountsHandler.ListTemplate},
+
+		// devices
+		{http.MethodGet, "/api/devices", PolicySelf, devicesHandler.List},
+		{http.MethodPost, "/api/devices", PolicySelf, devicesHandler.Create},
+		{http.MethodPut, "/api/devices", PolicyOwner, devicesHandler.Update},
+		{http.MethodDelete, "/api/devices", PolicyOwner, devicesHandler.Delete},
+		{http.MethodPut, "/api/devices/assignments", PolicyOwner, devicesHandler.UpdateAssignments},
+
+		// inbox（受取人として開示されたもの）
+		{http.MethodGet, "/api/inbox", PolicySelf, inboxHandler.List},
+
+		// trusts(相続の関係性）
+		{http.MethodGet, "/api/trusts", PolicySelf, trustsHandler.List},
+		{http.MethodPost, "/api/trusts", PolicySelf, trustsHandler.Create},
+		{http.MethodPut, "/api/trusts", PolicyOwner, trustsHandler.Update},
+		{http.MethodDelete, "/api/trusts", PolicyOwner, trustsHandler.Delete},
+
+		// 受取人への招待。アカウントのない人もメールアドレスで招待できる
+		{http.MethodGet, "/api/trust-invitations", PolicySelf, trustInvitationsHandler.List},
+		{http.MethodPost, "/api/trust-invitations", PolicySelf, trustInvitationsHandler.Create},
+		{http.MethodDelete, "/api/trust-invitations", PolicyOwner, trustInvitationsHandler.Delete},
+		{http.MethodGet, "/api/trust-invitations/incoming", PolicySelf, trustInvitationsHandler.ListIncoming},
+		// 受取人と結びついた招待は id で受取人だけが、メールのリンクからは token を持つ人が返事できる
+		{http.MethodPost, "/api/trust-invitations/accept", PolicyOwner, trustInvitationsHandler.Accept},
+		{http.MethodPost, "/api/trust-invitations/decline", PolicyOwner, trustInvitationsHandler.Decline},
+
+		// disclosures
+		{http.MethodGet, "/api/disclosures", PolicySelf, disclosuresHandler.List},
+		// 請求できるのはパッサーの受取人だけ
+		{http.MethodPost, "/api/disclosures", PolicyReceiver, disclosuresHandler.Create},
+		// 請求者による期限の延長と取り下げ (cancelled への変更)
+		{http.MethodPut, "/api/disclosures", PolicyOwner, disclosuresHandler.Update},
+		// パッサーとして受けた開示請求の確認と却下
+		{http.MethodGet, "/api/disclosures/incoming", PolicySelf, disclosuresHandler.ListIncoming},
+		// 請求者とパッサーのどちらも見られる
+		{http.MethodGet, "/api/disclosures/transitions", PolicyOwner, disclosuresHandler.ListTransitions},
+		{http.MethodPost, "/api/disclosures/reject", PolicyOwner, disclosuresHandler.Reject},
+		{http.MethodPost, "/api/disclosures/reject-all", PolicySelf, disclosuresHandler.RejectAll},
+
+		// 生存確認の催促の計画と受け取る経路
+		{http.MethodGet, "/api/reminders/plan", PolicySelf, remindersHandler.GetPlan},
+		{http.MethodPut, "/api/reminders/plan", PolicySelf, remindersHandler.UpdatePlan},
+		{http.MethodDelete, "/api/reminders/plan", PolicySelf, remindersHandler.DeletePlan},
+		{http.MethodGet, "/api/notification-channels", PolicySelf, remindersHandler.ListChannels},
+		{http.MethodPut, "/api/notification-channels", PolicySelf, remindersHandler.PutChannel},
+		{http.MethodDelete, "/api/notification-channels", PolicySelf, remindersHandler.DeleteChannel},
+
+		// しきい値開示
+		{http.MethodGet, "/api/disclosure-policy", PolicySelf, disclosurePoliciesHandler.Get},
+		{http.MethodPut, "/api/disclosure-policy", PolicySelf, disclosurePoliciesHandler.Update},
+		{http.MethodDelete, "/api/disclosure-policy", PolicySelf, disclosurePoliciesHandler.Delete},
+
+		// 生存確認（マジックリンク）
+		{http.MethodPost, "/api/verify/send-email", PolicySelf, verificationHandler.SendVerificationEmail},
+		// トークン検証は非認証でアクセス可能
+		{http.MethodPost, "/api/verify/token", PolicyPublic, verificationHandler.VerifyToken},
+		{http.MethodPost, "/api/verify/revoke", PolicySelf, verificationHandler.RevokeTokens},
+
+		// 定期的な生存確認
+		{http.MethodGet, "/api/alive-check-schedule", PolicySelf, aliveCheckSchedulesHandler.Get},
+		{http.MethodPut, "/api/alive-check-schedule", PolicySelf, aliveCheckSchedulesHandler.Update},
+		{http.MethodDelete, "/api/alive-check-schedule", PolicySelf, aliveCheckSchedulesHandler.Delete},
+		{http.MethodPost, "/api/alive-check-schedule/check-in", PolicySelf, aliveCheckSchedulesHandler.CheckIn},
+
+		// subscriptions
+		{http.MethodGet, "/api/subscriptions", PolicySelf, subscriptionsHandler.List},
+		{http.MethodPost, "/api/subscriptions", PolicySelf, subscriptionsHandler.Create},
+		{http.MethodPut, "/api/subscriptions", PolicyOwner, subscriptionsHandler.Update},
+		{http.MethodDelete, "/api/subscriptions", PolicyOwner, subscriptionsHandler.Delete},
+		{http.MethodPut, "/api/subscriptions/assignments", PolicyOwner, subscriptionsHandler.UpdateAssignments},
+
+		// keys（暗号鍵のローテーション）
+		{http.MethodPost, "/api/keys/rotate", PolicySelf, keysHandler.Rotate},
+		{http.MethodGet, "/api/keys/rotation", PolicySelf, keysHandler.RotationStatus},
+
+		// ゼロ知識モードのクライアント鍵と復旧用の鍵
+		{http.MethodPut, "/api/keys/client", PolicySelf, clientKeysHandler.RegisterPublicKey},
+		{http.MethodGet, "/api/keys/client", PolicySelf, clientKeysHandler.GetPublicKey},
+		{http.MethodGet, "/api/keys/client/receivers", PolicySelf, clientKeysHandler.ListReceiverPublicKeys},
+		{http.MethodPut, "/api/keys/recovery", PolicySelf, clientKeysHandler.EscrowRecoveryWrap},
+		{http.MethodGet, "/api/keys/recovery", PolicySelf, clientKeysHandler.GetRecoveryWrap},
+
+		// 暗号操作の監査ログ
+		{http.MethodGet, "/api/audit-events", PolicySelf, auditEventsHandler.List},
+
+		// alive check
+		{http.MethodGet, "/api/alive-checks", PolicySelf, aliveChecksHandler.List},
+		{http.MethodPost, "/api/alive-checks", PolicySelf, aliveChecksHandler.Create},
+		{http.MethodPut, "/api/alive-checks", PolicyOwner, aliveChecksHandler.Update},
+
+		// Chrome 拡張機能
+		{http.MethodGet, "/chrome/id", PolicyPublic, chromeHandler.HandleGetID},
+		{http.MethodGet, "/chrome/list", PolicySelf, chromeHandler.HandleGetAccessibleUsers},
+		{http.MethodPost, "/chrome/register", PolicySelf, chromeHandler.HandleCreate},
+		// 受取人はパッサーのパスキーで署名できる
+		{http.MethodPost, "/chrome/assert", PolicyReceiver, chromeHandler.HandleGetAssertion},
+	}
+}
+
+// RegisterRoutes は routes を router に登録します。PolicyPublic のルートには public を、それ以外には auth を先に通す
+func RegisterRoutes(router gin.IRoutes, routes []Route, public gin.HandlerFunc, auth ...gin.HandlerFunc) {
+	for _, route := range routes {
+		handlers := []gin.HandlerFunc{public, route.Handler}
+		if route.Policy != PolicyPublic {
+			handlers = append(append([]gin.HandlerFunc{}, auth...), route.Handler)
+		}
+		router.Handle(route.Method, route.Path, handlers...)
+	}
+}
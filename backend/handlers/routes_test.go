@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/jobs"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/pkg/mail"
+	"github.com/a-company-jp/digi-baton/backend/pkg/verification"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// attacker はリクエストを送る認証済みのユーザー
+	attacker = testUUID(0xa)
+	// victim はリクエストの中で指定される、攻撃されるユーザー
+	victim = testUUID(0xb)
+	// owner は DB にあるリソースの持ち主。attacker でも victim でもない
+	owner = testUUID(0xc)
+	// receiver は受取人として正当に指定できるユーザー
+	receiver = testUUID(0xd)
+)
+
+func testUUID(b byte) pgtype.UUID {
+	id := pgtype.UUID{Valid: true}
+	for i := range id.Bytes {
+		id.Bytes[i] = b
+	}
+	id.Bytes[6] = 0x40
+	id.Bytes[8] = 0x80
+	return id
+}
+
+// statement は DB に送られた1つのクエリ
+type statement struct {
+	sql  string
+	args []interface{}
+}
+
+// mutates は行を書き換えるクエリかを返す
+func (s statement) mutates() bool {
+	sql := strings.ToUpper(strings.ReplaceAll(s.sql, "FOR UPDATE", ""))
+	return strings.Contains(sql, "INSERT ") || strings.Contains(sql, "UPDATE ") || strings.Contains(sql, "DELETE ")
+}
+
+// mentions は引数に id が含まれるかを返す
+func (s statement) mentions(id pgtype.UUID) bool {
+	for _, arg := range s.args {
+		if mentions(arg, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func mentions(v interface{}, id pgtype.UUID) bool {
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	return strings.Contains(fmt.Sprint(v), id.String())
+}
+
+// fakeDB はクエリを記録する DB。1行を返すクエリは、UUID をすべて owner にした行を返す。
+// 複数行を返すクエリは空の結果を返す
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []statement
+}
+
+func (d *fakeDB) record(sql string, args []interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, statement{sql: sql, args: args})
+}
+
+func (d *fakeDB) reset() []statement {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	statements := d.statements
+	d.statements = nil
+	return statements
+}
+
+func (d *fakeDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	d.record(sql, args)
+	return pgconn.CommandTag{}, nil
+}
+
+func (d *fakeDB) Query(_ context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	d.record(sql, args)
+	return emptyRows{}, nil
+}
+
+func (d *fakeDB) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	d.record(sql, args)
+	return ownedRow{}
+}
+
+func (d *fakeDB) Begin(context.Context) (pgx.Tx, error) {
+	return fakeTx{db: d}, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+	db *fakeDB
+}
+
+func (t fakeTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.db.Exec(ctx, sql, args...)
+}
+
+func (t fakeTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return t.db.Query(ctx, sql, args...)
+}
+
+func (t fakeTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return t.db.QueryRow(ctx, sql, args...)
+}
+
+func (fakeTx) Commit(context.Context) error   { return nil }
+func (fakeTx) Rollback(context.Context) error { return nil }
+
+type ownedRow struct{}
+
+func (ownedRow) Scan(dest ...interface{}) error {
+	for _, d := range dest {
+		if id, ok := d.(*pgtype.UUID); ok {
+			*id = owner
+		}
+	}
+	return nil
+}
+
+type emptyRows struct {
+	pgx.Rows
+}
+
+func (emptyRows) Close()                        {}
+func (emptyRows) Err() error                    { return nil }
+func (emptyRows) Next() bool                    { return false }
+func (emptyRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+
+// fakeCryptoConn は crypto サービスへの呼び出しを記録し、すべて失敗させる
+type fakeCryptoConn struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakeCryptoConn) Invoke(_ context.Context, method string, args, _ interface{}, _ ...grpc.CallOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fmt.Sprintf("%s %v", method, args))
+	return status.Error(codes.Unavailable, "crypto service is not available in tests")
+}
+
+func (f *fakeCryptoConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "streams are not used")
+}
+
+func (f *fakeCryptoConn) reset() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+// notFoundTransport は Clerk の API をすべて 404 にする
+type notFoundTransport struct{}
+
+func (notFoundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"errors":[]}`)),
+		Request:    req,
+	}, nil
+}
+
+// testRoutes はフェイクの DB と crypto サービスでルートを組み立てる
+func testRoutes(t *testing.T) ([]Route, *fakeDB, *fakeCryptoConn) {
+	t.Helper()
+	clerk.SetBackend(clerk.NewBackend(&clerk.BackendConfig{
+		HTTPClient: &http.Client{Transport: notFoundTransport{}},
+	}))
+
+	db := &fakeDB{}
+	conn := &fakeCryptoConn{}
+	q := query.New(db)
+	client := crypto.NewEncryptionServiceClient(conn)
+
+	notifications := service.NewNotificationService(q, mail.NewDummySender(), "http://localhost", "")
+	tokens := verification.NewVerificationTokenManager(q, time.Hour)
+	reminders := service.NewReminderService(q, notifications, tokens, "http://localhost/verify?token=%s")
+	grants := service.NewDecryptGrantService(q, ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	routes := Routes(Dependencies{
+		Queries:       q,
+		CryptoClient:  client,
+		Disclosures:   service.NewDisclosureService(q, client, grants),
+		DecryptGrants: grants,
+		Reminders:     reminders,
+		AliveChecks:   service.NewAliveCheckService(db, q, notifications, reminders, tokens, "http://localhost/verify?token=%s"),
+		Invitations:   service.NewTrustInvitationService(q, notifications, "http://localhost/invitations?token=%s"),
+		KeyRotation:   jobs.NewKeyRotationRunner(context.Background(), q, client),
+	})
+	return routes, db, conn
+}
+
+// authenticateAs は ClerkAuth の代わりに userID を認証したユーザーにする
+func authenticateAs(userID pgtype.UUID) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("clerkUserId", "user_"+userID.String())
+		c.Set("userId", userID.String())
+		c.Next()
+	}
+}
+
+// crossUserCase は victim のものを扱おうとするリクエスト
+type crossUserCase struct {
+	// クエリ文字列とボディには、攻撃用のフィールドに加えて指定したものを付ける
+	query string
+	body  map[string]interface{}
+}
+
+// attackFields はユーザーを指定するのに使われてきたフィールド。どのルートも無視しなければならない
+var attackFields = map[string]interface{}{
+	"userID":       victim.String(),
+	"user_id":      victim.String(),
+	"passerID":     victim.String(),
+	"requesterID":  victim.String(),
+	"targetUserID": victim.String(),
+	"clerkUserID":  "user_" + victim.String(),
+}
+
+var crossUserCases = map[string]crossUserCase{
+	"POST /api/users": {body: map[string]interface{}{"clerkUserID": "user_new"}},
+	"GET /api/users":  {},
+	"PUT /api/users":  {body: map[string]interface{}{"defaultReceiverID": receiver.String()}},
+
+	"GET /api/receivers": {},
+
+	"GET /api/accounts":             {},
+	"GET /api/accounts/disclosed":   {},
+	"POST /api/accounts":            {body: map[string]interface{}{"appName": "app", "password": "secret", "trustID": 1}},
+	"PUT /api/accounts":             {body: map[string]interface{}{"id": 1, "appName": "app", "password": "secret"}},
+	"DELETE /api/accounts":          {body: map[string]interface{}{"deviceID": 1}},
+	"PUT /api/accounts/assignments": {body: map[string]interface{}{"id": 1, "assignments": []map[string]interface{}{{"trustID": 1}}}},
+	"GET /api/accounts/templates":   {},
+
+	"GET /api/devices":             {},
+	"POST /api/devices":            {body: map[string]interface{}{"deviceType": 1, "password": "secret"}},
+	"PUT /api/devices":             {body: map[string]interface{}{"id": 1, "password": "secret"}},
+	"DELETE /api/devices":          {body: map[string]interface{}{"deviceID": 1}},
+	"PUT /api/devices/assignments": {body: map[string]interface{}{"id": 1, "assignments": []map[string]interface{}{{"trustID": 1}}}},
+
+	"GET /api/inbox": {},
+
+	"GET /api/trusts":    {},
+	"POST /api/trusts":   {body: map[string]interface{}{"reviverID": receiver.String()}},
+	"PUT /api/trusts":    {body: map[string]interface{}{"trustID": 1, "reviverID": receiver.String()}},
+	"DELETE /api/trusts": {body: map[string]interface{}{"trustID": 1}},
+
+	"GET /api/trust-invitations":          {},
+	"POST /api/trust-invitations":         {body: map[string]interface{}{"email": "heir@example.com"}},
+	"DELETE /api/trust-invitations":       {body: map[string]interface{}{"id": 1}},
+	"GET /api/trust-invitations/incoming": {},
+	"POST /api/trust-invitations/accept":  {body: map[string]interface{}{"id": 1}},
+	"POST /api/trust-invitations/decline": {body: map[string]interface{}{"id": 1}},
+
+	"GET /api/disclosures":             {},
+	"POST /api/disclosures":            {body: map[string]interface{}{"deadlineDuration": 7}},
+	"PUT /api/disclosures":             {body: map[string]interface{}{"id": 1, "status": "cancelled"}},
+	"GET /api/disclosures/incoming":    {},
+	"GET /api/disclosures/transitions": {query: "id=1"},
+	"POST /api/disclosures/reject":     {body: map[string]interface{}{"id": 1}},
+	"POST /api/disclosures/reject-all": {},
+
+	"GET /api/reminders/plan":           {},
+	"PUT /api/reminders/plan":           {body: map[string]interface{}{"offsetsHours": []int{24}}},
+	"DELETE /api/reminders/plan":        {},
+	"GET /api/notification-channels":    {},
+	"PUT /api/notification-channels":    {body: map[string]interface{}{"channel": "email", "address": "a@example.com"}},
+	"DELETE /api/notification-channels": {body: map[string]interface{}{"channel": "email"}},
+
+	"GET /api/disclosure-policy":    {},
+	"PUT /api/disclosure-policy":    {body: map[string]interface{}{"threshold": 1}},
+	"DELETE /api/disclosure-policy": {},
+
+	"POST /api/verify/send-email": {body: map[string]interface{}{"email": "a@example.com"}},
+	"POST /api/verify/token":      {body: map[string]interface{}{"token": "token"}},
+	"POST /api/verify/revoke":     {},
+
+	"GET /api/alive-check-schedule":           {},
+	"PUT /api/alive-check-schedule":           {body: map[string]interface{}{"intervalDays": 30, "graceDays": 7, "enabled": true}},
+	"DELETE /api/alive-check-schedule":        {},
+	"POST /api/alive-check-schedule/check-in": {},
+
+	"GET /api/subscriptions":             {},
+	"POST /api/subscriptions":            {body: map[string]interface{}{"serviceName": "service", "password": "secret"}},
+	"PUT /api/subscriptions":             {body: map[string]interface{}{"id": 1, "password": "secret"}},
+	"DELETE /api/subscriptions":          {body: map[string]interface{}{"subscriptionID": 1}},
+	"PUT /api/subscriptions/assignments": {body: map[string]interface{}{"id": 1, "assignments": []map[string]interface{}{{"trustID": 1}}}},
+
+	"POST /api/keys/rotate":  {},
+	"GET /api/keys/rotation": {},
+
+	"PUT /api/keys/client":           {body: map[string]interface{}{"algorithm": "x25519", "publicKey": "AAAA"}},
+	"GET /api/keys/client":           {},
+	"GET /api/keys/client/receivers": {},
+	"PUT /api/keys/recovery":         {body: map[string]interface{}{"format": "kdf", "wrap": "AAAA"}},
+	"GET /api/keys/recovery":         {},
+
+	"GET /api/audit-events": {},
+
+	"GET /api/alive-checks":  {},
+	"POST /api/alive-checks": {body: map[string]interface{}{"checkMethod": 1}},
+	"PUT /api/alive-checks":  {body: map[string]interface{}{"id": testUUID(0xe).String(), "checkSuccess": true}},
+
+	"GET /chrome/id":   {},
+	"GET /chrome/list": {},
+	// ProcessCreate は読めない req_json でプロセスを終了するので、形式の正しいものを送る
+	"POST /chrome/register": {body: map[string]interface{}{"req_json": `{"requestDetailsJson":"{\"rp\":{\"id\":\"example.com\"}}"}`}},
+	"POST /chrome/assert":   {body: map[string]interface{}{"req_json": "{}"}},
+}
+
+// TestRoutesCrossUser は attacker が victim のものを指定して、すべてのルートを呼び出します。
+// どのルートも victim として DB や crypto サービスを使ってはならず、
+// 他のユーザーのリソースを指定するルートは何も書き換えずに断らなければならない
+func TestRoutesCrossUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	routes, db, conn := testRoutes(t)
+
+	seen := map[string]bool{}
+	for _, route := range routes {
+		seen[route.Method+" "+route.Path] = true
+	}
+	for key := range crossUserCases {
+		if !seen[key] {
+			t.Errorf("test case %q has no route", key)
+		}
+	}
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err interface{}) {
+		t.Errorf("%s %s panicked: %v", c.Request.Method, c.Request.URL.Path, err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	RegisterRoutes(router, routes, middleware.AsSystem(db), authenticateAs(attacker))
+
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		t.Run(key, func(t *testing.T) {
+			tt, ok := crossUserCases[key]
+			if !ok {
+				t.Fatalf("route %s (policy %s) has no cross-user test case", key, route.Policy)
+			}
+
+			body := map[string]interface{}{}
+			for k, v := range attackFields {
+				body[k] = v
+			}
+			for k, v := range tt.body {
+				body[k] = v
+			}
+			if route.Policy == PolicyReceiver {
+				// パッサーとして victim を指定する
+				body["passerID"] = victim.String()
+			}
+			data, err := json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target := route.Path + "?passerID=" + victim.String() + "&user_id=" + victim.String() + "&userID=" + victim.String()
+			if tt.query != "" {
+				target += "&" + tt.query
+			}
+
+			db.reset()
+			conn.reset()
+			req := httptest.NewRequest(route.Method, target, bytes.NewReader(data))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			statements := db.reset()
+			calls := conn.reset()
+
+			for _, s := range statements {
+				if s.mutates() && s.mentions(victim) {
+					t.Errorf("wrote as the victim: %s %v", s.sql, s.args)
+				}
+			}
+			for _, call := range calls {
+				if strings.Contains(call, victim.String()) {
+					t.Errorf("called the crypto service as the victim: %s", call)
+				}
+			}
+
+			switch route.Policy {
+			case PolicySelf:
+				for _, s := range statements {
+					if s.mentions(victim) {
+						t.Errorf("read the victim's data: %s %v", s.sql, s.args)
+					}
+				}
+			case PolicyOwner, PolicyReceiver:
+				if w.Code != http.StatusNotFound && w.Code != http.StatusForbidden {
+					t.Errorf("status = %d, want 403 or 404; body = %s", w.Code, w.Body.String())
+				}
+				for _, s := range statements {
+					if s.mutates() {
+						t.Errorf("wrote without the owner's consent: %s %v", s.sql, s.args)
+					}
+				}
+				if len(calls) > 0 {
+					t.Errorf("called the crypto service without the owner's consent: %v", calls)
+				}
+			}
+		})
+	}
+}
+
+// TestRegisterRoutes は PolicyPublic 以外のルートだけが認証を通り、PolicyPublic のルートは public を通ることを確かめます
+func TestRegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	routes, _, _ := testRoutes(t)
+
+	router := gin.New()
+	RegisterRoutes(router, routes, func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusNoContent)
+	}, func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusTeapot)
+	})
+
+	for _, route := range routes {
+		req := httptest.NewRequest(route.Method, route.Path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		want := http.StatusTeapot
+		if route.Policy == PolicyPublic {
+			want = http.StatusNoContent
+		}
+		if w.Code != want {
+			t.Errorf("%s %s (policy %s): status = %d, want %d", route.Method, route.Path, route.Policy, w.Code, want)
+		}
+	}
+}
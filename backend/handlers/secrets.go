@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+)
+
+// decryptedSecret は一括復号した1件分の結果
+type decryptedSecret struct {
+	Plaintext string
+	Error     string
+}
+
+// decryptSecrets は userID 本人が見るために、暗号文をまとめて1回のRPCで復号する。
+// grant に含まれない暗号文は crypto サービスが復号しない。
+// 失敗はその項目の Error に入れて返し、一覧全体は失敗させない
+func decryptSecrets(ctx context.Context, cryptoClient crypto.EncryptionServiceClient, userID string, purpose crypto.DecryptPurpose, grant *crypto.SignedDecryptGrant, ciphertexts [][]byte) []decryptedSecret {
+	secrets := make([]decryptedSecret, len(ciphertexts))
+
+	// 空の暗号文は復号しない
+	var indexes []int
+	var request [][]byte
+	for i, ciphertext := range ciphertexts {
+		if len(ciphertext) == 0 {
+			continue
+		}
+		indexes = append(indexes, i)
+		request = append(request, ciphertext)
+	}
+	if len(request) == 0 {
+		return secrets
+	}
+
+	resp, err := cryptoClient.BatchDecrypt(ctx, &crypto.BatchDecryptRequest{
+		UserId:      userID,
+		Ciphertexts: request,
+		ActorUserId: userID,
+		Purpose:     purpose,
+		Grant:       grant,
+	})
+	if err != nil || len(resp.GetResults()) != len(request) {
+		message := "パスワードの復号化に失敗しました"
+		if err != nil {
+			message += ": " + err.Error()
+		}
+		for _, i := range indexes {
+			secrets[i].Error = message
+		}
+		return secrets
+	}
+
+	for j, result := range resp.GetResults() {
+		i := indexes[j]
+		if result.GetError() != "" {
+			secrets[i].Error = "パスワードの復号化に失敗しました: " + result.GetError()
+			continue
+		}
+		secrets[i].Plaintext = string(result.GetPlaintext())
+	}
+	return secrets
+}
+
+// encryptSecret は userID の鍵でパスワードを暗号化し、保存する暗号文と enc_version を返す。
+// 空のパスワードは暗号化せず、空のまま暗号化済みとして扱う
+func encryptSecret(ctx context.Context, cryptoClient crypto.EncryptionServiceClient, userID string, password string) ([]byte, int32, error) {
+	if password == "" {
+		return []byte{}, service.EncVersionEnvelope, nil
+	}
+	resp, err := cryptoClient.Encrypt(ctx, &crypto.EncryptRequest{
+		UserId:    userID,
+		Plaintext: []byte(password),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.GetCiphertext(), service.EncVersionEnvelope, nil
+}
+
+// decryptVersionedSecrets は enc_version 付きのパスワード列を復号する。
+// encrypt-secrets コマンドでまだ暗号化されていない平文の行は、保存されている値をそのまま返す
+func decryptVersionedSecrets(ctx context.Context, cryptoClient crypto.EncryptionServiceClient, userID string, grant *crypto.SignedDecryptGrant, encPasswords [][]byte, encVersions []int32) []decryptedSecret {
+	ciphertexts := make([][]byte, len(encPasswords))
+	for i, encPassword := range encPasswords {
+		if encVersions[i] != service.EncVersionPlaintext {
+			ciphertexts[i] = encPassword
+		}
+	}
+	secrets := decryptSecrets(ctx, cryptoClient, userID, crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, grant, ciphertexts)
+	for i, encPassword := range encPasswords {
+		if encVersions[i] == service.EncVersionPlaintext {
+			secrets[i].Plaintext = string(encPassword)
+		}
+	}
+	return secrets
+}
@@ -1,20 +1,27 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/a-company-jp/digi-baton/backend/db/query"
 	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type SubscriptionsHandler struct {
-	queries *query.Queries
+	queries      *query.Queries
+	cryptoClient crypto.EncryptionServiceClient
+	disclosures  *service.DisclosureService
+	grants       *service.DecryptGrantService
 }
 
-func NewSubscriptionsHandler(q *query.Queries) *SubscriptionsHandler {
-	return &SubscriptionsHandler{queries: q}
+func NewSubscriptionsHandler(q *query.Queries, cryptoClient crypto.EncryptionServiceClient, disclosures *service.DisclosureService, grants *service.DecryptGrantService) *SubscriptionsHandler {
+	return &SubscriptionsHandler{queries: q, cryptoClient: cryptoClient, disclosures: disclosures, grants: grants}
 }
 
 type SubscriptionResponse struct {
@@ -23,7 +30,7 @@ type SubscriptionResponse struct {
 	IconUrl      string `json:"iconUrl"`
 	Username     string `json:"username"`
 	Email        string `json:"email"`
-	EncPassword  []byte `json:"encPassword"`
+	Password     string `json:"password"`
 	Amount       int32  `json:"amount"`
 	Currency     string `json:"currency"`
 	BillingCycle string `json:"billingCycle"`
@@ -31,9 +38,16 @@ type SubscriptionResponse struct {
 	PlsDelete    bool   `json:"plsDelete"`
 	Message      string `json:"message"`
 	PasserID     string `json:"passerID"`
-	TrustID      *int32 `json:"trustID"`
-	IsDisclosed  bool   `json:"isDisclosed"`
-	CustomData   []byte `json:"customData"`
+	// パッサーには最初の割り当ての、受取人には自分への割り当ての相続関係ID。割り当てがなければ空
+	TrustID     *int32 `json:"trustID"`
+	IsDisclosed bool   `json:"isDisclosed"`
+	CustomData  []byte `json:"customData"`
+	// パッサーにのみ返す、受取人ごとの割り当て
+	Assignments []AssignmentResponse `json:"assignments,omitempty"`
+	// 受取人にのみ返す、自分に見せられる範囲。message ならログイン情報は空になる
+	Permission string `json:"permission,omitempty" enums:"credentials,message"`
+	// 復号に失敗したときのみ設定される。Password は空になる
+	DecryptError string `json:"decryptError,omitempty"`
 }
 
 type SubscriptionCreateRequest struct {
@@ -48,8 +62,9 @@ type SubscriptionCreateRequest struct {
 	Memo         string  `json:"memo,omitempty"`
 	PlsDelete    bool    `json:"plsDelete"`
 	Message      string  `json:"message,omitempty"`
-	PasserID     string  `json:"passerID,omitempty"`
 	CustomData   *[]byte `json:"customData"`
+	// 受取人ごとの割り当て。更新で省略したときは今の割り当てのまま
+	Assignments []AssignmentRequest `json:"assignments"`
 }
 
 // @Summary サブスクリプション一覧取得
@@ -77,25 +92,59 @@ func (h *SubscriptionsHandler) List(c *gin.Context) {
 		return
 	}
 
+	// パスワードはまとめて1回で復号する
+	grant, err := h.grants.OwnSubscriptions(userUUID, subscriptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの復号の許可に失敗しました", err.Error()})
+		return
+	}
+	encPasswords := make([][]byte, len(subscriptions))
+	encVersions := make([]int32, len(subscriptions))
+	for i, subscription := range subscriptions {
+		encPasswords[i] = subscription.EncPassword
+		encVersions[i] = subscription.EncVersion
+	}
+	secrets := decryptVersionedSecrets(c.Request.Context(), h.cryptoClient, userUUID.String(), grant, encPasswords, encVersions)
+
+	assignments, err := h.queries.ListSubscriptionAssignmentsByPasserId(c, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"受取人の割り当ての取得に失敗しました", err.Error()})
+		return
+	}
+	assignmentsBySubscription := map[int32][]query.SubscriptionAssignment{}
+	for _, assignment := range assignments {
+		assignmentsBySubscription[assignment.SubscriptionID] = append(assignmentsBySubscription[assignment.SubscriptionID], assignment)
+	}
+
 	var response []SubscriptionResponse
-	for _, subscription := range subscriptions {
-		response = append(response, subscriptionToResponse(subscription))
+	for i, subscription := range subscriptions {
+		item := subscriptionToResponse(subscription, secrets[i].Plaintext)
+		item.DecryptError = secrets[i].Error
+		item.setAssignments(subscriptionAssignmentsToResponse(assignmentsBySubscription[subscription.ID]))
+		response = append(response, item)
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 // @Summary サブスクリプション作成
-// @Description 新しいサブスクリプションを作成する
+// @Description 自分の新しいサブスクリプションを作成し、assignments で指定した受取人に託す
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body SubscriptionCreateRequest true "サブスクリプション情報"
 // @Success 200 {object} SubscriptionResponse "成功"
 // @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 404 {object} ErrorResponse "相続関係が見つかりませんでした"
+// @Failure 409 {object} ErrorResponse "受取人が招待をまだ承諾していません"
 // @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
 // @Router /subscriptions [post]
 func (h *SubscriptionsHandler) Create(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req SubscriptionCreateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -103,19 +152,52 @@ func (h *SubscriptionsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	params, err := reqToCreateSubscriptionParams(req)
+	params, err := reqToCreateSubscriptionParams(passerID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{"パラメータ変換中にエラーが発生しました", err.Error()})
 		return
 	}
 
+	// 他のユーザーの相続関係には託させない
+	assignments, err := toAssignments(req.Assignments)
+	if err == nil {
+		err = h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments)
+	}
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	// パスワードを暗号化する
+	params.EncPassword, params.EncVersion, err = encryptSecret(c.Request.Context(), h.cryptoClient, passerID.String(), req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの暗号化に失敗しました", err.Error()})
+		return
+	}
+
 	subscription, err := h.queries.CreateSubscription(c, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{"サブスクリプション作成に失敗しました", err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, subscriptionToResponse(subscription))
+	// しきい値開示が設定されていれば金庫の鍵でも暗号化しておく
+	subscription, err = h.disclosures.SealSubscription(c.Request.Context(), subscription)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの金庫への登録に失敗しました", err.Error()})
+		return
+	}
+
+	assigned, err := h.disclosures.AssignSubscription(c.Request.Context(), subscription, assignments)
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	// 暗号化したばかりなので復号せずにリクエストのパスワードを返す
+	response := subscriptionToResponse(subscription, req.Password)
+	response.setAssignments(subscriptionAssignmentsToResponse(assigned))
+	c.JSON(http.StatusOK, response)
 }
 
 type SubscriptionUpdateRequest struct {
@@ -124,16 +206,23 @@ type SubscriptionUpdateRequest struct {
 }
 
 // @Summary サブスクリプション更新
-// @Description 既存のサブスクリプション情報を更新する
+// @Description 自分の既存のサブスクリプション情報を更新する
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body SubscriptionUpdateRequest true "サブスクリプション情報"
 // @Success 200 {object} SubscriptionResponse "成功"
 // @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 404 {object} ErrorResponse "サブスクリプションか相続関係が見つかりませんでした"
+// @Failure 409 {object} ErrorResponse "受取人が招待をまだ承諾していません"
 // @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
 // @Router /subscriptions [put]
 func (h *SubscriptionsHandler) Update(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req SubscriptionUpdateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -148,8 +237,24 @@ func (h *SubscriptionsHandler) Update(c *gin.Context) {
 	}
 
 	subscription, err := h.queries.GetSubscription(c, params.ID)
+	if !authorizeOwner(c, passerID, subscription.PasserID, err) {
+		return
+	}
+
+	reassign := req.Assignments != nil
+	assignments, err := toAssignments(req.Assignments)
+	if err == nil && reassign {
+		err = h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments)
+	}
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	// パスワードを暗号化する
+	params.EncPassword, params.EncVersion, err = encryptSecret(c.Request.Context(), h.cryptoClient, subscription.PasserID.String(), req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{"サブスクリプションが見つかりませんでした", err.Error()})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの暗号化に失敗しました", err.Error()})
 		return
 	}
 
@@ -159,7 +264,86 @@ func (h *SubscriptionsHandler) Update(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, subscriptionToResponse(subscription))
+	// しきい値開示が設定されていれば金庫の鍵でも暗号化しておく
+	subscription, err = h.disclosures.SealSubscription(c.Request.Context(), subscription)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{"パスワードの金庫への登録に失敗しました", err.Error()})
+		return
+	}
+
+	// 割り当てを置き換えるか、開示済みなら受取人側の暗号文も新しいパスワードで作り直す
+	var assigned []query.SubscriptionAssignment
+	if reassign {
+		assigned, err = h.disclosures.AssignSubscription(c.Request.Context(), subscription, assignments)
+		if err != nil {
+			respondAssignmentError(c, err)
+			return
+		}
+	} else {
+		if subscription.IsDisclosed {
+			subscription, err = h.disclosures.ReleaseSubscription(c.Request.Context(), subscription)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{"受取人へのパスワードの引き渡しに失敗しました", err.Error()})
+				return
+			}
+		}
+		assigned, err = h.queries.ListSubscriptionAssignmentsBySubscriptionId(c, subscription.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{"受取人の割り当ての取得に失敗しました", err.Error()})
+			return
+		}
+	}
+
+	response := subscriptionToResponse(subscription, req.Password)
+	response.setAssignments(subscriptionAssignmentsToResponse(assigned))
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary サブスクリプションの割り当て更新
+// @Description 自分のサブスクリプションを託す受取人と、受取人ごとに見せる範囲をまとめて置き換える。すでに開示された受取人にはその場で引き渡す
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param assignments body AssignmentsUpdateRequest true "割り当て"
+// @Success 200 {array} AssignmentResponse "成功"
+// @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 404 {object} ErrorResponse "サブスクリプションか相続関係が見つかりませんでした"
+// @Failure 409 {object} ErrorResponse "受取人が招待をまだ承諾していません"
+// @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
+// @Router /subscriptions/assignments [put]
+func (h *SubscriptionsHandler) UpdateAssignments(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
+	var req AssignmentsUpdateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{"リクエストデータが不正です", err.Error()})
+		return
+	}
+
+	subscription, err := h.queries.GetSubscription(c, req.ID)
+	if !authorizeOwner(c, passerID, subscription.PasserID, err) {
+		return
+	}
+
+	assignments, err := toAssignments(req.Assignments)
+	if err == nil {
+		err = h.disclosures.CheckAssignments(c.Request.Context(), passerID, assignments)
+	}
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+
+	assigned, err := h.disclosures.AssignSubscription(c.Request.Context(), subscription, assignments)
+	if err != nil {
+		respondAssignmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, subscriptionAssignmentsToResponse(assigned))
 }
 
 type DeleteSubscriptionRequest struct {
@@ -167,16 +351,23 @@ type DeleteSubscriptionRequest struct {
 }
 
 // @Summary サブスクリプション削除
-// @Description 指定されたサブスクリプションを削除する
+// @Description 自分のサブスクリプションを削除する
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body DeleteSubscriptionRequest true "削除するサブスクリプション情報"
 // @Success 200 {object} SubscriptionResponse "成功"
 // @Failure 400 {object} ErrorResponse "リクエストデータが不正です"
+// @Failure 401 {object} ErrorResponse "認証に失敗しました"
+// @Failure 404 {object} ErrorResponse "サブスクリプションが見つかりませんでした"
 // @Failure 500 {object} ErrorResponse "データベース接続に失敗しました"
 // @Router /subscriptions [delete]
 func (h *SubscriptionsHandler) Delete(c *gin.Context) {
+	// 認証済みミドルウェアからユーザIDを取得
+	userUUID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req DeleteSubscriptionRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -184,15 +375,13 @@ func (h *SubscriptionsHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	// 認証済みミドルウェアからユーザIDを取得
-	userUUID, exists := middleware.GetUserIdUUID(c)
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ユーザー認証に失敗しました"})
+	existing, err := h.queries.GetSubscription(c, int32(req.SubscriptionID))
+	if !authorizeOwner(c, userUUID, existing.PasserID, err) {
 		return
 	}
 
 	deleteParams := query.DeleteSubscriptionParams{
-		ID:       int32(req.SubscriptionID),
+		ID:       existing.ID,
 		PasserID: userUUID,
 	}
 
@@ -202,32 +391,23 @@ func (h *SubscriptionsHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, subscriptionToResponse(subscription))
+	c.JSON(http.StatusOK, subscriptionToResponse(subscription, ""))
 }
 
-func reqToCreateSubscriptionParams(req SubscriptionCreateRequest) (query.CreateSubscriptionParams, error) {
+func reqToCreateSubscriptionParams(passerID pgtype.UUID, req SubscriptionCreateRequest) (query.CreateSubscriptionParams, error) {
 	params := query.CreateSubscriptionParams{}
 
 	params.ServiceName = pgtype.Text{String: req.ServiceName, Valid: req.ServiceName != ""}
 	params.IconUrl = pgtype.Text{String: req.IconUrl, Valid: req.IconUrl != ""}
 	params.Username = req.Username
 	params.Email = req.Email
-	params.EncPassword = []byte(req.Password)
 	params.Amount = req.Amount
 	params.Currency = req.Currency
 	params.BillingCycle = req.BillingCycle
 	params.Memo = req.Memo
 	params.PlsDelete = req.PlsDelete
 	params.Message = req.Message
-
-	// PasserIDの処理
-	if req.PasserID != "" {
-		var passerUUID pgtype.UUID
-		if err := passerUUID.Scan(req.PasserID); err != nil {
-			return params, err
-		}
-		params.PasserID = passerUUID
-	}
+	params.PasserID = passerID
 
 	// CustomDataの処理
 	if req.CustomData != nil {
@@ -247,7 +427,6 @@ func reqToUpdateSubscriptionParams(req SubscriptionUpdateRequest) (query.UpdateS
 	params.IconUrl = pgtype.Text{String: req.IconUrl, Valid: req.IconUrl != ""}
 	params.Username = req.Username
 	params.Email = req.Email
-	params.EncPassword = []byte(req.Password)
 	params.Amount = req.Amount
 	params.Currency = req.Currency
 	params.BillingCycle = req.BillingCycle
@@ -264,7 +443,60 @@ func reqToUpdateSubscriptionParams(req SubscriptionUpdateRequest) (query.UpdateS
 	return params, nil
 }
 
-func subscriptionToResponse(subscription query.Subscription) SubscriptionResponse {
+// disclosedSubscriptionsToResponse は受取人に開示されたサブスクリプションをレスポンスに変換する。
+// 受取人向けに暗号化し直したものを受取人の鍵で復号する。暗号化前に保存された行は平文のまま返す。
+// 伝言だけを見せる割り当てでは、ログイン情報を空にして返す
+func disclosedSubscriptionsToResponse(ctx context.Context, cryptoClient crypto.EncryptionServiceClient, grants *service.DecryptGrantService, receiverID pgtype.UUID, rows []query.ListDisclosedSubscriptionsByReceiverIdRow) ([]SubscriptionResponse, error) {
+	grant, err := grants.DisclosedSubscriptions(ctx, receiverID, rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant decryption: %w", err)
+	}
+	ciphertexts := make([][]byte, len(rows))
+	for i, row := range rows {
+		if row.Permission == service.PermissionCredentials && row.Subscription.EncVersion != service.EncVersionPlaintext {
+			ciphertexts[i] = row.ReceiverEncPassword
+		}
+	}
+	secrets := decryptSecrets(ctx, cryptoClient, receiverID.String(), crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED, grant, ciphertexts)
+
+	response := make([]SubscriptionResponse, len(rows))
+	for i, row := range rows {
+		subscription := row.Subscription
+		switch {
+		case row.Permission != service.PermissionCredentials:
+			response[i] = subscriptionToResponse(subscription, "")
+			response[i].Username = ""
+			response[i].Email = ""
+			response[i].Memo = ""
+			response[i].CustomData = nil
+		case subscription.EncVersion == service.EncVersionPlaintext:
+			response[i] = subscriptionToResponse(subscription, string(subscription.EncPassword))
+		default:
+			response[i] = subscriptionToResponse(subscription, secrets[i].Plaintext)
+			response[i].DecryptError = secrets[i].Error
+			if len(subscription.EncPassword) > 0 && len(row.ReceiverEncPassword) == 0 {
+				response[i].DecryptError = "受取人への引き渡しが完了していません"
+			}
+		}
+		trustID := row.TrustID
+		response[i].TrustID = &trustID
+		response[i].Permission = row.Permission
+	}
+	return response, nil
+}
+
+// setAssignments はパッサーに返す割り当てを設定します
+func (r *SubscriptionResponse) setAssignments(assignments []AssignmentResponse) {
+	r.Assignments = assignments
+	r.TrustID = nil
+	if trustID, ok := primaryTrustID(assignments); ok {
+		r.TrustID = &trustID
+	}
+}
+
+// subscriptionToResponse はサブスクリプションをレスポンスに変換する。
+// password は復号したパスワードで、保存している暗号文は返さない
+func subscriptionToResponse(subscription query.Subscription, password string) SubscriptionResponse {
 	var serviceName, iconUrl string
 	if subscription.ServiceName.Valid {
 		serviceName = subscription.ServiceName.String
@@ -279,14 +511,13 @@ func subscriptionToResponse(subscription query.Subscription) SubscriptionRespons
 		IconUrl:      iconUrl,
 		Username:     subscription.Username,
 		Email:        subscription.Email,
-		EncPassword:  subscription.EncPassword,
+		Password:     password,
 		Amount:       subscription.Amount,
 		Currency:     subscription.Currency,
 		BillingCycle: subscription.BillingCycle,
 		Memo:         subscription.Memo,
 		PlsDelete:    subscription.PlsDelete,
 		Message:      subscription.Message,
-		TrustID:      &subscription.TrustID,
 		IsDisclosed:  subscription.IsDisclosed,
 		CustomData:   subscription.CustomData,
 	}
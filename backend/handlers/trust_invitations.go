@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/gin-gonic/gin"
+)
+
+type TrustInvitationsHandler struct {
+	queries     *query.Queries
+	invitations *service.TrustInvitationService
+}
+
+func NewTrustInvitationsHandler(q *query.Queries, invitations *service.TrustInvitationService) *TrustInvitationsHandler {
+	return &TrustInvitationsHandler{queries: q, invitations: invitations}
+}
+
+type TrustInvitationCreateRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+type TrustInvitationDeleteRequest struct {
+	ID int32 `json:"id" validate:"required"`
+}
+
+// TrustInvitationRespondRequest は招待への返事。受取人と結びついた招待は id で、メールのリンクからは token で指定する
+type TrustInvitationRespondRequest struct {
+	ID    int32  `json:"id"`
+	Token string `json:"token"`
+}
+
+type TrustInvitationResponse struct {
+	ID       int32  `json:"id" validate:"required"`
+	PasserID string `json:"passerID" validate:"required"`
+	Email    string `json:"email" validate:"required"`
+	// pending, accepted, declined, revoked のいずれか
+	Status string `json:"status" validate:"required"`
+	// 招待された人が登録するかリンクを開くまでは空
+	ReceiverID string `json:"receiverID"`
+	// 招待からできた相続関係
+	TrustID     *int32  `json:"trustID"`
+	ExpiresAt   string  `json:"expiresAt" validate:"required"`
+	RespondedAt *string `json:"respondedAt"`
+	CreatedAt   string  `json:"createdAt" validate:"required"`
+}
+
+// List 送った招待の一覧
+// @Summary		送った招待の一覧取得
+// @Description	自分がパッサーとして送った受取人への招待を新しい順に取得する
+// @Tags			trust-invitations
+// @Accept			json
+// @Produce		json
+// @Success		200	{array}		TrustInvitationResponse	"成功"
+// @Failure		401	{object}	ErrorResponse			"認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse			"データベース接続に失敗しました"
+// @Router			/trust-invitations [get]
+func (h *TrustInvitationsHandler) List(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.queries.ListTrustInvitationsByPasserID(c, passerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "招待一覧の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trustInvitationsToResponse(invitations))
+}
+
+// ListIncoming 受けた招待の一覧
+// @Summary		受けた招待の一覧取得
+// @Description	自分を受取人とする招待を新しい順に取得する。登録したメールアドレスへの招待と、リンクを開いた招待が含まれる
+// @Tags			trust-invitations
+// @Accept			json
+// @Produce		json
+// @Success		200	{array}		TrustInvitationResponse	"成功"
+// @Failure		401	{object}	ErrorResponse			"認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse			"データベース接続に失敗しました"
+// @Router			/trust-invitations/incoming [get]
+func (h *TrustInvitationsHandler) ListIncoming(c *gin.Context) {
+	receiverID, ok := actorID(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.queries.ListTrustInvitationsByReceiverID(c, receiverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "招待一覧の取得に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trustInvitationsToResponse(invitations))
+}
+
+// Create 受取人の招待
+// @Summary		受取人の招待
+// @Description	まだアカウントのない人を、メールアドレスで受取人に招待する。招待のリンクをメールで送る。
+// @Description	招待された人がそのメールアドレスで登録すると相続関係ができ、承諾するとその相続関係で開示請求できるようになる
+// @Tags			trust-invitations
+// @Accept			json
+// @Produce		json
+// @Param			invitation	body		TrustInvitationCreateRequest	true	"招待するメールアドレス"
+// @Success		200			{object}	TrustInvitationResponse			"成功"
+// @Failure		400			{object}	ErrorResponse					"リクエストデータが不正です"
+// @Failure		401			{object}	ErrorResponse					"認証に失敗しました"
+// @Failure		409			{object}	ErrorResponse					"同じメールアドレスの人が招待に返事していません"
+// @Failure		500			{object}	ErrorResponse					"招待の送信に失敗しました"
+// @Router			/trust-invitations [post]
+func (h *TrustInvitationsHandler) Create(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
+	var req TrustInvitationCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+	email, err := service.NormalizeInvitationEmail(req.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+
+	invitation, err := h.invitations.Invite(c.Request.Context(), passerID, email)
+	if errors.Is(err, service.ErrInvitationLinked) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "同じメールアドレスの人が招待に返事していません", Details: "返事を待つか、招待を取り消してください"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "招待の送信に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trustInvitationToResponse(invitation))
+}
+
+// Delete 招待の取り消し
+// @Summary		招待の取り消し
+// @Description	自分が送った、返事を待っている招待を取り消す。招待からできた相続関係も消す
+// @Tags			trust-invitations
+// @Accept			json
+// @Produce		json
+// @Param			invitation	body		TrustInvitationDeleteRequest	true	"招待"
+// @Success		200			{object}	TrustInvitationResponse			"成功"
+// @Failure		400			{object}	ErrorResponse					"リクエストデータが不正です"
+// @Failure		401			{object}	ErrorResponse					"認証に失敗しました"
+// @Failure		404			{object}	ErrorResponse					"招待が見つかりませんでした"
+// @Failure		409			{object}	ErrorResponse					"招待はすでに返事されています"
+// @Failure		500			{object}	ErrorResponse					"データベース接続に失敗しました"
+// @Router			/trust-invitations [delete]
+func (h *TrustInvitationsHandler) Delete(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
+	var req TrustInvitationDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+
+	invitation, err := h.queries.GetTrustInvitation(c, req.ID)
+	if !authorizeOwner(c, passerID, invitation.PasserUserID, err) {
+		return
+	}
+
+	invitation, err = h.invitations.Revoke(c.Request.Context(), invitation)
+	if errors.Is(err, service.ErrInvitationClosed) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "招待はすでに返事されています", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "招待の取り消しに失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trustInvitationToResponse(invitation))
+}
+
+// Accept 招待の承諾
+// @Summary		招待の承諾
+// @Description	受けた招待を承諾し、パッサーの受取人になる。メールのリンクの token で指定すると、その招待を自分と結びつけてから承諾する
+// @Tags			trust-invitations
+// @Accept			json
+// @Produce		json
+// @Param			invitation	body		TrustInvitationRespondRequest	true	"招待"
+// @Success		200			{object}	TrustInvitationResponse			"成功"
+// @Failure		400			{object}	ErrorResponse					"リクエストデータが不正です"
+// @Failure		401			{object}	ErrorResponse					"認証に失敗しました"
+// @Failure		404			{object}	ErrorResponse					"招待が見つかりませんでした"
+// @Failure		409			{object}	ErrorResponse					"招待はすでに返事されたか、期限が切れています"
+// @Failure		500			{object}	ErrorResponse					"データベース接続に失敗しました"
+// @Router			/trust-invitations/accept [post]
+func (h *TrustInvitationsHandler) Accept(c *gin.Context) {
+	h.respond(c, true)
+}
+
+// Decline 招待の辞退
+// @Summary		招待の辞退
+// @Description	受けた招待を辞退する。招待からできた相続関係は消える。メールのリンクの token で指定すると、登録したメールアドレスが違っても辞退できる
+// @Tags			trust-invitations
+// @Accept			json
+// @Produce		json
+// @Param			invitation	body		TrustInvitationRespondRequest	true	"招待"
+// @Success		200			{object}	TrustInvitationResponse			"成功"
+// @Failure		400			{object}	ErrorResponse					"リクエストデータが不正です"
+// @Failure		401			{object}	ErrorResponse					"認証に失敗しました"
+// @Failure		404			{object}	ErrorResponse					"招待が見つかりませんでした"
+// @Failure		409			{object}	ErrorResponse					"招待はすでに返事されたか、期限が切れています"
+// @Failure		500			{object}	ErrorResponse					"データベース接続に失敗しました"
+// @Router			/trust-invitations/decline [post]
+func (h *TrustInvitationsHandler) Decline(c *gin.Context) {
+	h.respond(c, false)
+}
+
+// respond は招待を承諾するか辞退します
+func (h *TrustInvitationsHandler) respond(c *gin.Context, accept bool) {
+	receiverID, ok := actorID(c)
+	if !ok {
+		return
+	}
+	var req TrustInvitationRespondRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: err.Error()})
+		return
+	}
+	if (req.ID == 0) == (req.Token == "") {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "リクエストデータが不正です", Details: "id か token のどちらか一方を指定してください"})
+		return
+	}
+
+	var invitation query.TrustInvitation
+	var err error
+	if req.Token != "" {
+		invitation, err = h.invitations.FindByToken(c.Request.Context(), receiverID, req.Token)
+		if errors.Is(err, service.ErrInvitationNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "招待が見つかりませんでした", Details: ""})
+			return
+		}
+		if errors.Is(err, service.ErrOwnInvitation) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "自分が送った招待には返事できません", Details: ""})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "データベース接続に失敗しました", Details: err.Error()})
+			return
+		}
+	} else {
+		invitation, err = h.queries.GetTrustInvitation(c, req.ID)
+		if !authorizeOwner(c, receiverID, invitation.ReceiverUserID, err) {
+			return
+		}
+	}
+
+	if accept {
+		invitation, err = h.invitations.Accept(c.Request.Context(), receiverID, invitation)
+	} else {
+		invitation, err = h.invitations.Decline(c.Request.Context(), invitation)
+	}
+	if errors.Is(err, service.ErrInvitationClosed) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "招待はすでに返事されたか、期限が切れています", Details: ""})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "招待への返事に失敗しました", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trustInvitationToResponse(invitation))
+}
+
+func trustInvitationsToResponse(invitations []query.TrustInvitation) []TrustInvitationResponse {
+	res := make([]TrustInvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		res = append(res, trustInvitationToResponse(invitation))
+	}
+	return res
+}
+
+func trustInvitationToResponse(invitation query.TrustInvitation) TrustInvitationResponse {
+	res := TrustInvitationResponse{
+		ID:        invitation.ID,
+		PasserID:  invitation.PasserUserID.String(),
+		Email:     invitation.Email,
+		Status:    invitation.Status,
+		ExpiresAt: invitation.ExpiresAt.Time.Format(time.RFC3339),
+		CreatedAt: invitation.CreatedAt.Time.Format(time.RFC3339),
+	}
+	if invitation.ReceiverUserID.Valid {
+		res.ReceiverID = invitation.ReceiverUserID.String()
+	}
+	if invitation.TrustID.Valid {
+		res.TrustID = &invitation.TrustID.Int32
+	}
+	if invitation.RespondedAt.Valid {
+		s := invitation.RespondedAt.Time.Format(time.RFC3339)
+		res.RespondedAt = &s
+	}
+	return res
+}
@@ -1,25 +1,27 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type TrustsHandler struct {
-	queries *query.Queries
+	queries     *query.Queries
+	disclosures *service.DisclosureService
 }
 
-func NewTrustsHandler(q *query.Queries) *TrustsHandler {
-	return &TrustsHandler{queries: q}
+func NewTrustsHandler(q *query.Queries, disclosures *service.DisclosureService) *TrustsHandler {
+	return &TrustsHandler{queries: q, disclosures: disclosures}
 }
 
 type TrustRequest struct {
-	PasserID  uuid.UUID `json:"passerID"`
 	ReviverID uuid.UUID `json:"reviverID"`
 }
 
@@ -30,25 +32,17 @@ type TrustResponse struct {
 }
 
 // @Summary		相続関係の一覧取得
-// @Description	ユーザが開示している相続関係一覧を取得する
+// @Description	自分がパッサーとして結んでいる相続関係一覧を取得する
 // @Tags			trusts
 // @Accept			json
 // @Produce		json
-// @Param			passerID	query		string			true	"相続関係を取得するユーザのID"
-// @Success		200			{array}		TrustResponse	"成功"
-// @Failure		400			{object}	ErrorResponse	"リクエストデータが不正です"
-// @Failure		500			{object}	ErrorResponse	"データベース接続に失敗しました"
+// @Success		200	{array}		TrustResponse	"成功"
+// @Failure		401	{object}	ErrorResponse	"認証に失敗しました"
+// @Failure		500	{object}	ErrorResponse	"データベース接続に失敗しました"
 // @Router			/trusts [get]
 func (h *TrustsHandler) List(c *gin.Context) {
-	passerID := c.Query("passerID")
-	if passerID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "パラメータが不正です", "details": "passerIDが指定されていません"})
-		return
-	}
-
-	pID, err := toPGUUID(passerID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "UUID変換に失敗しました", "details": err.Error()})
+	pID, ok := actorID(c)
+	if !ok {
 		return
 	}
 
@@ -67,23 +61,28 @@ func (h *TrustsHandler) List(c *gin.Context) {
 }
 
 // @Summary		相続関係の作成
-// @Description	ユーザ間の相続関係を作成する
+// @Description	自分をパッサーとして、指定したユーザを受取人にする相続関係を作成する
 // @Tags			trusts
 // @Accept			json
 // @Produce		json
 // @Param			trust	body		TrustRequest	true	"相続関係"
 // @Success		200		{object}	TrustResponse	"成功"
 // @Failure		400		{object}	ErrorResponse	"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse	"認証に失敗しました"
 // @Failure		500		{object}	ErrorResponse	"データベース接続に失敗しました"
 // @Router			/trusts [post]
 func (h *TrustsHandler) Create(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req TrustRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストデータが不正です", "details": err.Error()})
 		return
 	}
 
-	params, err := reqToCreateTrustParams(req)
+	params, err := reqToCreateTrustParams(passerID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストデータが不正です", "details": err.Error()})
 		return
@@ -94,42 +93,50 @@ func (h *TrustsHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "データベースエラー", "details": err.Error()})
 		return
 	}
+	if !h.resplitVault(c, passerID) {
+		return
+	}
 
 	c.JSON(http.StatusOK, trust)
 }
 
 type UpdateTrustRequest struct {
 	TrustID   int32     `json:"trustID"`
-	PasserID  uuid.UUID `json:"passerID"`
 	ReviverID uuid.UUID `json:"reviverID"`
 }
 
 // @Summary		相続関係の更新
-// @Description	ユーザ間の相続関係を更新する
+// @Description	自分がパッサーの相続関係の受取人を変更する
 // @Tags			trusts
 // @Accept			json
 // @Produce		json
 // @Param			trust	body		UpdateTrustRequest	true	"相続関係"
 // @Success		200		{object}	TrustResponse		"成功"
 // @Failure		400		{object}	ErrorResponse		"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse		"認証に失敗しました"
+// @Failure		404		{object}	ErrorResponse		"相続関係が見つかりませんでした"
+// @Failure		409		{object}	ErrorResponse		"しきい値開示に必要な受取人が足りなくなります"
 // @Failure		500		{object}	ErrorResponse		"データベース接続に失敗しました"
 // @Router			/trusts [put]
 func (h *TrustsHandler) Update(c *gin.Context) {
+	passerID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req UpdateTrustRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストデータが不正です", "details": err.Error()})
 		return
 	}
 
-	params, err := reqToUpdateTrustParams(req)
+	params, err := reqToUpdateTrustParams(passerID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストデータが不正です", "details": err.Error()})
 		return
 	}
 
 	trust, err := h.queries.GetTrust(c, params.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "相続関係が見つかりませんでした。", "details": err.Error()})
+	if !authorizeOwner(c, passerID, trust.PasserUserID, err) {
 		return
 	}
 
@@ -138,26 +145,35 @@ func (h *TrustsHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新エラー", "details": err.Error()})
 		return
 	}
+	if !h.resplitVault(c, passerID) {
+		return
+	}
 
 	c.JSON(http.StatusOK, trust)
 }
 
 type DeleteTrustRequest struct {
-	TrustID  int32  `json:"trustID"`
-	PasserID string `json:"passerID"`
+	TrustID int32 `json:"trustID"`
 }
 
 // @Summary		相続関係の削除
-// @Description	ユーザ間の相続関係を削除する
+// @Description	自分がパッサーの相続関係を削除する
 // @Tags			trusts
 // @Accept			json
 // @Produce		json
 // @Param			trust	body		DeleteTrustRequest	true	"相続関係"
 // @Success		200		{object}	TrustResponse		"成功"
 // @Failure		400		{object}	ErrorResponse		"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse		"認証に失敗しました"
+// @Failure		404		{object}	ErrorResponse		"相続関係が見つかりませんでした"
+// @Failure		409		{object}	ErrorResponse		"しきい値開示に必要な受取人が足りなくなります"
 // @Failure		500		{object}	ErrorResponse		"データベース接続に失敗しました"
 // @Router			/trusts [delete]
 func (h *TrustsHandler) Delete(c *gin.Context) {
+	pID, ok := actorID(c)
+	if !ok {
+		return
+	}
 	var req DeleteTrustRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -165,28 +181,39 @@ func (h *TrustsHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	pID, err := toPGUUID(req.PasserID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "パラメータ変換中にエラーが発生しました", "details": err.Error()})
+	existing, err := h.queries.GetTrust(c, req.TrustID)
+	if !authorizeOwner(c, pID, existing.PasserUserID, err) {
 		return
 	}
 
-	trust, err := h.queries.DeleteTrust(c, query.DeleteTrustParams{ID: req.TrustID, PasserUserID: pID})
+	trust, err := h.queries.DeleteTrust(c, query.DeleteTrustParams{ID: existing.ID, PasserUserID: pID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "トラスト削除に失敗しました", "details": err.Error()})
 		return
 	}
+	if !h.resplitVault(c, pID) {
+		return
+	}
 
 	c.JSON(http.StatusOK, trust)
 }
 
-func reqToCreateTrustParams(req TrustRequest) (query.CreateTrustParams, error) {
-
-	var passerID pgtype.UUID
-	if err := passerID.Scan(req.PasserID.String()); err != nil {
-		return query.CreateTrustParams{}, fmt.Errorf("パラメータの変換に失敗しました: %w", err)
+// resplitVault はしきい値開示が設定されていれば、変わった受取人に金庫の鍵のシェアを配り直します。
+// 受取人が threshold 人より少なくなるときは 409 を返し、相続関係の変更はトランザクションごと取り消される
+func (h *TrustsHandler) resplitVault(c *gin.Context, passerID pgtype.UUID) bool {
+	_, err := h.disclosures.ResplitPolicy(c.Request.Context(), passerID)
+	if errors.Is(err, service.ErrInvalidThreshold) || errors.Is(err, service.ErrNoReceivers) {
+		c.JSON(http.StatusConflict, gin.H{"error": "しきい値開示に必要な受取人が足りなくなります。先にしきい値を下げるか、しきい値開示をやめてください", "details": err.Error()})
+		return false
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "金庫の鍵のシェアの配り直しに失敗しました", "details": err.Error()})
+		return false
+	}
+	return true
+}
 
+func reqToCreateTrustParams(passerID pgtype.UUID, req TrustRequest) (query.CreateTrustParams, error) {
 	var receiverID pgtype.UUID
 	if err := receiverID.Scan(req.ReviverID.String()); err != nil {
 		return query.CreateTrustParams{}, fmt.Errorf("パラメータの変換に失敗しました: %w", err)
@@ -200,12 +227,7 @@ func reqToCreateTrustParams(req TrustRequest) (query.CreateTrustParams, error) {
 	return params, nil
 }
 
-func reqToUpdateTrustParams(req UpdateTrustRequest) (query.UpdateTrustParams, error) {
-	var passerID pgtype.UUID
-	if err := passerID.Scan(req.PasserID.String()); err != nil {
-		return query.UpdateTrustParams{}, fmt.Errorf("パラメータの変換に失敗しました: %w", err)
-	}
-
+func reqToUpdateTrustParams(passerID pgtype.UUID, req UpdateTrustRequest) (query.UpdateTrustParams, error) {
 	var receiverID pgtype.UUID
 	if err := receiverID.Scan(req.ReviverID.String()); err != nil {
 		return query.UpdateTrustParams{}, fmt.Errorf("パラメータの変換に失敗しました: %w", err)
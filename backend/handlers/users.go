@@ -3,21 +3,24 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/a-company-jp/digi-baton/backend/db/query"
 	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type UsersHandler struct {
-	queries *query.Queries
+	queries     *query.Queries
+	invitations *service.TrustInvitationService
 }
 
-func NewUsersHandler(q *query.Queries) *UsersHandler {
-	return &UsersHandler{queries: q}
+func NewUsersHandler(q *query.Queries, invitations *service.TrustInvitationService) *UsersHandler {
+	return &UsersHandler{queries: q, invitations: invitations}
 }
 
 type UserCreateRequest struct {
@@ -57,7 +60,8 @@ func (h *UsersHandler) GetByClerkID(c *gin.Context) {
 }
 
 // @Summary		ユーザ登録
-// @Description	clerkでユーザ認証した後にバックエンドのDBにユーザを登録するためのエンドポイント
+// @Description	clerkでユーザ認証した後にバックエンドのDBにユーザを登録するためのエンドポイント。
+// @Description	確認済みのメールアドレスに受取人への招待が届いていれば、招待したパッサーとの相続関係を作る
 // @Tags			users
 // @Accept			json
 // @Produce		json
@@ -85,35 +89,48 @@ func (h *UsersHandler) Create(c *gin.Context) {
 		return
 	}
 
+	// 招待はリンクのトークンからも受けられるので、ここで失敗しても登録は続ける
+	if _, err := h.invitations.LinkRegisteredUser(c.Request.Context(), user.ID, user.ClerkUserID); err != nil {
+		log.Printf("failed to link trust invitations to user %s: %v", user.ID.String(), err)
+	}
+
 	response := userToResponse(user)
 
 	c.JSON(http.StatusOK, response)
 }
 
 type UserUpdateRequest struct {
-	UserID            *uuid.UUID `json:"userID"`
 	DefaultReceiverID *uuid.UUID `json:"defaultReceiverID"`
-	ClerkUserID       string     `json:"clerkUserID"`
 }
 
 // @Summary		ユーザ更新
-// @Description	clerkでユーザ認証した後にバックエンドのDBにユーザを更新するためのエンドポイント
+// @Description	clerkでユーザ認証した後に、自分の既定の受取人を更新するためのエンドポイント
 // @Tags			users
 // @Accept			json
 // @Produce		json
-// @Param			user	body		UserCreateRequest	true	"ユーザ情報"
+// @Param			user	body		UserUpdateRequest	true	"ユーザ情報"
 // @Success		200		{object}	UserResponse		"成功"
 // @Failure		400		{object}	ErrorResponse		"リクエストデータが不正です"
+// @Failure		401		{object}	ErrorResponse		"認証に失敗しました"
 // @Failure		500		{object}	ErrorResponse		"データベース接続に失敗しました"
 // @Router			/users [put]
 func (h *UsersHandler) Update(c *gin.Context) {
+	userID, ok := actorID(c)
+	if !ok {
+		return
+	}
+	clerkUserID, ok := middleware.GetClerkUserId(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{"認証に失敗しました", "ClerkのユーザーIDが見つかりません"})
+		return
+	}
 	var req UserUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストデータが不正です", "details": err.Error()})
 		return
 	}
 
-	params, err := reqToUpdateUserParams(req)
+	params, err := reqToUpdateUserParams(userID, clerkUserID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストデータが不正です", "details": err.Error()})
 		return
@@ -155,17 +172,7 @@ func reqToUserCreateParams(req UserCreateRequest) (query.CreateUserParams, error
 	return params, nil
 }
 
-func reqToUpdateUserParams(req UserUpdateRequest) (query.UpdateUserParams, error) {
-
-	if req.UserID == nil {
-		return query.UpdateUserParams{}, fmt.Errorf("userIDは必須です。")
-	}
-
-	var useID pgtype.UUID
-	if err := useID.Scan(req.UserID.String()); err != nil {
-		return query.UpdateUserParams{}, fmt.Errorf("userIDがUUIDではありません: %w", err)
-	}
-
+func reqToUpdateUserParams(userID pgtype.UUID, clerkUserID string, req UserUpdateRequest) (query.UpdateUserParams, error) {
 	var receiverID pgtype.UUID
 	if req.DefaultReceiverID == nil {
 		receiverID = pgtype.UUID{Valid: false}
@@ -176,9 +183,9 @@ func reqToUpdateUserParams(req UserUpdateRequest) (query.UpdateUserParams, error
 	}
 
 	params := query.UpdateUserParams{
-		ID:                useID,
+		ID:                userID,
 		DefaultReceiverID: receiverID,
-		ClerkUserID:       req.ClerkUserID,
+		ClerkUserID:       clerkUserID,
 	}
 
 	return params, nil
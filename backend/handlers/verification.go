@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"time"
@@ -10,10 +12,10 @@ import (
 	"github.com/a-company-jp/digi-baton/backend/middleware"
 	"github.com/a-company-jp/digi-baton/backend/pkg/mail"
 	"github.com/a-company-jp/digi-baton/backend/pkg/verification"
+	"github.com/a-company-jp/digi-baton/backend/service"
 	"github.com/clerk/clerk-sdk-go/v2/user"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5"
 )
 
 const (
@@ -27,39 +29,28 @@ type VerificationHandler struct {
 	tokenManager       *verification.VerificationTokenManager
 	mailSender         *mail.Sender
 	verificationURLFmt string
+	checks             *service.AliveCheckService
+	disclosures        *service.DisclosureService
 }
 
 // NewVerificationHandler は新しい生存確認ハンドラーを作成します
-func NewVerificationHandler(queries *query.Queries) *VerificationHandler {
+func NewVerificationHandler(queries *query.Queries, checks *service.AliveCheckService, disclosures *service.DisclosureService) *VerificationHandler {
 	// 環境変数から設定を取得
-	mailjetPublicKey := os.Getenv("MAILJET_API_KEY_PUBLIC")
-	mailjetPrivateKey := os.Getenv("MAILJET_API_KEY_PRIVATE")
-	mailjetFromEmail := os.Getenv("MAILJET_FROM_EMAIL")
-	mailjetFromName := os.Getenv("MAILJET_FROM_NAME")
-	jwtSecret := os.Getenv("JWT_SECRET")
 	frontendURL := os.Getenv("FRONTEND_URL")
 
 	// 有効期限は24時間に設定
-	tokenManager := verification.NewVerificationTokenManager(jwtSecret, tokenExpirationTime)
+	tokenManager := verification.NewVerificationTokenManager(queries, tokenExpirationTime)
 
 	// Mailjetのメール送信機能を初期化
-	var mailSender *mail.Sender
-	if mailjetPublicKey != "" && mailjetPrivateKey != "" && mailjetFromEmail != "" {
-		if mailjetFromName == "" {
-			mailjetFromName = "Digi Baton" // デフォルト送信者名
-		}
-		mailSender = mail.NewSender(mailjetPublicKey, mailjetPrivateKey, mailjetFromEmail, mailjetFromName)
-	} else {
-		// 環境変数が設定されていない場合はダミー送信者を使用（ログのみ出力）
-		fmt.Println("WARNING: Mailjet credentials not found, using dummy email sender")
-		mailSender = mail.NewDummySender()
-	}
+	mailSender := mail.NewSenderFromEnv()
 
 	return &VerificationHandler{
 		queries:            queries,
 		tokenManager:       tokenManager,
 		mailSender:         mailSender,
 		verificationURLFmt: frontendURL + "/verify?token=%s",
+		checks:             checks,
+		disclosures:        disclosures,
 	}
 }
 
@@ -77,7 +68,7 @@ func (h *VerificationHandler) SendVerificationEmail(c *gin.Context) {
 	}
 
 	// ユーザーIDを取得
-	userID, exists := middleware.GetUserId(c)
+	userID, exists := middleware.GetUserIdUUID(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
 		return
@@ -108,7 +99,7 @@ func (h *VerificationHandler) SendVerificationEmail(c *gin.Context) {
 	}
 
 	// トークンを生成
-	token, err := h.tokenManager.GenerateToken(userID, clerkID, req.Email)
+	token, err := h.tokenManager.Issue(c.Request.Context(), userID, verification.PurposeManual, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("トークン生成に失敗しました: %v", err)})
 		return
@@ -127,13 +118,29 @@ func (h *VerificationHandler) SendVerificationEmail(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "確認メールを送信しました"})
 }
 
-// VerifyRequest はトークン検証リクエスト
+// VerifyRequest はトークン検証リクエスト。
+// 開示請求はトークンに結び付いているので、disclosure_id を送られても使わない
 type VerifyRequest struct {
-	Token        string `json:"token" binding:"required"`
-	DisclosureID int32  `json:"disclosure_id"`
+	Token string `json:"token" binding:"required"`
+}
+
+// tokenErrors はトークンが使えなかった理由ごとの応答
+var tokenErrors = []struct {
+	err     error
+	status  int
+	state   string
+	message string
+}{
+	{verification.ErrTokenNotFound, http.StatusUnauthorized, "invalid", "無効なトークンです"},
+	{verification.ErrTokenUsed, http.StatusConflict, "used", "このリンクはすでに使われています"},
+	{verification.ErrTokenSuperseded, http.StatusGone, "superseded", "新しいリンクが送られているため、このリンクは使えません。最新のリンクを使ってください"},
+	{verification.ErrTokenRevoked, http.StatusGone, "revoked", "このリンクは取り消されています"},
+	{verification.ErrTokenExpired, http.StatusGone, "expired", "このリンクは有効期限が切れています"},
 }
 
-// VerifyToken はトークンを検証します
+// VerifyToken はトークンを検証します。トークンは1回だけ使える。
+// トークンの使用と開示請求の却下は AsSystem のトランザクションでまとめて確定する。
+// 却下できなければエラーを返してロールバックし、リンクはもう一度使えるようにする
 func (h *VerificationHandler) VerifyToken(c *gin.Context) {
 	var req VerifyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -141,129 +148,85 @@ func (h *VerificationHandler) VerifyToken(c *gin.Context) {
 		return
 	}
 
-	// トークンを検証
-	claims, err := h.tokenManager.VerifyToken(req.Token)
+	// トークンを使用済みにする
+	token, err := h.tokenManager.Consume(c.Request.Context(), req.Token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("無効なトークンです: %v", err)})
+		for _, e := range tokenErrors {
+			if errors.Is(err, e.err) {
+				c.JSON(e.status, gin.H{"error": e.message, "token_status": e.state})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("トークンの検証に失敗しました: %v", err)})
 		return
 	}
 
 	result := gin.H{
 		"message":   "生存確認が完了しました",
-		"user_id":   claims.UserID,
-		"clerk_id":  claims.ClerkID,
-		"email":     claims.Email,
+		"user_id":   token.UserID.String(),
 		"validated": true,
 	}
 
-	// Disclosureのステータスを更新（生存確認されたので開示申請を却下）
-	if req.DisclosureID > 0 {
-		fmt.Printf("受信したDisclosureID: %d\n", req.DisclosureID) // デバッグ出力
-
-		// Disclosureを取得
-		disclosure, err := h.queries.GetDisclosure(c, req.DisclosureID)
-		if err != nil {
-			fmt.Printf("GetDisclosure失敗 ID=%d: %v\n", req.DisclosureID, err) // デバッグ出力
-			result["disclosure_status"] = fmt.Sprintf("開示申請が見つかりません: %v", err)
-			c.JSON(http.StatusOK, result)
-			return
-		}
-
-		fmt.Printf("取得したDisclosure: %+v\n", disclosure) // デバッグ出力
-
-		// パッサーIDが一致するか確認 (セキュリティチェック)
-		if disclosure.PasserID.String() != claims.UserID {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "このリクエストを確認する権限がありません"})
-			return
-		}
-
-		// Disclosureが処理中か確認
-		if !disclosure.InProgress {
-			result["disclosure_status"] = "この開示申請はすでに処理されています"
-			c.JSON(http.StatusOK, result)
-			return
-		}
-
-		// パッサーIDをUUIDに変換
-		passerUUID, err := uuid.Parse(claims.UserID)
-		if err != nil {
-			result["disclosure_status"] = fmt.Sprintf("UUIDの解析に失敗しました: %v", err)
-			c.JSON(http.StatusOK, result)
-			return
-		}
-
-		// PostgreSQL UUIDに変換
-		var passerPgUUID pgtype.UUID
-		err = passerPgUUID.Scan(passerUUID.String())
-		if err != nil {
-			result["disclosure_status"] = fmt.Sprintf("UUID変換に失敗しました: %v", err)
-			c.JSON(http.StatusOK, result)
-			return
-		}
-
-		// 現在時刻を取得
-		now := time.Now()
-		var pgNow pgtype.Timestamp
-		err = pgNow.Scan(now)
-		if err != nil {
-			result["disclosure_status"] = fmt.Sprintf("タイムスタンプ変換に失敗しました: %v", err)
-			c.JSON(http.StatusOK, result)
-			return
-		}
-
-		// 新しいUUIDを生成
-		newUUID := uuid.New()
-		var newPgUUID pgtype.UUID
-		err = newPgUUID.Scan(newUUID.String())
-		if err != nil {
-			result["disclosure_status"] = fmt.Sprintf("UUID生成に失敗しました: %v", err)
-			c.JSON(http.StatusOK, result)
+	// 開示請求のないリンクは定期的な生存確認として扱い、次の確認を先に延ばす
+	if verification.Purpose(token.Purpose) != verification.PurposeDisclosure {
+		schedule, err := h.checks.CheckIn(c.Request.Context(), token.UserID, service.AliveCheckMethodMagicLink)
+		if err == nil {
+			result["next_check_at"] = schedule.NextCheckAt.Time.Format(time.RFC3339)
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("定期的な生存確認の更新に失敗しました: %v", err)})
 			return
 		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
 
-		// 生存確認履歴を追加
-		aliveCheckParams := query.CreateAliveCheckHistoryParams{
-			ID:           newPgUUID,
-			TargetUserID: passerPgUUID,
-			CheckMethod:  1, // マジックリンクによる確認
-			CheckTime:    pgNow,
-			CustomData:   []byte("{}"),
-		}
+	// Disclosureのステータスを更新（生存確認されたので開示申請を却下）
+	disclosure, err := h.queries.GetDisclosure(c.Request.Context(), token.DisclosureID.Int32)
+	if errors.Is(err, pgx.ErrNoRows) {
+		result["disclosure_status"] = "開示申請が見つかりません"
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("開示申請の取得に失敗しました: %v", err)})
+		return
+	}
 
-		aliveCheckHistory, err := h.queries.CreateAliveCheckHistory(c, aliveCheckParams)
-		if err != nil {
-			fmt.Printf("生存確認履歴の追加に失敗: %v\n", err)
-			result["disclosure_status"] = fmt.Sprintf("生存確認履歴の追加に失敗しました: %v", err)
-			c.JSON(http.StatusOK, result)
-			return
-		}
+	// パッサーIDが一致するか確認 (セキュリティチェック)
+	if disclosure.PasserID != token.UserID {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "このリクエストを確認する権限がありません"})
+		return
+	}
 
-		fmt.Printf("生存確認履歴を追加しました: %+v\n", aliveCheckHistory)
+	// 生存確認の履歴を残して開示申請を却下
+	_, err = h.disclosures.Prevent(c.Request.Context(), disclosure, service.AliveCheckMethodMagicLink)
+	switch {
+	case errors.Is(err, service.ErrDisclosureNotPending):
+		result["disclosure_status"] = "この開示申請はすでに処理されています"
+	case err != nil:
+		log.Printf("failed to prevent disclosure %d: %v", disclosure.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "開示申請の却下に失敗しました。もう一度リンクを開いてください"})
+		return
+	default:
+		result["disclosure_status"] = "生存確認により開示申請を却下しました"
+	}
 
-		// 更新パラメータの構築 - 必ずdisclosureから取得したRequesterIDを使う
-		updateParams := query.UpdateDisclosureParams{
-			ID:          disclosure.ID,
-			RequesterID: disclosure.RequesterID, // ここが重要: WHERE句の条件に必要
-			PasserID:    disclosure.PasserID,
-			Disclosed:   false,
-			Deadline:    disclosure.Deadline,
-			PreventedBy: aliveCheckHistory.ID, // 追加した生存確認履歴のIDを使用
-			CustomData:  disclosure.CustomData,
-			InProgress:  false,
-		}
+	c.JSON(http.StatusOK, result)
+}
 
-		// デバッグ用にパラメータを表示
-		fmt.Printf("UpdateParams: %+v\n", updateParams)
+// RevokeTokens は自分宛てに送られた、まだ使われていない生存確認のリンクをすべて取り消します
+func (h *VerificationHandler) RevokeTokens(c *gin.Context) {
+	userID, exists := middleware.GetUserIdUUID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証が必要です"})
+		return
+	}
 
-		// 更新実行
-		_, err = h.queries.UpdateDisclosure(c, updateParams)
-		if err != nil {
-			fmt.Printf("UpdateDisclosure失敗: %v\n", err)
-			result["disclosure_status"] = fmt.Sprintf("開示申請の更新に失敗しました: %v", err)
-		} else {
-			result["disclosure_status"] = "生存確認により開示申請を却下しました"
-		}
+	revoked, err := h.tokenManager.RevokeForUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("リンクの取り消しに失敗しました: %v", err)})
+		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, gin.H{"message": "生存確認のリンクを取り消しました", "revoked": revoked})
 }
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/pkg/rls"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errScripted = errors.New("scripted failure")
+
+// scriptedDB は sqlc のクエリ名ごとに文字列の列の値を返す DB。fail に指定したクエリは失敗する。
+// トランザクションがコミットされたかを記録する
+type scriptedDB struct {
+	strings   map[string]string
+	fail      string
+	committed bool
+}
+
+// queryName は sqlc が付ける "-- name: X :one" から X を取り出す
+func queryName(sql string) string {
+	fields := strings.Fields(strings.TrimPrefix(sql, "-- name:"))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func (d *scriptedDB) Exec(_ context.Context, sql string, _ ...interface{}) (pgconn.CommandTag, error) {
+	if queryName(sql) == d.fail {
+		return pgconn.CommandTag{}, errScripted
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (d *scriptedDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return emptyRows{}, nil
+}
+
+func (d *scriptedDB) QueryRow(_ context.Context, sql string, _ ...interface{}) pgx.Row {
+	name := queryName(sql)
+	if name == d.fail {
+		return errorRow{err: errScripted}
+	}
+	return scriptedRow{str: d.strings[name]}
+}
+
+func (d *scriptedDB) Begin(context.Context) (pgx.Tx, error) {
+	return scriptedTx{db: d}, nil
+}
+
+type scriptedTx struct {
+	pgx.Tx
+	db *scriptedDB
+}
+
+func (t scriptedTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.db.Exec(ctx, sql, args...)
+}
+
+func (t scriptedTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return t.db.Query(ctx, sql, args...)
+}
+
+func (t scriptedTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return t.db.QueryRow(ctx, sql, args...)
+}
+
+func (t scriptedTx) Commit(context.Context) error {
+	t.db.committed = true
+	return nil
+}
+
+func (scriptedTx) Rollback(context.Context) error { return nil }
+
+// scriptedRow は UUID を owner、ID を 1、文字列を str、時刻を1時間後にした行
+type scriptedRow struct {
+	str string
+}
+
+func (r scriptedRow) Scan(dest ...interface{}) error {
+	for _, d := range dest {
+		switch v := d.(type) {
+		case *pgtype.UUID:
+			*v = owner
+		case *int32:
+			*v = 1
+		case *pgtype.Int4:
+			*v = pgtype.Int4{Int32: 1, Valid: true}
+		case *string:
+			*v = r.str
+		case *pgtype.Timestamp:
+			*v = pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true}
+		}
+	}
+	return nil
+}
+
+type errorRow struct {
+	err error
+}
+
+func (r errorRow) Scan(...interface{}) error { return r.err }
+
+// TestVerifyTokenRollsBackWhenPreventFails は開示請求を却下できなかったとき、
+// トークンの使用も含めてロールバックし、エラーを返すことを確かめます
+func TestVerifyTokenRollsBackWhenPreventFails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		fail          string
+		wantStatus    int
+		wantCommitted bool
+	}{
+		{
+			name:          "Commits token and veto together",
+			wantStatus:    http.StatusOK,
+			wantCommitted: true,
+		},
+		{
+			name:       "Disclosure cannot be read",
+			fail:       "GetDisclosure",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Alive check cannot be recorded",
+			fail:       "CreateAliveCheckHistory",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Disclosure cannot be vetoed",
+			fail:       "TransitionDisclosure",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Other links cannot be revoked",
+			fail:       "RevokeAliveCheckTokensByDisclosureId",
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &scriptedDB{
+				strings: map[string]string{
+					"ConsumeAliveCheckToken": "disclosure",
+					"GetDisclosure":          service.DisclosureStatusGrace,
+					"TransitionDisclosure":   service.DisclosureStatusVetoed,
+				},
+				fail: tt.fail,
+			}
+			// リクエストのトランザクションの外で実行したクエリは ErrNoTransaction で失敗する
+			q := query.New(rls.NewDB())
+			h := NewVerificationHandler(q, nil, service.NewDisclosureService(q, nil, nil))
+
+			router := gin.New()
+			router.POST("/api/verify/token", middleware.AsSystem(db), h.VerifyToken)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/verify/token", strings.NewReader(`{"token":"t"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if db.committed != tt.wantCommitted {
+				t.Errorf("committed = %v, want %v", db.committed, tt.wantCommitted)
+			}
+		})
+	}
+}
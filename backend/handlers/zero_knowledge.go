@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// パスワードの暗号化方式
+const (
+	// EncryptionModeServer は crypto サービスがパスワードを暗号化する従来の方式
+	EncryptionModeServer = "server"
+	// EncryptionModeZeroKnowledge はクライアントが暗号化し、サーバーは平文を受け取らない方式
+	EncryptionModeZeroKnowledge = "zero_knowledge"
+)
+
+// maxZeroKnowledgeBlobSize はクライアントから受け取る暗号文1つあたりの上限
+const maxZeroKnowledgeBlobSize = 64 << 10
+
+// ZeroKnowledgeSecret はクライアントが暗号化したパスワード。サーバーは中身を解釈しない。
+// アイテム鍵でパスワードを暗号化し、その鍵をパスフレーズから導出した鍵と受取人の公開鍵でそれぞれ包む
+type ZeroKnowledgeSecret struct {
+	// クライアントが決める暗号方式 (例: argon2id+aes-256-gcm/v1)
+	Format string `json:"format" validate:"required"`
+	// アイテム鍵で暗号化したパスワード
+	Ciphertext []byte `json:"ciphertext" validate:"required" swaggertype:"string" format:"byte"`
+	// パスフレーズから導出した鍵で包んだアイテム鍵
+	OwnerKeyEnvelope []byte `json:"ownerKeyEnvelope" validate:"required" swaggertype:"string" format:"byte"`
+	// 受取人の公開鍵で包んだアイテム鍵。受取人には開示後に自分の分だけを返す
+	ReceiverEnvelopes []ZeroKnowledgeEnvelope `json:"receiverEnvelopes"`
+}
+
+type ZeroKnowledgeEnvelope struct {
+	ReceiverUserID string `json:"receiverUserID" validate:"required"`
+	Envelope       []byte `json:"envelope" validate:"required" swaggertype:"string" format:"byte"`
+}
+
+// accountEncryptionMode はリクエストの暗号化方式を検証します。省略時は server
+func accountEncryptionMode(req AccountCreateRequest) (string, error) {
+	switch req.EncryptionMode {
+	case "", EncryptionModeServer:
+		if req.ZeroKnowledge != nil {
+			return "", errors.New("zeroKnowledge は encryptionMode が zero_knowledge のときのみ指定できます")
+		}
+		return EncryptionModeServer, nil
+	case EncryptionModeZeroKnowledge:
+		if req.Password != "" {
+			return "", errors.New("ゼロ知識モードではパスワードをサーバーに送らないでください")
+		}
+		if err := validateZeroKnowledgeSecret(req.ZeroKnowledge); err != nil {
+			return "", err
+		}
+		return EncryptionModeZeroKnowledge, nil
+	default:
+		return "", fmt.Errorf("encryptionMode は %s か %s を指定してください", EncryptionModeServer, EncryptionModeZeroKnowledge)
+	}
+}
+
+func validateZeroKnowledgeSecret(secret *ZeroKnowledgeSecret) error {
+	if secret == nil {
+		return errors.New("ゼロ知識モードでは zeroKnowledge が必須です")
+	}
+	if secret.Format == "" {
+		return errors.New("zeroKnowledge.format は必須です")
+	}
+	blobs := [][]byte{secret.Ciphertext, secret.OwnerKeyEnvelope}
+	for _, envelope := range secret.ReceiverEnvelopes {
+		blobs = append(blobs, envelope.Envelope)
+	}
+	for _, blob := range blobs {
+		if len(blob) == 0 || len(blob) > maxZeroKnowledgeBlobSize {
+			return fmt.Errorf("暗号文は1バイト以上 %d バイト以下にしてください", maxZeroKnowledgeBlobSize)
+		}
+	}
+	return nil
+}
+
+// zeroKnowledgeReceivers は受取人ごとの鍵がパッサーの受取人宛てで、
+// trustIDs の相続関係でログイン情報まで託す受取人の分が含まれていることを確かめ、受取人IDを返します
+func (h *AccountsHandler) zeroKnowledgeReceivers(ctx context.Context, passerID pgtype.UUID, trustIDs []int32, secret *ZeroKnowledgeSecret) ([]pgtype.UUID, error) {
+	receivers, err := h.queries.ListReceiverIDsByPasserID(ctx, passerID)
+	if err != nil {
+		return nil, fmt.Errorf("受取人一覧の取得に失敗しました: %w", err)
+	}
+	known := make(map[pgtype.UUID]bool, len(receivers))
+	for _, receiver := range receivers {
+		known[receiver] = true
+	}
+
+	receiverIDs := make([]pgtype.UUID, len(secret.ReceiverEnvelopes))
+	seen := map[pgtype.UUID]bool{}
+	for i, envelope := range secret.ReceiverEnvelopes {
+		receiverID, err := toPGUUID(envelope.ReceiverUserID)
+		if err != nil {
+			return nil, fmt.Errorf("受取人IDが不正です: %w", err)
+		}
+		if !known[receiverID] {
+			return nil, fmt.Errorf("%s はパッサーの受取人ではありません", envelope.ReceiverUserID)
+		}
+		if seen[receiverID] {
+			return nil, fmt.Errorf("受取人 %s の鍵が重複しています", envelope.ReceiverUserID)
+		}
+		seen[receiverID] = true
+		receiverIDs[i] = receiverID
+	}
+	if err := h.requireZeroKnowledgeReceivers(ctx, passerID, trustIDs, seen); err != nil {
+		return nil, err
+	}
+	return receiverIDs, nil
+}
+
+// checkZeroKnowledgeReceivers は保存済みの受取人ごとの鍵に、
+// trustIDs の相続関係でログイン情報まで託す受取人の分が含まれていることを確かめます
+func (h *AccountsHandler) checkZeroKnowledgeReceivers(ctx context.Context, account query.Account, trustIDs []int32) error {
+	envelopes, err := h.queries.ListZkKeyEnvelopesByAccountId(ctx, account.ID)
+	if err != nil {
+		return fmt.Errorf("受取人ごとの鍵の取得に失敗しました: %w", err)
+	}
+	seen := make(map[pgtype.UUID]bool, len(envelopes))
+	for _, envelope := range envelopes {
+		seen[envelope.ReceiverUserID] = true
+	}
+	return h.requireZeroKnowledgeReceivers(ctx, account.PasserID, trustIDs, seen)
+}
+
+// requireZeroKnowledgeReceivers は trustIDs の受取人がすべて seen に含まれていることを確かめます
+func (h *AccountsHandler) requireZeroKnowledgeReceivers(ctx context.Context, passerID pgtype.UUID, trustIDs []int32, seen map[pgtype.UUID]bool) error {
+	for _, trustID := range trustIDs {
+		trust, err := h.queries.GetTrust(ctx, trustID)
+		if err != nil {
+			return fmt.Errorf("信頼関係 %d が見つかりません: %w", trustID, err)
+		}
+		if trust.PasserUserID != passerID {
+			return fmt.Errorf("信頼関係 %d はパッサーのものではありません", trustID)
+		}
+		if !seen[trust.ReceiverUserID] {
+			return fmt.Errorf("受取人 %s 向けの鍵がありません", trust.ReceiverUserID.String())
+		}
+	}
+	return nil
+}
+
+// saveZeroKnowledgeSecret はクライアントが暗号化したパスワードと受取人ごとの鍵を保存し直します
+func (h *AccountsHandler) saveZeroKnowledgeSecret(ctx context.Context, accountID int32, secret *ZeroKnowledgeSecret, receiverIDs []pgtype.UUID) error {
+	if _, err := h.queries.UpsertZkAccountSecret(ctx, query.UpsertZkAccountSecretParams{
+		AccountID:        accountID,
+		Format:           secret.Format,
+		Ciphertext:       secret.Ciphertext,
+		OwnerKeyEnvelope: secret.OwnerKeyEnvelope,
+	}); err != nil {
+		return err
+	}
+	// 受取人を外したときに古い鍵が残らないよう、まとめて入れ替える
+	if err := h.queries.DeleteZkKeyEnvelopesByAccountId(ctx, accountID); err != nil {
+		return err
+	}
+	for i, envelope := range secret.ReceiverEnvelopes {
+		if err := h.queries.UpsertZkKeyEnvelope(ctx, query.UpsertZkKeyEnvelopeParams{
+			AccountID:      accountID,
+			ReceiverUserID: receiverIDs[i],
+			Envelope:       envelope.Envelope,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropZeroKnowledgeSecret はサーバー側の暗号化に戻したアカウントのクライアント暗号文を削除します
+func (h *AccountsHandler) dropZeroKnowledgeSecret(ctx context.Context, accountID int32) error {
+	if err := h.queries.DeleteZkKeyEnvelopesByAccountId(ctx, accountID); err != nil {
+		return err
+	}
+	return h.queries.DeleteZkAccountSecret(ctx, accountID)
+}
+
+// ownZeroKnowledgeSecrets はパッサー本人に返すクライアント暗号文をアカウントIDごとにまとめます
+func (h *AccountsHandler) ownZeroKnowledgeSecrets(ctx context.Context, passerID pgtype.UUID) (map[int32]*ZeroKnowledgeSecret, error) {
+	secrets, err := h.queries.ListZkAccountSecretsByPasserId(ctx, passerID)
+	if err != nil {
+		return nil, err
+	}
+	envelopes, err := h.queries.ListZkKeyEnvelopesByPasserId(ctx, passerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int32]*ZeroKnowledgeSecret, len(secrets))
+	for _, secret := range secrets {
+		result[secret.AccountID] = &ZeroKnowledgeSecret{
+			Format:            secret.Format,
+			Ciphertext:        secret.Ciphertext,
+			OwnerKeyEnvelope:  secret.OwnerKeyEnvelope,
+			ReceiverEnvelopes: []ZeroKnowledgeEnvelope{},
+		}
+	}
+	for _, envelope := range envelopes {
+		if secret, ok := result[envelope.AccountID]; ok {
+			secret.ReceiverEnvelopes = append(secret.ReceiverEnvelopes, ZeroKnowledgeEnvelope{
+				ReceiverUserID: envelope.ReceiverUserID.String(),
+				Envelope:       envelope.Envelope,
+			})
+		}
+	}
+	return result, nil
+}
+
+// disclosedZeroKnowledgeSecrets は受取人に返すクライアント暗号文をアカウントIDごとにまとめます。
+// ログイン情報まで見せる割り当てで開示が済んだアカウントの、受取人自身の鍵だけを含める。パスフレーズで包んだ鍵は返さない
+func disclosedZeroKnowledgeSecrets(ctx context.Context, q *query.Queries, grants *service.DecryptGrantService, receiverID pgtype.UUID, rows []query.ListDisclosedAccountsByReceiverIdRow) (map[int32]*ZeroKnowledgeSecret, error) {
+	var zkAccounts []query.ListDisclosedAccountsByReceiverIdRow
+	for _, row := range rows {
+		if row.Account.EncryptionMode == EncryptionModeZeroKnowledge {
+			zkAccounts = append(zkAccounts, row)
+		}
+	}
+	if len(zkAccounts) == 0 {
+		return map[int32]*ZeroKnowledgeSecret{}, nil
+	}
+
+	disclosed, err := grants.DisclosedAccountIDs(ctx, receiverID, zkAccounts)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := q.ListDisclosedZkAccountSecretsByReceiverId(ctx, receiverID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int32]*ZeroKnowledgeSecret, len(secrets))
+	for _, secret := range secrets {
+		if !disclosed[secret.AccountID] {
+			continue
+		}
+		result[secret.AccountID] = &ZeroKnowledgeSecret{
+			Format:     secret.Format,
+			Ciphertext: secret.Ciphertext,
+			ReceiverEnvelopes: []ZeroKnowledgeEnvelope{{
+				ReceiverUserID: receiverID.String(),
+				Envelope:       secret.Envelope,
+			}},
+		}
+	}
+	return result, nil
+}
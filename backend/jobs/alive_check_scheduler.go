@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
+)
+
+const defaultAliveCheckSchedulerBatchSize = 100
+
+// AliveCheckScheduler はパッサーが設定した定期的な生存確認を送り、猶予までに確認がなければ開示請求を出す
+type AliveCheckScheduler struct {
+	queries   *query.Queries
+	checks    *service.AliveCheckService
+	interval  time.Duration
+	batchSize int32
+}
+
+// NewAliveCheckScheduler は新しいスケジューラーを作成します
+func NewAliveCheckScheduler(q *query.Queries, checks *service.AliveCheckService, interval time.Duration) *AliveCheckScheduler {
+	return &AliveCheckScheduler{queries: q, checks: checks, interval: interval, batchSize: defaultAliveCheckSchedulerBatchSize}
+}
+
+// Run は ctx が終わるまで interval ごとに Tick を呼び出します
+func (s *AliveCheckScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil {
+			log.Printf("alive check scheduler: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick は猶予を過ぎた確認に開示請求を出し、時期が来た確認を送ります。
+// 1人のパッサーの失敗で他のパッサーの処理は止めない
+func (s *AliveCheckScheduler) Tick(ctx context.Context) error {
+	missed, err := s.queries.ListMissedAliveCheckSchedules(ctx, s.batchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list missed alive checks: %w", err)
+	}
+	for _, schedule := range missed {
+		err := s.checks.Miss(ctx, schedule)
+		if errors.Is(err, service.ErrNoDefaultReceiver) {
+			log.Printf("alive check scheduler: passer %s missed an alive check but has no default receiver", schedule.UserID.String())
+			continue
+		}
+		if err != nil {
+			log.Printf("alive check scheduler: passer %s: %v", schedule.UserID.String(), err)
+		}
+	}
+
+	due, err := s.queries.ListDueAliveCheckSchedules(ctx, s.batchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list due alive checks: %w", err)
+	}
+	for _, schedule := range due {
+		if err := s.checks.Send(ctx, schedule); err != nil {
+			log.Printf("alive check scheduler: passer %s: %v", schedule.UserID.String(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const defaultDisclosureSchedulerBatchSize = 100
+
+// txBeginner はトランザクションを開始できる接続。*pgxpool.Pool が満たす
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// DisclosureScheduler は期限までに止められなかった開示請求を開示する。
+// パッサーごとに advisory lock を取るので、複数のレプリカで動かしても同じパッサーを同時に処理しない
+type DisclosureScheduler struct {
+	db            txBeginner
+	queries       *query.Queries
+	disclosures   *service.DisclosureService
+	notifications *service.NotificationService
+	interval      time.Duration
+	batchSize     int32
+	// overdueAfter は期限を過ぎた開示請求を前回どこまで見たか (passer_id, id)。
+	// 人数が揃わない請求やロックを取れなかった請求が残っても、後ろのパッサーまで順に回る
+	overdueAfter query.Disclosure
+}
+
+// overdueStart は期限を過ぎた開示請求の一覧を先頭から読むカーソル。NULL と比べると何も返らないので Valid にする
+var overdueStart = query.Disclosure{PasserID: pgtype.UUID{Valid: true}}
+
+// NewDisclosureScheduler は新しいスケジューラーを作成します
+func NewDisclosureScheduler(db txBeginner, q *query.Queries, disclosures *service.DisclosureService, notifications *service.NotificationService, interval time.Duration) *DisclosureScheduler {
+	return &DisclosureScheduler{
+		db:            db,
+		queries:       q,
+		disclosures:   disclosures,
+		notifications: notifications,
+		interval:      interval,
+		batchSize:     defaultDisclosureSchedulerBatchSize,
+		overdueAfter:  overdueStart,
+	}
+}
+
+// Run は ctx が終わるまで interval ごとに Tick を呼び出します
+func (s *DisclosureScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil {
+			log.Printf("disclosure scheduler: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick は受取人が変わったパッサーの金庫の鍵のシェアを配り直してから、期限を過ぎた開示請求をパッサーごとにまとめて処理し、
+// 開示済みなのに引き渡せていないものを引き渡し直します。
+// 期限を過ぎた開示請求は1回に batchSize 件ずつ、前回の続きから読み、最後まで読んだら先頭に戻る。
+// 1人のパッサーの失敗で他のパッサーの処理は止めない。Run の1つのゴルーチンから呼び出す
+func (s *DisclosureScheduler) Tick(ctx context.Context) error {
+	if err := s.resplitVaults(ctx); err != nil {
+		log.Printf("disclosure scheduler: %v", err)
+	}
+
+	overdue, err := s.queries.ListOverdueDisclosures(ctx, query.ListOverdueDisclosuresParams{
+		PasserID: s.overdueAfter.PasserID,
+		ID:       s.overdueAfter.ID,
+		Limit:    s.batchSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list overdue disclosures: %w", err)
+	}
+	if len(overdue) < int(s.batchSize) {
+		s.overdueAfter = overdueStart
+	} else {
+		s.overdueAfter = overdue[len(overdue)-1]
+	}
+
+	// 一覧はパッサー順に並んでいる
+	for start := 0; start < len(overdue); {
+		end := start + 1
+		for end < len(overdue) && overdue[end].PasserID == overdue[start].PasserID {
+			end++
+		}
+		if err := s.processPasser(ctx, overdue[start].PasserID, overdue[start:end]); err != nil {
+			log.Printf("disclosure scheduler: passer %s: %v", overdue[start].PasserID.String(), err)
+		}
+		start = end
+	}
+	return s.retryReleases(ctx)
+}
+
+// retryReleases は開示済みなのに引き渡せていない割り当てが残る開示請求を、パッサーのロックを取って引き渡し直します。
+// 引き渡せるまで毎回やり直す
+func (s *DisclosureScheduler) retryReleases(ctx context.Context) error {
+	unreleased, err := s.queries.ListUnreleasedDisclosures(ctx, s.batchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list disclosures with unreleased items: %w", err)
+	}
+	for _, disclosure := range unreleased {
+		err := s.withPasserLock(ctx, disclosure.PasserID, func() error {
+			return s.disclosures.Release(ctx, disclosure)
+		})
+		if err != nil {
+			log.Printf("disclosure scheduler: failed to release disclosure %d: %v", disclosure.ID, err)
+		}
+	}
+	return nil
+}
+
+// resplitVaults はしきい値開示のシェアを配った後に受取人が変わったパッサーの金庫を、パッサーのロックを取って作り直します。
+// 受取人が招待を辞退したときなど、パッサーの操作によらない変更を拾う
+func (s *DisclosureScheduler) resplitVaults(ctx context.Context) error {
+	stale, err := s.queries.ListStaleDisclosurePolicies(ctx, s.batchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list disclosure policies to resplit: %w", err)
+	}
+	for _, policy := range stale {
+		err := s.withPasserLock(ctx, policy.PasserID, func() error {
+			_, err := s.disclosures.ResplitPolicy(ctx, policy.PasserID)
+			return err
+		})
+		if err != nil {
+			log.Printf("disclosure scheduler: failed to resplit vault of passer %s: %v", policy.PasserID.String(), err)
+		}
+	}
+	return nil
+}
+
+// withPasserLock はパッサーのロックを取れたときだけ fn を呼び出します。
+// ロックはトランザクションが終わるまで持ち、他のレプリカが取れなければそちらは何もしない
+func (s *DisclosureScheduler) withPasserLock(ctx context.Context, passerID pgtype.UUID, fn func() error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := s.queries.WithTx(tx).TryLockPasserDisclosures(ctx, passerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("passer does not exist")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock passer: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+	// 処理はプール経由で確定済み。コミットはロックを外すためだけに行う
+	return tx.Commit(ctx)
+}
+
+// processPasser はパッサーのロックを取ったうえで、1人のパッサーの開示請求を開示します
+func (s *DisclosureScheduler) processPasser(ctx context.Context, passerID pgtype.UUID, overdue []query.Disclosure) error {
+	return s.withPasserLock(ctx, passerID, func() error {
+		return s.discloseLocked(ctx, overdue)
+	})
+}
+
+// discloseLocked はロックを取った1人のパッサーの開示請求を開示し、請求者に知らせます
+func (s *DisclosureScheduler) discloseLocked(ctx context.Context, overdue []query.Disclosure) error {
+	// ロックを待つ間に他のレプリカが処理し終えたものは除く
+	var pending []int32
+	for _, disclosure := range overdue {
+		current, err := s.queries.GetDisclosure(ctx, disclosure.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get disclosure %d: %w", disclosure.ID, err)
+		}
+		if service.IsPending(current) {
+			pending = append(pending, current.ID)
+		}
+	}
+
+	for _, id := range pending {
+		s.discloseOverdue(ctx, id)
+	}
+
+	// しきい値開示では1件の開示で同じパッサーの他の請求もまとめて開示されるので、結果は読み直して知らせる
+	for _, id := range pending {
+		disclosure, err := s.queries.GetDisclosure(ctx, id)
+		if err != nil {
+			log.Printf("disclosure scheduler: failed to get disclosure %d: %v", id, err)
+			continue
+		}
+		if disclosure.Status != service.DisclosureStatusDisclosed {
+			continue
+		}
+		log.Printf("disclosure scheduler: disclosed %d after its deadline", id)
+		if err := s.notifications.NotifyDisclosed(ctx, disclosure); err != nil {
+			log.Printf("disclosure scheduler: failed to notify requester of disclosure %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// discloseOverdue は1件の開示請求を開示します。
+// 請求者がもう受取人でなければ開示せずに expired にする。
+// しきい値開示で人数が揃っていなければ、揃うまで開示請求中のままにする
+func (s *DisclosureScheduler) discloseOverdue(ctx context.Context, id int32) {
+	// 同じパッサーの前の請求と一緒に開示済みになっていれば何もしない
+	disclosure, err := s.queries.GetDisclosure(ctx, id)
+	if err != nil {
+		log.Printf("disclosure scheduler: failed to get disclosure %d: %v", id, err)
+		return
+	}
+	if !service.IsPending(disclosure) {
+		return
+	}
+
+	isReceiver, err := s.queries.IsReceiverOfPasser(ctx, query.IsReceiverOfPasserParams{
+		PasserUserID:   disclosure.PasserID,
+		ReceiverUserID: disclosure.RequesterID,
+	})
+	if err != nil {
+		log.Printf("disclosure scheduler: failed to check receiver of disclosure %d: %v", id, err)
+		return
+	}
+	if !isReceiver {
+		expired, err := s.disclosures.Transition(ctx, disclosure, service.DisclosureStatusExpired, service.DisclosureActorSystem, pgtype.UUID{})
+		if err != nil && expired.Status != service.DisclosureStatusExpired {
+			log.Printf("disclosure scheduler: failed to expire disclosure %d: %v", id, err)
+			return
+		}
+		if err != nil {
+			log.Printf("disclosure scheduler: disclosure %d: %v", id, err)
+		}
+		log.Printf("disclosure scheduler: expired %d because its requester is no longer a receiver", id)
+		return
+	}
+
+	// 引き渡せなかったものがあっても開示自体は確定し、請求者には知らせる。
+	// 引き渡せなかったものは retryReleases が引き渡し直す
+	if _, err := s.disclosures.Disclose(ctx, disclosure); err != nil && !errors.Is(err, service.ErrQuorumNotReached) {
+		log.Printf("disclosure scheduler: disclosure %d: %v", id, err)
+	}
+}
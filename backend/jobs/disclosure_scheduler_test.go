@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// queryName は sqlc が付ける "-- name: X :one" から X を取り出す
+func queryName(sql string) string {
+	fields := strings.Fields(strings.TrimPrefix(sql, "-- name:"))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// lockedOutDB は期限を過ぎた開示請求を overdue から返し、パッサーのロックはすべて他のレプリカが持っているように振る舞う。
+// ロックを取ろうとしたパッサーを記録する
+type lockedOutDB struct {
+	overdue []query.Disclosure
+	tried   []pgtype.UUID
+}
+
+func (d *lockedOutDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.NewCommandTag("UPDATE 0"), nil
+}
+
+func (d *lockedOutDB) Query(_ context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if queryName(sql) != "ListOverdueDisclosures" {
+		return &disclosureRows{}, nil
+	}
+	afterPasser, afterID, limit := args[0].(pgtype.UUID), args[1].(int32), args[2].(int32)
+	var rows []query.Disclosure
+	for _, disclosure := range d.overdue {
+		c := bytes.Compare(disclosure.PasserID.Bytes[:], afterPasser.Bytes[:])
+		if c < 0 || (c == 0 && disclosure.ID <= afterID) {
+			continue
+		}
+		if len(rows) == int(limit) {
+			break
+		}
+		rows = append(rows, disclosure)
+	}
+	return &disclosureRows{rows: rows}, nil
+}
+
+func (d *lockedOutDB) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	if queryName(sql) == "TryLockPasserDisclosures" {
+		d.tried = append(d.tried, args[0].(pgtype.UUID))
+	}
+	return boolRow(false)
+}
+
+func (d *lockedOutDB) Begin(context.Context) (pgx.Tx, error) {
+	return lockedOutTx{db: d}, nil
+}
+
+type lockedOutTx struct {
+	pgx.Tx
+	db *lockedOutDB
+}
+
+func (t lockedOutTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return t.db.QueryRow(ctx, sql, args...)
+}
+
+func (lockedOutTx) Rollback(context.Context) error { return nil }
+
+type boolRow bool
+
+func (r boolRow) Scan(dest ...interface{}) error {
+	for _, d := range dest {
+		if v, ok := d.(*bool); ok {
+			*v = bool(r)
+		}
+	}
+	return nil
+}
+
+// disclosureRows は disclosures.* の列順で ID と PasserID だけを返す
+type disclosureRows struct {
+	pgx.Rows
+	rows []query.Disclosure
+	next int
+}
+
+func (r *disclosureRows) Close()     {}
+func (r *disclosureRows) Err() error { return nil }
+
+func (r *disclosureRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *disclosureRows) Scan(dest ...interface{}) error {
+	row := r.rows[r.next-1]
+	*dest[0].(*int32) = row.ID
+	*dest[2].(*pgtype.UUID) = row.PasserID
+	return nil
+}
+
+func testPasser(b byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{15: b}, Valid: true}
+}
+
+// TestDisclosureSchedulerTickPagesThroughOverdue は処理できずに残った開示請求があっても、
+// Tick が前回の続きから読み進め、後ろのパッサーまで回ってから先頭に戻ることを確かめます
+func TestDisclosureSchedulerTickPagesThroughOverdue(t *testing.T) {
+	db := &lockedOutDB{}
+	for i := byte(1); i <= 5; i++ {
+		db.overdue = append(db.overdue, query.Disclosure{ID: int32(i), PasserID: testPasser(i)})
+	}
+	q := query.New(db)
+	s := NewDisclosureScheduler(db, q, service.NewDisclosureService(q, nil, nil), nil, time.Minute)
+	s.batchSize = 2
+
+	tests := []struct {
+		name string
+		want []byte
+	}{
+		{name: "First page", want: []byte{1, 2}},
+		{name: "Continues after the skipped passers", want: []byte{3, 4}},
+		{name: "Last page", want: []byte{5}},
+		{name: "Wraps around to the start", want: []byte{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.tried = nil
+			if err := s.Tick(context.Background()); err != nil {
+				t.Fatalf("Tick() error = %v", err)
+			}
+			if len(db.tried) != len(tt.want) {
+				t.Fatalf("tried %d passers, want %v", len(db.tried), tt.want)
+			}
+			for i, passer := range db.tried {
+				if passer != testPasser(tt.want[i]) {
+					t.Errorf("passer %d = %s, want %s", i, passer.String(), testPasser(tt.want[i]).String())
+				}
+			}
+		})
+	}
+}
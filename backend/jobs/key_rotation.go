@@ -0,0 +1,310 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ジョブの状態
+const (
+	KeyRotationStatusRunning   = "running"
+	KeyRotationStatusCompleted = "completed"
+	KeyRotationStatusFailed    = "failed"
+)
+
+// 再暗号化のフェーズ。この順番で処理する
+const (
+	KeyRotationPhaseAccounts      = "accounts"
+	KeyRotationPhaseDevices       = "devices"
+	KeyRotationPhaseSubscriptions = "subscriptions"
+	KeyRotationPhaseDone          = "done"
+)
+
+// ErrKeyRotationRunning は同じユーザーのジョブが既に実行中であることを表す
+var ErrKeyRotationRunning = errors.New("key rotation is already running")
+
+const defaultKeyRotationBatchSize = 50
+
+// rotationItem は再暗号化対象の1行
+type rotationItem struct {
+	id          int32
+	encPassword []byte
+}
+
+// rotationPhase はテーブルごとの一覧取得と更新の方法
+type rotationPhase struct {
+	name   string
+	next   string
+	list   func(ctx context.Context, passerID pgtype.UUID, afterID, limit int32) ([]rotationItem, error)
+	update func(ctx context.Context, id int32, newEnc, oldEnc []byte) (int64, error)
+}
+
+// KeyRotationRunner はユーザー鍵のローテーションと、既存データの再暗号化を行う。
+// 進捗はバッチごとに key_rotation_jobs に保存するため、途中で停止しても再開できる。
+type KeyRotationRunner struct {
+	// ctx はバックグラウンドの再暗号化を動かす context。終わると各ジョブは running のまま止まり、次の起動で再開する
+	ctx          context.Context
+	workers      sync.WaitGroup
+	queries      *query.Queries
+	cryptoClient crypto.EncryptionServiceClient
+	batchSize    int32
+	phases       map[string]rotationPhase
+	running      sync.Map // job id -> struct{}
+}
+
+// NewKeyRotationRunner は新しいランナーを作成します。ctx はシャットダウンでキャンセルする context を渡す
+func NewKeyRotationRunner(ctx context.Context, q *query.Queries, cryptoClient crypto.EncryptionServiceClient) *KeyRotationRunner {
+	r := &KeyRotationRunner{ctx: ctx, queries: q, cryptoClient: cryptoClient, batchSize: defaultKeyRotationBatchSize}
+	r.phases = map[string]rotationPhase{
+		KeyRotationPhaseAccounts: {
+			name: KeyRotationPhaseAccounts,
+			next: KeyRotationPhaseDevices,
+			list: func(ctx context.Context, passerID pgtype.UUID, afterID, limit int32) ([]rotationItem, error) {
+				rows, err := q.ListAccountsForKeyRotation(ctx, query.ListAccountsForKeyRotationParams{PasserID: passerID, ID: afterID, Limit: limit})
+				items := make([]rotationItem, len(rows))
+				for i, row := range rows {
+					items[i] = rotationItem{id: row.ID, encPassword: row.EncPassword}
+				}
+				return items, err
+			},
+			update: func(ctx context.Context, id int32, newEnc, oldEnc []byte) (int64, error) {
+				return q.UpdateAccountEncPassword(ctx, query.UpdateAccountEncPasswordParams{ID: id, EncPassword: newEnc, EncPassword_2: oldEnc})
+			},
+		},
+		KeyRotationPhaseDevices: {
+			name: KeyRotationPhaseDevices,
+			next: KeyRotationPhaseSubscriptions,
+			list: func(ctx context.Context, passerID pgtype.UUID, afterID, limit int32) ([]rotationItem, error) {
+				rows, err := q.ListDevicesForKeyRotation(ctx, query.ListDevicesForKeyRotationParams{PasserID: passerID, ID: afterID, Limit: limit})
+				items := make([]rotationItem, len(rows))
+				for i, row := range rows {
+					items[i] = rotationItem{id: row.ID, encPassword: row.EncPassword}
+				}
+				return items, err
+			},
+			update: func(ctx context.Context, id int32, newEnc, oldEnc []byte) (int64, error) {
+				return q.UpdateDeviceEncPassword(ctx, query.UpdateDeviceEncPasswordParams{ID: id, EncPassword: newEnc, EncPassword_2: oldEnc})
+			},
+		},
+		KeyRotationPhaseSubscriptions: {
+			name: KeyRotationPhaseSubscriptions,
+			next: KeyRotationPhaseDone,
+			list: func(ctx context.Context, passerID pgtype.UUID, afterID, limit int32) ([]rotationItem, error) {
+				rows, err := q.ListSubscriptionsForKeyRotation(ctx, query.ListSubscriptionsForKeyRotationParams{PasserID: passerID, ID: afterID, Limit: limit})
+				items := make([]rotationItem, len(rows))
+				for i, row := range rows {
+					items[i] = rotationItem{id: row.ID, encPassword: row.EncPassword}
+				}
+				return items, err
+			},
+			update: func(ctx context.Context, id int32, newEnc, oldEnc []byte) (int64, error) {
+				return q.UpdateSubscriptionEncPassword(ctx, query.UpdateSubscriptionEncPasswordParams{ID: id, EncPassword: newEnc, EncPassword_2: oldEnc})
+			},
+		},
+	}
+	return r
+}
+
+// Start はユーザーの鍵をローテーションし、再暗号化ジョブをバックグラウンドで開始します。
+// r の *query.Queries はシステム用の接続なので、ジョブはリクエストのトランザクションの外で作られ、バックグラウンドの処理からすぐに見える。
+// 鍵を新しくしたのに再暗号化するジョブがない状態を作らないよう、ジョブを先に作ってから鍵をローテーションする
+func (r *KeyRotationRunner) Start(ctx context.Context, userID pgtype.UUID) (query.KeyRotationJob, error) {
+	latest, err := r.queries.GetLatestKeyRotationJobByUserId(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return query.KeyRotationJob{}, err
+	}
+	if err == nil && latest.Status == KeyRotationStatusRunning {
+		return latest, ErrKeyRotationRunning
+	}
+
+	total, err := r.countItems(ctx, userID)
+	if err != nil {
+		return query.KeyRotationJob{}, fmt.Errorf("failed to count items: %w", err)
+	}
+
+	// 鍵のバージョンはローテーションするまで分からないので 0 で作る。
+	// 実行中のジョブは1ユーザーに1つなので、同時に来たリクエストが鍵を二重にローテーションすることもない
+	job, err := r.queries.CreateKeyRotationJob(ctx, query.CreateKeyRotationJobParams{
+		UserID:     userID,
+		TotalItems: total,
+	})
+	if err != nil {
+		return query.KeyRotationJob{}, fmt.Errorf("failed to create key rotation job: %w", err)
+	}
+
+	rotated, err := r.cryptoClient.RotateUserKey(ctx, &crypto.RotateUserKeyRequest{UserId: userID.String()})
+	if err != nil {
+		err = fmt.Errorf("failed to rotate user key: %w", err)
+		r.fail(context.WithoutCancel(ctx), job.ID, err)
+		return query.KeyRotationJob{}, err
+	}
+
+	job, err = r.queries.SetKeyRotationJobKeyVersion(ctx, query.SetKeyRotationJobKeyVersionParams{
+		ID:         job.ID,
+		KeyVersion: rotated.GetKeyVersion(),
+	})
+	if err != nil {
+		// 鍵は既に新しくなっている。ジョブは running のまま残り、次の起動で再開する
+		return query.KeyRotationJob{}, fmt.Errorf("failed to save key version: %w", err)
+	}
+
+	r.spawn(job)
+	return job, nil
+}
+
+// spawn はジョブをリクエストではなく r.ctx の下で動かします
+func (r *KeyRotationRunner) spawn(job query.KeyRotationJob) {
+	r.workers.Add(1)
+	go func() {
+		defer r.workers.Done()
+		r.run(r.ctx, job)
+	}()
+}
+
+// Wait は r.ctx がキャンセルされた後、バックグラウンドのジョブが進捗を保存して止まるまで待ちます
+func (r *KeyRotationRunner) Wait() {
+	r.workers.Wait()
+}
+
+// Resume は前回のプロセスで途中になったジョブを再開します。起動時に呼び出す
+func (r *KeyRotationRunner) Resume(ctx context.Context) error {
+	jobs, err := r.queries.ListRunningKeyRotationJobs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		log.Printf("resuming key rotation job %d (phase %s, after id %d)", job.ID, job.Phase, job.LastItemID)
+		r.spawn(job)
+	}
+	return nil
+}
+
+func (r *KeyRotationRunner) countItems(ctx context.Context, userID pgtype.UUID) (int32, error) {
+	accounts, err := r.queries.CountAccountsByPasserId(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	devices, err := r.queries.CountDevicesByPasserId(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	subscriptions, err := r.queries.CountSubscriptionsByPasserId(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return int32(accounts + devices + subscriptions), nil
+}
+
+// run はジョブを最後まで進める。各行の失敗は failed_items に数えて処理を続け、
+// DB が使えないなどジョブ自体を続けられない場合のみ failed で終了する
+func (r *KeyRotationRunner) run(ctx context.Context, job query.KeyRotationJob) {
+	// 同じプロセス内で同じジョブを二重に動かさない
+	if _, loaded := r.running.LoadOrStore(job.ID, struct{}{}); loaded {
+		return
+	}
+	defer r.running.Delete(job.ID)
+
+	if err := r.process(ctx, &job); err != nil {
+		if ctx.Err() != nil {
+			// シャットダウンで止めた。running のまま残し、次の起動で Resume が続きから処理する
+			log.Printf("key rotation job %d stopped at phase %s: %v", job.ID, job.Phase, err)
+			return
+		}
+		log.Printf("key rotation job %d failed: %v", job.ID, err)
+		r.fail(ctx, job.ID, err)
+		return
+	}
+
+	if _, err := r.queries.FinishKeyRotationJob(ctx, query.FinishKeyRotationJobParams{
+		ID:        job.ID,
+		Status:    KeyRotationStatusCompleted,
+		LastError: job.LastError,
+	}); err != nil {
+		log.Printf("failed to mark key rotation job %d as completed: %v", job.ID, err)
+	}
+}
+
+// fail はジョブを failed で終了します
+func (r *KeyRotationRunner) fail(ctx context.Context, jobID int32, cause error) {
+	if _, err := r.queries.FinishKeyRotationJob(ctx, query.FinishKeyRotationJobParams{
+		ID:        jobID,
+		Status:    KeyRotationStatusFailed,
+		LastError: pgtype.Text{String: cause.Error(), Valid: true},
+	}); err != nil {
+		log.Printf("failed to mark key rotation job %d as failed: %v", jobID, err)
+	}
+}
+
+func (r *KeyRotationRunner) process(ctx context.Context, job *query.KeyRotationJob) error {
+	userID := job.UserID.String()
+	for job.Phase != KeyRotationPhaseDone {
+		phase, ok := r.phases[job.Phase]
+		if !ok {
+			return fmt.Errorf("unknown phase %q", job.Phase)
+		}
+
+		items, err := phase.list(ctx, job.UserID, job.LastItemID, r.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", phase.name, err)
+		}
+
+		nextPhase, lastItemID := job.Phase, job.LastItemID
+		if len(items) == 0 {
+			// このテーブルは完了。次のテーブルを先頭から処理する
+			nextPhase, lastItemID = phase.next, 0
+		}
+		for _, item := range items {
+			if err := r.reencryptItem(ctx, phase, userID, item); err != nil {
+				job.FailedItems++
+				job.LastError = pgtype.Text{String: fmt.Sprintf("%s %d: %v", phase.name, item.id, err), Valid: true}
+			}
+			job.ProcessedItems++
+			lastItemID = item.id
+		}
+
+		updated, err := r.queries.UpdateKeyRotationJobProgress(ctx, query.UpdateKeyRotationJobProgressParams{
+			ID:             job.ID,
+			Phase:          nextPhase,
+			LastItemID:     lastItemID,
+			ProcessedItems: job.ProcessedItems,
+			FailedItems:    job.FailedItems,
+			LastError:      job.LastError,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save progress: %w", err)
+		}
+		*job = updated
+	}
+	return nil
+}
+
+// reencryptItem は1行を現在の鍵バージョンで暗号化し直す。
+// 既に現在のバージョンであれば何もしない
+func (r *KeyRotationRunner) reencryptItem(ctx context.Context, phase rotationPhase, userID string, item rotationItem) error {
+	if len(item.encPassword) == 0 {
+		return nil
+	}
+	resp, err := r.cryptoClient.Reencrypt(ctx, &crypto.ReencryptRequest{
+		UserId:     userID,
+		Ciphertext: item.encPassword,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.GetReencrypted() {
+		return nil
+	}
+	// 処理中にユーザーが更新した行は上書きしない（新しい値は既に現在の鍵で暗号化されている）
+	if _, err := phase.update(ctx, item.id, resp.GetCiphertext(), item.encPassword); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
+)
+
+const defaultReminderSchedulerBatchSize = 100
+
+// ReminderScheduler は猶予期間中の開示請求について、催促の段階が来たものを送る。
+// 送ったかどうかは disclosure_reminders に残るので、再起動しても複数のレプリカで動かしても二重に送らない
+type ReminderScheduler struct {
+	queries   *query.Queries
+	reminders *service.ReminderService
+	interval  time.Duration
+	batchSize int32
+}
+
+// NewReminderScheduler は新しいスケジューラーを作成します
+func NewReminderScheduler(q *query.Queries, reminders *service.ReminderService, interval time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{queries: q, reminders: reminders, interval: interval, batchSize: defaultReminderSchedulerBatchSize}
+}
+
+// Run は ctx が終わるまで interval ごとに Tick を呼び出します
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil {
+			log.Printf("reminder scheduler: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick は猶予期間中のすべての開示請求を確かめ、段階が来た催促を送ります
+func (s *ReminderScheduler) Tick(ctx context.Context) error {
+	var afterID int32
+	for {
+		pending, err := s.queries.ListPendingDisclosures(ctx, query.ListPendingDisclosuresParams{ID: afterID, Limit: s.batchSize})
+		if err != nil {
+			return fmt.Errorf("failed to list pending disclosures: %w", err)
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		for _, disclosure := range pending {
+			if err := s.reminders.Remind(ctx, disclosure); err != nil {
+				log.Printf("reminder scheduler: disclosure %d: %v", disclosure.ID, err)
+			}
+			afterID = disclosure.ID
+		}
+	}
+}
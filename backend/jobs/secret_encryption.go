@@ -0,0 +1,189 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/service"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const defaultSecretEncryptionBatchSize = 100
+
+// plaintextItem は暗号化前の平文が残っている1行
+type plaintextItem struct {
+	id          int32
+	passerID    pgtype.UUID
+	encPassword []byte
+}
+
+// plaintextTable はテーブルごとの平文の行の一覧取得と、暗号化した値への置き換えの方法
+type plaintextTable struct {
+	name string
+	list func(ctx context.Context, afterID, limit int32) ([]plaintextItem, error)
+	// 平文のまま変わっていない行だけを置き換え、置き換えた行数を返す
+	mark func(ctx context.Context, id int32, newEnc, oldEnc []byte) (int64, error)
+}
+
+// SecretEncryptionResult はテーブルごとの処理件数
+type SecretEncryptionResult struct {
+	Table     string
+	Encrypted int
+	Skipped   int
+	Failed    int
+}
+
+// SecretEncryptor は暗号化される前に保存された devices と subscriptions のパスワードを暗号化する。
+// 暗号化した行は enc_version を上げるため、何度実行しても同じ行を二重に暗号化しない
+type SecretEncryptor struct {
+	queries      *query.Queries
+	cryptoClient crypto.EncryptionServiceClient
+	batchSize    int32
+	tables       []plaintextTable
+}
+
+// NewSecretEncryptor は新しい SecretEncryptor を作成します
+func NewSecretEncryptor(q *query.Queries, cryptoClient crypto.EncryptionServiceClient) *SecretEncryptor {
+	e := &SecretEncryptor{queries: q, cryptoClient: cryptoClient, batchSize: defaultSecretEncryptionBatchSize}
+	e.tables = []plaintextTable{
+		{
+			name: "devices",
+			list: func(ctx context.Context, afterID, limit int32) ([]plaintextItem, error) {
+				rows, err := q.ListPlaintextDevices(ctx, query.ListPlaintextDevicesParams{ID: afterID, Limit: limit})
+				items := make([]plaintextItem, len(rows))
+				for i, row := range rows {
+					items[i] = plaintextItem{id: row.ID, passerID: row.PasserID, encPassword: row.EncPassword}
+				}
+				return items, err
+			},
+			mark: func(ctx context.Context, id int32, newEnc, oldEnc []byte) (int64, error) {
+				return q.EncryptPlaintextDevice(ctx, query.EncryptPlaintextDeviceParams{ID: id, EncPassword: newEnc, EncVersion: service.EncVersionEnvelope, EncPassword_2: oldEnc})
+			},
+		},
+		{
+			name: "subscriptions",
+			list: func(ctx context.Context, afterID, limit int32) ([]plaintextItem, error) {
+				rows, err := q.ListPlaintextSubscriptions(ctx, query.ListPlaintextSubscriptionsParams{ID: afterID, Limit: limit})
+				items := make([]plaintextItem, len(rows))
+				for i, row := range rows {
+					items[i] = plaintextItem{id: row.ID, passerID: row.PasserID, encPassword: row.EncPassword}
+				}
+				return items, err
+			},
+			mark: func(ctx context.Context, id int32, newEnc, oldEnc []byte) (int64, error) {
+				return q.EncryptPlaintextSubscription(ctx, query.EncryptPlaintextSubscriptionParams{ID: id, EncPassword: newEnc, EncVersion: service.EncVersionEnvelope, EncPassword_2: oldEnc})
+			},
+		},
+	}
+	return e
+}
+
+// Run はすべてのテーブルの平文を暗号化します。
+// 失敗した行は平文のまま残し、次の実行で再び対象になる
+func (e *SecretEncryptor) Run(ctx context.Context) ([]SecretEncryptionResult, error) {
+	results := make([]SecretEncryptionResult, 0, len(e.tables))
+	for _, table := range e.tables {
+		result, err := e.encryptTable(ctx, table)
+		results = append(results, result)
+		if err != nil {
+			return results, fmt.Errorf("failed to encrypt %s: %w", table.name, err)
+		}
+	}
+	return results, nil
+}
+
+func (e *SecretEncryptor) encryptTable(ctx context.Context, table plaintextTable) (SecretEncryptionResult, error) {
+	result := SecretEncryptionResult{Table: table.name}
+	var afterID int32
+	for {
+		items, err := table.list(ctx, afterID, e.batchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(items) == 0 {
+			return result, nil
+		}
+
+		// ユーザーの鍵を読み込むのが1回で済むよう、パッサーごとにまとめて暗号化する
+		byPasser := map[pgtype.UUID][]plaintextItem{}
+		var passers []pgtype.UUID
+		for _, item := range items {
+			if _, ok := byPasser[item.passerID]; !ok {
+				passers = append(passers, item.passerID)
+			}
+			byPasser[item.passerID] = append(byPasser[item.passerID], item)
+			afterID = item.id
+		}
+		for _, passerID := range passers {
+			if err := e.encryptItems(ctx, table, passerID, byPasser[passerID], &result); err != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+// encryptItems は1人のパッサーの行を暗号化して置き換える。
+// 行ごとの失敗は Failed に数えて続け、DB が使えない場合のみエラーを返す
+func (e *SecretEncryptor) encryptItems(ctx context.Context, table plaintextTable, passerID pgtype.UUID, items []plaintextItem, result *SecretEncryptionResult) error {
+	ciphertexts := make([][]byte, len(items))
+
+	// 空のパスワードは暗号化せずに暗号化済みにする
+	var indexes []int
+	var plaintexts [][]byte
+	for i, item := range items {
+		if len(item.encPassword) == 0 {
+			ciphertexts[i] = []byte{}
+			continue
+		}
+		indexes = append(indexes, i)
+		plaintexts = append(plaintexts, item.encPassword)
+	}
+
+	failed := make([]bool, len(items))
+	if len(plaintexts) > 0 {
+		resp, err := e.cryptoClient.BatchEncrypt(ctx, &crypto.BatchEncryptRequest{
+			UserId:     passerID.String(),
+			Plaintexts: plaintexts,
+		})
+		if err == nil && len(resp.GetResults()) != len(plaintexts) {
+			err = fmt.Errorf("got %d results for %d plaintexts", len(resp.GetResults()), len(plaintexts))
+		}
+		if err != nil {
+			log.Printf("failed to encrypt %s of passer %s: %v", table.name, passerID.String(), err)
+			for _, i := range indexes {
+				failed[i] = true
+			}
+		} else {
+			for j, encrypted := range resp.GetResults() {
+				i := indexes[j]
+				if encrypted.GetError() != "" {
+					log.Printf("failed to encrypt %s %d: %s", table.name, items[i].id, encrypted.GetError())
+					failed[i] = true
+					continue
+				}
+				ciphertexts[i] = encrypted.GetCiphertext()
+			}
+		}
+	}
+
+	for i, item := range items {
+		if failed[i] {
+			result.Failed++
+			continue
+		}
+		// 実行中にユーザーが更新した行は既に暗号化されているので上書きしない
+		n, err := table.mark(ctx, item.id, ciphertexts[i], item.encPassword)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			result.Skipped++
+			continue
+		}
+		result.Encrypted++
+	}
+	return nil
+}
@@ -2,17 +2,27 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/a-company-jp/digi-baton/backend/config"
 	"github.com/a-company-jp/digi-baton/backend/db/query"
 	"github.com/a-company-jp/digi-baton/backend/docs"
 	"github.com/a-company-jp/digi-baton/backend/handlers"
+	"github.com/a-company-jp/digi-baton/backend/jobs"
 	"github.com/a-company-jp/digi-baton/backend/middleware"
+	"github.com/a-company-jp/digi-baton/backend/pkg/cryptoclient"
+	"github.com/a-company-jp/digi-baton/backend/pkg/mail"
+	"github.com/a-company-jp/digi-baton/backend/pkg/rls"
+	"github.com/a-company-jp/digi-baton/backend/pkg/verification"
+	"github.com/a-company-jp/digi-baton/backend/service"
 	"github.com/a-company-jp/digi-baton/proto/crypto"
 	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/gin-contrib/cors"
@@ -21,7 +31,6 @@ import (
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 // @title		Digi Baton API
@@ -39,28 +48,106 @@ func main() {
 	clerk.SetKey(secretKey)
 
 	// Connect to the crypto service
-	conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	creds, err := config.Crypto.TransportCredentials()
+	if err != nil {
+		log.Fatalf("Failed to configure TLS for the crypto service: %v", err)
+	}
+	callerSecret, err := config.Crypto.Secret()
+	if err != nil {
+		log.Fatalf("Failed to load crypto caller secret: %v", err)
+	}
+	grantKey, err := config.Crypto.GrantSigningKey()
+	if err != nil {
+		log.Fatalf("Failed to load decrypt grant key: %v", err)
+	}
+	conn, err := grpc.NewClient(config.Crypto.Addr,
+		grpc.WithTransportCredentials(creds),
+		// 呼び出し元の署名と、監査ログ用の操作ユーザー・リクエストIDを付ける
+		grpc.WithUnaryInterceptor(cryptoclient.UnaryClientInterceptor(config.Crypto.Caller, callerSecret)),
+	)
 	if err != nil {
 		log.Fatalf("Failed to connect to gRPC server: %v", err)
 	}
 	defer conn.Close()
 
+	// 最初のリクエストで失敗しないよう、crypto サービスが使えることを起動時に確かめる
+	healthCtx, cancelHealth := context.WithTimeout(context.Background(), config.Crypto.HealthTimeout())
+	err = cryptoclient.WaitForHealthy(healthCtx, conn)
+	cancelHealth()
+	if err != nil {
+		log.Fatalf("Crypto service is unavailable: %v", err)
+	}
+
 	// Create a client
 	client := crypto.NewEncryptionServiceClient(conn)
 
+	// リクエストはテーブルの所有者でないロールで、ユーザーごとのトランザクションの中で処理し、行単位のセキュリティを効かせる
 	dbPool, err := initDatabasePool(config.DB.GetConnStr())
 	if err != nil {
 		panic(err)
 	}
 	defer dbPool.Close()
-	q := query.New(dbPool)
+	bypasses, err := query.New(dbPool).BypassesRowLevelSecurity(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to check row-level security: %v", err)
+	}
+	if bypasses {
+		log.Fatalf("DB_USER %s bypasses row-level security; connect as a role that does not own the tables", config.DB.User)
+	}
+
+	// スケジューラーやコマンドはユーザーをまたいで処理するので、テーブルの所有者で接続する
+	systemPool, err := initDatabasePool(config.DB.GetSystemConnStr())
+	if err != nil {
+		panic(err)
+	}
+	defer systemPool.Close()
+	// スケジューラーやコマンドはシステム用の接続で、ハンドラーはリクエストのトランザクションでクエリを実行する。
+	// ハンドラー用の *query.Queries はトランザクションがなければ失敗し、行単位のセキュリティを迂回しない
+	system := query.New(systemPool)
+	q := query.New(rls.NewDB())
+
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), system, client, os.Args[1:]); err != nil {
+			log.Fatalf("command %s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// シャットダウンのシグナルでスケジューラーと再暗号化ジョブを止める
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mailSender := mail.NewSenderFromEnv()
+	jobServices := newServices(system, systemPool, client, grantKey, mailSender)
+	requestServices := newServices(q, systemPool, client, grantKey, mailSender)
+
+	// 鍵ローテーション後の再暗号化ジョブ。前回途中で止まったものは再開する
+	keyRotation := jobs.NewKeyRotationRunner(ctx, system, client)
+	if err := keyRotation.Resume(ctx); err != nil {
+		log.Printf("Failed to resume key rotation jobs: %v", err)
+	}
+
+	// 期限までに止められなかった開示請求の開示と、請求者への通知
+	disclosureScheduler := jobs.NewDisclosureScheduler(systemPool, system, jobServices.disclosures, jobServices.notifications, config.Scheduler.Every())
+	go disclosureScheduler.Run(ctx)
+
+	// 猶予期間中の生存確認の催促。リンクは開示請求の期限まで有効
+	reminderScheduler := jobs.NewReminderScheduler(system, jobServices.reminders, config.Scheduler.Every())
+	go reminderScheduler.Run(ctx)
+
+	// パッサーが設定した定期的な生存確認。確認がなければ既定の受取人の名前で開示請求を出す
+	aliveCheckScheduler := jobs.NewAliveCheckScheduler(system, jobServices.aliveChecks, config.Scheduler.Every())
+	go aliveCheckScheduler.Run(ctx)
 
 	router := gin.Default()
+	// ハンドラーが *gin.Context をそのまま gRPC に渡しても、リクエストの context の値が届くようにする
+	router.ContextWithFallback = true
+	router.Use(middleware.RequestID())
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // すべてのオリジンを許可（必要に応じて制限可能）
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -71,83 +158,70 @@ func main() {
 	})
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	api := router.Group("/api")
-	{
-		usersHandler := handlers.NewUsersHandler(q)
-		// users
-		// passer and receiver are the same in the system
-		// clerkに認証の責務を負わせるため、本当に登録するためだけのエンドポイント。
-		api.POST("/users", usersHandler.Create)
-		api.PUT("/users", usersHandler.Update)
-
-		// 認証が必要なルートに対してClerkAuth middlewareを適用
-		authenticated := api.Group("/")
-		authenticated.Use(middleware.ClerkAuth(q))
-		{
-			// receivers
-			receiversHandler := handlers.NewReceiversHandler(q)
-			authenticated.GET("/receivers", receiversHandler.List)
-
-			// users
-			userHandlers := handlers.NewUsersHandler(q)
-			authenticated.GET("/users", userHandlers.GetByClerkID)
-
-			// accounts
-			accountHandlers := handlers.NewAccountsHandler(q, client)
-			authenticated.GET("/accounts", accountHandlers.List)
-			authenticated.POST("/accounts", accountHandlers.Create)
-			authenticated.PUT("/accounts", accountHandlers.Update)
-			authenticated.DELETE("/accounts", accountHandlers.Delete)
-			authenticated.GET("/accounts/templates", accountHandlers.ListTemplate)
-
-			// devices
-			devicesHandler := handlers.NewDevicesHandler(q)
-			authenticated.GET("/devices", devicesHandler.List)
-			authenticated.POST("/devices", devicesHandler.Create)
-			authenticated.PUT("/devices", devicesHandler.Update)
-			authenticated.DELETE("/devices", devicesHandler.Delete)
-
-			// trusts(相続の関係性）
-			trustsHandler := handlers.NewTrustsHandler(q)
-			authenticated.GET("/trusts", trustsHandler.List)
-			authenticated.POST("/trusts", trustsHandler.Create)
-			authenticated.PUT("/trusts", trustsHandler.Update)
-			authenticated.DELETE("/trusts", trustsHandler.Delete)
-
-			// disclosures
-			disclosuresHandler := handlers.NewDisclosuresHandler(q)
-			authenticated.GET("/disclosures", disclosuresHandler.List)
-			authenticated.POST("/disclosures", disclosuresHandler.Create)
-
-			// 生存確認（マジックリンク）
-			verificationHandler := handlers.NewVerificationHandler(q)
-			authenticated.POST("/verify/send-email", verificationHandler.SendVerificationEmail)
-			api.POST("/verify/token", verificationHandler.VerifyToken) // トークン検証は非認証でアクセス可能
-
-			// subscriptions
-			subscriptionsHandler := handlers.NewSubscriptionsHandler(q)
-			authenticated.GET("/subscriptions", subscriptionsHandler.List)
-			authenticated.POST("/subscriptions", subscriptionsHandler.Create)
-			authenticated.PUT("/subscriptions", subscriptionsHandler.Update)
-			authenticated.DELETE("/subscriptions", subscriptionsHandler.Delete)
-
-			// alive check
-			aliveChecksHandler := handlers.NewAliveChecksHandler(q)
-			authenticated.GET("/alive-checks", aliveChecksHandler.List)
-			authenticated.POST("/alive-checks", aliveChecksHandler.Create)
-			authenticated.PUT("/alive-checks", aliveChecksHandler.Update)
+	// ルートごとに誰が何を扱えるかは handlers.Routes に書く。認証が必要なルートには ClerkAuth middleware を適用
+	routes := handlers.Routes(handlers.Dependencies{
+		Queries:       q,
+		CryptoClient:  client,
+		Disclosures:   requestServices.disclosures,
+		DecryptGrants: requestServices.decryptGrants,
+		Reminders:     requestServices.reminders,
+		AliveChecks:   requestServices.aliveChecks,
+		Invitations:   requestServices.invitations,
+		KeyRotation:   keyRotation,
+	})
+	// ユーザーを確かめるまではトランザクションがないので、ClerkAuth はシステム用の接続で users を読む
+	handlers.RegisterRoutes(router, routes, middleware.AsSystem(systemPool), middleware.ClerkAuth(system), middleware.AssumeUser(dbPool))
+
+	server := &http.Server{Addr: ":" + config.Server.Port, Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to serve: %v", err)
 		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
 	}
+	// 再暗号化ジョブが止まってから DB の接続を閉じる
+	keyRotation.Wait()
+}
+
+// services は1つの *query.Queries の上に組み立てたサービス。
+// ハンドラー用とスケジューラー用で、クエリを実行する接続が違うので別々に作る
+type services struct {
+	decryptGrants *service.DecryptGrantService
+	disclosures   *service.DisclosureService
+	notifications *service.NotificationService
+	reminders     *service.ReminderService
+	aliveChecks   *service.AliveCheckService
+	invitations   *service.TrustInvitationService
+}
+
+// newServices は q でクエリを実行するサービスを組み立てます
+func newServices(q *query.Queries, systemPool *pgxpool.Pool, client crypto.EncryptionServiceClient, grantKey ed25519.PrivateKey, mailSender *mail.Sender) services {
+	frontendURL := os.Getenv("FRONTEND_URL")
 
-	chrome := router.Group("/chrome")
-	{
-		ch := handlers.NewChromeHandler(q)
-		chrome.GET("/id", ch.HandleGetID)
-		chrome.GET("/list", ch.HandleGetAccessibleUsers)
-		chrome.POST("/register", ch.HandleCreate)
-		chrome.POST("/assert", ch.HandleGetAssertion)
+	// crypto サービスに渡す復号の許可
+	decryptGrants := service.NewDecryptGrantService(q, grantKey)
+	// 通知と、猶予期間中の生存確認の催促。リンクは開示請求の期限まで有効
+	notifications := service.NewNotificationService(q, mailSender, frontendURL, os.Getenv("LINE_CHANNEL_ACCESS_TOKEN"))
+	tokens := verification.NewVerificationTokenManager(q, 7*24*time.Hour)
+	reminders := service.NewReminderService(q, notifications, tokens, frontendURL+"/verify?token=%s")
+	return services{
+		decryptGrants: decryptGrants,
+		// 開示されたデータの受取人への引き渡し
+		disclosures:   service.NewDisclosureService(q, client, decryptGrants),
+		notifications: notifications,
+		reminders:     reminders,
+		// パッサーが設定した定期的な生存確認
+		aliveChecks: service.NewAliveCheckService(systemPool, q, notifications, reminders, tokens, frontendURL+"/verify?token=%s"),
+		// アカウントのない受取人への招待。リンクは招待の確認ページに送る
+		invitations: service.NewTrustInvitationService(q, notifications, frontendURL+"/invitations?token=%s"),
 	}
-	router.Run(":" + config.Server.Port)
 }
 
 // initDatabasePool はデータベース接続プールを初期化
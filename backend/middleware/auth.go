@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/pkg/cryptoclient"
 	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/clerk/clerk-sdk-go/v2/jwt"
 	"github.com/gin-gonic/gin"
@@ -64,6 +65,8 @@ func ClerkAuth(q *query.Queries) gin.HandlerFunc {
 
 		// Set the DB user ID in the context
 		c.Set("userId", user.ID.String())
+		// crypto サービスの監査ログに操作したユーザーとして残す
+		c.Request = c.Request.WithContext(cryptoclient.WithActor(c.Request.Context(), user.ID.String()))
 		log.Printf("User ID: %s", user.ID.String())
 
 		c.Next()
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/a-company-jp/digi-baton/backend/pkg/cryptoclient"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID はリクエストごとのIDを決め、レスポンスヘッダーと crypto サービスへの呼び出しに引き継ぎます。
+// クライアントが X-Request-Id を送ってきた場合はそれを使う
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+		c.Set("requestId", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(cryptoclient.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
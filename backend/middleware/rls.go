@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/pkg/rls"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// txBeginner はトランザクションを開始できる接続。*pgxpool.Pool が満たす
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// AssumeUser はリクエストごとにトランザクションを開き、ClerkAuth が確かめたユーザーとして行単位のセキュリティを効かせます。
+// db はテーブルの所有者でないロールの接続。ハンドラーの *query.Queries は rls.DB を通してこのトランザクションを使う。
+// レスポンスはコミットできてから書き出し、ハンドラーが 400 以上を返したときはロールバックする
+func AssumeUser(db txBeginner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := GetUserId(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		// クライアントが切断しても、ハンドラーが終えた処理はコミットする
+		ctx := context.WithoutCancel(c.Request.Context())
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to begin transaction: %v", err)})
+			c.Abort()
+			return
+		}
+		// コミットした後のロールバックは何もしない
+		defer tx.Rollback(ctx)
+
+		if err := query.New(tx).AssumeUserID(ctx, userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to assume user: %v", err)})
+			c.Abort()
+			return
+		}
+		serveInTx(c, ctx, tx, "user "+userID)
+	}
+}
+
+// AsSystem は認証しないルートのリクエストごとに、テーブルの所有者の接続でトランザクションを開きます。
+// 生存確認のトークンのように、ユーザーを確かめる前に読む必要があるものを扱う。
+// AssumeUser と同じく、ハンドラーが 400 以上を返したときはロールバックする
+func AsSystem(db txBeginner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithoutCancel(c.Request.Context())
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to begin transaction: %v", err)})
+			c.Abort()
+			return
+		}
+		defer tx.Rollback(ctx)
+
+		serveInTx(c, ctx, tx, "public request")
+	}
+}
+
+// serveInTx はハンドラーを tx の中で動かし、レスポンスはコミットできてから書き出します。
+// ハンドラーが 400 以上を返したときはコミットしない
+func serveInTx(c *gin.Context, ctx context.Context, tx pgx.Tx, owner string) {
+	c.Request = c.Request.WithContext(rls.WithTx(c.Request.Context(), tx))
+
+	w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = w
+	c.Next()
+	c.Writer = w.ResponseWriter
+
+	if w.status >= http.StatusBadRequest {
+		w.flush()
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("failed to commit transaction of %s: %v", owner, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	w.flush()
+}
+
+// bufferedWriter はトランザクションの結果が決まるまでレスポンスを溜めておく
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+		w.written = true
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// flush は溜めたレスポンスを元の ResponseWriter に書き出します
+func (w *bufferedWriter) flush() {
+	if !w.written {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,76 @@
+package cryptoclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// crypto サービスが監査ログに記録するメタデータのキー
+const (
+	CallerMetadataKey    = "x-caller"
+	ActorMetadataKey     = "x-actor-id"
+	RequestIDMetadataKey = "x-request-id"
+	// AssertionMetadataKey は呼び出し元であることを示す署名のキー
+	AssertionMetadataKey = "x-caller-assertion"
+)
+
+// assertionVersion は署名の形式 "v1:<UNIX 秒>:<HMAC-SHA256 (base64url)>" のバージョン
+const assertionVersion = "v1"
+
+type requestIDKey struct{}
+
+type actorKey struct{}
+
+// WithRequestID は crypto サービスへの呼び出しに付けるリクエストIDを ctx に載せます
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// WithActor は操作しているユーザーのIDを ctx に載せます
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// RequestID は ctx に載っているリクエストIDを返します
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// SignAssertion は呼び出し元の名前、メソッド、リクエストID、時刻に対する署名を作ります。
+// crypto サービスは同じ秘密で検証し、別のメソッドや古い呼び出しへの使い回しを拒否する
+func SignAssertion(secret []byte, caller, method, requestID string, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(strings.Join([]string{assertionVersion, caller, method, requestID, ts}, "\n")))
+	return assertionVersion + ":" + ts + ":" + base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// UnaryClientInterceptor は呼び出し元の名前、操作しているユーザー、リクエストIDと、secret による署名をメタデータとして送ります。
+// バックグラウンドジョブのように ctx にリクエストIDがない呼び出しには、呼び出しごとに新しいIDを振る。
+// secret が空なら署名しない (crypto サービスが ALLOW_INSECURE のときのみ通る)
+func UnaryClientInterceptor(caller string, secret []byte) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		requestID := RequestID(ctx)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		pairs := []string{CallerMetadataKey, caller, RequestIDMetadataKey, requestID}
+		if actorID, _ := ctx.Value(actorKey{}).(string); actorID != "" {
+			pairs = append(pairs, ActorMetadataKey, actorID)
+		}
+		if len(secret) > 0 {
+			pairs = append(pairs, AssertionMetadataKey, SignAssertion(secret, caller, method, requestID, time.Now()))
+		}
+		return invoker(metadata.AppendToOutgoingContext(ctx, pairs...), method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,78 @@
+package cryptoclient
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		wantRequestID string
+		wantActor     string
+		secret        []byte
+	}{
+		{
+			name:          "request from a user",
+			ctx:           WithActor(WithRequestID(context.Background(), "req-1"), "user-1"),
+			wantRequestID: "req-1",
+			wantActor:     "user-1",
+			secret:        []byte("0123456789abcdef0123456789abcdef"),
+		},
+		{
+			name: "background job",
+			ctx:  context.Background(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var md metadata.MD
+			invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			}
+			if err := UnaryClientInterceptor("backend", tt.secret)(tt.ctx, "/crypto.EncryptionService/Decrypt", nil, nil, nil, invoker); err != nil {
+				t.Fatalf("interceptor returned %v", err)
+			}
+
+			if got := md.Get(CallerMetadataKey); len(got) != 1 || got[0] != "backend" {
+				t.Errorf("caller = %v, want [backend]", got)
+			}
+			requestID := md.Get(RequestIDMetadataKey)
+			if len(requestID) != 1 || requestID[0] == "" {
+				t.Fatalf("request ID = %v, want one non-empty value", requestID)
+			}
+			if tt.wantRequestID != "" && requestID[0] != tt.wantRequestID {
+				t.Errorf("request ID = %q, want %q", requestID[0], tt.wantRequestID)
+			}
+			actor := md.Get(ActorMetadataKey)
+			if tt.wantActor == "" && len(actor) != 0 {
+				t.Errorf("actor = %v, want none", actor)
+			}
+			if tt.wantActor != "" && (len(actor) != 1 || actor[0] != tt.wantActor) {
+				t.Errorf("actor = %v, want [%s]", actor, tt.wantActor)
+			}
+			assertion := md.Get(AssertionMetadataKey)
+			if tt.secret == nil && len(assertion) != 0 {
+				t.Errorf("assertion = %v, want none", assertion)
+			}
+			if tt.secret != nil && (len(assertion) != 1 || !strings.HasPrefix(assertion[0], "v1:")) {
+				t.Errorf("assertion = %v, want one v1 assertion", assertion)
+			}
+		})
+	}
+}
+
+func TestSignAssertion(t *testing.T) {
+	// crypto サービスの検証側と同じベクタ
+	got := SignAssertion([]byte("0123456789abcdef0123456789abcdef"), "backend", "/crypto.EncryptionService/Decrypt", "req-1", time.Unix(1_700_000_000, 0))
+	if want := "v1:1700000000:jNucBiSWIgytXiEw5mAV7OS6BKk-lfgOUpiHFu30vTw"; got != want {
+		t.Errorf("SignAssertion = %q, want %q", got, want)
+	}
+}
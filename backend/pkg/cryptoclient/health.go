@@ -0,0 +1,38 @@
+package cryptoclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthServiceName は crypto サービスが grpc.health.v1 で状態を報告するサービス名
+const HealthServiceName = "crypto.EncryptionService"
+
+// healthRetryInterval は起動待ちのあいだ状態を問い合わせる間隔
+const healthRetryInterval = time.Second
+
+// WaitForHealthy は crypto サービスが SERVING を返すまで待ちます。
+// 起動直後の再起動や DB 待ちに備えて ctx の期限まで問い合わせを繰り返し、
+// 間に合わなければ最後に分かった理由を返す
+func WaitForHealthy(ctx context.Context, conn grpc.ClientConnInterface) error {
+	client := healthpb.NewHealthClient(conn)
+	for {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: HealthServiceName})
+		if err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING {
+			return nil
+		}
+		if err == nil {
+			err = fmt.Errorf("crypto service is %s", resp.GetStatus())
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("crypto service is not healthy: %w", err)
+		case <-time.After(healthRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cryptoclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestWaitForHealthy(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := health.NewServer()
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// DB に繋がっていない間は待ちきれずにエラー
+	hs.SetServingStatus(HealthServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := WaitForHealthy(ctx, conn); err == nil {
+		t.Fatal("WaitForHealthy() should fail while the service is not serving")
+	}
+
+	// 途中で SERVING になれば成功する
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		hs.SetServingStatus(HealthServiceName, healthpb.HealthCheckResponse_SERVING)
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForHealthy(ctx, conn); err != nil {
+		t.Fatalf("WaitForHealthy() error = %v", err)
+	}
+}
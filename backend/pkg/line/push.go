@@ -0,0 +1,42 @@
+package line
+
+import (
+	"fmt"
+
+	"resty.dev/v3"
+)
+
+const (
+	pushMessageEndpoint = "https://api.line.me/v2/bot/message/push"
+)
+
+type textMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type pushMessageRequest struct {
+	To       string        `json:"to"`
+	Messages []textMessage `json:"messages"`
+}
+
+// PushText sends a text message to a LINE user through the Messaging API.
+// channelAccessToken is the long-lived token of the official account.
+func PushText(channelAccessToken, to, text string) error {
+	client := resty.New()
+	defer client.Close()
+	resp, err := client.R().
+		SetHeader("Authorization", "Bearer "+channelAccessToken).
+		SetBody(pushMessageRequest{
+			To:       to,
+			Messages: []textMessage{{Type: "text", Text: text}},
+		}).
+		Post(pushMessageEndpoint)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("line push message failed: %s", resp.Status())
+	}
+	return nil
+}
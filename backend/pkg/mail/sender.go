@@ -2,6 +2,8 @@ package mail
 
 import (
 	"fmt"
+	"log"
+	"os"
 
 	"github.com/mailjet/mailjet-apiv3-go/v4"
 )
@@ -27,6 +29,25 @@ func NewSender(apiKeyPublic, apiKeyPrivate, fromEmail, fromName string) *Sender
 	}
 }
 
+// NewSenderFromEnv は MAILJET_* 環境変数からメール送信機能を作成します。
+// 設定されていない場合はダミー送信者を返す
+func NewSenderFromEnv() *Sender {
+	mailjetPublicKey := os.Getenv("MAILJET_API_KEY_PUBLIC")
+	mailjetPrivateKey := os.Getenv("MAILJET_API_KEY_PRIVATE")
+	mailjetFromEmail := os.Getenv("MAILJET_FROM_EMAIL")
+	mailjetFromName := os.Getenv("MAILJET_FROM_NAME")
+
+	if mailjetPublicKey == "" || mailjetPrivateKey == "" || mailjetFromEmail == "" {
+		// 環境変数が設定されていない場合はダミー送信者を使用（ログのみ出力）
+		log.Println("WARNING: Mailjet credentials not found, using dummy email sender")
+		return NewDummySender()
+	}
+	if mailjetFromName == "" {
+		mailjetFromName = "Digi Baton" // デフォルト送信者名
+	}
+	return NewSender(mailjetPublicKey, mailjetPrivateKey, mailjetFromEmail, mailjetFromName)
+}
+
 // Message はメールメッセージの構造を定義します
 type Message struct {
 	To          []string
@@ -112,8 +133,130 @@ func (s *Sender) SendVerificationEmail(to, userName, verifyURL string, expiratio
 このメールは自動送信されています。返信しないでください。
 `, userName, expirationHrs, verifyURL)
 
-	// Mailjetメッセージの作成
-	messagesInfo := []mailjet.InfoMessagesV31{
+	return s.send(to, userName, "アカウント存在確認", plainTextContent, htmlContent)
+}
+
+// SendDisclosedEmail は開示請求が開示されたことを請求者に知らせるメールを送信します
+func (s *Sender) SendDisclosedEmail(to, userName, passerName, loginURL string) error {
+	htmlContent := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>開示のお知らせ</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; text-align: center; }
+        .content { padding: 20px; }
+        .button { background-color: #007bff; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px; display: inline-block; }
+        .footer { margin-top: 20px; text-align: center; font-size: 12px; color: #999; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>開示のお知らせ</h1>
+        </div>
+        <div class="content">
+            <p>%s 様</p>
+            <p>%s 様への開示請求は、期限までに生存確認がなかったため開示されました。</p>
+            <p>託された情報はログインして確認できます。</p>
+            <p style="text-align: center; margin: 30px 0;">
+                <a href="%s" class="button">ログインして確認する</a>
+            </p>
+        </div>
+        <div class="footer">
+            <p>このメールは自動送信されています。返信しないでください。</p>
+        </div>
+    </div>
+</body>
+</html>
+`, userName, passerName, loginURL)
+
+	plainTextContent := fmt.Sprintf(`
+開示のお知らせ
+
+%s 様
+
+%s 様への開示請求は、期限までに生存確認がなかったため開示されました。
+託された情報はログインして確認できます。
+
+%s
+
+このメールは自動送信されています。返信しないでください。
+`, userName, passerName, loginURL)
+
+	return s.send(to, userName, "開示のお知らせ", plainTextContent, htmlContent)
+}
+
+// SendTrustInvitationEmail は受取人として招待されたことを知らせるメールを送信します。
+// 招待された人はまだアカウントを持っていないことがあるので、宛名はメールアドレスにする
+func (s *Sender) SendTrustInvitationEmail(to, passerName, inviteURL string, expirationDays int) error {
+	htmlContent := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>受取人への招待</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; text-align: center; }
+        .content { padding: 20px; }
+        .button { background-color: #007bff; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px; display: inline-block; }
+        .footer { margin-top: 20px; text-align: center; font-size: 12px; color: #999; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>受取人への招待</h1>
+        </div>
+        <div class="content">
+            <p>%s 様</p>
+            <p>%s 様から、Digi Baton の受取人として招待されました。</p>
+            <p>以下のボタンから登録またはログインして、受取人になるかどうかをお選びください。</p>
+            <p style="text-align: center; margin: 30px 0;">
+                <a href="%s" class="button">招待を確認する</a>
+            </p>
+            <p>このリンクは%d日間有効です。</p>
+            <p>このメールに心当たりがない場合は、無視していただいて構いません。</p>
+        </div>
+        <div class="footer">
+            <p>このメールは自動送信されています。返信しないでください。</p>
+        </div>
+    </div>
+</body>
+</html>
+`, to, passerName, inviteURL, expirationDays)
+
+	plainTextContent := fmt.Sprintf(`
+受取人への招待
+
+%s 様
+
+%s 様から、Digi Baton の受取人として招待されました。
+以下のリンクから登録またはログインして、受取人になるかどうかをお選びください。
+
+%s
+
+このリンクは%d日間有効です。
+このメールに心当たりがない場合は、無視していただいて構いません。
+
+このメールは自動送信されています。返信しないでください。
+`, to, passerName, inviteURL, expirationDays)
+
+	return s.send(to, to, "受取人への招待", plainTextContent, htmlContent)
+}
+
+// send は1通のメールを送信します。ダミー送信者の場合はログに出すだけ
+func (s *Sender) send(to, userName, subject, textPart, htmlPart string) error {
+	if s.client == nil {
+		fmt.Printf("[DUMMY EMAIL] To: %s, UserName: %s, Subject: %s\n", to, userName, subject)
+		return nil
+	}
+	messages := mailjet.MessagesV31{Info: []mailjet.InfoMessagesV31{
 		{
 			From: &mailjet.RecipientV31{
 				Email: s.fromEmail,
@@ -125,16 +268,11 @@ func (s *Sender) SendVerificationEmail(to, userName, verifyURL string, expiratio
 					Name:  userName,
 				},
 			},
-			Subject:  "アカウント存在確認",
-			TextPart: plainTextContent,
-			HTMLPart: htmlContent,
+			Subject:  subject,
+			TextPart: textPart,
+			HTMLPart: htmlPart,
 		},
-	}
-
-	// メール送信リクエストの作成
-	messages := mailjet.MessagesV31{Info: messagesInfo}
-
-	// メールの送信
+	}}
 	_, err := s.client.SendMailV31(&messages)
 	return err
 }
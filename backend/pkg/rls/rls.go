@@ -0,0 +1,67 @@
+package rls
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNoTransaction はリクエストのトランザクションを持たない ctx でクエリを実行しようとしたことを表す
+var ErrNoTransaction = errors.New("rls: no request transaction in context")
+
+type txKey struct{}
+
+// WithTx は ctx にリクエストのトランザクションを持たせます。
+// DB はこの ctx で呼ばれたクエリをそのトランザクションで実行する
+func WithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext は ctx に持たせたトランザクションを返します
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok && tx != nil
+}
+
+// DB は ctx にあるリクエストのトランザクションでクエリを実行する query.DBTX。
+// トランザクションがなければ ErrNoTransaction を返し、行単位のセキュリティを迂回した接続では実行しない。
+// スケジューラーやコマンドはシステム用の接続で作った *query.Queries を使う
+type DB struct{}
+
+// NewDB は新しい DB を作成します
+func NewDB() *DB {
+	return &DB{}
+}
+
+func (d *DB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return pgconn.CommandTag{}, ErrNoTransaction
+	}
+	return tx.Exec(ctx, sql, args...)
+}
+
+func (d *DB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTransaction
+	}
+	return tx.Query(ctx, sql, args...)
+}
+
+func (d *DB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return errorRow{}
+	}
+	return tx.QueryRow(ctx, sql, args...)
+}
+
+// errorRow は Scan で ErrNoTransaction を返す行
+type errorRow struct{}
+
+func (errorRow) Scan(...interface{}) error {
+	return ErrNoTransaction
+}
@@ -0,0 +1,80 @@
+package rls
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recordingDB は Exec が呼ばれたことを記録する
+type recordingDB struct {
+	pgx.Tx
+	execs int
+}
+
+func (d *recordingDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	d.execs++
+	return pgconn.CommandTag{}, nil
+}
+
+func (d *recordingDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, nil
+}
+
+func (d *recordingDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return nil
+}
+
+func TestDB(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func(tx pgx.Tx) context.Context
+		wantErr error
+	}{
+		{
+			name:    "No transaction",
+			ctx:     func(pgx.Tx) context.Context { return context.Background() },
+			wantErr: ErrNoTransaction,
+		},
+		{
+			name: "Request transaction",
+			ctx:  func(tx pgx.Tx) context.Context { return WithTx(context.Background(), tx) },
+		},
+		{
+			name:    "Nil transaction",
+			ctx:     func(pgx.Tx) context.Context { return WithTx(context.Background(), nil) },
+			wantErr: ErrNoTransaction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &recordingDB{}
+			db := NewDB()
+			ctx := tt.ctx(tx)
+
+			if _, err := db.Exec(ctx, "SELECT 1"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Exec() error = %v, want %v", err, tt.wantErr)
+			}
+			wantExecs := 1
+			if tt.wantErr != nil {
+				wantExecs = 0
+			}
+			if tx.execs != wantExecs {
+				t.Errorf("Exec() ran on tx %d times, want %d", tx.execs, wantExecs)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			if _, err := db.Query(ctx, "SELECT 1"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Query() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := db.QueryRow(ctx, "SELECT 1").Scan(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("QueryRow().Scan() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
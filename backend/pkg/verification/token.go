@@ -1,85 +1,142 @@
 package verification
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// Purpose はトークンを何の生存確認に使うか
+type Purpose string
+
+const (
+	// PurposeDisclosure は開示請求への生存確認。開示請求に結び付く
+	PurposeDisclosure Purpose = "disclosure"
+	// PurposeScheduled はパッサーが設定した定期的な生存確認
+	PurposeScheduled Purpose = "scheduled"
+	// PurposeManual はユーザーが自分で送った確認メール
+	PurposeManual Purpose = "manual"
+)
+
+// tokenBytes はトークンの乱数のバイト数
+const tokenBytes = 32
+
+var (
+	ErrTokenNotFound   = errors.New("token not found")
+	ErrTokenUsed       = errors.New("token has already been used")
+	ErrTokenSuperseded = errors.New("token has been superseded by a newer one")
+	ErrTokenRevoked    = errors.New("token has been revoked")
+	ErrTokenExpired    = errors.New("token has expired")
+)
+
+// VerificationTokenManager は生存確認のリンクのトークンを発行し、1回だけ使えるようにする。
+// トークンはハッシュだけを alive_check_tokens に保存する
 type VerificationTokenManager struct {
-	secretKey []byte
+	queries   *query.Queries
 	expiresIn time.Duration
 }
 
-// TokenClaims はJWTトークンに含まれる情報
-type TokenClaims struct {
-	UserID    string `json:"user_id"`
-	ClerkID   string `json:"clerk_id"`
-	Email     string `json:"email"`
-	TokenType string `json:"token_type"`
-	jwt.RegisteredClaims
-}
-
 // NewVerificationTokenManager は新しいトークンマネージャーを作成します
-func NewVerificationTokenManager(secretKey string, expiresIn time.Duration) *VerificationTokenManager {
+func NewVerificationTokenManager(q *query.Queries, expiresIn time.Duration) *VerificationTokenManager {
 	return &VerificationTokenManager{
-		secretKey: []byte(secretKey),
+		queries:   q,
 		expiresIn: expiresIn,
 	}
 }
 
-// GenerateToken はユーザーに対して存在確認用のマジックリンクトークンを生成します
-func (m *VerificationTokenManager) GenerateToken(userID, clerkID, email string) (string, error) {
-	expirationTime := time.Now().Add(m.expiresIn)
-
-	claims := &TokenClaims{
-		UserID:    userID,
-		ClerkID:   clerkID,
-		Email:     email,
-		TokenType: "verification",
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   userID,
-		},
+// Issue は expiresIn の間有効なトークンを発行します。
+// disclosureID は PurposeDisclosure のときだけ指定し、それ以外は 0
+func (m *VerificationTokenManager) Issue(ctx context.Context, userID pgtype.UUID, purpose Purpose, disclosureID int32) (string, error) {
+	return m.IssueUntil(ctx, userID, purpose, disclosureID, time.Now().Add(m.expiresIn))
+}
+
+// IssueUntil は expirationTime まで有効なトークンを発行します。
+// 同じユーザー、用途、開示請求のまだ使われていないトークンは使えなくなる
+func (m *VerificationTokenManager) IssueUntil(ctx context.Context, userID pgtype.UUID, purpose Purpose, disclosureID int32, expirationTime time.Time) (string, error) {
+	if (purpose == PurposeDisclosure) != (disclosureID != 0) {
+		return "", fmt.Errorf("disclosure id must be set only for %s tokens", PurposeDisclosure)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(m.secretKey)
+	token, err := GenerateRandomToken(tokenBytes)
 	if err != nil {
 		return "", err
 	}
-
-	return tokenString, nil
+	if _, err := m.queries.CreateAliveCheckToken(ctx, query.CreateAliveCheckTokenParams{
+		TokenHash:    HashToken(token),
+		UserID:       userID,
+		Purpose:      string(purpose),
+		DisclosureID: pgtype.Int4{Int32: disclosureID, Valid: disclosureID != 0},
+		ExpiresAt:    pgtype.Timestamp{Time: expirationTime, Valid: true},
+	}); err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+	return token, nil
 }
 
-// VerifyToken はトークンを検証し、有効であればクレームを返します
-func (m *VerificationTokenManager) VerifyToken(tokenString string) (*TokenClaims, error) {
-	claims := &TokenClaims{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return m.secretKey, nil
-	})
+// Consume はトークンを使用済みにし、その内容を返します。
+// 2回目以降や、新しいトークンを発行済み、取り消し済み、期限切れのときはそれぞれのエラーを返す
+func (m *VerificationTokenManager) Consume(ctx context.Context, token string) (query.AliveCheckToken, error) {
+	hash := HashToken(token)
+	consumed, err := m.queries.ConsumeAliveCheckToken(ctx, hash)
+	if err == nil {
+		return consumed, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return query.AliveCheckToken{}, fmt.Errorf("failed to consume token: %w", err)
+	}
 
+	// 使えなかった理由を調べる
+	stored, err := m.queries.GetAliveCheckToken(ctx, hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return query.AliveCheckToken{}, ErrTokenNotFound
+	}
 	if err != nil {
-		return nil, err
+		return query.AliveCheckToken{}, fmt.Errorf("failed to get token: %w", err)
 	}
-
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
+	if err := tokenState(stored, time.Now()); err != nil {
+		return stored, err
 	}
+	// 調べる間に状態が変わることはないはずだが、使えたとは扱わない
+	return stored, ErrTokenUsed
+}
 
-	if claims.TokenType != "verification" {
-		return nil, fmt.Errorf("invalid token type")
+// RevokeForDisclosure は開示請求に結び付いたまだ使えるトークンを取り消します
+func (m *VerificationTokenManager) RevokeForDisclosure(ctx context.Context, disclosureID int32) (int64, error) {
+	return m.queries.RevokeAliveCheckTokensByDisclosureId(ctx, pgtype.Int4{Int32: disclosureID, Valid: true})
+}
+
+// RevokeForUser はユーザーのまだ使えるトークンをすべて取り消します
+func (m *VerificationTokenManager) RevokeForUser(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	return m.queries.RevokeAliveCheckTokensByUserId(ctx, userID)
+}
+
+// tokenState は保存されたトークンが now の時点で使えない理由を返します。使えるなら nil
+func tokenState(token query.AliveCheckToken, now time.Time) error {
+	switch {
+	case token.UsedAt.Valid:
+		return ErrTokenUsed
+	case token.RevokedAt.Valid:
+		return ErrTokenRevoked
+	case token.SupersededAt.Valid:
+		return ErrTokenSuperseded
+	case !token.ExpiresAt.Time.After(now):
+		return ErrTokenExpired
 	}
+	return nil
+}
 
-	return claims, nil
+// HashToken は保存と照合に使うトークンのハッシュを返します
+func HashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
 }
 
 // GenerateRandomToken は指定された長さのランダムなトークンを生成します
@@ -89,7 +146,7 @@ func GenerateRandomToken(length int) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
 // ExpiresIn はトークンの有効期限を返します
@@ -0,0 +1,87 @@
+package verification
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestTokenState(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := pgtype.Timestamp{Time: now.Add(-time.Hour), Valid: true}
+	future := pgtype.Timestamp{Time: now.Add(time.Hour), Valid: true}
+
+	tests := []struct {
+		name  string
+		token query.AliveCheckToken
+		want  error
+	}{
+		{
+			name:  "Usable",
+			token: query.AliveCheckToken{ExpiresAt: future},
+			want:  nil,
+		},
+		{
+			name:  "Used",
+			token: query.AliveCheckToken{ExpiresAt: future, UsedAt: at},
+			want:  ErrTokenUsed,
+		},
+		{
+			name:  "Used after a newer token was issued",
+			token: query.AliveCheckToken{ExpiresAt: future, UsedAt: at, SupersededAt: at},
+			want:  ErrTokenUsed,
+		},
+		{
+			name:  "Superseded",
+			token: query.AliveCheckToken{ExpiresAt: future, SupersededAt: at},
+			want:  ErrTokenSuperseded,
+		},
+		{
+			name:  "Revoked",
+			token: query.AliveCheckToken{ExpiresAt: future, RevokedAt: at},
+			want:  ErrTokenRevoked,
+		},
+		{
+			name:  "Expired",
+			token: query.AliveCheckToken{ExpiresAt: pgtype.Timestamp{Time: now, Valid: true}},
+			want:  ErrTokenExpired,
+		},
+		{
+			name:  "Superseded and expired",
+			token: query.AliveCheckToken{ExpiresAt: at, SupersededAt: at},
+			want:  ErrTokenSuperseded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenState(tt.token, now); !errors.Is(got, tt.want) {
+				t.Errorf("tokenState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := GenerateRandomToken(tokenBytes)
+	if err != nil {
+		t.Fatalf("GenerateRandomToken() error = %v", err)
+	}
+	other, err := GenerateRandomToken(tokenBytes)
+	if err != nil {
+		t.Fatalf("GenerateRandomToken() error = %v", err)
+	}
+
+	if !bytes.Equal(HashToken(token), HashToken(token)) {
+		t.Errorf("HashToken() is not deterministic")
+	}
+	if bytes.Equal(HashToken(token), HashToken(other)) {
+		t.Errorf("HashToken() returned the same hash for different tokens")
+	}
+	if bytes.Contains(HashToken(token), []byte(token)) {
+		t.Errorf("HashToken() contains the token")
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/pkg/utils"
+	"github.com/a-company-jp/digi-baton/backend/pkg/verification"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// missedAliveCheckDeadline は定期的な生存確認がなかったときに出す開示請求の期限
+const missedAliveCheckDeadline = 7 * 24 * time.Hour
+
+const maxAliveCheckIntervalDays = 365
+
+// ErrNoDefaultReceiver は既定の受取人がいないため、開示請求を出せないことを表す
+var ErrNoDefaultReceiver = errors.New("default receiver is not set")
+
+// txBeginner はトランザクションを開始できる接続。*pgxpool.Pool が満たす
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// ValidateAliveCheckSchedule は確認の間隔と猶予が上限以内で、猶予が間隔より長くないことを確かめます
+func ValidateAliveCheckSchedule(intervalDays, graceDays int32) error {
+	if intervalDays < 1 || intervalDays > maxAliveCheckIntervalDays {
+		return fmt.Errorf("確認の間隔は1日以上 %d 日以下にしてください", maxAliveCheckIntervalDays)
+	}
+	if graceDays < 1 || graceDays > intervalDays {
+		return errors.New("確認の猶予は1日以上、確認の間隔以下にしてください")
+	}
+	return nil
+}
+
+// AliveCheckService は開示請求とは別に、パッサーが設定した間隔で生存を確認する。
+// 猶予までに確認がなければ、既定の受取人の名前で開示請求を出す
+type AliveCheckService struct {
+	db                 txBeginner
+	queries            *query.Queries
+	notifications      *NotificationService
+	reminders          *ReminderService
+	tokens             *verification.VerificationTokenManager
+	verificationURLFmt string
+}
+
+// NewAliveCheckService は新しい AliveCheckService を作成します。
+// verificationURLFmt はトークンを埋め込む生存確認のURL
+func NewAliveCheckService(db txBeginner, q *query.Queries, notifications *NotificationService, reminders *ReminderService, tokens *verification.VerificationTokenManager, verificationURLFmt string) *AliveCheckService {
+	return &AliveCheckService{
+		db:                 db,
+		queries:            q,
+		notifications:      notifications,
+		reminders:          reminders,
+		tokens:             tokens,
+		verificationURLFmt: verificationURLFmt,
+	}
+}
+
+// ConfirmBy は送った確認のリンクの期限を返します。確認待ちでなければ false
+func ConfirmBy(schedule query.AliveCheckSchedule) (time.Time, bool) {
+	if !schedule.SentAt.Valid {
+		return time.Time{}, false
+	}
+	return schedule.SentAt.Time.AddDate(0, 0, int(schedule.GraceDays)), true
+}
+
+// Send は確認の時期が来たパッサーに、パッサーが設定したすべての経路で確認のリンクを送ります。
+// 送る前に確認待ちにするので、何度呼んでも同じ確認を二重に送らない。
+// どの経路にも届かなければ確認待ちを取り消し、次の実行で送り直す
+func (s *AliveCheckService) Send(ctx context.Context, schedule query.AliveCheckSchedule) error {
+	sentAt, err := s.queries.MarkAliveCheckSent(ctx, schedule.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark alive check as sent: %w", err)
+	}
+	schedule.SentAt = sentAt
+
+	delivered, err := s.deliver(ctx, schedule)
+	if delivered > 0 {
+		return err
+	}
+	// 届かなかった確認で猶予を数え始めると、確認を求められていないパッサーの開示請求が出てしまう
+	if releaseErr := s.queries.ReleaseAliveCheckSent(ctx, query.ReleaseAliveCheckSentParams{
+		UserID: schedule.UserID,
+		SentAt: sentAt,
+	}); releaseErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to release alive check: %w", releaseErr))
+	}
+	if err == nil {
+		err = errors.New("alive check was not delivered on any channel")
+	}
+	return err
+}
+
+// deliver は確認のリンクを発行してすべての経路で送り、届いた経路の数を返します
+func (s *AliveCheckService) deliver(ctx context.Context, schedule query.AliveCheckSchedule) (int, error) {
+	deadline, _ := ConfirmBy(schedule)
+
+	passer, err := s.notifications.contact(ctx, schedule.UserID)
+	if err != nil {
+		return 0, err
+	}
+	channels, err := s.notifications.aliveCheckChannels(ctx, schedule.UserID)
+	if err != nil {
+		return 0, err
+	}
+	token, err := s.tokens.IssueUntil(ctx, schedule.UserID, verification.PurposeScheduled, 0, deadline)
+	if err != nil {
+		return 0, fmt.Errorf("failed to issue verification token: %w", err)
+	}
+	check := aliveCheck{
+		PasserID:  schedule.UserID,
+		Deadline:  deadline,
+		VerifyURL: fmt.Sprintf(s.verificationURLFmt, token),
+	}
+
+	delivered := 0
+	var errs []error
+	for _, channel := range channels {
+		sendErr := s.notifications.sendAliveCheck(ctx, channel.Channel, channel.Address, passer, check)
+		data := map[string]any{"scheduled": true}
+		if err := s.notifications.recordAliveCheckSent(ctx, schedule.UserID, channel.Channel, data, sendErr); err != nil {
+			errs = append(errs, err)
+		}
+		if sendErr != nil {
+			errs = append(errs, fmt.Errorf("failed to send %s alive check: %w", channel.Channel, sendErr))
+			continue
+		}
+		delivered++
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// Miss は猶予までに確認がなかったパッサーについて、既定の受取人の名前で開示請求を出します。
+// 既定の受取人の開示請求がすでに進んでいれば、新しくは出さない。
+// 既定の受取人がいなければ確認がなかったことだけを残し、ErrNoDefaultReceiver を返す
+func (s *AliveCheckService) Miss(ctx context.Context, schedule query.AliveCheckSchedule) error {
+	passer, err := s.queries.GetUser(ctx, schedule.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.queries.WithTx(tx)
+
+	var disclosureID pgtype.Int4
+	var opened *query.Disclosure
+	if passer.DefaultReceiverID.Valid {
+		disclosure, err := qtx.GetOpenDisclosure(ctx, query.GetOpenDisclosureParams{
+			RequesterID: passer.DefaultReceiverID,
+			PasserID:    passer.ID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			disclosure, err = s.openDisclosure(ctx, qtx, passer, schedule)
+			opened = &disclosure
+		}
+		if err != nil {
+			return err
+		}
+		disclosureID = pgtype.Int4{Int32: disclosure.ID, Valid: true}
+	}
+
+	// 待つ間にパッサーが確認していれば、sent_at が変わっているので何もしない
+	missed, err := qtx.MarkAliveCheckMissed(ctx, query.MarkAliveCheckMissedParams{
+		UserID:           schedule.UserID,
+		SentAt:           schedule.SentAt,
+		LastDisclosureID: disclosureID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark alive check as missed: %w", err)
+	}
+	if missed == 0 {
+		return nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	if !passer.DefaultReceiverID.Valid {
+		return ErrNoDefaultReceiver
+	}
+	// 開示請求を受けたときと同じく、最初の催促をすぐに送る
+	if opened != nil {
+		return s.reminders.Remind(ctx, *opened)
+	}
+	return nil
+}
+
+func (s *AliveCheckService) openDisclosure(ctx context.Context, q *query.Queries, passer query.User, schedule query.AliveCheckSchedule) (query.Disclosure, error) {
+	customData, err := json.Marshal(map[string]any{
+		"reason": "missed_alive_check",
+		"sentAt": schedule.SentAt.Time.Format(time.RFC3339),
+	})
+	if err != nil {
+		return query.Disclosure{}, err
+	}
+	now := time.Now()
+	disclosure, err := q.CreateDisclosure(ctx, query.CreateDisclosureParams{
+		RequesterID: passer.DefaultReceiverID,
+		PasserID:    passer.ID,
+		IssuedTime:  pgtype.Timestamp{Time: now, Valid: true},
+		Deadline:    pgtype.Timestamp{Time: now.Add(missedAliveCheckDeadline), Valid: true},
+		CustomData:  customData,
+		Actor:       DisclosureActorSystem,
+	})
+	if err != nil {
+		return query.Disclosure{}, fmt.Errorf("failed to create disclosure: %w", err)
+	}
+	return disclosure, nil
+}
+
+// CheckIn はパッサーの生存を確認したことを残し、次の確認を interval_days 後にします。
+// 定期的な生存確認を設定していなければ pgx.ErrNoRows を返す
+func (s *AliveCheckService) CheckIn(ctx context.Context, passerID pgtype.UUID, method int32) (query.AliveCheckSchedule, error) {
+	schedule, err := s.queries.ConfirmAliveCheckSchedule(ctx, passerID)
+	if err != nil {
+		return query.AliveCheckSchedule{}, fmt.Errorf("failed to confirm alive check: %w", err)
+	}
+	if _, err := s.queries.CreateAliveCheckHistory(ctx, query.CreateAliveCheckHistoryParams{
+		ID:           utils.ToPgxUUID(uuid.New()),
+		TargetUserID: passerID,
+		CheckMethod:  method,
+		CheckTime:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+		CustomData:   []byte(`{"scheduled":true}`),
+	}); err != nil {
+		return query.AliveCheckSchedule{}, fmt.Errorf("failed to record alive check: %w", err)
+	}
+	return schedule, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errAliveCheckDB = errors.New("scripted failure")
+
+// aliveCheckDB は確認待ちにしたときの sent_at を返し、ユーザーの読み込みは失敗させる DB。
+// 確認待ちを取り消したかを記録する
+type aliveCheckDB struct {
+	sentAt      pgtype.Timestamp
+	notDue      bool
+	failRelease bool
+	released    []interface{}
+}
+
+func (d *aliveCheckDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if strings.HasPrefix(sql, "-- name: ReleaseAliveCheckSent ") {
+		d.released = args
+		if d.failRelease {
+			return pgconn.CommandTag{}, errAliveCheckDB
+		}
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (d *aliveCheckDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errAliveCheckDB
+}
+
+func (d *aliveCheckDB) QueryRow(_ context.Context, sql string, _ ...interface{}) pgx.Row {
+	if strings.HasPrefix(sql, "-- name: MarkAliveCheckSent ") {
+		if d.notDue {
+			return aliveCheckRow{err: pgx.ErrNoRows}
+		}
+		return aliveCheckRow{sentAt: d.sentAt}
+	}
+	return aliveCheckRow{err: errAliveCheckDB}
+}
+
+type aliveCheckRow struct {
+	sentAt pgtype.Timestamp
+	err    error
+}
+
+func (r aliveCheckRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*pgtype.Timestamp) = r.sentAt
+	return nil
+}
+
+// TestAliveCheckSendReleasesUndelivered は確認のリンクをだれにも送れなかったとき、
+// 猶予を数え始めないよう確認待ちを取り消すことを確かめます
+func TestAliveCheckSendReleasesUndelivered(t *testing.T) {
+	sentAt := pgtype.Timestamp{Time: time.Now(), Valid: true}
+
+	tests := []struct {
+		name         string
+		db           *aliveCheckDB
+		wantErr      bool
+		wantReleased bool
+	}{
+		{
+			name: "Not due",
+			db:   &aliveCheckDB{notDue: true},
+		},
+		{
+			name:         "Passer cannot be contacted",
+			db:           &aliveCheckDB{sentAt: sentAt},
+			wantErr:      true,
+			wantReleased: true,
+		},
+		{
+			name:         "Release fails",
+			db:           &aliveCheckDB{sentAt: sentAt, failRelease: true},
+			wantErr:      true,
+			wantReleased: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := query.New(tt.db)
+			s := NewAliveCheckService(nil, q, NewNotificationService(q, nil, "", ""), nil, nil, "%s")
+
+			err := s.Send(context.Background(), query.AliveCheckSchedule{UserID: pgtype.UUID{Valid: true}, GraceDays: 7})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if released := tt.db.released != nil; released != tt.wantReleased {
+				t.Fatalf("released = %v, want %v", released, tt.wantReleased)
+			}
+			if tt.wantReleased && tt.db.released[1] != sentAt {
+				t.Errorf("released sent_at = %v, want %v", tt.db.released[1], sentAt)
+			}
+		})
+	}
+}
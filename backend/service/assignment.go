@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// 割り当てごとに受取人に見せる範囲 (permission)
+const (
+	// PermissionCredentials はユーザー名やパスワードなどのログイン情報まで受取人に見せる
+	PermissionCredentials = "credentials"
+	// PermissionMessage はパッサーからの伝言だけを受取人に見せる
+	PermissionMessage = "message"
+)
+
+var (
+	// ErrInvalidAssignment は割り当ての指定が不正であることを表す
+	ErrInvalidAssignment = errors.New("invalid assignment")
+	// ErrTrustNotFound は割り当て先の相続関係がないか、パッサーのものでないことを表す
+	ErrTrustNotFound = errors.New("trust not found")
+	// ErrTrustAwaitingAcceptance は割り当て先の相続関係が、受取人が招待を承諾する前のものであることを表す
+	ErrTrustAwaitingAcceptance = errors.New("trust is awaiting acceptance")
+)
+
+// Assignment はアカウント、デバイス、サブスクリプションを託す相続関係と、その受取人に見せる範囲
+type Assignment struct {
+	TrustID    int32
+	Permission string
+}
+
+// NormalizeAssignments は割り当てを確かめます。permission を省略したものは credentials にする。
+// 同じ相続関係への割り当ては1つだけ
+func NormalizeAssignments(assignments []Assignment) ([]Assignment, error) {
+	normalized := make([]Assignment, 0, len(assignments))
+	seen := map[int32]bool{}
+	for _, assignment := range assignments {
+		switch assignment.Permission {
+		case "":
+			assignment.Permission = PermissionCredentials
+		case PermissionCredentials, PermissionMessage:
+		default:
+			return nil, fmt.Errorf("%w: permission must be %s or %s, got %q", ErrInvalidAssignment, PermissionCredentials, PermissionMessage, assignment.Permission)
+		}
+		if seen[assignment.TrustID] {
+			return nil, fmt.Errorf("%w: trust %d is assigned more than once", ErrInvalidAssignment, assignment.TrustID)
+		}
+		seen[assignment.TrustID] = true
+		normalized = append(normalized, assignment)
+	}
+	return normalized, nil
+}
+
+// AssignAccount はアカウントの割り当てを assignments で置き換えます。assignments は CheckAssignments で確かめたもの。
+// 受取人の開示請求がすでに開示済みなら、割り当てたものはその場で引き渡す
+func (s *DisclosureService) AssignAccount(ctx context.Context, account query.Account, assignments []Assignment) ([]query.AccountAssignment, error) {
+	if err := s.queries.DeleteAccountAssignmentsExcept(ctx, query.DeleteAccountAssignmentsExceptParams{
+		AccountID: account.ID,
+		TrustIds:  assignmentTrustIDs(assignments),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to remove assignments of account %d: %w", account.ID, err)
+	}
+	for _, assignment := range assignments {
+		if _, err := s.queries.UpsertAccountAssignment(ctx, query.UpsertAccountAssignmentParams{
+			AccountID:  account.ID,
+			TrustID:    assignment.TrustID,
+			Permission: assignment.Permission,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to assign account %d to trust %d: %w", account.ID, assignment.TrustID, err)
+		}
+	}
+	if _, err := s.ReleaseAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return s.queries.ListAccountAssignmentsByAccountId(ctx, account.ID)
+}
+
+// AssignDevice はデバイスの割り当てを assignments で置き換えます
+func (s *DisclosureService) AssignDevice(ctx context.Context, device query.Device, assignments []Assignment) ([]query.DeviceAssignment, error) {
+	if err := s.queries.DeleteDeviceAssignmentsExcept(ctx, query.DeleteDeviceAssignmentsExceptParams{
+		DeviceID: device.ID,
+		TrustIds: assignmentTrustIDs(assignments),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to remove assignments of device %d: %w", device.ID, err)
+	}
+	for _, assignment := range assignments {
+		if _, err := s.queries.UpsertDeviceAssignment(ctx, query.UpsertDeviceAssignmentParams{
+			DeviceID:   device.ID,
+			TrustID:    assignment.TrustID,
+			Permission: assignment.Permission,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to assign device %d to trust %d: %w", device.ID, assignment.TrustID, err)
+		}
+	}
+	if _, err := s.ReleaseDevice(ctx, device); err != nil {
+		return nil, err
+	}
+	return s.queries.ListDeviceAssignmentsByDeviceId(ctx, device.ID)
+}
+
+// AssignSubscription はサブスクリプションの割り当てを assignments で置き換えます
+func (s *DisclosureService) AssignSubscription(ctx context.Context, subscription query.Subscription, assignments []Assignment) ([]query.SubscriptionAssignment, error) {
+	if err := s.queries.DeleteSubscriptionAssignmentsExcept(ctx, query.DeleteSubscriptionAssignmentsExceptParams{
+		SubscriptionID: subscription.ID,
+		TrustIds:       assignmentTrustIDs(assignments),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to remove assignments of subscription %d: %w", subscription.ID, err)
+	}
+	for _, assignment := range assignments {
+		if _, err := s.queries.UpsertSubscriptionAssignment(ctx, query.UpsertSubscriptionAssignmentParams{
+			SubscriptionID: subscription.ID,
+			TrustID:        assignment.TrustID,
+			Permission:     assignment.Permission,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to assign subscription %d to trust %d: %w", subscription.ID, assignment.TrustID, err)
+		}
+	}
+	if _, err := s.ReleaseSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return s.queries.ListSubscriptionAssignmentsBySubscriptionId(ctx, subscription.ID)
+}
+
+// CheckAssignments は割り当て先の相続関係がパッサーのもので、招待した受取人が承諾済みかを確かめます。
+// 辞退されたら相続関係ごと消すため、承諾前の相続関係には割り当てない。
+// 何も書き込まずに断れるよう、ハンドラーはアイテムを保存する前にこれを呼ぶ
+func (s *DisclosureService) CheckAssignments(ctx context.Context, passerID pgtype.UUID, assignments []Assignment) error {
+	for _, assignment := range assignments {
+		trust, err := s.queries.GetTrust(ctx, assignment.TrustID)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && trust.PasserUserID != passerID) {
+			return fmt.Errorf("%w: %d", ErrTrustNotFound, assignment.TrustID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get trust %d: %w", assignment.TrustID, err)
+		}
+		awaiting, err := s.queries.IsTrustAwaitingAcceptance(ctx, pgtype.Int4{Int32: trust.ID, Valid: true})
+		if err != nil {
+			return fmt.Errorf("failed to check invitation of trust %d: %w", trust.ID, err)
+		}
+		if awaiting {
+			return fmt.Errorf("%w: %d", ErrTrustAwaitingAcceptance, trust.ID)
+		}
+	}
+	return nil
+}
+
+func assignmentTrustIDs(assignments []Assignment) []int32 {
+	ids := make([]int32, len(assignments))
+	for i, assignment := range assignments {
+		ids[i] = assignment.TrustID
+	}
+	return ids
+}
+
+// releaseTarget は割り当て先の受取人と、その受取人にいま引き渡すかを返します。
+// 引き渡し済みの割り当てか、受取人の開示請求が開示まで済んでいれば引き渡す
+func (s *DisclosureService) releaseTarget(ctx context.Context, trustID int32, passerID pgtype.UUID, released bool) (pgtype.UUID, bool, error) {
+	receiverID, err := s.trustReceiver(ctx, trustID, passerID)
+	if err != nil {
+		return pgtype.UUID{}, false, err
+	}
+	if released {
+		return receiverID, true, nil
+	}
+	disclosed, err := s.queries.HasCompletedDisclosure(ctx, query.HasCompletedDisclosureParams{
+		PasserID:    passerID,
+		RequesterID: receiverID,
+	})
+	if err != nil {
+		return pgtype.UUID{}, false, fmt.Errorf("failed to check disclosure: %w", err)
+	}
+	return receiverID, disclosed, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAssignments(t *testing.T) {
+	tests := []struct {
+		name        string
+		assignments []Assignment
+		want        []Assignment
+		wantErr     error
+	}{
+		{
+			name:        "Defaults permission to credentials",
+			assignments: []Assignment{{TrustID: 1}},
+			want:        []Assignment{{TrustID: 1, Permission: PermissionCredentials}},
+		},
+		{
+			name: "Keeps permission per trust",
+			assignments: []Assignment{
+				{TrustID: 1, Permission: PermissionCredentials},
+				{TrustID: 2, Permission: PermissionMessage},
+			},
+			want: []Assignment{
+				{TrustID: 1, Permission: PermissionCredentials},
+				{TrustID: 2, Permission: PermissionMessage},
+			},
+		},
+		{
+			name:        "Empty assigns to nobody",
+			assignments: []Assignment{},
+			want:        []Assignment{},
+		},
+		{
+			name:        "Rejects unknown permission",
+			assignments: []Assignment{{TrustID: 1, Permission: "admin"}},
+			wantErr:     ErrInvalidAssignment,
+		},
+		{
+			name: "Rejects duplicate trust",
+			assignments: []Assignment{
+				{TrustID: 1, Permission: PermissionCredentials},
+				{TrustID: 1, Permission: PermissionMessage},
+			},
+			wantErr: ErrInvalidAssignment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeAssignments(tt.assignments)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NormalizeAssignments() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeAssignments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
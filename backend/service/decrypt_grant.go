@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/proto"
+)
+
+// crypto サービスは復号の許可 (grant) に含まれる暗号文しか復号しない。
+// 許可はここで DB を確かめてから発行し、ハンドラーの不具合で他人のデータを復号させないようにする
+
+// devices と subscriptions のパスワード列の暗号化バージョン (enc_version)
+const (
+	// EncVersionPlaintext は暗号化前に保存された平文。encrypt-secrets コマンドで暗号化する
+	EncVersionPlaintext int32 = 0
+	// EncVersionEnvelope は crypto サービスで暗号化した暗号文
+	EncVersionEnvelope int32 = 1
+)
+
+// decryptGrantLifetime は許可の有効期間。crypto サービス側の上限 (5分) より短くする
+const decryptGrantLifetime = time.Minute
+
+// DecryptGrantService は復号の許可を発行する
+type DecryptGrantService struct {
+	queries *query.Queries
+	// nil なら署名しない (crypto サービスが ALLOW_INSECURE のときのみ通る)
+	key ed25519.PrivateKey
+}
+
+// NewDecryptGrantService は新しい DecryptGrantService を作成します
+func NewDecryptGrantService(q *query.Queries, key ed25519.PrivateKey) *DecryptGrantService {
+	return &DecryptGrantService{queries: q, key: key}
+}
+
+// OwnAccounts はパッサー本人が自分のアカウントのパスワードを見るための許可を発行します。
+// actorID のものでないアカウントは許可に含めない
+func (s *DecryptGrantService) OwnAccounts(actorID pgtype.UUID, accounts []query.Account) (*crypto.SignedDecryptGrant, error) {
+	var ciphertexts [][]byte
+	for _, account := range accounts {
+		if account.PasserID == actorID && len(account.EncPassword) > 0 {
+			ciphertexts = append(ciphertexts, account.EncPassword)
+		}
+	}
+	return s.sign(actorID, crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, ciphertexts)
+}
+
+// OwnDevices はパッサー本人が自分のデバイスのパスワードを見るための許可を発行します。
+// 平文のまま残っている行は復号しないので許可に含めない
+func (s *DecryptGrantService) OwnDevices(actorID pgtype.UUID, devices []query.Device) (*crypto.SignedDecryptGrant, error) {
+	var ciphertexts [][]byte
+	for _, device := range devices {
+		if device.PasserID == actorID && device.EncVersion != EncVersionPlaintext && len(device.EncPassword) > 0 {
+			ciphertexts = append(ciphertexts, device.EncPassword)
+		}
+	}
+	return s.sign(actorID, crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, ciphertexts)
+}
+
+// OwnSubscriptions はパッサー本人が自分のサブスクリプションのパスワードを見るための許可を発行します
+func (s *DecryptGrantService) OwnSubscriptions(actorID pgtype.UUID, subscriptions []query.Subscription) (*crypto.SignedDecryptGrant, error) {
+	var ciphertexts [][]byte
+	for _, subscription := range subscriptions {
+		if subscription.PasserID == actorID && subscription.EncVersion != EncVersionPlaintext && len(subscription.EncPassword) > 0 {
+			ciphertexts = append(ciphertexts, subscription.EncPassword)
+		}
+	}
+	return s.sign(actorID, crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, ciphertexts)
+}
+
+// DisclosedAccounts は受取人が開示されたアカウントのパスワードを見るための許可を発行します。
+// 受取人にログイン情報まで見せる割り当てがあり、その受取人の開示請求が開示済みになっているアカウントだけを許可に含める
+func (s *DecryptGrantService) DisclosedAccounts(ctx context.Context, receiverID pgtype.UUID, rows []query.ListDisclosedAccountsByReceiverIdRow) (*crypto.SignedDecryptGrant, error) {
+	disclosed, err := s.DisclosedAccountIDs(ctx, receiverID, rows)
+	if err != nil {
+		return nil, err
+	}
+	var ciphertexts [][]byte
+	for _, row := range rows {
+		if disclosed[row.Account.ID] && len(row.ReceiverEncPassword) > 0 {
+			ciphertexts = append(ciphertexts, row.ReceiverEncPassword)
+		}
+	}
+	return s.sign(receiverID, crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED, ciphertexts)
+}
+
+// DisclosedAccountIDs は rows のうち、受取人にログイン情報まで見せる割り当てがあり、その受取人の開示請求が
+// 開示済みになっているアカウントのIDを返します。
+// ゼロ知識モードのアカウントは crypto サービスを通らないので、受取人に鍵を返してよいかをこれで判断する
+func (s *DecryptGrantService) DisclosedAccountIDs(ctx context.Context, receiverID pgtype.UUID, rows []query.ListDisclosedAccountsByReceiverIdRow) (map[int32]bool, error) {
+	check := s.newDisclosureCheck(receiverID)
+	ids := map[int32]bool{}
+	for _, row := range rows {
+		if !row.Account.IsDisclosed || row.Permission != PermissionCredentials {
+			continue
+		}
+		disclosed, err := check.disclosed(ctx, row.TrustID, row.Account.PasserID)
+		if err != nil {
+			return nil, err
+		}
+		if disclosed {
+			ids[row.Account.ID] = true
+		}
+	}
+	return ids, nil
+}
+
+// DisclosedDevices は受取人が開示されたデバイスのパスワードを見るための許可を発行します。
+// 受取人の鍵で暗号化し直したものだけを許可に含める
+func (s *DecryptGrantService) DisclosedDevices(ctx context.Context, receiverID pgtype.UUID, rows []query.ListDisclosedDevicesByReceiverIdRow) (*crypto.SignedDecryptGrant, error) {
+	check := s.newDisclosureCheck(receiverID)
+	var ciphertexts [][]byte
+	for _, row := range rows {
+		if !row.Device.IsDisclosed || row.Permission != PermissionCredentials || len(row.ReceiverEncPassword) == 0 {
+			continue
+		}
+		disclosed, err := check.disclosed(ctx, row.TrustID, row.Device.PasserID)
+		if err != nil {
+			return nil, err
+		}
+		if disclosed {
+			ciphertexts = append(ciphertexts, row.ReceiverEncPassword)
+		}
+	}
+	return s.sign(receiverID, crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED, ciphertexts)
+}
+
+// DisclosedSubscriptions は受取人が開示されたサブスクリプションのパスワードを見るための許可を発行します
+func (s *DecryptGrantService) DisclosedSubscriptions(ctx context.Context, receiverID pgtype.UUID, rows []query.ListDisclosedSubscriptionsByReceiverIdRow) (*crypto.SignedDecryptGrant, error) {
+	check := s.newDisclosureCheck(receiverID)
+	var ciphertexts [][]byte
+	for _, row := range rows {
+		if !row.Subscription.IsDisclosed || row.Permission != PermissionCredentials || len(row.ReceiverEncPassword) == 0 {
+			continue
+		}
+		disclosed, err := check.disclosed(ctx, row.TrustID, row.Subscription.PasserID)
+		if err != nil {
+			return nil, err
+		}
+		if disclosed {
+			ciphertexts = append(ciphertexts, row.ReceiverEncPassword)
+		}
+	}
+	return s.sign(receiverID, crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED, ciphertexts)
+}
+
+// disclosureCheck は受取人に開示されたものかを確かめる。同じ trusts とパッサーは1回だけ DB に問い合わせる
+type disclosureCheck struct {
+	queries        *query.Queries
+	receiverID     pgtype.UUID
+	trustReceivers map[int32]pgtype.UUID
+	disclosedBy    map[pgtype.UUID]bool
+}
+
+func (s *DecryptGrantService) newDisclosureCheck(receiverID pgtype.UUID) *disclosureCheck {
+	return &disclosureCheck{
+		queries:        s.queries,
+		receiverID:     receiverID,
+		trustReceivers: map[int32]pgtype.UUID{},
+		disclosedBy:    map[pgtype.UUID]bool{},
+	}
+}
+
+// disclosed は trustID で託されたものが受取人宛てで、受取人の開示請求が開示まで済んでいるかを返します
+func (c *disclosureCheck) disclosed(ctx context.Context, trustID int32, passerID pgtype.UUID) (bool, error) {
+	// 1. 受取人に託されたものか
+	receiver, ok := c.trustReceivers[trustID]
+	if !ok {
+		trust, err := c.queries.GetTrust(ctx, trustID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get trust %d: %w", trustID, err)
+		}
+		receiver = trust.ReceiverUserID
+		c.trustReceivers[trustID] = receiver
+	}
+	if receiver != c.receiverID {
+		return false, nil
+	}
+
+	// 2. 受取人の開示請求が開示まで済んでいるか
+	disclosed, ok := c.disclosedBy[passerID]
+	if !ok {
+		var err error
+		disclosed, err = c.queries.HasCompletedDisclosure(ctx, query.HasCompletedDisclosureParams{
+			PasserID:    passerID,
+			RequesterID: c.receiverID,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to check disclosure: %w", err)
+		}
+		c.disclosedBy[passerID] = disclosed
+	}
+	return disclosed, nil
+}
+
+// Handover はパッサーの ciphertexts を recipientID 向けに暗号化し直す許可を発行します。
+// 平文はだれにも返らないが、付け替え先を固定して他人宛てに作り直させないようにする。
+// 金庫に入れるとき (DECRYPT_PURPOSE_SEAL_TO_VAULT) の recipientID はパッサー本人
+func (s *DecryptGrantService) Handover(purpose crypto.DecryptPurpose, passerID, recipientID pgtype.UUID, ciphertexts [][]byte) (*crypto.SignedDecryptGrant, error) {
+	return s.signGrant(&crypto.DecryptGrant{
+		UserId:          passerID.String(),
+		RecipientUserId: recipientID.String(),
+		Purpose:         purpose,
+	}, ciphertexts)
+}
+
+// Unlock はパッサーの金庫を requesterIDs のシェアで開ける許可を発行します。
+// 開示請求が期限を過ぎた受取人を数えて threshold 人に達してから呼び出す。暗号文は含まない
+func (s *DecryptGrantService) Unlock(passerID pgtype.UUID, requesterIDs []string) (*crypto.SignedDecryptGrant, error) {
+	return s.signGrant(&crypto.DecryptGrant{
+		UserId:           passerID.String(),
+		RequesterUserIds: requesterIDs,
+		Purpose:          crypto.DecryptPurpose_DECRYPT_PURPOSE_UNLOCK_VAULT,
+	}, nil)
+}
+
+// sign は userID 本人が ciphertexts を復号してよいという許可に署名します
+func (s *DecryptGrantService) sign(userID pgtype.UUID, purpose crypto.DecryptPurpose, ciphertexts [][]byte) (*crypto.SignedDecryptGrant, error) {
+	return s.signGrant(&crypto.DecryptGrant{
+		UserId:      userID.String(),
+		ActorUserId: userID.String(),
+		Purpose:     purpose,
+	}, ciphertexts)
+}
+
+// signGrant は grant に有効期限と ciphertexts のダイジェストを入れて署名します
+func (s *DecryptGrantService) signGrant(grant *crypto.DecryptGrant, ciphertexts [][]byte) (*crypto.SignedDecryptGrant, error) {
+	if s.key == nil {
+		return nil, nil
+	}
+	grant.ExpiresAt = time.Now().Add(decryptGrantLifetime).Unix()
+	for _, ciphertext := range ciphertexts {
+		digest := sha256.Sum256(ciphertext)
+		grant.CiphertextDigests = append(grant.CiphertextDigests, digest[:])
+	}
+	raw, err := proto.Marshal(grant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal decrypt grant: %w", err)
+	}
+	return &crypto.SignedDecryptGrant{Grant: raw, Signature: ed25519.Sign(s.key, raw)}, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"slices"
+	"testing"
+
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestDecryptGrantUnlock は金庫を開ける許可が、パッサーとシェアを使う受取人を署名付きで固定することを確かめます
+func TestDecryptGrantUnlock(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passer := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	requesters := []string{"receiver-a", "receiver-b"}
+
+	tests := []struct {
+		name     string
+		key      ed25519.PrivateKey
+		wantNil  bool
+		wantUser string
+	}{
+		{name: "Signed for the passer and requesters", key: key, wantUser: passer.String()},
+		{name: "Unsigned without a grant key", wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := NewDecryptGrantService(nil, tt.key).Unlock(passer, requesters)
+			if err != nil {
+				t.Fatalf("Unlock() error = %v", err)
+			}
+			if tt.wantNil {
+				if signed != nil {
+					t.Errorf("Unlock() = %v, want nil", signed)
+				}
+				return
+			}
+			if !ed25519.Verify(pub, signed.GetGrant(), signed.GetSignature()) {
+				t.Fatal("grant signature does not verify")
+			}
+			var grant crypto.DecryptGrant
+			if err := proto.Unmarshal(signed.GetGrant(), &grant); err != nil {
+				t.Fatal(err)
+			}
+			if grant.GetPurpose() != crypto.DecryptPurpose_DECRYPT_PURPOSE_UNLOCK_VAULT {
+				t.Errorf("purpose = %v, want UNLOCK_VAULT", grant.GetPurpose())
+			}
+			if grant.GetUserId() != tt.wantUser {
+				t.Errorf("user = %q, want %q", grant.GetUserId(), tt.wantUser)
+			}
+			if !slices.Equal(grant.GetRequesterUserIds(), requesters) {
+				t.Errorf("requesters = %v, want %v", grant.GetRequesterUserIds(), requesters)
+			}
+			if len(grant.GetCiphertextDigests()) != 0 {
+				t.Errorf("unlock grant covers %d ciphertexts, want none", len(grant.GetCiphertextDigests()))
+			}
+		})
+	}
+}
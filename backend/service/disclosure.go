@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DisclosureService は開示の確定と、開示されたデータの受取人への引き渡しを行う
+type DisclosureService struct {
+	queries      *query.Queries
+	cryptoClient crypto.EncryptionServiceClient
+	// crypto サービスに暗号文を付け替えさせる許可
+	grants *DecryptGrantService
+}
+
+// NewDisclosureService は新しい DisclosureService を作成します
+func NewDisclosureService(q *query.Queries, cryptoClient crypto.EncryptionServiceClient, grants *DecryptGrantService) *DisclosureService {
+	return &DisclosureService{queries: q, cryptoClient: cryptoClient, grants: grants}
+}
+
+// Disclose は開示請求を開示済みにし、パッサーが請求者に託したアカウント、デバイス、サブスクリプションを引き渡します。
+// 引き渡しに失敗したものがあっても開示自体は確定し、失敗はまとめてエラーで返す。
+// 失敗した割り当ては引き渡し前のまま残るので、スケジューラーが Release で引き渡し直す。
+// しきい値開示が設定されていれば、必要な人数の請求が揃うまで ErrQuorumNotReached を返し開示しない
+func (s *DisclosureService) Disclose(ctx context.Context, disclosure query.Disclosure) (query.Disclosure, error) {
+	policy, err := s.GetPolicy(ctx, disclosure.PasserID)
+	if err != nil {
+		return query.Disclosure{}, err
+	}
+	if policy != nil {
+		return s.discloseWithQuorum(ctx, disclosure, *policy)
+	}
+
+	disclosed, err := s.markDisclosed(ctx, disclosure)
+	if err != nil {
+		return query.Disclosure{}, err
+	}
+
+	if err := errors.Join(
+		s.releaseAll(ctx, disclosed.PasserID, disclosed.RequesterID),
+		s.revokeAliveChecks(ctx, disclosed.ID),
+	); err != nil {
+		return disclosed, err
+	}
+	return disclosed, nil
+}
+
+// revokeAliveChecks は片付いた開示請求の生存確認のリンクを取り消します。
+// 開示した後や止めた後では、リンクを使っても意味がないため
+func (s *DisclosureService) revokeAliveChecks(ctx context.Context, disclosureID int32) error {
+	if _, err := s.queries.RevokeAliveCheckTokensByDisclosureId(ctx, pgtype.Int4{Int32: disclosureID, Valid: true}); err != nil {
+		return fmt.Errorf("failed to revoke alive check tokens of disclosure %d: %w", disclosureID, err)
+	}
+	return nil
+}
+
+func (s *DisclosureService) markDisclosed(ctx context.Context, disclosure query.Disclosure) (query.Disclosure, error) {
+	disclosed, err := transitionDisclosure(ctx, s.queries, disclosure, DisclosureStatusDisclosed, DisclosureActorSystem, pgtype.UUID{}, pgtype.UUID{})
+	if err != nil {
+		return query.Disclosure{}, fmt.Errorf("failed to mark disclosure %d as disclosed: %w", disclosure.ID, err)
+	}
+	return disclosed, nil
+}
+
+// Release は開示済みの開示請求で、まだ引き渡せていない割り当てを引き渡し直します。
+// 開示済みでなければ何もしない
+func (s *DisclosureService) Release(ctx context.Context, disclosure query.Disclosure) error {
+	if disclosure.Status != DisclosureStatusDisclosed {
+		return nil
+	}
+	return s.releaseAll(ctx, disclosure.PasserID, disclosure.RequesterID)
+}
+
+// releaseAll はパッサーが受取人に託したもののうち、まだ引き渡していないものをすべて開示済みにします
+func (s *DisclosureService) releaseAll(ctx context.Context, passerID, receiverID pgtype.UUID) error {
+	_, err := s.ReleaseAccounts(ctx, passerID, receiverID)
+	return errors.Join(
+		err,
+		s.releaseDevices(ctx, passerID, receiverID),
+		s.releaseSubscriptions(ctx, passerID, receiverID),
+	)
+}
+
+// releaseDevices はパッサーが受取人に割り当ててまだ引き渡していないデバイスを受取人の鍵で暗号化し直し、開示済みにします
+func (s *DisclosureService) releaseDevices(ctx context.Context, passerID, receiverID pgtype.UUID) error {
+	devices, err := s.queries.ListDevicesByPasserIdAndReceiverId(ctx, query.ListDevicesByPasserIdAndReceiverIdParams{
+		PasserID:       passerID,
+		ReceiverUserID: receiverID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list devices to release: %w", err)
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+	policy, err := s.GetPolicy(ctx, passerID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, device := range devices {
+		if err := s.releaseDevice(ctx, policy, device, receiverID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReleaseDevice は1件のデバイスを、割り当てた受取人のうち開示が済んでいる人に引き渡します。
+// 開示後にパッサーがパスワードや割り当てを変えた場合も、これで受取人側の暗号文を作り直す
+func (s *DisclosureService) ReleaseDevice(ctx context.Context, device query.Device) (query.Device, error) {
+	assignments, err := s.queries.ListDeviceAssignmentsByDeviceId(ctx, device.ID)
+	if err != nil {
+		return device, fmt.Errorf("failed to list assignments of device %d: %w", device.ID, err)
+	}
+	if len(assignments) == 0 {
+		return device, nil
+	}
+	policy, err := s.GetPolicy(ctx, device.PasserID)
+	if err != nil {
+		return device, err
+	}
+	var errs []error
+	for _, assignment := range assignments {
+		receiverID, ok, err := s.releaseTarget(ctx, assignment.TrustID, device.PasserID, assignment.IsDisclosed)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		row := query.ListDevicesByPasserIdAndReceiverIdRow{Device: device, TrustID: assignment.TrustID, Permission: assignment.Permission}
+		if err := s.releaseDevice(ctx, policy, row, receiverID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		device.IsDisclosed = true
+	}
+	return device, errors.Join(errs...)
+}
+
+// releaseDevice は1つの割り当てでデバイスを引き渡します。伝言だけを見せる割り当てには暗号文を作らない。
+// policy があれば金庫から引き渡す
+func (s *DisclosureService) releaseDevice(ctx context.Context, policy *query.DisclosurePolicy, row query.ListDevicesByPasserIdAndReceiverIdRow, receiverID pgtype.UUID) error {
+	device := row.Device
+	var receiverEncPassword []byte
+	if row.Permission == PermissionCredentials && device.EncVersion != EncVersionPlaintext {
+		var err error
+		receiverEncPassword, err = s.handoverSecret(ctx, policy, device.PasserID, receiverID, device.EncPassword, device.VaultEncPassword)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt device %d for receiver: %w", device.ID, err)
+		}
+	}
+
+	if _, err := s.queries.ReleaseDeviceAssignment(ctx, query.ReleaseDeviceAssignmentParams{
+		DeviceID:            device.ID,
+		TrustID:             row.TrustID,
+		ReceiverEncPassword: receiverEncPassword,
+	}); err != nil {
+		return fmt.Errorf("failed to release device %d to trust %d: %w", device.ID, row.TrustID, err)
+	}
+	if !device.IsDisclosed {
+		if _, err := s.queries.SetDeviceDisclosureStatus(ctx, query.SetDeviceDisclosureStatusParams{
+			ID:          device.ID,
+			IsDisclosed: true,
+		}); err != nil {
+			return fmt.Errorf("failed to mark device %d as disclosed: %w", device.ID, err)
+		}
+	}
+	return nil
+}
+
+// releaseSubscriptions はパッサーが受取人に割り当ててまだ引き渡していないサブスクリプションを受取人の鍵で暗号化し直し、開示済みにします
+func (s *DisclosureService) releaseSubscriptions(ctx context.Context, passerID, receiverID pgtype.UUID) error {
+	subscriptions, err := s.queries.ListSubscriptionsByPasserIdAndReceiverId(ctx, query.ListSubscriptionsByPasserIdAndReceiverIdParams{
+		PasserID:       passerID,
+		ReceiverUserID: receiverID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions to release: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+	policy, err := s.GetPolicy(ctx, passerID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, subscription := range subscriptions {
+		if err := s.releaseSubscription(ctx, policy, subscription, receiverID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReleaseSubscription は1件のサブスクリプションを、割り当てた受取人のうち開示が済んでいる人に引き渡します。
+// 開示後にパッサーがパスワードや割り当てを変えた場合も、これで受取人側の暗号文を作り直す
+func (s *DisclosureService) ReleaseSubscription(ctx context.Context, subscription query.Subscription) (query.Subscription, error) {
+	assignments, err := s.queries.ListSubscriptionAssignmentsBySubscriptionId(ctx, subscription.ID)
+	if err != nil {
+		return subscription, fmt.Errorf("failed to list assignments of subscription %d: %w", subscription.ID, err)
+	}
+	if len(assignments) == 0 {
+		return subscription, nil
+	}
+	policy, err := s.GetPolicy(ctx, subscription.PasserID)
+	if err != nil {
+		return subscription, err
+	}
+	var errs []error
+	for _, assignment := range assignments {
+		receiverID, ok, err := s.releaseTarget(ctx, assignment.TrustID, subscription.PasserID, assignment.IsDisclosed)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		row := query.ListSubscriptionsByPasserIdAndReceiverIdRow{Subscription: subscription, TrustID: assignment.TrustID, Permission: assignment.Permission}
+		if err := s.releaseSubscription(ctx, policy, row, receiverID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		subscription.IsDisclosed = true
+	}
+	return subscription, errors.Join(errs...)
+}
+
+// releaseSubscription は1つの割り当てでサブスクリプションを引き渡します。伝言だけを見せる割り当てには暗号文を作らない。
+// policy があれば金庫から引き渡す
+func (s *DisclosureService) releaseSubscription(ctx context.Context, policy *query.DisclosurePolicy, row query.ListSubscriptionsByPasserIdAndReceiverIdRow, receiverID pgtype.UUID) error {
+	subscription := row.Subscription
+	var receiverEncPassword []byte
+	if row.Permission == PermissionCredentials && subscription.EncVersion != EncVersionPlaintext {
+		var err error
+		receiverEncPassword, err = s.handoverSecret(ctx, policy, subscription.PasserID, receiverID, subscription.EncPassword, subscription.VaultEncPassword)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt subscription %d for receiver: %w", subscription.ID, err)
+		}
+	}
+
+	if _, err := s.queries.ReleaseSubscriptionAssignment(ctx, query.ReleaseSubscriptionAssignmentParams{
+		SubscriptionID:      subscription.ID,
+		TrustID:             row.TrustID,
+		ReceiverEncPassword: receiverEncPassword,
+	}); err != nil {
+		return fmt.Errorf("failed to release subscription %d to trust %d: %w", subscription.ID, row.TrustID, err)
+	}
+	if !subscription.IsDisclosed {
+		if _, err := s.queries.SetSubscriptionDisclosureStatus(ctx, query.SetSubscriptionDisclosureStatusParams{
+			ID:          subscription.ID,
+			IsDisclosed: true,
+		}); err != nil {
+			return fmt.Errorf("failed to mark subscription %d as disclosed: %w", subscription.ID, err)
+		}
+	}
+	return nil
+}
+
+// trustReceiver は passerID が作った trusts の受取人を返します
+func (s *DisclosureService) trustReceiver(ctx context.Context, trustID int32, passerID pgtype.UUID) (pgtype.UUID, error) {
+	trust, err := s.queries.GetTrust(ctx, trustID)
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to get trust %d: %w", trustID, err)
+	}
+	if trust.PasserUserID != passerID {
+		return pgtype.UUID{}, fmt.Errorf("trust %d does not belong to passer %s", trustID, passerID.String())
+	}
+	return trust.ReceiverUserID, nil
+}
+
+// reencryptForReceiver はパッサーの鍵で暗号化した ciphertext を受取人の鍵で暗号化し直します。
+// 暗号文の付け替えは crypto サービス内で行い、平文はバックエンドに出てこない
+func (s *DisclosureService) reencryptForReceiver(ctx context.Context, passerID, receiverID pgtype.UUID, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) == 0 {
+		return nil, nil
+	}
+	grant, err := s.grants.Handover(crypto.DecryptPurpose_DECRYPT_PURPOSE_HANDOVER, passerID, receiverID, [][]byte{ciphertext})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	resp, err := s.cryptoClient.ReencryptForRecipient(ctx, &crypto.ReencryptForRecipientRequest{
+		OwnerUserId:     passerID.String(),
+		RecipientUserId: receiverID.String(),
+		Ciphertext:      ciphertext,
+		Grant:           grant,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetCiphertext(), nil
+}
+
+// ReleaseAccounts はパッサーが受取人に割り当ててまだ引き渡していないアカウントを受取人の鍵で暗号化し直し、開示済みにします。
+// 引き渡せた件数を返す
+func (s *DisclosureService) ReleaseAccounts(ctx context.Context, passerID, receiverID pgtype.UUID) (int, error) {
+	accounts, err := s.queries.ListAccountsByPasserIdAndReceiverId(ctx, query.ListAccountsByPasserIdAndReceiverIdParams{
+		PasserID:       passerID,
+		ReceiverUserID: receiverID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list accounts to release: %w", err)
+	}
+
+	policy, err := s.GetPolicy(ctx, passerID)
+	if err != nil {
+		return 0, err
+	}
+	if policy != nil {
+		return s.releaseFromVault(ctx, policy, accounts, receiverID)
+	}
+
+	released := 0
+	var errs []error
+	for _, account := range accounts {
+		if err := s.releaseAccount(ctx, account, receiverID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		released++
+	}
+	return released, errors.Join(errs...)
+}
+
+// ReleaseAccount は1件のアカウントを、割り当てた受取人のうち開示が済んでいる人に引き渡します。
+// 開示後にパッサーがパスワードや割り当てを変えた場合も、これで受取人側の暗号文を作り直す
+func (s *DisclosureService) ReleaseAccount(ctx context.Context, account query.Account) (query.Account, error) {
+	assignments, err := s.queries.ListAccountAssignmentsByAccountId(ctx, account.ID)
+	if err != nil {
+		return account, fmt.Errorf("failed to list assignments of account %d: %w", account.ID, err)
+	}
+	if len(assignments) == 0 {
+		return account, nil
+	}
+	policy, err := s.GetPolicy(ctx, account.PasserID)
+	if err != nil {
+		return account, err
+	}
+	var errs []error
+	for _, assignment := range assignments {
+		receiverID, ok, err := s.releaseTarget(ctx, assignment.TrustID, account.PasserID, assignment.IsDisclosed)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		row := query.ListAccountsByPasserIdAndReceiverIdRow{Account: account, TrustID: assignment.TrustID, Permission: assignment.Permission}
+		if policy != nil {
+			_, err = s.releaseFromVault(ctx, policy, []query.ListAccountsByPasserIdAndReceiverIdRow{row}, receiverID)
+		} else {
+			err = s.releaseAccount(ctx, row, receiverID)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		account.IsDisclosed = true
+	}
+	return account, errors.Join(errs...)
+}
+
+// releaseAccount は1つの割り当てでアカウントを引き渡します。伝言だけを見せる割り当てには暗号文を作らない
+func (s *DisclosureService) releaseAccount(ctx context.Context, row query.ListAccountsByPasserIdAndReceiverIdRow, receiverID pgtype.UUID) error {
+	var receiverEncPassword []byte
+	if row.Permission == PermissionCredentials {
+		var err error
+		receiverEncPassword, err = s.reencryptForReceiver(ctx, row.Account.PasserID, receiverID, row.Account.EncPassword)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt account %d for receiver: %w", row.Account.ID, err)
+		}
+	}
+	return s.markAccountReleased(ctx, row, receiverEncPassword)
+}
+
+// markAccountReleased は割り当てを開示済みにし、受取人向けの暗号文を保存します
+func (s *DisclosureService) markAccountReleased(ctx context.Context, row query.ListAccountsByPasserIdAndReceiverIdRow, receiverEncPassword []byte) error {
+	account := row.Account
+	if _, err := s.queries.ReleaseAccountAssignment(ctx, query.ReleaseAccountAssignmentParams{
+		AccountID:           account.ID,
+		TrustID:             row.TrustID,
+		ReceiverEncPassword: receiverEncPassword,
+	}); err != nil {
+		return fmt.Errorf("failed to release account %d to trust %d: %w", account.ID, row.TrustID, err)
+	}
+	if !account.IsDisclosed {
+		if _, err := s.queries.SetAccountDisclosureStatus(ctx, query.SetAccountDisclosureStatusParams{
+			ID:          account.ID,
+			IsDisclosed: true,
+		}); err != nil {
+			return fmt.Errorf("failed to mark account %d as disclosed: %w", account.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// しきい値開示では、パッサーのパスワードを crypto サービスの金庫 (vault) の鍵でも暗号化しておく。
+// 金庫の鍵は受取人ごとの Shamir シェアとしてしか存在せず、threshold 人分の開示請求が揃って
+// 初めて crypto サービス内で組み立てられる
+
+var (
+	// ErrNoReceivers はしきい値開示を設定しようとしたパッサーに受取人がいないことを表す
+	ErrNoReceivers = errors.New("passer has no receivers")
+	// ErrInvalidThreshold は threshold が 1 以上受取人の数以下でないことを表す
+	ErrInvalidThreshold = errors.New("invalid disclosure threshold")
+	// ErrQuorumNotReached は開示に必要な人数の開示請求がまだ揃っていないことを表す
+	ErrQuorumNotReached = errors.New("disclosure quorum not reached")
+	// ErrNotSealed はしきい値開示が設定されているのに、パスワードが金庫の鍵で暗号化されていないことを表す
+	ErrNotSealed = errors.New("secret is not sealed to the vault")
+)
+
+// vaultSealBatchSize は金庫への暗号化を1回の RPC でまとめて行う件数
+const vaultSealBatchSize = 100
+
+// GetPolicy はパッサーのしきい値開示の設定を返します。設定がなければ nil を返す
+func (s *DisclosureService) GetPolicy(ctx context.Context, passerID pgtype.UUID) (*query.DisclosurePolicy, error) {
+	policy, err := s.queries.GetDisclosurePolicyByPasserId(ctx, passerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disclosure policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// ConfigurePolicy はパッサーの全受取人を対象に、threshold 人で開ける金庫を作り直します。
+// 既存のアカウント、デバイス、サブスクリプションは新しい金庫の鍵で暗号化し直す
+func (s *DisclosureService) ConfigurePolicy(ctx context.Context, passerID pgtype.UUID, threshold int32) (query.DisclosurePolicy, error) {
+	receivers, err := s.queries.ListReceiverIDsByPasserID(ctx, passerID)
+	if err != nil {
+		return query.DisclosurePolicy{}, fmt.Errorf("failed to list receivers: %w", err)
+	}
+	if len(receivers) == 0 {
+		return query.DisclosurePolicy{}, ErrNoReceivers
+	}
+	if threshold < 1 || int(threshold) > len(receivers) {
+		return query.DisclosurePolicy{}, fmt.Errorf("%w: must be between 1 and %d, got %d", ErrInvalidThreshold, len(receivers), threshold)
+	}
+
+	receiverIDs := make([]string, len(receivers))
+	for i, receiver := range receivers {
+		receiverIDs[i] = receiver.String()
+	}
+	resp, err := s.cryptoClient.ConfigureThresholdVault(ctx, &crypto.ConfigureThresholdVaultRequest{
+		PasserUserId:    passerID.String(),
+		Threshold:       threshold,
+		ReceiverUserIds: receiverIDs,
+	})
+	if err != nil {
+		return query.DisclosurePolicy{}, fmt.Errorf("failed to configure vault: %w", err)
+	}
+
+	policy, err := s.queries.UpsertDisclosurePolicy(ctx, query.UpsertDisclosurePolicyParams{
+		PasserID:     passerID,
+		Threshold:    threshold,
+		ShareCount:   int32(len(receivers)),
+		VaultVersion: resp.GetVaultVersion(),
+		ReceiverIds:  receivers,
+	})
+	if err != nil {
+		return query.DisclosurePolicy{}, fmt.Errorf("failed to save disclosure policy: %w", err)
+	}
+	return policy, s.sealAll(ctx, passerID)
+}
+
+// ResplitPolicy は金庫の鍵のシェアを配った後に受取人が変わっていれば、同じ threshold で金庫を作り直します。
+// 受取人が threshold 人より少なくなったときは ErrInvalidThreshold を返し、作り直さない。
+// 金庫をすでに開けていれば、シェアはもう使わないので何もしない
+func (s *DisclosureService) ResplitPolicy(ctx context.Context, passerID pgtype.UUID) (*query.DisclosurePolicy, error) {
+	policy, err := s.GetPolicy(ctx, passerID)
+	if err != nil || policy == nil || policy.UnlockedAt.Valid {
+		return policy, err
+	}
+	receivers, err := s.queries.ListReceiverIDsByPasserID(ctx, passerID)
+	if err != nil {
+		return policy, fmt.Errorf("failed to list receivers: %w", err)
+	}
+	if slices.Equal(receivers, policy.ReceiverIds) {
+		return policy, nil
+	}
+	resplit, err := s.ConfigurePolicy(ctx, passerID, policy.Threshold)
+	if err != nil {
+		return policy, err
+	}
+	return &resplit, nil
+}
+
+// sealAll はパッサーのアカウント、デバイス、サブスクリプションをすべて金庫の鍵で暗号化し直します
+func (s *DisclosureService) sealAll(ctx context.Context, passerID pgtype.UUID) error {
+	accounts, err := s.queries.ListAccountsByPasserId(ctx, passerID)
+	if err != nil {
+		return fmt.Errorf("failed to list accounts to seal: %w", err)
+	}
+	devices, err := s.queries.ListDevicesByPasserId(ctx, passerID)
+	if err != nil {
+		return fmt.Errorf("failed to list devices to seal: %w", err)
+	}
+	subscriptions, err := s.queries.ListSubscriptionsByPasserId(ctx, passerID)
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions to seal: %w", err)
+	}
+
+	var errs []error
+	for start := 0; start < len(accounts); start += vaultSealBatchSize {
+		end := min(start+vaultSealBatchSize, len(accounts))
+		errs = append(errs, s.sealAccounts(ctx, passerID, accounts[start:end]))
+	}
+	for start := 0; start < len(devices); start += vaultSealBatchSize {
+		end := min(start+vaultSealBatchSize, len(devices))
+		errs = append(errs, s.sealDevices(ctx, passerID, devices[start:end]))
+	}
+	for start := 0; start < len(subscriptions); start += vaultSealBatchSize {
+		end := min(start+vaultSealBatchSize, len(subscriptions))
+		errs = append(errs, s.sealSubscriptions(ctx, passerID, subscriptions[start:end]))
+	}
+	return errors.Join(errs...)
+}
+
+// RemovePolicy はしきい値開示をやめ、1人の開示請求で開示される通常の動作に戻します
+func (s *DisclosureService) RemovePolicy(ctx context.Context, passerID pgtype.UUID) (query.DisclosurePolicy, error) {
+	return s.queries.DeleteDisclosurePolicy(ctx, passerID)
+}
+
+// SealAccount はしきい値開示が設定されていれば、アカウントのパスワードを金庫の鍵でも暗号化します
+func (s *DisclosureService) SealAccount(ctx context.Context, account query.Account) (query.Account, error) {
+	policy, err := s.GetPolicy(ctx, account.PasserID)
+	if err != nil || policy == nil {
+		return account, err
+	}
+	accounts := []query.Account{account}
+	if err := s.sealAccounts(ctx, account.PasserID, accounts); err != nil {
+		return account, err
+	}
+	return accounts[0], nil
+}
+
+// SealDevice はしきい値開示が設定されていれば、デバイスのパスワードを金庫の鍵でも暗号化します
+func (s *DisclosureService) SealDevice(ctx context.Context, device query.Device) (query.Device, error) {
+	policy, err := s.GetPolicy(ctx, device.PasserID)
+	if err != nil || policy == nil {
+		return device, err
+	}
+	devices := []query.Device{device}
+	if err := s.sealDevices(ctx, device.PasserID, devices); err != nil {
+		return device, err
+	}
+	return devices[0], nil
+}
+
+// SealSubscription はしきい値開示が設定されていれば、サブスクリプションのパスワードを金庫の鍵でも暗号化します
+func (s *DisclosureService) SealSubscription(ctx context.Context, subscription query.Subscription) (query.Subscription, error) {
+	policy, err := s.GetPolicy(ctx, subscription.PasserID)
+	if err != nil || policy == nil {
+		return subscription, err
+	}
+	subscriptions := []query.Subscription{subscription}
+	if err := s.sealSubscriptions(ctx, subscription.PasserID, subscriptions); err != nil {
+		return subscription, err
+	}
+	return subscriptions[0], nil
+}
+
+// vaultItem は金庫の鍵で暗号化する1件。ciphertext が空なら金庫の暗号文も消す
+type vaultItem struct {
+	name       string
+	ciphertext []byte
+	save       func(vaultCiphertext []byte) error
+}
+
+// sealAccounts は accounts の VaultEncPassword を書き換えて保存します
+func (s *DisclosureService) sealAccounts(ctx context.Context, passerID pgtype.UUID, accounts []query.Account) error {
+	items := make([]vaultItem, len(accounts))
+	for i := range accounts {
+		account := &accounts[i]
+		items[i] = vaultItem{
+			name:       fmt.Sprintf("account %d", account.ID),
+			ciphertext: account.EncPassword,
+			save: func(sealed []byte) error {
+				if err := s.queries.UpdateAccountVaultEncPassword(ctx, query.UpdateAccountVaultEncPasswordParams{
+					ID:               account.ID,
+					VaultEncPassword: sealed,
+				}); err != nil {
+					return err
+				}
+				account.VaultEncPassword = sealed
+				return nil
+			},
+		}
+	}
+	return s.sealItems(ctx, passerID, items)
+}
+
+// sealDevices は devices の VaultEncPassword を書き換えて保存します。暗号化前の平文は金庫に入れない
+func (s *DisclosureService) sealDevices(ctx context.Context, passerID pgtype.UUID, devices []query.Device) error {
+	items := make([]vaultItem, len(devices))
+	for i := range devices {
+		device := &devices[i]
+		var ciphertext []byte
+		if device.EncVersion != EncVersionPlaintext {
+			ciphertext = device.EncPassword
+		}
+		items[i] = vaultItem{
+			name:       fmt.Sprintf("device %d", device.ID),
+			ciphertext: ciphertext,
+			save: func(sealed []byte) error {
+				if err := s.queries.UpdateDeviceVaultEncPassword(ctx, query.UpdateDeviceVaultEncPasswordParams{
+					ID:               device.ID,
+					VaultEncPassword: sealed,
+				}); err != nil {
+					return err
+				}
+				device.VaultEncPassword = sealed
+				return nil
+			},
+		}
+	}
+	return s.sealItems(ctx, passerID, items)
+}
+
+// sealSubscriptions は subscriptions の VaultEncPassword を書き換えて保存します。暗号化前の平文は金庫に入れない
+func (s *DisclosureService) sealSubscriptions(ctx context.Context, passerID pgtype.UUID, subscriptions []query.Subscription) error {
+	items := make([]vaultItem, len(subscriptions))
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		var ciphertext []byte
+		if subscription.EncVersion != EncVersionPlaintext {
+			ciphertext = subscription.EncPassword
+		}
+		items[i] = vaultItem{
+			name:       fmt.Sprintf("subscription %d", subscription.ID),
+			ciphertext: ciphertext,
+			save: func(sealed []byte) error {
+				if err := s.queries.UpdateSubscriptionVaultEncPassword(ctx, query.UpdateSubscriptionVaultEncPasswordParams{
+					ID:               subscription.ID,
+					VaultEncPassword: sealed,
+				}); err != nil {
+					return err
+				}
+				subscription.VaultEncPassword = sealed
+				return nil
+			},
+		}
+	}
+	return s.sealItems(ctx, passerID, items)
+}
+
+// sealItems は items をまとめて金庫の鍵で暗号化し、1件ずつ保存します
+func (s *DisclosureService) sealItems(ctx context.Context, passerID pgtype.UUID, items []vaultItem) error {
+	var ciphertexts [][]byte
+	var sealing []int
+	for i, item := range items {
+		if len(item.ciphertext) == 0 {
+			continue
+		}
+		ciphertexts = append(ciphertexts, item.ciphertext)
+		sealing = append(sealing, i)
+	}
+
+	var results []*crypto.EncryptResult
+	if len(ciphertexts) > 0 {
+		grant, err := s.grants.Handover(crypto.DecryptPurpose_DECRYPT_PURPOSE_SEAL_TO_VAULT, passerID, passerID, ciphertexts)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		resp, err := s.cryptoClient.SealToVault(ctx, &crypto.SealToVaultRequest{
+			PasserUserId: passerID.String(),
+			Ciphertexts:  ciphertexts,
+			Grant:        grant,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seal secrets to vault: %w", err)
+		}
+		results = resp.GetResults()
+	}
+
+	var errs []error
+	sealed := make(map[int][]byte, len(sealing))
+	for j, i := range sealing {
+		if j >= len(results) {
+			errs = append(errs, fmt.Errorf("no vault result for %s", items[i].name))
+			continue
+		}
+		if results[j].GetError() != "" {
+			errs = append(errs, fmt.Errorf("failed to seal %s to vault: %s", items[i].name, results[j].GetError()))
+			continue
+		}
+		sealed[i] = results[j].GetCiphertext()
+	}
+	for i, item := range items {
+		if len(item.ciphertext) > 0 && sealed[i] == nil {
+			continue
+		}
+		if err := item.save(sealed[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save vault password of %s: %w", item.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// discloseWithQuorum は期限を過ぎても止められなかった開示請求を請求者ごとに数え、
+// threshold 人に達していれば金庫を開けて、揃った請求をまとめて開示します
+func (s *DisclosureService) discloseWithQuorum(ctx context.Context, disclosure query.Disclosure, policy query.DisclosurePolicy) (query.Disclosure, error) {
+	matured, err := s.queries.ListMaturedDisclosuresByPasserId(ctx, disclosure.PasserID)
+	if err != nil {
+		return disclosure, fmt.Errorf("failed to list matured disclosures: %w", err)
+	}
+	pending := []query.Disclosure{disclosure}
+	requesters := map[pgtype.UUID]bool{disclosure.RequesterID: true}
+	for _, d := range matured {
+		requesters[d.RequesterID] = true
+		if d.ID != disclosure.ID && d.Status != DisclosureStatusDisclosed {
+			pending = append(pending, d)
+		}
+	}
+	if len(requesters) < int(policy.Threshold) {
+		return disclosure, fmt.Errorf("%w: %d of %d receivers", ErrQuorumNotReached, len(requesters), policy.Threshold)
+	}
+
+	// シェアの組み立ては crypto サービスの中で行い、足りなければそこで拒否される
+	if !policy.UnlockedAt.Valid {
+		requesterIDs := make([]string, 0, len(requesters))
+		for requester := range requesters {
+			requesterIDs = append(requesterIDs, requester.String())
+		}
+		grant, err := s.grants.Unlock(disclosure.PasserID, requesterIDs)
+		if err != nil {
+			return disclosure, err
+		}
+		if _, err := s.cryptoClient.UnlockVault(ctx, &crypto.UnlockVaultRequest{
+			PasserUserId:     disclosure.PasserID.String(),
+			RequesterUserIds: requesterIDs,
+			Grant:            grant,
+		}); err != nil {
+			return disclosure, fmt.Errorf("failed to unlock vault: %w", err)
+		}
+		policy, err = s.queries.MarkDisclosurePolicyUnlocked(ctx, query.MarkDisclosurePolicyUnlockedParams{
+			PasserID:     policy.PasserID,
+			VaultVersion: policy.VaultVersion,
+		})
+		if err != nil {
+			return disclosure, fmt.Errorf("failed to mark vault as unlocked: %w", err)
+		}
+	}
+
+	var disclosed query.Disclosure
+	var errs []error
+	for i, d := range pending {
+		marked, err := s.markDisclosed(ctx, d)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if i == 0 {
+			disclosed = marked
+		}
+		if err := s.releaseAll(ctx, marked.PasserID, marked.RequesterID); err != nil {
+			errs = append(errs, err)
+		}
+		if err := s.revokeAliveChecks(ctx, marked.ID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return disclosed, errors.Join(errs...)
+}
+
+// releaseFromVault は金庫の鍵で暗号化したパスワードを受取人の鍵で暗号化し直して引き渡します。
+// 伝言だけを見せる割り当ては金庫を開けずに引き渡す。引き渡せた件数を返す
+func (s *DisclosureService) releaseFromVault(ctx context.Context, policy *query.DisclosurePolicy, rows []query.ListAccountsByPasserIdAndReceiverIdRow, receiverID pgtype.UUID) (int, error) {
+	if !policy.UnlockedAt.Valid {
+		return 0, ErrQuorumNotReached
+	}
+
+	var ciphertexts [][]byte
+	var opening []int
+	var errs []error
+	for i, row := range rows {
+		if row.Permission != PermissionCredentials || len(row.Account.EncPassword) == 0 {
+			continue
+		}
+		if len(row.Account.VaultEncPassword) == 0 {
+			// パッサーの鍵から直接作り直すと、しきい値を迂回してしまう
+			errs = append(errs, fmt.Errorf("account %d: %w", row.Account.ID, ErrNotSealed))
+			continue
+		}
+		ciphertexts = append(ciphertexts, row.Account.VaultEncPassword)
+		opening = append(opening, i)
+	}
+
+	receiverEncPasswords := make(map[int][]byte, len(opening))
+	if len(ciphertexts) > 0 {
+		results, err := s.openVault(ctx, policy, receiverID, ciphertexts)
+		if err != nil {
+			return 0, err
+		}
+		for j, i := range opening {
+			if j >= len(results) {
+				errs = append(errs, fmt.Errorf("no vault result for account %d", rows[i].Account.ID))
+				continue
+			}
+			result := results[j]
+			if result.GetError() != "" {
+				errs = append(errs, fmt.Errorf("failed to open account %d from vault: %s", rows[i].Account.ID, result.GetError()))
+				continue
+			}
+			receiverEncPasswords[i] = result.GetCiphertext()
+		}
+	}
+
+	released := 0
+	for i, row := range rows {
+		if row.Permission == PermissionCredentials && len(row.Account.EncPassword) > 0 && receiverEncPasswords[i] == nil {
+			continue
+		}
+		if err := s.markAccountReleased(ctx, row, receiverEncPasswords[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		released++
+	}
+	return released, errors.Join(errs...)
+}
+
+// openVault は金庫の鍵で暗号化した ciphertexts を受取人の鍵で暗号化し直します。結果は ciphertexts と同じ順に返す
+func (s *DisclosureService) openVault(ctx context.Context, policy *query.DisclosurePolicy, receiverID pgtype.UUID, ciphertexts [][]byte) ([]*crypto.EncryptResult, error) {
+	grant, err := s.grants.Handover(crypto.DecryptPurpose_DECRYPT_PURPOSE_OPEN_VAULT, policy.PasserID, receiverID, ciphertexts)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	resp, err := s.cryptoClient.OpenVaultForRecipient(ctx, &crypto.OpenVaultForRecipientRequest{
+		PasserUserId:    policy.PasserID.String(),
+		RecipientUserId: receiverID.String(),
+		Ciphertexts:     ciphertexts,
+		Grant:           grant,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vault for receiver: %w", err)
+	}
+	return resp.GetResults(), nil
+}
+
+// handoverSecret は受取人に引き渡すパスワードの暗号文を作ります。
+// しきい値開示が設定されていれば金庫の鍵で暗号化した vaultCiphertext から作り、パッサーの鍵の ciphertext は使わない
+func (s *DisclosureService) handoverSecret(ctx context.Context, policy *query.DisclosurePolicy, passerID, receiverID pgtype.UUID, ciphertext, vaultCiphertext []byte) ([]byte, error) {
+	if policy == nil {
+		return s.reencryptForReceiver(ctx, passerID, receiverID, ciphertext)
+	}
+	if len(ciphertext) == 0 {
+		return nil, nil
+	}
+	if !policy.UnlockedAt.Valid {
+		return nil, ErrQuorumNotReached
+	}
+	if len(vaultCiphertext) == 0 {
+		// パッサーの鍵から直接作り直すと、しきい値を迂回してしまう
+		return nil, ErrNotSealed
+	}
+	results, err := s.openVault(ctx, policy, receiverID, [][]byte{vaultCiphertext})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errors.New("no vault result")
+	}
+	if results[0].GetError() != "" {
+		return nil, fmt.Errorf("failed to open vault: %s", results[0].GetError())
+	}
+	return results[0].GetCiphertext(), nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TestHandoverSecretRequiresVault はしきい値開示が設定されているとき、
+// パッサーの鍵の暗号文から受取人に引き渡さないことを確かめます
+func TestHandoverSecretRequiresVault(t *testing.T) {
+	locked := &query.DisclosurePolicy{Threshold: 2, ShareCount: 3}
+	unlocked := &query.DisclosurePolicy{Threshold: 2, ShareCount: 3, UnlockedAt: pgtype.Timestamp{Time: time.Now(), Valid: true}}
+
+	tests := []struct {
+		name            string
+		policy          *query.DisclosurePolicy
+		ciphertext      []byte
+		vaultCiphertext []byte
+		wantErr         error
+	}{
+		{
+			name:            "Quorum not reached",
+			policy:          locked,
+			ciphertext:      []byte("passer"),
+			vaultCiphertext: []byte("vault"),
+			wantErr:         ErrQuorumNotReached,
+		},
+		{
+			name:       "Not sealed to vault",
+			policy:     unlocked,
+			ciphertext: []byte("passer"),
+			wantErr:    ErrNotSealed,
+		},
+		{
+			name:   "Nothing to hand over",
+			policy: locked,
+		},
+	}
+
+	// crypto サービスを呼ぶ前に止まるので、クライアントはいらない
+	s := &DisclosureService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.handoverSecret(context.Background(), tt.policy, pgtype.UUID{}, pgtype.UUID{}, tt.ciphertext, tt.vaultCiphertext)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("handoverSecret() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != nil {
+				t.Errorf("handoverSecret() = %q, want nil", got)
+			}
+		})
+	}
+}
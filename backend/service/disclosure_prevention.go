@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrDisclosureNotPending は開示請求がすでに開示済みか、止められていることを表す
+var ErrDisclosureNotPending = errors.New("disclosure is not pending")
+
+// Prevent はパッサーの生存を確認したことを alive_check_histories に残し、その履歴で開示請求を止めます。
+// method はマジックリンクかアプリからかなど、生存を確認した方法
+func (s *DisclosureService) Prevent(ctx context.Context, disclosure query.Disclosure, method int32) (query.Disclosure, error) {
+	if !IsPending(disclosure) {
+		return disclosure, ErrDisclosureNotPending
+	}
+
+	history, err := s.queries.CreateAliveCheckHistory(ctx, query.CreateAliveCheckHistoryParams{
+		ID:           utils.ToPgxUUID(uuid.New()),
+		TargetUserID: disclosure.PasserID,
+		CheckMethod:  method,
+		CheckTime:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+		CustomData:   []byte("{}"),
+	})
+	if err != nil {
+		return disclosure, fmt.Errorf("failed to record alive check: %w", err)
+	}
+
+	// 履歴を残す間に開示されていれば止めない
+	prevented, err := transitionDisclosure(ctx, s.queries, disclosure, DisclosureStatusVetoed, DisclosureActorPasser, disclosure.PasserID, history.ID)
+	if errors.Is(err, ErrDisclosureStatusChanged) {
+		return disclosure, ErrDisclosureNotPending
+	}
+	if err != nil {
+		return disclosure, err
+	}
+
+	if err := s.revokeAliveChecks(ctx, prevented.ID); err != nil {
+		return prevented, err
+	}
+	return prevented, nil
+}
+
+// PreventAll はパッサーへのまだ止めていない開示請求をすべて止めます。
+// 1件の失敗で他の請求を止めるのはやめず、止めた請求と失敗をまとめて返す
+func (s *DisclosureService) PreventAll(ctx context.Context, passerID pgtype.UUID, method int32) ([]query.Disclosure, error) {
+	disclosures, err := s.queries.ListDisclosuresByPasserId(ctx, passerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disclosures: %w", err)
+	}
+
+	prevented := []query.Disclosure{}
+	var errs []error
+	for _, disclosure := range disclosures {
+		if !IsPending(disclosure) {
+			continue
+		}
+		d, err := s.Prevent(ctx, disclosure, method)
+		if errors.Is(err, ErrDisclosureNotPending) {
+			continue
+		}
+		if d.Status == DisclosureStatusVetoed {
+			prevented = append(prevented, d)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return prevented, errors.Join(errs...)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// 開示請求の状態 (disclosures.status)
+const (
+	// DisclosureStatusRequested は請求を受け、まだ生存確認を送っていないことを表す
+	DisclosureStatusRequested = "requested"
+	// DisclosureStatusNotifying はパッサーに最初の生存確認を送っていることを表す
+	DisclosureStatusNotifying = "notifying"
+	// DisclosureStatusGrace は生存確認を送り、期限まで待っていることを表す
+	DisclosureStatusGrace = "grace"
+	// DisclosureStatusVetoed はパッサーが生存を確認して止めたことを表す
+	DisclosureStatusVetoed = "vetoed"
+	// DisclosureStatusDisclosed は期限までに止められず開示したことを表す
+	DisclosureStatusDisclosed = "disclosed"
+	// DisclosureStatusCancelled は請求者が取り下げたことを表す
+	DisclosureStatusCancelled = "cancelled"
+	// DisclosureStatusExpired は期限を過ぎたが、請求者がもう受取人でないため開示しなかったことを表す
+	DisclosureStatusExpired = "expired"
+)
+
+// 開示請求の状態を変えた人 (disclosure_transitions.actor)
+const (
+	DisclosureActorRequester = "requester"
+	DisclosureActorPasser    = "passer"
+	// DisclosureActorSystem はスケジューラーなど、利用者の操作によらない変更
+	DisclosureActorSystem = "system"
+)
+
+// disclosureTransitions は状態ごとに、移れる状態とそれを行える人を表す。
+// ここにない状態からはどこにも移れない
+var disclosureTransitions = map[string]map[string]string{
+	DisclosureStatusRequested: {
+		DisclosureStatusNotifying: DisclosureActorSystem,
+		DisclosureStatusVetoed:    DisclosureActorPasser,
+		DisclosureStatusCancelled: DisclosureActorRequester,
+		DisclosureStatusDisclosed: DisclosureActorSystem,
+		DisclosureStatusExpired:   DisclosureActorSystem,
+	},
+	DisclosureStatusNotifying: {
+		DisclosureStatusGrace:     DisclosureActorSystem,
+		DisclosureStatusVetoed:    DisclosureActorPasser,
+		DisclosureStatusCancelled: DisclosureActorRequester,
+		DisclosureStatusDisclosed: DisclosureActorSystem,
+		DisclosureStatusExpired:   DisclosureActorSystem,
+	},
+	DisclosureStatusGrace: {
+		DisclosureStatusVetoed:    DisclosureActorPasser,
+		DisclosureStatusCancelled: DisclosureActorRequester,
+		DisclosureStatusDisclosed: DisclosureActorSystem,
+		DisclosureStatusExpired:   DisclosureActorSystem,
+	},
+}
+
+var (
+	// ErrIllegalTransition は今の状態から、その人がその状態には変えられないことを表す
+	ErrIllegalTransition = errors.New("illegal disclosure transition")
+	// ErrDisclosureStatusChanged は読んでから変えるまでの間に、他で状態が変わったことを表す
+	ErrDisclosureStatusChanged = errors.New("disclosure status has changed")
+	// ErrDeadlineShortened は期限を早めようとしたことを表す。パッサーが止めるための猶予を縮めさせない
+	ErrDeadlineShortened = errors.New("disclosure deadline cannot be moved earlier")
+)
+
+// CanTransition は actor が開示請求を from から to に変えられるかを確かめます
+func CanTransition(from, to, actor string) error {
+	allowed, ok := disclosureTransitions[from][to]
+	if !ok {
+		return fmt.Errorf("%w: %s to %s", ErrIllegalTransition, from, to)
+	}
+	if allowed != actor {
+		return fmt.Errorf("%w: %s cannot change %s to %s", ErrIllegalTransition, actor, from, to)
+	}
+	return nil
+}
+
+// IsPending は開示請求がまだ止められも開示されもしていないかを返します
+func IsPending(disclosure query.Disclosure) bool {
+	_, ok := disclosureTransitions[disclosure.Status]
+	return ok
+}
+
+// Request は requester からの開示請求を作成します。作成したことも状態の移り変わりとして残す
+func (s *DisclosureService) Request(ctx context.Context, params query.CreateDisclosureParams) (query.Disclosure, error) {
+	params.Actor = DisclosureActorRequester
+	params.ActorUserID = params.RequesterID
+	disclosure, err := s.queries.CreateDisclosure(ctx, params)
+	if err != nil {
+		return query.Disclosure{}, fmt.Errorf("failed to create disclosure: %w", err)
+	}
+	return disclosure, nil
+}
+
+// Transition は開示請求を to の状態に変えます。
+// 変えられない場合は ErrIllegalTransition を、他で先に変わっていれば ErrDisclosureStatusChanged を返す。
+// 片付いた請求の生存確認のリンクは取り消す。取り消しに失敗しても状態は変わったままで、変えた後の請求を返す
+func (s *DisclosureService) Transition(ctx context.Context, disclosure query.Disclosure, to, actor string, actorUserID pgtype.UUID) (query.Disclosure, error) {
+	updated, err := transitionDisclosure(ctx, s.queries, disclosure, to, actor, actorUserID, pgtype.UUID{})
+	if err != nil {
+		return updated, err
+	}
+	if !IsPending(updated) {
+		if err := s.revokeAliveChecks(ctx, updated.ID); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// UpdateDetails は請求者がまだ処理されていない開示請求の期限と付加情報を変えます。期限は延ばすことだけができる
+func (s *DisclosureService) UpdateDetails(ctx context.Context, disclosure query.Disclosure, deadline pgtype.Timestamp, customData []byte) (query.Disclosure, error) {
+	if !IsPending(disclosure) {
+		return disclosure, ErrDisclosureNotPending
+	}
+	if deadline.Time.Before(disclosure.Deadline.Time) {
+		return disclosure, ErrDeadlineShortened
+	}
+	updated, err := s.queries.UpdateDisclosureDetails(ctx, query.UpdateDisclosureDetailsParams{
+		ID:          disclosure.ID,
+		RequesterID: disclosure.RequesterID,
+		Deadline:    deadline,
+		CustomData:  customData,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return disclosure, ErrDisclosureNotPending
+	}
+	if err != nil {
+		return disclosure, fmt.Errorf("failed to update disclosure %d: %w", disclosure.ID, err)
+	}
+	return updated, nil
+}
+
+// transitionDisclosure は状態を変え、disclosure_transitions に残します。
+// preventedBy は vetoed にするときの生存確認の履歴。それ以外では Valid を false にする
+func transitionDisclosure(ctx context.Context, q *query.Queries, disclosure query.Disclosure, to, actor string, actorUserID, preventedBy pgtype.UUID) (query.Disclosure, error) {
+	if err := CanTransition(disclosure.Status, to, actor); err != nil {
+		return disclosure, err
+	}
+	// 読んだときの状態のままであれば変える。他で先に変わっていれば何もしない
+	updated, err := q.TransitionDisclosure(ctx, query.TransitionDisclosureParams{
+		ToStatus:    to,
+		PreventedBy: preventedBy,
+		ID:          disclosure.ID,
+		FromStatus:  disclosure.Status,
+		Actor:       actor,
+		ActorUserID: actorUserID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return disclosure, fmt.Errorf("%w: disclosure %d is no longer %s", ErrDisclosureStatusChanged, disclosure.ID, disclosure.Status)
+	}
+	if err != nil {
+		return disclosure, fmt.Errorf("failed to change disclosure %d to %s: %w", disclosure.ID, to, err)
+	}
+	return updated, nil
+}
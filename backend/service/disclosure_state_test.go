@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		actor string
+		legal bool
+	}{
+		{
+			name:  "System starts notifying",
+			from:  DisclosureStatusRequested,
+			to:    DisclosureStatusNotifying,
+			actor: DisclosureActorSystem,
+			legal: true,
+		},
+		{
+			name:  "System starts grace period",
+			from:  DisclosureStatusNotifying,
+			to:    DisclosureStatusGrace,
+			actor: DisclosureActorSystem,
+			legal: true,
+		},
+		{
+			name:  "Passer vetoes during grace period",
+			from:  DisclosureStatusGrace,
+			to:    DisclosureStatusVetoed,
+			actor: DisclosureActorPasser,
+			legal: true,
+		},
+		{
+			name:  "Passer vetoes before being notified",
+			from:  DisclosureStatusRequested,
+			to:    DisclosureStatusVetoed,
+			actor: DisclosureActorPasser,
+			legal: true,
+		},
+		{
+			name:  "Requester cancels",
+			from:  DisclosureStatusNotifying,
+			to:    DisclosureStatusCancelled,
+			actor: DisclosureActorRequester,
+			legal: true,
+		},
+		{
+			name:  "System discloses after deadline",
+			from:  DisclosureStatusGrace,
+			to:    DisclosureStatusDisclosed,
+			actor: DisclosureActorSystem,
+			legal: true,
+		},
+		{
+			name:  "System expires after deadline",
+			from:  DisclosureStatusGrace,
+			to:    DisclosureStatusExpired,
+			actor: DisclosureActorSystem,
+			legal: true,
+		},
+		{
+			name:  "Requester cannot disclose",
+			from:  DisclosureStatusGrace,
+			to:    DisclosureStatusDisclosed,
+			actor: DisclosureActorRequester,
+			legal: false,
+		},
+		{
+			name:  "Passer cannot cancel",
+			from:  DisclosureStatusGrace,
+			to:    DisclosureStatusCancelled,
+			actor: DisclosureActorPasser,
+			legal: false,
+		},
+		{
+			name:  "Requester cannot veto",
+			from:  DisclosureStatusGrace,
+			to:    DisclosureStatusVetoed,
+			actor: DisclosureActorRequester,
+			legal: false,
+		},
+		{
+			name:  "Grace period does not go back",
+			from:  DisclosureStatusGrace,
+			to:    DisclosureStatusNotifying,
+			actor: DisclosureActorSystem,
+			legal: false,
+		},
+		{
+			name:  "Disclosed is final",
+			from:  DisclosureStatusDisclosed,
+			to:    DisclosureStatusVetoed,
+			actor: DisclosureActorPasser,
+			legal: false,
+		},
+		{
+			name:  "Vetoed is final",
+			from:  DisclosureStatusVetoed,
+			to:    DisclosureStatusDisclosed,
+			actor: DisclosureActorSystem,
+			legal: false,
+		},
+		{
+			name:  "Cancelled is final",
+			from:  DisclosureStatusCancelled,
+			to:    DisclosureStatusGrace,
+			actor: DisclosureActorSystem,
+			legal: false,
+		},
+		{
+			name:  "Expired is final",
+			from:  DisclosureStatusExpired,
+			to:    DisclosureStatusDisclosed,
+			actor: DisclosureActorSystem,
+			legal: false,
+		},
+		{
+			name:  "Unknown status",
+			from:  "in_progress",
+			to:    DisclosureStatusDisclosed,
+			actor: DisclosureActorSystem,
+			legal: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CanTransition(tt.from, tt.to, tt.actor)
+			if tt.legal && err != nil {
+				t.Errorf("CanTransition() error = %v, want nil", err)
+			}
+			if !tt.legal && !errors.Is(err, ErrIllegalTransition) {
+				t.Errorf("CanTransition() error = %v, want %v", err, ErrIllegalTransition)
+			}
+		})
+	}
+}
+
+func TestIsPending(t *testing.T) {
+	pending := map[string]bool{
+		DisclosureStatusRequested: true,
+		DisclosureStatusNotifying: true,
+		DisclosureStatusGrace:     true,
+		DisclosureStatusVetoed:    false,
+		DisclosureStatusDisclosed: false,
+		DisclosureStatusCancelled: false,
+		DisclosureStatusExpired:   false,
+	}
+	for status, want := range pending {
+		if got := IsPending(query.Disclosure{Status: status}); got != want {
+			t.Errorf("IsPending(%s) = %v, want %v", status, got, want)
+		}
+	}
+}
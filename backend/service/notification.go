@@ -0,0 +1,240 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/pkg/line"
+	"github.com/a-company-jp/digi-baton/backend/pkg/mail"
+	"github.com/a-company-jp/digi-baton/backend/pkg/utils"
+	"github.com/clerk/clerk-sdk-go/v2/user"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// webhookTimeout は Webhook 1回あたりの待ち時間
+const webhookTimeout = 10 * time.Second
+
+// NotificationService はユーザーへの通知を送る。連絡先は Clerk から取得する
+type NotificationService struct {
+	queries     *query.Queries
+	mailSender  *mail.Sender
+	frontendURL string
+	// LINE 公式アカウントのチャネルアクセストークン。空なら LINE には送らない
+	lineChannelToken string
+	httpClient       *http.Client
+}
+
+// NewNotificationService は新しい NotificationService を作成します
+func NewNotificationService(q *query.Queries, mailSender *mail.Sender, frontendURL, lineChannelToken string) *NotificationService {
+	return &NotificationService{
+		queries:          q,
+		mailSender:       mailSender,
+		frontendURL:      frontendURL,
+		lineChannelToken: lineChannelToken,
+		httpClient:       &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// contact はユーザーの連絡先
+type contact struct {
+	ClerkUserID string
+	Email       string
+	Name        string
+}
+
+// aliveCheck は送る生存確認の内容
+type aliveCheck struct {
+	// 開示請求への生存確認なら開示請求のID。定期的な生存確認なら 0
+	DisclosureID int32
+	PasserID     pgtype.UUID
+	// この時刻までに確認がなければ開示される
+	Deadline  time.Time
+	VerifyURL string
+}
+
+// aliveCheckWebhook は Webhook に送る生存確認の内容
+type aliveCheckWebhook struct {
+	Type         string `json:"type"`
+	DisclosureID int32  `json:"disclosureID,omitempty"`
+	PasserID     string `json:"passerID"`
+	VerifyURL    string `json:"verifyURL"`
+	Deadline     string `json:"deadline"`
+}
+
+// NotifyDisclosed は開示請求が開示されたことを請求者に知らせます
+func (s *NotificationService) NotifyDisclosed(ctx context.Context, disclosure query.Disclosure) error {
+	requester, err := s.contact(ctx, disclosure.RequesterID)
+	if err != nil {
+		return err
+	}
+	passer, err := s.contact(ctx, disclosure.PasserID)
+	if err != nil {
+		return err
+	}
+	if err := s.mailSender.SendDisclosedEmail(requester.Email, requester.Name, passer.Name, s.frontendURL); err != nil {
+		return fmt.Errorf("failed to send disclosure email to %s: %w", disclosure.RequesterID.String(), err)
+	}
+	return nil
+}
+
+// NotifyTrustInvitation は受取人として招待されたことを、招待したメールアドレスに知らせます
+func (s *NotificationService) NotifyTrustInvitation(ctx context.Context, invitation query.TrustInvitation, inviteURL string) error {
+	passer, err := s.contact(ctx, invitation.PasserUserID)
+	if err != nil {
+		return err
+	}
+	days := int(time.Until(invitation.ExpiresAt.Time).Hours() / 24)
+	if err := s.mailSender.SendTrustInvitationEmail(invitation.Email, passer.Name, inviteURL, max(days, 1)); err != nil {
+		return fmt.Errorf("failed to send trust invitation %d: %w", invitation.ID, err)
+	}
+	return nil
+}
+
+// contact はユーザーのメールアドレスと表示名を Clerk から取得します
+func (s *NotificationService) contact(ctx context.Context, userID pgtype.UUID) (contact, error) {
+	u, err := s.queries.GetUser(ctx, userID)
+	if err != nil {
+		return contact{}, fmt.Errorf("failed to get user %s: %w", userID.String(), err)
+	}
+	clerkUser, err := user.Get(ctx, u.ClerkUserID)
+	if err != nil {
+		return contact{}, fmt.Errorf("failed to get clerk user of %s: %w", userID.String(), err)
+	}
+	if len(clerkUser.EmailAddresses) == 0 {
+		return contact{}, fmt.Errorf("user %s has no email address", userID.String())
+	}
+
+	c := contact{ClerkUserID: u.ClerkUserID, Email: clerkUser.EmailAddresses[0].EmailAddress}
+	if clerkUser.FirstName != nil {
+		c.Name = *clerkUser.FirstName
+	}
+	if c.Name == "" {
+		c.Name = c.Email
+	}
+	return c, nil
+}
+
+// verifiedEmails は Clerk で確認済みのメールアドレスを小文字にそろえて返します
+func (s *NotificationService) verifiedEmails(ctx context.Context, clerkUserID string) ([]string, error) {
+	clerkUser, err := user.Get(ctx, clerkUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clerk user %s: %w", clerkUserID, err)
+	}
+	var emails []string
+	for _, address := range clerkUser.EmailAddresses {
+		if address.Verification == nil || address.Verification.Status != "verified" {
+			continue
+		}
+		emails = append(emails, strings.ToLower(address.EmailAddress))
+	}
+	return emails, nil
+}
+
+// aliveCheckChannels はユーザーの経路を催促で広げる順に並べます。
+// メールは設定がなくても Clerk のアドレスに送る
+func (s *NotificationService) aliveCheckChannels(ctx context.Context, userID pgtype.UUID) ([]query.NotificationChannel, error) {
+	configured, err := s.queries.ListNotificationChannelsByUserId(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	byChannel := make(map[string]query.NotificationChannel, len(configured))
+	for _, channel := range configured {
+		byChannel[channel.Channel] = channel
+	}
+	if _, ok := byChannel[NotificationChannelEmail]; !ok {
+		byChannel[NotificationChannelEmail] = query.NotificationChannel{UserID: userID, Channel: NotificationChannelEmail}
+	}
+
+	var channels []query.NotificationChannel
+	for _, name := range notificationEscalation {
+		if channel, ok := byChannel[name]; ok {
+			channels = append(channels, channel)
+		}
+	}
+	return channels, nil
+}
+
+// sendAliveCheck は生存確認のリンクを1つの経路で送ります。
+// email の address が空なら Clerk のメールアドレスに送る
+func (s *NotificationService) sendAliveCheck(ctx context.Context, channel, address string, passer contact, check aliveCheck) error {
+	switch channel {
+	case NotificationChannelEmail:
+		if address == "" {
+			address = passer.Email
+		}
+		hours := int(time.Until(check.Deadline).Hours())
+		return s.mailSender.SendVerificationEmail(address, passer.Name, check.VerifyURL, max(hours, 1))
+	case NotificationChannelLine:
+		if s.lineChannelToken == "" {
+			return errors.New("LINE channel access token is not configured")
+		}
+		reason := "開示請求が届いています。"
+		if check.DisclosureID == 0 {
+			reason = "定期的な生存確認の時期になりました。"
+		}
+		text := fmt.Sprintf("%s 様\n%s%s までに次のリンクから生存確認をしてください。\n%s",
+			passer.Name, reason, check.Deadline.Format("2006-01-02 15:04"), check.VerifyURL)
+		return line.PushText(s.lineChannelToken, address, text)
+	case NotificationChannelWebhook:
+		payload := aliveCheckWebhook{
+			Type:         "alive_check",
+			DisclosureID: check.DisclosureID,
+			PasserID:     check.PasserID.String(),
+			VerifyURL:    check.VerifyURL,
+			Deadline:     check.Deadline.Format(time.RFC3339),
+		}
+		if check.DisclosureID == 0 {
+			payload.Type = "scheduled_alive_check"
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned %s", resp.Status)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown notification channel %q", channel)
+	}
+}
+
+// recordAliveCheckSent は生存確認を1つの経路で送ったことを alive_check_histories に残します
+func (s *NotificationService) recordAliveCheckSent(ctx context.Context, userID pgtype.UUID, channel string, data map[string]any, sendErr error) error {
+	data["channel"] = channel
+	if sendErr != nil {
+		data["error"] = sendErr.Error()
+	}
+	customData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := s.queries.CreateAliveCheckHistory(ctx, query.CreateAliveCheckHistoryParams{
+		ID:           utils.ToPgxUUID(uuid.New()),
+		TargetUserID: userID,
+		CheckMethod:  aliveCheckMethods[channel],
+		CheckTime:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+		CustomData:   customData,
+	}); err != nil {
+		return fmt.Errorf("failed to record alive check: %w", err)
+	}
+	return nil
+}
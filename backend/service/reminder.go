@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/pkg/verification"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// 生存確認の方法 (alive_check_histories.check_method)
+const (
+	// AliveCheckMethodMagicLink はパッサーがマジックリンクで生存を確認したことを表す
+	AliveCheckMethodMagicLink int32 = 1
+	// 以下は催促を送ったことを表す。check_success は送った時点では false
+	AliveCheckMethodEmail   int32 = 2
+	AliveCheckMethodLine    int32 = 3
+	AliveCheckMethodWebhook int32 = 4
+	// AliveCheckMethodApp はパッサーがログインしてアプリから生存を確認したことを表す
+	AliveCheckMethodApp int32 = 5
+)
+
+// 生存確認を受け取る経路 (notification_channels.channel)
+const (
+	NotificationChannelEmail   = "email"
+	NotificationChannelLine    = "line"
+	NotificationChannelWebhook = "webhook"
+)
+
+// notificationEscalation は催促の段階が進むごとに広げていく経路の順番
+var notificationEscalation = []string{NotificationChannelEmail, NotificationChannelLine, NotificationChannelWebhook}
+
+var aliveCheckMethods = map[string]int32{
+	NotificationChannelEmail:   AliveCheckMethodEmail,
+	NotificationChannelLine:    AliveCheckMethodLine,
+	NotificationChannelWebhook: AliveCheckMethodWebhook,
+}
+
+// DefaultReminderPlan は催促の計画を設定していないパッサーに使う、開示請求からの経過時間 (時間)。
+// 期限が近づくほど間隔を短くする
+var DefaultReminderPlan = []int32{0, 72, 120, 144}
+
+const (
+	maxReminderSteps       = 10
+	maxReminderOffsetHours = 24 * 365
+)
+
+// ValidateReminderPlan は催促の計画が昇順で、件数と時間が上限以内であることを確かめます
+func ValidateReminderPlan(offsetsHours []int32) error {
+	if len(offsetsHours) == 0 || len(offsetsHours) > maxReminderSteps {
+		return fmt.Errorf("催促は1回以上 %d 回以下にしてください", maxReminderSteps)
+	}
+	for i, offset := range offsetsHours {
+		if offset < 0 || offset > maxReminderOffsetHours {
+			return fmt.Errorf("催促の時間は0時間以上 %d 時間以下にしてください", maxReminderOffsetHours)
+		}
+		if i > 0 && offset <= offsetsHours[i-1] {
+			return errors.New("催促の時間は昇順に並べてください")
+		}
+	}
+	return nil
+}
+
+// lineUserIDPattern は Messaging API の送り先になる LINE のユーザーID
+var lineUserIDPattern = regexp.MustCompile(`^U[0-9a-f]{32}$`)
+
+// ValidateNotificationChannel は経路ごとに宛先の形式を確かめます
+func ValidateNotificationChannel(channel, address string) error {
+	switch channel {
+	case NotificationChannelEmail:
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("メールアドレスが不正です: %w", err)
+		}
+	case NotificationChannelLine:
+		if !lineUserIDPattern.MatchString(address) {
+			return errors.New("LINE のユーザーIDが不正です")
+		}
+	case NotificationChannelWebhook:
+		u, err := url.Parse(address)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("Webhook の URL は https で指定してください")
+		}
+	default:
+		return fmt.Errorf("channel は %s、%s、%s のいずれかを指定してください", NotificationChannelEmail, NotificationChannelLine, NotificationChannelWebhook)
+	}
+	return nil
+}
+
+// ReminderService は開示請求の猶予期間中に、パッサーへ生存確認を催促する
+type ReminderService struct {
+	queries            *query.Queries
+	notifications      *NotificationService
+	tokens             *verification.VerificationTokenManager
+	verificationURLFmt string
+}
+
+// NewReminderService は新しい ReminderService を作成します。
+// verificationURLFmt はトークンを埋め込む生存確認のURL。開示請求はトークンに結び付く
+func NewReminderService(q *query.Queries, notifications *NotificationService, tokens *verification.VerificationTokenManager, verificationURLFmt string) *ReminderService {
+	return &ReminderService{queries: q, notifications: notifications, tokens: tokens, verificationURLFmt: verificationURLFmt}
+}
+
+// Plan はパッサーの催促の計画を返します。設定していなければ DefaultReminderPlan を返す
+func (s *ReminderService) Plan(ctx context.Context, passerID pgtype.UUID) ([]int32, bool, error) {
+	plan, err := s.queries.GetReminderPlan(ctx, passerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultReminderPlan, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get reminder plan: %w", err)
+	}
+	return plan.OffsetsHours, false, nil
+}
+
+// Remind は開示請求の今の段階の催促をまだ送っていなければ送ります。
+// 段階 n では、パッサーが設定した経路のうち先頭から n+1 個に送る。
+// 送る前に disclosure_reminders に記録するので、何度呼んでも同じ段階を二重に送らない。
+// 最初に送るときは notifying にし、送り終えたら grace にする
+func (s *ReminderService) Remind(ctx context.Context, disclosure query.Disclosure) error {
+	if !IsPending(disclosure) {
+		return nil
+	}
+
+	plan, _, err := s.Plan(ctx, disclosure.PasserID)
+	if err != nil {
+		return err
+	}
+	step := dueReminderStep(plan, disclosure.IssuedTime.Time, disclosure.Deadline.Time, time.Now())
+	if step < 0 {
+		return nil
+	}
+
+	if disclosure.Status == DisclosureStatusRequested {
+		disclosure, err = transitionDisclosure(ctx, s.queries, disclosure, DisclosureStatusNotifying, DisclosureActorSystem, pgtype.UUID{}, pgtype.UUID{})
+		// 他で先に送り始めたか、止められた
+		if errors.Is(err, ErrDisclosureStatusChanged) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	channels, err := s.notifications.aliveCheckChannels(ctx, disclosure.PasserID)
+	if err != nil {
+		return err
+	}
+	if len(channels) > step+1 {
+		channels = channels[:step+1]
+	}
+
+	// 同じ段階の経路には同じリンクを送る。新しいリンクを発行すると前の段階のリンクは使えなくなる
+	var passer *contact
+	var verifyURL string
+	var errs []error
+	for _, channel := range channels {
+		claimed, err := s.queries.ClaimDisclosureReminder(ctx, query.ClaimDisclosureReminderParams{
+			DisclosureID: disclosure.ID,
+			Step:         int32(step),
+			Channel:      channel.Channel,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record reminder: %w", err)
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		if passer == nil {
+			c, err := s.notifications.contact(ctx, disclosure.PasserID)
+			if err != nil {
+				return err
+			}
+			passer = &c
+			token, err := s.tokens.IssueUntil(ctx, disclosure.PasserID, verification.PurposeDisclosure, disclosure.ID, disclosure.Deadline.Time)
+			if err != nil {
+				return fmt.Errorf("failed to issue verification token: %w", err)
+			}
+			verifyURL = fmt.Sprintf(s.verificationURLFmt, token)
+		}
+		sendErr := s.send(ctx, channel, *passer, disclosure, verifyURL)
+		if err := s.recordAttempt(ctx, disclosure, step, channel.Channel, sendErr); err != nil {
+			errs = append(errs, err)
+		}
+		if sendErr != nil {
+			errs = append(errs, fmt.Errorf("failed to send %s reminder: %w", channel.Channel, sendErr))
+		}
+	}
+
+	if disclosure.Status == DisclosureStatusNotifying {
+		if _, err := transitionDisclosure(ctx, s.queries, disclosure, DisclosureStatusGrace, DisclosureActorSystem, pgtype.UUID{}, pgtype.UUID{}); err != nil && !errors.Is(err, ErrDisclosureStatusChanged) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dueReminderStep は now の時点で送るべき最後の段階を返します。まだなければ -1。
+// 期限以降の段階は送らない。止まっていた間に過ぎた段階は飛ばし、最新の段階だけを送る
+func dueReminderStep(plan []int32, issued, deadline, now time.Time) int {
+	step := -1
+	for i, offset := range plan {
+		at := issued.Add(time.Duration(offset) * time.Hour)
+		if at.After(now) || !at.Before(deadline) {
+			break
+		}
+		step = i
+	}
+	return step
+}
+
+func (s *ReminderService) send(ctx context.Context, channel query.NotificationChannel, passer contact, disclosure query.Disclosure, verifyURL string) error {
+	return s.notifications.sendAliveCheck(ctx, channel.Channel, channel.Address, passer, aliveCheck{
+		DisclosureID: disclosure.ID,
+		PasserID:     disclosure.PasserID,
+		Deadline:     disclosure.Deadline.Time,
+		VerifyURL:    verifyURL,
+	})
+}
+
+// recordAttempt は催促を1回送ったことを alive_check_histories に残します
+func (s *ReminderService) recordAttempt(ctx context.Context, disclosure query.Disclosure, step int, channel string, sendErr error) error {
+	data := map[string]any{
+		"disclosureID": disclosure.ID,
+		"step":         step,
+	}
+	return s.notifications.recordAliveCheckSent(ctx, disclosure.PasserID, channel, data, sendErr)
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/a-company-jp/digi-baton/backend/pkg/verification"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// trustInvitationTTL は招待のリンクの有効期限
+const trustInvitationTTL = 14 * 24 * time.Hour
+
+// trustInvitationTokenBytes は招待のトークンの乱数のバイト数
+const trustInvitationTokenBytes = 32
+
+// 招待の状態
+const (
+	TrustInvitationPending  = "pending"
+	TrustInvitationAccepted = "accepted"
+	TrustInvitationDeclined = "declined"
+	TrustInvitationRevoked  = "revoked"
+)
+
+var (
+	// ErrInvitationNotFound は招待がないか、操作するユーザーへの招待でないことを表す
+	ErrInvitationNotFound = errors.New("trust invitation not found")
+	// ErrInvitationClosed は招待に返事済みか、取り消されたか、期限が切れたことを表す
+	ErrInvitationClosed = errors.New("trust invitation is no longer pending")
+	// ErrInvitationLinked は同じメールアドレスへの招待がすでに受取人と結びつき、返事を待っていることを表す
+	ErrInvitationLinked = errors.New("trust invitation is already linked to a user")
+	// ErrOwnInvitation はパッサーが自分の招待に返事しようとしたことを表す
+	ErrOwnInvitation = errors.New("cannot respond to own trust invitation")
+)
+
+// TrustInvitationService はアカウントのない受取人を、パッサーがメールアドレスで招待する。
+// 招待された人が登録するかリンクを開くと trusts の行ができ、承諾するまではその相続関係で開示請求できない
+type TrustInvitationService struct {
+	queries       *query.Queries
+	notifications *NotificationService
+	inviteURLFmt  string
+}
+
+// NewTrustInvitationService は新しい TrustInvitationService を作成します。
+// inviteURLFmt はトークンを埋め込む招待のURL
+func NewTrustInvitationService(q *query.Queries, notifications *NotificationService, inviteURLFmt string) *TrustInvitationService {
+	return &TrustInvitationService{
+		queries:       q,
+		notifications: notifications,
+		inviteURLFmt:  inviteURLFmt,
+	}
+}
+
+// NormalizeInvitationEmail は招待するメールアドレスを確かめ、小文字にそろえます
+func NormalizeInvitationEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", fmt.Errorf("メールアドレスが不正です: %q", email)
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+// Invite は email を受取人として招待し、リンクをメールで送ります。email は NormalizeInvitationEmail でそろえたもの。
+// 同じメールアドレスへの、まだ誰とも結びついていない招待は取り消し、新しいリンクだけを使えるようにする
+func (s *TrustInvitationService) Invite(ctx context.Context, passerID pgtype.UUID, email string) (query.TrustInvitation, error) {
+	existing, err := s.queries.GetPendingTrustInvitationByEmail(ctx, query.GetPendingTrustInvitationByEmailParams{
+		PasserUserID: passerID,
+		Email:        email,
+	})
+	if err == nil && existing.ReceiverUserID.Valid && existing.TrustID.Valid {
+		return existing, ErrInvitationLinked
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return query.TrustInvitation{}, fmt.Errorf("failed to get pending invitation: %w", err)
+	}
+
+	token, err := verification.GenerateRandomToken(trustInvitationTokenBytes)
+	if err != nil {
+		return query.TrustInvitation{}, err
+	}
+	invitation, err := s.queries.CreateTrustInvitation(ctx, query.CreateTrustInvitationParams{
+		PasserUserID: passerID,
+		Email:        email,
+		TokenHash:    verification.HashToken(token),
+		ExpiresAt:    pgtype.Timestamp{Time: time.Now().Add(trustInvitationTTL), Valid: true},
+	})
+	if err != nil {
+		return query.TrustInvitation{}, fmt.Errorf("failed to store invitation: %w", err)
+	}
+
+	if err := s.notifications.NotifyTrustInvitation(ctx, invitation, fmt.Sprintf(s.inviteURLFmt, token)); err != nil {
+		return invitation, err
+	}
+	return invitation, nil
+}
+
+// LinkRegisteredUser は登録したユーザーの確認済みのメールアドレスへの招待を、そのユーザーを受取人とする trusts の行にします。
+// 招待は受取人が承諾するか辞退するまで pending のまま
+func (s *TrustInvitationService) LinkRegisteredUser(ctx context.Context, userID pgtype.UUID, clerkUserID string) ([]query.TrustInvitation, error) {
+	emails, err := s.notifications.verifiedEmails(ctx, clerkUserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	invitations, err := s.queries.ListLinkableTrustInvitationsByEmails(ctx, emails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	var linked []query.TrustInvitation
+	for _, invitation := range invitations {
+		linkedInvitation, err := s.queries.LinkTrustInvitation(ctx, query.LinkTrustInvitationParams{
+			ID:             invitation.ID,
+			ReceiverUserID: userID,
+		})
+		// 同時に他のリンクから結びついたか、自分への招待だった
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return linked, fmt.Errorf("failed to link invitation %d: %w", invitation.ID, err)
+		}
+		linked = append(linked, linkedInvitation)
+	}
+	return linked, nil
+}
+
+// FindByToken はリンクのトークンから、receiverID が返事できる招待を探します。
+// 他のユーザーとすでに結びついた招待は、ないものとして扱う
+func (s *TrustInvitationService) FindByToken(ctx context.Context, receiverID pgtype.UUID, token string) (query.TrustInvitation, error) {
+	invitation, err := s.queries.GetTrustInvitationByTokenHash(ctx, verification.HashToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return query.TrustInvitation{}, ErrInvitationNotFound
+	}
+	if err != nil {
+		return query.TrustInvitation{}, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation.ReceiverUserID.Valid && invitation.ReceiverUserID != receiverID {
+		return query.TrustInvitation{}, ErrInvitationNotFound
+	}
+	if invitation.PasserUserID == receiverID {
+		return query.TrustInvitation{}, ErrOwnInvitation
+	}
+	return invitation, nil
+}
+
+// Accept は receiverID が招待を承諾します。まだ結びついていなければ、ここで trusts の行を作る
+func (s *TrustInvitationService) Accept(ctx context.Context, receiverID pgtype.UUID, invitation query.TrustInvitation) (query.TrustInvitation, error) {
+	if err := trustInvitationState(invitation, time.Now()); err != nil {
+		return invitation, err
+	}
+	if !invitation.ReceiverUserID.Valid {
+		linked, err := s.queries.LinkTrustInvitation(ctx, query.LinkTrustInvitationParams{
+			ID:             invitation.ID,
+			ReceiverUserID: receiverID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return invitation, ErrInvitationClosed
+		}
+		if err != nil {
+			return invitation, fmt.Errorf("failed to link invitation: %w", err)
+		}
+		invitation = linked
+	}
+
+	accepted, err := s.queries.RespondTrustInvitation(ctx, query.RespondTrustInvitationParams{
+		ID:     invitation.ID,
+		Status: TrustInvitationAccepted,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return invitation, ErrInvitationClosed
+	}
+	if err != nil {
+		return invitation, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return accepted, nil
+}
+
+// Decline は受取人が招待を辞退します。招待からできた相続関係は消す
+func (s *TrustInvitationService) Decline(ctx context.Context, invitation query.TrustInvitation) (query.TrustInvitation, error) {
+	if err := trustInvitationState(invitation, time.Now()); err != nil {
+		return invitation, err
+	}
+	return s.close(ctx, invitation, TrustInvitationDeclined)
+}
+
+// Revoke はパッサーが返事を待っている招待を取り消します。招待からできた相続関係は消す
+func (s *TrustInvitationService) Revoke(ctx context.Context, invitation query.TrustInvitation) (query.TrustInvitation, error) {
+	if invitation.Status != TrustInvitationPending {
+		return invitation, ErrInvitationClosed
+	}
+	return s.close(ctx, invitation, TrustInvitationRevoked)
+}
+
+// close は招待を status にし、招待からできた trusts の行を消します。
+// 2つの書き込みはハンドラーのトランザクションの中でまとめて反映される
+func (s *TrustInvitationService) close(ctx context.Context, invitation query.TrustInvitation, status string) (query.TrustInvitation, error) {
+	closed, err := s.queries.RespondTrustInvitation(ctx, query.RespondTrustInvitationParams{
+		ID:     invitation.ID,
+		Status: status,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return invitation, ErrInvitationClosed
+	}
+	if err != nil {
+		return invitation, fmt.Errorf("failed to close invitation: %w", err)
+	}
+	if !closed.TrustID.Valid {
+		return closed, nil
+	}
+
+	// 承諾前の相続関係にはアカウントを割り当てられないので、消しても参照は残らない
+	_, err = s.queries.DeleteTrust(ctx, query.DeleteTrustParams{
+		ID:           closed.TrustID.Int32,
+		PasserUserID: closed.PasserUserID,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return invitation, fmt.Errorf("failed to delete trust %d: %w", closed.TrustID.Int32, err)
+	}
+	closed.TrustID = pgtype.Int4{}
+	return closed, nil
+}
+
+// trustInvitationState は招待にまだ返事できるかを確かめます。
+// 受取人と結びつく前はリンクの期限まで、結びついた後は相続関係が残っている間だけ返事できる
+func trustInvitationState(invitation query.TrustInvitation, now time.Time) error {
+	switch {
+	case invitation.Status != TrustInvitationPending:
+		return ErrInvitationClosed
+	case invitation.ReceiverUserID.Valid && !invitation.TrustID.Valid:
+		return ErrInvitationClosed
+	case !invitation.ReceiverUserID.Valid && !now.Before(invitation.ExpiresAt.Time):
+		return ErrInvitationClosed
+	default:
+		return nil
+	}
+}
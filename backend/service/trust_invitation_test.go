@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/backend/db/query"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestNormalizeInvitationEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Lowercases and trims",
+			email: "  Heir@Example.COM ",
+			want:  "heir@example.com",
+		},
+		{
+			name:    "Rejects display name",
+			email:   "Heir <heir@example.com>",
+			wantErr: true,
+		},
+		{
+			name:    "Rejects missing domain",
+			email:   "heir",
+			wantErr: true,
+		},
+		{
+			name:    "Rejects empty",
+			email:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeInvitationEmail(tt.email)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeInvitationEmail(%q) error = %v, wantErr %v", tt.email, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeInvitationEmail(%q) = %q, want %q", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrustInvitationState(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	receiver := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	trust := pgtype.Int4{Int32: 1, Valid: true}
+
+	tests := []struct {
+		name       string
+		invitation query.TrustInvitation
+		want       error
+	}{
+		{
+			name: "Pending link before expiry",
+			invitation: query.TrustInvitation{
+				Status:    TrustInvitationPending,
+				ExpiresAt: pgtype.Timestamp{Time: now.Add(time.Hour), Valid: true},
+			},
+		},
+		{
+			name: "Pending link after expiry",
+			invitation: query.TrustInvitation{
+				Status:    TrustInvitationPending,
+				ExpiresAt: pgtype.Timestamp{Time: now.Add(-time.Hour), Valid: true},
+			},
+			want: ErrInvitationClosed,
+		},
+		{
+			name: "Linked invitation after expiry",
+			invitation: query.TrustInvitation{
+				Status:         TrustInvitationPending,
+				ReceiverUserID: receiver,
+				TrustID:        trust,
+				ExpiresAt:      pgtype.Timestamp{Time: now.Add(-time.Hour), Valid: true},
+			},
+		},
+		{
+			name: "Linked invitation whose trust was deleted",
+			invitation: query.TrustInvitation{
+				Status:         TrustInvitationPending,
+				ReceiverUserID: receiver,
+				ExpiresAt:      pgtype.Timestamp{Time: now.Add(time.Hour), Valid: true},
+			},
+			want: ErrInvitationClosed,
+		},
+		{
+			name: "Already accepted",
+			invitation: query.TrustInvitation{
+				Status:         TrustInvitationAccepted,
+				ReceiverUserID: receiver,
+				TrustID:        trust,
+				ExpiresAt:      pgtype.Timestamp{Time: now.Add(time.Hour), Valid: true},
+			},
+			want: ErrInvitationClosed,
+		},
+		{
+			name: "Revoked",
+			invitation: query.TrustInvitation{
+				Status:    TrustInvitationRevoked,
+				ExpiresAt: pgtype.Timestamp{Time: now.Add(time.Hour), Valid: true},
+			},
+			want: ErrInvitationClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := trustInvitationState(tt.invitation, now); !errors.Is(err, tt.want) {
+				t.Errorf("trustInvitationState() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
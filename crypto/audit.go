@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Metadata keys callers set to identify themselves and the request.
+const (
+	callerMetadataKey    = "x-caller"
+	actorMetadataKey     = "x-actor-id"
+	requestIDMetadataKey = "x-request-id"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+
+	// auditChainLock is the advisory lock key that serialises appends so every
+	// event links to the one written right before it.
+	auditChainLock = 0x61756469 // "audi"
+)
+
+// genesisHash is the prev_hash of the first event in the chain.
+var genesisHash = make([]byte, sha256.Size)
+
+type auditEvent struct {
+	ID         int64
+	UserID     string
+	Operation  string
+	Caller     string
+	ActorID    string
+	RequestID  string
+	KeyVersion int32
+	CreatedAt  time.Time
+	PrevHash   []byte
+	Hash       []byte
+}
+
+// digest is the chain hash of the event: SHA-256 over the previous hash and
+// every field, each length-prefixed so field boundaries cannot shift.
+func (e *auditEvent) digest(prevHash []byte) []byte {
+	h := sha256.New()
+	h.Write(prevHash)
+	for _, field := range []string{e.UserID, e.Operation, e.Caller, e.ActorID, e.RequestID} {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(field)))
+		h.Write(n[:])
+		h.Write([]byte(field))
+	}
+	var tail [12]byte
+	binary.BigEndian.PutUint32(tail[:4], uint32(e.KeyVersion))
+	binary.BigEndian.PutUint64(tail[4:], uint64(e.CreatedAt.UnixMicro()))
+	h.Write(tail[:])
+	return h.Sum(nil)
+}
+
+// audit appends an event for the operation. Callers fail the operation when
+// it cannot be recorded, so nothing happens without leaving a trace.
+func (s *Server) audit(ctx context.Context, userID, operation string, keyVersion int32) error {
+	return s.auditAll(ctx, newAuditEvent(ctx, userID, operation, keyVersion))
+}
+
+// auditAll appends the events of one call in a single transaction, so a batch
+// takes the chain lock once instead of once per item.
+func (s *Server) auditAll(ctx context.Context, events ...*auditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := appendAuditEvents(ctx, s.db, events); err != nil {
+		auditWriteFailures.Inc()
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// newAuditEvent describes an operation on behalf of the caller in ctx.
+func newAuditEvent(ctx context.Context, userID, operation string, keyVersion int32) *auditEvent {
+	caller, actorID, requestID := callerFromContext(ctx)
+	return &auditEvent{
+		UserID:     userID,
+		Operation:  operation,
+		Caller:     caller,
+		ActorID:    actorID,
+		RequestID:  requestID,
+		KeyVersion: keyVersion,
+		// Postgres keeps microseconds, so hash exactly what is stored
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+// callerFromContext returns the caller verified by the auth interceptor and
+// the actor and request ID from the gRPC metadata. Without a verified caller
+// it falls back to the named caller and then to the peer address.
+func callerFromContext(ctx context.Context) (caller, actorID, requestID string) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		caller = firstMetadata(md, callerMetadataKey)
+		actorID = firstMetadata(md, actorMetadataKey)
+		requestID = firstMetadata(md, requestIDMetadataKey)
+	}
+	if verified, ok := ctx.Value(authenticatedCallerKey{}).(string); ok {
+		caller = verified
+	}
+	if caller == "" {
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			caller = p.Addr.String()
+		}
+	}
+	return caller, actorID, requestID
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// appendAuditEvents links the events to the chain in order.
+func appendAuditEvents(ctx context.Context, db *sql.DB, events []*auditEvent) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 1. Take the chain lock so no other event can slip in between
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, auditChainLock); err != nil {
+		return fmt.Errorf("failed to lock audit chain: %w", err)
+	}
+
+	// 2. Read the latest event once; each event links to the one before it
+	head := genesisHash
+	err = tx.QueryRowContext(ctx, `SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&head)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+
+	// 3. Append
+	for _, e := range events {
+		e.PrevHash = head
+		e.Hash = e.digest(e.PrevHash)
+		if err := tx.QueryRowContext(ctx, `
+            INSERT INTO audit_events (user_id, operation, caller, actor_id, request_id, key_version, created_at, prev_hash, hash)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+            RETURNING id
+        `, e.UserID, e.Operation, e.Caller, e.ActorID, e.RequestID, nullKeyVersion(e.KeyVersion), e.CreatedAt, e.PrevHash, e.Hash,
+		).Scan(&e.ID); err != nil {
+			return err
+		}
+		head = e.Hash
+	}
+	return tx.Commit()
+}
+
+func nullKeyVersion(v int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: v, Valid: v != 0}
+}
+
+func (s *Server) ListAuditEvents(ctx context.Context, req *crypto.ListAuditEventsRequest) (*crypto.ListAuditEventsResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	pageSize := req.GetPageSize()
+	if pageSize <= 0 {
+		pageSize = defaultAuditPageSize
+	}
+	pageSize = min(pageSize, maxAuditPageSize)
+
+	var operations any
+	if len(req.GetOperations()) > 0 {
+		operations = pq.Array(req.GetOperations())
+	}
+	beforeID := req.GetBeforeId()
+	if beforeID <= 0 {
+		beforeID = 1<<63 - 1
+	}
+
+	// One extra row tells whether another page follows
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT id, user_id, operation, caller, actor_id, request_id, key_version, created_at, prev_hash, hash
+        FROM audit_events
+        WHERE user_id = $1 AND id < $2 AND ($3::text[] IS NULL OR operation = ANY($3))
+        ORDER BY id DESC
+        LIMIT $4
+    `, req.GetUserId(), beforeID, operations, pageSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	events, err := scanAuditEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &crypto.ListAuditEventsResponse{}
+	if len(events) > int(pageSize) {
+		events = events[:pageSize]
+		resp.NextBeforeId = events[len(events)-1].ID
+	}
+	for _, e := range events {
+		resp.Events = append(resp.Events, &crypto.AuditEvent{
+			Id:         e.ID,
+			UserId:     e.UserID,
+			Operation:  e.Operation,
+			Caller:     e.Caller,
+			ActorId:    e.ActorID,
+			RequestId:  e.RequestID,
+			KeyVersion: e.KeyVersion,
+			CreatedAt:  e.CreatedAt.UnixMicro(),
+			PrevHash:   e.PrevHash,
+			Hash:       e.Hash,
+		})
+	}
+	return resp, nil
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]auditEvent, error) {
+	defer rows.Close()
+	var events []auditEvent
+	for rows.Next() {
+		var e auditEvent
+		var keyVersion sql.NullInt32
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Operation, &e.Caller, &e.ActorID, &e.RequestID,
+			&keyVersion, &e.CreatedAt, &e.PrevHash, &e.Hash); err != nil {
+			return nil, err
+		}
+		e.KeyVersion = keyVersion.Int32
+		e.CreatedAt = e.CreatedAt.UTC()
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// verifyAuditChain walks the whole log in order and reports the first event
+// whose link or hash does not match. It returns the number of events checked.
+func verifyAuditChain(ctx context.Context, db *sql.DB) (int, error) {
+	const pageSize = 1000
+	prevHash := genesisHash
+	var lastID int64
+	checked := 0
+	for {
+		rows, err := db.QueryContext(ctx, `
+            SELECT id, user_id, operation, caller, actor_id, request_id, key_version, created_at, prev_hash, hash
+            FROM audit_events WHERE id > $1 ORDER BY id LIMIT $2
+        `, lastID, pageSize)
+		if err != nil {
+			return checked, fmt.Errorf("failed to read audit events: %w", err)
+		}
+		events, err := scanAuditEvents(rows)
+		if err != nil {
+			return checked, err
+		}
+		if len(events) == 0 {
+			return checked, nil
+		}
+		if prevHash, err = verifyAuditEvents(prevHash, events); err != nil {
+			return checked, err
+		}
+		checked += len(events)
+		lastID = events[len(events)-1].ID
+	}
+}
+
+// verifyAuditEvents checks consecutive events against the hash of the event
+// before them and returns the hash of the last one.
+func verifyAuditEvents(prevHash []byte, events []auditEvent) ([]byte, error) {
+	for _, e := range events {
+		if !bytes.Equal(e.PrevHash, prevHash) {
+			return nil, fmt.Errorf("audit event %d does not link to the previous event", e.ID)
+		}
+		if !bytes.Equal(e.Hash, e.digest(prevHash)) {
+			return nil, fmt.Errorf("audit event %d does not match its hash", e.ID)
+		}
+		prevHash = e.Hash
+	}
+	return prevHash, nil
+}
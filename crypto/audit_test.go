@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAuditEvents(t *testing.T) {
+	chain := func() []auditEvent {
+		now := time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC)
+		events := []auditEvent{
+			{ID: 1, UserID: "passer", Operation: "ENCRYPT", Caller: "backend", KeyVersion: 1, CreatedAt: now},
+			{ID: 2, UserID: "passer", Operation: "DECRYPT", Caller: "backend", ActorID: "passer", RequestID: "req-1", KeyVersion: 1, CreatedAt: now.Add(time.Second)},
+			{ID: 3, UserID: "receiver", Operation: "RECEIVE_FROM_OWNER", Caller: "backend", KeyVersion: 2, CreatedAt: now.Add(2 * time.Second)},
+		}
+		prev := genesisHash
+		for i := range events {
+			events[i].PrevHash = prev
+			events[i].Hash = events[i].digest(prev)
+			prev = events[i].Hash
+		}
+		return events
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(events []auditEvent) []auditEvent
+		ok     bool
+	}{
+		{name: "untouched", tamper: func(e []auditEvent) []auditEvent { return e }, ok: true},
+		{name: "operation changed", tamper: func(e []auditEvent) []auditEvent {
+			e[1].Operation = "ENCRYPT"
+			return e
+		}},
+		{name: "actor changed", tamper: func(e []auditEvent) []auditEvent {
+			e[1].ActorID = "someone-else"
+			return e
+		}},
+		{name: "field boundary shifted", tamper: func(e []auditEvent) []auditEvent {
+			e[1].Caller, e[1].ActorID = "backendp", "asser"
+			return e
+		}},
+		{name: "event removed", tamper: func(e []auditEvent) []auditEvent {
+			return append(e[:1], e[2:]...)
+		}},
+		{name: "event rehashed without relinking", tamper: func(e []auditEvent) []auditEvent {
+			e[1].KeyVersion = 9
+			e[1].Hash = e[1].digest(e[1].PrevHash)
+			return e
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := verifyAuditEvents(genesisHash, tc.tamper(chain()))
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Every call carries a caller assertion in the x-caller-assertion metadata:
+//
+//	v1:<unix seconds>:<base64url HMAC-SHA256>
+//
+// The MAC covers the caller name, the full gRPC method, the request ID and
+// the timestamp, under the secret shared with that caller. With mutual TLS
+// the caller name must also match the client certificate's common name.
+const (
+	assertionMetadataKey = "x-caller-assertion"
+	assertionVersion     = "v1"
+
+	// maxAssertionSkew bounds how old or how far ahead an assertion may be.
+	maxAssertionSkew = 2 * time.Minute
+)
+
+type authenticatedCallerKey struct{}
+
+// callerAuth checks that calls come from a known caller.
+type callerAuth struct {
+	secrets map[string][]byte
+	now     func() time.Time
+}
+
+// loadCallerAuth parses CALLER_SECRETS. Without any caller configured, calls
+// are only let through when ALLOW_INSECURE is set.
+func loadCallerAuth(cfg Config) (*callerAuth, error) {
+	a := &callerAuth{secrets: map[string][]byte{}, now: time.Now}
+	for _, entry := range strings.Split(cfg.CallerSecrets, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, encoded, ok := strings.Cut(entry, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid CALLER_SECRETS entry, want name:base64")
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("caller secret for %q is not valid base64: %w", name, err)
+		}
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("caller secret for %q must be at least 32 bytes", name)
+		}
+		a.secrets[name] = secret
+	}
+	if len(a.secrets) == 0 && !cfg.AllowInsecure {
+		return nil, fmt.Errorf("CALLER_SECRETS must be set unless ALLOW_INSECURE is true")
+	}
+	return a, nil
+}
+
+// serverTLSConfig requires client certificates signed by the configured CA.
+func serverTLSConfig(cfg Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in client CA file")
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
+
+// serverOptions builds the transport credentials and the metrics and auth
+// interceptors.
+func serverOptions(cfg Config, auth *callerAuth) ([]grpc.ServerOption, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(metricsUnaryInterceptor, auth.unaryInterceptor),
+		grpc.ChainStreamInterceptor(metricsStreamInterceptor, auth.streamInterceptor),
+	}
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" && cfg.TLSClientCAFile == "" {
+		if !cfg.AllowInsecure {
+			return nil, fmt.Errorf("TLS_CERT_FILE, TLS_KEY_FILE and TLS_CLIENT_CA_FILE must be set unless ALLOW_INSECURE is true")
+		}
+		return opts, nil
+	}
+	tlsConfig, err := serverTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, grpc.Creds(credentials.NewTLS(tlsConfig))), nil
+}
+
+func (a *callerAuth) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *callerAuth) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, err := a.authenticate(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authenticate verifies the caller of one call and records its name in the
+// returned context for the audit log.
+func (a *callerAuth) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	// 1. With mutual TLS the certificate names the caller
+	certCaller := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			chains := tlsInfo.State.VerifiedChains
+			if len(chains) == 0 || len(chains[0]) == 0 {
+				return nil, status.Error(codes.Unauthenticated, "client certificate required")
+			}
+			certCaller = chains[0][0].Subject.CommonName
+		}
+	}
+
+	if len(a.secrets) == 0 {
+		// Only reachable with ALLOW_INSECURE
+		if certCaller != "" {
+			ctx = context.WithValue(ctx, authenticatedCallerKey{}, certCaller)
+		}
+		return ctx, nil
+	}
+
+	// 2. The assertion must be signed by a known caller, matching the certificate
+	md, _ := metadata.FromIncomingContext(ctx)
+	caller := firstMetadata(md, callerMetadataKey)
+	if caller == "" {
+		return nil, status.Error(codes.Unauthenticated, "caller is required")
+	}
+	if certCaller != "" && caller != certCaller {
+		return nil, status.Errorf(codes.PermissionDenied, "caller %q does not match client certificate", caller)
+	}
+	secret, ok := a.secrets[caller]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "unknown caller %q", caller)
+	}
+	err := verifyCallerAssertion(secret, firstMetadata(md, assertionMetadataKey), caller, fullMethod,
+		firstMetadata(md, requestIDMetadataKey), a.now())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return context.WithValue(ctx, authenticatedCallerKey{}, caller), nil
+}
+
+// signCallerAssertion is the caller side of the assertion, used in tests and
+// mirrored by the backend's crypto client.
+func signCallerAssertion(secret []byte, caller, fullMethod, requestID string, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return assertionVersion + ":" + ts + ":" + base64.RawURLEncoding.EncodeToString(assertionMAC(secret, caller, fullMethod, requestID, ts))
+}
+
+func verifyCallerAssertion(secret []byte, assertion, caller, fullMethod, requestID string, now time.Time) error {
+	parts := strings.Split(assertion, ":")
+	if len(parts) != 3 || parts[0] != assertionVersion {
+		return fmt.Errorf("missing or malformed caller assertion")
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed caller assertion timestamp")
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > maxAssertionSkew || skew < -maxAssertionSkew {
+		return fmt.Errorf("caller assertion expired")
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed caller assertion signature")
+	}
+	if !hmac.Equal(mac, assertionMAC(secret, caller, fullMethod, requestID, parts[1])) {
+		return fmt.Errorf("invalid caller assertion")
+	}
+	return nil
+}
+
+func assertionMAC(secret []byte, caller, fullMethod, requestID, ts string) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(strings.Join([]string{assertionVersion, caller, fullMethod, requestID, ts}, "\n")))
+	return m.Sum(nil)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const testMethod = "/crypto.EncryptionService/Decrypt"
+
+func TestVerifyCallerAssertion(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Unix(1_700_000_000, 0)
+	valid := signCallerAssertion(secret, "backend", testMethod, "req-1", now)
+	// The backend's crypto client signs the same vector
+	require.Equal(t, "v1:1700000000:jNucBiSWIgytXiEw5mAV7OS6BKk-lfgOUpiHFu30vTw", valid)
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		assertion string
+		method    string
+		requestID string
+		now       time.Time
+		ok        bool
+	}{
+		{name: "valid", secret: secret, assertion: valid, method: testMethod, requestID: "req-1", now: now, ok: true},
+		{name: "within skew", secret: secret, assertion: valid, method: testMethod, requestID: "req-1", now: now.Add(time.Minute), ok: true},
+		{name: "wrong secret", secret: []byte("fedcba9876543210fedcba9876543210"), assertion: valid, method: testMethod, requestID: "req-1", now: now},
+		{name: "expired", secret: secret, assertion: valid, method: testMethod, requestID: "req-1", now: now.Add(maxAssertionSkew + time.Second)},
+		{name: "from the future", secret: secret, assertion: valid, method: testMethod, requestID: "req-1", now: now.Add(-maxAssertionSkew - time.Second)},
+		{name: "other method", secret: secret, assertion: valid, method: "/crypto.EncryptionService/Encrypt", requestID: "req-1", now: now},
+		{name: "other request", secret: secret, assertion: valid, method: testMethod, requestID: "req-2", now: now},
+		{name: "missing", secret: secret, assertion: "", method: testMethod, requestID: "req-1", now: now},
+		{name: "malformed", secret: secret, assertion: "v1:abc:def", method: testMethod, requestID: "req-1", now: now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyCallerAssertion(tt.secret, tt.assertion, "backend", tt.method, tt.requestID, tt.now)
+			if tt.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Now()
+	auth := &callerAuth{secrets: map[string][]byte{"backend": secret}, now: func() time.Time { return now }}
+
+	withCert := func(ctx context.Context, commonName string) context.Context {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+		}})
+	}
+	withCall := func(ctx context.Context, caller string, secret []byte) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(
+			callerMetadataKey, caller,
+			requestIDMetadataKey, "req-1",
+			assertionMetadataKey, signCallerAssertion(secret, caller, testMethod, "req-1", now),
+		))
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{name: "valid", ctx: withCall(withCert(context.Background(), "backend"), "backend", secret), code: codes.OK},
+		{name: "no assertion", ctx: withCert(context.Background(), "backend"), code: codes.Unauthenticated},
+		{name: "certificate of another caller", ctx: withCall(withCert(context.Background(), "batch"), "backend", secret), code: codes.PermissionDenied},
+		{name: "unknown caller", ctx: withCall(withCert(context.Background(), "batch"), "batch", secret), code: codes.PermissionDenied},
+		{name: "wrong secret", ctx: withCall(withCert(context.Background(), "backend"), "backend", []byte("fedcba9876543210fedcba9876543210")), code: codes.Unauthenticated},
+		{name: "TLS without client certificate", ctx: withCall(peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}}), "backend", secret), code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := auth.authenticate(tt.ctx, testMethod)
+			require.Equal(t, tt.code, status.Code(err))
+			if tt.code == codes.OK {
+				caller, _, requestID := callerFromContext(ctx)
+				require.Equal(t, "backend", caller)
+				require.Equal(t, "req-1", requestID)
+			}
+		})
+	}
+}
+
+func TestLoadCallerAuth(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+	auth, err := loadCallerAuth(Config{CallerSecrets: "backend:" + secret})
+	require.NoError(t, err)
+	require.Len(t, auth.secrets, 1)
+
+	_, err = loadCallerAuth(Config{})
+	require.Error(t, err, "no callers without ALLOW_INSECURE")
+	_, err = loadCallerAuth(Config{AllowInsecure: true})
+	require.NoError(t, err)
+
+	_, err = loadCallerAuth(Config{CallerSecrets: "backend:" + base64.StdEncoding.EncodeToString([]byte("short"))})
+	require.Error(t, err, "short secret")
+	_, err = loadCallerAuth(Config{CallerSecrets: "backend"})
+	require.Error(t, err, "missing secret")
+}
+
+func TestGenDevCerts(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, genDevCerts(dir))
+
+	cfg := Config{
+		TLSCertFile:     filepath.Join(dir, "server.pem"),
+		TLSKeyFile:      filepath.Join(dir, "server-key.pem"),
+		TLSClientCAFile: filepath.Join(dir, "ca.pem"),
+	}
+	tlsConfig, err := serverTLSConfig(cfg)
+	require.NoError(t, err)
+
+	// The client certificate chains to the CA the server trusts
+	client, err := tls.LoadX509KeyPair(filepath.Join(dir, devCallerName+".pem"), filepath.Join(dir, devCallerName+"-key.pem"))
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(client.Certificate[0])
+	require.NoError(t, err)
+	require.Equal(t, devCallerName, leaf.Subject.CommonName)
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: tlsConfig.ClientCAs, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	require.NoError(t, err)
+
+	// The generated secret is accepted as a caller secret
+	secret, err := os.ReadFile(filepath.Join(dir, devCallerName+".secret"))
+	require.NoError(t, err)
+	_, err = loadCallerAuth(Config{CallerSecrets: devCallerName + ":" + string(secret[:len(secret)-1])})
+	require.NoError(t, err)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+)
+
+// maxBatchItems bounds a single batch so one call cannot pin the server.
+const maxBatchItems = 1000
+
+func (s *Server) BatchEncrypt(ctx context.Context, req *crypto.BatchEncryptRequest) (*crypto.BatchEncryptResponse, error) {
+	if len(req.GetPlaintexts()) > maxBatchItems {
+		return nil, fmt.Errorf("batch too large: %d items, max %d", len(req.GetPlaintexts()), maxBatchItems)
+	}
+
+	// 1. Look up or create the user's current RSA key pair once for the batch
+	key, err := s.keys.Current(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Seal every item on its own; a failure only affects that item
+	results := make([]*crypto.EncryptResult, len(req.GetPlaintexts()))
+	var events []*auditEvent
+	for i, plaintext := range req.GetPlaintexts() {
+		ciphertext, err := sealEnvelope(key.Public, key.Version, plaintext)
+		if err != nil {
+			results[i] = &crypto.EncryptResult{Error: fmt.Sprintf("envelope encrypt failed: %v", err)}
+			continue
+		}
+		results[i] = &crypto.EncryptResult{Ciphertext: ciphertext}
+		events = append(events, newAuditEvent(ctx, req.GetUserId(), "ENCRYPT", key.Version))
+	}
+
+	// 3. Record the batch at once; without a record no item is returned
+	failUnaudited(results, s.auditAll(ctx, events...))
+
+	return &crypto.BatchEncryptResponse{Results: results, KeyVersion: key.Version}, nil
+}
+
+func (s *Server) BatchDecrypt(ctx context.Context, req *crypto.BatchDecryptRequest) (*crypto.BatchDecryptResponse, error) {
+	if len(req.GetCiphertexts()) > maxBatchItems {
+		return nil, fmt.Errorf("batch too large: %d items, max %d", len(req.GetCiphertexts()), maxBatchItems)
+	}
+
+	// 1. Only for the key owner; items the grant does not cover fail on their own
+	covers, err := s.grants.authorize(ctx, req.GetUserId(), req.GetActorUserId(), req.GetPurpose(), req.GetGrant())
+	if err != nil {
+		return nil, err
+	}
+
+	keyFor, err := s.keyLoader(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Open every item with the key version it was sealed with
+	results := make([]*crypto.DecryptResult, len(req.GetCiphertexts()))
+	var events []*auditEvent
+	for i, ciphertext := range req.GetCiphertexts() {
+		if !covers(ciphertext) {
+			results[i] = &crypto.DecryptResult{Error: errNotGranted.Error()}
+			continue
+		}
+		key, err := keyFor(ciphertext)
+		if err != nil {
+			results[i] = &crypto.DecryptResult{Error: err.Error()}
+			continue
+		}
+
+		plaintext, err := decryptCiphertext(key.Private, ciphertext)
+		if err != nil {
+			results[i] = &crypto.DecryptResult{Error: err.Error()}
+			continue
+		}
+		results[i] = &crypto.DecryptResult{Plaintext: plaintext}
+		events = append(events, newAuditEvent(ctx, req.GetUserId(), "DECRYPT", key.Version))
+	}
+
+	// 3. Record the batch at once; without a record no item is returned
+	if err := s.auditAll(ctx, events...); err != nil {
+		for i, r := range results {
+			if r.GetError() == "" {
+				results[i] = &crypto.DecryptResult{Error: err.Error()}
+			}
+		}
+	}
+
+	return &crypto.BatchDecryptResponse{Results: results}, nil
+}
+
+// failUnaudited fails every successful item when the batch's audit events
+// could not be recorded.
+func failUnaudited(results []*crypto.EncryptResult, err error) {
+	if err == nil {
+		return
+	}
+	for i, r := range results {
+		if r.GetError() == "" {
+			results[i] = &crypto.EncryptResult{Error: err.Error()}
+		}
+	}
+}
+
+// keyLoader returns a lookup of the user key version each ciphertext needs.
+// Each key version is loaded at most once per lookup.
+func (s *Server) keyLoader(ctx context.Context, userID string) (func(ciphertext []byte) (*userKey, error), error) {
+	current, err := s.keys.Current(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[int32]*userKey{current.Version: current}
+	return func(ciphertext []byte) (*userKey, error) {
+		version := ciphertextKeyVersion(ciphertext)
+		if key, ok := keys[version]; ok {
+			return key, nil
+		}
+		key, err := s.keys.Version(ctx, userID, version)
+		if err != nil {
+			return nil, err
+		}
+		keys[version] = key
+		return key, nil
+	}, nil
+}
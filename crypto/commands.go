@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// runCommand executes a one-off maintenance command instead of serving gRPC,
+// e.g. `main rewrap-keys`.
+func runCommand(ctx context.Context, db *sql.DB, keks kekProvider, keys KeyStore, args []string) error {
+	switch args[0] {
+	case "rewrap-keys":
+		// Wraps legacy plaintext rows and moves keys off retired KEKs. Keys
+		// on a PKCS#11 token are not wrapped by a KEK and stay where they are.
+		if rewrapper, ok := keys.(keyRewrapper); ok {
+			n, err := rewrapper.Rewrap(ctx)
+			if err != nil {
+				return err
+			}
+			log.Printf("rewrapped %d user keys under KEK %q", n, keks.CurrentID())
+		}
+		n, err := rewrapVaultKeys(ctx, db, keks)
+		if err != nil {
+			return err
+		}
+		log.Printf("rewrapped %d unlocked vault keys under KEK %q", n, keks.CurrentID())
+		return nil
+	case "verify-audit":
+		// Walks the audit chain and fails at the first tampered event
+		n, err := verifyAuditChain(ctx, db)
+		if err != nil {
+			return fmt.Errorf("audit chain broken after %d events: %w", n, err)
+		}
+		log.Printf("verified %d audit events", n)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
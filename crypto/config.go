@@ -15,19 +15,40 @@ type Config struct {
 	DBName     string
 	ServerPort string
 	UseSSL     bool
-}
 
-var (
-	configInstance *Config
-)
+	// Key-encryption keys protecting user_keys.private_key
+	KEK        string
+	KEKID      string
+	KEKRetired string
+	KEKFile    string
 
-func init() {
-	cfg, err := loadConfig()
-	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
-	}
-	configInstance = cfg
-	log.Printf("Loaded config: %+v", configInstance)
+	// Algorithm of newly generated user and vault keys: rsa-2048 (default),
+	// x25519 or x25519-mlkem768. Existing keys keep their own algorithm.
+	KeyAlgorithm string
+
+	// Where user keys live: sql (default), file or pkcs11
+	KeyStore    string
+	KeyStoreDir string
+	// PKCS#11 module and token for KEY_STORE=pkcs11
+	PKCS11Module     string
+	PKCS11TokenLabel string
+	PKCS11PIN        string
+
+	// gRPC listener. Mutual TLS is required unless AllowInsecure is set.
+	GRPCAddr        string
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	// Callers allowed to use the service, as comma-separated name:base64
+	// pairs of the secret each one signs its calls with
+	CallerSecrets string
+	// Base64 Ed25519 public keys, comma-separated, that decrypt grants from
+	// the backend are checked against
+	GrantPublicKeys string
+	AllowInsecure   bool
+
+	// HTTP listener for /metrics, /healthz and /readyz; empty disables it
+	MetricsAddr string
 }
 
 func loadConfig() (*Config, error) {
@@ -40,6 +61,12 @@ func loadConfig() (*Config, error) {
 
 	viper.SetDefault("SERVER_PORT", "8080")
 	viper.SetDefault("USE_SSL", false)
+	viper.SetDefault("KEK_ID", "default")
+	viper.SetDefault("KEY_ALGORITHM", algorithmRSA2048)
+	viper.SetDefault("KEY_STORE", keyStoreSQL)
+	viper.SetDefault("GRPC_ADDR", ":50051")
+	viper.SetDefault("ALLOW_INSECURE", false)
+	viper.SetDefault("METRICS_ADDR", ":9090")
 
 	c := &Config{
 		DBHost:     viper.GetString("DB_HOST"),
@@ -49,6 +76,28 @@ func loadConfig() (*Config, error) {
 		DBName:     viper.GetString("DB_NAME"),
 		ServerPort: viper.GetString("SERVER_PORT"),
 		UseSSL:     viper.GetBool("USE_SSL"),
+		KEK:        viper.GetString("KEK"),
+		KEKID:      viper.GetString("KEK_ID"),
+		KEKRetired: viper.GetString("KEK_RETIRED"),
+		KEKFile:    viper.GetString("KEK_FILE"),
+
+		KeyAlgorithm: viper.GetString("KEY_ALGORITHM"),
+
+		KeyStore:         viper.GetString("KEY_STORE"),
+		KeyStoreDir:      viper.GetString("KEY_STORE_DIR"),
+		PKCS11Module:     viper.GetString("PKCS11_MODULE"),
+		PKCS11TokenLabel: viper.GetString("PKCS11_TOKEN_LABEL"),
+		PKCS11PIN:        viper.GetString("PKCS11_PIN"),
+
+		GRPCAddr:        viper.GetString("GRPC_ADDR"),
+		TLSCertFile:     viper.GetString("TLS_CERT_FILE"),
+		TLSKeyFile:      viper.GetString("TLS_KEY_FILE"),
+		TLSClientCAFile: viper.GetString("TLS_CLIENT_CA_FILE"),
+		CallerSecrets:   viper.GetString("CALLER_SECRETS"),
+		GrantPublicKeys: viper.GetString("GRANT_PUBLIC_KEYS"),
+		AllowInsecure:   viper.GetBool("ALLOW_INSECURE"),
+
+		MetricsAddr: viper.GetString("METRICS_ADDR"),
 	}
 
 	if !c.Validate() {
@@ -58,13 +107,6 @@ func loadConfig() (*Config, error) {
 	return c, nil
 }
 
-func ConfigData() Config {
-	if configInstance == nil {
-		return Config{}
-	}
-	return *configInstance
-}
-
 func (c *Config) Validate() bool {
 	return c.DBHost != "" &&
 		c.DBPort != "" &&
@@ -81,3 +123,22 @@ func (c *Config) DBConnStr() string {
 	}
 	return connStr
 }
+
+// String redacts secrets so the loaded config can be logged.
+func (c *Config) String() string {
+	type plain Config
+	redacted := plain(*c)
+	redacted.DBPassword = redact(c.DBPassword)
+	redacted.KEK = redact(c.KEK)
+	redacted.KEKRetired = redact(c.KEKRetired)
+	redacted.PKCS11PIN = redact(c.PKCS11PIN)
+	redacted.CallerSecrets = redact(c.CallerSecrets)
+	return fmt.Sprintf("%+v", redacted)
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
@@ -8,7 +8,10 @@ import (
 )
 
 func getDB() (*sql.DB, error) {
-	cfg := ConfigData()
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
 	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		cfg.DBUser,
 		cfg.DBPassword,
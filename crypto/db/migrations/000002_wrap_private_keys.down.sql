@@ -0,0 +1,4 @@
+-- 002_wrap_private_keys.down.sql
+-- Wrapped private keys cannot be unwrapped in SQL; only roll back before
+-- rewrap-keys has run.
+ALTER TABLE user_keys DROP COLUMN IF EXISTS kek_id;
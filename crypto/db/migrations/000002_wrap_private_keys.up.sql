@@ -0,0 +1,4 @@
+-- 002_wrap_private_keys.up.sql
+-- NULL kek_id marks a legacy row whose private_key is still plain PEM.
+-- The rewrap-keys command wraps those rows under the current KEK.
+ALTER TABLE user_keys ADD COLUMN IF NOT EXISTS kek_id TEXT;
@@ -0,0 +1,12 @@
+-- 003_user_key_versions.down.sql
+-- Retired key versions are dropped; ciphertexts sealed with them become
+-- unreadable, so only roll back before any key has been rotated.
+DROP INDEX IF EXISTS user_keys_current_idx;
+DELETE FROM user_keys WHERE NOT is_current;
+
+ALTER TABLE user_keys DROP CONSTRAINT IF EXISTS user_keys_pkey;
+ALTER TABLE user_keys ADD PRIMARY KEY (user_id);
+
+ALTER TABLE user_keys DROP COLUMN IF EXISTS created_at;
+ALTER TABLE user_keys DROP COLUMN IF EXISTS is_current;
+ALTER TABLE user_keys DROP COLUMN IF EXISTS version;
@@ -0,0 +1,11 @@
+-- 003_user_key_versions.up.sql
+-- A user may hold several key versions; exactly one of them is current and
+-- used for new ciphertexts. Existing rows become version 1.
+ALTER TABLE user_keys ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE user_keys ADD COLUMN IF NOT EXISTS is_current BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE user_keys ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW();
+
+ALTER TABLE user_keys DROP CONSTRAINT IF EXISTS user_keys_pkey;
+ALTER TABLE user_keys ADD PRIMARY KEY (user_id, version);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_keys_current_idx ON user_keys (user_id) WHERE is_current;
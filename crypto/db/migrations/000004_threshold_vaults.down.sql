@@ -0,0 +1,4 @@
+-- 004_threshold_vaults.down.sql
+DROP TABLE IF EXISTS vault_shares;
+DROP INDEX IF EXISTS vaults_current_idx;
+DROP TABLE IF EXISTS vaults;
@@ -0,0 +1,31 @@
+-- 004_threshold_vaults.up.sql
+-- A vault holds a passer's secrets for threshold disclosure. Its private key
+-- is sealed under a vault key that only exists as Shamir shares, one per
+-- receiver; unlocked_private_key is filled in once enough shares combined.
+CREATE TABLE IF NOT EXISTS vaults (
+    passer_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    threshold INTEGER NOT NULL,
+    share_count INTEGER NOT NULL,
+    is_current BOOLEAN NOT NULL DEFAULT true,
+    public_key TEXT NOT NULL,
+    sealed_private_key BYTEA NOT NULL,
+    unlocked_private_key TEXT,
+    unlock_kek_id TEXT,
+    unlocked_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (passer_id, version),
+    CHECK (threshold >= 1 AND threshold <= share_count)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS vaults_current_idx ON vaults (passer_id) WHERE is_current;
+
+-- share is an envelope sealed to the receiver's user key
+CREATE TABLE IF NOT EXISTS vault_shares (
+    passer_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    receiver_id TEXT NOT NULL,
+    share BYTEA NOT NULL,
+    PRIMARY KEY (passer_id, version, receiver_id),
+    FOREIGN KEY (passer_id, version) REFERENCES vaults (passer_id, version) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+-- 005_audit_events.down.sql
+ALTER TABLE encryption_decryption_history ADD COLUMN IF NOT EXISTS data BYTEA NOT NULL DEFAULT ''::bytea;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,30 @@
+-- 005_audit_events.up.sql
+-- Hash-chained audit log. Each row's hash covers its own fields and the hash
+-- of the row before it, so altering or removing a row breaks the chain.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    caller TEXT NOT NULL DEFAULT '',
+    actor_id TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    key_version INTEGER,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    prev_hash BYTEA NOT NULL,
+    hash BYTEA NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_user_idx ON audit_events (user_id, id);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+-- The old history stored whole ciphertexts; keep its rows but not the payloads.
+ALTER TABLE encryption_decryption_history DROP COLUMN IF EXISTS data;
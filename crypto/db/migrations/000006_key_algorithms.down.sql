@@ -0,0 +1,8 @@
+-- 006_key_algorithms.down.sql
+-- Only roll back while every key is still RSA-2048: other keys would be read
+-- as RSA and fail to parse.
+ALTER TABLE vaults DROP CONSTRAINT IF EXISTS vaults_algorithm_check;
+ALTER TABLE user_keys DROP CONSTRAINT IF EXISTS user_keys_algorithm_check;
+
+ALTER TABLE vaults DROP COLUMN IF EXISTS algorithm;
+ALTER TABLE user_keys DROP COLUMN IF EXISTS algorithm;
@@ -0,0 +1,10 @@
+-- 006_key_algorithms.up.sql
+-- Keys record the algorithm they were generated with. Every key so far is
+-- RSA-2048; new keys follow KEY_ALGORITHM.
+ALTER TABLE user_keys ADD COLUMN IF NOT EXISTS algorithm TEXT NOT NULL DEFAULT 'rsa-2048';
+ALTER TABLE vaults ADD COLUMN IF NOT EXISTS algorithm TEXT NOT NULL DEFAULT 'rsa-2048';
+
+ALTER TABLE user_keys ADD CONSTRAINT user_keys_algorithm_check
+    CHECK (algorithm IN ('rsa-2048', 'x25519', 'x25519-mlkem768'));
+ALTER TABLE vaults ADD CONSTRAINT vaults_algorithm_check
+    CHECK (algorithm IN ('rsa-2048', 'x25519', 'x25519-mlkem768'));
@@ -0,0 +1,2 @@
+-- 007_recovery_wraps.down.sql
+DROP TABLE IF EXISTS recovery_wraps;
@@ -0,0 +1,11 @@
+-- 007_recovery_wraps.up.sql
+-- Recovery wraps of zero-knowledge vaults. The client seals the wrap before it
+-- leaves the device; the crypto service only keeps it and cannot open it.
+CREATE TABLE IF NOT EXISTS recovery_wraps (
+    user_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    format TEXT NOT NULL DEFAULT '',
+    wrap BYTEA NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, version)
+);
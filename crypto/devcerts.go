@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// devCallerName is the caller the generated client certificate is issued to.
+const devCallerName = "backend"
+
+// genDevCerts writes a throwaway CA plus server and client certificates for
+// running the backend against the crypto service locally over mutual TLS,
+// a caller secret and a decrypt grant key for the backend. Never use these
+// outside development.
+func genDevCerts(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	// 1. Self-signed CA
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	caTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "digi-baton dev CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+	}
+	caCert, err := issueCert(caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	if err := writeCert(dir, "ca", caCert, caKey); err != nil {
+		return err
+	}
+
+	// 2. Server certificate for the crypto service
+	if err := issueLeaf(dir, "server", caCert, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "crypto"},
+		DNSNames:    []string{"localhost", "crypto"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return err
+	}
+
+	// 3. Client certificate naming the caller
+	if err := issueLeaf(dir, devCallerName, caCert, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: devCallerName},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return err
+	}
+
+	// 4. Secret the caller signs its assertions with
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(secret)
+	if err := os.WriteFile(filepath.Join(dir, devCallerName+".secret"), []byte(encoded+"\n"), 0o600); err != nil {
+		return err
+	}
+
+	// 5. Key the backend signs decrypt grants with
+	grantPub, grantKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	encodedGrantKey := base64.StdEncoding.EncodeToString(grantKey.Seed())
+	if err := os.WriteFile(filepath.Join(dir, "grant.key"), []byte(encodedGrantKey+"\n"), 0o600); err != nil {
+		return err
+	}
+	encodedGrantPub := base64.StdEncoding.EncodeToString(grantPub)
+
+	fmt.Printf(`crypto service:
+  TLS_CERT_FILE=%[1]s/server.pem
+  TLS_KEY_FILE=%[1]s/server-key.pem
+  TLS_CLIENT_CA_FILE=%[1]s/ca.pem
+  CALLER_SECRETS=%[2]s:%[3]s
+  GRANT_PUBLIC_KEYS=%[4]s
+backend:
+  CRYPTO_CA_FILE=%[1]s/ca.pem
+  CRYPTO_CERT_FILE=%[1]s/%[2]s.pem
+  CRYPTO_KEY_FILE=%[1]s/%[2]s-key.pem
+  CRYPTO_CALLER=%[2]s
+  CRYPTO_CALLER_SECRET=%[3]s
+  CRYPTO_GRANT_KEY=%[5]s
+`, dir, devCallerName, encoded, encodedGrantPub, encodedGrantKey)
+	return nil
+}
+
+func issueLeaf(dir, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, template *x509.Certificate) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	cert, err := issueCert(template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	return writeCert(dir, name, cert, key)
+}
+
+func issueCert(template, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().AddDate(1, 0, 0)
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate for %s: %w", template.Subject.CommonName, err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+func writeCert(dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o644); err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0o600)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Ciphertext envelope layout (version 2):
+//
+//	magic      4 bytes  "DBEV"
+//	version    1 byte   envelopeVersion2
+//	suite      1 byte   how the data key is wrapped, see below
+//	keyVersion 4 bytes  big-endian version of the user key that wrapped the data key
+//	keyLen     2 bytes  big-endian length of the wrapped data key
+//	wrappedKey keyLen   data key wrapped for the user's public key
+//	nonce      12 bytes AES-GCM nonce
+//	payload    rest     AES-GCM ciphertext and tag
+//
+// The suite follows the algorithm of the key version:
+//
+//	suiteRSAOAEPAESGCM         wrappedKey is the data key under RSA-OAEP SHA-256
+//	suiteX25519HKDFAESGCM      wrappedKey is an ephemeral X25519 public key; the
+//	                           data key is HKDF-SHA256 of the shared secret
+//	suiteX25519MLKEM768AESGCM  wrappedKey is an ephemeral X25519 public key and
+//	                           an ML-KEM-768 ciphertext; the data key is
+//	                           HKDF-SHA256 of both shared secrets
+//
+// Everything before the nonce is authenticated as additional data, so the
+// header cannot be altered without failing decryption. Version 1 envelopes
+// lack the keyVersion field and were always sealed with initialKeyVersion.
+var envelopeMagic = []byte("DBEV")
+
+const (
+	envelopeVersion1 byte = 1
+	envelopeVersion2 byte = 2
+
+	suiteRSAOAEPAESGCM        byte = 1
+	suiteX25519HKDFAESGCM     byte = 2
+	suiteX25519MLKEM768AESGCM byte = 3
+
+	dataKeySize = 32 // AES-256
+)
+
+var errNotEnvelope = errors.New("ciphertext is not an envelope")
+
+// oaepSHA256 are the RSA-OAEP parameters data keys are wrapped with.
+var oaepSHA256 = &rsa.OAEPOptions{Hash: crypto.SHA256}
+
+type envelope struct {
+	version    byte
+	suite      byte
+	keyVersion int32
+	wrappedKey []byte
+	nonce      []byte
+	payload    []byte
+	header     []byte
+}
+
+// sealEnvelope encrypts plaintext with a fresh AES-256-GCM data key and wraps
+// that key for the given version of the user's public key.
+func sealEnvelope(pub crypto.PublicKey, keyVersion int32, plaintext []byte) ([]byte, error) {
+	suite, dataKey, wrappedKey, err := wrapDataKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrappedKey) > 0xFFFF {
+		return nil, fmt.Errorf("wrapped data key too large: %d bytes", len(wrappedKey))
+	}
+
+	header := make([]byte, 0, len(envelopeMagic)+8+len(wrappedKey))
+	header = append(header, envelopeMagic...)
+	header = append(header, envelopeVersion2, suite)
+	header = binary.BigEndian.AppendUint32(header, uint32(keyVersion))
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrappedKey)))
+	header = append(header, wrappedKey...)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, header), nil
+}
+
+// openEnvelope decrypts an envelope produced by sealEnvelope. The data key is
+// unwrapped through priv, so an RSA key itself may live in an HSM.
+func openEnvelope(priv crypto.Decrypter, ciphertext []byte) ([]byte, error) {
+	env, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	suite, err := keySuite(priv.Public())
+	if err != nil {
+		return nil, err
+	}
+	if env.suite != suite {
+		return nil, fmt.Errorf("envelope suite %d does not match the key's suite %d", env.suite, suite)
+	}
+
+	// RSA keys decrypt the wrapped key; X25519 and hybrid keys derive the
+	// data key from it and ignore the options
+	dataKey, err := priv.Decrypt(rand.Reader, env.wrappedKey, oaepSHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, env.nonce, env.payload, env.header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// parseEnvelope splits a ciphertext into its envelope fields. It returns
+// errNotEnvelope when the input does not carry the envelope magic.
+func parseEnvelope(ciphertext []byte) (*envelope, error) {
+	if !bytes.HasPrefix(ciphertext, envelopeMagic) {
+		return nil, errNotEnvelope
+	}
+	rest := ciphertext[len(envelopeMagic):]
+	if len(rest) < 2 {
+		return nil, fmt.Errorf("envelope header truncated")
+	}
+
+	env := &envelope{version: rest[0], suite: rest[1]}
+	rest = rest[2:]
+	switch env.version {
+	case envelopeVersion1:
+		env.keyVersion = initialKeyVersion
+	case envelopeVersion2:
+		if len(rest) < 4 {
+			return nil, fmt.Errorf("envelope header truncated")
+		}
+		env.keyVersion = int32(binary.BigEndian.Uint32(rest[:4]))
+		rest = rest[4:]
+	default:
+		return nil, fmt.Errorf("unsupported envelope version: %d", env.version)
+	}
+
+	if len(rest) < 2 {
+		return nil, fmt.Errorf("envelope header truncated")
+	}
+	keyLen := int(binary.BigEndian.Uint16(rest[:2]))
+	rest = rest[2:]
+	if len(rest) < keyLen+12 {
+		return nil, fmt.Errorf("envelope body truncated")
+	}
+	env.wrappedKey = rest[:keyLen]
+	env.header = ciphertext[:len(ciphertext)-len(rest)+keyLen]
+	env.nonce = rest[keyLen : keyLen+12]
+	env.payload = rest[keyLen+12:]
+	return env, nil
+}
+
+// ciphertextKeyVersion reports which user key version a ciphertext needs.
+// Raw RSA blobs from before envelopes were sealed with the first version.
+func ciphertextKeyVersion(ciphertext []byte) int32 {
+	env, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return initialKeyVersion
+	}
+	return env.keyVersion
+}
+
+// wrapDataKey produces a data key and its wrapped form for pub, and names the
+// suite that wraps it.
+func wrapDataKey(pub crypto.PublicKey) (byte, []byte, []byte, error) {
+	suite, err := keySuite(pub)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		dataKey := make([]byte, dataKeySize)
+		if _, err := rand.Read(dataKey); err != nil {
+			return 0, nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+		}
+		wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, k, dataKey, nil)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("failed to wrap data key: %w", err)
+		}
+		return suite, dataKey, wrappedKey, nil
+	case *ecdh.PublicKey:
+		dataKey, encapsulated, err := x25519Encapsulate(k)
+		return suite, dataKey, encapsulated, err
+	case *hybridPublicKey:
+		dataKey, encapsulated, err := hybridEncapsulate(k)
+		return suite, dataKey, encapsulated, err
+	default:
+		return 0, nil, nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// keySuite returns the envelope suite sealed to keys like pub.
+func keySuite(pub crypto.PublicKey) (byte, error) {
+	algorithm, err := keyAlgorithm(pub)
+	if err != nil {
+		return 0, err
+	}
+	switch algorithm {
+	case algorithmX25519:
+		return suiteX25519HKDFAESGCM, nil
+	case algorithmX25519MLKEM768:
+		return suiteX25519MLKEM768AESGCM, nil
+	default:
+		return suiteRSAOAEPAESGCM, nil
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init AES: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+// decryptCiphertext opens both envelope ciphertexts and the raw RSA-OAEP blobs
+// written before envelopes were introduced.
+func decryptCiphertext(priv crypto.Decrypter, ciphertext []byte) ([]byte, error) {
+	plaintext, err := openEnvelope(priv, ciphertext)
+	if err == nil {
+		return plaintext, nil
+	}
+	// Legacy blobs only exist for RSA keys
+	rsaPub, ok := priv.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("envelope decrypt failed: %v", err)
+	}
+	// A legacy blob is exactly one RSA block; it only reaches this point when
+	// it happens to start with the envelope magic, so try it as legacy too.
+	if !errors.Is(err, errNotEnvelope) && len(ciphertext) != rsaPub.Size() {
+		return nil, fmt.Errorf("envelope decrypt failed: %v", err)
+	}
+
+	plaintext, legacyErr := priv.Decrypt(rand.Reader, ciphertext, oaepSHA256)
+	if legacyErr != nil {
+		if !errors.Is(err, errNotEnvelope) {
+			return nil, fmt.Errorf("envelope decrypt failed: %v", err)
+		}
+		return nil, fmt.Errorf("rsa decrypt failed: %v", legacyErr)
+	}
+	return plaintext, nil
+}
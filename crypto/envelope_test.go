@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	plaintext := make([]byte, 10*1024)
+	_, err = rand.Read(plaintext)
+	require.NoError(t, err)
+
+	ciphertext, err := sealEnvelope(&priv.PublicKey, 3, plaintext)
+	require.NoError(t, err, "sealEnvelope should succeed")
+
+	env, err := parseEnvelope(ciphertext)
+	require.NoError(t, err, "parseEnvelope should succeed")
+	require.Equal(t, envelopeVersion2, env.version)
+	require.Equal(t, suiteRSAOAEPAESGCM, env.suite)
+	require.Equal(t, int32(3), env.keyVersion)
+
+	got, err := openEnvelope(priv, ciphertext)
+	require.NoError(t, err, "openEnvelope should succeed")
+	require.Equal(t, plaintext, got)
+}
+
+func TestEnvelopeRejectsTampering(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ciphertext, err := sealEnvelope(&priv.PublicKey, 1, []byte("secret"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		offset int
+	}{
+		{name: "suite byte", offset: len(envelopeMagic) + 1},
+		{name: "key version", offset: len(envelopeMagic) + 5},
+		{name: "wrapped key", offset: len(envelopeMagic) + 10},
+		{name: "payload", offset: len(ciphertext) - 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tampered := append([]byte(nil), ciphertext...)
+			tampered[tc.offset] ^= 0x01
+			_, err := openEnvelope(priv, tampered)
+			require.Error(t, err, "tampered envelope must not decrypt")
+		})
+	}
+}
+
+func TestParseEnvelopeRejectsRawRSA(t *testing.T) {
+	_, err := parseEnvelope([]byte("not an envelope"))
+	require.ErrorIs(t, err, errNotEnvelope)
+}
+
+func TestOpenVersion1Envelope(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// Rebuild a version 1 envelope by dropping the key version field
+	ciphertext, err := sealEnvelope(&priv.PublicKey, initialKeyVersion, []byte("secret"))
+	require.NoError(t, err)
+	v1 := append([]byte(nil), ciphertext[:len(envelopeMagic)+2]...)
+	v1[len(envelopeMagic)] = envelopeVersion1
+	v1 = append(v1, ciphertext[len(envelopeMagic)+6:]...)
+
+	env, err := parseEnvelope(v1)
+	require.NoError(t, err, "version 1 envelopes should still parse")
+	require.Equal(t, initialKeyVersion, env.keyVersion)
+	require.Equal(t, initialKeyVersion, ciphertextKeyVersion(v1))
+}
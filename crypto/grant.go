@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Decrypt requests carry a grant the backend signs with its Ed25519 grant key
+// after checking ownership or a completed disclosure. So do the RPCs that
+// reseal a secret for someone else. The crypto service only holds the public
+// keys, so it cannot mint grants itself.
+
+// maxGrantLifetime bounds how far in the future a grant may expire.
+const maxGrantLifetime = 5 * time.Minute
+
+type grantVerifier struct {
+	keys []ed25519.PublicKey
+	now  func() time.Time
+}
+
+// loadGrantVerifier parses GRANT_PUBLIC_KEYS. Several keys may be listed while
+// the backend's grant key is rotated. Without any key, grant signatures are
+// only skipped when ALLOW_INSECURE is set.
+func loadGrantVerifier(cfg Config) (*grantVerifier, error) {
+	g := &grantVerifier{now: time.Now}
+	for _, encoded := range strings.Split(cfg.GrantPublicKeys, ",") {
+		encoded = strings.TrimSpace(encoded)
+		if encoded == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("grant public key is not valid base64: %w", err)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("grant public key must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+		}
+		g.keys = append(g.keys, ed25519.PublicKey(key))
+	}
+	if len(g.keys) == 0 && !cfg.AllowInsecure {
+		return nil, fmt.Errorf("GRANT_PUBLIC_KEYS must be set unless ALLOW_INSECURE is true")
+	}
+	return g, nil
+}
+
+// authorize checks who the plaintext is for and returns which ciphertexts the
+// grant lets them read. Only the key owner may read: receivers read the copy
+// that was re-encrypted to their own key.
+func (g *grantVerifier) authorize(ctx context.Context, userID, actorID string, purpose crypto.DecryptPurpose, signed *crypto.SignedDecryptGrant) (func(ciphertext []byte) bool, error) {
+	// 1. The request itself must name a legitimate reader
+	if purpose != crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER && purpose != crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED {
+		return nil, status.Error(codes.PermissionDenied, "decrypt purpose is required")
+	}
+	if actorID == "" || actorID != userID {
+		return nil, status.Error(codes.PermissionDenied, "only the key owner may decrypt")
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if actor := firstMetadata(md, actorMetadataKey); actor != "" && actor != actorID {
+			return nil, status.Error(codes.PermissionDenied, "actor does not match the calling user")
+		}
+	}
+
+	if len(g.keys) == 0 {
+		// Only reachable with ALLOW_INSECURE
+		return func([]byte) bool { return true }, nil
+	}
+
+	// 2. The grant must be signed by the backend and issued for this request
+	grant, err := g.verify(signed)
+	if err != nil {
+		return nil, err
+	}
+	if grant.GetUserId() != userID || grant.GetActorUserId() != actorID || grant.GetPurpose() != purpose {
+		return nil, status.Error(codes.PermissionDenied, "decrypt grant was issued for another request")
+	}
+	return coveredBy(grant), nil
+}
+
+// authorizeHandover checks a grant for the RPCs that open the owner's
+// ciphertexts only to seal them again for someone else. The grant has to name
+// the hand-over, the owner and the recipient, so it cannot be replayed to
+// rewrap the same secrets to another user.
+func (g *grantVerifier) authorizeHandover(purpose crypto.DecryptPurpose, ownerID, recipientID string, signed *crypto.SignedDecryptGrant) (func(ciphertext []byte) bool, error) {
+	if ownerID == "" || recipientID == "" {
+		return nil, status.Error(codes.InvalidArgument, "owner and recipient user IDs are required")
+	}
+	if len(g.keys) == 0 {
+		// Only reachable with ALLOW_INSECURE
+		return func([]byte) bool { return true }, nil
+	}
+
+	grant, err := g.verify(signed)
+	if err != nil {
+		return nil, err
+	}
+	if grant.GetPurpose() != purpose || grant.GetUserId() != ownerID || grant.GetRecipientUserId() != recipientID {
+		return nil, status.Error(codes.PermissionDenied, "decrypt grant was issued for another request")
+	}
+	return coveredBy(grant), nil
+}
+
+// authorizeUnlock checks a grant for UnlockVault. The backend signs it after
+// counting the receivers whose disclosures have matured, so only their shares
+// may be combined and a bug elsewhere cannot unlock the vault early.
+func (g *grantVerifier) authorizeUnlock(passerID string, requesterIDs []string, signed *crypto.SignedDecryptGrant) error {
+	if passerID == "" {
+		return status.Error(codes.InvalidArgument, "passer user ID is required")
+	}
+	if len(g.keys) == 0 {
+		// Only reachable with ALLOW_INSECURE
+		return nil
+	}
+
+	grant, err := g.verify(signed)
+	if err != nil {
+		return err
+	}
+	if grant.GetPurpose() != crypto.DecryptPurpose_DECRYPT_PURPOSE_UNLOCK_VAULT || grant.GetUserId() != passerID {
+		return status.Error(codes.PermissionDenied, "unlock grant was issued for another request")
+	}
+	granted := make(map[string]bool, len(grant.GetRequesterUserIds()))
+	for _, id := range grant.GetRequesterUserIds() {
+		granted[id] = true
+	}
+	for _, id := range requesterIDs {
+		if !granted[id] {
+			return status.Error(codes.PermissionDenied, "requester is not named in the unlock grant")
+		}
+	}
+	return nil
+}
+
+// verify checks the backend's signature and the lifetime of a grant.
+func (g *grantVerifier) verify(signed *crypto.SignedDecryptGrant) (*crypto.DecryptGrant, error) {
+	if signed == nil || !g.verifySignature(signed.GetGrant(), signed.GetSignature()) {
+		return nil, status.Error(codes.PermissionDenied, "missing or invalid decrypt grant")
+	}
+	var grant crypto.DecryptGrant
+	if err := proto.Unmarshal(signed.GetGrant(), &grant); err != nil {
+		return nil, status.Error(codes.PermissionDenied, "malformed decrypt grant")
+	}
+	now := g.now()
+	expiresAt := time.Unix(grant.GetExpiresAt(), 0)
+	if !now.Before(expiresAt) || expiresAt.Sub(now) > maxGrantLifetime {
+		return nil, status.Error(codes.PermissionDenied, "decrypt grant expired")
+	}
+	return &grant, nil
+}
+
+// coveredBy reports whether a ciphertext's SHA-256 digest is in the grant.
+func coveredBy(grant *crypto.DecryptGrant) func(ciphertext []byte) bool {
+	covered := make(map[[sha256.Size]byte]bool, len(grant.GetCiphertextDigests()))
+	for _, digest := range grant.GetCiphertextDigests() {
+		if len(digest) == sha256.Size {
+			covered[[sha256.Size]byte(digest)] = true
+		}
+	}
+	return func(ciphertext []byte) bool {
+		return covered[sha256.Sum256(ciphertext)]
+	}
+}
+
+func (g *grantVerifier) verifySignature(grant, signature []byte) bool {
+	for _, key := range g.keys {
+		if ed25519.Verify(key, grant, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// errNotGranted is returned for ciphertexts the grant does not cover.
+var errNotGranted = status.Error(codes.PermissionDenied, "ciphertext is not covered by the decrypt grant")
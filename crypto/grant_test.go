@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// testGrantKey plays the backend's grant key in tests.
+var testGrantPub, testGrantKey, _ = ed25519.GenerateKey(rand.Reader)
+
+func newTestGrantVerifier() *grantVerifier {
+	return &grantVerifier{keys: []ed25519.PublicKey{testGrantPub}, now: time.Now}
+}
+
+func signGrant(t *testing.T, key ed25519.PrivateKey, grant *crypto.DecryptGrant) *crypto.SignedDecryptGrant {
+	t.Helper()
+	raw, err := proto.Marshal(grant)
+	require.NoError(t, err, "marshal grant failed")
+	return &crypto.SignedDecryptGrant{Grant: raw, Signature: ed25519.Sign(key, raw)}
+}
+
+// signTestGrant issues a grant for the key owner like the backend does.
+func signTestGrant(t *testing.T, userID string, purpose crypto.DecryptPurpose, ciphertexts ...[]byte) *crypto.SignedDecryptGrant {
+	t.Helper()
+	grant := &crypto.DecryptGrant{
+		UserId:      userID,
+		ActorUserId: userID,
+		Purpose:     purpose,
+		ExpiresAt:   time.Now().Add(time.Minute).Unix(),
+	}
+	for _, ciphertext := range ciphertexts {
+		digest := sha256.Sum256(ciphertext)
+		grant.CiphertextDigests = append(grant.CiphertextDigests, digest[:])
+	}
+	return signGrant(t, testGrantKey, grant)
+}
+
+// signHandoverGrant issues a grant to reseal the owner's ciphertexts for the recipient.
+func signHandoverGrant(t *testing.T, purpose crypto.DecryptPurpose, ownerID, recipientID string, ciphertexts ...[]byte) *crypto.SignedDecryptGrant {
+	t.Helper()
+	grant := &crypto.DecryptGrant{
+		UserId:          ownerID,
+		RecipientUserId: recipientID,
+		Purpose:         purpose,
+		ExpiresAt:       time.Now().Add(time.Minute).Unix(),
+	}
+	for _, ciphertext := range ciphertexts {
+		digest := sha256.Sum256(ciphertext)
+		grant.CiphertextDigests = append(grant.CiphertextDigests, digest[:])
+	}
+	return signGrant(t, testGrantKey, grant)
+}
+
+// signUnlockGrant issues a grant to unlock the passer's vault with the requesters' shares.
+func signUnlockGrant(t *testing.T, passerID string, requesterIDs ...string) *crypto.SignedDecryptGrant {
+	t.Helper()
+	return signGrant(t, testGrantKey, &crypto.DecryptGrant{
+		UserId:           passerID,
+		RequesterUserIds: requesterIDs,
+		Purpose:          crypto.DecryptPurpose_DECRYPT_PURPOSE_UNLOCK_VAULT,
+		ExpiresAt:        time.Now().Add(time.Minute).Unix(),
+	})
+}
+
+// ownerDecryptRequest is the owner reading their own ciphertext.
+func ownerDecryptRequest(t *testing.T, userID string, ciphertext []byte) *crypto.DecryptRequest {
+	return &crypto.DecryptRequest{
+		UserId:      userID,
+		Ciphertext:  ciphertext,
+		ActorUserId: userID,
+		Purpose:     crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+		Grant:       signTestGrant(t, userID, crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, ciphertext),
+	}
+}
+
+func TestAuthorizeDecrypt(t *testing.T) {
+	const owner = "owner"
+	owned := []byte("owner ciphertext")
+	other := []byte("someone else's ciphertext")
+	g := newTestGrantVerifier()
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	grantWith := func(edit func(grant *crypto.DecryptGrant)) *crypto.DecryptGrant {
+		digest := sha256.Sum256(owned)
+		grant := &crypto.DecryptGrant{
+			UserId:            owner,
+			ActorUserId:       owner,
+			Purpose:           crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+			CiphertextDigests: [][]byte{digest[:]},
+			ExpiresAt:         time.Now().Add(time.Minute).Unix(),
+		}
+		if edit != nil {
+			edit(grant)
+		}
+		return grant
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		actorID string
+		purpose crypto.DecryptPurpose
+		grant   *crypto.SignedDecryptGrant
+		ok      bool
+	}{
+		{name: "owner", actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+			grant: signGrant(t, testGrantKey, grantWith(nil)), ok: true},
+		{name: "receiver of a disclosure", actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED,
+			grant: signGrant(t, testGrantKey, grantWith(func(g *crypto.DecryptGrant) { g.Purpose = crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED })), ok: true},
+		{name: "no purpose", actorID: owner,
+			grant: signGrant(t, testGrantKey, grantWith(func(g *crypto.DecryptGrant) { g.Purpose = 0 }))},
+		{name: "actor is not the key owner", actorID: "intruder", purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+			grant: signGrant(t, testGrantKey, grantWith(func(g *crypto.DecryptGrant) { g.ActorUserId = "intruder" }))},
+		{name: "actor differs from the calling user",
+			ctx:     metadata.NewIncomingContext(context.Background(), metadata.Pairs(actorMetadataKey, "intruder")),
+			actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+			grant: signGrant(t, testGrantKey, grantWith(nil))},
+		{name: "no grant", actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER},
+		{name: "grant signed by another key", actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+			grant: signGrant(t, otherKey, grantWith(nil))},
+		{name: "grant for another purpose", actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED,
+			grant: signGrant(t, testGrantKey, grantWith(nil))},
+		{name: "grant for another user", actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+			grant: signGrant(t, testGrantKey, grantWith(func(g *crypto.DecryptGrant) { g.UserId = "someone" }))},
+		{name: "expired grant", actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+			grant: signGrant(t, testGrantKey, grantWith(func(g *crypto.DecryptGrant) { g.ExpiresAt = time.Now().Add(-time.Second).Unix() }))},
+		{name: "grant valid for too long", actorID: owner, purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+			grant: signGrant(t, testGrantKey, grantWith(func(g *crypto.DecryptGrant) { g.ExpiresAt = time.Now().Add(time.Hour).Unix() }))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			covers, err := g.authorize(ctx, owner, tt.actorID, tt.purpose, tt.grant)
+			if !tt.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, covers(owned), "granted ciphertext must be covered")
+			require.False(t, covers(other), "other ciphertexts must not be covered")
+		})
+	}
+}
+
+func TestAuthorizeHandover(t *testing.T) {
+	const owner, recipient = "owner", "recipient"
+	owned := []byte("owner ciphertext")
+	other := []byte("someone else's ciphertext")
+	g := newTestGrantVerifier()
+	purpose := crypto.DecryptPurpose_DECRYPT_PURPOSE_HANDOVER
+
+	grantWith := func(edit func(grant *crypto.DecryptGrant)) *crypto.SignedDecryptGrant {
+		digest := sha256.Sum256(owned)
+		grant := &crypto.DecryptGrant{
+			UserId:            owner,
+			RecipientUserId:   recipient,
+			Purpose:           purpose,
+			CiphertextDigests: [][]byte{digest[:]},
+			ExpiresAt:         time.Now().Add(time.Minute).Unix(),
+		}
+		if edit != nil {
+			edit(grant)
+		}
+		return signGrant(t, testGrantKey, grant)
+	}
+
+	tests := []struct {
+		name  string
+		grant *crypto.SignedDecryptGrant
+		ok    bool
+	}{
+		{name: "hand-over", grant: grantWith(nil), ok: true},
+		{name: "no grant"},
+		{name: "expired grant", grant: grantWith(func(g *crypto.DecryptGrant) { g.ExpiresAt = time.Now().Add(-time.Second).Unix() })},
+		{name: "grant for another recipient", grant: grantWith(func(g *crypto.DecryptGrant) { g.RecipientUserId = "intruder" })},
+		{name: "grant for another owner", grant: grantWith(func(g *crypto.DecryptGrant) { g.UserId = "someone" })},
+		{name: "decrypt grant of the owner", grant: grantWith(func(g *crypto.DecryptGrant) {
+			g.Purpose = crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER
+			g.ActorUserId = owner
+		})},
+		{name: "grant for opening the vault", grant: grantWith(func(g *crypto.DecryptGrant) { g.Purpose = crypto.DecryptPurpose_DECRYPT_PURPOSE_OPEN_VAULT })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			covers, err := g.authorizeHandover(purpose, owner, recipient, tt.grant)
+			if !tt.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, covers(owned), "granted ciphertext must be covered")
+			require.False(t, covers(other), "other ciphertexts must not be covered")
+		})
+	}
+
+	// A hand-over grant does not let anyone read the plaintext
+	_, err := g.authorize(context.Background(), owner, owner, purpose, grantWith(nil))
+	require.Error(t, err)
+}
+
+// TestHandoverRPCsRequireGrant calls the RPCs that reseal secrets for someone
+// else with grants that must be rejected before any key is loaded.
+func TestHandoverRPCsRequireGrant(t *testing.T) {
+	const passer, receiver = "passer", "receiver"
+	ciphertext := []byte("passer ciphertext")
+	s := &Server{grants: newTestGrantVerifier()}
+	ctx := context.Background()
+
+	expired := func(purpose crypto.DecryptPurpose, recipientID string) *crypto.SignedDecryptGrant {
+		digest := sha256.Sum256(ciphertext)
+		return signGrant(t, testGrantKey, &crypto.DecryptGrant{
+			UserId:            passer,
+			RecipientUserId:   recipientID,
+			Purpose:           purpose,
+			CiphertextDigests: [][]byte{digest[:]},
+			ExpiresAt:         time.Now().Add(-time.Second).Unix(),
+		})
+	}
+
+	rpcs := []struct {
+		name    string
+		purpose crypto.DecryptPurpose
+		// recipient the grant has to name
+		recipient string
+		call      func(grant *crypto.SignedDecryptGrant) error
+	}{
+		{
+			name: "ReencryptForRecipient", purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_HANDOVER, recipient: receiver,
+			call: func(grant *crypto.SignedDecryptGrant) error {
+				_, err := s.ReencryptForRecipient(ctx, &crypto.ReencryptForRecipientRequest{
+					OwnerUserId: passer, RecipientUserId: receiver, Ciphertext: ciphertext, Grant: grant,
+				})
+				return err
+			},
+		},
+		{
+			name: "SealToVault", purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_SEAL_TO_VAULT, recipient: passer,
+			call: func(grant *crypto.SignedDecryptGrant) error {
+				_, err := s.SealToVault(ctx, &crypto.SealToVaultRequest{
+					PasserUserId: passer, Ciphertexts: [][]byte{ciphertext}, Grant: grant,
+				})
+				return err
+			},
+		},
+		{
+			name: "OpenVaultForRecipient", purpose: crypto.DecryptPurpose_DECRYPT_PURPOSE_OPEN_VAULT, recipient: receiver,
+			call: func(grant *crypto.SignedDecryptGrant) error {
+				_, err := s.OpenVaultForRecipient(ctx, &crypto.OpenVaultForRecipientRequest{
+					PasserUserId: passer, RecipientUserId: receiver, Ciphertexts: [][]byte{ciphertext}, Grant: grant,
+				})
+				return err
+			},
+		},
+	}
+	for _, rpc := range rpcs {
+		grants := []struct {
+			name  string
+			grant *crypto.SignedDecryptGrant
+		}{
+			{name: "no grant"},
+			{name: "expired grant", grant: expired(rpc.purpose, rpc.recipient)},
+			{name: "grant for another recipient", grant: signHandoverGrant(t, rpc.purpose, passer, "intruder", ciphertext)},
+			{name: "grant for another RPC", grant: signHandoverGrant(t, crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, passer, rpc.recipient, ciphertext)},
+		}
+		for _, tt := range grants {
+			t.Run(rpc.name+"/"+tt.name, func(t *testing.T) {
+				err := rpc.call(tt.grant)
+				require.Equal(t, codes.PermissionDenied, status.Code(err), "got %v", err)
+			})
+		}
+	}
+
+	t.Run("ReencryptForRecipient/grant for another ciphertext", func(t *testing.T) {
+		err := rpcs[0].call(signHandoverGrant(t, rpcs[0].purpose, passer, receiver, []byte("another ciphertext")))
+		require.Equal(t, codes.PermissionDenied, status.Code(err), "got %v", err)
+	})
+}
+
+// TestUnlockVaultRequiresGrant unlocks a vault with grants that must be
+// rejected before any share is loaded.
+func TestUnlockVaultRequiresGrant(t *testing.T) {
+	const passer = "passer"
+	s := &Server{grants: newTestGrantVerifier()}
+
+	grantWith := func(edit func(grant *crypto.DecryptGrant)) *crypto.SignedDecryptGrant {
+		grant := &crypto.DecryptGrant{
+			UserId:           passer,
+			RequesterUserIds: []string{"heir-a", "heir-b"},
+			Purpose:          crypto.DecryptPurpose_DECRYPT_PURPOSE_UNLOCK_VAULT,
+			ExpiresAt:        time.Now().Add(time.Minute).Unix(),
+		}
+		if edit != nil {
+			edit(grant)
+		}
+		return signGrant(t, testGrantKey, grant)
+	}
+
+	tests := []struct {
+		name       string
+		requesters []string
+		grant      *crypto.SignedDecryptGrant
+	}{
+		{name: "no grant", requesters: []string{"heir-a", "heir-b"}},
+		{name: "expired grant", requesters: []string{"heir-a", "heir-b"},
+			grant: grantWith(func(g *crypto.DecryptGrant) { g.ExpiresAt = time.Now().Add(-time.Second).Unix() })},
+		{name: "grant for another passer", requesters: []string{"heir-a", "heir-b"},
+			grant: grantWith(func(g *crypto.DecryptGrant) { g.UserId = "someone" })},
+		{name: "grant for another RPC", requesters: []string{"heir-a", "heir-b"},
+			grant: grantWith(func(g *crypto.DecryptGrant) { g.Purpose = crypto.DecryptPurpose_DECRYPT_PURPOSE_OPEN_VAULT })},
+		{name: "requester not in the grant", requesters: []string{"heir-a", "heir-c"}, grant: grantWith(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.UnlockVault(context.Background(), &crypto.UnlockVaultRequest{
+				PasserUserId: passer, RequesterUserIds: tt.requesters, Grant: tt.grant,
+			})
+			require.Equal(t, codes.PermissionDenied, status.Code(err), "got %v", err)
+		})
+	}
+
+	require.NoError(t, s.grants.authorizeUnlock(passer, []string{"heir-b"}, grantWith(nil)), "a subset of the granted requesters may unlock")
+}
+
+func TestLoadGrantVerifier(t *testing.T) {
+	g, err := loadGrantVerifier(Config{GrantPublicKeys: base64.StdEncoding.EncodeToString(testGrantPub)})
+	require.NoError(t, err)
+	require.Len(t, g.keys, 1)
+
+	_, err = loadGrantVerifier(Config{})
+	require.Error(t, err, "no grant keys without ALLOW_INSECURE")
+	_, err = loadGrantVerifier(Config{GrantPublicKeys: base64.StdEncoding.EncodeToString([]byte("short"))})
+	require.Error(t, err, "wrong key size")
+}
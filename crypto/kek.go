@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// kekProvider wraps and unwraps user private keys with a key-encryption key
+// (KEK). Wrapped keys remember the ID of the KEK that produced them, so a new
+// KEK can become current while older ones stay available for unwrapping.
+type kekProvider interface {
+	CurrentID() string
+	Wrap(userID string, plaintext []byte) (kekID string, wrapped []byte, err error)
+	Unwrap(kekID, userID string, wrapped []byte) ([]byte, error)
+}
+
+// localKeyring keeps KEKs in memory. It is loaded from config or from a local
+// keyring file and stands in for a KMS.
+type localKeyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// keyringFile is the on-disk format read from KEK_FILE:
+//
+//	{"current": "2025-01", "keys": {"2024-06": "<base64>", "2025-01": "<base64>"}}
+type keyringFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// loadKEKProvider builds the keyring from KEK_FILE if set, otherwise from the
+// KEK/KEK_ID pair plus any KEK_RETIRED "id:base64" entries.
+func loadKEKProvider(cfg Config) (kekProvider, error) {
+	if cfg.KEKFile != "" {
+		raw, err := os.ReadFile(cfg.KEKFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read KEK file: %w", err)
+		}
+		var f keyringFile
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse KEK file: %w", err)
+		}
+		return newLocalKeyring(f.Current, f.Keys)
+	}
+
+	if cfg.KEK == "" {
+		return nil, fmt.Errorf("KEK or KEK_FILE must be set")
+	}
+	keys := map[string]string{cfg.KEKID: cfg.KEK}
+	for _, entry := range strings.Split(cfg.KEKRetired, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, key, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid KEK_RETIRED entry %q, want id:base64", entry)
+		}
+		keys[id] = key
+	}
+	return newLocalKeyring(cfg.KEKID, keys)
+}
+
+func newLocalKeyring(current string, encoded map[string]string) (*localKeyring, error) {
+	if current == "" {
+		return nil, fmt.Errorf("current KEK ID is empty")
+	}
+	k := &localKeyring{current: current, keys: make(map[string][]byte, len(encoded))}
+	for id, enc := range encoded {
+		key, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("KEK %q is not valid base64: %w", id, err)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("KEK %q must be %d bytes, got %d", id, dataKeySize, len(key))
+		}
+		k.keys[id] = key
+	}
+	if _, ok := k.keys[current]; !ok {
+		return nil, fmt.Errorf("current KEK %q is not in the keyring", current)
+	}
+	return k, nil
+}
+
+func (k *localKeyring) CurrentID() string {
+	return k.current
+}
+
+// Wrap encrypts plaintext under the current KEK with AES-256-GCM. The user ID
+// is authenticated so a wrapped key cannot be moved to another user's row.
+func (k *localKeyring) Wrap(userID string, plaintext []byte) (string, []byte, error) {
+	gcm, err := newGCM(k.keys[k.current])
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.current, gcm.Seal(nonce, nonce, plaintext, []byte(userID)), nil
+}
+
+func (k *localKeyring) Unwrap(kekID, userID string, wrapped []byte) ([]byte, error) {
+	key, ok := k.keys[kekID]
+	if !ok {
+		return nil, fmt.Errorf("unknown KEK %q", kekID)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, fmt.Errorf("wrapped key truncated")
+	}
+	nonce, ct := wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ct, []byte(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap private key with KEK %q: %w", kekID, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalKeyringWrapUnwrap(t *testing.T) {
+	oldKey, newKey := randomKEK(t), randomKEK(t)
+	ring, err := newLocalKeyring("new", map[string]string{"old": oldKey, "new": newKey})
+	require.NoError(t, err)
+
+	kekID, wrapped, err := ring.Wrap("user-1", []byte("private key"))
+	require.NoError(t, err)
+	require.Equal(t, "new", kekID, "Wrap must use the current KEK")
+
+	got, err := ring.Unwrap(kekID, "user-1", wrapped)
+	require.NoError(t, err)
+	require.Equal(t, []byte("private key"), got)
+
+	_, err = ring.Unwrap(kekID, "user-2", wrapped)
+	require.Error(t, err, "a wrapped key must not unwrap for another user")
+
+	_, err = ring.Unwrap("missing", "user-1", wrapped)
+	require.Error(t, err, "unknown KEK IDs must be rejected")
+}
+
+func TestLoadKEKProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		current string
+		wantErr bool
+	}{
+		{
+			name:    "single KEK from config",
+			cfg:     Config{KEK: randomKEK(t), KEKID: "k1"},
+			current: "k1",
+		},
+		{
+			name:    "retired KEKs kept for unwrapping",
+			cfg:     Config{KEK: randomKEK(t), KEKID: "k2", KEKRetired: "k1:" + randomKEK(t)},
+			current: "k2",
+		},
+		{
+			name:    "missing KEK",
+			cfg:     Config{KEKID: "k1"},
+			wantErr: true,
+		},
+		{
+			name:    "KEK of wrong size",
+			cfg:     Config{KEK: "c2hvcnQ=", KEKID: "k1"},
+			wantErr: true,
+		},
+		{
+			name:    "malformed retired entry",
+			cfg:     Config{KEK: randomKEK(t), KEKID: "k2", KEKRetired: "k1"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			keks, err := loadKEKProvider(tc.cfg)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.current, keks.CurrentID())
+		})
+	}
+}
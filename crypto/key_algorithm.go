@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Key algorithms a user or vault key pair can use. The algorithm is recorded
+// next to each key, and KEY_ALGORITHM picks it for newly generated keys, so
+// rotating a user moves them to a new algorithm while old versions stay
+// readable.
+const (
+	algorithmRSA2048        = "rsa-2048"
+	algorithmX25519         = "x25519"
+	algorithmX25519MLKEM768 = "x25519-mlkem768"
+)
+
+// PEM block types of the non-RSA keys. The X25519 blocks hold the raw 32-byte
+// keys; the hybrid blocks hold the X25519 key followed by the ML-KEM-768 seed
+// (private) or encapsulation key (public).
+const (
+	pemX25519Private         = "X25519 PRIVATE KEY"
+	pemX25519Public          = "X25519 PUBLIC KEY"
+	pemX25519MLKEM768Private = "X25519 MLKEM768 PRIVATE KEY"
+	pemX25519MLKEM768Public  = "X25519 MLKEM768 PUBLIC KEY"
+)
+
+// HKDF info strings, so a shared secret of one suite is never reused as a
+// data key of another.
+var (
+	hkdfInfoX25519         = []byte("digi-baton envelope x25519 v1")
+	hkdfInfoX25519MLKEM768 = []byte("digi-baton envelope x25519-mlkem768 v1")
+)
+
+func validKeyAlgorithm(algorithm string) error {
+	switch algorithm {
+	case algorithmRSA2048, algorithmX25519, algorithmX25519MLKEM768:
+		return nil
+	default:
+		return fmt.Errorf("unknown key algorithm %q, want %s, %s or %s", algorithm, algorithmRSA2048, algorithmX25519, algorithmX25519MLKEM768)
+	}
+}
+
+// generateKey creates a key pair of the given algorithm. The public half is
+// available through Public().
+func generateKey(algorithm string) (crypto.Decrypter, error) {
+	priv, err := newKey(algorithm)
+	if err == nil {
+		keysGenerated.WithLabelValues(algorithm).Inc()
+	}
+	return priv, err
+}
+
+func newKey(algorithm string) (crypto.Decrypter, error) {
+	switch algorithm {
+	case algorithmRSA2048:
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		return priv, nil
+	case algorithmX25519:
+		priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate X25519 key: %w", err)
+		}
+		return &x25519Key{priv: priv}, nil
+	case algorithmX25519MLKEM768:
+		priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate X25519 key: %w", err)
+		}
+		seed := make([]byte, mlkem768.KeySeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, fmt.Errorf("failed to generate ML-KEM seed: %w", err)
+		}
+		return newHybridKey(priv, seed), nil
+	default:
+		return nil, validKeyAlgorithm(algorithm)
+	}
+}
+
+// marshalKey encodes a key pair as PEM, the way it is stored.
+func marshalKey(priv crypto.Decrypter) (string, string, error) {
+	switch k := priv.(type) {
+	case *rsa.PrivateKey:
+		privPEM, pubPEM := marshalRSA(k)
+		return privPEM, pubPEM, nil
+	case *x25519Key:
+		return encodePEM(pemX25519Private, k.priv.Bytes()),
+			encodePEM(pemX25519Public, k.priv.PublicKey().Bytes()), nil
+	case *hybridKey:
+		privBytes := append(k.x25519.Bytes(), k.seed...)
+		return encodePEM(pemX25519MLKEM768Private, privBytes),
+			encodePEM(pemX25519MLKEM768Public, k.pub.bytes()), nil
+	default:
+		return "", "", fmt.Errorf("cannot store %T keys", priv)
+	}
+}
+
+// keyAlgorithm names the algorithm of a public key.
+func keyAlgorithm(pub crypto.PublicKey) (string, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return algorithmRSA2048, nil
+	case *ecdh.PublicKey:
+		if k.Curve() == ecdh.X25519() {
+			return algorithmX25519, nil
+		}
+	case *hybridPublicKey:
+		return algorithmX25519MLKEM768, nil
+	}
+	return "", fmt.Errorf("unsupported public key type %T", pub)
+}
+
+func parseKeys(algorithm, pemPrivate, pemPublic string) (crypto.Decrypter, crypto.PublicKey, error) {
+	parsedPriv, err := parsePrivateKey(algorithm, pemPrivate)
+	if err != nil {
+		return nil, nil, err
+	}
+	parsedPub, err := parsePublicKey(algorithm, pemPublic)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parsedPriv, parsedPub, nil
+}
+
+func parsePrivateKey(algorithm, pemPrivate string) (crypto.Decrypter, error) {
+	switch algorithm {
+	case algorithmRSA2048:
+		return parseRSAPrivateKey(pemPrivate)
+	case algorithmX25519:
+		raw, err := decodePEM(pemX25519Private, pemPrivate)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := ecdh.X25519().NewPrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return &x25519Key{priv: priv}, nil
+	case algorithmX25519MLKEM768:
+		raw, err := decodePEM(pemX25519MLKEM768Private, pemPrivate)
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) != 32+mlkem768.KeySeedSize {
+			return nil, fmt.Errorf("invalid private key length")
+		}
+		priv, err := ecdh.X25519().NewPrivateKey(raw[:32])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return newHybridKey(priv, raw[32:]), nil
+	default:
+		return nil, validKeyAlgorithm(algorithm)
+	}
+}
+
+func parsePublicKey(algorithm, pemPublic string) (crypto.PublicKey, error) {
+	switch algorithm {
+	case algorithmRSA2048:
+		return parseRSAPublicKey(pemPublic)
+	case algorithmX25519:
+		raw, err := decodePEM(pemX25519Public, pemPublic)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := ecdh.X25519().NewPublicKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return pub, nil
+	case algorithmX25519MLKEM768:
+		raw, err := decodePEM(pemX25519MLKEM768Public, pemPublic)
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) != 32+mlkem768.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key length")
+		}
+		x25519Pub, err := ecdh.X25519().NewPublicKey(raw[:32])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		var mlkemPub mlkem768.PublicKey
+		if err := mlkemPub.Unpack(raw[32:]); err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return &hybridPublicKey{x25519: x25519Pub, mlkem: &mlkemPub}, nil
+	default:
+		return nil, validKeyAlgorithm(algorithm)
+	}
+}
+
+func parseRSAPrivateKey(pemPrivate string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemPrivate))
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		return nil, fmt.Errorf("invalid private key PEM")
+	}
+	parsedPriv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return parsedPriv, nil
+}
+
+func parseRSAPublicKey(pemPublic string) (*rsa.PublicKey, error) {
+	blockPub, _ := pem.Decode([]byte(pemPublic))
+	if blockPub == nil || blockPub.Type != "RSA PUBLIC KEY" {
+		return nil, fmt.Errorf("invalid public key PEM")
+	}
+	parsedPub, err := x509.ParsePKCS1PublicKey(blockPub.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return parsedPub, nil
+}
+
+func marshalRSA(rsaPriv *rsa.PrivateKey) (string, string) {
+	privBytes := x509.MarshalPKCS1PrivateKey(rsaPriv)
+	pubBytes := x509.MarshalPKCS1PublicKey(&rsaPriv.PublicKey)
+
+	privPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: privBytes,
+	})
+	pubPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: pubBytes,
+	})
+	return string(privPEM), string(pubPEM)
+}
+
+func encodePEM(blockType string, raw []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: raw}))
+}
+
+func decodePEM(blockType, encoded string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("invalid %s PEM", blockType)
+	}
+	return block.Bytes, nil
+}
+
+// x25519Key is an X25519 private key. Its Decrypt recovers a data key from
+// the ephemeral public key an envelope carries instead of a wrapped key.
+type x25519Key struct {
+	priv *ecdh.PrivateKey
+}
+
+func (k *x25519Key) Public() crypto.PublicKey {
+	return k.priv.PublicKey()
+}
+
+func (k *x25519Key) Decrypt(_ io.Reader, encapsulated []byte, _ crypto.DecrypterOpts) ([]byte, error) {
+	ephemeral, err := ecdh.X25519().NewPublicKey(encapsulated)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %w", err)
+	}
+	shared, err := k.priv.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	return deriveDataKey(shared, hkdfInfoX25519, encapsulated, k.priv.PublicKey().Bytes())
+}
+
+// x25519Encapsulate derives a fresh data key for pub. The ephemeral public key
+// it returns is all the recipient needs to derive the same key.
+func x25519Encapsulate(pub *ecdh.PublicKey) ([]byte, []byte, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	shared, err := ephemeral.ECDH(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	encapsulated := ephemeral.PublicKey().Bytes()
+	dataKey, err := deriveDataKey(shared, hkdfInfoX25519, encapsulated, pub.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, encapsulated, nil
+}
+
+// hybridPublicKey combines X25519 with ML-KEM-768: a data key sealed to it
+// stays confidential as long as either of the two holds, including against a
+// future quantum computer recording today's ciphertexts.
+type hybridPublicKey struct {
+	x25519 *ecdh.PublicKey
+	mlkem  *mlkem768.PublicKey
+}
+
+func (p *hybridPublicKey) bytes() []byte {
+	out := make([]byte, 32+mlkem768.PublicKeySize)
+	copy(out, p.x25519.Bytes())
+	p.mlkem.Pack(out[32:])
+	return out
+}
+
+func (p *hybridPublicKey) Equal(other crypto.PublicKey) bool {
+	o, ok := other.(*hybridPublicKey)
+	return ok && bytes.Equal(p.bytes(), o.bytes())
+}
+
+type hybridKey struct {
+	x25519 *ecdh.PrivateKey
+	seed   []byte
+	mlkem  *mlkem768.PrivateKey
+	pub    *hybridPublicKey
+}
+
+func newHybridKey(x25519Priv *ecdh.PrivateKey, seed []byte) *hybridKey {
+	mlkemPub, mlkemPriv := mlkem768.NewKeyFromSeed(seed)
+	return &hybridKey{
+		x25519: x25519Priv,
+		seed:   bytes.Clone(seed),
+		mlkem:  mlkemPriv,
+		pub:    &hybridPublicKey{x25519: x25519Priv.PublicKey(), mlkem: mlkemPub},
+	}
+}
+
+func (k *hybridKey) Public() crypto.PublicKey {
+	return k.pub
+}
+
+func (k *hybridKey) Decrypt(_ io.Reader, encapsulated []byte, _ crypto.DecrypterOpts) ([]byte, error) {
+	if len(encapsulated) != 32+mlkem768.CiphertextSize {
+		return nil, fmt.Errorf("invalid encapsulated key length")
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(encapsulated[:32])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %w", err)
+	}
+	classical, err := k.x25519.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	postQuantum := make([]byte, mlkem768.SharedKeySize)
+	k.mlkem.DecapsulateTo(postQuantum, encapsulated[32:])
+	return deriveDataKey(append(classical, postQuantum...), hkdfInfoX25519MLKEM768, encapsulated, k.pub.bytes())
+}
+
+// hybridEncapsulate derives a fresh data key from both an X25519 exchange and
+// an ML-KEM-768 encapsulation. It returns the key and the ephemeral X25519
+// public key followed by the ML-KEM ciphertext.
+func hybridEncapsulate(pub *hybridPublicKey) ([]byte, []byte, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	classical, err := ephemeral.ECDH(pub.x25519)
+	if err != nil {
+		return nil, nil, err
+	}
+	seed := make([]byte, mlkem768.EncapsulationSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate encapsulation seed: %w", err)
+	}
+	mlkemCiphertext := make([]byte, mlkem768.CiphertextSize)
+	postQuantum := make([]byte, mlkem768.SharedKeySize)
+	pub.mlkem.EncapsulateTo(mlkemCiphertext, postQuantum, seed)
+
+	encapsulated := append(ephemeral.PublicKey().Bytes(), mlkemCiphertext...)
+	dataKey, err := deriveDataKey(append(classical, postQuantum...), hkdfInfoX25519MLKEM768, encapsulated, pub.bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, encapsulated, nil
+}
+
+// deriveDataKey turns shared secrets into an AES-256 data key. The salt binds
+// the key to this exact encapsulation and recipient key.
+func deriveDataKey(secret, info, encapsulated, recipient []byte) ([]byte, error) {
+	salt := append(bytes.Clone(encapsulated), recipient...)
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), dataKey); err != nil {
+		return nil, fmt.Errorf("failed to derive data key: %w", err)
+	}
+	return dataKey, nil
+}
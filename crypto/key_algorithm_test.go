@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testAlgorithms = []struct {
+	algorithm string
+	suite     byte
+}{
+	{algorithm: algorithmRSA2048, suite: suiteRSAOAEPAESGCM},
+	{algorithm: algorithmX25519, suite: suiteX25519HKDFAESGCM},
+	{algorithm: algorithmX25519MLKEM768, suite: suiteX25519MLKEM768AESGCM},
+}
+
+func TestKeyAlgorithmEnvelopes(t *testing.T) {
+	for _, tc := range testAlgorithms {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			priv, err := generateKey(tc.algorithm)
+			require.NoError(t, err, "generateKey failed")
+			algorithm, err := keyAlgorithm(priv.Public())
+			require.NoError(t, err)
+			require.Equal(t, tc.algorithm, algorithm)
+
+			// 1. The envelope records the suite that sealed it
+			ciphertext, err := sealEnvelope(priv.Public(), 2, []byte("kept for decades"))
+			require.NoError(t, err, "sealEnvelope failed")
+			env, err := parseEnvelope(ciphertext)
+			require.NoError(t, err)
+			require.Equal(t, tc.suite, env.suite)
+			require.Equal(t, int32(2), env.keyVersion)
+
+			// 2. The stored PEM form opens it again
+			privPEM, pubPEM, err := marshalKey(priv)
+			require.NoError(t, err, "marshalKey failed")
+			parsedPriv, parsedPub, err := parseKeys(tc.algorithm, privPEM, pubPEM)
+			require.NoError(t, err, "parseKeys failed")
+			require.True(t, samePublicKey(priv.Public(), parsedPub), "public key must survive storage")
+			require.True(t, samePublicKey(priv.Public(), parsedPriv.Public()), "private key must survive storage")
+
+			plaintext, err := decryptCiphertext(parsedPriv, ciphertext)
+			require.NoError(t, err, "decryptCiphertext failed")
+			require.Equal(t, "kept for decades", string(plaintext))
+
+			// 3. Changing the encapsulated key breaks decryption
+			tampered := append([]byte(nil), ciphertext...)
+			tampered[len(env.header)-1] ^= 0x01
+			_, err = openEnvelope(priv, tampered)
+			require.Error(t, err, "tampered wrapped key must not decrypt")
+
+			// 4. Another key of the same algorithm cannot open it
+			other, err := generateKey(tc.algorithm)
+			require.NoError(t, err)
+			_, err = decryptCiphertext(other, ciphertext)
+			require.Error(t, err, "another key must not decrypt")
+		})
+	}
+}
+
+func TestKeyAlgorithmSuiteMismatch(t *testing.T) {
+	rsaKey, err := generateKey(algorithmRSA2048)
+	require.NoError(t, err)
+	x25519Priv, err := generateKey(algorithmX25519)
+	require.NoError(t, err)
+
+	ciphertext, err := sealEnvelope(x25519Priv.Public(), 1, []byte("secret"))
+	require.NoError(t, err)
+	_, err = decryptCiphertext(rsaKey, ciphertext)
+	require.Error(t, err, "an RSA key must not open an X25519 envelope")
+
+	privPEM, _, err := marshalKey(x25519Priv)
+	require.NoError(t, err)
+	_, err = parsePrivateKey(algorithmX25519MLKEM768, privPEM)
+	require.Error(t, err, "a key must be parsed as the algorithm it was stored with")
+	require.Error(t, validKeyAlgorithm("rsa-1024"))
+}
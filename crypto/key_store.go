@@ -2,113 +2,80 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
+	"crypto"
 	"database/sql"
-	"encoding/pem"
 	"fmt"
-	"github.com/lib/pq"
-	_ "github.com/lib/pq"
 	"sync"
 )
 
-var userKeyLocks sync.Map
+// initialKeyVersion is the version of a user's first key. Ciphertexts written
+// before key versions existed were all produced with it.
+const initialKeyVersion int32 = 1
 
-func getOrCreateUserKey(ctx context.Context, db *sql.DB, userID string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
-	mu, _ := userKeyLocks.LoadOrStore(userID, &sync.Mutex{})
-	userMu := mu.(*sync.Mutex)
-	userMu.Lock()
-	defer userMu.Unlock()
-
-	priv, pub, err := loadKey(ctx, db, userID)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, nil, fmt.Errorf("failed to query user key pair: %w", err)
-	}
-	if err == nil {
-		// Found an existing key
-		return priv, pub, nil
-	}
-
-	// Not found, so we generate a new key
-	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate RSA key: %w", err)
-	}
-	privPEM, pubPEM := marshalRSA(rsaPriv)
+// Key store backends selectable through KEY_STORE.
+const (
+	keyStoreSQL    = "sql"
+	keyStoreFile   = "file"
+	keyStorePKCS11 = "pkcs11"
+)
 
-	// Try inserting. If someone else beats us, handle unique constraint violation
-	_, insertErr := db.ExecContext(ctx, `
-        INSERT INTO user_keys (user_id, private_key, public_key)
-        VALUES ($1, $2, $3)
-    `, userID, privPEM, pubPEM)
-	if insertErr != nil {
-		// Check if it's a unique violation
-		// (This depends on your driver; the exact way to detect error code may vary.)
-		if isUniqueViolation(insertErr) {
-			// Another transaction inserted the row concurrently; just read it now
-			return loadKey(ctx, db, userID)
-		}
-		return nil, nil, fmt.Errorf("failed to insert new RSA keys: %w", insertErr)
-	}
-	return rsaPriv, &rsaPriv.PublicKey, nil
+// KeyStore keeps the versioned key pairs of users. Ciphertexts record the
+// key version they were sealed with, so every version stays available after
+// a rotation.
+type KeyStore interface {
+	// Current returns the user's current key, generating the first version
+	// if the user has none yet.
+	Current(ctx context.Context, userID string) (*userKey, error)
+	// Version returns a specific, possibly retired, key version.
+	Version(ctx context.Context, userID string, version int32) (*userKey, error)
+	// Rotate generates the next key version and makes it current. It returns
+	// the new key and the version it replaced. New versions use the store's
+	// configured algorithm, so rotating is how a user changes algorithm.
+	Rotate(ctx context.Context, userID string) (*userKey, int32, error)
 }
 
-func loadKey(ctx context.Context, db *sql.DB, userID string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
-	var pemPrivate, pemPublic string
-	err := db.QueryRowContext(ctx,
-		`SELECT private_key, public_key FROM user_keys WHERE user_id = $1`,
-		userID,
-	).Scan(&pemPrivate, &pemPublic)
-	if err != nil {
-		return nil, nil, err
-	}
-	parsedPriv, parsedPub, parseErr := parseKeys(pemPrivate, pemPublic)
-	if parseErr != nil {
-		return nil, nil, parseErr
-	}
-	return parsedPriv, parsedPub, nil
+// keyRewrapper is implemented by stores that keep private keys wrapped by a
+// KEK, so the rewrap-keys command can move them to the current KEK.
+type keyRewrapper interface {
+	Rewrap(ctx context.Context) (int, error)
 }
 
-func parseKeys(pemPrivate, pemPublic string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
-	block, _ := pem.Decode([]byte(pemPrivate))
-	if block == nil || block.Type != "RSA PRIVATE KEY" {
-		return nil, nil, fmt.Errorf("invalid private key PEM")
-	}
-	parsedPriv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse private key: %w", err)
-	}
+// userKey is one version of a user's key pair. Private is an
+// *rsa.PrivateKey, an X25519 or hybrid key from key_algorithm.go, or a handle
+// when the key never leaves an HSM.
+type userKey struct {
+	Version   int32
+	Algorithm string
+	Private   crypto.Decrypter
+	Public    crypto.PublicKey
+}
 
-	blockPub, _ := pem.Decode([]byte(pemPublic))
-	if blockPub == nil || blockPub.Type != "RSA PUBLIC KEY" {
-		return nil, nil, fmt.Errorf("invalid public key PEM")
+// openKeyStore builds the key store selected by KEY_STORE. Stores that hold
+// key material themselves protect it with the KEKs.
+func openKeyStore(cfg Config, db *sql.DB, keks kekProvider) (KeyStore, error) {
+	if err := validKeyAlgorithm(cfg.KeyAlgorithm); err != nil {
+		return nil, err
 	}
-	parsedPub, err := x509.ParsePKCS1PublicKey(blockPub.Bytes)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse public key: %w", err)
+	switch cfg.KeyStore {
+	case "", keyStoreSQL:
+		return &sqlKeyStore{db: db, keks: keks, algorithm: cfg.KeyAlgorithm}, nil
+	case keyStoreFile:
+		return newFileKeyStore(cfg.KeyStoreDir, keks, cfg.KeyAlgorithm)
+	case keyStorePKCS11:
+		if cfg.KeyAlgorithm != algorithmRSA2048 {
+			return nil, fmt.Errorf("the pkcs11 key store only supports %s keys", algorithmRSA2048)
+		}
+		return newPKCS11KeyStore(cfg.PKCS11Module, cfg.PKCS11TokenLabel, cfg.PKCS11PIN)
+	default:
+		return nil, fmt.Errorf("unknown KEY_STORE %q, want %s, %s or %s", cfg.KeyStore, keyStoreSQL, keyStoreFile, keyStorePKCS11)
 	}
-	return parsedPriv, parsedPub, nil
 }
 
-func marshalRSA(rsaPriv *rsa.PrivateKey) (string, string) {
-	privBytes := x509.MarshalPKCS1PrivateKey(rsaPriv)
-	pubBytes := x509.MarshalPKCS1PublicKey(&rsaPriv.PublicKey)
-
-	privPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: privBytes,
-	})
-	pubPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PUBLIC KEY",
-		Bytes: pubBytes,
-	})
-	return string(privPEM), string(pubPEM)
-}
+var userKeyLocks sync.Map
 
-func isUniqueViolation(err error) bool {
-	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-		return true
-	}
-	return false
+func lockUser(userID string) func() {
+	mu, _ := userKeyLocks.LoadOrStore(userID, &sync.Mutex{})
+	userMu := mu.(*sync.Mutex)
+	userMu.Lock()
+	return userMu.Unlock
 }
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileKeyStore keeps one keyring file per user under a directory. Private
+// keys are wrapped by a KEK exactly like user_keys rows, so the files are
+// useless without the KEKs. New keys are generated with algorithm.
+type fileKeyStore struct {
+	dir       string
+	keks      kekProvider
+	algorithm string
+}
+
+// userKeyringFile is the on-disk format of one user's keyring:
+//
+//	{"user_id": "...", "current": 2, "keys": [{"version": 1, "algorithm": "rsa-2048", "kek_id": "...", "private_key": "<base64>", "public_key": "<PEM>"}]}
+type userKeyringFile struct {
+	UserID  string          `json:"user_id"`
+	Current int32           `json:"current"`
+	Keys    []fileKeyRecord `json:"keys"`
+}
+
+type fileKeyRecord struct {
+	Version    int32  `json:"version"`
+	Algorithm  string `json:"algorithm"`
+	KEKID      string `json:"kek_id"`
+	PrivateKey []byte `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+func newFileKeyStore(dir string, keks kekProvider, algorithm string) (*fileKeyStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("KEY_STORE_DIR must be set for the file key store")
+	}
+	if keks == nil {
+		return nil, fmt.Errorf("no key-encryption key configured")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key store directory: %w", err)
+	}
+	return &fileKeyStore{dir: dir, keks: keks, algorithm: algorithm}, nil
+}
+
+func (s *fileKeyStore) Current(ctx context.Context, userID string) (*userKey, error) {
+	unlock := lockUser(userID)
+	defer unlock()
+
+	ring, err := s.read(userID)
+	if errors.Is(err, os.ErrNotExist) {
+		ring = &userKeyringFile{UserID: userID}
+		return s.addVersion(ring, initialKeyVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.unwrap(ring, ring.Current)
+}
+
+func (s *fileKeyStore) Version(ctx context.Context, userID string, version int32) (*userKey, error) {
+	ring, err := s.read(userID)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("key version %d not found for user", version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.unwrap(ring, version)
+}
+
+func (s *fileKeyStore) Rotate(ctx context.Context, userID string) (*userKey, int32, error) {
+	// Make sure there is a version to rotate away from
+	if _, err := s.Current(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	unlock := lockUser(userID)
+	defer unlock()
+
+	ring, err := s.read(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	previous := ring.Current
+	var latest int32
+	for _, k := range ring.Keys {
+		latest = max(latest, k.Version)
+	}
+	current, err := s.addVersion(ring, latest+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	return current, previous, nil
+}
+
+// Rewrap moves every private key that is not under the current KEK onto it.
+func (s *fileKeyStore) Rewrap(ctx context.Context) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list key store: %w", err)
+	}
+	current := s.keks.CurrentID()
+	rewrapped := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		ring, err := s.readFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return rewrapped, err
+		}
+
+		unlock := lockUser(ring.UserID)
+		changed := 0
+		for i, k := range ring.Keys {
+			if k.KEKID == current {
+				continue
+			}
+			pemPrivate, err := s.keks.Unwrap(k.KEKID, ring.UserID, k.PrivateKey)
+			if err != nil {
+				unlock()
+				return rewrapped, fmt.Errorf("failed to unwrap key for %s: %w", ring.UserID, err)
+			}
+			kekID, wrapped, err := s.keks.Wrap(ring.UserID, pemPrivate)
+			if err != nil {
+				unlock()
+				return rewrapped, fmt.Errorf("failed to wrap key for %s: %w", ring.UserID, err)
+			}
+			ring.Keys[i].KEKID, ring.Keys[i].PrivateKey = kekID, wrapped
+			changed++
+		}
+		if changed > 0 {
+			if err := s.write(ring); err != nil {
+				unlock()
+				return rewrapped, err
+			}
+			rewrapped += changed
+		}
+		unlock()
+	}
+	return rewrapped, nil
+}
+
+// addVersion generates a key, stores it as the user's current version and
+// returns it. The caller holds the user's lock.
+func (s *fileKeyStore) addVersion(ring *userKeyringFile, version int32) (*userKey, error) {
+	priv, err := generateKey(s.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	privPEM, pubPEM, err := marshalKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	kekID, wrapped, err := s.keks.Wrap(ring.UserID, []byte(privPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap private key: %w", err)
+	}
+
+	ring.Keys = append(ring.Keys, fileKeyRecord{Version: version, Algorithm: s.algorithm, KEKID: kekID, PrivateKey: wrapped, PublicKey: pubPEM})
+	ring.Current = version
+	if err := s.write(ring); err != nil {
+		return nil, err
+	}
+	return &userKey{Version: version, Algorithm: s.algorithm, Private: priv, Public: priv.Public()}, nil
+}
+
+func (s *fileKeyStore) unwrap(ring *userKeyringFile, version int32) (*userKey, error) {
+	for _, k := range ring.Keys {
+		if k.Version != version {
+			continue
+		}
+		pemPrivate, err := s.keks.Unwrap(k.KEKID, ring.UserID, k.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		priv, pub, err := parseKeys(k.Algorithm, string(pemPrivate), k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return &userKey{Version: version, Algorithm: k.Algorithm, Private: priv, Public: pub}, nil
+	}
+	return nil, fmt.Errorf("key version %d not found for user", version)
+}
+
+// path names the file after a hash of the user ID, so any ID is a safe file name.
+func (s *fileKeyStore) path(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *fileKeyStore) read(userID string) (*userKeyringFile, error) {
+	ring, err := s.readFile(s.path(userID))
+	if err != nil {
+		return nil, err
+	}
+	if ring.UserID != userID {
+		return nil, fmt.Errorf("keyring file belongs to another user")
+	}
+	return ring, nil
+}
+
+func (s *fileKeyStore) readFile(path string) (*userKeyringFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ring userKeyringFile
+	if err := json.Unmarshal(raw, &ring); err != nil {
+		return nil, fmt.Errorf("failed to parse keyring %s: %w", filepath.Base(path), err)
+	}
+	return &ring, nil
+}
+
+// write replaces the keyring atomically so a crash never leaves half a file.
+func (s *fileKeyStore) write(ring *userKeyringFile) error {
+	raw, err := json.Marshal(ring)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".keyring-*")
+	if err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(ring.UserID)); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// pkcs11KeyStore keeps user RSA keys on a PKCS#11 token. Private keys are
+// created on the token as sensitive, non-extractable objects and every RSA
+// decryption runs on the token, so key material never reaches this process.
+//
+// A key is found by its label "digi-baton:<user ID>" and its CKA_ID, the key
+// version as four big-endian bytes. The highest version is the current one.
+type pkcs11KeyStore struct {
+	ctx *pkcs11.Ctx
+
+	// mu guards the session: a PKCS#11 session runs one operation at a time
+	mu      sync.Mutex
+	session pkcs11.SessionHandle
+}
+
+const pkcs11LabelPrefix = "digi-baton:"
+
+func newPKCS11KeyStore(module, tokenLabel, pin string) (*pkcs11KeyStore, error) {
+	if module == "" || tokenLabel == "" {
+		return nil, fmt.Errorf("PKCS11_MODULE and PKCS11_TOKEN_LABEL must be set for the pkcs11 key store")
+	}
+	p := pkcs11.New(module)
+	if p == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", module)
+	}
+	if err := p.Initialize(); err != nil {
+		p.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module: %w", err)
+	}
+	s := &pkcs11KeyStore{ctx: p}
+
+	// 1. Find the token by label
+	slots, err := p.GetSlotList(true)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to list PKCS#11 slots: %w", err)
+	}
+	slot, found := uint(0), false
+	for _, id := range slots {
+		info, err := p.GetTokenInfo(id)
+		if err == nil && info.Label == tokenLabel {
+			slot, found = id, true
+			break
+		}
+	}
+	if !found {
+		s.Close()
+		return nil, fmt.Errorf("PKCS#11 token %q not found", tokenLabel)
+	}
+
+	// 2. Open a session and log in as the token user
+	s.session, err = p.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to open PKCS#11 session: %w", err)
+	}
+	if err := p.Login(s.session, pkcs11.CKU_USER, pin); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		s.Close()
+		return nil, fmt.Errorf("failed to log in to PKCS#11 token: %w", err)
+	}
+	return s, nil
+}
+
+func (s *pkcs11KeyStore) Close() error {
+	if s.session != 0 {
+		s.ctx.Logout(s.session)
+		s.ctx.CloseSession(s.session)
+	}
+	err := s.ctx.Finalize()
+	s.ctx.Destroy()
+	return err
+}
+
+func (s *pkcs11KeyStore) Current(ctx context.Context, userID string) (*userKey, error) {
+	unlock := lockUser(userID)
+	defer unlock()
+
+	latest, err := s.latestVersion(userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest == 0 {
+		return s.generate(userID, initialKeyVersion)
+	}
+	return s.load(userID, latest)
+}
+
+func (s *pkcs11KeyStore) Version(ctx context.Context, userID string, version int32) (*userKey, error) {
+	return s.load(userID, version)
+}
+
+func (s *pkcs11KeyStore) Rotate(ctx context.Context, userID string) (*userKey, int32, error) {
+	// Make sure there is a version to rotate away from
+	if _, err := s.Current(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	unlock := lockUser(userID)
+	defer unlock()
+
+	previous, err := s.latestVersion(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	current, err := s.generate(userID, previous+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	return current, previous, nil
+}
+
+func (s *pkcs11KeyStore) generate(userID string, version int32) (*userKey, error) {
+	label, id := pkcs11Label(userID), pkcs11ID(version)
+	public := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	}
+	private := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	}
+
+	s.mu.Lock()
+	pubHandle, privHandle, err := s.ctx.GenerateKeyPair(s.session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)}, public, private)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate RSA key on token: %w", err)
+	}
+	keysGenerated.WithLabelValues(algorithmRSA2048).Inc()
+	pub, err := s.publicKey(pubHandle)
+	if err != nil {
+		return nil, err
+	}
+	return &userKey{Version: version, Algorithm: algorithmRSA2048, Private: &pkcs11Key{store: s, handle: privHandle, pub: pub}, Public: pub}, nil
+}
+
+func (s *pkcs11KeyStore) load(userID string, version int32) (*userKey, error) {
+	label, id := pkcs11Label(userID), pkcs11ID(version)
+	privHandles, err := s.find(pkcs11.CKO_PRIVATE_KEY, label, id)
+	if err != nil {
+		return nil, err
+	}
+	pubHandles, err := s.find(pkcs11.CKO_PUBLIC_KEY, label, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(privHandles) == 0 || len(pubHandles) == 0 {
+		return nil, fmt.Errorf("key version %d not found for user", version)
+	}
+	pub, err := s.publicKey(pubHandles[0])
+	if err != nil {
+		return nil, err
+	}
+	return &userKey{Version: version, Algorithm: algorithmRSA2048, Private: &pkcs11Key{store: s, handle: privHandles[0], pub: pub}, Public: pub}, nil
+}
+
+// latestVersion returns the highest key version of the user, or 0 if there is none.
+func (s *pkcs11KeyStore) latestVersion(userID string) (int32, error) {
+	handles, err := s.find(pkcs11.CKO_PRIVATE_KEY, pkcs11Label(userID), nil)
+	if err != nil {
+		return 0, err
+	}
+	var latest int32
+	for _, h := range handles {
+		s.mu.Lock()
+		attrs, err := s.ctx.GetAttributeValue(s.session, h, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, nil)})
+		s.mu.Unlock()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read key ID: %w", err)
+		}
+		if len(attrs) == 1 && len(attrs[0].Value) == 4 {
+			latest = max(latest, int32(binary.BigEndian.Uint32(attrs[0].Value)))
+		}
+	}
+	return latest, nil
+}
+
+func (s *pkcs11KeyStore) find(class uint, label string, id []byte) ([]pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if id != nil {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, id))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return nil, fmt.Errorf("failed to search token: %w", err)
+	}
+	var handles []pkcs11.ObjectHandle
+	for {
+		batch, _, err := s.ctx.FindObjects(s.session, 100)
+		if err != nil {
+			s.ctx.FindObjectsFinal(s.session)
+			return nil, fmt.Errorf("failed to search token: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		handles = append(handles, batch...)
+	}
+	if err := s.ctx.FindObjectsFinal(s.session); err != nil {
+		return nil, fmt.Errorf("failed to search token: %w", err)
+	}
+	return handles, nil
+}
+
+func (s *pkcs11KeyStore) publicKey(h pkcs11.ObjectHandle) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	attrs, err := s.ctx.GetAttributeValue(s.session, h, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key from token: %w", err)
+	}
+	if len(attrs) != 2 {
+		return nil, fmt.Errorf("incomplete public key on token")
+	}
+	e := new(big.Int).SetBytes(attrs[1].Value)
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("unsupported public exponent on token")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(attrs[0].Value), E: int(e.Int64())}, nil
+}
+
+func pkcs11Label(userID string) string {
+	return pkcs11LabelPrefix + userID
+}
+
+func pkcs11ID(version int32) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(version))
+}
+
+// pkcs11Key is a private key that stays on the token. It only supports the
+// RSA-OAEP SHA-256 decryption envelopes are sealed with.
+type pkcs11Key struct {
+	store  *pkcs11KeyStore
+	handle pkcs11.ObjectHandle
+	pub    *rsa.PublicKey
+}
+
+func (k *pkcs11Key) Public() crypto.PublicKey {
+	return k.pub
+}
+
+func (k *pkcs11Key) Decrypt(_ io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	oaep, ok := opts.(*rsa.OAEPOptions)
+	if !ok || oaep.Hash != crypto.SHA256 || (oaep.MGFHash != 0 && oaep.MGFHash != crypto.SHA256) || len(oaep.Label) > 0 {
+		return nil, fmt.Errorf("token keys only support RSA-OAEP with SHA-256")
+	}
+	mechanism := pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_OAEP,
+		pkcs11.NewOAEPParams(pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256, pkcs11.CKZ_DATA_SPECIFIED, nil))
+
+	k.store.mu.Lock()
+	defer k.store.mu.Unlock()
+	if err := k.store.ctx.DecryptInit(k.store.session, []*pkcs11.Mechanism{mechanism}, k.handle); err != nil {
+		return nil, fmt.Errorf("failed to start decryption on token: %w", err)
+	}
+	plaintext, err := k.store.ctx.Decrypt(k.store.session, msg)
+	if err != nil {
+		return nil, fmt.Errorf("decryption on token failed: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// sqlKeyStore keeps user keys as PEM rows in user_keys, with the private
+// keys wrapped by a KEK. New keys are generated with algorithm.
+type sqlKeyStore struct {
+	db        *sql.DB
+	keks      kekProvider
+	algorithm string
+}
+
+func (s *sqlKeyStore) Current(ctx context.Context, userID string) (*userKey, error) {
+	return getOrCreateUserKey(ctx, s.db, s.keks, userID, s.algorithm)
+}
+
+func (s *sqlKeyStore) Version(ctx context.Context, userID string, version int32) (*userKey, error) {
+	return loadKeyVersion(ctx, s.db, s.keks, userID, version)
+}
+
+func (s *sqlKeyStore) Rotate(ctx context.Context, userID string) (*userKey, int32, error) {
+	return rotateUserKey(ctx, s.db, s.keks, userID, s.algorithm)
+}
+
+func (s *sqlKeyStore) Rewrap(ctx context.Context) (int, error) {
+	return rewrapPrivateKeys(ctx, s.db, s.keks)
+}
+
+// getOrCreateUserKey returns the user's current key, generating the first
+// version if the user has none yet.
+func getOrCreateUserKey(ctx context.Context, db *sql.DB, keks kekProvider, userID, algorithm string) (*userKey, error) {
+	if keks == nil {
+		return nil, fmt.Errorf("no key-encryption key configured")
+	}
+
+	unlock := lockUser(userID)
+	defer unlock()
+
+	key, err := loadCurrentKey(ctx, db, keks, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query user key pair: %w", err)
+	}
+	if err == nil {
+		// Found an existing key
+		return key, nil
+	}
+
+	// Not found, so we generate a new key
+	key, insertErr := insertNewKey(ctx, db, keks, userID, initialKeyVersion, algorithm)
+	if insertErr != nil {
+		// Check if it's a unique violation
+		// (This depends on your driver; the exact way to detect error code may vary.)
+		if isUniqueViolation(insertErr) {
+			// Another transaction inserted the row concurrently; just read it now
+			return loadCurrentKey(ctx, db, keks, userID)
+		}
+		return nil, insertErr
+	}
+	return key, nil
+}
+
+// rotateUserKey generates the next key version and makes it current. The
+// previous versions are kept so existing ciphertexts stay decryptable.
+func rotateUserKey(ctx context.Context, db *sql.DB, keks kekProvider, userID, algorithm string) (*userKey, int32, error) {
+	// Make sure there is a version to rotate away from
+	if _, err := getOrCreateUserKey(ctx, db, keks, userID, algorithm); err != nil {
+		return nil, 0, err
+	}
+
+	unlock := lockUser(userID)
+	defer unlock()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin rotation: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the current row so concurrent rotations cannot both retire it
+	var previous int32
+	if err := tx.QueryRowContext(ctx,
+		`SELECT version FROM user_keys WHERE user_id = $1 AND is_current FOR UPDATE`,
+		userID,
+	).Scan(&previous); err != nil {
+		return nil, 0, fmt.Errorf("failed to read current key version: %w", err)
+	}
+	var latest int32
+	if err := tx.QueryRowContext(ctx,
+		`SELECT MAX(version) FROM user_keys WHERE user_id = $1`,
+		userID,
+	).Scan(&latest); err != nil {
+		return nil, 0, fmt.Errorf("failed to read key versions: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE user_keys SET is_current = false WHERE user_id = $1 AND version = $2`,
+		userID, previous,
+	); err != nil {
+		return nil, 0, fmt.Errorf("failed to retire current key: %w", err)
+	}
+	current, err := insertNewKey(ctx, tx, keks, userID, latest+1, algorithm)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit rotation: %w", err)
+	}
+	return current, previous, nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertNewKey(ctx context.Context, db execer, keks kekProvider, userID string, version int32, algorithm string) (*userKey, error) {
+	priv, err := generateKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	privPEM, pubPEM, err := marshalKey(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never store the private key in the clear: wrap it under the current KEK
+	kekID, wrapped, err := keks.Wrap(userID, []byte(privPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap private key: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+        INSERT INTO user_keys (user_id, version, is_current, algorithm, private_key, public_key, kek_id)
+        VALUES ($1, $2, true, $3, $4, $5, $6)
+    `, userID, version, algorithm, base64.StdEncoding.EncodeToString(wrapped), pubPEM, kekID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to insert new keys: %w", err)
+	}
+	return &userKey{Version: version, Algorithm: algorithm, Private: priv, Public: priv.Public()}, nil
+}
+
+func loadCurrentKey(ctx context.Context, db *sql.DB, keks kekProvider, userID string) (*userKey, error) {
+	return scanKey(keks, userID, db.QueryRowContext(ctx,
+		`SELECT version, algorithm, private_key, public_key, kek_id FROM user_keys WHERE user_id = $1 AND is_current`,
+		userID,
+	))
+}
+
+// loadKeyVersion returns a specific, possibly retired, key version.
+func loadKeyVersion(ctx context.Context, db *sql.DB, keks kekProvider, userID string, version int32) (*userKey, error) {
+	if keks == nil {
+		return nil, fmt.Errorf("no key-encryption key configured")
+	}
+	key, err := scanKey(keks, userID, db.QueryRowContext(ctx,
+		`SELECT version, algorithm, private_key, public_key, kek_id FROM user_keys WHERE user_id = $1 AND version = $2`,
+		userID, version,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("key version %d not found for user", version)
+	}
+	return key, err
+}
+
+func scanKey(keks kekProvider, userID string, row *sql.Row) (*userKey, error) {
+	var version int32
+	var algorithm, storedPrivate, pemPublic string
+	var kekID sql.NullString
+	if err := row.Scan(&version, &algorithm, &storedPrivate, &pemPublic, &kekID); err != nil {
+		return nil, err
+	}
+	pemPrivate, err := unwrapPrivateKey(keks, userID, storedPrivate, kekID)
+	if err != nil {
+		return nil, err
+	}
+	parsedPriv, parsedPub, parseErr := parseKeys(algorithm, pemPrivate, pemPublic)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return &userKey{Version: version, Algorithm: algorithm, Private: parsedPriv, Public: parsedPub}, nil
+}
+
+// unwrapPrivateKey returns the PEM private key of a user_keys row. Rows with
+// no kek_id predate KEK protection and hold plain PEM until rewrapped.
+func unwrapPrivateKey(keks kekProvider, userID, storedPrivate string, kekID sql.NullString) (string, error) {
+	if !kekID.Valid {
+		log.Printf("user key for %s is not wrapped by a KEK; run the rewrap-keys command", userID)
+		return storedPrivate, nil
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(storedPrivate)
+	if err != nil {
+		return "", fmt.Errorf("invalid wrapped private key: %w", err)
+	}
+	pemPrivate, err := keks.Unwrap(kekID.String, userID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	return string(pemPrivate), nil
+}
+
+// rewrapPrivateKeys wraps every user_keys row that is not yet under the
+// current KEK: plaintext rows left from before KEK protection, and rows
+// wrapped by a KEK that has since been rotated out. User data stays untouched
+// because only the private keys themselves are re-encrypted.
+func rewrapPrivateKeys(ctx context.Context, db *sql.DB, keks kekProvider) (int, error) {
+	current := keks.CurrentID()
+	rows, err := db.QueryContext(ctx,
+		`SELECT user_id, version, private_key, kek_id FROM user_keys WHERE kek_id IS DISTINCT FROM $1`,
+		current,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list keys to rewrap: %w", err)
+	}
+	type staleKey struct {
+		userID  string
+		version int32
+		private string
+		kekID   sql.NullString
+	}
+	var stale []staleKey
+	for rows.Next() {
+		var k staleKey
+		if err := rows.Scan(&k.userID, &k.version, &k.private, &k.kekID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stale = append(stale, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, k := range stale {
+		pemPrivate, err := unwrapPrivateKey(keks, k.userID, k.private, k.kekID)
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to unwrap key for %s: %w", k.userID, err)
+		}
+		kekID, wrapped, err := keks.Wrap(k.userID, []byte(pemPrivate))
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to wrap key for %s: %w", k.userID, err)
+		}
+		// Only replace the row if nobody rewrapped it in the meantime
+		res, err := db.ExecContext(ctx, `
+            UPDATE user_keys SET private_key = $3, kek_id = $4
+            WHERE user_id = $1 AND version = $2 AND kek_id IS NOT DISTINCT FROM $5
+        `, k.userID, k.version, base64.StdEncoding.EncodeToString(wrapped), kekID, k.kekID)
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to store rewrapped key for %s: %w", k.userID, err)
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			rewrapped++
+		}
+	}
+	return rewrapped, nil
+}
+
+func isUniqueViolation(err error) bool {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeyStoreContract checks what the server relies on from every KeyStore.
+func testKeyStoreContract(t *testing.T, keys KeyStore) {
+	ctx := context.Background()
+	const userID = "contract-user"
+
+	// 1. The first use creates the initial version, later uses return it
+	first, err := keys.Current(ctx, userID)
+	require.NoError(t, err, "Current failed")
+	require.Equal(t, initialKeyVersion, first.Version)
+	again, err := keys.Current(ctx, userID)
+	require.NoError(t, err, "Current failed")
+	require.Equal(t, first.Version, again.Version)
+	require.True(t, samePublicKey(first.Public, again.Public), "Current must not create a new key")
+
+	sealed, err := sealEnvelope(first.Public, first.Version, []byte("under the first key"))
+	require.NoError(t, err, "sealEnvelope failed")
+	plaintext, err := openEnvelope(again.Private, sealed)
+	require.NoError(t, err, "openEnvelope failed")
+	require.Equal(t, "under the first key", string(plaintext))
+
+	// 2. Rotation adds a version and keeps the old one readable
+	rotated, previous, err := keys.Rotate(ctx, userID)
+	require.NoError(t, err, "Rotate failed")
+	require.Equal(t, initialKeyVersion, previous)
+	require.Equal(t, initialKeyVersion+1, rotated.Version)
+	require.False(t, samePublicKey(rotated.Public, first.Public), "rotation must create a new key")
+
+	current, err := keys.Current(ctx, userID)
+	require.NoError(t, err, "Current failed")
+	require.Equal(t, rotated.Version, current.Version)
+
+	old, err := keys.Version(ctx, userID, initialKeyVersion)
+	require.NoError(t, err, "Version failed")
+	plaintext, err = decryptCiphertext(old.Private, sealed)
+	require.NoError(t, err, "old ciphertext should decrypt after rotation")
+	require.Equal(t, "under the first key", string(plaintext))
+
+	// 3. Unknown versions and users are errors
+	_, err = keys.Version(ctx, userID, rotated.Version+1)
+	require.Error(t, err, "unknown version must not load")
+	_, err = keys.Version(ctx, "nobody", initialKeyVersion)
+	require.Error(t, err, "unknown user must not load")
+}
+
+func samePublicKey(a, b crypto.PublicKey) bool {
+	return a.(interface{ Equal(crypto.PublicKey) bool }).Equal(b)
+}
+
+func TestFileKeyStore(t *testing.T) {
+	for _, algorithm := range []string{algorithmRSA2048, algorithmX25519, algorithmX25519MLKEM768} {
+		t.Run(algorithm, func(t *testing.T) {
+			keys, err := newFileKeyStore(t.TempDir(), newTestKeyring(t), algorithm)
+			require.NoError(t, err, "newFileKeyStore failed")
+			testKeyStoreContract(t, keys)
+		})
+	}
+}
+
+func TestFileKeyStoreRewrap(t *testing.T) {
+	dir := t.TempDir()
+	oldKEK := randomKEK(t)
+	keks, err := newLocalKeyring("old", map[string]string{"old": oldKEK})
+	require.NoError(t, err)
+	keys, err := newFileKeyStore(dir, keks, algorithmRSA2048)
+	require.NoError(t, err, "newFileKeyStore failed")
+	testKeyStoreContract(t, keys)
+
+	// Private keys never reach the disk in the clear
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	raw, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), "PRIVATE KEY")
+
+	// Rewrapping onto a new KEK keeps the keys
+	before, err := keys.Current(context.Background(), "contract-user")
+	require.NoError(t, err)
+	rotatedKEKs, err := newLocalKeyring("new", map[string]string{"old": oldKEK, "new": randomKEK(t)})
+	require.NoError(t, err)
+	keys.keks = rotatedKEKs
+	n, err := keys.Rewrap(context.Background())
+	require.NoError(t, err, "Rewrap failed")
+	require.Equal(t, 2, n)
+	after, err := keys.Current(context.Background(), "contract-user")
+	require.NoError(t, err)
+	require.True(t, after.Private.(*rsa.PrivateKey).Equal(before.Private), "rewrapping must not change the user key")
+}
+
+func TestPKCS11KeyStore(t *testing.T) {
+	keys := newTestPKCS11KeyStore(t)
+	testKeyStoreContract(t, keys)
+
+	// The token must refuse to hand out the private key
+	key, err := keys.Current(context.Background(), "contract-user")
+	require.NoError(t, err)
+	_, ok := key.Private.(*rsa.PrivateKey)
+	require.False(t, ok, "the private key must stay on the token")
+	handle := key.Private.(*pkcs11Key).handle
+	keys.mu.Lock()
+	_, err = keys.ctx.GetAttributeValue(keys.session, handle, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_PRIVATE_EXPONENT, nil)})
+	keys.mu.Unlock()
+	require.Error(t, err, "the private exponent must not be readable")
+}
+
+// clearPKCS11Token destroys the keys earlier test runs left on the token, so
+// every run starts from the initial key version.
+func clearPKCS11Token(t *testing.T, s *pkcs11KeyStore) {
+	t.Helper()
+	for _, class := range []uint{pkcs11.CKO_PRIVATE_KEY, pkcs11.CKO_PUBLIC_KEY} {
+		s.mu.Lock()
+		require.NoError(t, s.ctx.FindObjectsInit(s.session, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}))
+		handles, _, err := s.ctx.FindObjects(s.session, 10000)
+		require.NoError(t, s.ctx.FindObjectsFinal(s.session))
+		s.mu.Unlock()
+		require.NoError(t, err)
+
+		for _, h := range handles {
+			s.mu.Lock()
+			attrs, err := s.ctx.GetAttributeValue(s.session, h, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_LABEL, nil)})
+			if err == nil && strings.HasPrefix(string(attrs[0].Value), pkcs11LabelPrefix) {
+				err = s.ctx.DestroyObject(s.session, h)
+			}
+			s.mu.Unlock()
+			require.NoError(t, err)
+		}
+	}
+}
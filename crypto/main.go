@@ -3,11 +3,15 @@ package main
 import (
 	"context"
 	"database/sql"
+	"io"
 	"log"
 	"net"
+	"os"
 
 	"github.com/a-company-jp/digi-baton/proto/crypto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
 	_ "github.com/lib/pq"
@@ -15,22 +19,35 @@ import (
 
 type Server struct {
 	crypto.UnimplementedEncryptionServiceServer
-	db *sql.DB
+	db     *sql.DB
+	keks   kekProvider
+	keys   KeyStore
+	grants *grantVerifier
+	// algorithm of newly created vault keys
+	algorithm string
 }
 
-func (s *Server) storeHistory(ctx context.Context, userID, operation string, data []byte) {
-	_, err := s.db.ExecContext(
-		ctx,
-		"INSERT INTO encryption_decryption_history (user_id, operation, data) VALUES ($1, $2, $3)",
-		userID, operation, data,
-	)
+func main() {
+	// gen-dev-certs needs neither the database nor the KEKs, so it runs
+	// before the config is loaded and validated
+	if len(os.Args) > 1 && os.Args[1] == "gen-dev-certs" {
+		dir := "certs"
+		if len(os.Args) > 2 {
+			dir = os.Args[2]
+		}
+		if err := genDevCerts(dir); err != nil {
+			log.Fatalf("command gen-dev-certs failed: %v", err)
+		}
+		return
+	}
+
+	loaded, err := loadConfig()
 	if err != nil {
-		log.Printf("failed to store history: %v", err)
+		log.Fatalf("failed to load config: %v", err)
 	}
-}
+	log.Printf("Loaded config: %+v", loaded)
+	cfg := *loaded
 
-func main() {
-	cfg := ConfigData()
 	dsn := cfg.DBConnStr()
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
@@ -40,23 +57,63 @@ func main() {
 		log.Fatalf("failed to ping db: %v", err)
 	}
 
-	// 2. Create a gRPC Server
-	grpcServer := grpc.NewServer()
-	srv := &Server{db: db}
+	keks, err := loadKEKProvider(cfg)
+	if err != nil {
+		log.Fatalf("failed to load key-encryption keys: %v", err)
+	}
+	keys, err := openKeyStore(cfg, db, keks)
+	if err != nil {
+		log.Fatalf("failed to open key store: %v", err)
+	}
+	if closer, ok := keys.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), db, keks, keys, os.Args[1:]); err != nil {
+			log.Fatalf("command %s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// 2. Create a gRPC Server that only accepts authenticated callers
+	auth, err := loadCallerAuth(cfg)
+	if err != nil {
+		log.Fatalf("failed to load caller secrets: %v", err)
+	}
+	opts, err := serverOptions(cfg, auth)
+	if err != nil {
+		log.Fatalf("failed to configure TLS: %v", err)
+	}
+	if cfg.AllowInsecure {
+		log.Println("WARNING: ALLOW_INSECURE is set; TLS or caller checks may be disabled")
+	}
+	grants, err := loadGrantVerifier(cfg)
+	if err != nil {
+		log.Fatalf("failed to load grant keys: %v", err)
+	}
+	grpcServer := grpc.NewServer(opts...)
+	srv := &Server{db: db, keks: keks, keys: keys, grants: grants, algorithm: cfg.KeyAlgorithm}
 
 	// 3. Register our encryption service
 	crypto.RegisterEncryptionServiceServer(grpcServer, srv)
 
-	// 4. Optionally enable reflection
+	// 4. Report health from database connectivity, and expose metrics
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go watchHealth(context.Background(), db, healthServer)
+	go serveMetrics(cfg.MetricsAddr, db)
+
+	// 5. Optionally enable reflection
 	reflection.Register(grpcServer)
 
-	// 5. Listen on a port
-	lis, err := net.Listen("tcp", ":50051")
+	// 6. Listen on a port
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	log.Println("Starting gRPC Server on :50051 ...")
+	log.Printf("Starting gRPC Server on %s ...", cfg.GRPCAddr)
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
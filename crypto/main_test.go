@@ -1,12 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"os"
 	"testing"
 
 	"github.com/a-company-jp/digi-baton/proto/crypto"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestEncryptDecryptDirect(t *testing.T) {
@@ -23,9 +33,7 @@ func TestEncryptDecryptDirect(t *testing.T) {
 		require.NoError(t, err, "runMigrationsDown failed")
 	}()
 
-	s := &Server{
-		db: db,
-	}
+	s := newTestServer(t, db)
 
 	userID := "test-user"
 	plaintext := []byte("Hello, RSA World!")
@@ -39,10 +47,7 @@ func TestEncryptDecryptDirect(t *testing.T) {
 	require.NotEmpty(t, encResp.GetCiphertext(), "Ciphertext should not be empty")
 
 	// 5. Decrypt directly
-	decResp, err := s.Decrypt(context.Background(), &crypto.DecryptRequest{
-		UserId:     userID,
-		Ciphertext: encResp.GetCiphertext(),
-	})
+	decResp, err := s.Decrypt(context.Background(), ownerDecryptRequest(t, userID, encResp.GetCiphertext()))
 	require.NoError(t, err, "Decrypt should succeed")
 	require.Equal(t, plaintext, decResp.GetPlaintext(), "plaintext mismatch after RSA encrypt/decrypt")
 }
@@ -59,7 +64,7 @@ func TestEncryptDecrypt_Unit(t *testing.T) {
 		require.NoError(t, err, "runMigrationsDown failed")
 	}()
 
-	s := &Server{db: db}
+	s := newTestServer(t, db)
 
 	// Table-driven approach: multiple scenarios
 	tests := []struct {
@@ -86,6 +91,12 @@ func TestEncryptDecrypt_Unit(t *testing.T) {
 			plaintext: "TestCase2",
 			repeat:    1,
 		},
+		{
+			name:      "plaintext larger than one RSA block",
+			userID:    "large-plaintext-user",
+			plaintext: string(bytes.Repeat([]byte("memo "), 4096)),
+			repeat:    1,
+		},
 		{
 			name:      "multiple encryption same user",
 			userID:    "multi-encrypt-user",
@@ -105,10 +116,7 @@ func TestEncryptDecrypt_Unit(t *testing.T) {
 				require.NoError(t, err, "Encrypt should succeed")
 				require.NotEmpty(t, encResp.GetCiphertext(), "Ciphertext must not be empty")
 
-				decResp, err := s.Decrypt(context.Background(), &crypto.DecryptRequest{
-					UserId:     tc.userID,
-					Ciphertext: encResp.GetCiphertext(),
-				})
+				decResp, err := s.Decrypt(context.Background(), ownerDecryptRequest(t, tc.userID, encResp.GetCiphertext()))
 				require.NoError(t, err, "Decrypt should succeed")
 				require.Equal(t, tc.plaintext, string(decResp.GetPlaintext()),
 					"plaintext mismatch after RSA encrypt/decrypt")
@@ -116,3 +124,533 @@ func TestEncryptDecrypt_Unit(t *testing.T) {
 		})
 	}
 }
+
+func TestDecryptLegacyRSACiphertext(t *testing.T) {
+	db, err := getDB()
+	require.NoError(t, err, "getDB failed")
+	defer db.Close()
+
+	err = runMigrationsUp(db)
+	require.NoError(t, err, "runMigrationsUp failed")
+	defer func() {
+		err := runMigrationsDown(db)
+		require.NoError(t, err, "runMigrationsDown failed")
+	}()
+
+	s := newTestServer(t, db)
+
+	userID := "legacy-user"
+	plaintext := []byte("stored before envelopes")
+
+	// Ciphertexts written before envelope encryption are raw RSA-OAEP blobs
+	key, err := s.keys.Current(context.Background(), userID)
+	require.NoError(t, err, "loading the user key failed")
+	rsaPub, ok := key.Public.(*rsa.PublicKey)
+	if !ok {
+		t.Skip("legacy ciphertexts only exist for RSA keys")
+	}
+	legacy, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, rsaPub, plaintext, nil)
+	require.NoError(t, err, "legacy encrypt failed")
+
+	decResp, err := s.Decrypt(context.Background(), ownerDecryptRequest(t, userID, legacy))
+	require.NoError(t, err, "Decrypt should accept legacy ciphertext")
+	require.Equal(t, plaintext, decResp.GetPlaintext(), "plaintext mismatch for legacy ciphertext")
+}
+
+func TestRewrapPrivateKeys(t *testing.T) {
+	db, err := getDB()
+	require.NoError(t, err, "getDB failed")
+	defer db.Close()
+
+	err = runMigrationsUp(db)
+	require.NoError(t, err, "runMigrationsUp failed")
+	defer func() {
+		err := runMigrationsDown(db)
+		require.NoError(t, err, "runMigrationsDown failed")
+	}()
+
+	ctx := context.Background()
+	oldKey, newKey := randomKEK(t), randomKEK(t)
+	oldRing, err := newLocalKeyring("old", map[string]string{"old": oldKey})
+	require.NoError(t, err)
+
+	// 1. A legacy plaintext row, as written before KEK protection
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privPEM, pubPEM := marshalRSA(rsaPriv)
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO user_keys (user_id, private_key, public_key) VALUES ($1, $2, $3)`,
+		"plaintext-user", privPEM, pubPEM)
+	require.NoError(t, err)
+
+	n, err := rewrapPrivateKeys(ctx, db, oldRing)
+	require.NoError(t, err, "wrapping plaintext rows should succeed")
+	require.Equal(t, 1, n)
+
+	var stored string
+	err = db.QueryRowContext(ctx, `SELECT private_key FROM user_keys WHERE user_id = $1`, "plaintext-user").Scan(&stored)
+	require.NoError(t, err)
+	require.NotContains(t, stored, "PRIVATE KEY", "private key must not stay in the clear")
+
+	// 2. Rotate to a new KEK while keeping the old one for unwrapping
+	newRing, err := newLocalKeyring("new", map[string]string{"old": oldKey, "new": newKey})
+	require.NoError(t, err)
+	n, err = rewrapPrivateKeys(ctx, db, newRing)
+	require.NoError(t, err, "rotating the KEK should succeed")
+	require.Equal(t, 1, n)
+
+	// 3. The old KEK is no longer needed and the user key is unchanged
+	onlyNew, err := newLocalKeyring("new", map[string]string{"new": newKey})
+	require.NoError(t, err)
+	key, err := loadCurrentKey(ctx, db, onlyNew, "plaintext-user")
+	require.NoError(t, err, "key should load with the new KEK only")
+	require.True(t, key.Private.(*rsa.PrivateKey).Equal(rsaPriv), "rewrapping must not change the user key")
+}
+
+func TestRotateUserKey(t *testing.T) {
+	db, err := getDB()
+	require.NoError(t, err, "getDB failed")
+	defer db.Close()
+
+	err = runMigrationsUp(db)
+	require.NoError(t, err, "runMigrationsUp failed")
+	defer func() {
+		err := runMigrationsDown(db)
+		require.NoError(t, err, "runMigrationsDown failed")
+	}()
+
+	ctx := context.Background()
+	s := newTestServer(t, db)
+	userID := "rotating-user"
+
+	// 1. Encrypt under the first key version
+	encResp, err := s.Encrypt(ctx, &crypto.EncryptRequest{UserId: userID, Plaintext: []byte("before rotation")})
+	require.NoError(t, err, "Encrypt failed")
+	require.Equal(t, initialKeyVersion, encResp.GetKeyVersion())
+
+	// 2. Rotate; new ciphertexts use the new version
+	rotResp, err := s.RotateUserKey(ctx, &crypto.RotateUserKeyRequest{UserId: userID})
+	require.NoError(t, err, "RotateUserKey failed")
+	require.Equal(t, initialKeyVersion, rotResp.GetPreviousKeyVersion())
+	require.Equal(t, initialKeyVersion+1, rotResp.GetKeyVersion())
+
+	newResp, err := s.Encrypt(ctx, &crypto.EncryptRequest{UserId: userID, Plaintext: []byte("after rotation")})
+	require.NoError(t, err, "Encrypt failed")
+	require.Equal(t, rotResp.GetKeyVersion(), newResp.GetKeyVersion())
+
+	// 3. The old ciphertext is still readable
+	decResp, err := s.Decrypt(ctx, ownerDecryptRequest(t, userID, encResp.GetCiphertext()))
+	require.NoError(t, err, "old ciphertext should decrypt after rotation")
+	require.Equal(t, "before rotation", string(decResp.GetPlaintext()))
+
+	// 4. Reencrypt moves it to the new version, and is a no-op afterwards
+	reResp, err := s.Reencrypt(ctx, &crypto.ReencryptRequest{UserId: userID, Ciphertext: encResp.GetCiphertext()})
+	require.NoError(t, err, "Reencrypt failed")
+	require.True(t, reResp.GetReencrypted())
+	require.Equal(t, rotResp.GetKeyVersion(), reResp.GetKeyVersion())
+
+	again, err := s.Reencrypt(ctx, &crypto.ReencryptRequest{UserId: userID, Ciphertext: reResp.GetCiphertext()})
+	require.NoError(t, err, "Reencrypt failed")
+	require.False(t, again.GetReencrypted(), "current ciphertexts should be left as is")
+	require.Equal(t, reResp.GetCiphertext(), again.GetCiphertext())
+
+	decResp, err = s.Decrypt(ctx, ownerDecryptRequest(t, userID, reResp.GetCiphertext()))
+	require.NoError(t, err, "reencrypted ciphertext should decrypt")
+	require.Equal(t, "before rotation", string(decResp.GetPlaintext()))
+}
+
+func TestBatchEncryptDecrypt(t *testing.T) {
+	db, err := getDB()
+	require.NoError(t, err, "getDB failed")
+	defer db.Close()
+
+	err = runMigrationsUp(db)
+	require.NoError(t, err, "runMigrationsUp failed")
+	defer func() {
+		err := runMigrationsDown(db)
+		require.NoError(t, err, "runMigrationsDown failed")
+	}()
+
+	ctx := context.Background()
+	s := newTestServer(t, db)
+	userID := "batch-user"
+
+	// 1. Encrypt two items under the first key version
+	encResp, err := s.BatchEncrypt(ctx, &crypto.BatchEncryptRequest{
+		UserId:     userID,
+		Plaintexts: [][]byte{[]byte("first"), []byte("second")},
+	})
+	require.NoError(t, err, "BatchEncrypt failed")
+	require.Len(t, encResp.GetResults(), 2)
+	for _, r := range encResp.GetResults() {
+		require.Empty(t, r.GetError())
+	}
+
+	// 2. One more item under a rotated key, so the batch spans versions
+	_, err = s.RotateUserKey(ctx, &crypto.RotateUserKeyRequest{UserId: userID})
+	require.NoError(t, err, "RotateUserKey failed")
+	third, err := s.Encrypt(ctx, &crypto.EncryptRequest{UserId: userID, Plaintext: []byte("third")})
+	require.NoError(t, err, "Encrypt failed")
+
+	// 3. A broken item, and one the grant does not cover, fail alone
+	ciphertexts := [][]byte{
+		encResp.GetResults()[0].GetCiphertext(),
+		[]byte("garbage"),
+		encResp.GetResults()[1].GetCiphertext(),
+		third.GetCiphertext(),
+	}
+	decResp, err := s.BatchDecrypt(ctx, &crypto.BatchDecryptRequest{
+		UserId:      userID,
+		Ciphertexts: ciphertexts,
+		ActorUserId: userID,
+		Purpose:     crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+		Grant:       signTestGrant(t, userID, crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, ciphertexts[:3]...),
+	})
+	require.NoError(t, err, "BatchDecrypt should not fail the whole batch")
+	results := decResp.GetResults()
+	require.Len(t, results, 4)
+	require.Equal(t, "first", string(results[0].GetPlaintext()))
+	require.NotEmpty(t, results[1].GetError(), "garbage must report an error")
+	require.Empty(t, results[1].GetPlaintext())
+	require.Equal(t, "second", string(results[2].GetPlaintext()))
+	require.NotEmpty(t, results[3].GetError(), "ungranted item must report an error")
+	require.Empty(t, results[3].GetPlaintext())
+}
+
+func TestReencryptForRecipient(t *testing.T) {
+	db, err := getDB()
+	require.NoError(t, err, "getDB failed")
+	defer db.Close()
+
+	err = runMigrationsUp(db)
+	require.NoError(t, err, "runMigrationsUp failed")
+	defer func() {
+		err := runMigrationsDown(db)
+		require.NoError(t, err, "runMigrationsDown failed")
+	}()
+
+	ctx := context.Background()
+	s := newTestServer(t, db)
+
+	encResp, err := s.Encrypt(ctx, &crypto.EncryptRequest{UserId: "passer", Plaintext: []byte("handed over")})
+	require.NoError(t, err, "Encrypt failed")
+
+	handOff, err := s.ReencryptForRecipient(ctx, &crypto.ReencryptForRecipientRequest{
+		OwnerUserId:     "passer",
+		RecipientUserId: "receiver",
+		Ciphertext:      encResp.GetCiphertext(),
+		Grant:           signHandoverGrant(t, crypto.DecryptPurpose_DECRYPT_PURPOSE_HANDOVER, "passer", "receiver", encResp.GetCiphertext()),
+	})
+	require.NoError(t, err, "ReencryptForRecipient failed")
+
+	// A grant for the passer's other ciphertexts does not cover this one
+	other, err := s.Encrypt(ctx, &crypto.EncryptRequest{UserId: "passer", Plaintext: []byte("kept back")})
+	require.NoError(t, err, "Encrypt failed")
+	_, err = s.ReencryptForRecipient(ctx, &crypto.ReencryptForRecipientRequest{
+		OwnerUserId:     "passer",
+		RecipientUserId: "receiver",
+		Ciphertext:      other.GetCiphertext(),
+		Grant:           signHandoverGrant(t, crypto.DecryptPurpose_DECRYPT_PURPOSE_HANDOVER, "passer", "receiver", encResp.GetCiphertext()),
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "ungranted ciphertext must not be handed over")
+
+	// The receiver reads it with their own key
+	decResp, err := s.Decrypt(ctx, &crypto.DecryptRequest{
+		UserId:      "receiver",
+		Ciphertext:  handOff.GetCiphertext(),
+		ActorUserId: "receiver",
+		Purpose:     crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED,
+		Grant:       signTestGrant(t, "receiver", crypto.DecryptPurpose_DECRYPT_PURPOSE_DISCLOSED, handOff.GetCiphertext()),
+	})
+	require.NoError(t, err, "receiver should decrypt with their own key")
+	require.Equal(t, "handed over", string(decResp.GetPlaintext()))
+
+	// and the passer's key cannot open the receiver's copy
+	_, err = s.Decrypt(ctx, ownerDecryptRequest(t, "passer", handOff.GetCiphertext()))
+	require.Error(t, err, "passer key must not open the receiver copy")
+
+	// nor can the passer ask for the receiver's key
+	_, err = s.Decrypt(ctx, &crypto.DecryptRequest{
+		UserId:      "receiver",
+		Ciphertext:  handOff.GetCiphertext(),
+		ActorUserId: "passer",
+		Purpose:     crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER,
+		Grant:       signTestGrant(t, "receiver", crypto.DecryptPurpose_DECRYPT_PURPOSE_OWNER, handOff.GetCiphertext()),
+	})
+	require.Error(t, err, "only the key owner may decrypt")
+}
+
+func TestThresholdVault(t *testing.T) {
+	db, err := getDB()
+	require.NoError(t, err, "getDB failed")
+	defer db.Close()
+
+	err = runMigrationsUp(db)
+	require.NoError(t, err, "runMigrationsUp failed")
+	defer func() {
+		err := runMigrationsDown(db)
+		require.NoError(t, err, "runMigrationsDown failed")
+	}()
+
+	ctx := context.Background()
+	s := newTestServer(t, db)
+
+	_, err = s.ConfigureThresholdVault(ctx, &crypto.ConfigureThresholdVaultRequest{
+		PasserUserId:    "passer",
+		Threshold:       3,
+		ReceiverUserIds: []string{"heir-a", "heir-b"},
+	})
+	require.Error(t, err, "threshold above the number of receivers must fail")
+
+	cfgResp, err := s.ConfigureThresholdVault(ctx, &crypto.ConfigureThresholdVaultRequest{
+		PasserUserId:    "passer",
+		Threshold:       2,
+		ReceiverUserIds: []string{"heir-a", "heir-b", "heir-c"},
+	})
+	require.NoError(t, err, "ConfigureThresholdVault failed")
+	require.Equal(t, int32(1), cfgResp.GetVaultVersion())
+
+	encResp, err := s.Encrypt(ctx, &crypto.EncryptRequest{UserId: "passer", Plaintext: []byte("needs two heirs")})
+	require.NoError(t, err, "Encrypt failed")
+	kept, err := s.Encrypt(ctx, &crypto.EncryptRequest{UserId: "passer", Plaintext: []byte("not granted")})
+	require.NoError(t, err, "Encrypt failed")
+	sealResp, err := s.SealToVault(ctx, &crypto.SealToVaultRequest{
+		PasserUserId: "passer",
+		Ciphertexts:  [][]byte{encResp.GetCiphertext(), kept.GetCiphertext()},
+		Grant:        signHandoverGrant(t, crypto.DecryptPurpose_DECRYPT_PURPOSE_SEAL_TO_VAULT, "passer", "passer", encResp.GetCiphertext()),
+	})
+	require.NoError(t, err, "SealToVault failed")
+	require.Empty(t, sealResp.GetResults()[0].GetError())
+	require.NotEmpty(t, sealResp.GetResults()[1].GetError(), "ungranted item must not be sealed")
+	sealed := sealResp.GetResults()[0].GetCiphertext()
+
+	// Locked: nothing can be opened yet
+	openResp, err := s.OpenVaultForRecipient(ctx, &crypto.OpenVaultForRecipientRequest{
+		PasserUserId:    "passer",
+		RecipientUserId: "heir-a",
+		Ciphertexts:     [][]byte{sealed},
+		Grant:           signHandoverGrant(t, crypto.DecryptPurpose_DECRYPT_PURPOSE_OPEN_VAULT, "passer", "heir-a", sealed),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, openResp.GetResults()[0].GetError(), "locked vault must not open")
+
+	// One heir plus a stranger is below the threshold
+	_, err = s.UnlockVault(ctx, &crypto.UnlockVaultRequest{
+		PasserUserId:     "passer",
+		RequesterUserIds: []string{"heir-a", "stranger", "heir-a"},
+		Grant:            signUnlockGrant(t, "passer", "heir-a", "stranger"),
+	})
+	require.ErrorIs(t, err, errVaultQuorum)
+
+	unlockResp, err := s.UnlockVault(ctx, &crypto.UnlockVaultRequest{
+		PasserUserId:     "passer",
+		RequesterUserIds: []string{"heir-c", "heir-a"},
+		Grant:            signUnlockGrant(t, "passer", "heir-a", "heir-c"),
+	})
+	require.NoError(t, err, "two heirs should unlock the vault")
+	require.Equal(t, int32(2), unlockResp.GetSharesUsed())
+
+	// A grant for heir-a cannot be used to open the vault for heir-b
+	_, err = s.OpenVaultForRecipient(ctx, &crypto.OpenVaultForRecipientRequest{
+		PasserUserId:    "passer",
+		RecipientUserId: "heir-b",
+		Ciphertexts:     [][]byte{sealed},
+		Grant:           signHandoverGrant(t, crypto.DecryptPurpose_DECRYPT_PURPOSE_OPEN_VAULT, "passer", "heir-a", sealed),
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	openResp, err = s.OpenVaultForRecipient(ctx, &crypto.OpenVaultForRecipientRequest{
+		PasserUserId:    "passer",
+		RecipientUserId: "heir-b",
+		Ciphertexts:     [][]byte{sealed, encResp.GetCiphertext()},
+		Grant:           signHandoverGrant(t, crypto.DecryptPurpose_DECRYPT_PURPOSE_OPEN_VAULT, "passer", "heir-b", sealed),
+	})
+	require.NoError(t, err, "OpenVaultForRecipient failed")
+	require.Empty(t, openResp.GetResults()[0].GetError())
+	require.NotEmpty(t, openResp.GetResults()[1].GetError(), "ungranted item must not be opened")
+
+	decResp, err := s.Decrypt(ctx, ownerDecryptRequest(t, "heir-b", openResp.GetResults()[0].GetCiphertext()))
+	require.NoError(t, err, "heir should decrypt with their own key")
+	require.Equal(t, "needs two heirs", string(decResp.GetPlaintext()))
+}
+
+func TestAuditEvents(t *testing.T) {
+	db, err := getDB()
+	require.NoError(t, err, "getDB failed")
+	defer db.Close()
+
+	err = runMigrationsUp(db)
+	require.NoError(t, err, "runMigrationsUp failed")
+	defer func() {
+		err := runMigrationsDown(db)
+		require.NoError(t, err, "runMigrationsDown failed")
+	}()
+
+	s := newTestServer(t, db)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		callerMetadataKey, "backend",
+		actorMetadataKey, "audited",
+		requestIDMetadataKey, "req-1",
+	))
+
+	encResp, err := s.Encrypt(ctx, &crypto.EncryptRequest{UserId: "audited", Plaintext: []byte("secret")})
+	require.NoError(t, err, "Encrypt failed")
+	for i := 0; i < 2; i++ {
+		_, err = s.Decrypt(ctx, ownerDecryptRequest(t, "audited", encResp.GetCiphertext()))
+		require.NoError(t, err, "Decrypt failed")
+	}
+
+	// Newest first, filtered, and paged
+	listResp, err := s.ListAuditEvents(ctx, &crypto.ListAuditEventsRequest{UserId: "audited", PageSize: 1, Operations: []string{"DECRYPT"}})
+	require.NoError(t, err, "ListAuditEvents failed")
+	require.Len(t, listResp.GetEvents(), 1)
+	event := listResp.GetEvents()[0]
+	require.Equal(t, "DECRYPT", event.GetOperation())
+	require.Equal(t, "backend", event.GetCaller())
+	require.Equal(t, "audited", event.GetActorId())
+	require.Equal(t, "req-1", event.GetRequestId())
+	require.Equal(t, int32(1), event.GetKeyVersion())
+	require.NotZero(t, listResp.GetNextBeforeId())
+
+	nextResp, err := s.ListAuditEvents(ctx, &crypto.ListAuditEventsRequest{UserId: "audited", BeforeId: listResp.GetNextBeforeId()})
+	require.NoError(t, err)
+	require.Len(t, nextResp.GetEvents(), 2, "one more DECRYPT and the ENCRYPT")
+	require.Equal(t, "ENCRYPT", nextResp.GetEvents()[1].GetOperation())
+
+	// A batch records one event per item, chained in the same transaction
+	_, err = s.BatchEncrypt(ctx, &crypto.BatchEncryptRequest{UserId: "audited", Plaintexts: [][]byte{[]byte("a"), []byte("b"), []byte("c")}})
+	require.NoError(t, err, "BatchEncrypt failed")
+	batchResp, err := s.ListAuditEvents(ctx, &crypto.ListAuditEventsRequest{UserId: "audited", Operations: []string{"ENCRYPT"}})
+	require.NoError(t, err)
+	require.Len(t, batchResp.GetEvents(), 4, "three batch items and the first ENCRYPT")
+
+	n, err := verifyAuditChain(ctx, db)
+	require.NoError(t, err, "untouched chain must verify")
+	require.Equal(t, 6, n)
+
+	// Rows cannot be changed in place
+	_, err = db.Exec(`UPDATE audit_events SET operation = 'ENCRYPT' WHERE id = $1`, event.GetId())
+	require.Error(t, err, "audit events must be append-only")
+}
+
+func TestRecoveryWrap(t *testing.T) {
+	db, err := getDB()
+	require.NoError(t, err, "getDB failed")
+	defer db.Close()
+
+	err = runMigrationsUp(db)
+	require.NoError(t, err, "runMigrationsUp failed")
+	defer func() {
+		err := runMigrationsDown(db)
+		require.NoError(t, err, "runMigrationsDown failed")
+	}()
+
+	s := newTestServer(t, db)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(actorMetadataKey, "zk-user"))
+
+	// 1. Nothing escrowed yet
+	_, err = s.GetRecoveryWrap(ctx, &crypto.GetRecoveryWrapRequest{UserId: "zk-user"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// 2. Every escrow gets the next version and the latest one is returned as is
+	for i, wrap := range []string{"first wrap", "second wrap"} {
+		escrowResp, err := s.EscrowRecoveryWrap(ctx, &crypto.EscrowRecoveryWrapRequest{UserId: "zk-user", Wrap: []byte(wrap), Format: "test/v1"})
+		require.NoError(t, err, "EscrowRecoveryWrap failed")
+		require.Equal(t, int32(i+1), escrowResp.GetVersion())
+	}
+	getResp, err := s.GetRecoveryWrap(ctx, &crypto.GetRecoveryWrapRequest{UserId: "zk-user"})
+	require.NoError(t, err, "GetRecoveryWrap failed")
+	require.Equal(t, "second wrap", string(getResp.GetWrap()))
+	require.Equal(t, "test/v1", getResp.GetFormat())
+	require.Equal(t, int32(2), getResp.GetVersion())
+
+	// 3. Nobody else may read or replace it
+	_, err = s.GetRecoveryWrap(ctx, &crypto.GetRecoveryWrapRequest{UserId: "someone-else"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = s.EscrowRecoveryWrap(ctx, &crypto.EscrowRecoveryWrapRequest{UserId: "someone-else", Wrap: []byte("x")})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	listResp, err := s.ListAuditEvents(ctx, &crypto.ListAuditEventsRequest{UserId: "zk-user"})
+	require.NoError(t, err)
+	require.Len(t, listResp.GetEvents(), 3, "two escrows and one read")
+}
+
+func newTestServer(t *testing.T, db *sql.DB) *Server {
+	keks := newTestKeyring(t)
+	algorithm := testKeyAlgorithm(t)
+	return &Server{db: db, keks: keks, keys: newTestKeyStore(t, db, keks, algorithm), grants: newTestGrantVerifier(), algorithm: algorithm}
+}
+
+// testKeyAlgorithm picks the algorithm of new keys from TEST_KEY_ALGORITHM,
+// so the suites here can run with every algorithm:
+//
+//	TEST_KEY_ALGORITHM=x25519-mlkem768 go test ./...
+func testKeyAlgorithm(t *testing.T) string {
+	t.Helper()
+	algorithm := os.Getenv("TEST_KEY_ALGORITHM")
+	if algorithm == "" {
+		return algorithmRSA2048
+	}
+	require.NoError(t, validKeyAlgorithm(algorithm))
+	return algorithm
+}
+
+// newTestKeyStore picks the key store under test from TEST_KEY_STORE, so the
+// suites here can run against every backend:
+//
+//	TEST_KEY_STORE=file go test ./...
+//	TEST_KEY_STORE=pkcs11 PKCS11_MODULE=/usr/lib/softhsm/libsofthsm2.so PKCS11_TOKEN_LABEL=digi-baton PKCS11_PIN=1234 go test ./...
+func newTestKeyStore(t *testing.T, db *sql.DB, keks kekProvider, algorithm string) KeyStore {
+	t.Helper()
+	switch store := os.Getenv("TEST_KEY_STORE"); store {
+	case "", keyStoreSQL:
+		return &sqlKeyStore{db: db, keks: keks, algorithm: algorithm}
+	case keyStoreFile:
+		s, err := newFileKeyStore(t.TempDir(), keks, algorithm)
+		require.NoError(t, err, "newFileKeyStore failed")
+		return s
+	case keyStorePKCS11:
+		if algorithm != algorithmRSA2048 {
+			t.Skipf("the pkcs11 key store has no %s keys", algorithm)
+		}
+		return newTestPKCS11KeyStore(t)
+	default:
+		t.Fatalf("unknown TEST_KEY_STORE %q", store)
+		return nil
+	}
+}
+
+// newTestPKCS11KeyStore opens the token named by the PKCS11_* variables, e.g.
+// a SoftHSM token created with
+//
+//	softhsm2-util --init-token --free --label digi-baton --pin 1234 --so-pin 1234
+func newTestPKCS11KeyStore(t *testing.T) *pkcs11KeyStore {
+	t.Helper()
+	module := os.Getenv("PKCS11_MODULE")
+	if module == "" {
+		t.Skip("PKCS11_MODULE is not set")
+	}
+	s, err := newPKCS11KeyStore(module, os.Getenv("PKCS11_TOKEN_LABEL"), os.Getenv("PKCS11_PIN"))
+	require.NoError(t, err, "newPKCS11KeyStore failed")
+	clearPKCS11Token(t, s)
+	t.Cleanup(func() {
+		clearPKCS11Token(t, s)
+		s.Close()
+	})
+	return s
+}
+
+func newTestKeyring(t *testing.T) kekProvider {
+	t.Helper()
+	k, err := newLocalKeyring("test", map[string]string{"test": randomKEK(t)})
+	require.NoError(t, err, "newLocalKeyring failed")
+	return k
+}
+
+func randomKEK(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, dataKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
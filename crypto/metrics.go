@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthCheckInterval is how often the database is pinged to update the gRPC
+// health status.
+const healthCheckInterval = 5 * time.Second
+
+// healthServiceName is the service the backend checks before serving.
+const healthServiceName = "crypto.EncryptionService"
+
+var (
+	rpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crypto_rpc_requests_total",
+		Help: "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "crypto_rpc_duration_seconds",
+		Help:    "Latency of gRPC calls, by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	keysGenerated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crypto_keys_generated_total",
+		Help: "User and vault key pairs generated, by algorithm.",
+	}, []string{"algorithm"})
+	auditWriteFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "crypto_audit_write_failures_total",
+		Help: "Audit events that could not be written; the operation failed with them.",
+	})
+)
+
+// metricsUnaryInterceptor records count and latency of every call. It runs
+// before authentication, so rejected callers show up as well.
+func metricsUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeRPC(info.FullMethod, start, err)
+	return resp, err
+}
+
+func metricsStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeRPC(info.FullMethod, start, err)
+	return err
+}
+
+func observeRPC(method string, start time.Time, err error) {
+	rpcRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	rpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// watchHealth keeps the gRPC health status in line with database
+// connectivity until ctx is done. Without the database no operation can be
+// audited, so the service is not serving.
+func watchHealth(ctx context.Context, db *sql.DB, hs *health.Server) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		st := healthpb.HealthCheckResponse_SERVING
+		if err := pingDB(ctx, db); err != nil {
+			log.Printf("database ping failed: %v", err)
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		hs.SetServingStatus("", st)
+		hs.SetServingStatus(healthServiceName, st)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func pingDB(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return db.PingContext(ctx)
+}
+
+// metricsHandler serves Prometheus metrics along with liveness (/healthz)
+// and readiness (/readyz) probes for orchestrators that do not speak gRPC.
+func metricsHandler(db *sql.DB) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := pingDB(r.Context(), db); err != nil {
+			http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	return mux
+}
+
+// serveMetrics runs the HTTP listener for metricsHandler. An empty address
+// disables it.
+func serveMetrics(addr string, db *sql.DB) {
+	if addr == "" {
+		return
+	}
+	srv := &http.Server{Addr: addr, Handler: metricsHandler(db), ReadHeaderTimeout: 5 * time.Second}
+	log.Printf("Serving metrics on %s ...", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("failed to serve metrics: %v", err)
+	}
+}
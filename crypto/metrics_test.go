@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// unreachableDB is a handle whose pings always fail.
+func unreachableDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("postgres", "postgres://u:p@127.0.0.1:1/n?sslmode=disable&connect_timeout=1")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMetricsUnaryInterceptor(t *testing.T) {
+	const method = "/crypto.EncryptionService/TestMetrics"
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	_, err := metricsUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	_, err = metricsUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.PermissionDenied, "denied")
+	})
+	require.Error(t, err)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(rpcRequests.WithLabelValues(method, codes.OK.String())))
+	require.Equal(t, 1.0, testutil.ToFloat64(rpcRequests.WithLabelValues(method, codes.PermissionDenied.String())))
+}
+
+func TestKeyGenerationMetric(t *testing.T) {
+	before := testutil.ToFloat64(keysGenerated.WithLabelValues(algorithmX25519))
+	_, err := generateKey(algorithmX25519)
+	require.NoError(t, err)
+	require.Equal(t, before+1, testutil.ToFloat64(keysGenerated.WithLabelValues(algorithmX25519)))
+}
+
+func TestHealthFollowsDatabase(t *testing.T) {
+	db := unreachableDB(t)
+
+	// 1. Without the database the service is not serving
+	hs := health.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchHealth(ctx, db, hs)
+	require.Eventually(t, func() bool {
+		resp, err := hs.Check(context.Background(), &healthpb.HealthCheckRequest{Service: healthServiceName})
+		return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_NOT_SERVING
+	}, 5*time.Second, 50*time.Millisecond, "health must report the database outage")
+
+	// 2. The HTTP probes agree: alive, but not ready
+	handler := metricsHandler(db)
+	for path, want := range map[string]int{
+		"/healthz": http.StatusOK,
+		"/readyz":  http.StatusServiceUnavailable,
+		"/metrics": http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, want, rec.Code, path)
+	}
+}
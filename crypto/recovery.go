@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// maxRecoveryWrapSize bounds what a client may escrow. A wrapped vault key is
+// well under a kilobyte.
+const maxRecoveryWrapSize = 16 << 10
+
+// EscrowRecoveryWrap stores the recovery wrap of a zero-knowledge vault. The
+// client sealed it before it reached the backend, so it is kept as is and
+// never opened here.
+func (s *Server) EscrowRecoveryWrap(ctx context.Context, req *crypto.EscrowRecoveryWrapRequest) (*crypto.EscrowRecoveryWrapResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	if len(req.GetWrap()) == 0 || len(req.GetWrap()) > maxRecoveryWrapSize {
+		return nil, status.Errorf(codes.InvalidArgument, "recovery wrap must be between 1 and %d bytes", maxRecoveryWrapSize)
+	}
+	if err := checkActor(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	// 1. Append the next version; earlier wraps stay so a bad upload can be undone
+	var version int32
+	if err := s.db.QueryRowContext(ctx, `
+        INSERT INTO recovery_wraps (user_id, version, format, wrap)
+        SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3
+        FROM recovery_wraps
+        WHERE user_id = $1
+        RETURNING version
+    `, req.GetUserId(), req.GetFormat(), req.GetWrap()).Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to store recovery wrap: %w", err)
+	}
+
+	// 2. Record the event
+	if err := s.audit(ctx, req.GetUserId(), "ESCROW_RECOVERY_WRAP", 0); err != nil {
+		return nil, err
+	}
+
+	return &crypto.EscrowRecoveryWrapResponse{Version: version}, nil
+}
+
+// GetRecoveryWrap returns the latest recovery wrap of the user. Only the user
+// may ask for it.
+func (s *Server) GetRecoveryWrap(ctx context.Context, req *crypto.GetRecoveryWrapRequest) (*crypto.GetRecoveryWrapResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, fmt.Errorf("user ID is required")
+	}
+	if err := checkActor(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	resp := &crypto.GetRecoveryWrapResponse{}
+	err := s.db.QueryRowContext(ctx, `
+        SELECT wrap, format, version
+        FROM recovery_wraps
+        WHERE user_id = $1
+        ORDER BY version DESC
+        LIMIT 1
+    `, req.GetUserId()).Scan(&resp.Wrap, &resp.Format, &resp.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "no recovery wrap escrowed")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recovery wrap: %w", err)
+	}
+
+	if err := s.audit(ctx, req.GetUserId(), "GET_RECOVERY_WRAP", 0); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// checkActor rejects calls made on behalf of an end user other than userID.
+func checkActor(ctx context.Context, userID string) error {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if actor := firstMetadata(md, actorMetadataKey); actor != "" && actor != userID {
+			return status.Error(codes.PermissionDenied, "actor does not match the user")
+		}
+	}
+	return nil
+}
@@ -2,59 +2,164 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/sha256"
 	"fmt"
 
 	"github.com/a-company-jp/digi-baton/proto/crypto"
 )
 
 func (s *Server) Encrypt(ctx context.Context, req *crypto.EncryptRequest) (*crypto.EncryptResponse, error) {
-	// 1. Look up or create RSA key pair
-	priv, pub, err := getOrCreateUserKey(ctx, s.db, req.GetUserId())
+	// 1. Look up or create the user's current RSA key pair
+	key, err := s.keys.Current(ctx, req.GetUserId())
 	if err != nil {
 		return nil, err
 	}
-	_ = priv // might not be used if we only need the pub for encryption
 
-	// 2. RSA encryption using OAEP with SHA256
-	ciphertext, err := rsa.EncryptOAEP(
-		sha256.New(),
-		rand.Reader,
-		pub, // use the public key to encrypt
-		req.GetPlaintext(),
-		nil, // optional label
-	)
+	// 2. Encrypt the payload with a fresh AES-256-GCM data key wrapped by the RSA key
+	ciphertext, err := sealEnvelope(key.Public, key.Version, req.GetPlaintext())
 	if err != nil {
-		return nil, fmt.Errorf("rsa encrypt failed: %v", err)
+		return nil, fmt.Errorf("envelope encrypt failed: %v", err)
 	}
 
-	// 3. Log event
-	s.storeHistory(ctx, req.GetUserId(), "ENCRYPT", ciphertext)
+	// 3. Record the event
+	if err := s.audit(ctx, req.GetUserId(), "ENCRYPT", key.Version); err != nil {
+		return nil, err
+	}
 
-	return &crypto.EncryptResponse{Ciphertext: ciphertext}, nil
+	return &crypto.EncryptResponse{Ciphertext: ciphertext, KeyVersion: key.Version}, nil
 }
 
-// Decrypt uses the private key
+// Decrypt uses the private key of the version the ciphertext was sealed with
 func (s *Server) Decrypt(ctx context.Context, req *crypto.DecryptRequest) (*crypto.DecryptResponse, error) {
-	priv, _, err := getOrCreateUserKey(ctx, s.db, req.GetUserId())
+	// 1. Only for the key owner, with a grant covering this ciphertext
+	covers, err := s.grants.authorize(ctx, req.GetUserId(), req.GetActorUserId(), req.GetPurpose(), req.GetGrant())
 	if err != nil {
 		return nil, err
 	}
+	if !covers(req.GetCiphertext()) {
+		return nil, errNotGranted
+	}
 
-	plaintext, err := rsa.DecryptOAEP(
-		sha256.New(),
-		rand.Reader,
-		priv,                // private key
-		req.GetCiphertext(), // data we want to decrypt
-		nil,                 // optional label
-	)
+	// 2. Open with the key version the ciphertext was sealed with
+	key, err := s.keyForCiphertext(ctx, req.GetUserId(), req.GetCiphertext())
 	if err != nil {
-		return nil, fmt.Errorf("rsa decrypt failed: %v", err)
+		return nil, err
 	}
 
-	s.storeHistory(ctx, req.GetUserId(), "DECRYPT", req.GetCiphertext())
+	plaintext, err := decryptCiphertext(key.Private, req.GetCiphertext())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit(ctx, req.GetUserId(), "DECRYPT", key.Version); err != nil {
+		return nil, err
+	}
 
 	return &crypto.DecryptResponse{Plaintext: plaintext}, nil
 }
+
+// RotateUserKey generates a new key version for the user. Ciphertexts under
+// older versions remain decryptable until they are reencrypted.
+func (s *Server) RotateUserKey(ctx context.Context, req *crypto.RotateUserKeyRequest) (*crypto.RotateUserKeyResponse, error) {
+	current, previous, err := s.keys.Rotate(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit(ctx, req.GetUserId(), "ROTATE_KEY", current.Version); err != nil {
+		return nil, err
+	}
+
+	return &crypto.RotateUserKeyResponse{
+		KeyVersion:         current.Version,
+		PreviousKeyVersion: previous,
+	}, nil
+}
+
+// Reencrypt opens a ciphertext with the key version it was sealed with and
+// seals it again under the user's current version.
+func (s *Server) Reencrypt(ctx context.Context, req *crypto.ReencryptRequest) (*crypto.ReencryptResponse, error) {
+	current, err := s.keys.Current(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Envelopes already under the current version are left untouched
+	if env, err := parseEnvelope(req.GetCiphertext()); err == nil && env.keyVersion == current.Version {
+		return &crypto.ReencryptResponse{Ciphertext: req.GetCiphertext(), KeyVersion: current.Version}, nil
+	}
+
+	old, err := s.keyForCiphertext(ctx, req.GetUserId(), req.GetCiphertext())
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decryptCiphertext(old.Private, req.GetCiphertext())
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := sealEnvelope(current.Public, current.Version, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("envelope encrypt failed: %v", err)
+	}
+
+	if err := s.audit(ctx, req.GetUserId(), "REENCRYPT", current.Version); err != nil {
+		return nil, err
+	}
+
+	return &crypto.ReencryptResponse{Ciphertext: ciphertext, KeyVersion: current.Version, Reencrypted: true}, nil
+}
+
+// ReencryptForRecipient hands a secret over to another user: the owner's
+// ciphertext is opened and sealed again under the recipient's current key.
+func (s *Server) ReencryptForRecipient(ctx context.Context, req *crypto.ReencryptForRecipientRequest) (*crypto.ReencryptForRecipientResponse, error) {
+	// 1. Only with a grant for this owner, recipient and ciphertext
+	covers, err := s.grants.authorizeHandover(crypto.DecryptPurpose_DECRYPT_PURPOSE_HANDOVER, req.GetOwnerUserId(), req.GetRecipientUserId(), req.GetGrant())
+	if err != nil {
+		return nil, err
+	}
+	if !covers(req.GetCiphertext()) {
+		return nil, errNotGranted
+	}
+
+	// 2. Open with the owner's key version the ciphertext was sealed with
+	ownerKey, err := s.keyForCiphertext(ctx, req.GetOwnerUserId(), req.GetCiphertext())
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decryptCiphertext(ownerKey.Private, req.GetCiphertext())
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Seal to the recipient, creating their key pair on first use
+	recipientKey, err := s.keys.Current(ctx, req.GetRecipientUserId())
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := sealEnvelope(recipientKey.Public, recipientKey.Version, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("envelope encrypt failed: %v", err)
+	}
+
+	// 4. Record the hand-off on both sides
+	if err := s.audit(ctx, req.GetOwnerUserId(), "REENCRYPT_FOR_RECIPIENT", ownerKey.Version); err != nil {
+		return nil, err
+	}
+	if err := s.audit(ctx, req.GetRecipientUserId(), "RECEIVE_FROM_OWNER", recipientKey.Version); err != nil {
+		return nil, err
+	}
+
+	return &crypto.ReencryptForRecipientResponse{Ciphertext: ciphertext, KeyVersion: recipientKey.Version}, nil
+}
+
+// keyForCiphertext returns the user key version a ciphertext was sealed with.
+func (s *Server) keyForCiphertext(ctx context.Context, userID string, ciphertext []byte) (*userKey, error) {
+	current, err := s.keys.Current(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	version := ciphertextKeyVersion(ciphertext)
+	if version == current.Version {
+		return current, nil
+	}
+	return s.keys.Version(ctx, userID, version)
+}
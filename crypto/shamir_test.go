@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShamirSplitCombine(t *testing.T) {
+	secret := make([]byte, dataKeySize)
+	_, err := rand.Read(secret)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		n         int
+		threshold int
+		use       []int // indexes of the shares handed to combineShares
+		recovers  bool
+	}{
+		{name: "1 of 1", n: 1, threshold: 1, use: []int{0}, recovers: true},
+		{name: "2 of 3, first two", n: 3, threshold: 2, use: []int{0, 1}, recovers: true},
+		{name: "2 of 3, last two", n: 3, threshold: 2, use: []int{2, 1}, recovers: true},
+		{name: "2 of 3, all three", n: 3, threshold: 2, use: []int{0, 1, 2}, recovers: true},
+		{name: "3 of 5, below threshold", n: 5, threshold: 3, use: []int{0, 4}, recovers: false},
+		{name: "3 of 5, exactly threshold", n: 5, threshold: 3, use: []int{1, 3, 4}, recovers: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			shares, err := splitSecret(secret, tc.n, tc.threshold)
+			require.NoError(t, err)
+			require.Len(t, shares, tc.n)
+
+			var picked [][]byte
+			for _, i := range tc.use {
+				picked = append(picked, shares[i])
+			}
+			got, err := combineShares(picked)
+			require.NoError(t, err)
+			if tc.recovers {
+				require.Equal(t, secret, got)
+			} else {
+				require.NotEqual(t, secret, got)
+			}
+		})
+	}
+}
+
+func TestShamirRejectsBadInput(t *testing.T) {
+	_, err := splitSecret([]byte("secret"), 3, 4)
+	require.Error(t, err, "threshold above n must fail")
+	_, err = splitSecret([]byte("secret"), 3, 0)
+	require.Error(t, err, "zero threshold must fail")
+
+	shares, err := splitSecret([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+	_, err = combineShares([][]byte{shares[0], shares[0]})
+	require.Error(t, err, "duplicate shares must fail")
+}
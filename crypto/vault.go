@@ -0,0 +1,526 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/a-company-jp/digi-baton/proto/crypto"
+	"github.com/lib/pq"
+)
+
+// A threshold vault lets a passer require that several receivers act together
+// before their secrets are handed over. Secrets are sealed to the vault's own
+// key pair; its private key is sealed under a random vault key which is never
+// stored, only its Shamir shares, each sealed to one receiver's user key.
+
+var errVaultQuorum = errors.New("not enough shares to unlock vault")
+
+type vault struct {
+	PasserID   string
+	Version    int32
+	Threshold  int32
+	ShareCount int32
+	Algorithm  string
+	// Public is a public key as returned by parsePublicKey
+	Public any
+
+	sealedPrivate   []byte
+	unlockedPrivate sql.NullString
+	unlockKEKID     sql.NullString
+}
+
+func (v *vault) unlocked() bool {
+	return v.unlockedPrivate.Valid
+}
+
+// lockVault serialises vault changes of one passer. It uses its own lock key
+// so it can be held while the receivers' user keys are created.
+func lockVault(passerID string) func() {
+	return lockUser("vault:" + passerID)
+}
+
+// vaultAAD binds a sealed or wrapped vault private key to its vault row.
+func vaultAAD(passerID string, version int32) string {
+	return fmt.Sprintf("vault:%s:%d", passerID, version)
+}
+
+func (s *Server) ConfigureThresholdVault(ctx context.Context, req *crypto.ConfigureThresholdVaultRequest) (*crypto.ConfigureThresholdVaultResponse, error) {
+	v, err := s.createVault(ctx, req.GetPasserUserId(), req.GetThreshold(), req.GetReceiverUserIds())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit(ctx, req.GetPasserUserId(), "CONFIGURE_VAULT", v.Version); err != nil {
+		return nil, err
+	}
+
+	return &crypto.ConfigureThresholdVaultResponse{VaultVersion: v.Version}, nil
+}
+
+func (s *Server) SealToVault(ctx context.Context, req *crypto.SealToVaultRequest) (*crypto.SealToVaultResponse, error) {
+	if len(req.GetCiphertexts()) > maxBatchItems {
+		return nil, fmt.Errorf("batch too large: %d items, max %d", len(req.GetCiphertexts()), maxBatchItems)
+	}
+
+	// 1. The vault belongs to the passer, so the grant names them as the recipient
+	covers, err := s.grants.authorizeHandover(crypto.DecryptPurpose_DECRYPT_PURPOSE_SEAL_TO_VAULT, req.GetPasserUserId(), req.GetPasserUserId(), req.GetGrant())
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. The current vault receives the sealed copies
+	v, err := loadCurrentVault(ctx, s.db, req.GetPasserUserId())
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no threshold vault configured for passer")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vault: %w", err)
+	}
+
+	keyFor, err := s.keyLoader(ctx, req.GetPasserUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Open each granted passer ciphertext and seal it to the vault key
+	results := make([]*crypto.EncryptResult, len(req.GetCiphertexts()))
+	var events []*auditEvent
+	for i, ciphertext := range req.GetCiphertexts() {
+		if !covers(ciphertext) {
+			results[i] = &crypto.EncryptResult{Error: errNotGranted.Error()}
+			continue
+		}
+		key, err := keyFor(ciphertext)
+		if err != nil {
+			results[i] = &crypto.EncryptResult{Error: err.Error()}
+			continue
+		}
+		plaintext, err := decryptCiphertext(key.Private, ciphertext)
+		if err != nil {
+			results[i] = &crypto.EncryptResult{Error: err.Error()}
+			continue
+		}
+		sealed, err := sealEnvelope(v.Public, v.Version, plaintext)
+		if err != nil {
+			results[i] = &crypto.EncryptResult{Error: fmt.Sprintf("envelope encrypt failed: %v", err)}
+			continue
+		}
+		results[i] = &crypto.EncryptResult{Ciphertext: sealed}
+		events = append(events, newAuditEvent(ctx, req.GetPasserUserId(), "SEAL_TO_VAULT", v.Version))
+	}
+
+	// 4. Record the batch at once; without a record no item is returned
+	failUnaudited(results, s.auditAll(ctx, events...))
+
+	return &crypto.SealToVaultResponse{Results: results, VaultVersion: v.Version}, nil
+}
+
+func (s *Server) UnlockVault(ctx context.Context, req *crypto.UnlockVaultRequest) (*crypto.UnlockVaultResponse, error) {
+	// Only the requesters the backend counted towards the quorum may contribute shares
+	if err := s.grants.authorizeUnlock(req.GetPasserUserId(), req.GetRequesterUserIds(), req.GetGrant()); err != nil {
+		return nil, err
+	}
+
+	v, used, err := s.unlockVault(ctx, req.GetPasserUserId(), req.GetRequesterUserIds())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(used) > 0 {
+		events := []*auditEvent{newAuditEvent(ctx, req.GetPasserUserId(), "UNLOCK_VAULT", v.Version)}
+		for _, receiverID := range used {
+			events = append(events, newAuditEvent(ctx, receiverID, "CONTRIBUTE_SHARE", v.Version))
+		}
+		if err := s.auditAll(ctx, events...); err != nil {
+			return nil, err
+		}
+	}
+
+	return &crypto.UnlockVaultResponse{VaultVersion: v.Version, SharesUsed: int32(len(used))}, nil
+}
+
+func (s *Server) OpenVaultForRecipient(ctx context.Context, req *crypto.OpenVaultForRecipientRequest) (*crypto.OpenVaultForRecipientResponse, error) {
+	if len(req.GetCiphertexts()) > maxBatchItems {
+		return nil, fmt.Errorf("batch too large: %d items, max %d", len(req.GetCiphertexts()), maxBatchItems)
+	}
+
+	// Only with a grant for this passer and recipient; items it does not cover fail on their own
+	covers, err := s.grants.authorizeHandover(crypto.DecryptPurpose_DECRYPT_PURPOSE_OPEN_VAULT, req.GetPasserUserId(), req.GetRecipientUserId(), req.GetGrant())
+	if err != nil {
+		return nil, err
+	}
+
+	recipientKey, err := s.keys.Current(ctx, req.GetRecipientUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	// Each vault version is unwrapped at most once per call
+	vaultKeys := map[int32]*userKey{}
+	results := make([]*crypto.EncryptResult, len(req.GetCiphertexts()))
+	var events []*auditEvent
+	for i, ciphertext := range req.GetCiphertexts() {
+		if !covers(ciphertext) {
+			results[i] = &crypto.EncryptResult{Error: errNotGranted.Error()}
+			continue
+		}
+		env, err := parseEnvelope(ciphertext)
+		if err != nil {
+			results[i] = &crypto.EncryptResult{Error: err.Error()}
+			continue
+		}
+		vaultKey, ok := vaultKeys[env.keyVersion]
+		if !ok {
+			vaultKey, err = loadUnlockedVaultKey(ctx, s.db, s.keks, req.GetPasserUserId(), env.keyVersion)
+			if err != nil {
+				results[i] = &crypto.EncryptResult{Error: err.Error()}
+				continue
+			}
+			vaultKeys[env.keyVersion] = vaultKey
+		}
+
+		plaintext, err := openEnvelope(vaultKey.Private, ciphertext)
+		if err != nil {
+			results[i] = &crypto.EncryptResult{Error: err.Error()}
+			continue
+		}
+		sealed, err := sealEnvelope(recipientKey.Public, recipientKey.Version, plaintext)
+		if err != nil {
+			results[i] = &crypto.EncryptResult{Error: fmt.Sprintf("envelope encrypt failed: %v", err)}
+			continue
+		}
+		results[i] = &crypto.EncryptResult{Ciphertext: sealed}
+		events = append(events,
+			newAuditEvent(ctx, req.GetPasserUserId(), "OPEN_VAULT", env.keyVersion),
+			newAuditEvent(ctx, req.GetRecipientUserId(), "RECEIVE_FROM_VAULT", recipientKey.Version),
+		)
+	}
+
+	// Record the batch at once; without a record no item is returned
+	failUnaudited(results, s.auditAll(ctx, events...))
+
+	return &crypto.OpenVaultForRecipientResponse{Results: results, KeyVersion: recipientKey.Version}, nil
+}
+
+// createVault makes a new vault version current for the passer. Secrets
+// sealed to earlier versions have to be sealed again by the caller.
+func (s *Server) createVault(ctx context.Context, passerID string, threshold int32, receiverIDs []string) (*vault, error) {
+	if s.keks == nil {
+		return nil, fmt.Errorf("no key-encryption key configured")
+	}
+	if passerID == "" {
+		return nil, fmt.Errorf("passer user ID is required")
+	}
+	seen := make(map[string]bool, len(receiverIDs))
+	for _, id := range receiverIDs {
+		if id == "" || id == passerID || seen[id] {
+			return nil, fmt.Errorf("receivers must be distinct users other than the passer")
+		}
+		seen[id] = true
+	}
+	if threshold < 1 || int(threshold) > len(receiverIDs) {
+		return nil, fmt.Errorf("threshold must be between 1 and %d, got %d", len(receiverIDs), threshold)
+	}
+
+	unlock := lockVault(passerID)
+	defer unlock()
+
+	// 1. Generate the vault key pair and the vault key that seals its private half
+	priv, err := generateKey(s.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	privPEM, pubPEM, err := marshalKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	vaultKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(vaultKey); err != nil {
+		return nil, fmt.Errorf("failed to generate vault key: %w", err)
+	}
+
+	// 2. Split the vault key and seal each share to its receiver
+	shares, err := splitSecret(vaultKey, len(receiverIDs), int(threshold))
+	if err != nil {
+		return nil, err
+	}
+	sealedShares := make([][]byte, len(shares))
+	for i, receiverID := range receiverIDs {
+		receiverKey, err := s.keys.Current(ctx, receiverID)
+		if err != nil {
+			return nil, err
+		}
+		sealedShares[i], err = sealEnvelope(receiverKey.Public, receiverKey.Version, shares[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to seal share: %w", err)
+		}
+	}
+
+	// 3. Seal the private key under the vault key, bound to the new version
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin vault creation: %w", err)
+	}
+	defer tx.Rollback()
+
+	var latest int32
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM vaults WHERE passer_id = $1`,
+		passerID,
+	).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to read vault versions: %w", err)
+	}
+	v := &vault{
+		PasserID:   passerID,
+		Version:    latest + 1,
+		Threshold:  threshold,
+		ShareCount: int32(len(receiverIDs)),
+		Algorithm:  s.algorithm,
+		Public:     priv.Public(),
+	}
+
+	gcm, err := newGCM(vaultKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	v.sealedPrivate = gcm.Seal(nonce, nonce, []byte(privPEM), []byte(vaultAAD(passerID, v.Version)))
+
+	// 4. Retire the previous version and store the new one
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE vaults SET is_current = false WHERE passer_id = $1 AND is_current`,
+		passerID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to retire current vault: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO vaults (passer_id, version, threshold, share_count, is_current, algorithm, public_key, sealed_private_key)
+        VALUES ($1, $2, $3, $4, true, $5, $6, $7)
+    `, passerID, v.Version, v.Threshold, v.ShareCount, v.Algorithm, pubPEM, v.sealedPrivate); err != nil {
+		return nil, fmt.Errorf("failed to insert vault: %w", err)
+	}
+	for i, receiverID := range receiverIDs {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO vault_shares (passer_id, version, receiver_id, share)
+            VALUES ($1, $2, $3, $4)
+        `, passerID, v.Version, receiverID, sealedShares[i]); err != nil {
+			return nil, fmt.Errorf("failed to insert vault share: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit vault: %w", err)
+	}
+	return v, nil
+}
+
+// unlockVault combines the shares of the given requesters and keeps the vault
+// private key wrapped under the KEK from then on. It returns the receivers
+// whose shares were used; none when the vault was already unlocked.
+func (s *Server) unlockVault(ctx context.Context, passerID string, requesterIDs []string) (*vault, []string, error) {
+	unlock := lockVault(passerID)
+	defer unlock()
+
+	v, err := loadCurrentVault(ctx, s.db, passerID)
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("no threshold vault configured for passer")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load vault: %w", err)
+	}
+	if v.unlocked() {
+		return v, nil, nil
+	}
+
+	// 1. Collect the shares held by the requesters; unknown IDs simply match nothing
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT receiver_id, share FROM vault_shares
+        WHERE passer_id = $1 AND version = $2 AND receiver_id = ANY($3)
+        ORDER BY receiver_id
+    `, passerID, v.Version, pq.Array(requesterIDs))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load vault shares: %w", err)
+	}
+	var holders []string
+	var sealedShares [][]byte
+	for rows.Next() {
+		var holder string
+		var share []byte
+		if err := rows.Scan(&holder, &share); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		holders = append(holders, holder)
+		sealedShares = append(sealedShares, share)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(holders) < int(v.Threshold) {
+		return nil, nil, fmt.Errorf("%w: %d of %d required", errVaultQuorum, len(holders), v.Threshold)
+	}
+	holders, sealedShares = holders[:v.Threshold], sealedShares[:v.Threshold]
+
+	// 2. Open each share with its holder's key and combine them
+	shares := make([][]byte, len(sealedShares))
+	for i, sealed := range sealedShares {
+		key, err := s.keyForCiphertext(ctx, holders[i], sealed)
+		if err != nil {
+			return nil, nil, err
+		}
+		shares[i], err = decryptCiphertext(key.Private, sealed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open share of %s: %w", holders[i], err)
+		}
+	}
+	vaultKey, err := combineShares(shares)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 3. The vault key is only right if it opens the sealed private key
+	gcm, err := newGCM(vaultKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(v.sealedPrivate) < gcm.NonceSize() {
+		return nil, nil, fmt.Errorf("sealed vault key truncated")
+	}
+	nonce, ct := v.sealedPrivate[:gcm.NonceSize()], v.sealedPrivate[gcm.NonceSize():]
+	privPEM, err := gcm.Open(nil, nonce, ct, []byte(vaultAAD(passerID, v.Version)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("shares did not reconstruct the vault key: %w", err)
+	}
+
+	// 4. Keep the private key under the KEK like any user key
+	kekID, wrapped, err := s.keks.Wrap(vaultAAD(passerID, v.Version), privPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap vault private key: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+        UPDATE vaults SET unlocked_private_key = $3, unlock_kek_id = $4, unlocked_at = NOW()
+        WHERE passer_id = $1 AND version = $2
+    `, passerID, v.Version, base64.StdEncoding.EncodeToString(wrapped), kekID); err != nil {
+		return nil, nil, fmt.Errorf("failed to store unlocked vault: %w", err)
+	}
+	return v, holders, nil
+}
+
+func loadCurrentVault(ctx context.Context, db *sql.DB, passerID string) (*vault, error) {
+	return scanVault(passerID, db.QueryRowContext(ctx, `
+        SELECT version, threshold, share_count, algorithm, public_key, sealed_private_key, unlocked_private_key, unlock_kek_id
+        FROM vaults WHERE passer_id = $1 AND is_current
+    `, passerID))
+}
+
+// loadUnlockedVaultKey returns the key pair of an unlocked vault version.
+func loadUnlockedVaultKey(ctx context.Context, db *sql.DB, keks kekProvider, passerID string, version int32) (*userKey, error) {
+	v, err := scanVault(passerID, db.QueryRowContext(ctx, `
+        SELECT version, threshold, share_count, algorithm, public_key, sealed_private_key, unlocked_private_key, unlock_kek_id
+        FROM vaults WHERE passer_id = $1 AND version = $2
+    `, passerID, version))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("vault version %d not found for passer", version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !v.unlocked() {
+		return nil, fmt.Errorf("vault version %d is still locked", version)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(v.unlockedPrivate.String)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped vault key: %w", err)
+	}
+	pemPrivate, err := keks.Unwrap(v.unlockKEKID.String, vaultAAD(passerID, version), wrapped)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := parsePrivateKey(v.Algorithm, string(pemPrivate))
+	if err != nil {
+		return nil, err
+	}
+	return &userKey{Version: version, Algorithm: v.Algorithm, Private: priv, Public: v.Public}, nil
+}
+
+func scanVault(passerID string, row *sql.Row) (*vault, error) {
+	v := &vault{PasserID: passerID}
+	var pemPublic string
+	if err := row.Scan(&v.Version, &v.Threshold, &v.ShareCount, &v.Algorithm, &pemPublic, &v.sealedPrivate, &v.unlockedPrivate, &v.unlockKEKID); err != nil {
+		return nil, err
+	}
+	pub, err := parsePublicKey(v.Algorithm, pemPublic)
+	if err != nil {
+		return nil, err
+	}
+	v.Public = pub
+	return v, nil
+}
+
+// rewrapVaultKeys moves unlocked vault keys off retired KEKs, the same way
+// rewrapPrivateKeys does for user keys.
+func rewrapVaultKeys(ctx context.Context, db *sql.DB, keks kekProvider) (int, error) {
+	current := keks.CurrentID()
+	rows, err := db.QueryContext(ctx, `
+        SELECT passer_id, version, unlocked_private_key, unlock_kek_id FROM vaults
+        WHERE unlocked_private_key IS NOT NULL AND unlock_kek_id <> $1
+    `, current)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list vault keys to rewrap: %w", err)
+	}
+	type staleVault struct {
+		passerID string
+		version  int32
+		wrapped  string
+		kekID    string
+	}
+	var stale []staleVault
+	for rows.Next() {
+		var v staleVault
+		if err := rows.Scan(&v.passerID, &v.version, &v.wrapped, &v.kekID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stale = append(stale, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, v := range stale {
+		aad := vaultAAD(v.passerID, v.version)
+		wrapped, err := base64.StdEncoding.DecodeString(v.wrapped)
+		if err != nil {
+			return rewrapped, fmt.Errorf("invalid wrapped vault key for %s: %w", v.passerID, err)
+		}
+		pemPrivate, err := keks.Unwrap(v.kekID, aad, wrapped)
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to unwrap vault key for %s: %w", v.passerID, err)
+		}
+		kekID, rewrappedKey, err := keks.Wrap(aad, pemPrivate)
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to wrap vault key for %s: %w", v.passerID, err)
+		}
+		res, err := db.ExecContext(ctx, `
+            UPDATE vaults SET unlocked_private_key = $3, unlock_kek_id = $4
+            WHERE passer_id = $1 AND version = $2 AND unlock_kek_id = $5
+        `, v.passerID, v.version, base64.StdEncoding.EncodeToString(rewrappedKey), kekID, v.kekID)
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to store rewrapped vault key for %s: %w", v.passerID, err)
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			rewrapped++
+		}
+	}
+	return rewrapped, nil
+}
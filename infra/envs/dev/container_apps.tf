@@ -134,13 +134,25 @@ resource "azurerm_container_app" "backend" {
         secret_name = null
         value       = "backend-dev"
       }
+      # リクエストは RLS が効くよう所有者でないロールで処理する。
+      # ロールは CREATE ROLE digi_baton_backend LOGIN PASSWORD '...' IN ROLE digi_baton_app; で作る
       env {
         name        = "DB_USER"
         secret_name = null
-        value       = "system"
+        value       = "digi_baton_backend"
       }
       env {
         name        = "DB_PASSWORD"
+        secret_name = "pg-app-password"
+        value       = null
+      }
+      env {
+        name        = "DB_SYSTEM_USER"
+        secret_name = null
+        value       = "system"
+      }
+      env {
+        name        = "DB_SYSTEM_PASSWORD"
         secret_name = "pg-password"
         value       = null
       }
@@ -166,6 +178,11 @@ resource "azurerm_container_app" "backend" {
     name                = "pg-password"
     key_vault_secret_id = data.azurerm_key_vault_secret.pg_password.id
   }
+  secret {
+    identity            = data.azurerm_user_assigned_identity.main.id
+    name                = "pg-app-password"
+    key_vault_secret_id = data.azurerm_key_vault_secret.pg_app_password.id
+  }
   lifecycle {
     ignore_changes = [
       secret, template[0].container[0].env, registry
@@ -25,6 +25,11 @@ data "azurerm_key_vault_secret" "pg_password" {
   key_vault_id = azurerm_key_vault.main.id
 }
 
+data "azurerm_key_vault_secret" "pg_app_password" {
+  name         = "pg-app-password"
+  key_vault_id = azurerm_key_vault.main.id
+}
+
 data "azurerm_key_vault_secret" "clerk_secret_key" {
   name         = "clerk-secret-key"
   key_vault_id = azurerm_key_vault.main.id
@@ -19,8 +19,20 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	EncryptionService_Encrypt_FullMethodName = "/crypto.EncryptionService/Encrypt"
-	EncryptionService_Decrypt_FullMethodName = "/crypto.EncryptionService/Decrypt"
+	EncryptionService_Encrypt_FullMethodName                 = "/crypto.EncryptionService/Encrypt"
+	EncryptionService_Decrypt_FullMethodName                 = "/crypto.EncryptionService/Decrypt"
+	EncryptionService_RotateUserKey_FullMethodName           = "/crypto.EncryptionService/RotateUserKey"
+	EncryptionService_Reencrypt_FullMethodName               = "/crypto.EncryptionService/Reencrypt"
+	EncryptionService_BatchEncrypt_FullMethodName            = "/crypto.EncryptionService/BatchEncrypt"
+	EncryptionService_BatchDecrypt_FullMethodName            = "/crypto.EncryptionService/BatchDecrypt"
+	EncryptionService_ReencryptForRecipient_FullMethodName   = "/crypto.EncryptionService/ReencryptForRecipient"
+	EncryptionService_ConfigureThresholdVault_FullMethodName = "/crypto.EncryptionService/ConfigureThresholdVault"
+	EncryptionService_SealToVault_FullMethodName             = "/crypto.EncryptionService/SealToVault"
+	EncryptionService_UnlockVault_FullMethodName             = "/crypto.EncryptionService/UnlockVault"
+	EncryptionService_OpenVaultForRecipient_FullMethodName   = "/crypto.EncryptionService/OpenVaultForRecipient"
+	EncryptionService_ListAuditEvents_FullMethodName         = "/crypto.EncryptionService/ListAuditEvents"
+	EncryptionService_EscrowRecoveryWrap_FullMethodName      = "/crypto.EncryptionService/EscrowRecoveryWrap"
+	EncryptionService_GetRecoveryWrap_FullMethodName         = "/crypto.EncryptionService/GetRecoveryWrap"
 )
 
 // EncryptionServiceClient is the client API for EncryptionService service.
@@ -28,7 +40,46 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type EncryptionServiceClient interface {
 	Encrypt(ctx context.Context, in *EncryptRequest, opts ...grpc.CallOption) (*EncryptResponse, error)
+	// Decrypt and BatchDecrypt only open ciphertexts covered by a decrypt grant
+	// the backend signed for the acting user and purpose.
 	Decrypt(ctx context.Context, in *DecryptRequest, opts ...grpc.CallOption) (*DecryptResponse, error)
+	// RotateUserKey makes a new key version current. Older versions stay
+	// available for decryption.
+	RotateUserKey(ctx context.Context, in *RotateUserKeyRequest, opts ...grpc.CallOption) (*RotateUserKeyResponse, error)
+	// Reencrypt moves a ciphertext onto the user's current key version without
+	// the plaintext leaving the crypto service.
+	Reencrypt(ctx context.Context, in *ReencryptRequest, opts ...grpc.CallOption) (*ReencryptResponse, error)
+	// BatchEncrypt and BatchDecrypt load the user's key once for many items.
+	// A failing item is reported in its own result and does not fail the call.
+	BatchEncrypt(ctx context.Context, in *BatchEncryptRequest, opts ...grpc.CallOption) (*BatchEncryptResponse, error)
+	BatchDecrypt(ctx context.Context, in *BatchDecryptRequest, opts ...grpc.CallOption) (*BatchDecryptResponse, error)
+	// ReencryptForRecipient opens a ciphertext of the owner and seals it to the
+	// recipient's current key, so the recipient can read it with their own key.
+	// Like SealToVault and OpenVaultForRecipient it needs a grant naming the
+	// owner, the recipient and every ciphertext.
+	ReencryptForRecipient(ctx context.Context, in *ReencryptForRecipientRequest, opts ...grpc.CallOption) (*ReencryptForRecipientResponse, error)
+	// ConfigureThresholdVault creates a new vault key for the passer and splits
+	// it into one Shamir share per receiver, sealed to that receiver's key. Any
+	// threshold of the receivers together can unlock the vault.
+	ConfigureThresholdVault(ctx context.Context, in *ConfigureThresholdVaultRequest, opts ...grpc.CallOption) (*ConfigureThresholdVaultResponse, error)
+	// SealToVault opens ciphertexts of the passer and seals them to the current
+	// vault, so they can only be handed over once the vault is unlocked.
+	SealToVault(ctx context.Context, in *SealToVaultRequest, opts ...grpc.CallOption) (*SealToVaultResponse, error)
+	// UnlockVault combines the shares held by the given requesters. It fails
+	// unless at least threshold distinct share holders are among them.
+	UnlockVault(ctx context.Context, in *UnlockVaultRequest, opts ...grpc.CallOption) (*UnlockVaultResponse, error)
+	// OpenVaultForRecipient seals vault ciphertexts to a recipient's key. The
+	// vault version they were sealed with must be unlocked.
+	OpenVaultForRecipient(ctx context.Context, in *OpenVaultForRecipientRequest, opts ...grpc.CallOption) (*OpenVaultForRecipientResponse, error)
+	// ListAuditEvents returns a user's audit events, newest first. Every event
+	// carries its chain hash so the log can be checked for tampering.
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+	// EscrowRecoveryWrap keeps a user's recovery wrap for zero-knowledge vaults.
+	// The wrap is sealed by the client and stored as is; the crypto service
+	// cannot open it.
+	EscrowRecoveryWrap(ctx context.Context, in *EscrowRecoveryWrapRequest, opts ...grpc.CallOption) (*EscrowRecoveryWrapResponse, error)
+	// GetRecoveryWrap returns the user's current recovery wrap.
+	GetRecoveryWrap(ctx context.Context, in *GetRecoveryWrapRequest, opts ...grpc.CallOption) (*GetRecoveryWrapResponse, error)
 }
 
 type encryptionServiceClient struct {
@@ -57,12 +108,159 @@ func (c *encryptionServiceClient) Decrypt(ctx context.Context, in *DecryptReques
 	return out, nil
 }
 
+func (c *encryptionServiceClient) RotateUserKey(ctx context.Context, in *RotateUserKeyRequest, opts ...grpc.CallOption) (*RotateUserKeyResponse, error) {
+	out := new(RotateUserKeyResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_RotateUserKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) Reencrypt(ctx context.Context, in *ReencryptRequest, opts ...grpc.CallOption) (*ReencryptResponse, error) {
+	out := new(ReencryptResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_Reencrypt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) BatchEncrypt(ctx context.Context, in *BatchEncryptRequest, opts ...grpc.CallOption) (*BatchEncryptResponse, error) {
+	out := new(BatchEncryptResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_BatchEncrypt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) BatchDecrypt(ctx context.Context, in *BatchDecryptRequest, opts ...grpc.CallOption) (*BatchDecryptResponse, error) {
+	out := new(BatchDecryptResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_BatchDecrypt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) ReencryptForRecipient(ctx context.Context, in *ReencryptForRecipientRequest, opts ...grpc.CallOption) (*ReencryptForRecipientResponse, error) {
+	out := new(ReencryptForRecipientResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_ReencryptForRecipient_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) ConfigureThresholdVault(ctx context.Context, in *ConfigureThresholdVaultRequest, opts ...grpc.CallOption) (*ConfigureThresholdVaultResponse, error) {
+	out := new(ConfigureThresholdVaultResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_ConfigureThresholdVault_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) SealToVault(ctx context.Context, in *SealToVaultRequest, opts ...grpc.CallOption) (*SealToVaultResponse, error) {
+	out := new(SealToVaultResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_SealToVault_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) UnlockVault(ctx context.Context, in *UnlockVaultRequest, opts ...grpc.CallOption) (*UnlockVaultResponse, error) {
+	out := new(UnlockVaultResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_UnlockVault_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) OpenVaultForRecipient(ctx context.Context, in *OpenVaultForRecipientRequest, opts ...grpc.CallOption) (*OpenVaultForRecipientResponse, error) {
+	out := new(OpenVaultForRecipientResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_OpenVaultForRecipient_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_ListAuditEvents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) EscrowRecoveryWrap(ctx context.Context, in *EscrowRecoveryWrapRequest, opts ...grpc.CallOption) (*EscrowRecoveryWrapResponse, error) {
+	out := new(EscrowRecoveryWrapResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_EscrowRecoveryWrap_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *encryptionServiceClient) GetRecoveryWrap(ctx context.Context, in *GetRecoveryWrapRequest, opts ...grpc.CallOption) (*GetRecoveryWrapResponse, error) {
+	out := new(GetRecoveryWrapResponse)
+	err := c.cc.Invoke(ctx, EncryptionService_GetRecoveryWrap_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EncryptionServiceServer is the server API for EncryptionService service.
 // All implementations must embed UnimplementedEncryptionServiceServer
 // for forward compatibility
 type EncryptionServiceServer interface {
 	Encrypt(context.Context, *EncryptRequest) (*EncryptResponse, error)
+	// Decrypt and BatchDecrypt only open ciphertexts covered by a decrypt grant
+	// the backend signed for the acting user and purpose.
 	Decrypt(context.Context, *DecryptRequest) (*DecryptResponse, error)
+	// RotateUserKey makes a new key version current. Older versions stay
+	// available for decryption.
+	RotateUserKey(context.Context, *RotateUserKeyRequest) (*RotateUserKeyResponse, error)
+	// Reencrypt moves a ciphertext onto the user's current key version without
+	// the plaintext leaving the crypto service.
+	Reencrypt(context.Context, *ReencryptRequest) (*ReencryptResponse, error)
+	// BatchEncrypt and BatchDecrypt load the user's key once for many items.
+	// A failing item is reported in its own result and does not fail the call.
+	BatchEncrypt(context.Context, *BatchEncryptRequest) (*BatchEncryptResponse, error)
+	BatchDecrypt(context.Context, *BatchDecryptRequest) (*BatchDecryptResponse, error)
+	// ReencryptForRecipient opens a ciphertext of the owner and seals it to the
+	// recipient's current key, so the recipient can read it with their own key.
+	// Like SealToVault and OpenVaultForRecipient it needs a grant naming the
+	// owner, the recipient and every ciphertext.
+	ReencryptForRecipient(context.Context, *ReencryptForRecipientRequest) (*ReencryptForRecipientResponse, error)
+	// ConfigureThresholdVault creates a new vault key for the passer and splits
+	// it into one Shamir share per receiver, sealed to that receiver's key. Any
+	// threshold of the receivers together can unlock the vault.
+	ConfigureThresholdVault(context.Context, *ConfigureThresholdVaultRequest) (*ConfigureThresholdVaultResponse, error)
+	// SealToVault opens ciphertexts of the passer and seals them to the current
+	// vault, so they can only be handed over once the vault is unlocked.
+	SealToVault(context.Context, *SealToVaultRequest) (*SealToVaultResponse, error)
+	// UnlockVault combines the shares held by the given requesters. It fails
+	// unless at least threshold distinct share holders are among them.
+	UnlockVault(context.Context, *UnlockVaultRequest) (*UnlockVaultResponse, error)
+	// OpenVaultForRecipient seals vault ciphertexts to a recipient's key. The
+	// vault version they were sealed with must be unlocked.
+	OpenVaultForRecipient(context.Context, *OpenVaultForRecipientRequest) (*OpenVaultForRecipientResponse, error)
+	// ListAuditEvents returns a user's audit events, newest first. Every event
+	// carries its chain hash so the log can be checked for tampering.
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	// EscrowRecoveryWrap keeps a user's recovery wrap for zero-knowledge vaults.
+	// The wrap is sealed by the client and stored as is; the crypto service
+	// cannot open it.
+	EscrowRecoveryWrap(context.Context, *EscrowRecoveryWrapRequest) (*EscrowRecoveryWrapResponse, error)
+	// GetRecoveryWrap returns the user's current recovery wrap.
+	GetRecoveryWrap(context.Context, *GetRecoveryWrapRequest) (*GetRecoveryWrapResponse, error)
 	mustEmbedUnimplementedEncryptionServiceServer()
 }
 
@@ -76,6 +274,42 @@ func (UnimplementedEncryptionServiceServer) Encrypt(context.Context, *EncryptReq
 func (UnimplementedEncryptionServiceServer) Decrypt(context.Context, *DecryptRequest) (*DecryptResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Decrypt not implemented")
 }
+func (UnimplementedEncryptionServiceServer) RotateUserKey(context.Context, *RotateUserKeyRequest) (*RotateUserKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateUserKey not implemented")
+}
+func (UnimplementedEncryptionServiceServer) Reencrypt(context.Context, *ReencryptRequest) (*ReencryptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reencrypt not implemented")
+}
+func (UnimplementedEncryptionServiceServer) BatchEncrypt(context.Context, *BatchEncryptRequest) (*BatchEncryptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchEncrypt not implemented")
+}
+func (UnimplementedEncryptionServiceServer) BatchDecrypt(context.Context, *BatchDecryptRequest) (*BatchDecryptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDecrypt not implemented")
+}
+func (UnimplementedEncryptionServiceServer) ReencryptForRecipient(context.Context, *ReencryptForRecipientRequest) (*ReencryptForRecipientResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReencryptForRecipient not implemented")
+}
+func (UnimplementedEncryptionServiceServer) ConfigureThresholdVault(context.Context, *ConfigureThresholdVaultRequest) (*ConfigureThresholdVaultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfigureThresholdVault not implemented")
+}
+func (UnimplementedEncryptionServiceServer) SealToVault(context.Context, *SealToVaultRequest) (*SealToVaultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SealToVault not implemented")
+}
+func (UnimplementedEncryptionServiceServer) UnlockVault(context.Context, *UnlockVaultRequest) (*UnlockVaultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockVault not implemented")
+}
+func (UnimplementedEncryptionServiceServer) OpenVaultForRecipient(context.Context, *OpenVaultForRecipientRequest) (*OpenVaultForRecipientResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenVaultForRecipient not implemented")
+}
+func (UnimplementedEncryptionServiceServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedEncryptionServiceServer) EscrowRecoveryWrap(context.Context, *EscrowRecoveryWrapRequest) (*EscrowRecoveryWrapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EscrowRecoveryWrap not implemented")
+}
+func (UnimplementedEncryptionServiceServer) GetRecoveryWrap(context.Context, *GetRecoveryWrapRequest) (*GetRecoveryWrapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecoveryWrap not implemented")
+}
 func (UnimplementedEncryptionServiceServer) mustEmbedUnimplementedEncryptionServiceServer() {}
 
 // UnsafeEncryptionServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -125,6 +359,222 @@ func _EncryptionService_Decrypt_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EncryptionService_RotateUserKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateUserKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).RotateUserKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_RotateUserKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).RotateUserKey(ctx, req.(*RotateUserKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_Reencrypt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReencryptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).Reencrypt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_Reencrypt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).Reencrypt(ctx, req.(*ReencryptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_BatchEncrypt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchEncryptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).BatchEncrypt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_BatchEncrypt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).BatchEncrypt(ctx, req.(*BatchEncryptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_BatchDecrypt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDecryptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).BatchDecrypt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_BatchDecrypt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).BatchDecrypt(ctx, req.(*BatchDecryptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_ReencryptForRecipient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReencryptForRecipientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).ReencryptForRecipient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_ReencryptForRecipient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).ReencryptForRecipient(ctx, req.(*ReencryptForRecipientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_ConfigureThresholdVault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigureThresholdVaultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).ConfigureThresholdVault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_ConfigureThresholdVault_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).ConfigureThresholdVault(ctx, req.(*ConfigureThresholdVaultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_SealToVault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SealToVaultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).SealToVault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_SealToVault_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).SealToVault(ctx, req.(*SealToVaultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_UnlockVault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockVaultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).UnlockVault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_UnlockVault_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).UnlockVault(ctx, req.(*UnlockVaultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_OpenVaultForRecipient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenVaultForRecipientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).OpenVaultForRecipient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_OpenVaultForRecipient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).OpenVaultForRecipient(ctx, req.(*OpenVaultForRecipientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_ListAuditEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_EscrowRecoveryWrap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EscrowRecoveryWrapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).EscrowRecoveryWrap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_EscrowRecoveryWrap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).EscrowRecoveryWrap(ctx, req.(*EscrowRecoveryWrapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EncryptionService_GetRecoveryWrap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecoveryWrapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncryptionServiceServer).GetRecoveryWrap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EncryptionService_GetRecoveryWrap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncryptionServiceServer).GetRecoveryWrap(ctx, req.(*GetRecoveryWrapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EncryptionService_ServiceDesc is the grpc.ServiceDesc for EncryptionService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -140,6 +590,54 @@ var EncryptionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Decrypt",
 			Handler:    _EncryptionService_Decrypt_Handler,
 		},
+		{
+			MethodName: "RotateUserKey",
+			Handler:    _EncryptionService_RotateUserKey_Handler,
+		},
+		{
+			MethodName: "Reencrypt",
+			Handler:    _EncryptionService_Reencrypt_Handler,
+		},
+		{
+			MethodName: "BatchEncrypt",
+			Handler:    _EncryptionService_BatchEncrypt_Handler,
+		},
+		{
+			MethodName: "BatchDecrypt",
+			Handler:    _EncryptionService_BatchDecrypt_Handler,
+		},
+		{
+			MethodName: "ReencryptForRecipient",
+			Handler:    _EncryptionService_ReencryptForRecipient_Handler,
+		},
+		{
+			MethodName: "ConfigureThresholdVault",
+			Handler:    _EncryptionService_ConfigureThresholdVault_Handler,
+		},
+		{
+			MethodName: "SealToVault",
+			Handler:    _EncryptionService_SealToVault_Handler,
+		},
+		{
+			MethodName: "UnlockVault",
+			Handler:    _EncryptionService_UnlockVault_Handler,
+		},
+		{
+			MethodName: "OpenVaultForRecipient",
+			Handler:    _EncryptionService_OpenVaultForRecipient_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _EncryptionService_ListAuditEvents_Handler,
+		},
+		{
+			MethodName: "EscrowRecoveryWrap",
+			Handler:    _EncryptionService_EscrowRecoveryWrap_Handler,
+		},
+		{
+			MethodName: "GetRecoveryWrap",
+			Handler:    _EncryptionService_GetRecoveryWrap_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "comm.proto",